@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"mcp-router/internal/shim"
+)
+
+// Modo genérico (--exec <programa> [-- args...]): roda qualquer comando local com o
+// mesmo piping de stdio e shutdown gracioso do modo WSL, sem wsl.exe/bash no meio.
+// Útil no Linux/macOS como ponte stdio supervisionada:
+//
+//	mcp-gw-shim-proc --exec ./mcp-gw --cwd /srv/mcp -- stdio --config ./config.yaml
+
+// buildCommand monta o processo filho: o programa de --exec ou wsl.exe (--cmd). Ao
+// cancelar ctx, o filho recebe SIGTERM (encerramento gracioso); onde não há sinais
+// (Windows), é morto direto. run dá --shutdown-grace antes do kill.
+func buildCommand(ctx context.Context, cfg config) *exec.Cmd {
+	var cmd *exec.Cmd
+	if cfg.Exec != "" {
+		cmd = exec.CommandContext(ctx, cfg.Exec, cfg.Args...)
+		cmd.Dir = cfg.Cwd
+	} else {
+		cmd = exec.CommandContext(ctx, "wsl.exe", buildWslArgs(cfg, buildBashScript(cfg))...)
+	}
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	return cmd
+}
+
+// program é o nome do filho nos logs.
+func (c config) program() string {
+	if c.Exec != "" {
+		return c.Exec
+	}
+	return "wsl.exe"
+}
+
+// probeExec é o probe do modo --exec: cwd, programa e --config (se houver nos args)
+// verificados localmente, mais o --probe-healthz opcional.
+func probeExec(ctx context.Context, cfg config, log *slog.Logger) error {
+	start := time.Now()
+
+	if cfg.Cwd != "" {
+		if st, err := os.Stat(cfg.Cwd); err != nil || !st.IsDir() {
+			return fmt.Errorf("probe: working directory %q not found (check --cwd)", cfg.Cwd)
+		}
+	}
+
+	bin := cfg.Exec
+	if strings.ContainsRune(bin, filepath.Separator) || strings.Contains(bin, "/") {
+		bin = resolveInCwd(cfg.Cwd, bin)
+		st, err := os.Stat(bin)
+		if err != nil || st.IsDir() || (runtime.GOOS != "windows" && st.Mode()&0o111 == 0) {
+			return fmt.Errorf("probe: program %q not found or not executable (check --exec/--cwd)", cfg.Exec)
+		}
+	} else if _, err := exec.LookPath(bin); err != nil {
+		return fmt.Errorf("probe: program %q not found in PATH (check --exec)", cfg.Exec)
+	}
+
+	if cfgFile := configArg(cfg.Args); cfgFile != "" {
+		f, err := os.Open(resolveInCwd(cfg.Cwd, cfgFile))
+		if err != nil {
+			return fmt.Errorf("probe: config file %q not readable (check --config in the program args)", cfgFile)
+		}
+		_ = f.Close()
+	}
+
+	if cfg.ProbeHealthz != "" {
+		pctx, cancel := context.WithTimeout(ctx, cfg.ProbeTimeout)
+		defer cancel()
+		if err := probeHealthz(pctx, cfg.ProbeHealthz); err != nil {
+			return err
+		}
+	}
+
+	log.Info("probe ok",
+		slog.String("binary", bin),
+		slog.String("config", configArg(cfg.Args)),
+		slog.String("healthz", cfg.ProbeHealthz),
+		shim.DurationMs(time.Since(start).Milliseconds()),
+	)
+	return nil
+}
+
+func resolveInCwd(cwd, p string) string {
+	if cwd == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(cwd, p)
+}
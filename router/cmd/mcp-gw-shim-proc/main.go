@@ -18,6 +18,10 @@ import (
 )
 
 type config struct {
+	// Modo genérico: programa local + args (posicionais); vazio = modo WSL (--cmd)
+	Exec string
+	Args []string
+
 	Distro        string
 	User          string
 	Cwd           string
@@ -25,6 +29,20 @@ type config struct {
 	ExtraWslArgs  string
 	ShutdownGrace time.Duration
 	Debug         bool
+
+	// Restart do filho (ver restart.go)
+	Restart     string
+	MaxRestarts int
+	Backoff     time.Duration
+
+	// Probe (pré-voo) antes de ligar o stdio
+	Probe        bool
+	ProbeHealthz string
+	ProbeTimeout time.Duration
+
+	// Sidecar de status (GET /healthz); vazio = desligado
+	StatusAddr string
+	status     *shim.Status
 }
 
 func main() {
@@ -47,6 +65,14 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	if cfg.StatusAddr != "" {
+		cfg.status = shim.NewStatus("shim-proc", true)
+		if err := cfg.status.Serve(ctx, cfg.StatusAddr, logger); err != nil {
+			logger.Error("status listener failed", shim.Err(err))
+			os.Exit(1)
+		}
+	}
+
 	code := run(ctx, cfg, logger)
 	os.Exit(code)
 }
@@ -56,38 +82,89 @@ func parseFlags(args []string) (config, error) {
 	fs := flag.NewFlagSet("mcp-gw-shim-proc", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 
+	fs.StringVar(&cfg.Exec, "exec", "", "Programa local a executar no lugar do WSL; os args vêm depois de --. Ex: --exec ./mcp-gw -- stdio --config ./config.yaml")
 	fs.StringVar(&cfg.Distro, "distro", "", "Nome da distro WSL (ex: Ubuntu-22.04). Opcional.")
 	fs.StringVar(&cfg.User, "user", "", "Usuário no WSL (opcional).")
-	fs.StringVar(&cfg.Cwd, "cwd", "", "Diretório de trabalho no WSL (opcional).")
-	fs.StringVar(&cfg.Command, "cmd", "", "Comando a executar no WSL (obrigatório). Ex: ./mcp-gw --config ./config.yaml")
+	fs.StringVar(&cfg.Cwd, "cwd", "", "Diretório de trabalho (no WSL, ou local com --exec). Opcional.")
+	fs.StringVar(&cfg.Command, "cmd", "", "Comando a executar no WSL (obrigatório sem --exec). Ex: ./mcp-gw --config ./config.yaml")
 	fs.StringVar(&cfg.ExtraWslArgs, "wsl-args", "", "Args extras para o wsl.exe (opcional). Ex: \"--exec\"")
 	fs.DurationVar(&cfg.ShutdownGrace, "shutdown-grace", 1500*time.Millisecond, "Janela para shutdown gracioso.")
+	fs.StringVar(&cfg.Restart, "restart", restartNo, "Política de restart do filho: no | on-failure (sai com erro sem o stdin ter fechado).")
+	fs.IntVar(&cfg.MaxRestarts, "max-restarts", 5, "Restarts seguidos antes de desistir (0 = sem limite). Zera quando o filho fica de pé por 1min.")
+	fs.DurationVar(&cfg.Backoff, "backoff", time.Second, "Espera antes do primeiro restart (dobra a cada restart seguido, com jitter; teto de 30s).")
 	fs.BoolVar(&cfg.Debug, "debug", false, "Habilita debug no stderr (override de SHIM_LOG_LEVEL).")
+	fs.BoolVar(&cfg.Probe, "probe", false, "Verifica binário/config do gateway (no WSL, ou local com --exec) antes de ligar o stdio.")
+	fs.StringVar(&cfg.ProbeHealthz, "probe-healthz", "", "URL /healthz do gateway para checar no probe (modo HTTP, opcional). Ex: http://localhost:8080/healthz")
+	fs.DurationVar(&cfg.ProbeTimeout, "probe-timeout", 10*time.Second, "Timeout total do probe.")
+	fs.StringVar(&cfg.StatusAddr, "status-addr", "", "Sobe GET /healthz (pid do filho, uptime, bytes, restarts, último erro) neste endereço. Ex: 127.0.0.1:0")
 
 	if err := fs.Parse(args); err != nil {
 		return cfg, fmt.Errorf("failed to parse flags: %w", err)
 	}
-	if strings.TrimSpace(cfg.Command) == "" {
-		return cfg, errors.New("missing --cmd")
+	cfg.Args = fs.Args()
+	if cfg.Exec != "" {
+		if cfg.Command != "" || cfg.Distro != "" || cfg.User != "" || cfg.ExtraWslArgs != "" {
+			return cfg, errors.New("--exec cannot be combined with --cmd/--distro/--user/--wsl-args (WSL mode)")
+		}
+	} else {
+		if strings.TrimSpace(cfg.Command) == "" {
+			return cfg, errors.New("missing --cmd (or --exec)")
+		}
+		if len(cfg.Args) > 0 {
+			return cfg, fmt.Errorf("unexpected arguments %q (program args are only used with --exec)", cfg.Args)
+		}
+	}
+	if cfg.ProbeHealthz != "" {
+		cfg.Probe = true
+	}
+	if cfg.ProbeTimeout <= 0 {
+		return cfg, errors.New("--probe-timeout must be > 0")
+	}
+	if cfg.Restart != restartNo && cfg.Restart != restartOnFailure {
+		return cfg, fmt.Errorf("--restart must be %s or %s", restartNo, restartOnFailure)
+	}
+	if cfg.MaxRestarts < 0 || cfg.Backoff <= 0 {
+		return cfg, errors.New("--max-restarts must be >= 0 and --backoff > 0")
 	}
 	return cfg, nil
 }
 
 func run(ctx context.Context, cfg config, log *slog.Logger) int {
-	start := time.Now()
+	if cfg.Probe {
+		if err := probe(ctx, cfg, log); err != nil {
+			log.Error("probe failed", shim.Err(err))
+			return 1
+		}
+	}
 
-	bashScript := buildBashScript(cfg)
+	// stdin é lido uma vez só: com --restart, o próximo filho recebe a continuação
+	in := newStdinPump(cfg.status.In(os.Stdin))
+	if cfg.Restart == restartNo {
+		return runChild(ctx, cfg, log, in)
+	}
+	return superviseChild(ctx, cfg, log, in)
+}
 
-	wslArgs := buildWslArgs(cfg, bashScript)
+// runChild roda o filho uma vez, com o stdio ligado ao do shim, e retorna o exit code.
+func runChild(ctx context.Context, cfg config, log *slog.Logger, in *stdinPump) int {
+	start := time.Now()
 
-	log.Info("starting",
-		slog.String("distro", cfg.Distro),
-		slog.String("user", cfg.User),
-		slog.String("cwd", cfg.Cwd),
-		slog.String("wsl_args", strings.Join(wslArgs, " ")),
-	)
+	cmd := buildCommand(ctx, cfg)
 
-	cmd := exec.CommandContext(ctx, "wsl.exe", wslArgs...)
+	if cfg.Exec != "" {
+		log.Info("starting",
+			slog.String("exec", cfg.Exec),
+			slog.String("args", strings.Join(cfg.Args, " ")),
+			slog.String("cwd", cfg.Cwd),
+		)
+	} else {
+		log.Info("starting",
+			slog.String("distro", cfg.Distro),
+			slog.String("user", cfg.User),
+			slog.String("cwd", cfg.Cwd),
+			slog.String("wsl_args", strings.Join(cmd.Args[1:], " ")),
+		)
+	}
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -106,21 +183,28 @@ func run(ctx context.Context, cfg config, log *slog.Logger) int {
 	}
 
 	if err := cmd.Start(); err != nil {
-		log.Error("failed to start wsl.exe", shim.Err(err))
+		log.Error("failed to start "+cfg.program(), shim.Err(err))
+		cfg.status.SetError(err)
 		return 1
 	}
+	cfg.status.ChildStarted(cmd.Process.Pid)
 
 	// Piping (stdout deve permanecer "limpo")
+	exited := make(chan struct{})
 	copyInDone := make(chan struct{})
 	go func() {
-		_, _ = io.Copy(stdin, os.Stdin)
-		_ = stdin.Close()
+		in.feed(stdin, exited)
 		close(copyInDone)
 	}()
+	// o feed precisa terminar antes do próximo filho (restart) assumir o stdin
+	defer func() {
+		close(exited)
+		<-copyInDone
+	}()
 
 	copyOutDone := make(chan struct{})
 	go func() {
-		_, _ = io.Copy(os.Stdout, stdout)
+		_, _ = io.Copy(cfg.status.Out(os.Stdout), stdout)
 		close(copyOutDone)
 	}()
 
@@ -131,7 +215,11 @@ func run(ctx context.Context, cfg config, log *slog.Logger) int {
 	}()
 
 	waitErr := make(chan error, 1)
-	go func() { waitErr <- cmd.Wait() }()
+	go func() {
+		err := cmd.Wait()
+		cfg.status.ChildExited(err)
+		waitErr <- err
+	}()
 
 	select {
 	case <-ctx.Done():
@@ -147,7 +235,7 @@ func run(ctx context.Context, cfg config, log *slog.Logger) int {
 			)
 			return code
 		case <-time.After(cfg.ShutdownGrace):
-			log.Warn("force killing wsl.exe",
+			log.Warn("force killing "+cfg.program(),
 				slog.Int64("grace_ms", cfg.ShutdownGrace.Milliseconds()),
 			)
 			_ = cmd.Process.Kill()
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"mcp-router/internal/shim"
+)
+
+// Exit codes do script de probe (dentro do WSL).
+// Cada código mapeia para uma mensagem acionável no lado Windows.
+const (
+	probeExitCwd    = 10
+	probeExitBinary = 11
+	probeExitConfig = 12
+)
+
+// probe verifica, antes de ligar o stdio, que o ambiente WSL está utilizável (modo
+// --exec: ver probeExec):
+// - cwd existe (se informado)
+// - binário do gateway existe e é executável
+// - config existe e é legível (se --config presente no --cmd)
+// - opcional: GET em --probe-healthz responde 2xx (modo HTTP)
+//
+// Falha rápido com erro acionável em vez de pipear para um ambiente quebrado.
+func probe(ctx context.Context, cfg config, log *slog.Logger) error {
+	if cfg.Exec != "" {
+		return probeExec(ctx, cfg, log)
+	}
+	start := time.Now()
+
+	pctx, cancel := context.WithTimeout(ctx, cfg.ProbeTimeout)
+	defer cancel()
+
+	bin, cfgFile := probeTargets(cfg.Command)
+
+	script := buildProbeScript(cfg.Cwd, bin, cfgFile)
+	args := buildWslArgs(cfg, script)
+
+	log.Debug("probe starting",
+		slog.String("binary", bin),
+		slog.String("config", cfgFile),
+		slog.String("wsl_args", strings.Join(args, " ")),
+	)
+
+	cmd := exec.CommandContext(pctx, "wsl.exe", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if pctx.Err() != nil {
+			return fmt.Errorf("probe timed out after %s (is the WSL distro %q running?)", cfg.ProbeTimeout, cfg.Distro)
+		}
+		detail := strings.TrimSpace(string(out))
+
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) {
+			return fmt.Errorf("probe failed to start wsl.exe: %w (is WSL installed?)", err)
+		}
+
+		switch ee.ExitCode() {
+		case probeExitCwd:
+			return fmt.Errorf("probe: working directory %q not found in WSL (check --cwd)", cfg.Cwd)
+		case probeExitBinary:
+			return fmt.Errorf("probe: gateway binary %q not found or not executable in WSL (check --cmd/--cwd)", bin)
+		case probeExitConfig:
+			return fmt.Errorf("probe: config file %q not readable in WSL (check --config inside --cmd)", cfgFile)
+		default:
+			return fmt.Errorf("probe: wsl.exe exited with code %d: %s (check --distro/--user)", ee.ExitCode(), detail)
+		}
+	}
+
+	if cfg.ProbeHealthz != "" {
+		if err := probeHealthz(pctx, cfg.ProbeHealthz); err != nil {
+			return err
+		}
+	}
+
+	log.Info("probe ok",
+		slog.String("binary", bin),
+		slog.String("config", cfgFile),
+		slog.String("healthz", cfg.ProbeHealthz),
+		shim.DurationMs(time.Since(start).Milliseconds()),
+	)
+	return nil
+}
+
+// probeTargets extrai (best effort) o binário e o --config do comando.
+// Ex: "./mcp-gw --config ./config.yaml http --addr :8080" -> ("./mcp-gw", "./config.yaml")
+func probeTargets(command string) (bin, cfgFile string) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	return fields[0], configArg(fields[1:])
+}
+
+// configArg acha o valor de --config (ou --config=) numa lista de args.
+func configArg(args []string) string {
+	for i, f := range args {
+		if v, ok := strings.CutPrefix(f, "--config="); ok {
+			return v
+		}
+		if f == "--config" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func buildProbeScript(cwd, bin, cfgFile string) string {
+	var sb strings.Builder
+	if cwd != "" {
+		fmt.Fprintf(&sb, "cd %s 2>/dev/null || exit %d; ", shellQuote(cwd), probeExitCwd)
+	}
+	fmt.Fprintf(&sb, "command -v %s >/dev/null 2>&1 || exit %d; ", shellQuote(bin), probeExitBinary)
+	if cfgFile != "" {
+		fmt.Fprintf(&sb, "test -r %s || exit %d; ", shellQuote(cfgFile), probeExitConfig)
+	}
+	sb.WriteString("exit 0")
+	return sb.String()
+}
+
+func probeHealthz(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("probe: invalid --probe-healthz url %q: %w", url, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("probe: gateway healthz %s unreachable: %w (is `mcp-gw http` running?)", url, err)
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("probe: gateway healthz %s returned %s", url, resp.Status)
+	}
+	return nil
+}
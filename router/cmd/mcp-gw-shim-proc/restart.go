@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"mcp-router/internal/shim"
+)
+
+// Restart automático (--restart on-failure): se o filho (wsl.exe ou --exec) sai com erro
+// enquanto o cliente MCP ainda está conectado (stdin aberto), o shim sobe outro e
+// continua o piping; o cliente vê um servidor novo em vez de perder o servidor.
+// Cada restart gera um log estruturado (event=restart). As esperas seguem backoff
+// exponencial com jitter; --max-restarts conta restarts seguidos, e um filho que fica
+// de pé por restartResetAfter zera a contagem.
+
+const (
+	restartNo        = "no"
+	restartOnFailure = "on-failure"
+
+	restartResetAfter = time.Minute
+	maxRestartBackoff = 30 * time.Second
+)
+
+func superviseChild(ctx context.Context, cfg config, log *slog.Logger, in *stdinPump) int {
+	backoff := shim.Backoff{Initial: cfg.Backoff, Max: max(maxRestartBackoff, cfg.Backoff)}
+	restarts := 0
+	for {
+		start := time.Now()
+		code := runChild(ctx, cfg, log, in)
+		if code == 0 || ctx.Err() != nil || in.closed() {
+			return code
+		}
+
+		uptime := time.Since(start)
+		if uptime >= restartResetAfter {
+			restarts = 0
+		}
+		restarts++
+		if cfg.MaxRestarts > 0 && restarts > cfg.MaxRestarts {
+			log.Error("child keeps failing; giving up",
+				slog.Int("max_restarts", cfg.MaxRestarts),
+				slog.Int("exit_code", code),
+			)
+			return code
+		}
+
+		cfg.status.Restarted()
+		wait := backoff.Delay(restarts)
+		log.Warn("restarting child",
+			slog.String("event", "restart"),
+			slog.Int("restart", restarts),
+			slog.Int("exit_code", code),
+			slog.Int64("uptime_ms", uptime.Milliseconds()),
+			slog.Int64("backoff_ms", wait.Milliseconds()),
+		)
+		if shim.Sleep(ctx, wait) != nil {
+			return code
+		}
+	}
+}
+
+// stdinPump lê o stdin do shim uma vez só e entrega ao filho da vez. Um pedaço que não
+// chegou a um filho que morreu vai para o próximo.
+type stdinPump struct {
+	ch      chan []byte   // fechado no EOF do stdin
+	eof     chan struct{} // fechado no EOF do stdin
+	pending []byte        // só acessado pelo feed da vez
+}
+
+func newStdinPump(r io.Reader) *stdinPump {
+	p := &stdinPump{ch: make(chan []byte), eof: make(chan struct{})}
+	go func() {
+		defer close(p.ch)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				p.ch <- bytes.Clone(buf[:n])
+			}
+			if err != nil {
+				close(p.eof)
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// closed diz se o stdin do shim chegou ao fim (o cliente MCP foi embora).
+func (p *stdinPump) closed() bool {
+	select {
+	case <-p.eof:
+		return true
+	default:
+		return false
+	}
+}
+
+// feed copia o stdin para w até o EOF (fecha w) ou até o filho sair (exited).
+func (p *stdinPump) feed(w io.WriteCloser, exited <-chan struct{}) {
+	for {
+		b := p.pending
+		p.pending = nil
+		if b == nil {
+			select {
+			case chunk, ok := <-p.ch:
+				if !ok {
+					_ = w.Close()
+					return
+				}
+				b = chunk
+			case <-exited:
+				return
+			}
+		}
+		if _, err := w.Write(b); err != nil {
+			p.pending = b
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Autenticação no gateway: headers extras em todas as requests do shim (POST da tool,
+// resume, sessões). Segredos vêm de variáveis de ambiente para não aparecerem no ps.
+//
+//	--header 'K: V'                    repetível
+//	--bearer-token-env VAR             Authorization: Bearer $VAR
+//	--cf-access-client-id-env VAR      service token do Cloudflare Access
+//	--cf-access-client-secret-env VAR  (os dois juntos)
+
+const (
+	cfAccessClientIDHeader     = "CF-Access-Client-Id"
+	cfAccessClientSecretHeader = "CF-Access-Client-Secret"
+)
+
+// headerFlag acumula --header repetido.
+type headerFlag []string
+
+func (h *headerFlag) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerFlag) Set(v string) error {
+	k, _, ok := strings.Cut(v, ":")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" || strings.ContainsAny(k, " \t\r\n") || strings.ContainsAny(v, "\r\n") {
+		return fmt.Errorf("expected 'Name: value', got %q", v)
+	}
+	*h = append(*h, v)
+	return nil
+}
+
+// authHeaders monta os headers de --header, --bearer-token-env e --cf-access-*.
+func authHeaders(cfg config) (http.Header, error) {
+	h := http.Header{}
+	for _, kv := range cfg.Headers {
+		k, v, _ := strings.Cut(kv, ":")
+		h.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+
+	if cfg.BearerTokenEnv != "" {
+		tok, err := secretFromEnv("--bearer-token-env", cfg.BearerTokenEnv)
+		if err != nil {
+			return nil, err
+		}
+		h.Set("Authorization", "Bearer "+tok)
+	}
+
+	if (cfg.CFAccessClientIDEnv == "") != (cfg.CFAccessClientSecretEnv == "") {
+		return nil, fmt.Errorf("--cf-access-client-id-env and --cf-access-client-secret-env must be used together")
+	}
+	if cfg.CFAccessClientIDEnv != "" {
+		id, err := secretFromEnv("--cf-access-client-id-env", cfg.CFAccessClientIDEnv)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := secretFromEnv("--cf-access-client-secret-env", cfg.CFAccessClientSecretEnv)
+		if err != nil {
+			return nil, err
+		}
+		h.Set(cfAccessClientIDHeader, id)
+		h.Set(cfAccessClientSecretHeader, secret)
+	}
+	return h, nil
+}
+
+func secretFromEnv(flagName, env string) (string, error) {
+	v := strings.TrimSpace(os.Getenv(env))
+	if v == "" {
+		return "", fmt.Errorf("%s: environment variable %s is empty or unset", flagName, env)
+	}
+	return v, nil
+}
+
+// headerTransport aplica os headers de autenticação em cada request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, vs := range t.headers {
+		req.Header[k] = vs
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newClient é o cliente HTTP do shim (timeout, proxy/TLS e headers de autenticação).
+func newClient(cfg config) *http.Client {
+	var rt http.RoundTripper = cfg.transport
+	if len(cfg.authHeader) > 0 {
+		rt = &headerTransport{base: rt, headers: cfg.authHeader}
+	}
+	return &http.Client{Timeout: cfg.Timeout, Transport: rt}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-router/internal/shim"
+)
+
+// Failover entre endpoints (--endpoint repetido ou lista com vírgula), ex: gateway local
+// primeiro e um remoto de reserva. Cada chamada tenta os endpoints em ordem; erro de
+// conexão ou 5xx antes do stream começar passa para o próximo. Um endpoint que falhou
+// fica em cooldown (--endpoint-cooldown) e vai para o fim da fila; passado o cooldown,
+// o primário volta a ser o primeiro. Com mais de um endpoint, a memória de saúde fica
+// num arquivo no cache do usuário e vale entre execuções do shim (best effort).
+//
+// Depois que um endpoint atende, a chamada fica presa a ele: resume e sessão são
+// estado daquele gateway.
+
+// endpointFlag acumula --endpoint repetido e listas separadas por vírgula.
+type endpointFlag []string
+
+func (e *endpointFlag) String() string { return strings.Join(*e, ",") }
+
+func (e *endpointFlag) Set(v string) error {
+	for _, ep := range strings.Split(v, ",") {
+		if ep = strings.TrimSpace(ep); ep != "" {
+			*e = append(*e, ep)
+		}
+	}
+	return nil
+}
+
+// endpointSet é a lista de endpoints (primário primeiro) com memória de saúde.
+type endpointSet struct {
+	urls     []string
+	cooldown time.Duration
+	state    string // arquivo da memória de saúde (vazio = só em memória)
+
+	mu        sync.Mutex
+	downUntil map[string]time.Time
+}
+
+func newEndpointSet(urls []string, cooldown time.Duration) *endpointSet {
+	s := &endpointSet{urls: urls, cooldown: cooldown, downUntil: make(map[string]time.Time)}
+	if len(urls) > 1 {
+		if dir, err := os.UserCacheDir(); err == nil {
+			s.state = filepath.Join(dir, "mcp-gw-shim", "endpoints.json")
+			s.load()
+		}
+	}
+	return s
+}
+
+// order é a ordem de tentativa: saudáveis na ordem configurada, depois os em cooldown.
+func (s *endpointSet) order() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	out := make([]string, 0, len(s.urls))
+	var down []string
+	for _, u := range s.urls {
+		if now.Before(s.downUntil[u]) {
+			down = append(down, u)
+			continue
+		}
+		out = append(out, u)
+	}
+	return append(out, down...)
+}
+
+func (s *endpointSet) markDown(u string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.downUntil[u] = time.Now().Add(s.cooldown)
+	s.saveLocked()
+}
+
+func (s *endpointSet) markUp(u string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.downUntil[u]; !ok {
+		return
+	}
+	delete(s.downUntil, u)
+	s.saveLocked()
+}
+
+func (s *endpointSet) load() {
+	data, err := os.ReadFile(s.state)
+	if err != nil {
+		return
+	}
+	var m map[string]time.Time
+	if json.Unmarshal(data, &m) != nil {
+		return
+	}
+	for _, u := range s.urls {
+		if t, ok := m[u]; ok {
+			s.downUntil[u] = t
+		}
+	}
+}
+
+// saveLocked grava a memória de saúde (tmp + rename: outros shims podem estar lendo).
+func (s *endpointSet) saveLocked() {
+	if s.state == "" {
+		return
+	}
+	m := make(map[string]time.Time)
+	if data, err := os.ReadFile(s.state); err == nil {
+		_ = json.Unmarshal(data, &m)
+	}
+	now := time.Now()
+	for u, t := range m {
+		if !now.Before(t) {
+			delete(m, u)
+		}
+	}
+	for _, u := range s.urls {
+		if t, ok := s.downUntil[u]; ok {
+			m[u] = t
+		} else {
+			delete(m, u)
+		}
+	}
+	data, _ := json.Marshal(m)
+	if err := os.MkdirAll(filepath.Dir(s.state), 0o700); err != nil {
+		return
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", s.state, os.Getpid())
+	if os.WriteFile(tmp, data, 0o600) != nil {
+		return
+	}
+	_ = os.Rename(tmp, s.state)
+}
+
+// try chama fn em cada endpoint até um deles atender; retorna o que atendeu. Sem
+// failover possível, retorna o erro do último.
+func (s *endpointSet) try(ctx context.Context, log *slog.Logger, fn func(endpoint string) error) (string, error) {
+	var err error
+	eps := s.order()
+	for i, ep := range eps {
+		err = fn(ep)
+		if ctx.Err() != nil {
+			return ep, err
+		}
+		if !failoverable(err) {
+			s.markUp(ep)
+			return ep, err
+		}
+		s.markDown(ep)
+		if i+1 < len(eps) {
+			log.Warn("endpoint failed; trying next",
+				slog.String("failed", ep),
+				slog.String("next", eps[i+1]),
+				shim.Err(err),
+			)
+		}
+	}
+	return "", err
+}
+
+// post faz o POST da tool com failover entre os endpoints; rp (opcional, --reconnect)
+// registra o endpoint que atendeu.
+func post(ctx context.Context, cfg config, client *http.Client, body []byte, rid string, start time.Time, log *slog.Logger, rp *resumePoint) error {
+	_, err := cfg.endpoints.try(ctx, log, func(ep string) error {
+		if rp != nil {
+			rp.endpoint = ep
+		}
+		req, err := newPost(ctx, ep, bytes.NewReader(body), rid)
+		if err != nil {
+			return err
+		}
+		return stream(ctx, client, req, start, log, rp)
+	})
+	return err
+}
+
+// failoverable: o endpoint não atendeu (sem resposta) ou respondeu 5xx.
+func failoverable(err error) bool {
+	var ce *connError
+	if errors.As(err, &ce) {
+		return ce.noResponse
+	}
+	var ue *upstreamError
+	return errors.As(err, &ue) && ue.status >= http.StatusInternalServerError
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -19,12 +20,42 @@ import (
 )
 
 type config struct {
-	Endpoint  string
-	Timeout   time.Duration
-	Debug     bool
-	RequestID string
+	Endpoints        endpointFlag
+	EndpointCooldown time.Duration
+	Timeout          time.Duration
+	Debug            bool
+	RequestID        string
+	Session          bool
+	MCP              bool
+
+	Reconnect            bool
+	ReconnectMaxAttempts int
+	ReconnectBackoff     time.Duration
+	ReconnectMaxBackoff  time.Duration
+
+	Headers                 headerFlag
+	BearerTokenEnv          string
+	CFAccessClientIDEnv     string
+	CFAccessClientSecretEnv string
+
+	Proxy              string
+	CACert             string
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+
+	authHeader http.Header     // resolvido de Headers/*Env (ver auth.go)
+	transport  *http.Transport // proxy/TLS (ver tls.go)
+	endpoints  *endpointSet    // ordem de failover + memória de saúde (ver failover.go)
+
+	// Sidecar de status (GET /healthz); vazio = desligado
+	StatusAddr string
+	status     *shim.Status
 }
 
+// stdout é o destino da resposta (contado pelo sidecar de status, se ligado).
+var stdout io.Writer = os.Stdout
+
 func main() {
 	cfg := parseFlags()
 
@@ -42,10 +73,14 @@ func main() {
 		Level:     level,
 		Component: "shim-xport",
 	}).With(
-		slog.String("endpoint", cfg.Endpoint),
+		slog.String("endpoint", cfg.Endpoints.String()),
 		shim.RequestID(rid),
 	)
 
+	if cfg.InsecureSkipVerify {
+		logger.Warn("TLS certificate verification is DISABLED (--insecure-skip-verify): anyone on the path can intercept or alter gateway traffic")
+	}
+
 	ctx, cancel := signal.NotifyContext(
 		context.Background(),
 		os.Interrupt,
@@ -53,7 +88,23 @@ func main() {
 	)
 	defer cancel()
 
-	if err := run(ctx, cfg, rid, logger); err != nil {
+	if cfg.StatusAddr != "" {
+		cfg.status = shim.NewStatus("shim-xport", false)
+		if err := cfg.status.Serve(ctx, cfg.StatusAddr, logger); err != nil {
+			logger.Error("status listener failed", shim.Err(err))
+			os.Exit(1)
+		}
+		stdout = cfg.status.Out(os.Stdout)
+	}
+
+	runFn := run
+	switch {
+	case cfg.Session:
+		runFn = runSession
+	case cfg.MCP:
+		runFn = runMCP
+	}
+	if err := runFn(ctx, cfg, rid, logger); err != nil {
 		logger.Error("fatal", shim.Err(err))
 		os.Exit(1)
 	}
@@ -61,16 +112,64 @@ func main() {
 
 func parseFlags() config {
 	var cfg config
-	flag.StringVar(&cfg.Endpoint, "endpoint", "", "HTTP endpoint MCP (ex: http://localhost:8080/mcp/echo)")
+	flag.Var(&cfg.Endpoints, "endpoint", "HTTP endpoint MCP (ex: http://localhost:8080/mcp/echo ou, no Windows, npipe://./pipe/mcp-gw/mcp/echo); repetível ou lista com vírgula: failover em ordem")
+	flag.DurationVar(&cfg.EndpointCooldown, "endpoint-cooldown", 30*time.Second, "Tempo que um endpoint que falhou vai para o fim da fila de failover")
 	flag.DurationVar(&cfg.Timeout, "timeout", 0, "Timeout HTTP (0 = sem timeout)")
 	flag.BoolVar(&cfg.Debug, "debug", false, "Habilita debug (override de SHIM_LOG_LEVEL)")
 	flag.StringVar(&cfg.RequestID, "request-id", "", "Request ID para correlação (opcional; se vazio, gera)")
+	flag.BoolVar(&cfg.Session, "session", false, "Modo sessão: abre uma sessão (tool daemon) e envia cada linha do stdin como uma mensagem dela")
+	flag.BoolVar(&cfg.MCP, "mcp", false, "Modo MCP: servidor MCP stdio completo (initialize local, tools/list e tools/call no gateway); --endpoint é a base do gateway")
+	flag.BoolVar(&cfg.Reconnect, "reconnect", false, "Reconecta com backoff se a conexão cair (retoma o stream com Last-Event-ID quando o gateway tem resume)")
+	flag.IntVar(&cfg.ReconnectMaxAttempts, "reconnect-max-attempts", 5, "Tentativas seguidas de reconexão antes de desistir (0 = sem limite)")
+	flag.DurationVar(&cfg.ReconnectBackoff, "reconnect-backoff", 500*time.Millisecond, "Espera antes da primeira reconexão (dobra a cada tentativa, com jitter)")
+	flag.DurationVar(&cfg.ReconnectMaxBackoff, "reconnect-max-backoff", 30*time.Second, "Teto da espera entre reconexões")
+	flag.Var(&cfg.Headers, "header", "Header extra em todas as requests, 'Nome: valor' (repetível)")
+	flag.StringVar(&cfg.BearerTokenEnv, "bearer-token-env", "", "Variável de ambiente com o token enviado como Authorization: Bearer")
+	flag.StringVar(&cfg.CFAccessClientIDEnv, "cf-access-client-id-env", "", "Variável de ambiente com o Client ID do service token do Cloudflare Access")
+	flag.StringVar(&cfg.CFAccessClientSecretEnv, "cf-access-client-secret-env", "", "Variável de ambiente com o Client Secret do service token do Cloudflare Access")
+	flag.StringVar(&cfg.Proxy, "proxy", "", "Proxy HTTP(S) até o gateway (default: HTTPS_PROXY/HTTP_PROXY/NO_PROXY do ambiente)")
+	flag.StringVar(&cfg.CACert, "ca-cert", "", "Bundle PEM de CAs extras para verificar o gateway (somado às CAs do sistema)")
+	flag.StringVar(&cfg.ClientCert, "client-cert", "", "Certificado de cliente PEM (mTLS); exige --client-key")
+	flag.StringVar(&cfg.ClientKey, "client-key", "", "Chave privada PEM do --client-cert")
+	flag.BoolVar(&cfg.InsecureSkipVerify, "insecure-skip-verify", false, "NÃO verifica o certificado do gateway (inseguro; só para laboratório)")
+	flag.StringVar(&cfg.StatusAddr, "status-addr", "", "Sobe GET /healthz (uptime, bytes, último erro) neste endereço. Ex: 127.0.0.1:0")
 	flag.Parse()
 
-	if cfg.Endpoint == "" {
+	if len(cfg.Endpoints) == 0 {
 		fmt.Fprintln(os.Stderr, "missing --endpoint")
 		os.Exit(2)
 	}
+	if cfg.MCP && (cfg.Session || cfg.Reconnect) {
+		fmt.Fprintln(os.Stderr, "--mcp cannot be combined with --session or --reconnect")
+		os.Exit(2)
+	}
+	if cfg.EndpointCooldown <= 0 {
+		fmt.Fprintln(os.Stderr, "--endpoint-cooldown must be positive")
+		os.Exit(2)
+	}
+	for _, ep := range cfg.Endpoints {
+		if err := checkNpipeEndpoint(ep); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	cfg.endpoints = newEndpointSet(cfg.Endpoints, cfg.EndpointCooldown)
+	if cfg.Reconnect && (cfg.ReconnectMaxAttempts < 0 || cfg.ReconnectBackoff <= 0 || cfg.ReconnectMaxBackoff < cfg.ReconnectBackoff) {
+		fmt.Fprintln(os.Stderr, "invalid --reconnect settings: need --reconnect-max-attempts >= 0 and 0 < --reconnect-backoff <= --reconnect-max-backoff")
+		os.Exit(2)
+	}
+
+	h, err := authHeaders(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cfg.authHeader = h
+
+	if cfg.transport, err = baseTransport(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 	return cfg
 }
 
@@ -86,7 +185,7 @@ func run(ctx context.Context, cfg config, rid string, log *slog.Logger) error {
 
 	// stdin -> pipe (linha a linha). Não loga payload; no debug, loga tamanho.
 	go func() {
-		scanner := bufio.NewScanner(os.Stdin)
+		scanner := bufio.NewScanner(cfg.status.In(os.Stdin))
 		for scanner.Scan() {
 			b := scanner.Bytes()
 
@@ -104,22 +203,62 @@ func run(ctx context.Context, cfg config, rid string, log *slog.Logger) error {
 		_ = pw.Close()
 	}()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.Endpoint, pr)
+	client := newClient(cfg)
+	if cfg.Reconnect || len(cfg.Endpoints) > 1 {
+		// o gateway lê o body inteiro antes de executar: guardá-lo permite repetir o POST
+		body, err := io.ReadAll(pr)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+		if cfg.Reconnect {
+			err = streamWithReconnect(ctx, cfg, client, body, rid, start, log)
+		} else {
+			err = post(ctx, cfg, client, body, rid, start, log, nil)
+		}
+		if err != nil {
+			return err
+		}
+		log.Info("stopped",
+			shim.DurationMs(time.Since(start).Milliseconds()),
+		)
+		return nil
+	}
+
+	req, err := newPost(ctx, cfg.Endpoints[0], pr, rid)
 	if err != nil {
 		return err
 	}
+	if err := stream(ctx, client, req, start, log, nil); err != nil {
+		return err
+	}
+
+	log.Info("stopped",
+		shim.DurationMs(time.Since(start).Milliseconds()),
+	)
+	return nil
+}
+
+// newPost monta o POST da chamada da tool com body.
+func newPost(ctx context.Context, endpoint string, body io.Reader, rid string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "text/event-stream, application/json")
 
 	// 🔑 Correlaciona shim -> gateway/router
 	req.Header.Set("X-Request-Id", rid)
+	return req, nil
+}
 
-	client := &http.Client{Timeout: cfg.Timeout}
-
+// stream executa req e copia a resposta (SSE ou NDJSON) para o stdout. rp (opcional,
+// --reconnect) acompanha o que já foi entregue; falhas de rede voltam como *connError.
+func stream(ctx context.Context, client *http.Client, req *http.Request, start time.Time, log *slog.Logger, rp *resumePoint) error {
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return &connError{err: err, noResponse: true}
 	}
 	//nolint:errcheck
 	defer resp.Body.Close()
@@ -128,6 +267,7 @@ func run(ctx context.Context, cfg config, rid string, log *slog.Logger) error {
 	isSSE := strings.Contains(ct, "text/event-stream")
 
 	log.Info("connected",
+		slog.String("url", req.URL.Redacted()),
 		slog.String("status", resp.Status),
 		slog.Int("status_code", resp.StatusCode),
 		slog.String("content_type", ct),
@@ -142,12 +282,16 @@ func run(ctx context.Context, cfg config, rid string, log *slog.Logger) error {
 			shim.Err(err),
 			shim.DurationMs(time.Since(start).Milliseconds()),
 		)
-		return err
+		return &upstreamError{status: resp.StatusCode, err: err}
+	}
+
+	if tok := resp.Header.Get(resumeHeader); rp != nil && tok != "" {
+		rp.token = tok
 	}
 
 	var consumeErr error
 	if isSSE {
-		consumeErr = consumeSSE(ctx, resp.Body, log)
+		consumeErr = consumeSSE(ctx, resp.Body, log, rp)
 	} else {
 		consumeErr = consumeStream(ctx, resp.Body, log)
 	}
@@ -159,16 +303,80 @@ func run(ctx context.Context, cfg config, rid string, log *slog.Logger) error {
 		)
 		return consumeErr
 	}
-
-	log.Info("stopped",
-		shim.DurationMs(time.Since(start).Milliseconds()),
-	)
 	return nil
 }
 
+// upstreamError é uma resposta não-2xx do gateway.
+type upstreamError struct {
+	status int
+	err    error
+}
+
+func (e *upstreamError) Error() string { return e.err.Error() }
+func (e *upstreamError) Unwrap() error { return e.err }
+
+// writeFrame decodifica um frame binário (event: data) e escreve os bytes crus no stdout.
+func writeFrame(ctx context.Context, payload []byte, seq *shim.FrameSequence, log *slog.Logger) (int, error) {
+	f, err := shim.DecodeFrame(payload)
+	if err != nil {
+		return 0, err
+	}
+	if lost := seq.Observe(f); lost > 0 {
+		log.Warn("binary frames lost", slog.Int64("lost", lost), slog.Int64("seq", f.Seq))
+	}
+	if _, err := stdout.Write(f.Data); err != nil {
+		return 0, err
+	}
+	if log.Enabled(ctx, slog.LevelDebug) {
+		log.Debug("data frame -> stdout", slog.Int64("seq", f.Seq), slog.Int("bytes", len(f.Data)))
+	}
+	return len(f.Data), nil
+}
+
+// writeLinePart escreve um pedaço de linha gigante (event: continuation); o newline só
+// sai no último pedaço, então o stdout recebe a linha original inteira.
+func writeLinePart(ctx context.Context, payload []byte, log *slog.Logger) (int, error) {
+	p, err := shim.DecodeLinePart(payload)
+	if err != nil {
+		return 0, err
+	}
+	out := p.Data
+	if !p.More {
+		out += "\n"
+	}
+	if _, err := io.WriteString(stdout, out); err != nil {
+		return 0, err
+	}
+	if log.Enabled(ctx, slog.LevelDebug) {
+		log.Debug("continuation -> stdout", slog.Int64("part", p.Part), slog.Bool("more", p.More), slog.Int("bytes", len(out)))
+	}
+	return len(out), nil
+}
+
+// outputEvent trata os eventos de output além de message (data, continuation,
+// line_truncated). handled=false: evento desconhecido, segue o fluxo normal.
+func outputEvent(ctx context.Context, event string, payload []byte, seq *shim.FrameSequence, log *slog.Logger) (n int, handled bool, err error) {
+	switch event {
+	case "data":
+		n, err = writeFrame(ctx, payload, seq, log)
+		return n, true, err
+	case "continuation":
+		n, err = writeLinePart(ctx, payload, log)
+		return n, true, err
+	case "line_truncated":
+		// o início de uma linha cortada não é JSON válido: não vai para o cliente MCP
+		var t shim.LineTruncated
+		_ = json.Unmarshal(payload, &t)
+		log.Warn("tool line truncated by gateway", slog.Int64("line_bytes", t.LineBytes))
+		return 0, true, nil
+	}
+	return 0, false, nil
+}
+
 func consumeStream(ctx context.Context, r io.Reader, log *slog.Logger) error {
 	reader := bufio.NewReader(r)
 	var bytesOut int64
+	var seq shim.FrameSequence
 
 	for {
 		select {
@@ -179,8 +387,26 @@ func consumeStream(ctx context.Context, r io.Reader, log *slog.Logger) error {
 
 		line, err := reader.ReadBytes('\n')
 
+		// NDJSON: eventos de output chegam como {"event":"data|continuation|...","data":{...}}
+		if bytes.Contains(line, []byte(`"event":"`)) {
+			var env struct {
+				Event string          `json:"event"`
+				Data  json.RawMessage `json:"data"`
+			}
+			if json.Unmarshal(line, &env) == nil && env.Event != "" {
+				n, handled, ferr := outputEvent(ctx, env.Event, env.Data, &seq, log)
+				if ferr != nil {
+					return ferr
+				}
+				if handled {
+					bytesOut += int64(n)
+					line = nil
+				}
+			}
+		}
+
 		if len(bytes.TrimSpace(line)) > 0 {
-			_, _ = os.Stdout.Write(line)
+			_, _ = stdout.Write(line)
 			bytesOut += int64(len(line))
 
 			if log.Enabled(ctx, slog.LevelDebug) {
@@ -198,19 +424,24 @@ func consumeStream(ctx context.Context, r io.Reader, log *slog.Logger) error {
 				}
 				return nil
 			}
-			return err
+			return &connError{err: err}
 		}
 	}
 }
 
-func consumeSSE(ctx context.Context, r io.Reader, log *slog.Logger) error {
+// consumeSSE copia os eventos para o stdout; com rp, registra o id: de cada evento
+// entregue (Last-Event-ID da reconexão).
+func consumeSSE(ctx context.Context, r io.Reader, log *slog.Logger, rp *resumePoint) error {
 	scanner := bufio.NewScanner(r)
 
-	const maxToken = 1024 * 1024
+	// pedaços de event: continuation têm até max_line_bytes (64MiB) + escape JSON
+	const maxToken = 128 * 1024 * 1024
 	buf := make([]byte, 64*1024)
 	scanner.Buffer(buf, maxToken)
 
 	var bytesOut int64
+	var seq shim.FrameSequence
+	event, id := "", ""
 
 	for scanner.Scan() {
 		select {
@@ -221,7 +452,19 @@ func consumeSSE(ctx context.Context, r io.Reader, log *slog.Logger) error {
 
 		line := scanner.Text()
 
-		if line == "" || strings.HasPrefix(line, ":") {
+		if line == "" {
+			event, id = "", "" // fim do evento
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		if strings.HasPrefix(line, "event:") {
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			continue
+		}
+		if strings.HasPrefix(line, "id:") {
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
 			continue
 		}
 
@@ -235,8 +478,19 @@ func consumeSSE(ctx context.Context, r io.Reader, log *slog.Logger) error {
 				return nil
 			}
 
+			// output: binary e linhas gigantes
+			n, handled, err := outputEvent(ctx, event, []byte(payload), &seq, log)
+			if err != nil {
+				return err
+			}
+			rp.delivered(id)
+			if handled {
+				bytesOut += int64(n)
+				continue
+			}
+
 			out := []byte(payload + "\n")
-			_, _ = os.Stdout.Write(out)
+			_, _ = stdout.Write(out)
 			bytesOut += int64(len(out))
 
 			if log.Enabled(ctx, slog.LevelDebug) {
@@ -249,7 +503,7 @@ func consumeSSE(ctx context.Context, r io.Reader, log *slog.Logger) error {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return err
+		return &connError{err: err}
 	}
 	return nil
 }
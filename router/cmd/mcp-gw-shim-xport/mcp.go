@@ -0,0 +1,584 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mcp-router/internal/shim"
+)
+
+// Modo MCP (--mcp): o shim vira um servidor MCP stdio completo na frente de um gateway
+// remoto, e qualquer cliente MCP usa o gateway como se fosse um servidor local.
+//
+//   - initialize / ping: respondidos localmente
+//   - tools/list: GET <base>/mcp/tools (input_schema vira inputSchema)
+//   - tools/call: POST <base>/mcp/<name> com arguments como input; as linhas do stream
+//     viram o content do resultado; event: error/cancelled e erros da tool viram isError
+//   - notifications/cancelled: DELETE <base>/mcp/requests/<id> e aborta a chamada
+//
+// --endpoint é a base do gateway (http://localhost:8080; um /mcp/<tool> no fim é
+// ignorado). As chamadas rodam em paralelo e as respostas saem conforme terminam.
+
+// mcpProtocolVersion é a versão MCP anunciada no initialize (a mesma do gateway).
+const mcpProtocolVersion = "2024-11-05"
+
+// mcpCancelTimeout limita o DELETE de cancelamento no gateway.
+const mcpCancelTimeout = 5 * time.Second
+
+// Códigos JSON-RPC padrão.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+func rpcErr(id json.RawMessage, code int, msg string) *rpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: msg}}
+}
+
+// mcpServer atende o JSON-RPC do stdin traduzindo para a API HTTP do gateway.
+type mcpServer struct {
+	cfg    config
+	client *http.Client
+	rid    string
+	log    *slog.Logger
+	seq    atomic.Int64
+
+	outMu sync.Mutex
+
+	mu    sync.Mutex
+	calls map[string]*mcpCall // tools/call em andamento, por id JSON-RPC
+}
+
+// mcpCall é um tools/call em andamento (cancelável por notifications/cancelled).
+type mcpCall struct {
+	rid       string
+	endpoint  string // endpoint que atendeu (o DELETE de cancelamento vai nele)
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+func runMCP(ctx context.Context, cfg config, rid string, log *slog.Logger) error {
+	start := time.Now()
+	s := &mcpServer{
+		cfg:    cfg,
+		client: newClient(cfg),
+		rid:    rid,
+		log:    log,
+		calls:  make(map[string]*mcpCall),
+	}
+	log.Info("mcp server started")
+
+	// stdin numa goroutine: um sinal encerra mesmo sem input pendente
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(cfg.status.In(os.Stdin))
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- bytes.Clone(scanner.Bytes()):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+		close(lines)
+	}()
+
+	// EOF no stdin: espera as chamadas em andamento responderem antes de sair
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	msgs := 0
+	for {
+		var line []byte
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case l, ok := <-lines:
+			if !ok {
+				if err := <-readErr; err != nil {
+					return fmt.Errorf("read stdin: %w", err)
+				}
+				log.Info("stopped", slog.Int("messages", msgs), shim.DurationMs(time.Since(start).Milliseconds()))
+				return nil
+			}
+			line = bytes.TrimSpace(l)
+		}
+		if len(line) == 0 {
+			continue
+		}
+		msgs++
+
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.reply(rpcErr(nil, rpcParseError, "parse error"))
+			continue
+		}
+		if req.Method == "" && req.JSONRPC == "2.0" {
+			continue // resposta do cliente (ex: a um ping nosso): nada a fazer
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			s.reply(rpcErr(req.ID, rpcInvalidRequest, "invalid request"))
+			continue
+		}
+		if log.Enabled(ctx, slog.LevelDebug) {
+			log.Debug("stdin -> mcp", slog.String("method", req.Method), slog.Int("bytes", len(line)))
+		}
+
+		// tools/* vão ao gateway em paralelo; o resto é local e responde na ordem
+		if req.Method == "tools/list" || req.Method == "tools/call" {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.reply(s.handle(ctx, &req))
+			}()
+			continue
+		}
+		s.reply(s.handle(ctx, &req))
+	}
+}
+
+// reply escreve uma resposta JSON-RPC no stdout (nil: notificação, sem resposta).
+func (s *mcpServer) reply(resp *rpcResponse) {
+	if resp == nil {
+		return
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		b, _ = json.Marshal(rpcErr(resp.ID, rpcInternalError, "marshal response: "+err.Error()))
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	_, _ = stdout.Write(append(b, '\n'))
+}
+
+// handle processa uma request JSON-RPC. Retorna nil para notificações.
+func (s *mcpServer) handle(ctx context.Context, req *rpcRequest) *rpcResponse {
+	notification := len(req.ID) == 0
+
+	var (
+		result any
+		rerr   *rpcError
+	)
+	switch req.Method {
+	case "initialize":
+		result = map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "mcp-gw-shim-xport"},
+		}
+	case "ping":
+		result = map[string]any{}
+	case "notifications/cancelled":
+		var p struct {
+			RequestID json.RawMessage `json:"requestId"`
+		}
+		if json.Unmarshal(req.Params, &p) == nil && len(p.RequestID) > 0 {
+			s.cancelCall(p.RequestID)
+		}
+		return nil
+	case "tools/list":
+		result, rerr = s.listTools(ctx)
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil || p.Name == "" || strings.Contains(p.Name, "/") {
+			return rpcErr(req.ID, rpcInvalidParams, "tools/call requires params.name")
+		}
+		var cancelled bool
+		result, rerr, cancelled = s.callTool(ctx, req.ID, p.Name, p.Arguments)
+		if cancelled {
+			return nil // o cliente cancelou: a request não tem resposta
+		}
+	default:
+		if notification {
+			return nil // notifications/* desconhecidas são ignoradas
+		}
+		return rpcErr(req.ID, rpcMethodNotFound, "method not found: "+req.Method)
+	}
+
+	if notification {
+		return nil
+	}
+	if rerr != nil {
+		s.log.Warn("mcp method failed", slog.String("method", req.Method), slog.String("error", rerr.Message))
+		return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Error: rerr}
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// mcpTool é uma tool no formato do tools/list do MCP.
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// listTools traduz GET <base>/mcp/tools para o tools/list do MCP.
+func (s *mcpServer) listTools(ctx context.Context) (any, *rpcError) {
+	var catalog struct {
+		Tools []struct {
+			Name        string         `json:"name"`
+			Description string         `json:"description"`
+			InputSchema map[string]any `json:"input_schema"`
+		} `json:"tools"`
+	}
+	_, err := s.cfg.endpoints.try(ctx, s.log, func(ep string) error {
+		u, err := gatewayURL(ep, "/mcp/tools")
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Request-Id", s.nextRequestID())
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return &connError{err: err, noResponse: true}
+		}
+		//nolint:errcheck
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return upstreamAPIError(resp)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+			return fmt.Errorf("decode tool catalog: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &rpcError{Code: rpcInternalError, Message: "list tools: " + err.Error()}
+	}
+
+	tools := make([]mcpTool, 0, len(catalog.Tools))
+	for _, t := range catalog.Tools {
+		schema := t.InputSchema
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		tools = append(tools, mcpTool{Name: t.Name, Description: t.Description, InputSchema: schema})
+	}
+	return map[string]any{"tools": tools}, nil
+}
+
+// callTool traduz tools/call para POST <base>/mcp/<name>. cancelled: o cliente cancelou
+// a chamada (notifications/cancelled) e ela não tem resposta.
+func (s *mcpServer) callTool(ctx context.Context, id json.RawMessage, name string, args json.RawMessage) (any, *rpcError, bool) {
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	call := &mcpCall{rid: s.nextRequestID(), cancel: cancel}
+	key := rpcIDKey(id)
+	s.mu.Lock()
+	s.calls[key] = call
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.calls, key)
+		s.mu.Unlock()
+	}()
+
+	start := time.Now()
+	var out *toolOutput
+	_, err := s.cfg.endpoints.try(ctx, s.log, func(ep string) error {
+		s.mu.Lock()
+		call.endpoint = ep
+		s.mu.Unlock()
+		var err error
+		out, err = s.postTool(ctx, ep, name, args, call.rid)
+		return err
+	})
+
+	s.mu.Lock()
+	cancelled := call.cancelled
+	s.mu.Unlock()
+	if cancelled {
+		s.log.Info("tool call cancelled by client", slog.String("tool", name), shim.RequestID(call.rid))
+		return nil, nil, true
+	}
+
+	var ue *upstreamError
+	switch {
+	case err == nil:
+	case errors.As(err, &ue) && ue.status == http.StatusNotFound:
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "unknown tool: " + name}, false
+	case errors.As(err, &ue):
+		// input inválido, tool ocupada, timeout, falha da tool: erro de execução (isError)
+		out = &toolOutput{errMsg: ue.Error()}
+	default:
+		return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}, false
+	}
+	if out.errMsg != "" {
+		s.cfg.status.SetError(errors.New(out.errMsg))
+	}
+	s.log.Info("tool call finished",
+		slog.String("tool", name),
+		shim.RequestID(call.rid),
+		slog.Bool("is_error", out.errMsg != ""),
+		shim.DurationMs(time.Since(start).Milliseconds()),
+	)
+	return out.result(name), nil, false
+}
+
+// cancelCall cancela o tools/call id: DELETE no gateway (mata a tool) e aborta a request.
+func (s *mcpServer) cancelCall(id json.RawMessage) {
+	s.mu.Lock()
+	call, ok := s.calls[rpcIDKey(id)]
+	if ok {
+		call.cancelled = true
+	}
+	endpoint := ""
+	if ok {
+		endpoint = call.endpoint
+	}
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	go func() {
+		defer call.cancel()
+		u, err := gatewayURL(endpoint, "/mcp/requests/"+call.rid)
+		if err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), mcpCancelTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("X-Request-Id", call.rid)
+		resp, err := s.client.Do(req)
+		if err != nil {
+			s.log.Warn("cancel request failed", shim.RequestID(call.rid), shim.Err(err))
+			return
+		}
+		_ = resp.Body.Close()
+	}()
+}
+
+// postTool executa a tool e junta o stream da resposta.
+func (s *mcpServer) postTool(ctx context.Context, endpoint, name string, args json.RawMessage, rid string) (*toolOutput, error) {
+	u, err := gatewayURL(endpoint, "/mcp/"+name)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newPost(ctx, u, bytes.NewReader(args), rid)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, &connError{err: err, noResponse: true}
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, upstreamAPIError(resp)
+	}
+
+	out := &toolOutput{}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream") {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, &connError{err: err}
+		}
+		out.text.Write(b)
+		return out, nil
+	}
+	if err := out.collectSSE(resp.Body, s.log); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// toolOutput é o stream de uma chamada juntado para o resultado do tools/call.
+type toolOutput struct {
+	text   strings.Builder
+	binary bytes.Buffer
+	errMsg string // event: error/cancelled ou erro HTTP do gateway
+}
+
+// collectSSE junta os eventos de output (message, continuation, data) e guarda o
+// evento terminal de erro.
+func (o *toolOutput) collectSSE(r io.Reader, log *slog.Logger) error {
+	scanner := bufio.NewScanner(r)
+	// pedaços de event: continuation têm até max_line_bytes (64MiB) + escape JSON
+	scanner.Buffer(make([]byte, 64*1024), 128*1024*1024)
+
+	event := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			event = ""
+			continue
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			continue
+		case !strings.HasPrefix(line, "data:"):
+			continue // comentários, id:
+		}
+		payload := []byte(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		if string(payload) == "[DONE]" {
+			return nil
+		}
+
+		switch event {
+		case "", "message":
+			o.text.Write(payload)
+			o.text.WriteByte('\n')
+		case "continuation":
+			p, err := shim.DecodeLinePart(payload)
+			if err != nil {
+				return err
+			}
+			o.text.WriteString(p.Data)
+			if !p.More {
+				o.text.WriteByte('\n')
+			}
+		case "data":
+			f, err := shim.DecodeFrame(payload)
+			if err != nil {
+				return err
+			}
+			o.binary.Write(f.Data)
+		case "line_truncated":
+			var t shim.LineTruncated
+			_ = json.Unmarshal(payload, &t)
+			log.Warn("tool line truncated by gateway", slog.Int64("line_bytes", t.LineBytes))
+		case "truncated":
+			o.text.WriteString("[output truncated by the gateway: " + string(payload) + "]\n")
+		case "error":
+			var e struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			}
+			_ = json.Unmarshal(payload, &e)
+			o.errMsg = e.Code + ": " + e.Message
+		case "cancelled":
+			o.errMsg = "tool call cancelled"
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return &connError{err: err}
+	}
+	return nil
+}
+
+// result monta o CallToolResult do MCP: o texto das linhas, o output binário como blob e
+// o erro (isError) no fim.
+func (o *toolOutput) result(tool string) map[string]any {
+	content := []map[string]any{}
+	if text := strings.TrimSuffix(o.text.String(), "\n"); text != "" || (o.binary.Len() == 0 && o.errMsg == "") {
+		content = append(content, map[string]any{"type": "text", "text": text})
+	}
+	if o.binary.Len() > 0 {
+		content = append(content, map[string]any{
+			"type": "resource",
+			"resource": map[string]any{
+				"uri":      "mcp-gw://tools/" + url.PathEscape(tool) + "/output",
+				"mimeType": "application/octet-stream",
+				"blob":     base64.StdEncoding.EncodeToString(o.binary.Bytes()),
+			},
+		})
+	}
+	if o.errMsg != "" {
+		content = append(content, map[string]any{"type": "text", "text": o.errMsg})
+	}
+	return map[string]any{"content": content, "isError": o.errMsg != ""}
+}
+
+// upstreamAPIError lê o erro do gateway (core.APIError) de uma resposta não-2xx.
+func upstreamAPIError(resp *http.Response) error {
+	snippet := readSnippet(resp.Body, 2048)
+	var e struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	msg := fmt.Sprintf("%s body=%q", resp.Status, snippet)
+	if json.Unmarshal([]byte(snippet), &e) == nil && e.Message != "" {
+		msg = e.Code + ": " + e.Message
+	}
+	return &upstreamError{status: resp.StatusCode, err: errors.New(msg)}
+}
+
+// rpcIDKey normaliza um id JSON-RPC para chave de mapa (1 e 1 com espaços são o mesmo id).
+func rpcIDKey(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if json.Compact(&buf, id) != nil {
+		return string(id)
+	}
+	return buf.String()
+}
+
+// nextRequestID gera o X-Request-Id de cada request ao gateway (<rid>-<n>).
+func (s *mcpServer) nextRequestID() string {
+	return s.rid + "-" + strconv.FormatInt(s.seq.Add(1), 10)
+}
+
+// gatewayURL troca o path de --endpoint (base do gateway, com ou sem /mcp/<tool> no
+// fim) por path.
+func gatewayURL(endpoint, path string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid --endpoint: %w", err)
+	}
+	base := u.Path
+	if i := strings.LastIndex(base, "/mcp/"); i >= 0 {
+		base = base[:i]
+	}
+	base = strings.TrimSuffix(strings.TrimSuffix(base, "/"), "/mcp")
+	u.Path = base + path
+	u.RawPath = ""
+	u.RawQuery = ""
+	return u.String(), nil
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGateway imita a API HTTP do gateway que o modo --mcp usa.
+type fakeGateway struct {
+	mu        sync.Mutex
+	cancelled []string // X-Request-Id dos DELETE /mcp/requests/<id>
+	started   chan string
+	release   chan struct{}
+}
+
+func newFakeGateway(t *testing.T) (*fakeGateway, *httptest.Server) {
+	t.Helper()
+	g := &fakeGateway{started: make(chan string, 1), release: make(chan struct{})}
+	srv := httptest.NewServer(http.HandlerFunc(g.serve))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() {
+		select {
+		case <-g.release:
+		default:
+			close(g.release)
+		}
+	})
+	return g, srv
+}
+
+func (g *fakeGateway) serve(w http.ResponseWriter, r *http.Request) {
+	sse := func() {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+	}
+	apiError := func(status int, code, msg string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]string{"code": code, "message": msg})
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/mcp/tools":
+		_, _ = io.WriteString(w, `{"tools":[`+
+			`{"name":"echo","description":"Echo the input","input_schema":{"type":"object","properties":{"msg":{"type":"string"}}}},`+
+			`{"name":"bare"}]}`)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/mcp/requests/"):
+		g.mu.Lock()
+		g.cancelled = append(g.cancelled, strings.TrimPrefix(r.URL.Path, "/mcp/requests/"))
+		g.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method != http.MethodPost:
+		apiError(http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+
+	case r.URL.Path == "/mcp/echo":
+		// o input volta como primeira linha; depois uma linha em pedaços e um frame binário
+		in, _ := io.ReadAll(r.Body)
+		sse()
+		_, _ = io.WriteString(w, "event: message\ndata: "+string(in)+"\n\n"+
+			"event: continuation\ndata: {\"part\":0,\"more\":true,\"data\":\"{\\\"big\\\":\"}\n\n"+
+			"event: continuation\ndata: {\"part\":1,\"more\":false,\"data\":\"true}\"}\n\n"+
+			"event: data\ndata: {\"seq\":0,\"data\":\"AAEC\"}\n\n"+
+			"data: [DONE]\n\n")
+	case r.URL.Path == "/mcp/plain":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	case r.URL.Path == "/mcp/fails":
+		sse()
+		_, _ = io.WriteString(w, "event: message\ndata: {\"step\":1}\n\n"+
+			"event: error\ndata: {\"code\":\"tool_failed\",\"message\":\"exit status 2\"}\n\n")
+	case r.URL.Path == "/mcp/busy":
+		apiError(http.StatusTooManyRequests, "tool_busy", "tool concurrency limit reached")
+	case r.URL.Path == "/mcp/hang":
+		sse()
+		w.(http.Flusher).Flush()
+		g.started <- r.Header.Get("X-Request-Id")
+		select {
+		case <-r.Context().Done():
+		case <-g.release:
+		}
+	default:
+		apiError(http.StatusNotFound, "unknown_tool", "tool not found")
+	}
+}
+
+func newTestServer(t *testing.T, endpoint string) *mcpServer {
+	t.Helper()
+	cfg := config{Endpoints: endpointFlag{endpoint}}
+	var err error
+	if cfg.transport, err = baseTransport(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.endpoints = newEndpointSet(cfg.Endpoints, time.Second)
+	return &mcpServer{
+		cfg:    cfg,
+		client: newClient(cfg),
+		rid:    "test",
+		log:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		calls:  make(map[string]*mcpCall),
+	}
+}
+
+// jsonEqual compara dois JSON ignorando formatação e ordem das chaves.
+func jsonEqual(t *testing.T, got any, want string) {
+	t.Helper()
+	raw, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g, w any
+	if err := json.Unmarshal(raw, &g); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("bad expectation %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Fatalf("got  %s\nwant %s", raw, want)
+	}
+}
+
+func TestMCPServer_Handle(t *testing.T) {
+	_, srv := newFakeGateway(t)
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	cases := []struct {
+		name     string
+		endpoint string // "" = o gateway fake
+		req      string
+		want     string // "" = sem resposta (notificação)
+	}{
+		{
+			name: "initialize is answered locally",
+			req:  `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+			want: `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"mcp-gw-shim-xport"}}}`,
+		},
+		{
+			name: "ping",
+			req:  `{"jsonrpc":"2.0","id":"p","method":"ping"}`,
+			want: `{"jsonrpc":"2.0","id":"p","result":{}}`,
+		},
+		{
+			name: "initialized notification has no response",
+			req:  `{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		},
+		{
+			name: "unknown method",
+			req:  `{"jsonrpc":"2.0","id":2,"method":"resources/list"}`,
+			want: `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found: resources/list"}}`,
+		},
+		{
+			name: "tools/list maps the catalog",
+			req:  `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`,
+			want: `{"jsonrpc":"2.0","id":3,"result":{"tools":[
+				{"name":"echo","description":"Echo the input","inputSchema":{"type":"object","properties":{"msg":{"type":"string"}}}},
+				{"name":"bare","inputSchema":{"type":"object"}}]}}`,
+		},
+		{
+			name: "tools/call joins the SSE stream",
+			req:  `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo","arguments":{"msg":"hi"}}}`,
+			want: `{"jsonrpc":"2.0","id":4,"result":{"isError":false,"content":[
+				{"type":"text","text":"{\"msg\":\"hi\"}\n{\"big\":true}"},
+				{"type":"resource","resource":{"uri":"mcp-gw://tools/echo/output","mimeType":"application/octet-stream","blob":"AAEC"}}]}}`,
+		},
+		{
+			name: "tools/call without arguments sends an empty object",
+			req:  `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"echo"}}`,
+			want: `{"jsonrpc":"2.0","id":5,"result":{"isError":false,"content":[
+				{"type":"text","text":"{}\n{\"big\":true}"},
+				{"type":"resource","resource":{"uri":"mcp-gw://tools/echo/output","mimeType":"application/octet-stream","blob":"AAEC"}}]}}`,
+		},
+		{
+			name: "tools/call with a plain JSON response",
+			req:  `{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"plain"}}`,
+			want: `{"jsonrpc":"2.0","id":6,"result":{"isError":false,"content":[{"type":"text","text":"{\"ok\":true}"}]}}`,
+		},
+		{
+			name: "event: error becomes isError",
+			req:  `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"fails"}}`,
+			want: `{"jsonrpc":"2.0","id":7,"result":{"isError":true,"content":[
+				{"type":"text","text":"{\"step\":1}"},
+				{"type":"text","text":"tool_failed: exit status 2"}]}}`,
+		},
+		{
+			name: "gateway API error becomes isError",
+			req:  `{"jsonrpc":"2.0","id":8,"method":"tools/call","params":{"name":"busy"}}`,
+			want: `{"jsonrpc":"2.0","id":8,"result":{"isError":true,"content":[
+				{"type":"text","text":"tool_busy: tool concurrency limit reached"}]}}`,
+		},
+		{
+			name: "unknown tool is invalid params",
+			req:  `{"jsonrpc":"2.0","id":9,"method":"tools/call","params":{"name":"missing"}}`,
+			want: `{"jsonrpc":"2.0","id":9,"error":{"code":-32602,"message":"unknown tool: missing"}}`,
+		},
+		{
+			name: "tools/call without a name",
+			req:  `{"jsonrpc":"2.0","id":10,"method":"tools/call","params":{}}`,
+			want: `{"jsonrpc":"2.0","id":10,"error":{"code":-32602,"message":"tools/call requires params.name"}}`,
+		},
+		{
+			name: "tool name cannot escape /mcp/",
+			req:  `{"jsonrpc":"2.0","id":11,"method":"tools/call","params":{"name":"../admin/keys"}}`,
+			want: `{"jsonrpc":"2.0","id":11,"error":{"code":-32602,"message":"tools/call requires params.name"}}`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ep := tc.endpoint
+			if ep == "" {
+				ep = srv.URL
+			}
+			s := newTestServer(t, ep)
+			var req rpcRequest
+			if err := json.Unmarshal([]byte(tc.req), &req); err != nil {
+				t.Fatal(err)
+			}
+			resp := s.handle(context.Background(), &req)
+			if tc.want == "" {
+				if resp != nil {
+					t.Fatalf("notification must not be answered: %+v", resp)
+				}
+				return
+			}
+			jsonEqual(t, resp, tc.want)
+		})
+	}
+
+	t.Run("unreachable gateway is an internal error", func(t *testing.T) {
+		s := newTestServer(t, downURL)
+		for _, method := range []string{"tools/list", "tools/call"} {
+			req := rpcRequest{JSONRPC: "2.0", ID: json.RawMessage(`1`), Method: method, Params: json.RawMessage(`{"name":"echo"}`)}
+			resp := s.handle(context.Background(), &req)
+			if resp == nil || resp.Error == nil || resp.Error.Code != rpcInternalError {
+				t.Fatalf("%s: expected -32603, got %+v", method, resp)
+			}
+		}
+	})
+}
+
+func TestMCPServer_CancelledNotification(t *testing.T) {
+	g, srv := newFakeGateway(t)
+	s := newTestServer(t, srv.URL)
+
+	done := make(chan *rpcResponse, 1)
+	go func() {
+		req := rpcRequest{JSONRPC: "2.0", ID: json.RawMessage(`"call-1"`), Method: "tools/call", Params: json.RawMessage(`{"name":"hang"}`)}
+		done <- s.handle(context.Background(), &req)
+	}()
+
+	var rid string
+	select {
+	case rid = <-g.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tool call did not reach the gateway")
+	}
+
+	// o id da notificação pode vir com outra formatação: é o mesmo id JSON-RPC
+	cancel := rpcRequest{JSONRPC: "2.0", Method: "notifications/cancelled", Params: json.RawMessage(`{"requestId": "call-1" ,"reason":"user"}`)}
+	if resp := s.handle(context.Background(), &cancel); resp != nil {
+		t.Fatalf("notification must not be answered: %+v", resp)
+	}
+
+	select {
+	case resp := <-done:
+		if resp != nil {
+			t.Fatalf("a cancelled call has no response, got %+v", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tool call not aborted")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		g.mu.Lock()
+		got := append([]string(nil), g.cancelled...)
+		g.mu.Unlock()
+		if len(got) == 1 && got[0] == rid {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected DELETE /mcp/requests/%s, got %v", rid, got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// cancelar um id desconhecido não faz nada
+	unknown := rpcRequest{JSONRPC: "2.0", Method: "notifications/cancelled", Params: json.RawMessage(`{"requestId":99}`)}
+	if resp := s.handle(context.Background(), &unknown); resp != nil {
+		t.Fatalf("notification must not be answered: %+v", resp)
+	}
+}
+
+func TestRunMCP_Stdio(t *testing.T) {
+	_, srv := newFakeGateway(t)
+	s := newTestServer(t, srv.URL)
+
+	in, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.WriteString(in, strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize"}`,
+		`not json`,
+		`{"id":2,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":3,"result":{}}`,
+		``,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"plain"}}`,
+	}, "\n")+"\n")
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	oldIn, oldOut := os.Stdin, stdout
+	var out bytes.Buffer
+	os.Stdin, stdout = in, &out
+	t.Cleanup(func() { os.Stdin, stdout = oldIn, oldOut })
+
+	// EOF no stdin: runMCP espera o tools/call em andamento antes de voltar
+	if err := runMCP(context.Background(), s.cfg, s.rid, s.log); err != nil {
+		t.Fatal(err)
+	}
+
+	byID := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid output line %q: %v", line, err)
+		}
+		byID[string(r.ID)] = line
+	}
+	want := map[string]string{
+		"1":    `"protocolVersion":"2024-11-05"`,
+		"null": `{"code":-32700,"message":"parse error"}`,
+		"2":    `{"code":-32600,"message":"invalid request"}`,
+		"4":    `"text":"{\"ok\":true}"`,
+	}
+	for id, frag := range want {
+		if !strings.Contains(byID[id], frag) {
+			t.Errorf("response %s: want %s, got %q", id, frag, byID[id])
+		}
+	}
+	if len(byID) != len(want) {
+		t.Fatalf("client responses must not be answered; got %v", byID)
+	}
+}
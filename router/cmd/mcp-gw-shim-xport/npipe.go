@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Named pipe do Windows: --endpoint npipe://./pipe/<nome>/mcp/<tool> fala HTTP com um
+// gateway local que escuta em \\.\pipe\<nome> (mcp-gw http --addr npipe://./pipe/<nome>),
+// sem TCP, firewall ou a rede do WSL no meio. Resume, sessões e failover funcionam
+// igual: o que vem depois do nome do pipe é o path HTTP.
+
+// npipeScheme é o scheme de --endpoint para named pipe.
+const npipeScheme = "npipe"
+
+// npipeHost é o Host das requests que vão pelo pipe.
+const npipeHost = "npipe"
+
+// errNpipeUnsupported: named pipes só existem no Windows.
+var errNpipeUnsupported = errors.New("npipe endpoints are only supported on Windows")
+
+// npipeTransport atende as URLs npipe:// (registrado no transport do shim): um
+// http.Transport por pipe, com keep-alive como no TCP.
+type npipeTransport struct {
+	mu    sync.Mutex
+	pipes map[string]*http.Transport
+}
+
+func newNpipeTransport() *npipeTransport {
+	return &npipeTransport{pipes: make(map[string]*http.Transport)}
+}
+
+func (t *npipeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	pipe, path, err := splitPipeURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	u := *req.URL
+	u.Scheme, u.Host, u.Path, u.RawPath = "http", npipeHost, path, ""
+	req = req.Clone(req.Context())
+	req.URL, req.Host = &u, npipeHost
+	return t.transport(pipe).RoundTrip(req)
+}
+
+func (t *npipeTransport) transport(pipe string) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tr, ok := t.pipes[pipe]; ok {
+		return tr
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = nil
+	tr.DialContext = dialPipe(pipe)
+	t.pipes[pipe] = tr
+	return tr
+}
+
+// splitPipeURL separa npipe://<host>/pipe/<nome>/<path> em \\<host>\pipe\<nome> e /<path>.
+func splitPipeURL(u *url.URL) (string, string, error) {
+	name, path, _ := strings.Cut(strings.TrimPrefix(u.Path, "/pipe/"), "/")
+	if u.Host == "" || name == "" || !strings.HasPrefix(u.Path, "/pipe/") {
+		return "", "", fmt.Errorf("invalid npipe endpoint %q (expected npipe://./pipe/<name>/mcp/<tool>)", u.Redacted())
+	}
+	return `\\` + u.Host + `\pipe\` + name, "/" + path, nil
+}
+
+// checkNpipeEndpoint valida um --endpoint npipe:// na partida (outros schemes passam).
+func checkNpipeEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != npipeScheme {
+		return nil
+	}
+	if runtime.GOOS != "windows" {
+		return errNpipeUnsupported
+	}
+	_, _, err = splitPipeURL(u)
+	return err
+}
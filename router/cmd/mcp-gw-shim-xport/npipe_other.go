@@ -0,0 +1,15 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"net"
+)
+
+func dialPipe(pipe string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	_ = pipe
+	return func(context.Context, string, string) (net.Conn, error) {
+		return nil, errNpipeUnsupported
+	}
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"net"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// dialPipe conecta em pipe ignorando o addr do http.Transport (sempre o host npipe).
+func dialPipe(pipe string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return winio.DialPipeContext(ctx, pipe)
+	}
+}
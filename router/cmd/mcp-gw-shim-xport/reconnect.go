@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"mcp-router/internal/shim"
+)
+
+// Reconexão (--reconnect): quedas transitórias (túnel, proxy, restart de LB) não
+// derrubam a sessão do cliente MCP.
+//
+//   - nenhuma resposta do gateway (conexão recusada/resetada): repete o POST
+//   - stream interrompido com resume no gateway (X-MCP-Resume, stream.resume_window_ms):
+//     GET <base>/mcp/streams/<token> com Last-Event-ID; chega só o que faltou
+//   - stream interrompido sem resume: erro (repetir o POST rodaria a tool de novo)
+//
+// As esperas seguem backoff exponencial com jitter; --reconnect-max-attempts conta
+// tentativas seguidas sem receber nenhum evento.
+
+// resumeHeader traz o token do stream retomável (ver stream.resume_window_ms no gateway).
+const resumeHeader = "X-MCP-Resume"
+
+// resumePoint é até onde a resposta foi entregue no stdout.
+type resumePoint struct {
+	endpoint string // endpoint que atendeu o POST (o resume volta nele)
+	token    string // vazio = gateway sem resume
+	lastID   string // id: do último evento entregue
+	events   int    // eventos entregues na conexão atual
+}
+
+// delivered registra um evento entregue (rp nil: sem --reconnect).
+func (rp *resumePoint) delivered(id string) {
+	if rp == nil {
+		return
+	}
+	rp.events++
+	if id != "" {
+		rp.lastID = id
+	}
+}
+
+// connError é uma falha de rede (sem resposta ou stream interrompido); só ela é retentada.
+type connError struct {
+	err        error
+	noResponse bool // a request nem chegou a ter resposta
+}
+
+func (e *connError) Error() string { return e.err.Error() }
+func (e *connError) Unwrap() error { return e.err }
+
+func streamWithReconnect(ctx context.Context, cfg config, client *http.Client, body []byte, rid string, start time.Time, log *slog.Logger) error {
+	backoff := shim.Backoff{Initial: cfg.ReconnectBackoff, Max: cfg.ReconnectMaxBackoff}
+	var rp resumePoint
+	attempt := 0
+
+	for {
+		rp.events = 0
+		var err error
+		if rp.token == "" {
+			err = post(ctx, cfg, client, body, rid, start, log, &rp)
+		} else {
+			err = resume(ctx, client, rid, start, log, &rp)
+		}
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		if !canReconnect(err, &rp) {
+			return err
+		}
+		cfg.status.SetError(err)
+
+		if rp.events > 0 {
+			attempt = 0 // a conexão andou: recomeça o backoff
+		}
+		attempt++
+		if cfg.ReconnectMaxAttempts > 0 && attempt > cfg.ReconnectMaxAttempts {
+			return fmt.Errorf("giving up after %d reconnect attempts: %w", cfg.ReconnectMaxAttempts, err)
+		}
+		wait := backoff.Delay(attempt)
+		log.Warn("connection lost; reconnecting",
+			slog.Int("attempt", attempt),
+			slog.Int64("backoff_ms", wait.Milliseconds()),
+			slog.Bool("resume", rp.token != ""),
+			slog.String("last_event_id", rp.lastID),
+			shim.Err(err),
+		)
+		if err := shim.Sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// resume retoma o stream em andamento (GET de resume no endpoint que o atendeu).
+func resume(ctx context.Context, client *http.Client, rid string, start time.Time, log *slog.Logger, rp *resumePoint) error {
+	u, err := streamsURL(rp.endpoint, rp.token)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("X-Request-Id", rid)
+	if rp.lastID != "" {
+		req.Header.Set("Last-Event-ID", rp.lastID)
+	}
+	return stream(ctx, client, req, start, log, rp)
+}
+
+// canReconnect diz se err é uma queda que dá para retomar sem executar a tool de novo.
+func canReconnect(err error, rp *resumePoint) bool {
+	var ue *upstreamError
+	if errors.As(err, &ue) {
+		// no resume, 502/503/504 vêm do proxy/túnel no caminho; 404 = stream expirou
+		return rp.token != "" && (ue.status == http.StatusBadGateway ||
+			ue.status == http.StatusServiceUnavailable || ue.status == http.StatusGatewayTimeout)
+	}
+	var ce *connError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	return rp.token != "" || ce.noResponse
+}
+
+// streamsURL deriva <base>/mcp/streams/<token> de --endpoint (<base>/mcp/<tool>).
+func streamsURL(endpoint, token string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid --endpoint: %w", err)
+	}
+	i := strings.LastIndex(u.Path, "/mcp/")
+	if i < 0 {
+		return "", fmt.Errorf("--reconnect needs --endpoint in the form <base>/mcp/<tool>, got %q", endpoint)
+	}
+	u.Path = u.Path[:i] + "/mcp/streams/" + url.PathEscape(token)
+	u.RawPath = ""
+	u.RawQuery = ""
+	return u.String(), nil
+}
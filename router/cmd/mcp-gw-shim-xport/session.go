@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-router/internal/shim"
+)
+
+// Modo sessão (--session): semântica de stdio MCP sobre HTTP para tools daemon.
+//
+//  1. POST <base>/mcp/sessions {"tool":"<tool>"} abre a sessão (um processo exclusivo)
+//  2. cada linha do stdin vira um POST --endpoint com Mcp-Session-Id; a resposta (até o
+//     fim do stream) vai para o stdout antes da próxima linha
+//  3. EOF no stdin (ou sinal) fecha a sessão (DELETE /mcp/sessions/<id>)
+//
+// Sessão perdida no gateway (404: expirou por idle, processo morreu) encerra o shim com erro;
+// outros erros de uma mensagem são logados e a sessão segue.
+
+const sessionHeader = "Mcp-Session-Id"
+
+// sessionCloseTimeout limita o DELETE da sessão no encerramento.
+const sessionCloseTimeout = 5 * time.Second
+
+func runSession(ctx context.Context, cfg config, rid string, log *slog.Logger) error {
+	start := time.Now()
+
+	client := newClient(cfg)
+
+	// a sessão é estado de um gateway: abre no primeiro endpoint que atender e fica nele
+	var sessionsURL, tool, id string
+	endpoint, err := cfg.endpoints.try(ctx, log, func(ep string) error {
+		u, t, err := sessionsEndpoint(ep)
+		if err != nil {
+			return err
+		}
+		sessionsURL, tool = u, t
+		id, err = openSession(ctx, client, u, t, rid)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	log = log.With(slog.String("session_id", id))
+	log.Info("session opened", slog.String("tool", tool), slog.String("url", endpoint))
+	defer closeSession(client, sessionsURL, id, rid, log)
+
+	// stdin numa goroutine: um sinal encerra (e fecha a sessão) mesmo sem input pendente
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(cfg.status.In(os.Stdin))
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- bytes.Clone(scanner.Bytes()):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+		close(lines)
+	}()
+
+	msgs := 0
+	for {
+		var line []byte
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case l, ok := <-lines:
+			if !ok {
+				if err := <-readErr; err != nil {
+					return fmt.Errorf("read stdin: %w", err)
+				}
+				log.Info("stopped", slog.Int("messages", msgs), shim.DurationMs(time.Since(start).Milliseconds()))
+				return nil
+			}
+			line = bytes.TrimSpace(l)
+		}
+		if len(line) == 0 {
+			continue
+		}
+		msgs++
+		if log.Enabled(ctx, slog.LevelDebug) {
+			log.Debug("stdin -> session", slog.Int("bytes", len(line)), slog.Int("message", msgs))
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(line))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "text/event-stream, application/json")
+		req.Header.Set(sessionHeader, id)
+		req.Header.Set("X-Request-Id", rid+"-"+strconv.Itoa(msgs))
+
+		err = stream(ctx, client, req, time.Now(), log, nil)
+		var ue *upstreamError
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.As(err, &ue) && ue.status == http.StatusNotFound:
+			return fmt.Errorf("session %s lost: %w", id, err)
+		default:
+			log.Warn("session message failed", slog.Int("message", msgs), shim.Err(err))
+			cfg.status.SetError(err)
+		}
+	}
+}
+
+// sessionsEndpoint deriva <base>/mcp/sessions e o nome da tool de --endpoint (<base>/mcp/<tool>).
+func sessionsEndpoint(endpoint string) (string, string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid --endpoint: %w", err)
+	}
+	i := strings.LastIndex(u.Path, "/mcp/")
+	tool := ""
+	if i >= 0 {
+		tool = strings.Trim(u.Path[i+len("/mcp/"):], "/")
+	}
+	if tool == "" || strings.Contains(tool, "/") {
+		return "", "", fmt.Errorf("--session needs --endpoint in the form <base>/mcp/<tool>, got %q", endpoint)
+	}
+	u.Path = u.Path[:i] + "/mcp/sessions"
+	u.RawQuery = ""
+	return u.String(), tool, nil
+}
+
+func openSession(ctx context.Context, client *http.Client, sessionsURL, tool, rid string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"tool": tool})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sessionsURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", rid)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", &connError{err: fmt.Errorf("open session: %w", err), noResponse: true}
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		err := fmt.Errorf("open session: %s body=%q", resp.Status, readSnippet(resp.Body, 2048))
+		return "", &upstreamError{status: resp.StatusCode, err: err}
+	}
+
+	id := resp.Header.Get(sessionHeader)
+	if id == "" {
+		var out struct {
+			ID string `json:"session_id"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		id = out.ID
+	}
+	if id == "" {
+		return "", errors.New("open session: response without session id")
+	}
+	return id, nil
+}
+
+// closeSession encerra a sessão no gateway (best effort: ela expira por idle de qualquer forma).
+func closeSession(client *http.Client, sessionsURL, id, rid string, log *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), sessionCloseTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, sessionsURL+"/"+url.PathEscape(id), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("X-Request-Id", rid)
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Warn("close session failed", shim.Err(err))
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		log.Warn("close session failed", slog.String("status", resp.Status))
+		return
+	}
+	log.Info("session closed")
+}
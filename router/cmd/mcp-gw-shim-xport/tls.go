@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Rede do shim até o gateway: proxy e TLS (named pipe: ver npipe.go).
+//
+//	--proxy URL                   proxy explícito (default: HTTPS_PROXY/HTTP_PROXY/NO_PROXY)
+//	--ca-cert FILE                CAs extras em PEM, somadas às do sistema (CA própria, self-signed)
+//	--client-cert/--client-key    certificado de cliente (mTLS: tls.client_ca_file no gateway)
+//	--insecure-skip-verify        não verifica o certificado do gateway (só para laboratório)
+
+// baseTransport monta o http.Transport do shim a partir das flags de proxy/TLS.
+func baseTransport(cfg config) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		u, err := url.Parse(cfg.Proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("--proxy: invalid proxy URL %q", cfg.Proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	tc := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // opt-in explícito, com aviso no log
+	}
+	if cfg.CACert != "" {
+		pem, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("--ca-cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("--ca-cert: %q has no PEM certificates", cfg.CACert)
+		}
+		tc.RootCAs = pool
+	}
+
+	if (cfg.ClientCert == "") != (cfg.ClientKey == "") {
+		return nil, fmt.Errorf("--client-cert and --client-key must be used together")
+	}
+	if cfg.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("--client-cert/--client-key: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	t.TLSClientConfig = tc
+	t.RegisterProtocol(npipeScheme, newNpipeTransport()) // --endpoint npipe:// (ver npipe.go)
+	return t, nil
+}
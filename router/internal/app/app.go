@@ -3,42 +3,333 @@ package app
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
 
 	"mcp-router/internal/config"
+	"mcp-router/internal/configsrc"
 	"mcp-router/internal/core"
+	"mcp-router/internal/observability/events"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/storage"
 	"mcp-router/internal/transport"
 )
 
 type App struct {
 	http  *transport.HTTP
 	stdio *transport.Stdio
+	store storage.Store
+
+	// src: config remoto (--config https://..., s3://, git::); nil = arquivo local
+	path    string
+	src     *configsrc.Source
+	refresh time.Duration
+	watch   sync.Once
+
+	// dev: --dev (logs em texto no nível debug, config local recarregado ao mudar)
+	dev bool
+
+	// logLevel: --verbose/--quiet (vence logging.level, logging.components e o --dev); nil = do config
+	logLevel *slog.Level
+
+	// selftest: --selftest-on-start (uma vez, antes de atender)
+	selftest     bool
+	selftestOnce sync.Once
+	selftestErr  error
+
+	mu     sync.Mutex
+	svc    *core.Service
+	cancel context.CancelFunc // loops de background do svc atual
+}
+
+// Option customiza o App na construção.
+type Option func(*App)
+
+// WithConfigRefresh define o intervalo de refresh de configs remotos (0 = sem refresh).
+// Arquivos locais nunca são recarregados.
+func WithConfigRefresh(d time.Duration) Option {
+	return func(a *App) { a.refresh = d }
+}
+
+// WithSelftestOnStart roda o selftest das tools antes de atender; falha em alguma tool
+// impede o start.
+func WithSelftestOnStart() Option {
+	return func(a *App) { a.selftest = true }
 }
 
-func New(configPath string) (*App, error) {
-	cfg, err := config.LoadFromFile(configPath)
+// WithDev liga o modo de desenvolvimento: logs em texto no nível debug, config local
+// recarregado quando o arquivo muda e o modo dev do HTTP (CORS para origens locais, plano de spawn nos
+// headers, só loopback).
+func WithDev() Option {
+	return func(a *App) { a.dev = true }
+}
+
+// WithLogLevel fixa o nível dos logs (--verbose/--quiet), no lugar de logging.level e
+// logging.components. Os log_level por tool continuam valendo.
+func WithLogLevel(l slog.Level) Option {
+	return func(a *App) { a.logLevel = &l }
+}
+
+func New(configPath string, opts ...Option) (*App, error) {
+	a := &App{refresh: configsrc.DefaultRefresh}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	cfg, err := a.loadConfig(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("load config: %w", err)
 	}
 
-	svc := core.New(cfg)
+	if err := setupLogging(cfg, a.dev, a.logLevel); err != nil {
+		return nil, fmt.Errorf("init logging: %w", err)
+	}
+
+	st, err := storage.New(cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("init storage: %w", err)
+	}
+
+	a.store = st
+	a.path = configPath
+	a.svc = core.New(cfg, a.serviceOptions()...)
+	a.http = transport.NewHTTP(a.svc)
+	if a.dev {
+		a.http.EnableDev()
+	}
+	a.stdio = transport.NewStdio(a.svc)
 
 	// opcional: log centralizado aqui
+	if a.src != nil {
+		log.Println("Config source:", a.src)
+	}
+	logTools(cfg)
+	return a, nil
+}
+
+func (a *App) RunStdio(ctx context.Context) error {
+	return a.run(ctx, a.stdio.Run)
+}
+
+func (a *App) RunHTTP(ctx context.Context, addr string) error {
+	return a.run(ctx, func(ctx context.Context) error { return a.http.Run(ctx, addr) })
+}
+
+func (a *App) run(ctx context.Context, serve func(context.Context) error) error {
+	if a.selftest {
+		a.selftestOnce.Do(func() { a.selftestErr = a.runSelftest(ctx) })
+		if a.selftestErr != nil {
+			return a.selftestErr
+		}
+	}
+
+	a.mu.Lock()
+	if a.cancel == nil { // http --stdio: os dois transportes dividem o serviço
+		a.start(ctx, a.svc)
+	}
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.cancel()
+		a.svc.Close()
+	}()
+
+	if a.src != nil && a.refresh > 0 {
+		a.watch.Do(func() {
+			go a.src.Watch(ctx, a.refresh, func(cfg *config.Config) { a.reload(ctx, cfg) })
+		})
+	}
+	if a.src == nil && a.dev {
+		a.watch.Do(func() { go a.watchFile(ctx) })
+	}
+	return serve(ctx)
+}
+
+// runSelftest exercita todas as tools e loga o resultado de cada uma.
+func (a *App) runSelftest(ctx context.Context) error {
+	results, err := a.svc.Selftest(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("selftest: %w", err)
+	}
+	failed := 0
+	for _, r := range results {
+		attrs := []any{
+			slog.String("tool", r.Tool), slog.String("mode", r.Mode),
+			slog.String("status", r.Status), slog.Int64("duration_ms", r.DurationMS),
+		}
+		if r.Detail != "" {
+			attrs = append(attrs, slog.String("detail", r.Detail))
+		}
+		if r.Status == core.SelftestFail {
+			failed++
+			slog.Error("selftest", attrs...)
+			continue
+		}
+		slog.Info("selftest", attrs...)
+	}
+	if failed > 0 {
+		return fmt.Errorf("selftest: %d of %d tools failed", failed, len(results))
+	}
+	return nil
+}
+
+// start dispara os loops de background de svc (cancelados quando svc é substituído).
+// Chamado com a.mu.
+func (a *App) start(ctx context.Context, svc *core.Service) {
+	ctx, a.cancel = context.WithCancel(ctx)
+	go svc.PrepullImages(ctx)
+	go svc.RunContainerGC(ctx)
+	go svc.RunProcessAudit(ctx)
+	if a.src == nil {
+		go svc.RunConfigDriftCheck(ctx, a.path)
+	}
+}
+
+func (a *App) serviceOptions() []core.Option {
+	source := a.path
+	if a.src != nil {
+		source = a.src.String()
+	}
+	return []core.Option{core.WithStore(a.store), core.WithConfigSource(source)}
+}
+
+func (a *App) loadConfig(path string) (*config.Config, error) {
+	if !configsrc.IsRemote(path) {
+		return config.LoadFromFile(path)
+	}
+	src, err := configsrc.New(path)
+	if err != nil {
+		return nil, err
+	}
+	a.src = src
+	return src.Load(context.Background())
+}
+
+// reload troca o serviço por um construído com cfg (refresh do config remoto). As
+// requests novas vão para o serviço novo; o antigo termina as execuções em andamento e é
+// fechado. Uso das chaves de API e o anti-replay do HMAC passam para o novo
+// (core.WithStateFrom); o resto do estado em memória (stats, sessões, KV, chave das URLs
+// assinadas) recomeça. listen, TLS, logging e storage só mudam com restart.
+func (a *App) reload(ctx context.Context, cfg *config.Config) {
+	if err := applyToolLevels(cfg); err != nil {
+		slog.Warn("config reload: tool log levels", slog.String("error", err.Error()))
+	}
+	a.mu.Lock()
+	prev := a.svc
+	a.mu.Unlock()
+	svc := core.New(cfg, append(a.serviceOptions(), core.WithStateFrom(prev))...)
+
+	a.mu.Lock()
+	old, oldCancel := a.svc, a.cancel
+	a.svc = svc
+	a.start(ctx, svc)
+	a.mu.Unlock()
+
+	a.http.Reload(svc)
+	a.stdio.Reload(svc)
+	slog.Info("config reloaded", slog.Int("tools", len(cfg.Tools)))
+	events.Default.Publish(events.Event{Type: events.TypeConfigReloaded, Tools: len(cfg.Tools)})
+	logTools(cfg)
+
+	go retire(ctx, old, oldCancel)
+}
+
+// retire espera o serviço substituído terminar as execuções em andamento (no máximo
+// config.MaxToolTimeout) e o fecha.
+func retire(ctx context.Context, svc *core.Service, cancel context.CancelFunc) {
+	defer cancel()
+	defer svc.Close()
+	deadline := time.NewTimer(config.MaxToolTimeout)
+	defer deadline.Stop()
+	tick := time.NewTicker(500 * time.Millisecond)
+	defer tick.Stop()
+	for len(svc.Executions()) > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			slog.Warn("config reload: closing previous service with executions still running",
+				slog.Int("executions", len(svc.Executions())))
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+func logTools(cfg *config.Config) {
+	if cfg.ActiveProfile != "" {
+		log.Println("Config profile:", cfg.ActiveProfile)
+	}
 	log.Println("Loaded tools:")
 	for k := range cfg.Tools {
 		log.Println(" -", k)
 	}
-
-	return &App{
-		http:  transport.NewHTTP(svc),
-		stdio: transport.NewStdio(svc),
-	}, nil
 }
 
-func (a *App) RunStdio(ctx context.Context) error {
-	return a.stdio.Run(ctx)
+// setupLogging aplica o bloco logging do config ao slog.Default (e ao pacote log, que
+// passa a escrever pelo mesmo handler), os níveis por componente e os log_level por
+// tool. Sem saída/formato no bloco: texto no stderr (o nível continua ajustável em
+// runtime via /admin/loglevel). dev: sempre texto, no nível debug. flagLevel
+// (--verbose/--quiet) vence logging.level, os componentes e o dev.
+func setupLogging(cfg *config.Config, dev bool, flagLevel *slog.Level) error {
+	lc := cfg.Logging
+	var out io.Writer = os.Stderr
+	if lc.Output == "file" {
+		rf, err := logging.OpenRotatingFile(lc.Path, int64(lc.MaxSizeMBEffective())<<20, lc.MaxBackupsEffective())
+		if err != nil {
+			return err
+		}
+		out = rf // aberto até o fim do processo (escritas não são bufferizadas)
+	}
+
+	mode, level := logging.ModeJSON, slog.LevelInfo
+	if !lc.Configured() || lc.Format == "text" || dev {
+		mode = logging.ModeText
+	}
+	if lc.Level != "" {
+		l, err := logging.ParseLevel(lc.Level)
+		if err != nil {
+			return fmt.Errorf("logging.level: %w", err)
+		}
+		level = l
+	}
+	if dev {
+		level = slog.LevelDebug
+	}
+	components := make(map[string]slog.Level, len(lc.Components))
+	for c, lv := range lc.Components {
+		l, err := logging.ParseLevel(lv)
+		if err != nil {
+			return fmt.Errorf("logging.components[%s]: %w", c, err)
+		}
+		components[c] = l
+	}
+	if flagLevel != nil {
+		level, components = *flagLevel, nil
+	}
+
+	logging.New(logging.Config{Mode: mode, Level: level, Out: out})
+	logging.SetComponentLevels(components)
+	logging.SetCapture(lc.Capture.Requests, lc.Capture.RecordsEffective())
+	return applyToolLevels(cfg)
 }
 
-func (a *App) RunHTTP(ctx context.Context, addr string) error {
-	return a.http.Run(ctx, addr)
+// applyToolLevels aplica os log_level por tool.
+func applyToolLevels(cfg *config.Config) error {
+	for name, t := range cfg.Tools {
+		if t.LogLevel == "" {
+			continue
+		}
+		l, err := logging.ParseLevel(t.LogLevel)
+		if err != nil {
+			return fmt.Errorf("tools[%s]: %w", name, err)
+		}
+		logging.SetToolLevel(name, l)
+	}
+	return nil
 }
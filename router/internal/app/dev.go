@@ -0,0 +1,48 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+// devReloadInterval é o intervalo com que o --dev confere o arquivo de config.
+const devReloadInterval = time.Second
+
+// watchFile recarrega o config local quando o arquivo (com includes e profile) passa a
+// ter outro checksum (--dev). Arquivo inválido mantém o config em execução; o erro é
+// logado uma vez por mudança.
+func (a *App) watchFile(ctx context.Context) {
+	t := time.NewTicker(devReloadInterval)
+	defer t.Stop()
+	lastErr := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		cfg, err := config.LoadFromFile(a.path)
+		if err != nil {
+			if err.Error() != lastErr {
+				slog.Warn("dev: config on disk is invalid; running config is unchanged",
+					slog.String("path", a.path), slog.String("error", err.Error()))
+			}
+			lastErr = err.Error()
+			continue
+		}
+		lastErr = ""
+
+		a.mu.Lock()
+		loaded := a.svc.ConfigInfo().SHA256
+		a.mu.Unlock()
+		if cfg.WithDiagnosticTools().Checksum() == loaded {
+			continue
+		}
+		slog.Info("dev: config file changed, reloading", slog.String("path", a.path))
+		a.reload(ctx, cfg)
+	}
+}
@@ -0,0 +1,159 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	goruntime "runtime"
+	"runtime/debug"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/flags"
+	"mcp-router/internal/observability/logging"
+)
+
+// Limites das tools de diagnóstico (o timeout da tool continua valendo por cima).
+const (
+	maxEchoRepeat   = 1000
+	maxEchoInterval = time.Minute
+)
+
+// EchoRequest são os campos de controle de _echo; o resto do input é devolvido como está.
+//
+//	{"msg":"hi"}                                 -> {"msg":"hi"}
+//	{"msg":"hi","repeat":5,"interval_ms":500}    -> 5 linhas, uma a cada 500ms (flush do SSE)
+type EchoRequest struct {
+	Repeat     int   `json:"repeat,omitempty"`
+	IntervalMS int64 `json:"interval_ms,omitempty"`
+}
+
+// Echo emite o input repeat vezes (default 1), esperando interval_ms entre as linhas.
+func Echo(ctx context.Context, input []byte, emit func(any) error) error {
+	if len(input) == 0 {
+		input = []byte(`{}`)
+	}
+	var req EchoRequest
+	if err := json.Unmarshal(input, &req); err != nil {
+		return fmt.Errorf("_echo: input must be a JSON object: %w", err)
+	}
+	interval := time.Duration(req.IntervalMS) * time.Millisecond
+	if req.Repeat < 0 || req.Repeat > maxEchoRepeat {
+		return fmt.Errorf("_echo: repeat must be between 0 and %d", maxEchoRepeat)
+	}
+	if interval < 0 || interval > maxEchoInterval {
+		return fmt.Errorf("_echo: interval_ms must be between 0 and %d", maxEchoInterval.Milliseconds())
+	}
+
+	msg := json.RawMessage(input)
+	for i := range max(req.Repeat, 1) {
+		if i > 0 && interval > 0 {
+			if err := sleepCtx(ctx, interval); err != nil {
+				return err
+			}
+		}
+		if err := emit(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SleepRequest é o input de _sleep: {"ms":1500}.
+type SleepRequest struct {
+	MS int64 `json:"ms"`
+}
+
+// Sleep dorme ms (interrompido pelo cancelamento/timeout da request) e responde {"slept_ms"}.
+func Sleep(ctx context.Context, input []byte) (any, error) {
+	var req SleepRequest
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &req); err != nil {
+			return nil, fmt.Errorf("_sleep: input must be a JSON object: %w", err)
+		}
+	}
+	d := time.Duration(req.MS) * time.Millisecond
+	if d < 0 || d > config.MaxToolTimeout {
+		return nil, fmt.Errorf("_sleep: ms must be between 0 and %d", config.MaxToolTimeout.Milliseconds())
+	}
+	if err := sleepCtx(ctx, d); err != nil {
+		return nil, err
+	}
+	return map[string]int64{"slept_ms": req.MS}, nil
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// EnvReport é a resposta de _env: o que o gateway sabe da request (nunca o ambiente do
+// processo, que pode ter segredos).
+type EnvReport struct {
+	Tool       string   `json:"tool"`
+	RequestID  string   `json:"request_id,omitempty"`
+	Client     string   `json:"client,omitempty"`
+	SessionID  string   `json:"session_id,omitempty"`
+	Flags      []string `json:"flags"`
+	DeadlineMS int64    `json:"deadline_ms,omitempty"` // tempo restante até o timeout da request
+	Hostname   string   `json:"hostname,omitempty"`
+}
+
+// Env monta o EnvReport a partir do ctx da request.
+func Env(ctx context.Context, tool, sessionID string) EnvReport {
+	rep := EnvReport{
+		Tool:      tool,
+		RequestID: logging.RequestIDFromContext(ctx),
+		Client:    logging.ClientFromContext(ctx),
+		SessionID: sessionID,
+		Flags:     flags.FromContext(ctx).List(),
+	}
+	if rep.Flags == nil {
+		rep.Flags = []string{}
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		rep.DeadlineMS = time.Until(dl).Milliseconds()
+	}
+	rep.Hostname, _ = os.Hostname()
+	return rep
+}
+
+// BuildInfo é a versão do gateway (preenchida pelo CLI a partir do -ldflags).
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+}
+
+// Build é a versão reportada por _version.
+var Build = BuildInfo{Version: "dev", Commit: "none", BuildDate: "unknown"}
+
+// VersionReport é a resposta de _version.
+type VersionReport struct {
+	BuildInfo
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Module    string `json:"module,omitempty"`
+}
+
+// Version responde _version.
+func Version() VersionReport {
+	rep := VersionReport{
+		BuildInfo: Build,
+		GoVersion: goruntime.Version(),
+		OS:        goruntime.GOOS,
+		Arch:      goruntime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		rep.Module = bi.Main.Path
+	}
+	return rep
+}
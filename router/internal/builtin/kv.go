@@ -0,0 +1,231 @@
+// Package builtin implementa tools que rodam dentro do próprio gateway (sem processo filho).
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+// maxKVKeyLen limita o tamanho das chaves (bytes).
+const maxKVKeyLen = 256
+
+// kvSweepInterval é o intervalo mínimo entre varreduras globais de chaves expiradas
+// (namespaces abandonados não seriam limpos pelo acesso preguiçoso).
+const kvSweepInterval = time.Minute
+
+var (
+	ErrKVValueTooLarge = errors.New("kv: value too large")
+	ErrKVTooManyKeys   = errors.New("kv: namespace key limit reached")
+	ErrKVInvalidKey    = errors.New("kv: invalid key")
+)
+
+// KVRequest é o input da tool kv (1 JSON no stdin).
+//
+//	{"op":"set","key":"plan","value":{"step":2},"ttl_ms":60000}
+//	{"op":"get","key":"plan"}
+//	{"op":"delete","key":"plan"}
+//	{"op":"list","prefix":"pl"}
+//
+// session (opcional) separa estados do mesmo cliente (ex: uma conversa por agente).
+type KVRequest struct {
+	Op      string          `json:"op"`
+	Key     string          `json:"key,omitempty"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	TTLMS   int64           `json:"ttl_ms,omitempty"`
+	Prefix  string          `json:"prefix,omitempty"`
+	Session string          `json:"session,omitempty"`
+}
+
+type kvEntry struct {
+	value     json.RawMessage
+	expiresAt time.Time
+}
+
+// KV é um key-value em memória, isolado por namespace (identidade + sessão).
+// Não é durável: reiniciar o gateway limpa o estado (pensado para estado efêmero de agentes).
+type KV struct {
+	opts config.KVOptions
+	now  func() time.Time
+
+	mu        sync.Mutex
+	nss       map[string]map[string]kvEntry
+	lastSweep time.Time
+}
+
+func NewKV(opts config.KVOptions) *KV {
+	return &KV{
+		opts: opts,
+		now:  time.Now,
+		nss:  make(map[string]map[string]kvEntry),
+	}
+}
+
+// Namespace monta o namespace a partir da identidade do cliente e da sessão opcional.
+func Namespace(identity, session string) string {
+	if identity == "" {
+		identity = "local"
+	}
+	return identity + "/" + session
+}
+
+// Handle executa uma operação e devolve o resultado (serializável em JSON).
+func (kv *KV) Handle(identity string, input []byte) (any, error) {
+	var req KVRequest
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("kv: invalid input: %w", err)
+	}
+	ns := Namespace(identity, req.Session)
+
+	switch req.Op {
+	case "get":
+		if err := validKVKey(req.Key); err != nil {
+			return nil, err
+		}
+		v, ok := kv.get(ns, req.Key)
+		return map[string]any{"key": req.Key, "found": ok, "value": v}, nil
+
+	case "set":
+		if err := validKVKey(req.Key); err != nil {
+			return nil, err
+		}
+		exp, err := kv.set(ns, req.Key, req.Value, time.Duration(req.TTLMS)*time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"key": req.Key, "ok": true, "expires_at": exp.UTC()}, nil
+
+	case "delete":
+		if err := validKVKey(req.Key); err != nil {
+			return nil, err
+		}
+		return map[string]any{"key": req.Key, "deleted": kv.delete(ns, req.Key)}, nil
+
+	case "list":
+		return map[string]any{"keys": kv.list(ns, req.Prefix)}, nil
+
+	default:
+		return nil, fmt.Errorf("kv: unknown op %q (use get, set, delete or list)", req.Op)
+	}
+}
+
+func validKVKey(key string) error {
+	if key == "" || len(key) > maxKVKeyLen {
+		return fmt.Errorf("%w: must be 1-%d bytes", ErrKVInvalidKey, maxKVKeyLen)
+	}
+	if strings.ContainsAny(key, "\x00\n\r") {
+		return fmt.Errorf("%w: control characters not allowed", ErrKVInvalidKey)
+	}
+	return nil
+}
+
+func (kv *KV) get(ns, key string) (json.RawMessage, bool) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	e, ok := kv.nss[ns][key]
+	if !ok {
+		return nil, false
+	}
+	if !kv.now().Before(e.expiresAt) {
+		kv.deleteLocked(ns, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (kv *KV) set(ns, key string, value json.RawMessage, ttl time.Duration) (time.Time, error) {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		value = json.RawMessage(`null`)
+	}
+	if limit := kv.opts.MaxValueBytesEffective(); len(value) > limit {
+		return time.Time{}, fmt.Errorf("%w: %d bytes (max %d)", ErrKVValueTooLarge, len(value), limit)
+	}
+	if ttl <= 0 {
+		ttl = kv.opts.DefaultTTL()
+	}
+	ttl = min(ttl, kv.opts.MaxTTL())
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	now := kv.now()
+	kv.maybeSweepAllLocked(now)
+
+	if _, exists := kv.nss[ns][key]; !exists && len(kv.nss[ns]) >= kv.opts.MaxKeysEffective() {
+		// antes de recusar, libera o que já expirou
+		kv.sweepLocked(ns, now)
+		if len(kv.nss[ns]) >= kv.opts.MaxKeysEffective() {
+			return time.Time{}, fmt.Errorf("%w (max %d)", ErrKVTooManyKeys, kv.opts.MaxKeysEffective())
+		}
+	}
+
+	m := kv.nss[ns]
+	if m == nil {
+		m = make(map[string]kvEntry)
+		kv.nss[ns] = m
+	}
+	exp := now.Add(ttl)
+	m[key] = kvEntry{value: append(json.RawMessage(nil), value...), expiresAt: exp}
+	return exp, nil
+}
+
+func (kv *KV) delete(ns, key string) bool {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	e, ok := kv.nss[ns][key]
+	kv.deleteLocked(ns, key)
+	return ok && kv.now().Before(e.expiresAt)
+}
+
+func (kv *KV) list(ns, prefix string) []string {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	kv.sweepLocked(ns, kv.now())
+	keys := []string{}
+	for k := range kv.nss[ns] {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (kv *KV) deleteLocked(ns, key string) {
+	m := kv.nss[ns]
+	delete(m, key)
+	if len(m) == 0 {
+		delete(kv.nss, ns)
+	}
+}
+
+// sweepLocked remove as chaves expiradas do namespace. Chamar com kv.mu travado.
+func (kv *KV) sweepLocked(ns string, now time.Time) {
+	for k, e := range kv.nss[ns] {
+		if !now.Before(e.expiresAt) {
+			kv.deleteLocked(ns, k)
+		}
+	}
+}
+
+// maybeSweepAllLocked varre todos os namespaces no máximo uma vez por kvSweepInterval.
+func (kv *KV) maybeSweepAllLocked(now time.Time) {
+	if now.Sub(kv.lastSweep) < kvSweepInterval {
+		return
+	}
+	kv.lastSweep = now
+	for ns := range kv.nss {
+		kv.sweepLocked(ns, now)
+	}
+}
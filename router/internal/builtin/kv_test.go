@@ -0,0 +1,117 @@
+package builtin
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+func kvCall(t *testing.T, kv *KV, identity, input string) map[string]any {
+	t.Helper()
+	res, err := kv.Handle(identity, []byte(input))
+	if err != nil {
+		t.Fatalf("Handle(%s): %v", input, err)
+	}
+	b, _ := json.Marshal(res)
+	var out map[string]any
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+func TestKV_SetGetDeleteList(t *testing.T) {
+	kv := NewKV(config.KVOptions{})
+
+	kvCall(t, kv, "1.2.3.4", `{"op":"set","key":"plan","value":{"step":2}}`)
+	kvCall(t, kv, "1.2.3.4", `{"op":"set","key":"notes","value":"x"}`)
+
+	got := kvCall(t, kv, "1.2.3.4", `{"op":"get","key":"plan"}`)
+	if got["found"] != true {
+		t.Fatalf("expected found, got %v", got)
+	}
+	if v, _ := got["value"].(map[string]any); v["step"] != float64(2) {
+		t.Fatalf("unexpected value: %v", got["value"])
+	}
+
+	list := kvCall(t, kv, "1.2.3.4", `{"op":"list","prefix":"pl"}`)
+	if keys, _ := list["keys"].([]any); len(keys) != 1 || keys[0] != "plan" {
+		t.Fatalf("unexpected list: %v", list)
+	}
+
+	del := kvCall(t, kv, "1.2.3.4", `{"op":"delete","key":"plan"}`)
+	if del["deleted"] != true {
+		t.Fatalf("expected deleted, got %v", del)
+	}
+	if got := kvCall(t, kv, "1.2.3.4", `{"op":"get","key":"plan"}`); got["found"] != false {
+		t.Fatalf("expected not found after delete, got %v", got)
+	}
+}
+
+func TestKV_NamespaceIsolation(t *testing.T) {
+	kv := NewKV(config.KVOptions{})
+
+	kvCall(t, kv, "a", `{"op":"set","key":"k","value":1}`)
+	kvCall(t, kv, "a", `{"op":"set","key":"k","value":2,"session":"s1"}`)
+
+	if got := kvCall(t, kv, "b", `{"op":"get","key":"k"}`); got["found"] != false {
+		t.Fatalf("other identity must not see key, got %v", got)
+	}
+	if got := kvCall(t, kv, "a", `{"op":"get","key":"k","session":"s1"}`); got["value"] != float64(2) {
+		t.Fatalf("session value mismatch, got %v", got)
+	}
+	if got := kvCall(t, kv, "a", `{"op":"get","key":"k"}`); got["value"] != float64(1) {
+		t.Fatalf("default session value mismatch, got %v", got)
+	}
+}
+
+func TestKV_TTL(t *testing.T) {
+	kv := NewKV(config.KVOptions{MaxTTLMS: 60_000})
+	now := time.Unix(1_700_000_000, 0)
+	kv.now = func() time.Time { return now }
+
+	kvCall(t, kv, "a", `{"op":"set","key":"short","value":1,"ttl_ms":1000}`)
+	// ttl acima do teto é truncado para max_ttl_ms
+	kvCall(t, kv, "a", `{"op":"set","key":"long","value":1,"ttl_ms":999999999}`)
+
+	now = now.Add(2 * time.Second)
+	if got := kvCall(t, kv, "a", `{"op":"get","key":"short"}`); got["found"] != false {
+		t.Fatalf("expected expired, got %v", got)
+	}
+
+	now = now.Add(59 * time.Second)
+	if got := kvCall(t, kv, "a", `{"op":"get","key":"long"}`); got["found"] != false {
+		t.Fatalf("expected ttl capped at max_ttl_ms, got %v", got)
+	}
+}
+
+func TestKV_Limits(t *testing.T) {
+	kv := NewKV(config.KVOptions{MaxKeys: 2, MaxValueBytes: 16})
+
+	_, err := kv.Handle("a", []byte(`{"op":"set","key":"big","value":"`+strings.Repeat("x", 32)+`"}`))
+	if !errors.Is(err, ErrKVValueTooLarge) {
+		t.Fatalf("expected ErrKVValueTooLarge, got %v", err)
+	}
+
+	kvCall(t, kv, "a", `{"op":"set","key":"k1","value":1}`)
+	kvCall(t, kv, "a", `{"op":"set","key":"k2","value":1}`)
+	// sobrescrever chave existente não conta no limite
+	kvCall(t, kv, "a", `{"op":"set","key":"k2","value":2}`)
+
+	_, err = kv.Handle("a", []byte(`{"op":"set","key":"k3","value":1}`))
+	if !errors.Is(err, ErrKVTooManyKeys) {
+		t.Fatalf("expected ErrKVTooManyKeys, got %v", err)
+	}
+
+	// limite é por namespace
+	kvCall(t, kv, "b", `{"op":"set","key":"k3","value":1}`)
+
+	if _, err := kv.Handle("a", []byte(`{"op":"get","key":""}`)); !errors.Is(err, ErrKVInvalidKey) {
+		t.Fatalf("expected ErrKVInvalidKey, got %v", err)
+	}
+	if _, err := kv.Handle("a", []byte(`{"op":"nope"}`)); err == nil {
+		t.Fatalf("expected error for unknown op")
+	}
+}
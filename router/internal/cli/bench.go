@@ -0,0 +1,325 @@
+// internal/cli/bench.go
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+)
+
+func newBenchCmd() *cobra.Command {
+	var (
+		server      string
+		tokenEnv    string
+		requests    int
+		concurrency int
+		data        string
+		dataFile    string
+		timeout     string
+		jsonOut     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bench <tool>",
+		Short: "Load-test a tool on a running gateway",
+		Long: "Fires --requests calls of the tool (POST /mcp/<tool>) on a running gateway, --concurrency at a\n" +
+			"time, with the same input, and reports latency percentiles, time to first byte, busy\n" +
+			"rejections (tool_busy, client_busy, gateway_busy), errors by code and throughput.\n" +
+			"Latency and first byte cover the successful calls only.\n" +
+			"Useful to check max_concurrent, queue_timeout_ms and timeout_ms before production.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if requests <= 0 || concurrency <= 0 {
+				return fmt.Errorf("--requests and --concurrency must be positive")
+			}
+			input := []byte(data)
+			if dataFile != "" {
+				b, err := os.ReadFile(dataFile)
+				if err != nil {
+					return err
+				}
+				input = b
+			}
+			if !json.Valid(input) {
+				return fmt.Errorf("input is not valid JSON")
+			}
+			token := ""
+			if tokenEnv != "" {
+				if token = os.Getenv(tokenEnv); token == "" {
+					return fmt.Errorf("%s is not set", tokenEnv)
+				}
+			}
+			if timeout != "" {
+				if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+					return fmt.Errorf("invalid --timeout %q (want a positive duration, e.g. 30s)", timeout)
+				}
+			}
+
+			b := bench{
+				url:     strings.TrimSuffix(server, "/") + "/mcp/" + neturl.PathEscape(args[0]),
+				input:   input,
+				token:   token,
+				timeout: timeout,
+				client:  &http.Client{Timeout: config.MaxToolTimeout + 30*time.Second},
+			}
+			rep := b.run(cmd.Context(), args[0], requests, concurrency)
+			return printBench(rep, jsonOut)
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "http://127.0.0.1:8080", "running gateway base URL")
+	cmd.Flags().StringVar(&tokenEnv, "token-env", "", "env var holding a bearer token or API key for the calls (none by default)")
+	cmd.Flags().IntVarP(&requests, "requests", "n", 100, "total number of calls")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "c", 10, "calls in flight at the same time")
+	cmd.Flags().StringVarP(&data, "data", "d", "{}", "tool input (JSON)")
+	cmd.Flags().StringVar(&dataFile, "data-file", "", "read the tool input from a file instead of --data")
+	cmd.Flags().StringVar(&timeout, "timeout", "", "per-call timeout sent as X-MCP-Timeout (e.g. 30s; capped by max_timeout_ms)")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "print the report as JSON")
+	return cmd
+}
+
+// bench dispara as chamadas de uma tool num gateway em execução.
+type bench struct {
+	url     string
+	input   []byte
+	token   string
+	timeout string
+	client  *http.Client
+}
+
+// benchResult é o resultado de uma chamada: code vazio = sucesso.
+type benchResult struct {
+	code      string
+	latency   time.Duration
+	firstByte time.Duration // 0 = nada chegou
+}
+
+// BenchLatency são percentis (nearest-rank) em ms.
+type BenchLatency struct {
+	Min int64 `json:"min"`
+	P50 int64 `json:"p50"`
+	P90 int64 `json:"p90"`
+	P95 int64 `json:"p95"`
+	P99 int64 `json:"p99"`
+	Max int64 `json:"max"`
+}
+
+// BenchReport é a saída do mcp-gw bench.
+type BenchReport struct {
+	Tool        string         `json:"tool"`
+	Requests    int            `json:"requests"`
+	Concurrency int            `json:"concurrency"`
+	DurationMS  int64          `json:"duration_ms"`
+	Throughput  float64        `json:"throughput_rps"`
+	OK          int            `json:"ok"`
+	Busy        int            `json:"busy"`
+	Errors      map[string]int `json:"errors,omitempty"` // por código (sem busy)
+	Latency     BenchLatency   `json:"latency_ms"`       // das chamadas ok (rejeições busy são imediatas)
+	FirstByte   *BenchLatency  `json:"first_byte_ms,omitempty"`
+}
+
+func (b *bench) run(ctx context.Context, tool string, requests, concurrency int) BenchReport {
+	results := make([]benchResult, requests)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < min(concurrency, requests); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = b.call(ctx)
+			}
+		}()
+	}
+feed:
+	for i := 0; i < requests; i++ {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			requests = i
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	rep := BenchReport{Tool: tool, Requests: requests, Concurrency: concurrency, DurationMS: elapsed.Milliseconds()}
+	if elapsed > 0 {
+		rep.Throughput = float64(requests) / elapsed.Seconds()
+	}
+	var lat, fb []int64
+	for _, r := range results[:requests] {
+		switch {
+		case r.code == "":
+			rep.OK++
+			lat = append(lat, r.latency.Milliseconds())
+			if r.firstByte > 0 {
+				fb = append(fb, r.firstByte.Milliseconds())
+			}
+		case r.code == core.CodeToolBusy || r.code == core.CodeClientBusy || r.code == core.CodeGatewayBusy:
+			rep.Busy++
+		default:
+			if rep.Errors == nil {
+				rep.Errors = map[string]int{}
+			}
+			rep.Errors[r.code]++
+		}
+	}
+	rep.Latency = benchLatency(lat)
+	if len(fb) > 0 {
+		l := benchLatency(fb)
+		rep.FirstByte = &l
+	}
+	return rep
+}
+
+// call faz uma chamada e classifica o resultado: HTTP != 200 pelo code do corpo, stream
+// pelo event: error (ou cancelled/truncated) antes do fim.
+func (b *bench) call(ctx context.Context) benchResult {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(b.input))
+	if err != nil {
+		return benchResult{code: "client_error", latency: time.Since(start)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	if b.timeout != "" {
+		req.Header.Set("X-MCP-Timeout", b.timeout)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return benchResult{code: "connection_error", latency: time.Since(start)}
+	}
+	defer resp.Body.Close()
+
+	res := benchResult{}
+	br := bufio.NewReader(resp.Body)
+	if _, err := br.Peek(1); err == nil {
+		res.firstByte = time.Since(start)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code string `json:"code"`
+		}
+		_ = json.NewDecoder(io.LimitReader(br, 64<<10)).Decode(&apiErr)
+		res.code = apiErr.Code
+		if res.code == "" {
+			res.code = fmt.Sprintf("http_%d", resp.StatusCode)
+		}
+		res.latency = time.Since(start)
+		return res
+	}
+
+	event := ""
+	sc := bufio.NewScanner(br)
+	sc.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			event = "" // fim do evento SSE
+			continue
+		}
+		if ev, ok := strings.CutPrefix(line, "event: "); ok {
+			event = ev
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		switch event {
+		case "error":
+			var apiErr struct {
+				Code string `json:"code"`
+			}
+			_ = json.Unmarshal([]byte(data), &apiErr)
+			res.code = apiErr.Code
+			if res.code == "" {
+				res.code = core.CodeToolFailed
+			}
+		case "cancelled", "truncated":
+			res.code = event
+		}
+	}
+	if err := sc.Err(); err != nil && res.code == "" {
+		res.code = "stream_error"
+	}
+	res.latency = time.Since(start)
+	return res
+}
+
+// benchLatency calcula os percentis de ms (ordena ms).
+func benchLatency(ms []int64) BenchLatency {
+	if len(ms) == 0 {
+		return BenchLatency{}
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i] < ms[j] })
+	rank := func(p int) int64 {
+		i := (p*len(ms)+99)/100 - 1
+		if i < 0 {
+			i = 0
+		}
+		return ms[i]
+	}
+	return BenchLatency{Min: ms[0], P50: rank(50), P90: rank(90), P95: rank(95), P99: rank(99), Max: ms[len(ms)-1]}
+}
+
+func printBench(rep BenchReport, jsonOut bool) error {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	}
+	fmt.Printf("tool: %s\nrequests: %d (concurrency %d) in %dms, %.1f req/s\n",
+		rep.Tool, rep.Requests, rep.Concurrency, rep.DurationMS, rep.Throughput)
+	fmt.Printf("ok: %d  busy: %d  errors: %d\n", rep.OK, rep.Busy, rep.Requests-rep.OK-rep.Busy)
+
+	if rep.OK == 0 {
+		return printBenchErrors(rep)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tMIN\tP50\tP90\tP95\tP99\tMAX")
+	printLatency := func(name string, l BenchLatency) {
+		fmt.Fprintf(tw, "%s\t%dms\t%dms\t%dms\t%dms\t%dms\t%dms\n", name, l.Min, l.P50, l.P90, l.P95, l.P99, l.Max)
+	}
+	printLatency("latency", rep.Latency)
+	if rep.FirstByte != nil {
+		printLatency("first byte", *rep.FirstByte)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return printBenchErrors(rep)
+}
+
+func printBenchErrors(rep BenchReport) error {
+	codes := make([]string, 0, len(rep.Errors))
+	for c := range rep.Errors {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	for _, c := range codes {
+		fmt.Printf("  %s: %d\n", c, rep.Errors[c])
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// internal/cli/completion.go
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newCompletionCmd() *cobra.Command {
+	var noDesc bool
+
+	cmd := &cobra.Command{
+		Use:   "completion bash|zsh|fish|powershell",
+		Short: "Generate the shell completion script",
+		Long: "Prints the completion script for the given shell to stdout. Examples:\n\n" +
+			"  bash:        mcp-gw completion bash > /etc/bash_completion.d/mcp-gw\n" +
+			"  zsh:         mcp-gw completion zsh > \"${fpath[1]}/_mcp-gw\"\n" +
+			"  fish:        mcp-gw completion fish > ~/.config/fish/completions/mcp-gw.fish\n" +
+			"  powershell:  mcp-gw completion powershell | Out-String | Invoke-Expression",
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(os.Stdout, !noDesc)
+			case "zsh":
+				if noDesc {
+					return root.GenZshCompletionNoDesc(os.Stdout)
+				}
+				return root.GenZshCompletion(os.Stdout)
+			case "fish":
+				return root.GenFishCompletion(os.Stdout, !noDesc)
+			case "powershell":
+				if noDesc {
+					return root.GenPowerShellCompletion(os.Stdout)
+				}
+				return root.GenPowerShellCompletionWithDesc(os.Stdout)
+			}
+			return fmt.Errorf("unsupported shell %q", args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&noDesc, "no-descriptions", false, "omit command and flag descriptions from the completions")
+	return cmd
+}
@@ -0,0 +1,106 @@
+// internal/cli/config_diff.go
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+)
+
+func newConfigDiffCmd() *cobra.Command {
+	var (
+		server   string
+		tokenEnv string
+		jsonOut  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <new.yaml>",
+		Short: "Compare the current config with a proposed one (dry-run, nothing is applied)",
+		Long: "Compare the current config (--config) with a proposed one and report tools added/changed/removed.\n" +
+			"With --server, asks the running gateway (POST /admin/config/plan), which also lists the\n" +
+			"in-flight requests a reload would affect.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if server != "" {
+				return diffConfigRemote(server, os.Getenv(tokenEnv), args[0], jsonOut)
+			}
+			return diffConfigLocal(cfgPath, args[0], jsonOut)
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "", "running gateway base URL (ex: http://127.0.0.1:8080)")
+	cmd.Flags().StringVar(&tokenEnv, "token-env", config.DefaultAdminTokenEnv, "env var holding the admin token (with --server)")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "print plan as JSON")
+	return cmd
+}
+
+func diffConfigLocal(currentPath, nextPath string, jsonOut bool) error {
+	cur, err := config.LoadFromFile(currentPath)
+	if err != nil {
+		return err
+	}
+	next, err := config.LoadFromFile(nextPath)
+	if err != nil {
+		return err
+	}
+	return printPlan(core.ConfigPlan{Diff: config.Compare(cur, next)}, jsonOut)
+}
+
+func diffConfigRemote(server, token, nextPath string, jsonOut bool) error {
+	body, err := os.ReadFile(nextPath)
+	if err != nil {
+		return fmt.Errorf("read %q: %w", nextPath, err)
+	}
+	if token == "" {
+		return fmt.Errorf("admin token not set (see --token-env)")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(server, "/")+"/admin/config/plan", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("plan failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var plan core.ConfigPlan
+	if err := json.NewDecoder(resp.Body).Decode(&plan); err != nil {
+		return fmt.Errorf("decode plan: %w", err)
+	}
+	return printPlan(plan, jsonOut)
+}
+
+func printPlan(plan core.ConfigPlan, jsonOut bool) error {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+
+	fmt.Print(plan.Diff.String())
+	for _, e := range plan.Affected {
+		fmt.Printf("! in-flight request_id=%s tool=%s age_ms=%d\n", e.RequestID, e.Tool, e.AgeMs)
+	}
+	return nil
+}
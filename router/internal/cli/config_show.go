@@ -15,7 +15,12 @@ func newConfigCmd() *cobra.Command {
 		Short: "Config utilities",
 	}
 
-	cmd.AddCommand(newConfigShowCmd())
+	cmd.AddCommand(
+		newConfigShowCmd(),
+		newConfigValidateCmd(),
+		newConfigSchemaCmd(),
+		newConfigDiffCmd(),
+	)
 	return cmd
 }
 
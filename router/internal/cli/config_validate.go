@@ -0,0 +1,81 @@
+// internal/cli/config_validate.go
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"mcp-router/internal/config"
+)
+
+func newConfigValidateCmd() *cobra.Command {
+	var (
+		strict  bool
+		jsonOut bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate config (schema, unknown keys, duplicate names, cmd paths)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return validateConfig(cfgPath, strict, jsonOut)
+		},
+	}
+
+	cmd.Flags().BoolVar(&strict, "strict", false, "treat warnings as errors")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "print issues as JSON")
+	return cmd
+}
+
+func newConfigSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print JSON Schema for config.yaml (editor integration)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(config.JSONSchema())
+		},
+	}
+}
+
+func validateConfig(path string, strict, jsonOut bool) error {
+	_, issues, err := config.LintFile(path, strict)
+	if err != nil {
+		return err
+	}
+
+	errCount := 0
+	for _, i := range issues {
+		if i.Level == config.IssueError {
+			errCount++
+		}
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]any{
+			"path":   path,
+			"valid":  errCount == 0,
+			"issues": issues,
+		}); err != nil {
+			return err
+		}
+	} else {
+		for _, i := range issues {
+			fmt.Println(i.String())
+		}
+		if errCount == 0 {
+			fmt.Printf("config %s: OK (%d warning(s))\n", path, len(issues))
+		}
+	}
+
+	if errCount > 0 {
+		return fmt.Errorf("config %s: %d error(s)", path, errCount)
+	}
+	return nil
+}
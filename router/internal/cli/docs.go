@@ -0,0 +1,265 @@
+// internal/cli/docs.go
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newDocsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate CLI reference docs (man pages, markdown)",
+	}
+	cmd.AddCommand(newDocsManCmd(), newDocsMarkdownCmd())
+	return cmd
+}
+
+func newDocsManCmd() *cobra.Command {
+	var (
+		dir     string
+		section string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "man",
+		Short: "Write one man page per command",
+		Long: "Writes a troff man page per command (mcp-gw.1, mcp-gw-http.1, ...) into --dir.\n" +
+			"The date comes from $SOURCE_DATE_EPOCH when set (reproducible builds), else the build date.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			date := docsDate()
+			return writeDocs(cmd.Root(), dir, func(c *cobra.Command) (string, []byte) {
+				return manName(c) + "." + section, manPage(c, section, date)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "man", "output directory (created if missing)")
+	cmd.Flags().StringVar(&section, "section", "1", "man section")
+	return cmd
+}
+
+func newDocsMarkdownCmd() *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "markdown",
+		Short: "Write one markdown page per command",
+		Long:  "Writes a markdown page per command (mcp-gw.md, mcp-gw_http.md, ...) into --dir, linked to each other.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeDocs(cmd.Root(), dir, func(c *cobra.Command) (string, []byte) {
+				return markdownName(c), markdownPage(c)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "docs/cli", "output directory (created if missing)")
+	return cmd
+}
+
+// writeDocs gera uma página por comando documentável da árvore (pré-ordem) em dir.
+func writeDocs(root *cobra.Command, dir string, page func(*cobra.Command) (string, []byte)) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	n := 0
+	var walk func(c *cobra.Command) error
+	walk = func(c *cobra.Command) error {
+		name, b := page(c)
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0o644); err != nil {
+			return err
+		}
+		n++
+		for _, sub := range docCommands(c) {
+			if err := walk(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d pages to %s\n", n, dir)
+	return nil
+}
+
+// docCommands são os subcomandos que ganham página: sem help, ocultos e deprecated.
+func docCommands(c *cobra.Command) []*cobra.Command {
+	var out []*cobra.Command
+	for _, sub := range c.Commands() {
+		if sub.IsAvailableCommand() || sub.IsAdditionalHelpTopicCommand() {
+			out = append(out, sub)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// docsDate é a data das páginas: $SOURCE_DATE_EPOCH, senão BuildDate (RFC 3339), senão hoje.
+func docsDate() time.Time {
+	if s := os.Getenv("SOURCE_DATE_EPOCH"); s != "" {
+		if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC()
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, BuildDate); err == nil {
+		return t.UTC()
+	}
+	return time.Now().UTC()
+}
+
+func manName(c *cobra.Command) string {
+	return strings.ReplaceAll(c.CommandPath(), " ", "-")
+}
+
+func markdownName(c *cobra.Command) string {
+	return strings.ReplaceAll(c.CommandPath(), " ", "_") + ".md"
+}
+
+// docsLong é a descrição do comando (Long, senão Short).
+func docsLong(c *cobra.Command) string {
+	if c.Long != "" {
+		return c.Long
+	}
+	return c.Short
+}
+
+// manPage renderiza c em troff (macros man).
+func manPage(c *cobra.Command, section string, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, ".TH %q %q %q %q %q\n",
+		strings.ToUpper(manName(c)), section, date.Format("2006-01-02"), "mcp-gw "+Version, "mcp-gw Manual")
+	b.WriteString(".nh\n.ad l\n")
+
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", manName(c), manEscape(c.Short))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, "\\fB%s\\fP\n", manEscape(c.UseLine()))
+
+	b.WriteString(".SH DESCRIPTION\n")
+	manParagraphs(&b, docsLong(c))
+
+	manFlags(&b, "OPTIONS", c.NonInheritedFlags())
+	manFlags(&b, "OPTIONS INHERITED FROM PARENT COMMANDS", c.InheritedFlags())
+
+	if c.Example != "" {
+		b.WriteString(".SH EXAMPLE\n.PP\n.RS\n.nf\n")
+		b.WriteString(manEscape(c.Example))
+		b.WriteString("\n.fi\n.RE\n")
+	}
+
+	var see []string
+	if c.HasParent() {
+		see = append(see, fmt.Sprintf("\\fB%s(%s)\\fP", manName(c.Parent()), section))
+	}
+	for _, sub := range docCommands(c) {
+		see = append(see, fmt.Sprintf("\\fB%s(%s)\\fP", manName(sub), section))
+	}
+	if len(see) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		b.WriteString(strings.Join(see, ", "))
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// manParagraphs escreve o texto preservando as quebras de linha (o Long já vem formatado).
+func manParagraphs(b *bytes.Buffer, text string) {
+	b.WriteString(".PP\n.nf\n")
+	b.WriteString(manEscape(strings.TrimRight(text, "\n")))
+	b.WriteString("\n.fi\n")
+}
+
+func manFlags(b *bytes.Buffer, title string, fs *pflag.FlagSet) {
+	if !fs.HasAvailableFlags() {
+		return
+	}
+	fmt.Fprintf(b, ".SH %s\n", title)
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+		varname, usage := pflag.UnquoteUsage(f)
+		b.WriteString(".TP\n")
+		if f.Shorthand != "" && f.ShorthandDeprecated == "" {
+			fmt.Fprintf(b, "\\fB\\-%s\\fP, ", f.Shorthand)
+		}
+		fmt.Fprintf(b, "\\fB\\-\\-%s\\fP", f.Name)
+		if varname != "" {
+			fmt.Fprintf(b, " \\fI%s\\fP", manEscape(varname))
+		}
+		b.WriteString("\n")
+		b.WriteString(manEscape(usage))
+		if flagHasDefault(f) {
+			fmt.Fprintf(b, " (default %s)", manEscape(f.DefValue))
+		}
+		b.WriteString("\n")
+	})
+}
+
+// flagHasDefault diz se o default da flag vale ser mostrado (não é o valor zero do tipo).
+func flagHasDefault(f *pflag.Flag) bool {
+	switch f.DefValue {
+	case "", "false", "0", "0s", "[]":
+		return false
+	}
+	return true
+}
+
+// manEscape escapa barras invertidas e linhas que o troff leria como request (. ou ').
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownPage renderiza c em markdown, com links relativos para o pai e os subcomandos.
+func markdownPage(c *cobra.Command) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "## %s\n\n%s\n\n", c.CommandPath(), c.Short)
+	fmt.Fprintf(&b, "### Synopsis\n\n%s\n\n", docsLong(c))
+	if c.Runnable() {
+		fmt.Fprintf(&b, "```\n%s\n```\n\n", c.UseLine())
+	}
+	if c.Example != "" {
+		fmt.Fprintf(&b, "### Examples\n\n```\n%s\n```\n\n", c.Example)
+	}
+	if fs := c.NonInheritedFlags(); fs.HasAvailableFlags() {
+		fmt.Fprintf(&b, "### Options\n\n```\n%s```\n\n", fs.FlagUsages())
+	}
+	if fs := c.InheritedFlags(); fs.HasAvailableFlags() {
+		fmt.Fprintf(&b, "### Options inherited from parent commands\n\n```\n%s```\n\n", fs.FlagUsages())
+	}
+
+	subs := docCommands(c)
+	if !c.HasParent() && len(subs) == 0 {
+		return b.Bytes()
+	}
+	b.WriteString("### SEE ALSO\n\n")
+	if c.HasParent() {
+		p := c.Parent()
+		fmt.Fprintf(&b, "* [%s](%s)\t - %s\n", p.CommandPath(), markdownName(p), p.Short)
+	}
+	for _, sub := range subs {
+		fmt.Fprintf(&b, "* [%s](%s)\t - %s\n", sub.CommandPath(), markdownName(sub), sub.Short)
+	}
+	return b.Bytes()
+}
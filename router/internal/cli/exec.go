@@ -0,0 +1,112 @@
+// internal/cli/exec.go
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func newExecCmd() *cobra.Command {
+	var (
+		input   string
+		data    string
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "exec <tool>",
+		Short: "Run a tool once locally and print its output lines",
+		Long: "Loads --config and runs the tool the way a call would (runtime, sandbox, hardening,\n" +
+			"input_schema, timeouts), without starting a server. Each output line goes to stdout;\n" +
+			"gateway logs go to stderr. The input comes from --input (a file, or - for stdin) or\n" +
+			"--data (default {}). Exits non-zero when the call fails.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body, err := execInput(input, data)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.LoadFromFile(cfgPath)
+			if err != nil {
+				return err
+			}
+			svc := core.New(cfg)
+			defer svc.Close()
+
+			ctx, rid := logging.EnsureRequestID(cmd.Context())
+			if timeout > 0 {
+				d, _ := svc.ClampToolTimeout(args[0], timeout)
+				ctx = core.WithRequestTimeout(ctx, d)
+			}
+
+			out := &lineWriter{w: bufio.NewWriter(os.Stdout)}
+			err = svc.StreamTool(ctx, args[0], body, out)
+			if ferr := out.w.Flush(); err == nil {
+				err = ferr
+			}
+			if err != nil {
+				return fmt.Errorf("%s (request_id %s): %w", core.ErrorCode(err), rid, err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&input, "input", "", "read the tool input from a file, or - for stdin")
+	cmd.Flags().StringVarP(&data, "data", "d", "{}", "tool input (JSON) when --input is not given")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "call timeout (default the tool timeout_ms; capped by max_timeout_ms)")
+	return cmd
+}
+
+// execInput lê o input do exec: --input (arquivo ou - para stdin) ou --data.
+func execInput(input, data string) ([]byte, error) {
+	body := []byte(data)
+	switch input {
+	case "":
+	case "-":
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("read stdin: %w", err)
+		}
+		body = b
+	default:
+		b, err := os.ReadFile(input)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		body = []byte(`{}`)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("input is not valid JSON")
+	}
+	return body, nil
+}
+
+// lineWriter escreve cada linha de saída da tool no stdout (flush por linha: a saída
+// aparece conforme a tool produz).
+type lineWriter struct{ w *bufio.Writer }
+
+func (l *lineWriter) WriteLine(b []byte) error {
+	if _, err := l.w.Write(b); err != nil {
+		return err
+	}
+	if err := l.w.WriteByte('\n'); err != nil {
+		return err
+	}
+	return l.w.Flush()
+}
@@ -9,19 +9,29 @@ import (
 	"github.com/spf13/cobra"
 
 	"mcp-router/internal/app"
+	"mcp-router/internal/transport"
 )
 
+// devAddr é o --addr default do --dev (o modo dev só aceita loopback).
+const devAddr = "127.0.0.1:8080"
+
 func newHTTPCmd() *cobra.Command {
 	var (
 		addr      string
 		alsoStdio bool
+		selftest  bool
+		dev       bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "http",
 		Short: "Run MCP gateway in HTTP mode",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if addr == "" {
+			// socket activation do systemd: o socket vem pronto, --addr é dispensável
+			if addr == "" && dev && !transport.SocketActivated() {
+				addr = devAddr
+			}
+			if addr == "" && !transport.SocketActivated() {
 				return fmt.Errorf("missing required flag: --addr (e.g. --addr :8080)")
 			}
 
@@ -29,7 +39,11 @@ func newHTTPCmd() *cobra.Command {
 			ctx, cancel := context.WithCancel(cmd.Context())
 			defer cancel()
 
-			a, err := app.New(cfgPath)
+			opts := appOptions(selftest)
+			if dev {
+				opts = append(opts, app.WithDev())
+			}
+			a, err := app.New(cfgPath, opts...)
 			if err != nil {
 				return err
 			}
@@ -47,8 +61,10 @@ func newHTTPCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&addr, "addr", "", "HTTP listen address (e.g. :8080)")
+	cmd.Flags().StringVar(&addr, "addr", "", "HTTP listen address (e.g. :8080, or npipe://./pipe/mcp-gw on Windows); ignored under systemd socket activation")
 	cmd.Flags().BoolVar(&alsoStdio, "also-stdio", false, "also run stdio while HTTP is running")
+	cmd.Flags().BoolVar(&selftest, "selftest-on-start", false, "run the selftest on every tool before serving; refuse to start if any fails")
+	cmd.Flags().BoolVar(&dev, "dev", false, "local development mode: CORS for loopback origins (plus host_check.allowed_origins), debug text logs, config reload on file change and spawn plan headers; loopback only (default --addr "+devAddr+")")
 
 	return cmd
 }
@@ -0,0 +1,100 @@
+// internal/cli/loglevel.go
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/transport"
+)
+
+func newLogLevelCmd() *cobra.Command {
+	var (
+		server   string
+		tokenEnv string
+		tool     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "loglevel [debug|info|warn|error|default]",
+		Short: "Show or change the log level of a running gateway",
+		Long: "Without arguments, prints the global log level and the per-tool overrides (GET /admin/loglevel).\n" +
+			"With a level, changes it at runtime (PUT /admin/loglevel); --tool changes only that tool.\n" +
+			"\"default\" with --tool drops the override (the tool follows the global level again).\n" +
+			"Changes last until the gateway restarts.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token := os.Getenv(tokenEnv)
+			if token == "" {
+				return fmt.Errorf("admin token not set (see --token-env)")
+			}
+
+			method, body := http.MethodGet, []byte(nil)
+			if len(args) == 1 {
+				level := args[0]
+				if level == "default" {
+					if tool == "" {
+						return fmt.Errorf(`"default" requires --tool`)
+					}
+					level = ""
+				} else if _, err := logging.ParseLevel(level); err != nil {
+					return err
+				}
+				method = http.MethodPut
+				body, _ = json.Marshal(map[string]string{"tool": tool, "level": level})
+			}
+			return logLevelRemote(server, token, method, body)
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "http://127.0.0.1:8080", "running gateway base URL")
+	cmd.Flags().StringVar(&tokenEnv, "token-env", config.DefaultAdminTokenEnv, "env var holding the admin token")
+	cmd.Flags().StringVar(&tool, "tool", "", "change only this tool's level")
+	return cmd
+}
+
+func logLevelRemote(server, token, method string, body []byte) error {
+	req, err := http.NewRequest(method, strings.TrimSuffix(server, "/")+"/admin/loglevel", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("loglevel failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var st transport.LogLevelState
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	fmt.Printf("level: %s\n", st.Level)
+	names := make([]string, 0, len(st.Tools))
+	for name := range st.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("tool %s: %s\n", name, st.Tools[name])
+	}
+	return nil
+}
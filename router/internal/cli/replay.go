@@ -0,0 +1,126 @@
+// internal/cli/replay.go
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+)
+
+func newReplayCmd() *cobra.Command {
+	var (
+		server   string
+		tokenEnv string
+		list     bool
+		tool     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay <recording-id>",
+		Short: "Re-run a recorded tool call and compare the output",
+		Long: "Runs the tool again on a running gateway with the input of a recording (tools[*].record: true)\n" +
+			"and compares the new output stream with the recorded one (POST /admin/recordings/<id>/replay).\n" +
+			"Exits with an error when the output or the error code differ.\n" +
+			"--list prints the recording ids instead (of one tool with --tool).",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if list {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token := os.Getenv(tokenEnv)
+			if token == "" {
+				return fmt.Errorf("admin token not set (see --token-env)")
+			}
+			base := strings.TrimSuffix(server, "/") + "/admin/recordings"
+
+			if list {
+				url := base
+				if tool != "" {
+					url += "?tool=" + neturl.QueryEscape(tool)
+				}
+				var resp struct {
+					Recordings []core.RecordingInfo `json:"recordings"`
+				}
+				if err := adminCall(http.MethodGet, url, token, &resp); err != nil {
+					return err
+				}
+				for _, r := range resp.Recordings {
+					fmt.Printf("%s  %s\n", r.ID, r.Tool)
+				}
+				return nil
+			}
+
+			var res core.ReplayResult
+			if err := adminCall(http.MethodPost, base+"/"+args[0]+"/replay", token, &res); err != nil {
+				return err
+			}
+			fmt.Printf("tool: %s\nrecorded events: %d\nreplayed events: %d\nduration: %dms\n",
+				res.Tool, res.RecordedEvents, res.ReplayedEvents, res.DurationMS)
+			if res.Match {
+				fmt.Println("result: match")
+				return nil
+			}
+			if d := res.FirstDiff; d != nil {
+				fmt.Printf("first difference at event %d:\n  recorded: %s\n  replayed: %s\n", d.Index, fmtEvent(d.Recorded), fmtEvent(d.Replayed))
+			}
+			replayCode := ""
+			if res.ReplayError != nil {
+				replayCode = res.ReplayError.Code
+			}
+			if res.RecordedError != replayCode {
+				fmt.Printf("error code: recorded %q, replayed %q\n", res.RecordedError, replayCode)
+			}
+			return fmt.Errorf("replay of %s does not match the recording", res.ID)
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "http://127.0.0.1:8080", "running gateway base URL")
+	cmd.Flags().StringVar(&tokenEnv, "token-env", config.DefaultAdminTokenEnv, "env var holding the admin token")
+	cmd.Flags().BoolVar(&list, "list", false, "list recordings instead of replaying")
+	cmd.Flags().StringVar(&tool, "tool", "", "with --list, only this tool's recordings")
+	return cmd
+}
+
+func fmtEvent(e *core.RecordedEvent) string {
+	if e == nil {
+		return "(end of stream)"
+	}
+	return e.Event + " " + e.Data
+}
+
+// adminCall faz uma chamada admin e decodifica a resposta JSON em out.
+func adminCall(method, url, token string, out any) error {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	// replay executa a tool: o prazo é o timeout dela, não o de uma chamada admin comum
+	resp, err := (&http.Client{Timeout: config.MaxToolTimeout + 30*time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
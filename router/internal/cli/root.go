@@ -4,13 +4,19 @@ package cli
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"mcp-router/internal/app"
+	"mcp-router/internal/builtin"
+	"mcp-router/internal/config"
+	"mcp-router/internal/configsrc"
+	"mcp-router/internal/observability/logging"
 )
 
 var (
@@ -21,6 +27,8 @@ var (
 
 	// global flags
 	cfgPath string
+	profile string
+	refresh time.Duration
 	verbose bool
 	quiet   bool
 )
@@ -37,12 +45,23 @@ func NewRootCmd() *cobra.Command {
 		Use:   "mcp-gw",
 		Short: "mcp-gw (mcp-router gateway)",
 		Long:  "mcp-gw is a gateway for routing MCP traffic via stdio and/or HTTP.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			bootstrapLogging()
+			// --profile vence MCP_GW_PROFILE: o load do config lê o profile do env,
+			// então vale para todos os subcomandos
+			if profile != "" {
+				return os.Setenv(config.ProfileEnv, profile)
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// default behavior: stdio
 			return runStdioDefault(cmd.Context(), cfgPath)
 		},
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		// completion próprio (newCompletionCmd), com --no-descriptions para todos os shells
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
 	}
 
 	// global flags
@@ -50,7 +69,19 @@ func NewRootCmd() *cobra.Command {
 		&cfgPath,
 		"config",
 		defaultConfig,
-		"path to config.yaml",
+		"path to config.yaml, or a remote source (https://..., s3://bucket/key, git::<repo>//<file>?ref=<ref>)",
+	)
+	cmd.PersistentFlags().StringVar(
+		&profile,
+		"profile",
+		"",
+		"config profile to apply (overrides $"+config.ProfileEnv+")",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&refresh,
+		"config-refresh",
+		configsrc.DefaultRefresh,
+		"refresh interval for remote configs (https://, s3://, git::); 0 disables",
 	)
 	cmd.PersistentFlags().BoolVar(
 		&verbose,
@@ -64,6 +95,7 @@ func NewRootCmd() *cobra.Command {
 		false,
 		"suppress non-error logs",
 	)
+	cmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
 
 	// version wiring (supports `mcp-gw --version`)
 	cmd.Version = Version
@@ -73,7 +105,16 @@ func NewRootCmd() *cobra.Command {
 	cmd.AddCommand(
 		newStdioCmd(),
 		newHTTPCmd(),
+		newBenchCmd(),
+		newCompletionCmd(),
+		newExecCmd(),
 		newConfigCmd(),
+		newDocsCmd(),
+		newLogLevelCmd(),
+		newReplayCmd(),
+		newSelftestCmd(),
+		newServiceCmd(),
+		newToolsCmd(),
 		newVersionCmd(),
 	)
 
@@ -89,6 +130,9 @@ func Execute() {
 	)
 	defer stop()
 
+	// _version (tool de diagnóstico) reporta o mesmo build do `mcp-gw version`
+	builtin.Build = builtin.BuildInfo{Version: Version, Commit: Commit, BuildDate: BuildDate}
+
 	root := NewRootCmd()
 	root.SetContext(ctx)
 
@@ -100,13 +144,39 @@ func Execute() {
 }
 
 func runStdioDefault(ctx context.Context, configPath string) error {
-	a, err := app.New(configPath)
+	a, err := app.New(configPath, appOptions(false)...)
 	if err != nil {
 		return err
 	}
 	return a.RunStdio(ctx)
 }
 
+// flagLogLevel é o nível global pedido por --verbose (debug) ou --quiet (error); nil =
+// o do config.
+func flagLogLevel() *slog.Level {
+	var l slog.Level
+	switch {
+	case verbose:
+		l = slog.LevelDebug
+	case quiet:
+		l = slog.LevelError
+	default:
+		return nil
+	}
+	return &l
+}
+
+// bootstrapLogging configura o slog.Default antes de qualquer config: texto no stderr,
+// no nível das flags (default info). Vale para os comandos que não sobem o app (exec,
+// selftest, replay, ...); stdio, http e service trocam pelo bloco logging do config.
+func bootstrapLogging() {
+	level := slog.LevelInfo
+	if l := flagLogLevel(); l != nil {
+		level = *l
+	}
+	logging.New(logging.Config{Mode: logging.ModeText, Level: level})
+}
+
 func versionTemplate() string {
 	return `mcp-gw {{.Version}}
 `
@@ -0,0 +1,70 @@
+// internal/cli/selftest.go
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+)
+
+func newSelftestCmd() *cobra.Command {
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "selftest [tool...]",
+		Short: "Spawn each configured tool and report which ones work",
+		Long: "Exercises the tools from --config (all of them, or the ones named) the way a call would.\n" +
+			"Tools with selftest.input are called with it and must print a line within\n" +
+			"startup_timeout_ms (default 10s); other launcher tools are spawned with stdin closed and\n" +
+			"must not exit with an error in that time. Exits non-zero when any tool fails.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadFromFile(cfgPath)
+			if err != nil {
+				return err
+			}
+			svc := core.New(cfg)
+			defer svc.Close()
+
+			results, err := svc.Selftest(cmd.Context(), args)
+			if err != nil {
+				return err
+			}
+			if err := printSelftest(results, jsonOut); err != nil {
+				return err
+			}
+			failed := 0
+			for _, r := range results {
+				if r.Status == core.SelftestFail {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("selftest: %d of %d tools failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "print results as JSON")
+	return cmd
+}
+
+func printSelftest(results []core.SelftestResult, jsonOut bool) error {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tMODE\tSTATUS\tDURATION\tDETAIL")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%dms\t%s\n", r.Tool, orDash(r.Mode), r.Status, r.DurationMS, r.Detail)
+	}
+	return tw.Flush()
+}
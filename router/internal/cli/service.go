@@ -0,0 +1,116 @@
+// internal/cli/service.go
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"mcp-router/internal/app"
+	"mcp-router/internal/winsvc"
+)
+
+func newServiceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Manage mcp-gw as a Windows service",
+		Long: "Registers the gateway (HTTP mode) with the Windows service manager.\n" +
+			"On Linux use a systemd unit running `mcp-gw http`: socket activation\n" +
+			"(LISTEN_FDS, --addr not needed) and Type=notify are supported.",
+	}
+
+	cmd.AddCommand(
+		newServiceInstallCmd(),
+		newServiceUninstallCmd(),
+		newServiceRunCmd(),
+	)
+	return cmd
+}
+
+func newServiceInstallCmd() *cobra.Command {
+	var name, addr string
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Register the service (automatic start) running this binary in HTTP mode",
+		Long: "The service runs `mcp-gw service run` with the current --config (made absolute:\n" +
+			"services start in the system directory), --profile and --addr. Configure\n" +
+			"logging.output: file, since services have no console.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if addr == "" {
+				return fmt.Errorf("missing required flag: --addr (e.g. --addr :8080)")
+			}
+			exe, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			cfg, err := filepath.Abs(cfgPath)
+			if err != nil {
+				return err
+			}
+
+			svcArgs := []string{"--config", cfg}
+			if profile != "" {
+				svcArgs = append(svcArgs, "--profile", profile)
+			}
+			svcArgs = append(svcArgs, "service", "run", "--name", name, "--addr", addr)
+			if err := winsvc.Install(name, exe, svcArgs); err != nil {
+				return err
+			}
+			fmt.Printf("service %q installed (start it with: sc start %s)\n", name, name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", winsvc.DefaultName, "service name")
+	cmd.Flags().StringVar(&addr, "addr", "", "HTTP listen address (e.g. :8080 or npipe://./pipe/mcp-gw)")
+	return cmd
+}
+
+func newServiceUninstallCmd() *cobra.Command {
+	var name string
+
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and remove the service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := winsvc.Uninstall(name); err != nil {
+				return err
+			}
+			fmt.Printf("service %q removed\n", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", winsvc.DefaultName, "service name")
+	return cmd
+}
+
+func newServiceRunCmd() *cobra.Command {
+	var name, addr string
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run as the service (invoked by the service manager)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// sem systemd, não há socket activation: sem --addr o serviço só falharia no listen
+			if addr == "" {
+				return fmt.Errorf("missing required flag: --addr (e.g. --addr :8080)")
+			}
+			return winsvc.Run(name, func(ctx context.Context) error {
+				a, err := app.New(cfgPath, appOptions(false)...)
+				if err != nil {
+					return err
+				}
+				return a.RunHTTP(ctx, addr)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", winsvc.DefaultName, "service name")
+	cmd.Flags().StringVar(&addr, "addr", "", "HTTP listen address (e.g. :8080 or npipe://./pipe/mcp-gw)")
+	return cmd
+}
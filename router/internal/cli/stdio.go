@@ -8,16 +8,32 @@ import (
 )
 
 func newStdioCmd() *cobra.Command {
+	var selftest bool
+
 	cmd := &cobra.Command{
 		Use:   "stdio",
 		Short: "Run MCP gateway in stdio mode (default)",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			a, err := app.New(cfgPath)
+			a, err := app.New(cfgPath, appOptions(selftest)...)
 			if err != nil {
 				return err
 			}
 			return a.RunStdio(cmd.Context())
 		},
 	}
+
+	cmd.Flags().BoolVar(&selftest, "selftest-on-start", false, "run the selftest on every tool before serving; refuse to start if any fails")
 	return cmd
 }
+
+// appOptions são as opções do app comuns a stdio, http e service.
+func appOptions(selftest bool) []app.Option {
+	opts := []app.Option{app.WithConfigRefresh(refresh)}
+	if l := flagLogLevel(); l != nil {
+		opts = append(opts, app.WithLogLevel(*l))
+	}
+	if selftest {
+		opts = append(opts, app.WithSelftestOnStart())
+	}
+	return opts
+}
@@ -0,0 +1,127 @@
+// internal/cli/tools.go
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+)
+
+func newToolsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tools",
+		Short: "Tool utilities",
+	}
+
+	cmd.AddCommand(
+		newToolsPlanCmd(),
+	)
+	return cmd
+}
+
+func newToolsPlanCmd() *cobra.Command {
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "plan <name>",
+		Short: "Print the exact spawn plan of a tool without executing it",
+		Long: "Resolves the tool from --config exactly as a call would: command line (docker args for\n" +
+			"containers), env added by the gateway, user, mounts, timeouts and hardening flags.\n" +
+			"Nothing is started. Same output as POST /mcp/<tool>?dry_run=1 on a running gateway.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadFromFile(cfgPath)
+			if err != nil {
+				return err
+			}
+			cfg = cfg.WithDiagnosticTools()
+			tool, ok := cfg.Tools[args[0]]
+			if !ok {
+				return fmt.Errorf("tool %q not found in %s", args[0], cfgPath)
+			}
+			plan, err := core.PlanTool(cmd.Context(), cfg, args[0], tool)
+			if err != nil {
+				return err
+			}
+			return printToolPlan(plan, jsonOut)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "print plan as JSON")
+	return cmd
+}
+
+func printToolPlan(plan core.ToolPlan, jsonOut bool) error {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+
+	fmt.Printf("tool: %s\nruntime: %s\n", plan.Tool, plan.Runtime)
+	if plan.Mode != "" {
+		fmt.Printf("mode: %s\n", plan.Mode)
+	}
+	switch {
+	case plan.Endpoint != "":
+		fmt.Printf("endpoint: %s\n", plan.Endpoint)
+	case plan.Builtin != "":
+		fmt.Printf("builtin: %s\n", plan.Builtin)
+	}
+	if sp := plan.Spawn; sp != nil {
+		quoted := make([]string, len(sp.Args))
+		for i, a := range sp.Args {
+			quoted[i] = shellQuote(a)
+		}
+		fmt.Printf("command: %s\n", strings.Join(quoted, " "))
+		if sp.Path != "" && (len(sp.Args) == 0 || sp.Path != sp.Args[0]) {
+			fmt.Printf("path: %s\n", sp.Path)
+		}
+		if sp.Dir != "" {
+			fmt.Printf("dir: %s\n", sp.Dir)
+		}
+		if sp.User != "" {
+			fmt.Printf("user: %s\n", sp.User)
+		}
+		for _, e := range sp.Env {
+			fmt.Printf("env: %s\n", e)
+		}
+		for _, m := range sp.Mounts {
+			fmt.Printf("mount: %s\n", m)
+		}
+		h := sp.Hardening
+		fmt.Printf("hardening: network=%s read_only=%t no_new_privileges=%t cap_drop=%s seccomp=%s apparmor=%s container_runtime=%s sandbox=%s\n",
+			orDash(h.Network), h.ReadOnly, h.NoNewPrivileges, orDash(h.CapDrop), orDash(h.SeccompProfile),
+			orDash(h.AppArmorProfile), orDash(h.ContainerRuntime), orDash(h.Sandbox))
+		for _, w := range sp.Warnings {
+			fmt.Printf("warning: %s\n", w)
+		}
+	}
+	fmt.Printf("timeout_ms: %d\nstartup_timeout_ms: %d\nshutdown_grace_ms: %d\nshutdown: %s\nqueue_timeout_ms: %d\nmax_concurrent: %d\nreuse: %t\n",
+		plan.TimeoutMS, plan.StartupTimeoutMS, plan.ShutdownGraceMS, plan.Shutdown, plan.QueueTimeoutMS, plan.MaxConcurrent, plan.Reuse)
+	if plan.Workspace != "" {
+		fmt.Printf("workspace: %s\n", plan.Workspace)
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// shellQuote cita a para colar o comando num shell (aspas simples só quando precisa).
+func shellQuote(a string) string {
+	if a != "" && !strings.ContainsAny(a, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return a
+	}
+	return "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+}
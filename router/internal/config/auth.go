@@ -0,0 +1,382 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Auth autentica os clientes de /mcp/* e /workspace/*. Sem api_keys, oidc nem hmac o
+// acesso continua aberto (o deploy típico fica atrás de túnel/proxy ou em loopback).
+type Auth struct {
+	// api_keys: chaves de máquina ("Authorization: Bearer <key>" ou "X-API-Key: <key>").
+	// Com pelo menos uma chave, requests sem chave válida recebem 401.
+	APIKeys []APIKey `yaml:"api_keys"`
+
+	// oidc: bearer tokens (JWT RS256) de um provedor de identidade corporativo.
+	OIDC OIDC `yaml:"oidc"`
+
+	// hmac: requests assinadas (X-MCP-Signature) para clientes que não podem mandar
+	// segredo em header.
+	HMAC HMAC `yaml:"hmac"`
+
+	// public_roles: o que requests sem credencial podem fazer com auth ligado (ex: [read]
+	// para um catálogo aberto). Default: nada; admin nunca é público. /healthz, /readyz e
+	// /livez não dependem disso: os probes ficam sempre fora da auth.
+	PublicRoles []string `yaml:"public_roles"`
+}
+
+// Papéis de uma credencial: invoke chama tools (/mcp/<tool>, /mcp, sessões, uploads),
+// read consulta (/mcp/tools, stats, /version, /metrics, downloads) e admin abre
+// /admin/*.
+const (
+	RoleInvoke = "invoke"
+	RoleRead   = "read"
+	RoleAdmin  = "admin"
+)
+
+// Roles são os papéis válidos.
+var Roles = []string{RoleInvoke, RoleRead, RoleAdmin}
+
+// DefaultRoles são os papéis de uma credencial sem roles.
+var DefaultRoles = []string{RoleInvoke, RoleRead}
+
+// DefaultOIDCRolesClaim é a claim com os papéis do token.
+const DefaultOIDCRolesClaim = "mcp.roles"
+
+// RolesEffective retorna os papéis configurados ou DefaultRoles.
+func RolesEffective(roles []string) []string {
+	if len(roles) == 0 {
+		return DefaultRoles
+	}
+	return roles
+}
+
+// HasAdminCredential diz se alguma credencial estática (api_keys, hmac) tem o papel
+// admin. Tokens OIDC ganham papéis pela claim, então oidc ligado também conta.
+func (a Auth) HasAdminCredential() bool {
+	for _, k := range a.APIKeys {
+		if slices.Contains(k.Roles, RoleAdmin) {
+			return true
+		}
+	}
+	for _, c := range a.HMAC.Clients {
+		if slices.Contains(c.Roles, RoleAdmin) {
+			return true
+		}
+	}
+	return a.OIDC.Enabled()
+}
+
+func validRoles(roles []string) error {
+	for _, r := range roles {
+		if !slices.Contains(Roles, r) {
+			return fmt.Errorf("unknown role %q (use %s)", r, strings.Join(Roles, ", "))
+		}
+	}
+	return nil
+}
+
+// DefaultHMACWindowMS é a janela de replay das requests assinadas (±5min do relógio).
+const DefaultHMACWindowMS = 300_000
+
+// HMAC autentica requests assinadas com HMAC-SHA256 por um segredo compartilhado com o
+// cliente. A assinatura cobre método, path, timestamp e SHA-256 do body; fora da janela
+// ou repetida dentro dela, a request é recusada.
+type HMAC struct {
+	Clients  []HMACClient `yaml:"clients"`
+	WindowMS int          `yaml:"window_ms"` // default 300000
+}
+
+// HMACClient é um cliente com segredo compartilhado e o escopo de tools dele (mesma regra
+// de api_keys).
+type HMACClient struct {
+	Name      string   `yaml:"name"`
+	SecretEnv string   `yaml:"secret_env"`
+	Tools     []string `yaml:"tools"`
+	Tags      []string `yaml:"tags"`
+	Roles     []string `yaml:"roles"` // default invoke e read
+
+	Workspaces []string `yaml:"workspaces"` // ver APIKey.Workspaces
+}
+
+// Enabled diz se requests assinadas são aceitas.
+func (h HMAC) Enabled() bool {
+	return len(h.Clients) > 0
+}
+
+// Window retorna a tolerância do timestamp das requests assinadas.
+func (h HMAC) Window() time.Duration {
+	if h.WindowMS <= 0 {
+		return DefaultHMACWindowMS * time.Millisecond
+	}
+	return time.Duration(h.WindowMS) * time.Millisecond
+}
+
+// Client retorna o cliente pelo nome.
+func (h HMAC) Client(name string) (HMACClient, bool) {
+	for _, c := range h.Clients {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return HMACClient{}, false
+}
+
+// Secret é o segredo do cliente ("" quando a variável não está definida).
+func (c HMACClient) Secret() string {
+	return os.Getenv(c.SecretEnv)
+}
+
+func (h HMAC) validate() error {
+	seen := make(map[string]bool, len(h.Clients))
+	for i, c := range h.Clients {
+		if !validNamespace(c.Name) {
+			return fmt.Errorf("config: auth.hmac.clients[%d].name %q is invalid (use letters, digits, - and _)", i, c.Name)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("config: auth.hmac.clients[%s] is defined twice", c.Name)
+		}
+		seen[c.Name] = true
+		if c.SecretEnv == "" {
+			return fmt.Errorf("config: auth.hmac.clients[%s].secret_env is required", c.Name)
+		}
+		if err := validScopePatterns(c.Tools); err != nil {
+			return fmt.Errorf("config: auth.hmac.clients[%s].tools: %w", c.Name, err)
+		}
+		if err := validRoles(c.Roles); err != nil {
+			return fmt.Errorf("config: auth.hmac.clients[%s].roles: %w", c.Name, err)
+		}
+	}
+	if h.WindowMS < 0 {
+		return fmt.Errorf("config: auth.hmac.window_ms must be >= 0")
+	}
+	return nil
+}
+
+// validScopePatterns confere os globs de tools de uma credencial.
+func validScopePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil || p == "" {
+			return fmt.Errorf("invalid pattern %q", p)
+		}
+	}
+	return nil
+}
+
+// DefaultOIDCToolsClaim é a claim com as tools que o token pode chamar.
+const DefaultOIDCToolsClaim = "mcp.tools"
+
+// DefaultOIDCWorkspacesClaim é a claim com os workspaces nomeados que o token pode usar.
+const DefaultOIDCWorkspacesClaim = "mcp.workspaces"
+
+// DefaultOIDCJWKSRefreshMS é a validade do cache das chaves públicas (JWKS).
+const DefaultOIDCJWKSRefreshMS = 3_600_000
+
+// OIDC valida bearer tokens emitidos pelo provedor de identidade (RS256, chaves do
+// jwks_url). A claim tools_claim (lista ou string separada por espaço, com nomes, globs
+// ou "*") diz o que o token pode chamar; token sem a claim não chama nenhuma tool.
+type OIDC struct {
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	JWKSURL  string `yaml:"jwks_url"`
+
+	ToolsClaim      string `yaml:"tools_claim"`      // default: mcp.tools
+	RolesClaim      string `yaml:"roles_claim"`      // default: mcp.roles (sem a claim: invoke e read)
+	WorkspacesClaim string `yaml:"workspaces_claim"` // default: mcp.workspaces (sem a claim: só o default)
+	ClockSkewMS     int    `yaml:"clock_skew_ms"`    // tolerância em exp/nbf/iat (default 60000)
+	JWKSRefreshMS   int    `yaml:"jwks_refresh_ms"`  // cache do JWKS (default 1h)
+}
+
+// Enabled diz se bearer tokens OIDC são aceitos.
+func (o OIDC) Enabled() bool {
+	return o.Issuer != ""
+}
+
+// ToolsClaimEffective retorna a claim de tools (default mcp.tools).
+func (o OIDC) ToolsClaimEffective() string {
+	if o.ToolsClaim == "" {
+		return DefaultOIDCToolsClaim
+	}
+	return o.ToolsClaim
+}
+
+// WorkspacesClaimEffective retorna a claim de workspaces (default mcp.workspaces).
+func (o OIDC) WorkspacesClaimEffective() string {
+	if o.WorkspacesClaim == "" {
+		return DefaultOIDCWorkspacesClaim
+	}
+	return o.WorkspacesClaim
+}
+
+// RolesClaimEffective retorna a claim de papéis (default mcp.roles).
+func (o OIDC) RolesClaimEffective() string {
+	if o.RolesClaim == "" {
+		return DefaultOIDCRolesClaim
+	}
+	return o.RolesClaim
+}
+
+// ClockSkew retorna a tolerância de relógio na validação de exp/nbf/iat.
+func (o OIDC) ClockSkew() time.Duration {
+	if o.ClockSkewMS <= 0 {
+		return time.Minute
+	}
+	return time.Duration(o.ClockSkewMS) * time.Millisecond
+}
+
+// JWKSRefresh retorna por quanto tempo o JWKS baixado vale.
+func (o OIDC) JWKSRefresh() time.Duration {
+	if o.JWKSRefreshMS <= 0 {
+		return DefaultOIDCJWKSRefreshMS * time.Millisecond
+	}
+	return time.Duration(o.JWKSRefreshMS) * time.Millisecond
+}
+
+func (o OIDC) validate() error {
+	if !o.Enabled() {
+		if o.Audience != "" || o.JWKSURL != "" {
+			return fmt.Errorf("config: auth.oidc needs issuer")
+		}
+		return nil
+	}
+	if o.Audience == "" {
+		return fmt.Errorf("config: auth.oidc.audience is required")
+	}
+	u, err := url.Parse(o.JWKSURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("config: auth.oidc.jwks_url must be an http(s) URL")
+	}
+	if o.ClockSkewMS < 0 || o.JWKSRefreshMS < 0 {
+		return fmt.Errorf("config: auth.oidc.clock_skew_ms/jwks_refresh_ms must be >= 0")
+	}
+	return nil
+}
+
+// APIKey é uma chave de cliente com o escopo e as cotas dela. O segredo nunca fica no
+// config: vem da variável key_env ou é conferido pelo key_sha256 (hex do SHA-256).
+type APIKey struct {
+	Name      string `yaml:"name"`
+	KeyEnv    string `yaml:"key_env"`
+	KeySHA256 string `yaml:"key_sha256"`
+
+	// tools/tags: o que a chave pode chamar (nome, glob tipo "fs_*" ou tag da tool).
+	// Os dois vazios = todas as tools.
+	Tools []string `yaml:"tools"`
+	Tags  []string `yaml:"tags"`
+
+	// roles: papéis da chave (default invoke e read).
+	Roles []string `yaml:"roles"`
+
+	// workspaces: nomes de workspaces: que a chave pode escolher com X-MCP-Workspace ("*" =
+	// todos). Vazio = só o workspace_root.
+	Workspaces []string `yaml:"workspaces"`
+
+	DailyQuota    int `yaml:"daily_quota"`    // chamadas por dia UTC (0 = sem cota)
+	MaxConcurrent int `yaml:"max_concurrent"` // execuções simultâneas (0 = sem limite)
+}
+
+// Enabled diz se o gateway exige autenticação nos endpoints de tools.
+func (a Auth) Enabled() bool {
+	return len(a.APIKeys) > 0 || a.OIDC.Enabled() || a.HMAC.Enabled()
+}
+
+// Key retorna a chave pelo nome.
+func (a Auth) Key(name string) (APIKey, bool) {
+	for _, k := range a.APIKeys {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// Hash é o SHA-256 (hex) do segredo da chave: key_sha256 ou o da variável key_env
+// ("" quando a variável não está definida; a chave fica inutilizável).
+func (k APIKey) Hash() string {
+	if k.KeySHA256 != "" {
+		return strings.ToLower(k.KeySHA256)
+	}
+	secret := os.Getenv(k.KeyEnv)
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Permits diz se a chave pode chamar a tool name (com as tags dela).
+func (k APIKey) Permits(name string, tags []string) bool {
+	return ScopePermits(k.Tools, k.Tags, name, tags)
+}
+
+// WorkspacePermitted é a regra dos workspaces de uma credencial: o nome precisa estar na
+// lista (ou "*"); o workspace_root ("") é sempre permitido.
+func WorkspacePermitted(allowed []string, name string) bool {
+	return name == "" || slices.Contains(allowed, name) || slices.Contains(allowed, "*")
+}
+
+// ScopePermits é a regra de escopo de tools: sem tools nem tags, tudo; senão a tool
+// precisa casar com um nome/glob de tools ou ter uma das tags.
+func ScopePermits(tools, tags []string, name string, toolTags []string) bool {
+	if len(tools) == 0 && len(tags) == 0 {
+		return true
+	}
+	for _, p := range tools {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	for _, t := range toolTags {
+		if slices.Contains(tags, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Auth) validate() error {
+	seen := make(map[string]bool, len(a.APIKeys))
+	for i, k := range a.APIKeys {
+		if !validNamespace(k.Name) {
+			return fmt.Errorf("config: auth.api_keys[%d].name %q is invalid (use letters, digits, - and _)", i, k.Name)
+		}
+		if seen[k.Name] {
+			return fmt.Errorf("config: auth.api_keys[%s] is defined twice", k.Name)
+		}
+		seen[k.Name] = true
+		if (k.KeyEnv == "") == (k.KeySHA256 == "") {
+			return fmt.Errorf("config: auth.api_keys[%s] needs exactly one of key_env or key_sha256", k.Name)
+		}
+		if k.KeySHA256 != "" {
+			if b, err := hex.DecodeString(k.KeySHA256); err != nil || len(b) != sha256.Size {
+				return fmt.Errorf("config: auth.api_keys[%s].key_sha256 must be a hex SHA-256", k.Name)
+			}
+		}
+		if err := validScopePatterns(k.Tools); err != nil {
+			return fmt.Errorf("config: auth.api_keys[%s].tools: %w", k.Name, err)
+		}
+		if err := validRoles(k.Roles); err != nil {
+			return fmt.Errorf("config: auth.api_keys[%s].roles: %w", k.Name, err)
+		}
+		if k.DailyQuota < 0 || k.MaxConcurrent < 0 {
+			return fmt.Errorf("config: auth.api_keys[%s].daily_quota/max_concurrent must be >= 0", k.Name)
+		}
+	}
+	if err := validRoles(a.PublicRoles); err != nil {
+		return fmt.Errorf("config: auth.public_roles: %w", err)
+	}
+	if slices.Contains(a.PublicRoles, RoleAdmin) {
+		return fmt.Errorf("config: auth.public_roles cannot include admin")
+	}
+	if err := a.OIDC.validate(); err != nil {
+		return err
+	}
+	return a.HMAC.validate()
+}
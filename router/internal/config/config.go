@@ -1,11 +1,23 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"mcp-router/internal/jsonschema"
 )
 
 const (
@@ -19,169 +31,2244 @@ const (
 	// Concurrency defaults
 	DefaultMaxConcurrent  = 1
 	MaxAllowedConcurrency = 32 // proteção contra configs absurdas
+	MaxGlobalConcurrency  = 4096
+
+	// Remote: teto de retries (evita amplificar carga em upstream instável)
+	MaxRemoteRetries = 5
+
+	// Retry de falhas transitórias (antes da 1ª linha de saída)
+	MaxRetryAttempts    = 5
+	DefaultRetryBackoff = 200 * time.Millisecond
+	MaxRetryBackoff     = 30 * time.Second
+
+	// Eventos de execução: fila por sink (eventos) antes de descartar
+	DefaultEventBuffer = 1024
+	MaxEventBuffer     = 65536
+	RetryOnSpawnError  = "spawn_error"
+	RetryOnNonzeroExit = "nonzero_exit"
+	// startup_timeout só é repetido quando listado explicitamente em retry.on
+	RetryOnStartupTimeout = "startup_timeout"
+
+	// Builtin kv: limites por namespace (identidade/sessão)
+	DefaultKVMaxKeys       = 1000
+	DefaultKVMaxValueBytes = 64 << 10 // 64KiB
+	MaxKVValueBytes        = 1 << 20  // 1MiB (limite do body HTTP)
+	DefaultKVTTL           = 24 * time.Hour
+	MaxKVTTL               = 30 * 24 * time.Hour
+
+	// stream_body: teto do body repassado em stream para o stdin (max_body_bytes)
+	DefaultMaxBodyBytes = 1 << 30 // 1GiB
+	MaxMaxBodyBytes     = 64 << 30
+
+	// files: upload/download no workspace (/workspace/files/<path>)
+	DefaultMaxUploadBytes = 256 << 20 // 256MiB
+
+	// egress_proxy: proxy de saída das tools native (egress_allow)
+	DefaultEgressProxyListen = "127.0.0.1:0"
+
+	// Streaming HTTP: tetos do tuning de flush/buffer por tool
+	MaxFlushInterval = 5 * time.Second
+	MaxWriteBuffer   = 1 << 20 // 1MiB
+
+	// Streaming HTTP: buffer de saída por stream (linhas) e política de cliente lento
+	DefaultStreamBufferLines = 256
+	MaxStreamBufferLines     = 65536
+	DefaultSlowClientPolicy  = "block" // block | drop | disconnect
+	MaxStreamResumeWindow    = 10 * time.Minute
 
 	// Hardening defaults (somente container)
 	DefaultDockerNetwork = "none" // "none" | "bridge"
 	DefaultReadOnly      = true
+
+	// Pull de imagens (pull_policy)
+	PullAlways        = "always"
+	PullIfNotPresent  = "if-not-present"
+	PullNever         = "never"
+	DefaultPullPolicy = PullIfNotPresent
+
+	// Formato do stdout das tools (output)
+	OutputText   = "text"
+	OutputBinary = "binary"
+
+	// Validação das linhas do stdout contra output_schema
+	OutputValidationWarn   = "warn"
+	OutputValidationStrict = "strict"
+
+	// Linhas gigantes no stdout (max_line_bytes / long_lines)
+	DefaultMaxLineBytes = 4 << 20    // 4MiB
+	MaxMaxLineBytes     = 64 << 20   // 64MiB
+	LongLinesSplit      = "split"    // event: continuation em pedaços (default)
+	LongLinesTruncate   = "truncate" // só o início + event: line_truncated
+
+	// Classes de prioridade (priority): pesos na admissão quando o gateway satura
+	PriorityHigh   = "high"
+	PriorityNormal = "normal" // default
+	PriorityLow    = "low"
+
+	// Reuso de containers (reuse)
+	DefaultReuseMaxIdle = 5 * time.Minute
+	MaxReuseMaxIdle     = time.Hour
+	DefaultReuseMaxAge  = time.Hour
+	MaxReuseMaxAge      = 24 * time.Hour
+
+	// Sessões de tools daemon (session)
+	DefaultSessionIdle = 10 * time.Minute
+	MaxSessionIdle     = 24 * time.Hour
+	DefaultMaxSessions = 16
+
+	// Health checks de sessões reutilizadas (health)
+	DefaultHealthInterval = 30 * time.Second
+	MinHealthInterval     = 100 * time.Millisecond
+	MaxHealthInterval     = time.Hour
+	DefaultHealthTimeout  = 5 * time.Second
+	MaxHealthRestartDelay = time.Minute
+
+	// sandbox de tools native
+	SandboxBwrap = "bwrap"
+
+	// seccomp_profile embutido no gateway
+	SeccompProfileStrict = "strict"
+
+	// Containers sem user configurado rodam como nobody (nunca root por default)
+	DefaultContainerUser = "65534:65534"
+
+	// Acesso da tool ao workspace (workspace_access)
+	WorkspaceAccessRW      = "rw"
+	WorkspaceAccessRO      = "ro"
+	WorkspaceAccessNone    = "none"
+	DefaultWorkspaceAccess = WorkspaceAccessRW
+
+	// workspace: ephemeral = um diretório temporário por request em <workspace_root>/.ephemeral
+	WorkspaceShared    = "shared"
+	WorkspaceEphemeral = "ephemeral"
+	EphemeralDir       = ".ephemeral"
+
+	// workspace_retain: quando o diretório efêmero sobrevive à request (debug)
+	WorkspaceRetainNever     = "never"
+	WorkspaceRetainOnFailure = "on_failure"
+	WorkspaceRetainAlways    = "always"
+
+	// Shutdown defaults
+	DefaultShutdownDrain = 10 * time.Second
+	MaxShutdownDrain     = 5 * time.Minute
+
+	// SIGTERM -> SIGKILL por tool (shutdown_grace_ms)
+	DefaultShutdownGrace = 800 * time.Millisecond
+	MaxShutdownGrace     = time.Minute
+
+	// Como a tool é encerrada no fim da request e no cancelamento (shutdown)
+	ShutdownEOF     = "eof"     // fecha o stdin e espera a saída; SIGKILL no fim da grace
+	ShutdownSIGTERM = "sigterm" // SIGTERM no grupo; SIGKILL no fim da grace
+	ShutdownBoth    = "both"    // default: EOF e SIGTERM juntos
+
+	// Cancelamento em duas fases (cancellation: cooperative): mensagem no stdin, espera
+	// cancel_grace_ms e só então o kill. {request_id} na mensagem vira o id da request.
+	CancellationKill        = "kill" // default: direto para SIGTERM/SIGKILL
+	CancellationCooperative = "cooperative"
+	DefaultCancelGrace      = 2 * time.Second
+	DefaultCancelMessage    = `{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":"{request_id}"}}`
+
+	// Reaper de containers órfãos (orphan_gc_interval_ms)
+	DefaultOrphanGCInterval = 5 * time.Minute
+	MinOrphanGCInterval     = 10 * time.Second
+	MaxOrphanGCInterval     = 24 * time.Hour
+
+	// Auditoria de processos deixados para trás por tools (process_audit)
+	DefaultProcessAuditInterval = 30 * time.Second
+	MinProcessAuditInterval     = time.Second
+	MaxProcessAuditInterval     = time.Hour
+
+	// Admin defaults
+	DefaultAdminTokenEnv = "MCP_GW_ADMIN_TOKEN"
+
+	// ConfigTokenEnv: bearer token das fontes de config http(s) (ver configsrc)
+	ConfigTokenEnv = "MCP_GW_CONFIG_TOKEN"
+
+	// URLs assinadas de uso único (emitidas via admin)
+	DefaultSignedURLTTL = 5 * time.Minute
+	MaxSignedURLTTL     = 24 * time.Hour
+
+	// Storage defaults
+	DefaultStorageBackend = "local"
+	DefaultStoragePath    = "/var/lib/mcp-gw"
+	DefaultS3Region       = "us-east-1"
+
+	// Logger do gateway (logging.output: file)
+	DefaultLogMaxSizeMB  = 100
+	MaxLogSizeMB         = 10240
+	DefaultLogMaxBackups = 5
+	MaxLogBackups        = 1000
+
+	DefaultLogCaptureRecords = 200
+	MaxLogCaptureRequests    = 10000
+	MaxLogCaptureRecords     = 10000
 )
 
 type Tool struct {
+	// Catálogo (GET /mcp/tools): só metadados para clientes e UIs, não afetam a execução.
+	Description string   `yaml:"description"`
+	Version     string   `yaml:"version"`
+	Tags        []string `yaml:"tags"`
+
+	// aliases: nomes antigos que continuam roteando para esta tool (renomear sem quebrar
+	// clientes); chamadas por alias recebem aviso de depreciação (header Deprecation,
+	// event deprecation no stdio). deprecated: a própria tool está depreciada (mesmo aviso).
+	Aliases    []string `yaml:"aliases"`
+	Deprecated bool     `yaml:"deprecated"`
+
+	// group: herda os settings de groups.<nome> (sobre defaults:)
+	Group string `yaml:"group"`
+
+	// input_schema: JSON Schema do input (inline) ou input_schema_file: arquivo JSON/YAML
+	// com o schema (relativo ao diretório do config). O core valida o input antes do
+	// spawn: violação = 422 schema_violation sem gastar um processo.
+	InputSchema     map[string]any `yaml:"input_schema"`
+	InputSchemaFile string         `yaml:"input_schema_file"`
+
+	// output_schema / output_schema_file: contrato de cada linha do stdout (JSON Schema).
+	// output_validation: warn (default; log + event: warning) | strict (viola = erro
+	// output_violation e o stream termina). Sem output_schema não há validação.
+	OutputSchema     map[string]any `yaml:"output_schema"`
+	OutputSchemaFile string         `yaml:"output_schema_file"`
+	OutputValidation string         `yaml:"output_validation"`
+
 	// Execução
-	Runtime string `yaml:"runtime"` // native | container
-	Mode    string `yaml:"mode"`    // launcher | daemon (daemon reservado)
+	Runtime string `yaml:"runtime"` // native | container | remote | builtin
+	Mode    string `yaml:"mode"`    // launcher | daemon (processo por sessão; ver Session)
 
 	// Native
 	Cmd  string   `yaml:"cmd"`
 	Args []string `yaml:"args"`
+	// sandbox: bwrap = confina a tool com bubblewrap (só workspace + tools_root visíveis,
+	// sem rede salvo docker_network: bridge). Vazio = processo direto no host.
+	Sandbox string `yaml:"sandbox"`
 
 	// Container
 	Image string `yaml:"image"`
 
+	// Workspace visível para a tool (native e container), relativos e validados pelo sandbox:
+	// workspace_subdir: expõe só <workspace_root>/<subdir> (container monta só ele em /workspaces)
+	// workdir: diretório de trabalho dentro do workspace da tool (native: cmd.Dir; container: -w)
+	WorkspaceSubdir string `yaml:"workspace_subdir"`
+	Workdir         string `yaml:"workdir"`
+	// workspace_access: rw | ro | none (default: rw)
+	// container: mount normal, ":ro" ou sem mount; native: só convenção (WORKSPACE_ACCESS no env),
+	// o enforcement de ro fica com as permissões do diretório
+	WorkspaceAccess string `yaml:"workspace_access"`
+	// workspace: shared | ephemeral (default: shared). ephemeral cria um diretório por request
+	// em <workspace_root>/.ephemeral, expõe só ele (como um workspace_subdir) e o remove no fim;
+	// workspace_retain: never | on_failure | always mantém o diretório para inspeção.
+	Workspace       string `yaml:"workspace"`
+	WorkspaceRetain string `yaml:"workspace_retain"`
+
+	// user: "uid[:gid]" com que a tool roda (container: --user; native: setuid/setgid).
+	// Sobrescreve o user global; "0:0" roda como root explicitamente.
+	User string `yaml:"user"`
+
+	// reuse: mantém o container vivo entre requests (somente container). Presente = ligado.
+	Reuse *Reuse `yaml:"reuse"`
+
+	// session: limites das sessões de tools mode: daemon (um processo por sessão, criado
+	// em POST /mcp/sessions e endereçado pelo header Mcp-Session-Id)
+	Session Session `yaml:"session"`
+
+	// health: checagem periódica das sessões reutilizadas (exige reuse), com restart automático.
+	Health *Health `yaml:"health"`
+
+	// Volumes extras (somente container): caches de modelo, diretórios de dados etc.
+	// O host precisa estar dentro de um dos mount_roots globais.
+	Mounts []Mount `yaml:"mounts"`
+
+	// Remote (endpoint MCP HTTP/SSE proxied)
+	Endpoint string            `yaml:"endpoint"` // http(s)://.../mcp/<tool>
+	Headers  map[string]string `yaml:"headers"`  // injetados na request; valores aceitam ${ENV}
+	Retries  int               `yaml:"retries"`  // tentativas extras antes do 1º byte (conexão/429/5xx)
+
+	// Builtin (implementada dentro do gateway, sem processo)
+	Builtin string    `yaml:"builtin"` // kv
+	KV      KVOptions `yaml:"kv"`
+
+	// Federação: a tool é um servidor MCP completo cujo tools/list é agregado
+	// com prefixo "<namespace>." (default namespace: nome da tool)
+	Federate  bool   `yaml:"federate"`
+	Namespace string `yaml:"namespace"`
+
 	// Limites
 	TimeoutMS     int `yaml:"timeout_ms"`     // opcional; se 0 usa default
 	MaxConcurrent int `yaml:"max_concurrent"` // opcional; se 0 usa default
 
-	// Hardening (somente container)
+	// max_timeout_ms: teto do timeout pedido pelo cliente (header X-MCP-Timeout) nesta
+	// tool. 0 = o cliente só pode encurtar o timeout_ms, nunca alongar.
+	MaxTimeoutMS int `yaml:"max_timeout_ms"`
+
+	// deadline_field: campo injetado no input (objeto JSON) com o prazo da execução em
+	// epoch Unix ms, o mesmo de MCP_DEADLINE_UNIX_MS no env. Vazio = não injeta.
+	DeadlineField string `yaml:"deadline_field"`
+
+	// request_id_field: campo injetado no input (objeto JSON) com o request_id, o mesmo
+	// de MCP_REQUEST_ID no env. Vazio = não injeta.
+	RequestIDField string `yaml:"request_id_field"`
+
+	// startup_timeout_ms: prazo para a 1ª linha de stdout (0 = só timeout_ms). Estourou:
+	// processo morto com tool_startup_timeout (spawn travado, não execução longa).
+	StartupTimeoutMS int `yaml:"startup_timeout_ms"`
+
+	// selftest: como `mcp-gw selftest` (e --selftest-on-start) exercita a tool. Com input,
+	// a tool é chamada com ele e precisa emitir uma linha dentro do startup_timeout; sem
+	// input, o processo só é iniciado (spawn a seco) e não pode sair com erro nesse prazo.
+	Selftest ToolSelftest `yaml:"selftest"`
+
+	// shutdown_grace_ms: espera entre SIGTERM e SIGKILL ao matar a tool (default
+	// DefaultShutdownGrace). Tools que precisam gravar estado (ex: índice do git) pedem mais.
+	ShutdownGraceMS int `yaml:"shutdown_grace_ms"`
+
+	// shutdown: eof | sigterm | both (default). Como o processo é encerrado no fim da
+	// request e no cancelamento: servidores MCP que saem no EOF do stdin usam eof (sem
+	// sinal), os que ignoram o EOF usam sigterm. Em todos, SIGKILL após shutdown_grace_ms.
+	Shutdown string `yaml:"shutdown"`
+
+	// cancellation: kill (default) | cooperative. Cooperative: no cancelamento (cliente
+	// saiu, DELETE, timeout), a tool recebe cancel_message no stdin e tem cancel_grace_ms
+	// para sair sozinha antes do kill. O stdin fica aberto depois do input (sem EOF): a
+	// tool precisa terminar sozinha ao responder.
+	Cancellation  string `yaml:"cancellation"`
+	CancelMessage string `yaml:"cancel_message"`  // default DefaultCancelMessage
+	CancelGraceMS int    `yaml:"cancel_grace_ms"` // default DefaultCancelGrace
+
+	// tty: stdout num pty (native) / docker run -t (container), para CLIs que bufferizam ou
+	// mudam de formato sem terminal. O "\r" do terminal é removido do fim das linhas.
+	TTY bool `yaml:"tty"`
+
+	// output: text (default; uma mensagem por linha) | binary (stdout em pedaços base64,
+	// entregues como event: data {"seq", "data"}; para tools que emitem bytes arbitrários)
+	Output string `yaml:"output"`
+
+	// interactive: aceita input em stream (POST com Content-Type application/x-ndjson): a 1ª
+	// linha é o input, as seguintes vão para o stdin da tool até o cliente encerrar o body.
+	// Sem retry (as mensagens já consumidas não podem ser reenviadas).
+	Interactive bool `yaml:"interactive"`
+
+	// stream_body: bodies grandes (chunked, acima do limite de 1MiB ou
+	// application/octet-stream) vão direto para o stdin da tool à medida que chegam, sem
+	// buffer nem validação (input_schema, deadline_field), até max_body_bytes (default
+	// DefaultMaxBodyBytes); o stdin fecha no fim do body. Sem retry.
+	StreamBody   bool  `yaml:"stream_body"`
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+
+	// queue_timeout_ms: espera máxima por slot quando max_concurrent está cheio
+	// (0 = fail-fast/busy). A fila é justa entre identidades (round-robin).
+	QueueTimeoutMS int `yaml:"queue_timeout_ms"`
+
+	// priority: high | normal (default) | low. Com limits.max_concurrent cheio, chamadas
+	// com queue_timeout_ms esperam numa fila do gateway e os slots que liberam vão para as
+	// classes por peso (high 4 : normal 2 : low 1): tools interativas passam na frente
+	// de batch sem que low fique sem nenhum slot.
+	Priority string `yaml:"priority"`
+
+	// log_level: debug | info | warn | error. Nível dos logs desta tool no lugar do global
+	// (depurar uma tool barulhenta sem ligar debug no gateway inteiro).
+	LogLevel string `yaml:"log_level"`
+
+	// Retry transparente de falhas antes da 1ª linha (ex: crash no startup do npx)
+	Retry Retry `yaml:"retry"`
+
+	// redaction: detectores/padrões extras desta tool (somados ao bloco global) ou
+	// disabled: true para não redigir a saída dela
+	Redaction Redaction `yaml:"redaction"`
+
+	// record: grava input + saída de cada chamada no storage (recordings/<tool>/<id>.json)
+	// para reproduzir regressões com mcp-gw replay <id>. Input em stream não é gravado.
+	Record bool `yaml:"record"`
+
+	// events: espelha as execuções desta tool (início, cada linha, fim) em sistemas
+	// externos. Assíncrono: sink lento ou fora do ar descarta eventos, nunca segura a tool.
+	Events []EventSink `yaml:"events"`
+
+	// Limites de saída (0 = sem limite). Ao exceder: processo morto + event: truncated
+	MaxOutputBytes int64 `yaml:"max_output_bytes"`
+	MaxOutputLines int64 `yaml:"max_output_lines"`
+
+	// Linhas maiores que max_line_bytes (default 4MiB) não abortam o stream:
+	// long_lines: split (default) = event: continuation com os pedaços da linha
+	//             truncate        = event: line_truncated com o início da linha (o resto é descartado)
+	MaxLineBytes int    `yaml:"max_line_bytes"`
+	LongLines    string `yaml:"long_lines"`
+
+	// Streaming HTTP (latência x throughput)
+	// flush_interval_ms: 0 = flush por linha (default; tools interativas)
+	//                    >0 = agrupa linhas e faz flush a cada intervalo (tools de alta frequência)
+	// write_buffer_bytes: 0 = buffer padrão do servidor; >0 = buffer de escrita da resposta
+	FlushIntervalMS  int `yaml:"flush_interval_ms"`
+	WriteBufferBytes int `yaml:"write_buffer_bytes"`
+
+	// slow_client: sobrescreve stream.slow_client para esta tool
+	SlowClient string `yaml:"slow_client"`
+
+	// Hardening (container; docker_network também vale para native com sandbox: bwrap)
 	// docker_network: none | bridge (default: none)
 	DockerNetwork string `yaml:"docker_network"`
+	// egress_allow (native): domínios que a tool alcança pelo proxy de saída do gateway
+	// (HTTP_PROXY/HTTPS_PROXY injetados). "api.example.com", "*.example.com" ou "*" (só
+	// auditoria). Ver internal/egress e egress_proxy.
+	EgressAllow []string `yaml:"egress_allow"`
 	// read_only: true|false (default: true quando omitido)
 	// ponteiro permite distinguir "omitido" de "false"
 	ReadOnly *bool `yaml:"read_only"`
+	// seccomp_profile: "strict" (perfil embutido no gateway) ou caminho absoluto de um JSON
+	// (vazio = default do docker). apparmor_profile: nome de um perfil carregado no host.
+	SeccompProfile  string `yaml:"seccomp_profile"`
+	AppArmorProfile string `yaml:"apparmor_profile"`
+	// pull_policy: always | if-not-present | never (default: if-not-present).
+	// A imagem também é pré-baixada no startup (exceto never).
+	PullPolicy string `yaml:"pull_policy"`
+	// container_runtime: runtime OCI do docker (runsc = gVisor, kata, runc; vazio = default do daemon)
+	ContainerRuntime string `yaml:"container_runtime"`
 }
 
-type Config struct {
-	WorkspaceRoot string          `yaml:"workspace_root"`
-	ToolsRoot     string          `yaml:"tools_root"`
-	Tools         map[string]Tool `yaml:"tools"`
+// Reuse mantém um container com a sessão stdio anexada e despacha requests para ele,
+// evitando o custo de subir a imagem a cada chamada. A tool precisa processar uma mensagem
+// por linha do stdin e terminar cada resposta com uma linha contendo "done": true.
+type Reuse struct {
+	MaxIdleMS   int `yaml:"max_idle_ms"`  // ocioso por mais que isso: derrubado (default DefaultReuseMaxIdle)
+	MaxAgeMS    int `yaml:"max_age_ms"`   // reciclado após essa idade (default DefaultReuseMaxAge)
+	MaxRequests int `yaml:"max_requests"` // reciclado após N requests (0 = sem limite)
 }
 
-func LoadFromFile(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read config file %q: %w", path, err)
+// MaxIdle retorna o tempo ocioso máximo efetivo.
+func (r Reuse) MaxIdle() time.Duration {
+	if r.MaxIdleMS <= 0 {
+		return DefaultReuseMaxIdle
 	}
+	return time.Duration(r.MaxIdleMS) * time.Millisecond
+}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("invalid yaml %q: %w", path, err)
+// MaxAge retorna a idade máxima efetiva.
+func (r Reuse) MaxAge() time.Duration {
+	if r.MaxAgeMS <= 0 {
+		return DefaultReuseMaxAge
 	}
+	return time.Duration(r.MaxAgeMS) * time.Millisecond
+}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, err
+func (r Reuse) validate(name string) error {
+	if r.MaxIdleMS < 0 || time.Duration(r.MaxIdleMS)*time.Millisecond > MaxReuseMaxIdle {
+		return fmt.Errorf("config: tools[%s].reuse.max_idle_ms must be between 0 and %d", name, MaxReuseMaxIdle.Milliseconds())
 	}
+	if r.MaxAgeMS < 0 || time.Duration(r.MaxAgeMS)*time.Millisecond > MaxReuseMaxAge {
+		return fmt.Errorf("config: tools[%s].reuse.max_age_ms must be between 0 and %d", name, MaxReuseMaxAge.Milliseconds())
+	}
+	if r.MaxRequests < 0 {
+		return fmt.Errorf("config: tools[%s].reuse.max_requests must be >= 0", name)
+	}
+	return nil
+}
 
-	return &cfg, nil
+// Session configura as sessões de uma tool daemon. Como no reuse, a tool processa uma
+// mensagem por linha do stdin e termina cada resposta com uma linha "done": true; aqui o
+// processo é exclusivo da sessão, então o estado em memória (REPL, conexão) persiste.
+type Session struct {
+	IdleTimeoutMS int `yaml:"idle_timeout_ms"` // sem requests por mais que isso: encerrada (default DefaultSessionIdle)
+	MaxSessions   int `yaml:"max_sessions"`    // sessões abertas simultâneas da tool (default DefaultMaxSessions)
 }
 
-func (c *Config) Validate() error {
-	if c.WorkspaceRoot == "" {
-		return fmt.Errorf("config: workspace_root is required")
+// IdleTimeout retorna o tempo ocioso máximo efetivo de uma sessão.
+func (s Session) IdleTimeout() time.Duration {
+	if s.IdleTimeoutMS <= 0 {
+		return DefaultSessionIdle
 	}
+	return time.Duration(s.IdleTimeoutMS) * time.Millisecond
+}
 
-	if c.ToolsRoot == "" {
-		return fmt.Errorf("config: tools_root is required")
+// MaxSessionsEffective retorna o limite efetivo de sessões abertas.
+func (s Session) MaxSessionsEffective() int {
+	if s.MaxSessions <= 0 {
+		return DefaultMaxSessions
 	}
+	return s.MaxSessions
+}
 
-	if len(c.Tools) == 0 {
-		return fmt.Errorf("config: tools must not be empty")
+func (s Session) validate(name string) error {
+	if s.IdleTimeoutMS < 0 || time.Duration(s.IdleTimeoutMS)*time.Millisecond > MaxSessionIdle {
+		return fmt.Errorf("config: tools[%s].session.idle_timeout_ms must be between 0 and %d", name, MaxSessionIdle.Milliseconds())
 	}
+	if s.MaxSessions < 0 {
+		return fmt.Errorf("config: tools[%s].session.max_sessions must be >= 0", name)
+	}
+	return nil
+}
 
-	for name, t := range c.Tools {
-		switch t.Runtime {
-		case "native":
-			if t.Cmd == "" {
-				return fmt.Errorf("config: tools[%s].cmd is required for native runtime", name)
-			}
-		case "container":
-			if t.Image == "" {
-				return fmt.Errorf("config: tools[%s].image is required for container runtime", name)
-			}
-			// valida hardening específico do container
-			if t.DockerNetwork != "" && t.DockerNetwork != "none" && t.DockerNetwork != "bridge" {
-				return fmt.Errorf("config: tools[%s].docker_network must be none or bridge", name)
-			}
-		default:
-			return fmt.Errorf("config: tools[%s].runtime must be native or container", name)
-		}
-
-		if t.Mode != "" && t.Mode != "launcher" && t.Mode != "daemon" {
-			return fmt.Errorf("config: tools[%s].mode must be launcher or daemon", name)
-		}
+// Health checa periodicamente as sessões ociosas de uma tool com reuse. Sem ping, só a
+// liveness do processo é verificada; com ping, a linha é enviada e a resposta (até a linha
+// "done") precisa conter expect. Sessões que falham são derrubadas e substituídas com
+// backoff exponencial (intervalo, 2x, 4x... até MaxHealthRestartDelay).
+type Health struct {
+	IntervalMS int    `yaml:"interval_ms"` // default DefaultHealthInterval
+	TimeoutMS  int    `yaml:"timeout_ms"`  // resposta do ping (default DefaultHealthTimeout)
+	Ping       string `yaml:"ping"`        // linha JSON enviada à tool (vazio = só liveness)
+	Expect     string `yaml:"expect"`      // substring esperada na resposta (vazio = basta "done")
+}
 
-		// ---- Fail-safe invariants ----
-		if t.TimeoutMS < 0 {
-			return fmt.Errorf("config: tools[%s].timeout_ms must be >= 0", name)
-		}
-		// Garante timeout efetivo e teto máximo
-		effectiveTimeout := t.Timeout()
-		if effectiveTimeout <= 0 {
-			return fmt.Errorf("config: tools[%s] must have a positive timeout (effective timeout <= 0)", name)
-		}
-		if effectiveTimeout > MaxToolTimeout {
-			return fmt.Errorf(
-				"config: tools[%s].timeout_ms too large (effective timeout %s exceeds max %s)",
-				name,
-				effectiveTimeout,
-				MaxToolTimeout,
-			)
-		}
+// Interval retorna o intervalo efetivo entre checagens.
+func (h Health) Interval() time.Duration {
+	if h.IntervalMS <= 0 {
+		return DefaultHealthInterval
+	}
+	return time.Duration(h.IntervalMS) * time.Millisecond
+}
 
-		// ---- Concurrency invariants ----
-		if t.MaxConcurrent < 0 {
-			return fmt.Errorf("config: tools[%s].max_concurrent must be >= 0", name)
-		}
-		if t.MaxConcurrent > MaxAllowedConcurrency {
-			return fmt.Errorf(
-				"config: tools[%s].max_concurrent must be <= %d",
-				name,
-				MaxAllowedConcurrency,
-			)
-		}
+// Timeout retorna o timeout efetivo do ping.
+func (h Health) Timeout() time.Duration {
+	if h.TimeoutMS <= 0 {
+		return DefaultHealthTimeout
 	}
+	return time.Duration(h.TimeoutMS) * time.Millisecond
+}
 
+func (h Health) validate(name string) error {
+	if h.IntervalMS < 0 || (h.IntervalMS > 0 && time.Duration(h.IntervalMS)*time.Millisecond < MinHealthInterval) ||
+		time.Duration(h.IntervalMS)*time.Millisecond > MaxHealthInterval {
+		return fmt.Errorf("config: tools[%s].health.interval_ms must be 0 or between %d and %d", name, MinHealthInterval.Milliseconds(), MaxHealthInterval.Milliseconds())
+	}
+	if h.TimeoutMS < 0 || time.Duration(h.TimeoutMS)*time.Millisecond > MaxToolTimeout {
+		return fmt.Errorf("config: tools[%s].health.timeout_ms must be between 0 and %d", name, MaxToolTimeout.Milliseconds())
+	}
+	if h.Ping != "" && (!json.Valid([]byte(h.Ping)) || strings.ContainsAny(h.Ping, "\n\r")) {
+		return fmt.Errorf("config: tools[%s].health.ping must be a single-line JSON value", name)
+	}
+	if h.Expect != "" && h.Ping == "" {
+		return fmt.Errorf("config: tools[%s].health.expect requires health.ping", name)
+	}
 	return nil
 }
 
-// Timeout retorna o timeout efetivo da tool.
-// Invariante do core: NENHUMA tool roda sem timeout.
-func (t Tool) Timeout() time.Duration {
-	if t.TimeoutMS <= 0 {
-		return DefaultToolTimeout
-	}
-	return time.Duration(t.TimeoutMS) * time.Millisecond
+// Mount é um volume extra de uma tool container (-v host:container[:ro]).
+type Mount struct {
+	Host      string `yaml:"host"`      // caminho absoluto no host, dentro de mount_roots
+	Container string `yaml:"container"` // caminho absoluto no container
+	Mode      string `yaml:"mode"`      // ro | rw (default: ro)
 }
 
-// MaxConc retorna o limite efetivo de concorrência da tool.
-// Default conservador para evitar fork-bomb acidental.
-func (t Tool) MaxConc() int {
-	if t.MaxConcurrent <= 0 {
-		return DefaultMaxConcurrent
+// ModeEffective retorna ro ou rw (default: ro).
+func (m Mount) ModeEffective() string {
+	if m.Mode == "" {
+		return WorkspaceAccessRO
 	}
-	if t.MaxConcurrent > MaxAllowedConcurrency {
-		return MaxAllowedConcurrency
+	return m.Mode
+}
+
+// Retry configura novas tentativas de falhas transitórias. Só falhas antes da primeira
+// linha de saída são repetidas (o cliente não viu nada), dentro do mesmo timeout da tool.
+type Retry struct {
+	Attempts  int      `yaml:"attempts"`   // total de tentativas (0/1 = sem retry)
+	BackoffMS int      `yaml:"backoff_ms"` // espera antes do 1º retry, dobra a cada tentativa
+	On        []string `yaml:"on"`         // spawn_error | nonzero_exit (default: ambos)
+}
+
+// Tipos de sink de eventos de execução.
+const (
+	EventSinkWebhook = "webhook" // POST JSON de cada evento em url
+	EventSinkKafka   = "kafka"   // Kafka REST Proxy (v2): POST url/topics/<topic>
+	EventSinkNATS    = "nats"    // PUB <topic> num servidor nats://host:port
+)
+
+// EventSink é um destino dos eventos de execução de uma tool.
+type EventSink struct {
+	Type    string            `yaml:"type"`    // webhook | kafka | nats
+	URL     string            `yaml:"url"`     // endpoint (webhook/kafka: http(s)://; nats: nats://host:port)
+	Topic   string            `yaml:"topic"`   // kafka: tópico; nats: subject (default: mcp.events.<tool>)
+	Headers map[string]string `yaml:"headers"` // webhook/kafka; valores aceitam ${ENV}
+	// buffer_events: fila em memória até o sink (default DefaultEventBuffer); cheia = descarta
+	BufferEvents int `yaml:"buffer_events"`
+	// lines: false = só start/end (sem um evento por linha de saída). Default: true.
+	Lines *bool `yaml:"lines"`
+}
+
+// TopicEffective retorna o tópico/subject efetivo do sink.
+func (e EventSink) TopicEffective(tool string) string {
+	if e.Topic == "" {
+		return "mcp.events." + tool
 	}
-	return t.MaxConcurrent
+	return e.Topic
 }
 
-// DockerNetworkEffective retorna o modo efetivo de rede para container.
-// Default conservador: "none".
-func (t Tool) DockerNetworkEffective() string {
-	if t.DockerNetwork == "" {
-		return DefaultDockerNetwork
+// BufferEffective retorna o tamanho efetivo da fila do sink.
+func (e EventSink) BufferEffective() int {
+	if e.BufferEvents <= 0 {
+		return DefaultEventBuffer
 	}
-	switch t.DockerNetwork {
-	case "none", "bridge":
-		return t.DockerNetwork
+	return e.BufferEvents
+}
+
+// LinesEnabled diz se o sink recebe um evento por linha de saída.
+func (e EventSink) LinesEnabled() bool {
+	return e.Lines == nil || *e.Lines
+}
+
+func (e EventSink) validate(name string, i int) error {
+	var schemes []string
+	switch e.Type {
+	case EventSinkWebhook, EventSinkKafka:
+		schemes = []string{"http", "https"}
+	case EventSinkNATS:
+		schemes = []string{"nats"}
 	default:
-		return DefaultDockerNetwork
+		return fmt.Errorf("config: tools[%s].events[%d].type must be webhook, kafka or nats", name, i)
+	}
+	u, err := url.Parse(e.URL)
+	if err != nil || u.Host == "" || !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("config: tools[%s].events[%d].url must be a %s:// URL", name, i, strings.Join(schemes, ":// or "))
+	}
+	if e.BufferEvents < 0 || e.BufferEvents > MaxEventBuffer {
+		return fmt.Errorf("config: tools[%s].events[%d].buffer_events must be between 0 and %d", name, i, MaxEventBuffer)
 	}
+	return nil
 }
 
-// ReadOnlyEffective retorna se o container deve rodar read-only.
-// Default conservador: true (quando omitido).
-func (t Tool) ReadOnlyEffective() bool {
-	if t.ReadOnly == nil {
-		return DefaultReadOnly
+// KVOptions limita a tool builtin kv (estado pequeno de agentes, em memória).
+type KVOptions struct {
+	MaxKeys       int `yaml:"max_keys"`        // por namespace; default DefaultKVMaxKeys
+	MaxValueBytes int `yaml:"max_value_bytes"` // default DefaultKVMaxValueBytes
+	DefaultTTLMS  int `yaml:"default_ttl_ms"`  // default DefaultKVTTL
+	MaxTTLMS      int `yaml:"max_ttl_ms"`      // default MaxKVTTL
+}
+
+type Config struct {
+	WorkspaceRoot string          `yaml:"workspace_root"`
+	ToolsRoot     string          `yaml:"tools_root"`
+	Tools         map[string]Tool `yaml:"tools"`
+
+	// include: globs de arquivos com mais tools (relativos ao config), somados depois do
+	// arquivo principal e antes de conf.d/*.yaml. Ver fragment para a semântica de override.
+	Include []string `yaml:"include"`
+
+	// defaults: settings herdados por todas as tools; groups: settings herdados pelas tools
+	// com group: <nome>. A própria tool vence campo a campo (ver inheritance).
+	Defaults Tool            `yaml:"defaults"`
+	Groups   map[string]Tool `yaml:"groups"`
+	inherit  *inheritance
+
+	// profiles: overlays nomeados sobre as tools (--profile / MCP_GW_PROFILE).
+	// ActiveProfile é o profile aplicado no load ("" = nenhum).
+	Profiles      map[string]Profile `yaml:"profiles"`
+	ActiveProfile string             `yaml:"-"`
+
+	// Usuário default das tools ("uid[:gid]"). Sem ele: container usa DefaultContainerUser,
+	// native herda o usuário do gateway.
+	User string `yaml:"user"`
+
+	// workspaces: raízes nomeadas selecionáveis por request (header X-MCP-Workspace); um
+	// gateway atende vários projetos/tenants, cada um no seu diretório. Sem o header: workspace_root.
+	// Com auth, cada credencial só escolhe os workspaces da sua lista (auth.*.workspaces).
+	Workspaces map[string]string `yaml:"workspaces"`
+
+	// Raízes do host permitidas em tools[*].mounts (vazio = nenhum volume extra permitido)
+	MountRoots []string `yaml:"mount_roots"`
+
+	// Storage de artefatos/transcripts/jobs/exports (default: disco local)
+	Storage Storage `yaml:"storage"`
+
+	// Backpressure do streaming HTTP (cliente lento)
+	Stream Stream `yaml:"stream"`
+
+	// Feature flags experimentais por request (header X-MCP-Flags)
+	Flags Flags `yaml:"flags"`
+
+	// Shutdown gracioso: janela para requests em andamento terminarem antes do kill forçado
+	ShutdownDrainMS int `yaml:"shutdown_drain_ms"` // default: DefaultShutdownDrain
+
+	// Intervalo do reaper de containers órfãos (roda também no startup; só com tools container)
+	OrphanGCIntervalMS int `yaml:"orphan_gc_interval_ms"` // default: DefaultOrphanGCInterval
+
+	// Auditoria de processos que sobreviveram ao Close das tools (grupo ou netos que escaparam)
+	ProcessAudit ProcessAudit `yaml:"process_audit"`
+
+	// Endpoints /admin/* (desligados se o token não estiver definido no ambiente)
+	Admin Admin `yaml:"admin"`
+
+	// Autenticação dos clientes das tools (api_keys; ver auth.go)
+	Auth Auth `yaml:"auth"`
+
+	// TLS no transport HTTP (vazio = HTTP puro, ex: atrás de um túnel/proxy que termina TLS)
+	TLS TLS `yaml:"tls"`
+
+	// Compressão gzip/deflate das respostas HTTP (negociada pelo Accept-Encoding)
+	Compression Compression `yaml:"compression"`
+
+	// Transferência de arquivos do workspace por HTTP (/workspace/files/<path>)
+	Files Files `yaml:"files"`
+
+	// Proxy de saída das tools native com egress_allow
+	EgressProxy EgressProxy `yaml:"egress_proxy"`
+
+	// Proteção contra DNS rebinding: Host/Origin aceitos pelo transport HTTP
+	HostCheck HostCheck `yaml:"host_check"`
+
+	// Destino/formato do logger do gateway (vazio = texto no stderr)
+	Logging Logging `yaml:"logging"`
+
+	// Access log do transport HTTP (uma linha por request ao terminar)
+	AccessLog AccessLog `yaml:"access_log"`
+
+	// Proxies (IP ou CIDR) cujos CF-Connecting-IP/X-Forwarded-For valem como IP do cliente;
+	// vazio = o cliente é sempre o peer da conexão
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	// Limites de concorrência do gateway inteiro (somam com o max_concurrent de cada tool)
+	Limits Limits `yaml:"limits"`
+
+	// Redação de segredos nas linhas de saída de todas as tools (antes do cliente e dos logs)
+	Redaction Redaction `yaml:"redaction"`
+}
+
+// Detectores embutidos de redação (regex mantidas pelo core).
+var RedactionDetectors = []string{"aws_access_key", "aws_secret_key", "bearer_token", "email", "private_key", "github_token", "jwt"}
+
+// Redaction remove segredos das linhas de saída das tools antes de chegarem ao cliente.
+// O bloco global vale para todas as tools; tools[*].redaction soma detectores/padrões
+// próprios ou desliga a redação daquela tool (disabled: true).
+type Redaction struct {
+	Detectors []string        `yaml:"detectors"` // ver RedactionDetectors
+	Patterns  []RedactPattern `yaml:"patterns"`
+	// replacement: texto no lugar do segredo (default "[REDACTED:<nome>]"; "<nome>" é trocado
+	// pelo detector/padrão que casou)
+	Replacement string `yaml:"replacement"`
+	Disabled    bool   `yaml:"disabled"` // só em tools[*]
+}
+
+// RedactPattern é um detector próprio (regex RE2).
+type RedactPattern struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+// Enabled diz se há algo para redigir.
+func (r Redaction) Enabled() bool {
+	return !r.Disabled && (len(r.Detectors) > 0 || len(r.Patterns) > 0)
+}
+
+// ReplacementEffective retorna o texto de substituição para o detector name.
+func (r Redaction) ReplacementEffective(name string) string {
+	if r.Replacement == "" {
+		return "[REDACTED:" + name + "]"
 	}
-	return *t.ReadOnly
+	return strings.ReplaceAll(r.Replacement, "<name>", name)
+}
+
+func (r Redaction) validate(at string) error {
+	for _, d := range r.Detectors {
+		if !slices.Contains(RedactionDetectors, d) {
+			return fmt.Errorf("config: %s.detectors: unknown detector %q (known: %s)", at, d, strings.Join(RedactionDetectors, ", "))
+		}
+	}
+	for i, p := range r.Patterns {
+		if p.Name == "" {
+			return fmt.Errorf("config: %s.patterns[%d].name is required", at, i)
+		}
+		if _, err := regexp.Compile(p.Regex); err != nil || p.Regex == "" {
+			return fmt.Errorf("config: %s.patterns[%d].regex is not a valid regular expression", at, i)
+		}
+	}
+	return nil
+}
+
+// Logging configura o logger do gateway. output: stderr (default) ou file (path, com
+// rotação por tamanho: ao passar de max_size_mb o arquivo vira path.1, path.1 vira
+// path.2... e só max_backups antigos ficam). format: json (default) ou text.
+type Logging struct {
+	Output     string `yaml:"output"`
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+	Format     string `yaml:"format"`
+
+	// level: debug | info (default) | warn | error. --verbose/--quiet vencem.
+	Level string `yaml:"level"`
+	// components: nível por componente do gateway (ver LogComponents), no lugar do level
+	// para os registros daquele pacote. log_level da tool vence o do componente;
+	// --verbose/--quiet vencem os dois níveis do bloco.
+	Components map[string]string `yaml:"components"`
+
+	// capture: guarda em memória os registros de cada request (GET
+	// /admin/requests/<request_id>/logs). Desligada por default.
+	Capture LogCapture `yaml:"capture"`
+}
+
+// LogCapture limita a captura de logs por request: as requests mais recentes (0 =
+// desligada) e os últimos records registros de cada uma (default DefaultLogCaptureRecords).
+type LogCapture struct {
+	Requests int `yaml:"requests"`
+	Records  int `yaml:"records"`
+}
+
+// RecordsEffective retorna os registros guardados por request.
+func (c LogCapture) RecordsEffective() int {
+	if c.Records <= 0 {
+		return DefaultLogCaptureRecords
+	}
+	return c.Records
+}
+
+// LogComponents são os componentes aceitos em logging.components.
+var LogComponents = []string{"core", "runner", "runtime", "transport"}
+
+// Configured diz se o bloco logging define saída ou formato (senão: texto no stderr).
+// level, components e capture não contam: só mudam níveis e captura.
+func (l Logging) Configured() bool {
+	return l.Output != "" || l.Path != "" || l.MaxSizeMB != 0 || l.MaxBackups != 0 || l.Format != ""
+}
+
+// MaxSizeMBEffective retorna o tamanho de rotação (default DefaultLogMaxSizeMB).
+func (l Logging) MaxSizeMBEffective() int {
+	if l.MaxSizeMB <= 0 {
+		return DefaultLogMaxSizeMB
+	}
+	return l.MaxSizeMB
+}
+
+// MaxBackupsEffective retorna quantos arquivos rotacionados manter (default DefaultLogMaxBackups).
+func (l Logging) MaxBackupsEffective() int {
+	if l.MaxBackups <= 0 {
+		return DefaultLogMaxBackups
+	}
+	return l.MaxBackups
+}
+
+func (l Logging) validate() error {
+	switch l.Output {
+	case "", "stderr":
+		if l.Path != "" {
+			return fmt.Errorf("config: logging.path requires logging.output: file")
+		}
+	case "file":
+		if l.Path == "" {
+			return fmt.Errorf("config: logging.output: file requires logging.path")
+		}
+	default:
+		return fmt.Errorf("config: logging.output must be stderr or file")
+	}
+	if l.MaxSizeMB < 0 || l.MaxSizeMB > MaxLogSizeMB {
+		return fmt.Errorf("config: logging.max_size_mb must be between 0 and %d", MaxLogSizeMB)
+	}
+	if l.MaxBackups < 0 || l.MaxBackups > MaxLogBackups {
+		return fmt.Errorf("config: logging.max_backups must be between 0 and %d", MaxLogBackups)
+	}
+	switch l.Format {
+	case "", "json", "text":
+	default:
+		return fmt.Errorf("config: logging.format must be json or text")
+	}
+	switch strings.ToLower(l.Level) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("config: logging.level must be debug, info, warn or error")
+	}
+	for c, lv := range l.Components {
+		if !slices.Contains(LogComponents, c) {
+			return fmt.Errorf("config: logging.components: unknown component %q (known: %s)", c, strings.Join(LogComponents, ", "))
+		}
+		switch strings.ToLower(lv) {
+		case "debug", "info", "warn", "error":
+		default:
+			return fmt.Errorf("config: logging.components[%s] must be debug, info, warn or error", c)
+		}
+	}
+	if l.Capture.Requests < 0 || l.Capture.Requests > MaxLogCaptureRequests {
+		return fmt.Errorf("config: logging.capture.requests must be between 0 and %d", MaxLogCaptureRequests)
+	}
+	if l.Capture.Records < 0 || l.Capture.Records > MaxLogCaptureRecords {
+		return fmt.Errorf("config: logging.capture.records must be between 0 and %d", MaxLogCaptureRecords)
+	}
+	return nil
+}
+
+// AccessLog escolhe o formato da linha de resumo por request: slog (default; linha Info
+// "http access" com method, path, tool, status, bytes_out, lines_out, duration_ms,
+// request_id, client_ip e identity), combined (texto estilo Apache combined) ou off.
+type AccessLog struct {
+	Format string `yaml:"format"`
+}
+
+// TrustedProxyPrefixes retorna trusted_proxies como prefixos (um IP vira /32 ou /128).
+// Entradas inválidas (barradas no validate) são ignoradas.
+func (c *Config) TrustedProxyPrefixes() []netip.Prefix {
+	return ipPrefixes(c.TrustedProxies)
+}
+
+func ipPrefixes(list []string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, p := range list {
+		if pfx, err := parseIPPrefix(p); err == nil {
+			out = append(out, pfx)
+		}
+	}
+	return out
+}
+
+func parseIPPrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		pfx, err := netip.ParsePrefix(s)
+		return pfx.Masked(), err
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()), nil
+}
+
+// Limits limita execuções simultâneas somando todas as tools: max_concurrent no total e
+// max_concurrent_per_client por identidade (principal autenticado; sem auth, o IP do
+// cliente), para que um cliente agressivo não ocupe ao mesmo tempo os slots de todas as
+// tools. 0 = sem limite. O limite por cliente é fail-fast (429); o total espera até
+// queue_timeout_ms da tool, por priority.
+type Limits struct {
+	MaxConcurrent          int `yaml:"max_concurrent"`
+	MaxConcurrentPerClient int `yaml:"max_concurrent_per_client"`
+}
+
+func (l Limits) validate() error {
+	if l.MaxConcurrent < 0 || l.MaxConcurrent > MaxGlobalConcurrency {
+		return fmt.Errorf("config: limits.max_concurrent must be between 0 and %d", MaxGlobalConcurrency)
+	}
+	if l.MaxConcurrentPerClient < 0 || l.MaxConcurrentPerClient > MaxGlobalConcurrency {
+		return fmt.Errorf("config: limits.max_concurrent_per_client must be between 0 and %d", MaxGlobalConcurrency)
+	}
+	if l.MaxConcurrent > 0 && l.MaxConcurrentPerClient > l.MaxConcurrent {
+		return fmt.Errorf("config: limits.max_concurrent_per_client must be <= limits.max_concurrent")
+	}
+	return nil
+}
+
+// Compression liga gzip/deflate nas respostas HTTP quando o cliente aceita.
+// enabled cobre /mcp/tools, /mcp (JSON-RPC), /admin/* etc.; streams estende aos
+// streams SSE/NDJSON das tools (o flush por mensagem continua valendo).
+type Compression struct {
+	Enabled bool `yaml:"enabled"`
+	Streams bool `yaml:"streams"`
+}
+
+// Files liga GET/PUT/DELETE /workspace/files/<path>: clientes colocam inputs no
+// workspace_root (e buscam saídas) sem acesso ao host. Todo caminho passa pelo
+// sandbox.ValidatePath. Desligado por default.
+type Files struct {
+	Enabled        bool  `yaml:"enabled"`
+	ReadOnly       bool  `yaml:"read_only"`        // só GET
+	MaxUploadBytes int64 `yaml:"max_upload_bytes"` // default: DefaultMaxUploadBytes
+}
+
+// MaxUpload retorna o teto efetivo de um PUT.
+func (f Files) MaxUpload() int64 {
+	if f.MaxUploadBytes <= 0 {
+		return DefaultMaxUploadBytes
+	}
+	return f.MaxUploadBytes
+}
+
+// EgressProxy é o listener do proxy de saída (sobe só quando uma tool com egress_allow
+// roda). Listen precisa ser alcançável pelas tools (default: DefaultEgressProxyListen).
+// Destinos que resolvem para loopback, link-local ou rede privada são recusados mesmo com
+// o nome no egress_allow (o gateway e a rede interna não são saída), exceto os cobertos
+// por allow_internal.
+type EgressProxy struct {
+	Listen        string   `yaml:"listen"`
+	AllowInternal []string `yaml:"allow_internal"` // IP ou CIDR internos liberados
+}
+
+// AllowInternalPrefixes retorna allow_internal como prefixos (entradas inválidas, barradas
+// no validate, são ignoradas).
+func (e EgressProxy) AllowInternalPrefixes() []netip.Prefix {
+	return ipPrefixes(e.AllowInternal)
+}
+
+// HostCheck limita os Host/Origin aceitos pelo transport HTTP (DNS rebinding: uma página
+// qualquer resolvendo o próprio domínio para 127.0.0.1 e falando com o gateway local).
+// Sem allowed_hosts, o listener em loopback aceita só localhost/127.0.0.1/[::1]; em outro
+// endereço não há checagem de Host. Origin (quando o cliente manda) segue a mesma regra
+// com allowed_origins. "*" libera tudo; disabled desliga as duas checagens.
+type HostCheck struct {
+	Disabled       bool     `yaml:"disabled"`
+	AllowedHosts   []string `yaml:"allowed_hosts"`   // host ou *.dominio (porta ignorada)
+	AllowedOrigins []string `yaml:"allowed_origins"` // scheme://host[:port] ou *
+}
+
+func (h HostCheck) validate() error {
+	for _, host := range h.AllowedHosts {
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			return fmt.Errorf("config: host_check.allowed_hosts: invalid host %q", host)
+		}
+	}
+	for _, o := range h.AllowedOrigins {
+		if o == "*" {
+			continue
+		}
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("config: host_check.allowed_origins: invalid origin %q (expected scheme://host[:port])", o)
+		}
+	}
+	return nil
+}
+
+// TLS faz o gateway terminar TLS sozinho. Com client_ca_file, certificados de cliente
+// apresentados são verificados contra essa CA; require_client_cert exige um (mTLS).
+type TLS struct {
+	CertFile          string `yaml:"cert_file"`
+	KeyFile           string `yaml:"key_file"`
+	ClientCAFile      string `yaml:"client_ca_file"`
+	RequireClientCert bool   `yaml:"require_client_cert"`
+}
+
+// Enabled diz se o transport HTTP deve servir HTTPS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+func (t TLS) validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("config: tls.cert_file and tls.key_file must be set together")
+	}
+	if !t.Enabled() && (t.ClientCAFile != "" || t.RequireClientCert) {
+		return fmt.Errorf("config: tls.client_ca_file/require_client_cert require tls.cert_file and tls.key_file")
+	}
+	if t.RequireClientCert && t.ClientCAFile == "" {
+		return fmt.Errorf("config: tls.require_client_cert requires tls.client_ca_file")
+	}
+	return nil
+}
+
+// Stream controla o buffer de saída entre a tool e o cliente HTTP.
+// Quando o buffer enche (cliente lendo devagar), slow_client decide:
+// - block: para de ler o stdout da tool até o cliente consumir (default)
+// - drop: descarta linhas e avisa com event: dropped (contagem)
+// - disconnect: derruba o cliente e mata a tool
+type Stream struct {
+	BufferLines int    `yaml:"buffer_lines"` // default: DefaultStreamBufferLines
+	SlowClient  string `yaml:"slow_client"`  // default: DefaultSlowClientPolicy
+
+	// ResumeWindowMS > 0 liga o resume de streams SSE: a execução sobrevive à queda do
+	// cliente por até essa janela, esperando a reconexão com Last-Event-ID.
+	ResumeWindowMS int `yaml:"resume_window_ms"`
+}
+
+// ProcessAudit procura periodicamente processos que sobraram de tools já encerradas:
+// membros do process group que não morreram e netos que escaparam do grupo (setsid,
+// daemonize). No Linux o gateway vira subreaper para herdar esses netos.
+type ProcessAudit struct {
+	IntervalMS int  `yaml:"interval_ms"` // default: DefaultProcessAuditInterval
+	Kill       bool `yaml:"kill"`        // SIGKILL nos encontrados (default: só log + métrica)
+}
+
+// Interval retorna o intervalo efetivo da auditoria.
+func (p ProcessAudit) Interval() time.Duration {
+	if p.IntervalMS <= 0 {
+		return DefaultProcessAuditInterval
+	}
+	return time.Duration(p.IntervalMS) * time.Millisecond
+}
+
+// Admin configura o acesso aos endpoints administrativos.
+// O token nunca fica no YAML: é lido da variável de ambiente indicada.
+type Admin struct {
+	TokenEnv string `yaml:"token_env"` // default: DefaultAdminTokenEnv
+}
+
+// Flags controla comportamentos experimentais por request.
+// - allowed: flags que o cliente pode ligar/desligar via X-MCP-Flags
+// - default: flags ligadas para todas as requests (rollout gradual)
+type Flags struct {
+	Allowed []string `yaml:"allowed"`
+	Default []string `yaml:"default"`
+}
+
+// Storage seleciona o backend de persistência de saídas do gateway.
+// Em deploys cloud (containers stateless) use s3 (AWS ou MinIO).
+type Storage struct {
+	Backend string    `yaml:"backend"` // local | s3 (default: local)
+	Path    string    `yaml:"path"`    // local: diretório base (default: DefaultStoragePath)
+	S3      S3Storage `yaml:"s3"`
+}
+
+type S3Storage struct {
+	Endpoint string `yaml:"endpoint"` // ex: https://s3.us-east-1.amazonaws.com | http://minio:9000
+	Bucket   string `yaml:"bucket"`
+	Region   string `yaml:"region"` // default: us-east-1
+	Prefix   string `yaml:"prefix"` // opcional; prefixo de todas as chaves
+
+	// Credenciais vêm sempre do ambiente (nunca do YAML).
+	AccessKeyEnv string `yaml:"access_key_env"` // default: AWS_ACCESS_KEY_ID
+	SecretKeyEnv string `yaml:"secret_key_env"` // default: AWS_SECRET_ACCESS_KEY
+
+	// path_style: true para MinIO (endpoint/bucket/key em vez de bucket.endpoint/key)
+	PathStyle bool `yaml:"path_style"`
+}
+
+func LoadFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file %q: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid yaml %q: %w", path, err)
+	}
+
+	if err := cfg.loadInheritance(data); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadSchemaFiles(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := cfg.mergeIncludes(path, true); err != nil {
+		return nil, err
+	}
+	if err := cfg.applyProfile(data, profileFromEnv(), filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// Parse decodifica e valida um config a partir de bytes YAML (ex: config proposto via admin).
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid yaml: %w", err)
+	}
+	if err := cfg.loadInheritance(data); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadSchemaFiles("."); err != nil {
+		return nil, err
+	}
+	// sem arquivo: include: relativo ao diretório atual, sem conf.d
+	if err := cfg.mergeIncludes("", false); err != nil {
+		return nil, err
+	}
+	if err := cfg.applyProfile(data, profileFromEnv(), "."); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadSchemaFiles lê os tools[*].input_schema_file/output_schema_file (relativos a
+// baseDir) para InputSchema/OutputSchema.
+func (c *Config) loadSchemaFiles(baseDir string) error {
+	for name, t := range c.Tools {
+		for _, f := range []struct {
+			key    string
+			file   string
+			schema *map[string]any
+		}{
+			{"input_schema", t.InputSchemaFile, &t.InputSchema},
+			{"output_schema", t.OutputSchemaFile, &t.OutputSchema},
+		} {
+			if f.file == "" {
+				continue
+			}
+			if *f.schema != nil {
+				return fmt.Errorf("config: tools[%s]: %s and %s_file are mutually exclusive", name, f.key, f.key)
+			}
+			p := f.file
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(baseDir, p)
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return fmt.Errorf("config: tools[%s].%s_file: %w", name, f.key, err)
+			}
+			// YAML é superconjunto de JSON: o mesmo decoder lê os dois formatos
+			if err := yaml.Unmarshal(data, f.schema); err != nil {
+				return fmt.Errorf("config: tools[%s].%s_file %q: %w", name, f.key, p, err)
+			}
+		}
+		c.Tools[name] = t
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	if c.WorkspaceRoot == "" {
+		return fmt.Errorf("config: workspace_root is required")
+	}
+
+	if c.ToolsRoot == "" {
+		return fmt.Errorf("config: tools_root is required")
+	}
+
+	if len(c.Tools) == 0 {
+		return fmt.Errorf("config: tools must not be empty")
+	}
+
+	for name, root := range c.Workspaces {
+		if !validNamespace(name) {
+			return fmt.Errorf("config: workspaces[%s]: invalid name (use letters, digits, - and _)", name)
+		}
+		if !filepath.IsAbs(root) {
+			return fmt.Errorf("config: workspaces[%s] must be an absolute path", name)
+		}
+	}
+
+	if err := c.Storage.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Flags.validate(); err != nil {
+		return err
+	}
+
+	if err := c.TLS.validate(); err != nil {
+		return err
+	}
+
+	if err := c.HostCheck.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Auth.validate(); err != nil {
+		return err
+	}
+	if err := c.validateCredentialWorkspaces(); err != nil {
+		return err
+	}
+
+	if err := c.Logging.validate(); err != nil {
+		return err
+	}
+
+	switch c.AccessLog.Format {
+	case "", "slog", "combined", "off":
+	default:
+		return fmt.Errorf("config: access_log.format must be slog, combined or off")
+	}
+
+	for _, p := range c.TrustedProxies {
+		if _, err := parseIPPrefix(p); err != nil {
+			return fmt.Errorf("config: trusted_proxies: invalid IP or CIDR %q", p)
+		}
+	}
+
+	if c.Redaction.Disabled {
+		return fmt.Errorf("config: redaction.disabled is only valid in tools[*].redaction")
+	}
+	if err := c.Redaction.validate("redaction"); err != nil {
+		return err
+	}
+	if err := c.Limits.validate(); err != nil {
+		return err
+	}
+
+	if c.Compression.Streams && !c.Compression.Enabled {
+		return fmt.Errorf("config: compression.streams requires compression.enabled")
+	}
+
+	if c.Files.MaxUploadBytes < 0 || c.Files.MaxUploadBytes > MaxMaxBodyBytes {
+		return fmt.Errorf("config: files.max_upload_bytes must be between 0 and %d", int64(MaxMaxBodyBytes))
+	}
+
+	if c.EgressProxy.Listen != "" {
+		if _, _, err := net.SplitHostPort(c.EgressProxy.Listen); err != nil {
+			return fmt.Errorf("config: egress_proxy.listen must be host:port: %w", err)
+		}
+	}
+	for _, p := range c.EgressProxy.AllowInternal {
+		if _, err := parseIPPrefix(p); err != nil {
+			return fmt.Errorf("config: egress_proxy.allow_internal: invalid IP or CIDR %q", p)
+		}
+	}
+
+	if c.Stream.BufferLines < 0 || c.Stream.BufferLines > MaxStreamBufferLines {
+		return fmt.Errorf("config: stream.buffer_lines must be between 0 and %d", MaxStreamBufferLines)
+	}
+	if !validSlowClientPolicy(c.Stream.SlowClient) {
+		return fmt.Errorf("config: stream.slow_client must be block, drop or disconnect")
+	}
+	if c.Stream.ResumeWindowMS < 0 || c.Stream.ResumeWindow() > MaxStreamResumeWindow {
+		return fmt.Errorf("config: stream.resume_window_ms must be between 0 and %d", MaxStreamResumeWindow.Milliseconds())
+	}
+
+	if c.ShutdownDrainMS < 0 || time.Duration(c.ShutdownDrainMS)*time.Millisecond > MaxShutdownDrain {
+		return fmt.Errorf("config: shutdown_drain_ms must be between 0 and %d", MaxShutdownDrain.Milliseconds())
+	}
+
+	if c.OrphanGCIntervalMS != 0 {
+		d := time.Duration(c.OrphanGCIntervalMS) * time.Millisecond
+		if d < MinOrphanGCInterval || d > MaxOrphanGCInterval {
+			return fmt.Errorf("config: orphan_gc_interval_ms must be 0 or between %d and %d", MinOrphanGCInterval.Milliseconds(), MaxOrphanGCInterval.Milliseconds())
+		}
+	}
+
+	if c.ProcessAudit.IntervalMS != 0 {
+		d := time.Duration(c.ProcessAudit.IntervalMS) * time.Millisecond
+		if d < MinProcessAuditInterval || d > MaxProcessAuditInterval {
+			return fmt.Errorf("config: process_audit.interval_ms must be 0 or between %d and %d", MinProcessAuditInterval.Milliseconds(), MaxProcessAuditInterval.Milliseconds())
+		}
+	}
+
+	if c.User != "" {
+		if _, _, err := ParseUser(c.User); err != nil {
+			return fmt.Errorf("config: user: %w", err)
+		}
+	}
+
+	namespaces := make(map[string]string)
+	aliases := make(map[string]string)
+	for name, t := range c.Tools {
+		if IsDiagnosticTool(name) {
+			return fmt.Errorf("config: tools[%s]: names starting with %q are reserved for the built-in diagnostic tools", name, DiagnosticPrefix)
+		}
+		if _, ok := c.Groups[t.Group]; t.Group != "" && !ok {
+			return fmt.Errorf("config: tools[%s].group %q is not defined in groups", name, t.Group)
+		}
+		for _, a := range t.Aliases {
+			if a == "" || strings.ContainsAny(a, " \t\n\r/\\") || strings.Contains(a, "..") || IsDiagnosticTool(a) {
+				return fmt.Errorf("config: tools[%s].aliases: invalid alias %q", name, a)
+			}
+			if _, clash := c.Tools[a]; clash {
+				return fmt.Errorf("config: tools[%s].aliases: %q is already a tool name", name, a)
+			}
+			if other, dup := aliases[a]; dup {
+				return fmt.Errorf("config: tools[%s].aliases: %q is already an alias of tools[%s]", name, a, other)
+			}
+			aliases[a] = name
+		}
+		if t.Federate {
+			ns := t.NamespaceEffective(name)
+			if !validNamespace(ns) {
+				return fmt.Errorf("config: tools[%s].namespace %q is invalid (use letters, digits, - and _)", name, ns)
+			}
+			if t.Runtime == "remote" || t.Runtime == "builtin" {
+				return fmt.Errorf("config: tools[%s].federate is not supported for %s runtime", name, t.Runtime)
+			}
+			if other, dup := namespaces[ns]; dup {
+				return fmt.Errorf("config: tools[%s].namespace %q already used by tools[%s]", name, ns, other)
+			}
+			namespaces[ns] = name
+		}
+
+		switch t.Runtime {
+		case "native":
+			if t.Cmd == "" {
+				return fmt.Errorf("config: tools[%s].cmd is required for native runtime", name)
+			}
+			if t.Sandbox != "" && t.Sandbox != SandboxBwrap {
+				return fmt.Errorf("config: tools[%s].sandbox must be bwrap", name)
+			}
+		case "container":
+			if t.Image == "" {
+				return fmt.Errorf("config: tools[%s].image is required for container runtime", name)
+			}
+			// valida hardening específico do container
+			if t.DockerNetwork != "" && t.DockerNetwork != "none" && t.DockerNetwork != "bridge" {
+				return fmt.Errorf("config: tools[%s].docker_network must be none or bridge", name)
+			}
+			switch t.PullPolicy {
+			case "", PullAlways, PullIfNotPresent, PullNever:
+			default:
+				return fmt.Errorf("config: tools[%s].pull_policy must be always, if-not-present or never", name)
+			}
+			switch t.ContainerRuntime {
+			case "", "runsc", "kata", "runc":
+			default:
+				return fmt.Errorf("config: tools[%s].container_runtime must be runsc, kata or runc", name)
+			}
+			if p := t.SeccompProfile; p != "" && p != SeccompProfileStrict && !filepath.IsAbs(p) {
+				return fmt.Errorf("config: tools[%s].seccomp_profile must be %q or an absolute path", name, SeccompProfileStrict)
+			}
+			if p := t.AppArmorProfile; p != "" && (p == "unconfined" || !validAppArmorName(p)) {
+				return fmt.Errorf("config: tools[%s].apparmor_profile %q is invalid", name, p)
+			}
+		case "remote":
+			u, err := url.Parse(t.Endpoint)
+			if t.Endpoint == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("config: tools[%s].endpoint must be an http(s) URL for remote runtime", name)
+			}
+			if t.Retries < 0 || t.Retries > MaxRemoteRetries {
+				return fmt.Errorf("config: tools[%s].retries must be between 0 and %d", name, MaxRemoteRetries)
+			}
+		case "builtin":
+			if t.Builtin != "kv" {
+				return fmt.Errorf("config: tools[%s].builtin must be kv for builtin runtime", name)
+			}
+			if err := t.KV.validate(name); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("config: tools[%s].runtime must be native, container, remote or builtin", name)
+		}
+
+		if t.Mode != "" && t.Mode != "launcher" && t.Mode != "daemon" {
+			return fmt.Errorf("config: tools[%s].mode must be launcher or daemon", name)
+		}
+		if t.Mode == "daemon" {
+			if (t.Runtime != "native" && t.Runtime != "container") || t.Federate || t.Reuse != nil {
+				return fmt.Errorf("config: tools[%s].mode daemon is only supported for non-federated native and container tools without reuse", name)
+			}
+			if t.TTY || t.Interactive || t.Output == OutputBinary {
+				return fmt.Errorf("config: tools[%s].mode daemon cannot be combined with tty, interactive or output binary", name)
+			}
+		}
+		if err := t.Session.validate(name); err != nil {
+			return err
+		}
+
+		// ---- Fail-safe invariants ----
+		if t.TimeoutMS < 0 {
+			return fmt.Errorf("config: tools[%s].timeout_ms must be >= 0", name)
+		}
+		// Garante timeout efetivo e teto máximo
+		effectiveTimeout := t.Timeout()
+		if effectiveTimeout <= 0 {
+			return fmt.Errorf("config: tools[%s] must have a positive timeout (effective timeout <= 0)", name)
+		}
+		if effectiveTimeout > MaxToolTimeout {
+			return fmt.Errorf(
+				"config: tools[%s].timeout_ms too large (effective timeout %s exceeds max %s)",
+				name,
+				effectiveTimeout,
+				MaxToolTimeout,
+			)
+		}
+
+		if t.MaxTimeoutMS < 0 || time.Duration(t.MaxTimeoutMS)*time.Millisecond > MaxToolTimeout {
+			return fmt.Errorf("config: tools[%s].max_timeout_ms must be between 0 and %d", name, MaxToolTimeout.Milliseconds())
+		}
+		if t.MaxTimeoutMS > 0 && time.Duration(t.MaxTimeoutMS)*time.Millisecond < effectiveTimeout {
+			return fmt.Errorf("config: tools[%s].max_timeout_ms must be >= the effective timeout (%d)", name, effectiveTimeout.Milliseconds())
+		}
+
+		if t.StartupTimeoutMS < 0 || time.Duration(t.StartupTimeoutMS)*time.Millisecond > effectiveTimeout {
+			return fmt.Errorf("config: tools[%s].startup_timeout_ms must be between 0 and the effective timeout (%d)", name, effectiveTimeout.Milliseconds())
+		}
+
+		if t.ShutdownGraceMS < 0 || time.Duration(t.ShutdownGraceMS)*time.Millisecond > MaxShutdownGrace {
+			return fmt.Errorf("config: tools[%s].shutdown_grace_ms must be between 0 and %d", name, MaxShutdownGrace.Milliseconds())
+		}
+		switch t.Shutdown {
+		case "", ShutdownEOF, ShutdownSIGTERM, ShutdownBoth:
+		default:
+			return fmt.Errorf("config: tools[%s].shutdown must be eof, sigterm or both", name)
+		}
+
+		switch t.Cancellation {
+		case "", CancellationKill:
+		case CancellationCooperative:
+			if (t.Runtime != "native" && t.Runtime != "container") || t.Federate || t.Reuse != nil || t.Mode == "daemon" {
+				return fmt.Errorf("config: tools[%s].cancellation cooperative is only supported for non-federated native and container launcher tools without reuse", name)
+			}
+		default:
+			return fmt.Errorf("config: tools[%s].cancellation must be kill or cooperative", name)
+		}
+		if strings.ContainsAny(t.CancelMessage, "\r\n") {
+			return fmt.Errorf("config: tools[%s].cancel_message must be a single line", name)
+		}
+		if t.CancelGraceMS < 0 || time.Duration(t.CancelGraceMS)*time.Millisecond > MaxShutdownGrace {
+			return fmt.Errorf("config: tools[%s].cancel_grace_ms must be between 0 and %d", name, MaxShutdownGrace.Milliseconds())
+		}
+
+		switch t.WorkspaceAccess {
+		case "", WorkspaceAccessRW, WorkspaceAccessRO:
+		case WorkspaceAccessNone:
+			if t.WorkspaceSubdir != "" || t.Workdir != "" {
+				return fmt.Errorf("config: tools[%s].workspace_subdir/workdir require workspace_access rw or ro", name)
+			}
+		default:
+			return fmt.Errorf("config: tools[%s].workspace_access must be rw, ro or none", name)
+		}
+		switch t.Workspace {
+		case "", WorkspaceShared:
+			if t.WorkspaceRetain != "" {
+				return fmt.Errorf("config: tools[%s].workspace_retain requires workspace: ephemeral", name)
+			}
+		case WorkspaceEphemeral:
+			if (t.Runtime != "native" && t.Runtime != "container") || t.Federate || t.Reuse != nil || t.Mode == "daemon" {
+				return fmt.Errorf("config: tools[%s].workspace ephemeral is only supported for non-federated native and container launcher tools without reuse", name)
+			}
+			if t.WorkspaceSubdir != "" || t.WorkspaceAccess == WorkspaceAccessNone {
+				return fmt.Errorf("config: tools[%s].workspace ephemeral cannot be combined with workspace_subdir or workspace_access none", name)
+			}
+		default:
+			return fmt.Errorf("config: tools[%s].workspace must be shared or ephemeral", name)
+		}
+		switch t.WorkspaceRetain {
+		case "", WorkspaceRetainNever, WorkspaceRetainOnFailure, WorkspaceRetainAlways:
+		default:
+			return fmt.Errorf("config: tools[%s].workspace_retain must be never, on_failure or always", name)
+		}
+		if t.User != "" {
+			if _, _, err := ParseUser(t.User); err != nil {
+				return fmt.Errorf("config: tools[%s].user: %w", name, err)
+			}
+		}
+		if err := c.validateMounts(name, t); err != nil {
+			return err
+		}
+		if t.Reuse != nil {
+			if t.Runtime != "container" || t.Federate {
+				return fmt.Errorf("config: tools[%s].reuse is only supported for non-federated container tools", name)
+			}
+			if err := t.Reuse.validate(name); err != nil {
+				return err
+			}
+		}
+		switch t.Output {
+		case "", OutputText:
+		case OutputBinary:
+			if t.TTY || t.Federate || t.Reuse != nil {
+				return fmt.Errorf("config: tools[%s].output binary cannot be combined with tty, federate or reuse", name)
+			}
+		default:
+			return fmt.Errorf("config: tools[%s].output must be text or binary", name)
+		}
+		if t.Interactive && ((t.Runtime != "native" && t.Runtime != "container") || t.Federate || t.Reuse != nil) {
+			return fmt.Errorf("config: tools[%s].interactive is only supported for non-federated native and container tools without reuse", name)
+		}
+		if t.StreamBody && ((t.Runtime != "native" && t.Runtime != "container") || t.Federate || t.Reuse != nil || t.Mode == "daemon" || t.TTY) {
+			return fmt.Errorf("config: tools[%s].stream_body is only supported for non-federated native and container launcher tools without reuse or tty", name)
+		}
+		if t.MaxBodyBytes < 0 || t.MaxBodyBytes > MaxMaxBodyBytes {
+			return fmt.Errorf("config: tools[%s].max_body_bytes must be between 0 and %d", name, int64(MaxMaxBodyBytes))
+		}
+		if t.MaxBodyBytes > 0 && !t.StreamBody {
+			return fmt.Errorf("config: tools[%s].max_body_bytes requires stream_body", name)
+		}
+		if t.TTY {
+			if t.Runtime != "native" && t.Runtime != "container" {
+				return fmt.Errorf("config: tools[%s].tty is only supported for native and container runtimes", name)
+			}
+			if t.Federate || t.Reuse != nil {
+				return fmt.Errorf("config: tools[%s].tty cannot be combined with federate or reuse", name)
+			}
+		}
+		if t.Health != nil {
+			if t.Reuse == nil {
+				return fmt.Errorf("config: tools[%s].health requires reuse", name)
+			}
+			if err := t.Health.validate(name); err != nil {
+				return err
+			}
+		}
+		if t.EgressAllow != nil {
+			if t.Runtime != "native" || t.Sandbox != "" {
+				return fmt.Errorf("config: tools[%s].egress_allow is only supported for native tools without sandbox (containers use docker_network)", name)
+			}
+			for _, p := range t.EgressAllow {
+				if !validEgressPattern(p) {
+					return fmt.Errorf("config: tools[%s].egress_allow: invalid domain %q (use host, *.domain or *)", name, p)
+				}
+			}
+		}
+		if t.Sandbox != "" && t.Runtime != "native" {
+			return fmt.Errorf("config: tools[%s].sandbox is only supported for native runtime", name)
+		}
+		for key, p := range map[string]string{"workspace_subdir": t.WorkspaceSubdir, "workdir": t.Workdir} {
+			if p != "" && !validRelPath(p) {
+				return fmt.Errorf("config: tools[%s].%s must be a relative path inside the workspace", name, key)
+			}
+		}
+		if err := t.Retry.validate(name); err != nil {
+			return err
+		}
+		for i, e := range t.Events {
+			if err := e.validate(name, i); err != nil {
+				return err
+			}
+		}
+		if !validSlowClientPolicy(t.SlowClient) {
+			return fmt.Errorf("config: tools[%s].slow_client must be block, drop or disconnect", name)
+		}
+		if t.MaxOutputBytes < 0 || t.MaxOutputLines < 0 {
+			return fmt.Errorf("config: tools[%s].max_output_bytes/max_output_lines must be >= 0", name)
+		}
+		if t.MaxLineBytes < 0 || t.MaxLineBytes > MaxMaxLineBytes {
+			return fmt.Errorf("config: tools[%s].max_line_bytes must be between 0 and %d", name, MaxMaxLineBytes)
+		}
+		switch t.LongLines {
+		case "", LongLinesSplit, LongLinesTruncate:
+		default:
+			return fmt.Errorf("config: tools[%s].long_lines must be split or truncate", name)
+		}
+		if err := t.Redaction.validate("tools[" + name + "].redaction"); err != nil {
+			return err
+		}
+		if t.InputSchema != nil {
+			if _, err := jsonschema.Compile(t.InputSchema); err != nil {
+				return fmt.Errorf("config: tools[%s].input_schema: %w", name, err)
+			}
+		}
+		if t.OutputSchema != nil {
+			if _, err := jsonschema.Compile(t.OutputSchema); err != nil {
+				return fmt.Errorf("config: tools[%s].output_schema: %w", name, err)
+			}
+			if t.Output == OutputBinary {
+				return fmt.Errorf("config: tools[%s].output_schema is not supported with output: binary", name)
+			}
+		}
+		switch t.OutputValidation {
+		case "", OutputValidationWarn, OutputValidationStrict:
+		default:
+			return fmt.Errorf("config: tools[%s].output_validation must be warn or strict", name)
+		}
+		if t.OutputValidation != "" && t.OutputSchema == nil {
+			return fmt.Errorf("config: tools[%s].output_validation requires output_schema", name)
+		}
+		for _, tag := range t.Tags {
+			if tag == "" || strings.ContainsAny(tag, ", \t") {
+				return fmt.Errorf("config: tools[%s].tags must be non-empty and contain no spaces or commas", name)
+			}
+		}
+		switch strings.ToLower(t.LogLevel) {
+		case "", "debug", "info", "warn", "error":
+		default:
+			return fmt.Errorf("config: tools[%s].log_level must be debug, info, warn or error", name)
+		}
+		switch t.Priority {
+		case "", PriorityHigh, PriorityNormal, PriorityLow:
+		default:
+			return fmt.Errorf("config: tools[%s].priority must be high, normal or low", name)
+		}
+
+		// ---- Streaming invariants ----
+		if t.FlushIntervalMS < 0 || time.Duration(t.FlushIntervalMS)*time.Millisecond > MaxFlushInterval {
+			return fmt.Errorf("config: tools[%s].flush_interval_ms must be between 0 and %d", name, MaxFlushInterval.Milliseconds())
+		}
+		if t.WriteBufferBytes < 0 || t.WriteBufferBytes > MaxWriteBuffer {
+			return fmt.Errorf("config: tools[%s].write_buffer_bytes must be between 0 and %d", name, MaxWriteBuffer)
+		}
+
+		// ---- Concurrency invariants ----
+		if t.MaxConcurrent < 0 {
+			return fmt.Errorf("config: tools[%s].max_concurrent must be >= 0", name)
+		}
+		if t.QueueTimeoutMS < 0 || time.Duration(t.QueueTimeoutMS)*time.Millisecond > MaxToolTimeout {
+			return fmt.Errorf("config: tools[%s].queue_timeout_ms must be between 0 and %d", name, MaxToolTimeout.Milliseconds())
+		}
+		if t.MaxConcurrent > MaxAllowedConcurrency {
+			return fmt.Errorf(
+				"config: tools[%s].max_concurrent must be <= %d",
+				name,
+				MaxAllowedConcurrency,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Timeout retorna o timeout efetivo da tool.
+// Invariante do core: NENHUMA tool roda sem timeout.
+func (t Tool) Timeout() time.Duration {
+	if t.TimeoutMS <= 0 {
+		return DefaultToolTimeout
+	}
+	return time.Duration(t.TimeoutMS) * time.Millisecond
+}
+
+// MaxTimeout retorna o maior timeout que o cliente pode pedir (X-MCP-Timeout); sem
+// max_timeout_ms, é o próprio timeout da tool.
+func (t Tool) MaxTimeout() time.Duration {
+	return max(t.Timeout(), time.Duration(t.MaxTimeoutMS)*time.Millisecond)
+}
+
+// StartupTimeout retorna o prazo para a primeira linha de saída (0 = sem prazo próprio).
+func (t Tool) StartupTimeout() time.Duration {
+	if t.StartupTimeoutMS <= 0 {
+		return 0
+	}
+	return time.Duration(t.StartupTimeoutMS) * time.Millisecond
+}
+
+// ToolSelftest é o probe de uma tool no selftest.
+type ToolSelftest struct {
+	Input map[string]any `yaml:"input"` // enviado como input (benigno: sem efeito colateral)
+	Skip  bool           `yaml:"skip"`  // fora do selftest (ex: tools que cobram por chamada)
+}
+
+// DefaultSelftestTimeout é o prazo do selftest de tools sem startup_timeout_ms.
+const DefaultSelftestTimeout = 10 * time.Second
+
+// SelftestTimeout é o prazo do selftest: startup_timeout_ms ou DefaultSelftestTimeout.
+func (t Tool) SelftestTimeout() time.Duration {
+	if d := t.StartupTimeout(); d > 0 {
+		return d
+	}
+	return DefaultSelftestTimeout
+}
+
+// ShutdownGrace retorna a espera efetiva entre SIGTERM e SIGKILL.
+func (t Tool) ShutdownGrace() time.Duration {
+	if t.ShutdownGraceMS <= 0 {
+		return DefaultShutdownGrace
+	}
+	return time.Duration(t.ShutdownGraceMS) * time.Millisecond
+}
+
+// ShutdownEffective retorna como a tool é encerrada (default both).
+func (t Tool) ShutdownEffective() string {
+	if t.Shutdown == "" {
+		return ShutdownBoth
+	}
+	return t.Shutdown
+}
+
+// Cooperative diz se o cancelamento da tool é em duas fases (cancellation: cooperative).
+func (t Tool) Cooperative() bool {
+	return t.Cancellation == CancellationCooperative
+}
+
+// CancelGrace retorna a espera entre a mensagem de cancelamento e o kill.
+func (t Tool) CancelGrace() time.Duration {
+	if t.CancelGraceMS <= 0 {
+		return DefaultCancelGrace
+	}
+	return time.Duration(t.CancelGraceMS) * time.Millisecond
+}
+
+// CancelMessageFor retorna a linha de cancelamento da request rid.
+func (t Tool) CancelMessageFor(rid string) string {
+	msg := t.CancelMessage
+	if msg == "" {
+		msg = DefaultCancelMessage
+	}
+	return strings.ReplaceAll(msg, "{request_id}", rid)
+}
+
+// MaxBodyBytesEffective retorna o teto do body em stream (stream_body).
+func (t Tool) MaxBodyBytesEffective() int64 {
+	if t.MaxBodyBytes <= 0 {
+		return DefaultMaxBodyBytes
+	}
+	return t.MaxBodyBytes
+}
+
+// MaxLineBytesEffective retorna o tamanho a partir do qual uma linha é tratada como gigante.
+func (t Tool) MaxLineBytesEffective() int {
+	if t.MaxLineBytes <= 0 {
+		return DefaultMaxLineBytes
+	}
+	return t.MaxLineBytes
+}
+
+// LongLinesEffective retorna a política efetiva para linhas gigantes (default split).
+func (t Tool) LongLinesEffective() string {
+	if t.LongLines == "" {
+		return LongLinesSplit
+	}
+	return t.LongLines
+}
+
+// OutputValidationEffective retorna o modo de validação do output (default warn).
+func (t Tool) OutputValidationEffective() string {
+	if t.OutputValidation == "" {
+		return OutputValidationWarn
+	}
+	return t.OutputValidation
+}
+
+// PriorityEffective retorna a classe de prioridade da tool (default normal).
+func (t Tool) PriorityEffective() string {
+	if t.Priority == "" {
+		return PriorityNormal
+	}
+	return t.Priority
+}
+
+// QueueTimeout retorna a espera máxima por slot de concorrência (0 = fail-fast).
+func (t Tool) QueueTimeout() time.Duration {
+	if t.QueueTimeoutMS <= 0 {
+		return 0
+	}
+	return time.Duration(t.QueueTimeoutMS) * time.Millisecond
+}
+
+// FlushInterval retorna a janela de flush do streaming HTTP (0 = flush por linha).
+func (t Tool) FlushInterval() time.Duration {
+	if t.FlushIntervalMS <= 0 {
+		return 0
+	}
+	return min(time.Duration(t.FlushIntervalMS)*time.Millisecond, MaxFlushInterval)
+}
+
+// NamespaceEffective retorna o prefixo da tool na federação (default: nome da tool).
+func (t Tool) NamespaceEffective(name string) string {
+	if t.Namespace == "" {
+		return name
+	}
+	return t.Namespace
+}
+
+// validEgressPattern aceita "*", "*.dominio" ou um host (sem esquema, porta ou caminho).
+func validEgressPattern(p string) bool {
+	if p == "*" {
+		return true
+	}
+	p = strings.TrimPrefix(p, "*.")
+	if p == "" || len(p) > 253 {
+		return false
+	}
+	for _, ch := range p {
+		if !((ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '-' || ch == '.' || ch == '_') {
+			return false
+		}
+	}
+	return !strings.HasPrefix(p, ".") && !strings.HasSuffix(p, ".") && !strings.Contains(p, "..")
+}
+
+func validNamespace(ns string) bool {
+	if ns == "" {
+		return false
+	}
+	for _, ch := range ns {
+		if !((ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '-' || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// MaxConc retorna o limite efetivo de concorrência da tool.
+// Default conservador para evitar fork-bomb acidental.
+func (t Tool) MaxConc() int {
+	if t.MaxConcurrent <= 0 {
+		return DefaultMaxConcurrent
+	}
+	if t.MaxConcurrent > MaxAllowedConcurrency {
+		return MaxAllowedConcurrency
+	}
+	return t.MaxConcurrent
+}
+
+// DockerNetworkEffective retorna o modo efetivo de rede para container.
+// Default conservador: "none".
+func (t Tool) DockerNetworkEffective() string {
+	if t.DockerNetwork == "" {
+		return DefaultDockerNetwork
+	}
+	switch t.DockerNetwork {
+	case "none", "bridge":
+		return t.DockerNetwork
+	default:
+		return DefaultDockerNetwork
+	}
+}
+
+// PullPolicyEffective retorna always, if-not-present ou never (default: if-not-present).
+func (t Tool) PullPolicyEffective() string {
+	if t.PullPolicy == "" {
+		return DefaultPullPolicy
+	}
+	return t.PullPolicy
+}
+
+// WorkspaceAccessEffective retorna rw, ro ou none (default: rw).
+func (t Tool) WorkspaceAccessEffective() string {
+	if t.WorkspaceAccess == "" {
+		return DefaultWorkspaceAccess
+	}
+	return t.WorkspaceAccess
+}
+
+// Ephemeral indica workspace: ephemeral (um diretório por request).
+func (t Tool) Ephemeral() bool {
+	return t.Workspace == WorkspaceEphemeral
+}
+
+// WorkspaceRetainEffective retorna never, on_failure ou always (default: never).
+func (t Tool) WorkspaceRetainEffective() string {
+	if t.WorkspaceRetain == "" {
+		return WorkspaceRetainNever
+	}
+	return t.WorkspaceRetain
+}
+
+// ReadOnlyEffective retorna se o container deve rodar read-only.
+// Default conservador: true (quando omitido).
+func (t Tool) ReadOnlyEffective() bool {
+	if t.ReadOnly == nil {
+		return DefaultReadOnly
+	}
+	return *t.ReadOnly
+}
+
+func (c *Config) validateMounts(name string, t Tool) error {
+	if len(t.Mounts) > 0 && t.Runtime != "container" {
+		return fmt.Errorf("config: tools[%s].mounts is only supported for container runtime", name)
+	}
+	for i, m := range t.Mounts {
+		p := fmt.Sprintf("tools[%s].mounts[%d]", name, i)
+		for _, v := range []string{m.Host, m.Container} {
+			if !filepath.IsAbs(v) || strings.ContainsAny(v, ":,") {
+				return fmt.Errorf("config: %s host/container must be absolute paths without ':' or ','", p)
+			}
+		}
+		if m.Mode != "" && m.Mode != WorkspaceAccessRO && m.Mode != WorkspaceAccessRW {
+			return fmt.Errorf("config: %s.mode must be ro or rw", p)
+		}
+		if path.Clean(m.Container) == "/" || path.Clean(m.Container) == "/workspaces" {
+			return fmt.Errorf("config: %s.container must not shadow / or /workspaces", p)
+		}
+		if !c.MountAllowed(m.Host) {
+			return fmt.Errorf("config: %s.host %q is outside mount_roots", p, m.Host)
+		}
+	}
+	return nil
+}
+
+// MountAllowed indica se host está dentro de alguma das mount_roots.
+// O runtime chama de novo com o caminho já resolvido (symlinks) antes do spawn.
+func (c *Config) MountAllowed(host string) bool {
+	host = filepath.Clean(host)
+	for _, root := range c.MountRoots {
+		root = filepath.Clean(root)
+		if root == "" || !filepath.IsAbs(root) {
+			continue
+		}
+		if host == root || strings.HasPrefix(host, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCredentialWorkspaces confere que os workspaces das credenciais existem em workspaces:.
+func (c *Config) validateCredentialWorkspaces() error {
+	check := func(kind, name string, list []string) error {
+		for _, ws := range list {
+			if _, ok := c.Workspaces[ws]; !ok && ws != "*" {
+				return fmt.Errorf("config: auth.%s[%s].workspaces: unknown workspace %q", kind, name, ws)
+			}
+		}
+		return nil
+	}
+	for _, k := range c.Auth.APIKeys {
+		if err := check("api_keys", k.Name, k.Workspaces); err != nil {
+			return err
+		}
+	}
+	for _, cl := range c.Auth.HMAC.Clients {
+		if err := check("hmac.clients", cl.Name, cl.Workspaces); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WorkspaceFor resolve a raiz do workspace nomeado ("" = workspace_root).
+func (c *Config) WorkspaceFor(name string) (string, bool) {
+	if name == "" {
+		return c.WorkspaceRoot, true
+	}
+	root, ok := c.Workspaces[name]
+	return root, ok
+}
+
+// WithWorkspaceRoot é uma cópia rasa do config com outra raiz de workspace (request com
+// X-MCP-Workspace); o resto é compartilhado e não deve ser alterado.
+func (c *Config) WithWorkspaceRoot(root string) *Config {
+	if root == c.WorkspaceRoot {
+		return c
+	}
+	cc := *c
+	cc.WorkspaceRoot = root
+	return &cc
+}
+
+// ToolUser retorna o "uid[:gid]" efetivo da tool ("" = native herda o usuário do gateway).
+func (c *Config) ToolUser(t Tool) string {
+	switch {
+	case t.User != "":
+		return t.User
+	case c.User != "":
+		return c.User
+	case t.Runtime == "container":
+		return DefaultContainerUser
+	}
+	return ""
+}
+
+// ParseUser interpreta "uid[:gid]" numérico (gid omitido = uid).
+// Nomes não são aceitos: o mesmo nome pode ter outro uid dentro da imagem.
+func ParseUser(s string) (uid, gid int, err error) {
+	us, gs, hasGID := strings.Cut(s, ":")
+	uid, err = strconv.Atoi(us)
+	if err != nil || uid < 0 {
+		return 0, 0, fmt.Errorf("invalid user %q (use numeric uid[:gid])", s)
+	}
+	if !hasGID {
+		return uid, uid, nil
+	}
+	gid, err = strconv.Atoi(gs)
+	if err != nil || gid < 0 {
+		return 0, 0, fmt.Errorf("invalid user %q (use numeric uid[:gid])", s)
+	}
+	return uid, gid, nil
+}
+
+// validAppArmorName aceita nomes de perfil simples (ex: docker-default, mcp-gw.strict).
+func validAppArmorName(p string) bool {
+	for _, ch := range p {
+		if !((ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// validRelPath rejeita caminhos absolutos e com ".." (a validação completa, com symlinks,
+// é feita pelo sandbox no spawn, quando o workspace existe).
+func validRelPath(p string) bool {
+	if filepath.IsAbs(p) || strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\`) {
+		return false
+	}
+	for _, part := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Retry) validate(name string) error {
+	if r.Attempts < 0 || r.Attempts > MaxRetryAttempts {
+		return fmt.Errorf("config: tools[%s].retry.attempts must be between 0 and %d", name, MaxRetryAttempts)
+	}
+	if r.BackoffMS < 0 || time.Duration(r.BackoffMS)*time.Millisecond > MaxRetryBackoff {
+		return fmt.Errorf("config: tools[%s].retry.backoff_ms must be between 0 and %d", name, MaxRetryBackoff.Milliseconds())
+	}
+	for _, on := range r.On {
+		if on != RetryOnSpawnError && on != RetryOnNonzeroExit && on != RetryOnStartupTimeout {
+			return fmt.Errorf("config: tools[%s].retry.on must contain only %s, %s or %s", name, RetryOnSpawnError, RetryOnNonzeroExit, RetryOnStartupTimeout)
+		}
+	}
+	return nil
+}
+
+// AttemptsEffective retorna o total de tentativas (mínimo 1).
+func (r Retry) AttemptsEffective() int {
+	return max(r.Attempts, 1)
+}
+
+// Backoff retorna a espera antes do primeiro retry.
+func (r Retry) Backoff() time.Duration {
+	if r.BackoffMS <= 0 {
+		return DefaultRetryBackoff
+	}
+	return time.Duration(r.BackoffMS) * time.Millisecond
+}
+
+// RetriesOn indica se a falha do tipo kind deve ser repetida.
+func (r Retry) RetriesOn(kind string) bool {
+	if len(r.On) == 0 {
+		return kind == RetryOnSpawnError || kind == RetryOnNonzeroExit
+	}
+	for _, on := range r.On {
+		if on == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (o KVOptions) validate(name string) error {
+	if o.MaxKeys < 0 {
+		return fmt.Errorf("config: tools[%s].kv.max_keys must be >= 0", name)
+	}
+	if o.MaxValueBytes < 0 || o.MaxValueBytes > MaxKVValueBytes {
+		return fmt.Errorf("config: tools[%s].kv.max_value_bytes must be between 0 and %d", name, MaxKVValueBytes)
+	}
+	if o.DefaultTTLMS < 0 || o.MaxTTLMS < 0 {
+		return fmt.Errorf("config: tools[%s].kv ttl values must be >= 0", name)
+	}
+	if time.Duration(o.MaxTTLMS)*time.Millisecond > MaxKVTTL {
+		return fmt.Errorf("config: tools[%s].kv.max_ttl_ms must be <= %d", name, MaxKVTTL.Milliseconds())
+	}
+	if o.DefaultTTL() > o.MaxTTL() {
+		return fmt.Errorf("config: tools[%s].kv.default_ttl_ms must be <= max_ttl_ms", name)
+	}
+	return nil
+}
+
+// MaxKeysEffective retorna o limite de chaves por namespace.
+func (o KVOptions) MaxKeysEffective() int {
+	if o.MaxKeys <= 0 {
+		return DefaultKVMaxKeys
+	}
+	return o.MaxKeys
+}
+
+// MaxValueBytesEffective retorna o tamanho máximo de um valor (JSON serializado).
+func (o KVOptions) MaxValueBytesEffective() int {
+	if o.MaxValueBytes <= 0 {
+		return DefaultKVMaxValueBytes
+	}
+	return o.MaxValueBytes
+}
+
+// DefaultTTL retorna o TTL aplicado quando o set não informa ttl_ms.
+func (o KVOptions) DefaultTTL() time.Duration {
+	if o.DefaultTTLMS <= 0 {
+		return min(DefaultKVTTL, o.MaxTTL())
+	}
+	return time.Duration(o.DefaultTTLMS) * time.Millisecond
+}
+
+// MaxTTL retorna o teto de TTL de uma chave.
+func (o KVOptions) MaxTTL() time.Duration {
+	if o.MaxTTLMS <= 0 {
+		return MaxKVTTL
+	}
+	return time.Duration(o.MaxTTLMS) * time.Millisecond
+}
+
+func (s Storage) validate() error {
+	switch s.BackendEffective() {
+	case "local":
+		return nil
+	case "s3":
+		if s.S3.Endpoint == "" {
+			return fmt.Errorf("config: storage.s3.endpoint is required for s3 backend")
+		}
+		if s.S3.Bucket == "" {
+			return fmt.Errorf("config: storage.s3.bucket is required for s3 backend")
+		}
+		return nil
+	default:
+		return fmt.Errorf("config: storage.backend must be local or s3")
+	}
+}
+
+// BackendEffective retorna o backend de storage efetivo (default: local).
+func (s Storage) BackendEffective() string {
+	if s.Backend == "" {
+		return DefaultStorageBackend
+	}
+	return s.Backend
+}
+
+// PathEffective retorna o diretório base do backend local.
+func (s Storage) PathEffective() string {
+	if s.Path == "" {
+		return DefaultStoragePath
+	}
+	return s.Path
+}
+
+func (f Flags) validate() error {
+	for _, list := range [][]string{f.Allowed, f.Default} {
+		for _, name := range list {
+			if !validFlagName(name) {
+				return fmt.Errorf("config: invalid flag name %q (use a-z, 0-9, _ and -)", name)
+			}
+		}
+	}
+	return nil
+}
+
+func validFlagName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, ch := range name {
+		if !((ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') || ch == '_' || ch == '-') {
+			return false
+		}
+	}
+	return name[0] != '-'
+}
+
+func validSlowClientPolicy(p string) bool {
+	switch p {
+	case "", "block", "drop", "disconnect":
+		return true
+	}
+	return false
+}
+
+// BufferLinesEffective retorna o tamanho do buffer de saída por stream (linhas).
+func (s Stream) BufferLinesEffective() int {
+	if s.BufferLines <= 0 {
+		return DefaultStreamBufferLines
+	}
+	return s.BufferLines
+}
+
+// ResumeWindow é a janela de reconexão de streams SSE (0 = resume desligado).
+func (s Stream) ResumeWindow() time.Duration {
+	return time.Duration(s.ResumeWindowMS) * time.Millisecond
+}
+
+// SlowClientPolicy retorna a política efetiva da tool (override da tool > stream > default).
+func (c *Config) SlowClientPolicy(t Tool) string {
+	switch {
+	case t.SlowClient != "":
+		return t.SlowClient
+	case c.Stream.SlowClient != "":
+		return c.Stream.SlowClient
+	default:
+		return DefaultSlowClientPolicy
+	}
+}
+
+// OrphanGCInterval retorna o intervalo efetivo do reaper de containers órfãos.
+func (c *Config) OrphanGCInterval() time.Duration {
+	if c.OrphanGCIntervalMS <= 0 {
+		return DefaultOrphanGCInterval
+	}
+	return time.Duration(c.OrphanGCIntervalMS) * time.Millisecond
+}
+
+// ShutdownDrain retorna a janela de drain efetiva do shutdown gracioso.
+func (c *Config) ShutdownDrain() time.Duration {
+	if c.ShutdownDrainMS <= 0 {
+		return DefaultShutdownDrain
+	}
+	return time.Duration(c.ShutdownDrainMS) * time.Millisecond
+}
+
+// SecretEnvNames são as variáveis de ambiente com segredos do próprio gateway: token admin,
+// token da fonte de config, key_env das api_keys, secret_env do hmac e as credenciais S3 do
+// storage. O runtime as tira do ambiente herdado pelas tools.
+func (c *Config) SecretEnvNames() []string {
+	admin := c.Admin.TokenEnv
+	if admin == "" {
+		admin = DefaultAdminTokenEnv
+	}
+	names := []string{admin, ConfigTokenEnv}
+	for _, k := range c.Auth.APIKeys {
+		if k.KeyEnv != "" {
+			names = append(names, k.KeyEnv)
+		}
+	}
+	for _, h := range c.Auth.HMAC.Clients {
+		if h.SecretEnv != "" {
+			names = append(names, h.SecretEnv)
+		}
+	}
+	if c.Storage.Backend == "s3" {
+		ak, sk := c.Storage.S3.AccessKeyEnv, c.Storage.S3.SecretKeyEnv
+		if ak == "" {
+			ak = "AWS_ACCESS_KEY_ID"
+		}
+		if sk == "" {
+			sk = "AWS_SECRET_ACCESS_KEY"
+		}
+		names = append(names, ak, sk)
+	}
+	return names
+}
+
+// Token retorna o token admin do ambiente ("" = endpoints admin desligados).
+func (a Admin) Token() string {
+	env := a.TokenEnv
+	if env == "" {
+		env = DefaultAdminTokenEnv
+	}
+	return os.Getenv(env)
 }
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"maps"
+	"strings"
+)
+
+// Tools de diagnóstico embutidas: sempre presentes (mesmo com um config mínimo), rodam
+// dentro do gateway e servem para validar transporte, flush do SSE, timeouts e auth ponta
+// a ponta sem configurar binário externo. O prefixo "_" é reservado para elas.
+const (
+	DiagnosticPrefix = "_"
+
+	DiagEcho    = "_echo"    // devolve o input (repeat/interval_ms: várias linhas espaçadas)
+	DiagSleep   = "_sleep"   // dorme ms e responde (testa timeout/cancel)
+	DiagEnv     = "_env"     // contexto da request visto pelo gateway (identidade, request id, flags)
+	DiagVersion = "_version" // versão/build do gateway
+)
+
+// diagnosticMaxConcurrent permite testes de carga leves sem esbarrar no default (1).
+const diagnosticMaxConcurrent = 8
+
+// DiagnosticTools retorna as definições das tools de diagnóstico (runtime builtin).
+func DiagnosticTools() map[string]Tool {
+	tool := func(builtin, desc string) Tool {
+		return Tool{
+			Runtime:       "builtin",
+			Mode:          "launcher",
+			Builtin:       builtin,
+			Description:   desc,
+			Tags:          []string{"diagnostic"},
+			MaxConcurrent: diagnosticMaxConcurrent,
+		}
+	}
+	return map[string]Tool{
+		DiagEcho:    tool("echo", "Echoes the input back; repeat/interval_ms emit several spaced lines"),
+		DiagSleep:   tool("sleep", "Sleeps for ms milliseconds, then answers"),
+		DiagEnv:     tool("env", "Request context as seen by the gateway (client, request id, session, flags)"),
+		DiagVersion: tool("version", "Gateway version and build information"),
+	}
+}
+
+// IsDiagnosticTool diz se name está no espaço reservado das tools de diagnóstico.
+func IsDiagnosticTool(name string) bool {
+	return strings.HasPrefix(name, DiagnosticPrefix)
+}
+
+// WithDiagnosticTools retorna uma cópia rasa de c com as tools de diagnóstico somadas
+// a Tools (c não é alterado: diff/validação continuam vendo só o que está no arquivo).
+func (c *Config) WithDiagnosticTools() *Config {
+	out := *c
+	out.Tools = maps.Clone(c.Tools)
+	if out.Tools == nil {
+		out.Tools = make(map[string]Tool)
+	}
+	maps.Copy(out.Tools, DiagnosticTools())
+	return &out
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Tipos de mudança de tool no Diff.
+const (
+	ChangeAdded   = "added"
+	ChangeChanged = "changed"
+	ChangeRemoved = "removed"
+)
+
+// ToolChange descreve a mudança de uma tool entre dois configs.
+type ToolChange struct {
+	Name   string   `json:"name"`
+	Kind   string   `json:"kind"`             // added | changed | removed
+	Fields []string `json:"fields,omitempty"` // changed: chaves yaml alteradas (ex: "timeout_ms", "kv.max_keys")
+}
+
+// Diff é a comparação entre o config em execução e um proposto.
+type Diff struct {
+	Tools    []ToolChange `json:"tools"`
+	Settings []string     `json:"settings,omitempty"` // chaves globais alteradas (fora de tools)
+}
+
+// Empty indica que os configs são equivalentes.
+func (d Diff) Empty() bool {
+	return len(d.Tools) == 0 && len(d.Settings) == 0
+}
+
+// String formata o diff para terminal (+ added, ~ changed, - removed).
+func (d Diff) String() string {
+	if d.Empty() {
+		return "no changes\n"
+	}
+	var sb strings.Builder
+	for _, t := range d.Tools {
+		switch t.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(&sb, "+ tools.%s\n", t.Name)
+		case ChangeRemoved:
+			fmt.Fprintf(&sb, "- tools.%s\n", t.Name)
+		default:
+			fmt.Fprintf(&sb, "~ tools.%s (%s)\n", t.Name, strings.Join(t.Fields, ", "))
+		}
+	}
+	for _, s := range d.Settings {
+		fmt.Fprintf(&sb, "~ %s\n", s)
+	}
+	return sb.String()
+}
+
+// Compare calcula o diff de old para next (tools ordenadas por nome).
+func Compare(old, next *Config) Diff {
+	d := Diff{Tools: []ToolChange{}}
+
+	names := make(map[string]struct{})
+	for n := range old.Tools {
+		names[n] = struct{}{}
+	}
+	for n := range next.Tools {
+		names[n] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+
+	for _, n := range sorted {
+		a, inOld := old.Tools[n]
+		b, inNew := next.Tools[n]
+		switch {
+		case !inOld:
+			d.Tools = append(d.Tools, ToolChange{Name: n, Kind: ChangeAdded})
+		case !inNew:
+			d.Tools = append(d.Tools, ToolChange{Name: n, Kind: ChangeRemoved})
+		default:
+			if fields := diffFields(reflect.ValueOf(a), reflect.ValueOf(b), ""); len(fields) > 0 {
+				d.Tools = append(d.Tools, ToolChange{Name: n, Kind: ChangeChanged, Fields: fields})
+			}
+		}
+	}
+
+	// globais: compara tudo menos tools (já detalhado acima)
+	oc, nc := *old, *next
+	oc.Tools, nc.Tools = nil, nil
+	d.Settings = diffFields(reflect.ValueOf(oc), reflect.ValueOf(nc), "")
+	return d
+}
+
+// diffFields lista as chaves yaml (com caminho) que diferem; desce em structs, compara o resto inteiro.
+func diffFields(a, b reflect.Value, prefix string) []string {
+	var out []string
+	fields := yamlFields(a.Type())
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		f := fields[k]
+		av, bv := a.FieldByIndex(f.Index), b.FieldByIndex(f.Index)
+		if f.Type.Kind() == reflect.Struct {
+			out = append(out, diffFields(av, bv, joinPath(prefix, k))...)
+			continue
+		}
+		if !sameValue(av, bv) {
+			out = append(out, joinPath(prefix, k))
+		}
+	}
+	return out
+}
+
+// sameValue é DeepEqual tratando slice/map nil e vazio como iguais (yaml omitido vs []).
+func sameValue(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare_ToolsAndSettings(t *testing.T) {
+	old := &Config{
+		WorkspaceRoot: "/ws",
+		ToolsRoot:     "/tools",
+		Tools: map[string]Tool{
+			"echo": {Runtime: "native", Cmd: "/bin/echo", TimeoutMS: 1000},
+			"kv":   {Runtime: "builtin", Builtin: "kv"},
+			"gone": {Runtime: "native", Cmd: "/bin/true"},
+		},
+	}
+	next := &Config{
+		WorkspaceRoot: "/ws",
+		ToolsRoot:     "/tools",
+		Tools: map[string]Tool{
+			"echo": {Runtime: "native", Cmd: "/bin/echo", TimeoutMS: 2000, Args: []string{}},
+			"kv":   {Runtime: "builtin", Builtin: "kv", KV: KVOptions{MaxKeys: 10}},
+			"new":  {Runtime: "native", Cmd: "/bin/new"},
+		},
+		Stream: Stream{SlowClient: "drop"},
+	}
+
+	d := Compare(old, next)
+	want := []ToolChange{
+		{Name: "echo", Kind: ChangeChanged, Fields: []string{"timeout_ms"}},
+		{Name: "gone", Kind: ChangeRemoved},
+		{Name: "kv", Kind: ChangeChanged, Fields: []string{"kv.max_keys"}},
+		{Name: "new", Kind: ChangeAdded},
+	}
+	if !reflect.DeepEqual(d.Tools, want) {
+		t.Fatalf("tools diff mismatch:\n got %+v\nwant %+v", d.Tools, want)
+	}
+	if !reflect.DeepEqual(d.Settings, []string{"stream.slow_client"}) {
+		t.Fatalf("settings diff mismatch: %v", d.Settings)
+	}
+
+	if !Compare(old, old).Empty() {
+		t.Fatalf("expected empty diff for identical configs")
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue substitui segredos no config efetivo (/admin/config).
+const RedactedValue = "<redacted>"
+
+// Resolved é o config efetivo: include:, defaults/groups e profiles já aplicados às
+// tools, sem as chaves que só descrevem como chegar nele.
+func (c *Config) Resolved() *Config {
+	r := *c
+	r.Include, r.Profiles = nil, nil
+	r.Defaults, r.Groups = Tool{}, nil
+	return &r
+}
+
+// Checksum é o sha256 (hex) do config efetivo serializado. Muda quando qualquer valor
+// efetivo muda (inclusive em includes/conf.d); comentários e formatação não contam.
+func (c *Config) Checksum() string {
+	data, err := yaml.Marshal(c.Resolved())
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Redacted é o config efetivo sem segredos literais: valores de headers (de remote e
+// de sinks de eventos) e credenciais em URLs (user:senha@). Segredos que vêm de
+// variáveis de ambiente (*_env) só aparecem pelo nome.
+func (c *Config) Redacted() *Config {
+	r := c.Resolved()
+	r.Tools = make(map[string]Tool, len(c.Tools))
+	for name, t := range c.Tools {
+		t.Endpoint = redactUserinfo(t.Endpoint)
+		t.Headers = redactHeaders(t.Headers)
+		if len(t.Events) > 0 {
+			evs := make([]EventSink, len(t.Events))
+			for i, ev := range t.Events {
+				ev.URL = redactUserinfo(ev.URL)
+				ev.Headers = redactHeaders(ev.Headers)
+				evs[i] = ev
+			}
+			t.Events = evs
+		}
+		r.Tools[name] = t
+	}
+	r.Storage.S3.Endpoint = redactUserinfo(r.Storage.S3.Endpoint)
+	return r
+}
+
+func redactHeaders(h map[string]string) map[string]string {
+	if h == nil {
+		return nil
+	}
+	out := maps.Clone(h)
+	for k := range out {
+		out[k] = RedactedValue
+	}
+	return out
+}
+
+var userinfoRe = regexp.MustCompile(`://[^/@\s]+@`)
+
+func redactUserinfo(u string) string {
+	return userinfoRe.ReplaceAllString(u, "://"+RedactedValue+"@")
+}
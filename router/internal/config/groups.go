@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Herança de settings das tools (defaults: e groups:), para não repetir o mesmo
+// boilerplate (timeouts, hardening, mounts...) em cada tool:
+//
+//	defaults:            # todas as tools
+//	  timeout_ms: 30000
+//	groups:
+//	  sandboxed:         # tools com group: sandboxed
+//	    read_only: true
+//	    docker_network: none
+//	tools:
+//	  grep:
+//	    group: sandboxed
+//	    runtime: container
+//
+// A tool final é defaults, depois o grupo, depois a própria tool, campo a campo: campos
+// escritos na tool vencem, maps (headers...) são somados chave a chave e listas (args,
+// mounts...) são substituídas inteiras. Vale também para tools de include:/conf.d; o
+// profile ativo é aplicado por cima do resultado.
+
+// inheritance guarda os nós YAML de defaults e groups do arquivo principal: a tool é
+// redecodificada a partir do nó original para que só os campos escritos nela contem
+// como override.
+type inheritance struct {
+	defaults *yaml.Node
+	groups   map[string]*yaml.Node
+}
+
+// loadInheritance lê defaults/groups de data (o arquivo principal) e reaplica nas tools
+// do próprio arquivo.
+func (c *Config) loadInheritance(data []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	doc := docRoot(&root)
+	inh := &inheritance{defaults: mappingValue(doc, "defaults"), groups: make(map[string]*yaml.Node)}
+	if groups := mappingValue(doc, "groups"); groups != nil {
+		for i := 0; i+1 < len(groups.Content); i += 2 {
+			name, node := groups.Content[i].Value, groups.Content[i+1]
+			if mappingValue(node, "group") != nil {
+				return fmt.Errorf("config: groups[%s].group: groups cannot be nested", name)
+			}
+			inh.groups[name] = node
+		}
+	}
+	if mappingValue(inh.defaults, "group") != nil {
+		return fmt.Errorf("config: defaults.group: use group: in each tool")
+	}
+	if inh.defaults == nil && len(inh.groups) == 0 {
+		return nil
+	}
+	c.inherit = inh
+	return c.inheritTools(mappingValue(doc, "tools"), c.Tools, "tools")
+}
+
+// inheritTools redecodifica as tools de um nó tools:/overrides: (em dst) sobre defaults
+// e o grupo de cada uma. Sem defaults/groups no config, não faz nada.
+func (c *Config) inheritTools(node *yaml.Node, dst map[string]Tool, at string) error {
+	if c.inherit == nil || node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, own := node.Content[i].Value, node.Content[i+1]
+		group := dst[name].Group
+
+		var t Tool
+		if c.inherit.defaults != nil {
+			if err := c.inherit.defaults.Decode(&t); err != nil {
+				return fmt.Errorf("config: defaults: %w", err)
+			}
+		}
+		if group != "" {
+			g, ok := c.inherit.groups[group]
+			if !ok {
+				return fmt.Errorf("config: %s[%s].group %q is not defined in groups", at, name, group)
+			}
+			if err := g.Decode(&t); err != nil {
+				return fmt.Errorf("config: groups[%s]: %w", group, err)
+			}
+		}
+		if err := own.Decode(&t); err != nil {
+			return fmt.Errorf("config: %s[%s]: %w", at, name, err)
+		}
+		dst[name] = t
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromFile_DefaultsAndGroups(t *testing.T) {
+	p := writeConfig(t, `
+workspace_root: /tmp
+tools_root: /tmp
+include: ["more.yaml"]
+defaults:
+  timeout_ms: 9000
+  headers: {x-base: "1"}
+groups:
+  sandboxed:
+    docker_network: none
+    read_only: true
+    args: ["--safe"]
+tools:
+  git:
+    group: sandboxed
+    runtime: container
+    image: alpine/git
+    read_only: false
+    headers: {x-tool: "2"}
+  sh:
+    runtime: native
+    cmd: sh
+    timeout_ms: 1000
+`)
+	writeFile(t, filepath.Join(filepath.Dir(p), "more.yaml"), `
+tools:
+  jq:
+    group: sandboxed
+    runtime: container
+    image: jq
+`)
+
+	cfg, err := LoadFromFile(p)
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	git := cfg.Tools["git"]
+	if git.TimeoutMS != 9000 || git.DockerNetwork != "none" || git.ReadOnly == nil || *git.ReadOnly ||
+		len(git.Args) != 1 || git.Headers["x-base"] != "1" || git.Headers["x-tool"] != "2" {
+		t.Fatalf("git must inherit defaults and group, with its own overrides: %+v", git)
+	}
+	if sh := cfg.Tools["sh"]; sh.TimeoutMS != 1000 || sh.DockerNetwork != "" || len(sh.Args) != 0 {
+		t.Fatalf("sh must inherit only defaults: %+v", sh)
+	}
+	if jq := cfg.Tools["jq"]; jq.TimeoutMS != 9000 || jq.ReadOnly == nil || !*jq.ReadOnly {
+		t.Fatalf("included tools inherit too: %+v", jq)
+	}
+
+	p = writeConfig(t, "workspace_root: /tmp\ntools_root: /tmp\ntools:\n  a: {runtime: native, cmd: sh, group: nope}\n")
+	if _, err := LoadFromFile(p); err == nil || !strings.Contains(err.Error(), `group "nope" is not defined`) {
+		t.Fatalf("unknown group must be rejected, got %v", err)
+	}
+}
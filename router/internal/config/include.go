@@ -0,0 +1,170 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfDDir é o diretório de fragments lido automaticamente ao lado do config
+// (<dir do config>/conf.d/*.yaml, em ordem lexical).
+const ConfDDir = "conf.d"
+
+// fragment é um arquivo incluído (include: ou conf.d). Só define tools:
+//   - tools: tools novas; o mesmo nome em dois arquivos é erro (duplicata acidental)
+//   - overrides: substitui por inteiro uma tool definida antes (no config principal ou
+//     num fragment anterior); override de tool inexistente é erro
+type fragment struct {
+	Tools     map[string]Tool `yaml:"tools"`
+	Overrides map[string]Tool `yaml:"overrides"`
+}
+
+// mergeIncludes soma ao config os fragments, nesta ordem: config principal, include: (na
+// ordem declarada; os matches de cada glob em ordem lexical) e, com confD, conf.d/*.yaml.
+// Caminhos relativos partem do diretório de mainPath ("" = diretório atual). Um arquivo
+// que aparece mais de uma vez (inclusive o próprio config) é lido uma vez só.
+func (c *Config) mergeIncludes(mainPath string, confD bool) error {
+	files, err := c.includeFiles(mainPath, confD)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	mainLabel := mainPath
+	if mainLabel == "" {
+		mainLabel = "config"
+	}
+	origin := make(map[string]string, len(c.Tools))
+	for name := range c.Tools {
+		origin[name] = mainLabel
+	}
+	if c.Tools == nil {
+		c.Tools = make(map[string]Tool)
+	}
+
+	for _, f := range files {
+		frag, node, err := readFragment(f)
+		if err != nil {
+			return err
+		}
+		// defaults/groups do config principal valem também para as tools dos fragments
+		if err := c.inheritTools(mappingValue(node, "tools"), frag.Tools, "tools"); err != nil {
+			return fmt.Errorf("%w (in %s)", err, f)
+		}
+		if err := c.inheritTools(mappingValue(node, "overrides"), frag.Overrides, "overrides"); err != nil {
+			return fmt.Errorf("%w (in %s)", err, f)
+		}
+		for name := range frag.Overrides {
+			if _, ok := origin[name]; !ok {
+				return fmt.Errorf("config: %s: overrides[%s]: tool is not defined before this file", f, name)
+			}
+		}
+		for name := range frag.Tools {
+			if prev, dup := origin[name]; dup {
+				return fmt.Errorf("config: tools[%s] is defined in both %s and %s (use overrides: to replace it)", name, prev, f)
+			}
+		}
+
+		// *_schema_file de um fragment é relativo ao próprio fragment
+		for _, tools := range []map[string]Tool{frag.Tools, frag.Overrides} {
+			if err := (&Config{Tools: tools}).loadSchemaFiles(filepath.Dir(f)); err != nil {
+				return fmt.Errorf("%w (in %s)", err, f)
+			}
+		}
+		for name, t := range frag.Tools {
+			c.Tools[name], origin[name] = t, f
+		}
+		for name, t := range frag.Overrides {
+			c.Tools[name], origin[name] = t, f
+		}
+	}
+	return nil
+}
+
+// includeFiles resolve include: e conf.d para a lista de arquivos, sem repetição.
+func (c *Config) includeFiles(mainPath string, confD bool) ([]string, error) {
+	baseDir := filepath.Dir(mainPath)
+	seen := make(map[string]bool)
+	if mainPath != "" {
+		if abs, err := filepath.Abs(mainPath); err == nil {
+			seen[abs] = true
+		}
+	}
+
+	var files []string
+	add := func(matches []string) {
+		slices.Sort(matches)
+		for _, m := range matches {
+			abs, err := filepath.Abs(m)
+			if err != nil || seen[abs] {
+				continue
+			}
+			seen[abs] = true
+			files = append(files, m)
+		}
+	}
+
+	for _, pat := range c.Include {
+		p := pat
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(baseDir, p)
+		}
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("config: include %q: %w", pat, err)
+		}
+		// glob sem match é válido (diretório vazio); caminho literal inexistente é erro
+		if len(matches) == 0 && !strings.ContainsAny(p, `*?[\`) {
+			return nil, fmt.Errorf("config: include %q: %w", pat, os.ErrNotExist)
+		}
+		add(matches)
+	}
+
+	if confD {
+		var matches []string
+		for _, ext := range []string{"*.yaml", "*.yml"} {
+			m, _ := filepath.Glob(filepath.Join(baseDir, ConfDDir, ext))
+			matches = append(matches, m...)
+		}
+		add(matches)
+	}
+	return files, nil
+}
+
+// readFragment lê um fragment; o nó raiz volta junto (herança de defaults/groups).
+func readFragment(path string) (fragment, *yaml.Node, error) {
+	var frag fragment
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return frag, nil, fmt.Errorf("config: read include %q: %w", path, err)
+	}
+
+	var root yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		if errors.Is(err, io.EOF) {
+			return frag, nil, nil // arquivo vazio
+		}
+		return frag, nil, fmt.Errorf("invalid yaml %q: %w", path, err)
+	}
+	doc := root.Content[0]
+	if doc.Kind == yaml.MappingNode {
+		for i := 0; i < len(doc.Content); i += 2 {
+			if k := doc.Content[i].Value; k != "tools" && k != "overrides" {
+				return frag, nil, fmt.Errorf("config: %s: %q is not allowed in included files (only tools and overrides)", path, k)
+			}
+		}
+	}
+	if err := root.Decode(&frag); err != nil {
+		return frag, nil, fmt.Errorf("invalid yaml %q: %w", path, err)
+	}
+	return frag, doc, nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFromFile_IncludesAndConfD(t *testing.T) {
+	p := writeConfig(t, `
+workspace_root: /tmp
+tools_root: /tmp
+include: ["tools/*.yaml"]
+tools:
+  echo:
+    runtime: native
+    cmd: sh
+`)
+	dir := filepath.Dir(p)
+	writeFile(t, filepath.Join(dir, "tools", "a.yaml"), `
+tools:
+  grep:
+    runtime: native
+    cmd: grep
+    input_schema_file: grep.schema.json
+`)
+	writeFile(t, filepath.Join(dir, "tools", "grep.schema.json"), `{"type":"object"}`)
+	writeFile(t, filepath.Join(dir, ConfDDir, "10-local.yaml"), `
+overrides:
+  echo:
+    runtime: native
+    cmd: cat
+`)
+
+	cfg, err := LoadFromFile(p)
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if cfg.Tools["grep"].InputSchema["type"] != "object" {
+		t.Fatalf("included tool (schema relative to the fragment): %+v", cfg.Tools["grep"])
+	}
+	if cfg.Tools["echo"].Cmd != "cat" {
+		t.Fatalf("conf.d override must replace the tool: %+v", cfg.Tools["echo"])
+	}
+
+	for name, tc := range map[string]struct{ file, body, want string }{
+		"duplicate": {"tools/b.yaml", "tools:\n  echo: {runtime: native, cmd: sh}\n", "defined in both"},
+		"override":  {"tools/b.yaml", "overrides:\n  nope: {runtime: native, cmd: sh}\n", "not defined before"},
+		"top-level": {"conf.d/20.yaml", "workspace_root: /\n", "not allowed in included files"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := filepath.Join(dir, tc.file)
+			writeFile(t, f, tc.body)
+			defer os.Remove(f)
+			if _, err := LoadFromFile(p); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"mcp-router/internal/sandbox"
+)
+
+// Severidade de um Issue reportado por Lint.
+const (
+	IssueError   = "error"
+	IssueWarning = "warning"
+)
+
+// Issue é um problema encontrado na validação estendida do config.
+// Line é 0 quando o problema não está ligado a uma posição no YAML.
+type Issue struct {
+	Level   string `json:"level"`
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s: %s (line %d): %s", i.Level, i.Path, i.Line, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Level, i.Path, i.Message)
+}
+
+// LintFile carrega o YAML e roda Validate + checagens estendidas:
+// - chaves desconhecidas (typos como "timeout" em vez de "timeout_ms")
+// - nomes de tool duplicados (ignorando caixa) ou inválidos para a rota /mcp/<tool>
+// - cmd de tools native que não existe/não é executável neste host
+//
+// Com strict=true, warnings são promovidos a erro.
+// O Config retornado pode ser nil se o YAML não puder ser interpretado.
+func LintFile(path string, strict bool) (*Config, []Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read config file %q: %w", path, err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("invalid yaml %q: %w", path, err)
+	}
+
+	var issues []Issue
+	if len(root.Content) > 0 {
+		issues = append(issues, unknownKeys(root.Content[0], reflect.TypeOf(Config{}), "")...)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		// ex: chaves duplicadas já reportadas acima com linha
+		issues = append(issues, Issue{Level: IssueError, Path: "config", Message: err.Error()})
+		return nil, issues, nil
+	}
+
+	if err := cfg.loadInheritance(data); err != nil {
+		issues = append(issues, Issue{Level: IssueError, Path: "groups", Message: err.Error()})
+	} else if err := cfg.mergeIncludes(path, true); err != nil {
+		issues = append(issues, Issue{Level: IssueError, Path: "include", Message: err.Error()})
+	} else if err := cfg.applyProfile(data, profileFromEnv(), filepath.Dir(path)); err != nil {
+		issues = append(issues, Issue{Level: IssueError, Path: "profiles", Message: err.Error()})
+	} else if err := cfg.Validate(); err != nil {
+		issues = append(issues, Issue{Level: IssueError, Path: "config", Message: err.Error()})
+	}
+	issues = append(issues, cfg.Lint()...)
+
+	if strict {
+		for i := range issues {
+			issues[i].Level = IssueError
+		}
+	}
+
+	return &cfg, issues, nil
+}
+
+// Lint roda checagens que não impedem o boot, mas indicam config provavelmente errado.
+func (c *Config) Lint() []Issue {
+	var issues []Issue
+
+	names := make([]string, 0, len(c.Tools))
+	for name := range c.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		t := c.Tools[name]
+		p := "tools." + name
+
+		if err := sandbox.ValidateToolName(name); err != nil {
+			issues = append(issues, Issue{Level: IssueError, Path: p, Message: "tool name not routable via /mcp/<tool>: " + err.Error()})
+		}
+
+		lower := strings.ToLower(name)
+		if other, ok := seen[lower]; ok {
+			issues = append(issues, Issue{Level: IssueWarning, Path: p, Message: fmt.Sprintf("duplicate tool name (differs from %q only by case)", other)})
+		}
+		seen[lower] = name
+
+		if t.Runtime == "native" && t.Cmd != "" {
+			if err := checkExecutable(t.Cmd); err != nil {
+				issues = append(issues, Issue{Level: IssueWarning, Path: p + ".cmd", Message: err.Error()})
+			}
+		}
+		if t.Runtime == "container" && t.SeccompProfile != "" && t.SeccompProfile != SeccompProfileStrict {
+			if _, err := os.Stat(t.SeccompProfile); err != nil {
+				issues = append(issues, Issue{Level: IssueWarning, Path: p + ".seccomp_profile", Message: "profile file not found on this host: " + err.Error()})
+			}
+		}
+		if t.Runtime == "container" && t.AppArmorProfile != "" {
+			if loaded, ok := appArmorProfileLoaded(t.AppArmorProfile); ok && !loaded {
+				issues = append(issues, Issue{Level: IssueWarning, Path: p + ".apparmor_profile", Message: "profile not loaded on this host"})
+			}
+		}
+		if t.Runtime == "native" && t.Sandbox == "" && t.WorkspaceAccessEffective() == WorkspaceAccessRO {
+			issues = append(issues, Issue{Level: IssueWarning, Path: p + ".workspace_access",
+				Message: "ro is not enforced for native tools (only WORKSPACE_ACCESS=ro in env); use directory permissions, sandbox: bwrap or runtime: container"})
+		}
+		if t.Runtime == "native" && t.Sandbox == SandboxBwrap {
+			if err := checkExecutable("bwrap"); err != nil {
+				issues = append(issues, Issue{Level: IssueWarning, Path: p + ".sandbox", Message: err.Error()})
+			}
+		}
+	}
+
+	return issues
+}
+
+// appArmorProfilesFile lista os perfis AppArmor carregados no kernel.
+const appArmorProfilesFile = "/sys/kernel/security/apparmor/profiles"
+
+// appArmorProfileLoaded verifica se o perfil está carregado (ok=false: AppArmor indisponível aqui).
+func appArmorProfileLoaded(name string) (loaded, ok bool) {
+	data, err := os.ReadFile(appArmorProfilesFile)
+	if err != nil {
+		return false, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// formato: "<nome> (enforce|complain)"
+		if n, _, _ := strings.Cut(line, " ("); n == name {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// checkExecutable verifica se cmd resolve para um executável neste host.
+// Warning (e não erro) porque o config costuma ser validado fora do container do gateway.
+func checkExecutable(cmd string) error {
+	if filepath.IsAbs(cmd) || strings.ContainsRune(cmd, filepath.Separator) {
+		st, err := os.Stat(cmd)
+		if err != nil {
+			return fmt.Errorf("cmd %q not found", cmd)
+		}
+		if st.IsDir() || st.Mode()&0o111 == 0 {
+			return fmt.Errorf("cmd %q is not executable", cmd)
+		}
+		return nil
+	}
+	if _, err := exec.LookPath(cmd); err != nil {
+		return fmt.Errorf("cmd %q not found in PATH", cmd)
+	}
+	return nil
+}
+
+// unknownKeys percorre o YAML comparando com as tags yaml dos structs.
+func unknownKeys(n *yaml.Node, t reflect.Type, path string) []Issue {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+
+	var issues []Issue
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Value == "<<" {
+				issues = append(issues, unknownKeys(v, t, path)...)
+				continue
+			}
+			p := joinPath(path, k.Value)
+			f, ok := fields[k.Value]
+			if !ok {
+				issues = append(issues, Issue{Level: IssueWarning, Path: p, Line: k.Line, Message: "unknown key"})
+				continue
+			}
+			issues = append(issues, unknownKeys(v, f.Type, p)...)
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		seen := make(map[string]int)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i]
+			p := joinPath(path, k.Value)
+			if line, dup := seen[k.Value]; dup {
+				issues = append(issues, Issue{Level: IssueError, Path: p, Line: k.Line, Message: fmt.Sprintf("duplicate key (first defined at line %d)", line)})
+				continue
+			}
+			seen[k.Value] = k.Line
+			issues = append(issues, unknownKeys(n.Content[i+1], t.Elem(), p)...)
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, c := range n.Content {
+			issues = append(issues, unknownKeys(c, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return issues
+}
+
+// yamlFields mapeia nome yaml -> campo (inclui campos ",inline").
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	out := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range yamlFields(ft) {
+					out[k] = v
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		out[name] = f
+	}
+	return out
+}
+
+func joinPath(base, key string) string {
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
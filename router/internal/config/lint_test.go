@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return p
+}
+
+func TestLintFile_UnknownAndDuplicateKeys(t *testing.T) {
+	p := writeConfig(t, `
+workspace_root: /tmp
+tools_root: /tmp
+tools:
+  echo:
+    runtime: native
+    cmd: sh
+    timeout: 5000
+  echo:
+    runtime: native
+    cmd: sh
+`)
+
+	_, issues, err := LintFile(p, false)
+	if err != nil {
+		t.Fatalf("LintFile: %v", err)
+	}
+
+	var unknown, dup bool
+	for _, i := range issues {
+		if i.Path == "tools.echo.timeout" && i.Message == "unknown key" && i.Line == 8 {
+			unknown = true
+		}
+		if i.Path == "tools.echo" && strings.HasPrefix(i.Message, "duplicate key") && i.Level == IssueError {
+			dup = true
+		}
+	}
+	if !unknown || !dup {
+		t.Fatalf("expected unknown+duplicate issues, got %v", issues)
+	}
+}
+
+func TestLintFile_StrictPromotesWarnings(t *testing.T) {
+	p := writeConfig(t, `
+workspace_root: /tmp
+tools_root: /tmp
+tools:
+  echo:
+    runtime: native
+    cmd: /definitely/not/here
+`)
+
+	_, issues, err := LintFile(p, false)
+	if err != nil {
+		t.Fatalf("LintFile: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Level != IssueWarning || issues[0].Path != "tools.echo.cmd" {
+		t.Fatalf("expected one cmd warning, got %v", issues)
+	}
+
+	_, issues, _ = LintFile(p, true)
+	if len(issues) != 1 || issues[0].Level != IssueError {
+		t.Fatalf("expected warning promoted to error in strict mode, got %v", issues)
+	}
+}
+
+func TestJSONSchema_CoversToolFields(t *testing.T) {
+	s := JSONSchema()
+	tools := s["properties"].(map[string]any)["tools"].(map[string]any)
+	tool := tools["additionalProperties"].(map[string]any)
+	props := tool["properties"].(map[string]any)
+
+	for _, k := range []string{"runtime", "cmd", "image", "timeout_ms", "read_only"} {
+		if _, ok := props[k]; !ok {
+			t.Fatalf("schema missing tool property %q", k)
+		}
+	}
+	if props["read_only"].(map[string]any)["type"] != "boolean" {
+		t.Fatalf("read_only should be boolean, got %v", props["read_only"])
+	}
+}
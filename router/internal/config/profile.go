@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileEnv seleciona o profile quando --profile não é passado.
+const ProfileEnv = "MCP_GW_PROFILE"
+
+// Profile é um overlay sobre as tools do config base, selecionado por --profile ou
+// MCP_GW_PROFILE (ex: dev roda como native uma tool que em prod é container).
+//
+// Cada tool do profile é aplicada campo a campo sobre a tool base de mesmo nome: campos
+// presentes no profile substituem os da base, maps (headers...) são somados chave a chave
+// e listas (args, mounts...) são substituídas inteiras. Tool que não existe na base é
+// adicionada.
+type Profile struct {
+	Tools map[string]Tool `yaml:"tools"`
+}
+
+// applyProfile aplica o profile name (vazio = nenhum) sobre c.Tools. data é o YAML do
+// arquivo principal: o overlay é decodificado do nó original para que só os campos
+// escritos no profile mudem. *_schema_file do profile são relativos a baseDir.
+func (c *Config) applyProfile(data []byte, name, baseDir string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := c.Profiles[name]; !ok {
+		avail := make([]string, 0, len(c.Profiles))
+		for p := range c.Profiles {
+			avail = append(avail, p)
+		}
+		if len(avail) == 0 {
+			return fmt.Errorf("config: profile %q is not defined (the config has no profiles)", name)
+		}
+		slices.Sort(avail)
+		return fmt.Errorf("config: profile %q is not defined (profiles: %s)", name, strings.Join(avail, ", "))
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	tools := mappingValue(mappingValue(mappingValue(docRoot(&root), "profiles"), name), "tools")
+	if tools == nil {
+		c.ActiveProfile = name
+		return nil
+	}
+	if c.Tools == nil {
+		c.Tools = make(map[string]Tool)
+	}
+
+	for i := 0; i+1 < len(tools.Content); i += 2 {
+		toolName, overlay := tools.Content[i].Value, tools.Content[i+1]
+		t := c.Tools[toolName]
+		if err := overlay.Decode(&t); err != nil {
+			return fmt.Errorf("config: profiles[%s].tools[%s]: %w", name, toolName, err)
+		}
+
+		// schema_file no profile troca o schema da base (carregado antes do overlay)
+		p := c.Profiles[name].Tools[toolName]
+		files := Tool{InputSchemaFile: p.InputSchemaFile, OutputSchemaFile: p.OutputSchemaFile}
+		tmp := &Config{Tools: map[string]Tool{toolName: files}}
+		if err := tmp.loadSchemaFiles(baseDir); err != nil {
+			return fmt.Errorf("%w (profile %s)", err, name)
+		}
+		if p.InputSchemaFile != "" {
+			t.InputSchema = tmp.Tools[toolName].InputSchema
+		}
+		if p.OutputSchemaFile != "" {
+			t.OutputSchema = tmp.Tools[toolName].OutputSchema
+		}
+		c.Tools[toolName] = t
+	}
+	c.ActiveProfile = name
+	return nil
+}
+
+// profileFromEnv é o profile selecionado (--profile grava em ProfileEnv).
+func profileFromEnv() string {
+	return strings.TrimSpace(os.Getenv(ProfileEnv))
+}
+
+func docRoot(n *yaml.Node) *yaml.Node {
+	if n != nil && n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		return n.Content[0]
+	}
+	return n
+}
+
+// mappingValue retorna o valor de key num nó mapping (nil se ausente ou não for mapping).
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
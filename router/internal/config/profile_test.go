@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadFromFile_ProfileOverlay(t *testing.T) {
+	p := writeConfig(t, `
+workspace_root: /tmp
+tools_root: /tmp
+tools:
+  git:
+    runtime: container
+    image: alpine/git
+    args: ["--version"]
+    timeout_ms: 9000
+    headers: {a: "1"}
+profiles:
+  dev:
+    tools:
+      git:
+        runtime: native
+        cmd: git
+        image: ""
+        headers: {b: "2"}
+      scratch:
+        runtime: native
+        cmd: sh
+`)
+
+	t.Setenv(ProfileEnv, "")
+	base, err := LoadFromFile(p)
+	if err != nil {
+		t.Fatalf("base: %v", err)
+	}
+	if base.Tools["git"].Runtime != "container" || base.ActiveProfile != "" || len(base.Tools) != 1 {
+		t.Fatalf("no profile selected must keep the base: %+v", base.Tools)
+	}
+
+	t.Setenv(ProfileEnv, "dev")
+	cfg, err := LoadFromFile(p)
+	if err != nil {
+		t.Fatalf("dev: %v", err)
+	}
+	git := cfg.Tools["git"]
+	if git.Runtime != "native" || git.Cmd != "git" || git.Image != "" || git.TimeoutMS != 9000 ||
+		len(git.Args) != 1 || git.Headers["a"] != "1" || git.Headers["b"] != "2" {
+		t.Fatalf("overlay must change only the fields set in the profile: %+v", git)
+	}
+	if _, ok := cfg.Tools["scratch"]; !ok || cfg.ActiveProfile != "dev" {
+		t.Fatalf("profile tool must be added: %+v", cfg.Tools)
+	}
+
+	t.Setenv(ProfileEnv, "prod")
+	if _, err := LoadFromFile(p); err == nil || !strings.Contains(err.Error(), `profile "prod" is not defined`) {
+		t.Fatalf("unknown profile: %v", err)
+	}
+}
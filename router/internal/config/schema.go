@@ -0,0 +1,187 @@
+package config
+
+import "reflect"
+
+// schemaHints complementa a reflexão com enums/descrições por campo.
+// Chave: "<Struct>.<yaml key>".
+var schemaHints = map[string]map[string]any{
+	"Config.orphan_gc_interval_ms":     {"minimum": 0, "maximum": MaxOrphanGCInterval.Milliseconds()},
+	"ProcessAudit.interval_ms":         {"minimum": 0, "maximum": MaxProcessAuditInterval.Milliseconds()},
+	"Config.workspace_root":            {"description": "Workspace root mounted/exposed to tools"},
+	"Config.workspaces":                {"description": "Named workspace roots selectable per request with the X-MCP-Workspace header"},
+	"Config.tools_root":                {"description": "Root directory for native tool scripts"},
+	"Config.defaults":                  {"description": "Settings inherited by every tool (each tool overrides field by field)"},
+	"Config.groups":                    {"description": "Named settings inherited by tools with group: <name>, over defaults"},
+	"Config.profiles":                  {"description": "Named overlays over tools, selected with --profile or MCP_GW_PROFILE"},
+	"Config.include":                   {"description": "Globs of extra files with tools/overrides, relative to the config file (conf.d/*.yaml is always read)"},
+	"Tool.group":                       {"description": "Inherit the settings of groups.<name>"},
+	"Tool.aliases":                     {"description": "Old names that still route to this tool, with a deprecation warning"},
+	"Tool.deprecated":                  {"description": "Mark the tool as deprecated (callers get a deprecation warning)"},
+	"Tool.input_schema":                {"description": "JSON Schema of the tool input, published in GET /mcp/tools"},
+	"Tool.input_schema_file":           {"description": "JSON/YAML file with the input JSON Schema, relative to the config file"},
+	"Tool.output_schema":               {"description": "JSON Schema each stdout line must match"},
+	"Tool.output_schema_file":          {"description": "JSON/YAML file with the output JSON Schema, relative to the config file"},
+	"Tool.output_validation":           {"enum": []string{"warn", "strict"}},
+	"Redaction.detectors":              {"items": map[string]any{"enum": RedactionDetectors}},
+	"Redaction.replacement":            {"description": "Replacement text; <name> expands to the matching detector"},
+	"Tool.runtime":                     {"enum": []string{"native", "container", "remote", "builtin"}},
+	"Tool.builtin":                     {"enum": []string{"kv"}},
+	"Tool.mode":                        {"enum": []string{"launcher", "daemon"}},
+	"Tool.workspace_access":            {"enum": []string{"rw", "ro", "none"}},
+	"ToolSelftest.input":               {"description": "Benign input sent by mcp-gw selftest; without it the tool is only spawned"},
+	"Tool.egress_allow":                {"description": "Native tools: domains reachable through the gateway egress proxy (host, *.domain or *)"},
+	"EgressProxy.allow_internal":       {"description": "Internal IPs/CIDRs (loopback, link-local, private) the egress proxy may reach; others are refused even when egress_allow names them"},
+	"Config.trusted_proxies":           {"description": "Proxies (IP or CIDR) whose CF-Connecting-IP / X-Forwarded-For headers name the client; empty = the peer address is the client"},
+	"Config.auth":                      {"description": "Client authentication for tool endpoints (api_keys with tool scopes and quotas, oidc bearer tokens, hmac signed requests)"},
+	"APIKey.key_env":                   {"description": "Environment variable holding the key secret"},
+	"APIKey.key_sha256":                {"description": "Hex SHA-256 of the key secret (alternative to key_env)"},
+	"OIDC.jwks_url":                    {"description": "URL of the identity provider's JSON Web Key Set (RS256 keys)"},
+	"Auth.public_roles":                {"items": map[string]any{"enum": []string{RoleInvoke, RoleRead}}, "description": "Roles granted to requests without credentials"},
+	"APIKey.roles":                     {"items": map[string]any{"enum": Roles}},
+	"HMACClient.roles":                 {"items": map[string]any{"enum": Roles}},
+	"OIDC.workspaces_claim":            {"description": "Claim listing the named workspaces the token may select (names or *)", "default": DefaultOIDCWorkspacesClaim},
+	"APIKey.workspaces":                {"description": "Named workspaces the key may select with X-MCP-Workspace (names or *; empty = workspace_root only)"},
+	"HMACClient.workspaces":            {"description": "Named workspaces the client may select with X-MCP-Workspace (names or *; empty = workspace_root only)"},
+	"OIDC.tools_claim":                 {"description": "Claim listing the tools the token may call (names, globs or *)", "default": DefaultOIDCToolsClaim},
+	"HMACClient.secret_env":            {"description": "Environment variable holding the shared secret used to sign X-MCP-Signature"},
+	"HMAC.window_ms":                   {"description": "Accepted clock difference of signed requests; signatures are single-use within it", "default": DefaultHMACWindowMS},
+	"Config.host_check":                {"description": "Allowed Host/Origin values of the HTTP transport (DNS rebinding protection; loopback listeners default to localhost)"},
+	"Config.egress_proxy":              {"description": "Egress proxy listener for native tools with egress_allow (default 127.0.0.1:0)"},
+	"Tool.workspace":                   {"enum": []string{"shared", "ephemeral"}},
+	"Tool.workspace_retain":            {"enum": []string{"never", "on_failure", "always"}},
+	"Mount.mode":                       {"enum": []string{"ro", "rw"}},
+	"Tool.user":                        {"pattern": "^[0-9]+(:[0-9]+)?$"},
+	"Config.user":                      {"pattern": "^[0-9]+(:[0-9]+)?$"},
+	"Tool.container_runtime":           {"enum": []string{"runsc", "kata", "runc"}},
+	"Tool.output":                      {"enum": []string{"text", "binary"}},
+	"Tool.cancellation":                {"enum": []string{"kill", "cooperative"}},
+	"Tool.cancel_message":              {"description": "Line written to stdin on cooperative cancellation; {request_id} is replaced"},
+	"Tool.cancel_grace_ms":             {"minimum": 0, "maximum": MaxShutdownGrace.Milliseconds()},
+	"Tool.stream_body":                 {"description": "Pipe large bodies (chunked, over 1MiB or application/octet-stream) straight to the tool stdin"},
+	"Tool.max_body_bytes":              {"minimum": 0, "maximum": int64(MaxMaxBodyBytes)},
+	"Tool.sandbox":                     {"enum": []string{"bwrap"}},
+	"Reuse.max_idle_ms":                {"minimum": 0, "maximum": MaxReuseMaxIdle.Milliseconds()},
+	"Reuse.max_age_ms":                 {"minimum": 0, "maximum": MaxReuseMaxAge.Milliseconds()},
+	"Reuse.max_requests":               {"minimum": 0},
+	"Session.idle_timeout_ms":          {"minimum": 0, "maximum": MaxSessionIdle.Milliseconds()},
+	"Session.max_sessions":             {"minimum": 0},
+	"Health.interval_ms":               {"minimum": 0, "maximum": MaxHealthInterval.Milliseconds()},
+	"Health.timeout_ms":                {"minimum": 0, "maximum": MaxToolTimeout.Milliseconds()},
+	"Health.ping":                      {"description": "Single-line JSON request sent to idle sessions; empty = liveness only"},
+	"Tool.pull_policy":                 {"enum": []string{"always", "if-not-present", "never"}},
+	"Tool.docker_network":              {"enum": []string{"none", "bridge"}},
+	"Tool.timeout_ms":                  {"minimum": 0, "maximum": MaxToolTimeout.Milliseconds()},
+	"Tool.startup_timeout_ms":          {"minimum": 0, "maximum": MaxToolTimeout.Milliseconds()},
+	"Tool.max_timeout_ms":              {"minimum": 0, "maximum": MaxToolTimeout.Milliseconds()},
+	"Tool.deadline_field":              {"description": "Input field that receives the execution deadline (Unix ms), e.g. _deadline_unix_ms"},
+	"Tool.request_id_field":            {"description": "Input field that receives the request_id (also in MCP_REQUEST_ID), e.g. _request_id"},
+	"Tool.shutdown_grace_ms":           {"minimum": 0, "maximum": MaxShutdownGrace.Milliseconds()},
+	"Tool.shutdown":                    {"enum": []string{"eof", "sigterm", "both"}},
+	"Tool.max_concurrent":              {"minimum": 0, "maximum": MaxAllowedConcurrency},
+	"Tool.queue_timeout_ms":            {"minimum": 0, "maximum": MaxToolTimeout.Milliseconds()},
+	"Retry.attempts":                   {"minimum": 0, "maximum": MaxRetryAttempts},
+	"Retry.backoff_ms":                 {"minimum": 0, "maximum": MaxRetryBackoff.Milliseconds()},
+	"Tool.retries":                     {"minimum": 0, "maximum": MaxRemoteRetries},
+	"Tool.flush_interval_ms":           {"minimum": 0, "maximum": MaxFlushInterval.Milliseconds()},
+	"Tool.max_output_bytes":            {"minimum": 0},
+	"Tool.max_output_lines":            {"minimum": 0},
+	"Tool.max_line_bytes":              {"minimum": 0, "maximum": MaxMaxLineBytes},
+	"Tool.long_lines":                  {"enum": []string{"split", "truncate"}},
+	"Tool.write_buffer_bytes":          {"minimum": 0, "maximum": MaxWriteBuffer},
+	"Tool.log_level":                   {"enum": []string{"debug", "info", "warn", "error"}},
+	"Tool.priority":                    {"enum": []string{"high", "normal", "low"}},
+	"EventSink.type":                   {"enum": []string{"webhook", "kafka", "nats"}},
+	"EventSink.buffer_events":          {"minimum": 0, "maximum": MaxEventBuffer},
+	"EventSink.url":                    {"description": "webhook: POST endpoint; kafka: REST Proxy base URL; nats: nats://host:port"},
+	"Tool.slow_client":                 {"enum": []string{"block", "drop", "disconnect"}},
+	"Stream.slow_client":               {"enum": []string{"block", "drop", "disconnect"}},
+	"Stream.buffer_lines":              {"minimum": 0, "maximum": MaxStreamBufferLines},
+	"Stream.resume_window_ms":          {"minimum": 0, "maximum": MaxStreamResumeWindow.Milliseconds(), "description": "How long a dropped SSE stream keeps running, waiting for a reconnect with Last-Event-ID (0 = off)"},
+	"Config.files":                     {"description": "GET/PUT/DELETE /workspace/files/<path> for staging files in workspace_root (off by default)"},
+	"Files.max_upload_bytes":           {"minimum": 0, "maximum": int64(MaxMaxBodyBytes)},
+	"TLS.cert_file":                    {"description": "PEM certificate (chain) served by the HTTP transport; enables HTTPS"},
+	"TLS.client_ca_file":               {"description": "PEM CA bundle used to verify client certificates"},
+	"AccessLog.format":                 {"enum": []string{"slog", "combined", "off"}},
+	"Logging.output":                   {"enum": []string{"stderr", "file"}},
+	"Logging.format":                   {"enum": []string{"json", "text"}},
+	"Logging.level":                    {"enum": []string{"debug", "info", "warn", "error"}},
+	"Logging.components":               {"propertyNames": map[string]any{"enum": LogComponents}, "additionalProperties": map[string]any{"type": "string", "enum": []string{"debug", "info", "warn", "error"}}},
+	"Logging.max_size_mb":              {"minimum": 0, "maximum": MaxLogSizeMB},
+	"Logging.max_backups":              {"minimum": 0, "maximum": MaxLogBackups},
+	"LogCapture.requests":              {"minimum": 0, "maximum": MaxLogCaptureRequests, "description": "Most recent requests whose gateway log records are kept in memory (0 = capture off)"},
+	"LogCapture.records":               {"minimum": 0, "maximum": MaxLogCaptureRecords, "description": "Last log records kept per request (default 200)"},
+	"Limits.max_concurrent":            {"minimum": 0, "maximum": MaxGlobalConcurrency},
+	"Limits.max_concurrent_per_client": {"minimum": 0, "maximum": MaxGlobalConcurrency},
+	"Storage.backend":                  {"enum": []string{"local", "s3"}},
+}
+
+// schemaRequired lista campos obrigatórios por struct (espelha Validate).
+var schemaRequired = map[string][]string{
+	"Config": {"workspace_root", "tools_root", "tools"},
+	"Tool":   {"runtime"},
+}
+
+// JSONSchema gera um JSON Schema (draft 2020-12) do config a partir das tags yaml.
+// Pensado para integração com editores (ex: yaml-language-server).
+func JSONSchema() map[string]any {
+	s := schemaFor(reflect.TypeOf(Config{}))
+	// tools de um profile, defaults e groups são parciais: nenhum campo obrigatório
+	props := s["properties"].(map[string]any)
+	profile := props["profiles"].(map[string]any)["additionalProperties"].(map[string]any)
+	delete(profile["properties"].(map[string]any)["tools"].(map[string]any)["additionalProperties"].(map[string]any), "required")
+	delete(props["defaults"].(map[string]any), "required")
+	delete(props["groups"].(map[string]any)["additionalProperties"].(map[string]any), "required")
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["$id"] = "https://github.com/JJDSNT/mcp-gateway/config.schema.json"
+	s["title"] = "mcp-gw config"
+	return s
+}
+
+func schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		props := map[string]any{}
+		for name, f := range yamlFields(t) {
+			p := schemaFor(f.Type)
+			for k, v := range schemaHints[t.Name()+"."+name] {
+				p[k] = v
+			}
+			props[name] = p
+		}
+		s := map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+		if req := schemaRequired[t.Name()]; len(req) > 0 {
+			s["required"] = req
+		}
+		return s
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": schemaFor(t.Elem()),
+		}
+	case reflect.Slice:
+		return map[string]any{
+			"type":  "array",
+			"items": schemaFor(t.Elem()),
+		}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		// interface{} / tipos livres
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,206 @@
+// Package configsrc carrega o config de fontes remotas (--config https://..., s3://...,
+// git::...), com cache local da última versão válida e refresh periódico.
+//
+// Um config remoto só é aceito depois de passar por config.Parse: fetch que falha ou
+// conteúdo inválido nunca substituem a versão boa, nem no boot (cai no cache) nem no
+// refresh (mantém o config em execução).
+package configsrc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/metrics"
+)
+
+// Variáveis de ambiente das fontes remotas (segredos nunca vêm da URL).
+const (
+	// TokenEnv: bearer token para fontes http(s).
+	TokenEnv = config.ConfigTokenEnv
+	// S3EndpointEnv: endpoint S3 (MinIO etc.); vazio = AWS na região AWS_REGION.
+	S3EndpointEnv = "MCP_GW_CONFIG_S3_ENDPOINT"
+	// CacheDirEnv: diretório do cache da última versão válida.
+	CacheDirEnv = "MCP_GW_CONFIG_CACHE"
+)
+
+// DefaultRefresh é o intervalo de refresh de fontes remotas.
+const DefaultRefresh = time.Minute
+
+// fetchTimeout limita cada fetch (o refresh não pode empilhar fetches travados).
+const fetchTimeout = 30 * time.Second
+
+var configRefreshes = metrics.Default.CounterVec("mcp_gw_config_refresh_total",
+	"Remote config refreshes by result (unchanged, applied, fetch_error, invalid).", "result")
+
+// fetcher busca o conteúdo bruto do config.
+type fetcher interface {
+	fetch(ctx context.Context) ([]byte, error)
+}
+
+// IsRemote diz se path é uma fonte remota (e não um arquivo local).
+func IsRemote(path string) bool {
+	for _, p := range []string{"http://", "https://", "s3://", "git::"} {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Source é um config remoto com cache da última versão válida.
+type Source struct {
+	raw   string
+	f     fetcher
+	cache string // arquivo do cache
+
+	hash string // sha256 do conteúdo em uso
+}
+
+// New interpreta raw (https://host/config.yaml, s3://bucket/key, git::<repo>//<arquivo>?ref=<ref>).
+func New(raw string) (*Source, error) {
+	cacheDir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(raw))
+	id := hex.EncodeToString(sum[:8])
+
+	var f fetcher
+	switch {
+	case strings.HasPrefix(raw, "http://"), strings.HasPrefix(raw, "https://"):
+		f, err = newHTTPFetcher(raw)
+	case strings.HasPrefix(raw, "s3://"):
+		f, err = newS3Fetcher(raw)
+	case strings.HasPrefix(raw, "git::"):
+		f, err = newGitFetcher(strings.TrimPrefix(raw, "git::"), filepath.Join(cacheDir, "git-"+id))
+	default:
+		err = fmt.Errorf("configsrc: unsupported config source %q", raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Source{raw: raw, f: f, cache: filepath.Join(cacheDir, "config-"+id+".yaml")}, nil
+}
+
+func cacheDir() (string, error) {
+	dir := os.Getenv(CacheDirEnv)
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			base = os.TempDir()
+		}
+		dir = filepath.Join(base, "mcp-gw")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("configsrc: cache dir: %w", err)
+	}
+	return dir, nil
+}
+
+// String é a fonte sem credenciais (para logs).
+func (s *Source) String() string {
+	return redactURL(s.raw)
+}
+
+// Load busca e valida o config. Se o fetch ou a validação falharem, usa a última versão
+// válida do cache (erro só quando nem o cache serve).
+func (s *Source) Load(ctx context.Context) (*config.Config, error) {
+	data, err := s.fetch(ctx)
+	if err == nil {
+		var cfg *config.Config
+		if cfg, err = config.Parse(data); err == nil {
+			s.commit(data)
+			return cfg, nil
+		}
+	}
+
+	cached, cerr := os.ReadFile(s.cache)
+	if cerr != nil {
+		return nil, fmt.Errorf("load config from %s: %w (no cached copy: %v)", s, err, cerr)
+	}
+	cfg, perr := config.Parse(cached)
+	if perr != nil {
+		return nil, fmt.Errorf("load config from %s: %w (cached copy is invalid too: %v)", s, err, perr)
+	}
+	slog.Warn("remote config unavailable, using last good cached copy",
+		slog.String("source", s.String()), slog.String("cache", s.cache), slog.String("error", err.Error()))
+	s.hash = hashOf(cached)
+	return cfg, nil
+}
+
+// Watch busca o config a cada interval e chama apply quando o conteúdo muda e é válido.
+// Falhas só são logadas: o config em execução continua valendo.
+func (s *Source) Watch(ctx context.Context, interval time.Duration, apply func(*config.Config)) {
+	if interval <= 0 {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if cfg := s.refresh(ctx); cfg != nil {
+			apply(cfg)
+		}
+	}
+}
+
+// refresh faz um ciclo do Watch; retorna o config novo (nil = nada a aplicar).
+func (s *Source) refresh(ctx context.Context) *config.Config {
+	log := slog.With(slog.String("source", s.String()))
+	data, err := s.fetch(ctx)
+	if err != nil {
+		configRefreshes.With("fetch_error").Inc()
+		log.Warn("config refresh failed, keeping current config", slog.String("error", err.Error()))
+		return nil
+	}
+	if hashOf(data) == s.hash {
+		configRefreshes.With("unchanged").Inc()
+		return nil
+	}
+	cfg, err := config.Parse(data)
+	if err != nil {
+		configRefreshes.With("invalid").Inc()
+		log.Warn("refreshed config is invalid, keeping current config", slog.String("error", err.Error()))
+		return nil
+	}
+	s.commit(data)
+	configRefreshes.With("applied").Inc()
+	log.Info("config changed at source", slog.String("sha256", s.hash))
+	return cfg
+}
+
+func (s *Source) fetch(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	return s.f.fetch(ctx)
+}
+
+// commit marca data como versão em uso e grava no cache (rename atômico; best effort).
+func (s *Source) commit(data []byte) {
+	s.hash = hashOf(data)
+	tmp := s.cache + ".tmp"
+	err := os.WriteFile(tmp, data, 0o600)
+	if err == nil {
+		err = os.Rename(tmp, s.cache)
+	}
+	if err != nil {
+		slog.Warn("config cache write failed", slog.String("cache", s.cache), slog.String("error", err.Error()))
+	}
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
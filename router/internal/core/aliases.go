@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/observability/metrics"
+)
+
+var deprecatedCalls = metrics.Default.CounterVec("mcp_gw_tool_deprecated_calls_total",
+	"Calls to deprecated tools or through tool aliases (alias empty = the tool itself is deprecated).", "tool", "alias")
+
+// Deprecation é o aviso de uma chamada por alias ou a uma tool deprecated.
+type Deprecation struct {
+	Tool    string `json:"tool"`            // nome canônico
+	Alias   string `json:"alias,omitempty"` // nome usado na chamada ("" = a própria tool)
+	Message string `json:"message"`
+}
+
+// newAliases mapeia tools[*].aliases para o nome canônico.
+func newAliases(cfg *config.Config) map[string]string {
+	out := make(map[string]string)
+	for name, t := range cfg.Tools {
+		for _, a := range t.Aliases {
+			out[a] = name
+		}
+	}
+	return out
+}
+
+// canonicalTool troca um alias pelo nome da tool (outros nomes voltam iguais).
+func (s *Service) canonicalTool(name string) string {
+	if c, ok := s.aliases[name]; ok {
+		return c
+	}
+	return name
+}
+
+// ResolveTool é a entrada dos transports: devolve o nome canônico e, para alias ou tool
+// deprecated, o aviso (já logado e contado em mcp_gw_tool_deprecated_calls_total).
+func (s *Service) ResolveTool(ctx context.Context, name string) (string, *Deprecation) {
+	canonical := s.canonicalTool(name)
+	t, ok := s.cfg.Tools[canonical]
+	if !ok || (canonical == name && !t.Deprecated) {
+		return name, nil
+	}
+
+	d := &Deprecation{Tool: canonical, Message: fmt.Sprintf("tool %q is deprecated", canonical)}
+	if canonical != name {
+		d.Alias = name
+		d.Message = fmt.Sprintf("tool %q is deprecated, use %q", name, canonical)
+	}
+	deprecatedCalls.With(canonical, d.Alias).Inc()
+	logging.LoggerFromContext(ctx).Warn("deprecated tool called", logging.Tool(canonical), logging.String("alias", d.Alias))
+	return canonical, d
+}
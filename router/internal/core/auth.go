@@ -0,0 +1,346 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/observability/metrics"
+	"mcp-router/internal/oidc"
+)
+
+// Autorização por credencial (auth.api_keys): o transport autentica e grava o Principal no
+// ctx; o core decide se ele pode chamar a tool e aplica as cotas da chave. Sem Principal
+// (auth desligado, admin, URL assinada) não há restrição.
+var (
+	ErrToolNotPermitted = errors.New("tool not permitted for this credential")
+	ErrQuotaExceeded    = errors.New("daily quota exceeded")
+	ErrKeyBusy          = errors.New("api key concurrency limit reached")
+)
+
+// Tipos de Principal.
+const (
+	PrincipalAPIKey = "api_key"
+	PrincipalOIDC   = "oidc"
+)
+
+var keyCalls = metrics.Default.CounterVec("mcp_gw_api_key_calls_total",
+	"Tool calls by API key and outcome (allowed, forbidden, quota, busy).", "key", "result")
+
+// Principal é o cliente autenticado da request.
+type Principal struct {
+	Kind  string   `json:"kind"`
+	Name  string   `json:"name"`
+	Tools []string `json:"tools,omitempty"` // escopo (ver config.ScopePermits)
+	Tags  []string `json:"tags,omitempty"`
+	Roles []string `json:"roles"` // config.RoleInvoke, RoleRead, RoleAdmin
+
+	Workspaces []string `json:"workspaces,omitempty"` // X-MCP-Workspace permitidos (config.WorkspacePermitted)
+}
+
+// HasRole diz se o principal tem o papel.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// Permits diz se o principal pode chamar a tool name (com as tags dela). Token OIDC sem
+// tools na claim não chama nada; chave sem tools nem tags chama tudo.
+func (p *Principal) Permits(name string, toolTags []string) bool {
+	if p.Kind == PrincipalOIDC && len(p.Tools) == 0 {
+		return false
+	}
+	return config.ScopePermits(p.Tools, p.Tags, name, toolTags)
+}
+
+// PermitsWorkspace diz se o principal pode usar o workspace nomeado ("" = workspace_root).
+func (p *Principal) PermitsWorkspace(name string) bool {
+	return config.WorkspacePermitted(p.Workspaces, name)
+}
+
+// Identity é o nome do principal nos logs e no access log (ex: "api_key:ci").
+func (p *Principal) Identity() string {
+	return p.Kind + ":" + p.Name
+}
+
+type principalKey struct{}
+
+// WithPrincipal grava o cliente autenticado no ctx.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext retorna o cliente autenticado (nil = sem autenticação).
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Identity é quem responde pela request: o principal autenticado ("api_key:ci") ou, sem
+// auth, o IP do cliente (peer da conexão ou via trusted_proxies). É a chave de posse
+// (cancel), dos limites por cliente e do namespace do kv; nunca vem de header do cliente.
+func Identity(ctx context.Context) string {
+	if p := PrincipalFromContext(ctx); p != nil {
+		return p.Identity()
+	}
+	return logging.ClientFromContext(ctx)
+}
+
+// QuotaError é a cota diária esgotada (details do erro: chave, limite, uso e reset).
+type QuotaError struct {
+	Key     string    `json:"key"`
+	Limit   int       `json:"limit"`
+	Used    int64     `json:"used"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: key %s used %d of %d calls (resets at %s)", ErrQuotaExceeded, e.Key, e.Used, e.Limit, e.ResetAt.Format(time.RFC3339))
+}
+
+func (e *QuotaError) Unwrap() error { return ErrQuotaExceeded }
+
+// ErrorDetails é o próprio QuotaError (details do APIError).
+func (e *QuotaError) ErrorDetails() any { return e }
+
+// KeyUsage é o uso de uma chave (GET /admin/keys).
+type KeyUsage struct {
+	Name          string     `json:"name"`
+	Tools         []string   `json:"tools,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+	DailyQuota    int        `json:"daily_quota,omitempty"`
+	UsedToday     int64      `json:"used_today"`
+	Remaining     *int64     `json:"remaining,omitempty"` // só com daily_quota
+	ResetAt       time.Time  `json:"reset_at"`
+	MaxConcurrent int        `json:"max_concurrent,omitempty"`
+	InFlight      int        `json:"in_flight"`
+	Total         int64      `json:"total"`
+	Rejected      int64      `json:"rejected"`
+	LastUsed      *time.Time `json:"last_used,omitempty"`
+	Configured    bool       `json:"configured"` // false: key_env sem valor no ambiente
+}
+
+// keyRegistry guarda os hashes das chaves e o uso delas. O uso vive em memória: recomeça
+// no restart, mas passa de um serviço para o seguinte no reload do config (WithStateFrom).
+type keyRegistry struct {
+	keys   map[string]config.APIKey
+	hashes map[string]string // sha256 hex -> nome
+
+	*keyUsage
+}
+
+// keyUsage são os contadores das chaves, por nome; compartilhado entre o serviço antigo e
+// o novo no reload, para as execuções em andamento devolverem o slot no lugar certo.
+type keyUsage struct {
+	mu       sync.Mutex
+	day      string // dia UTC dos contadores de used
+	used     map[string]int64
+	inFlight map[string]int
+	total    map[string]int64
+	rejected map[string]int64
+	last     map[string]time.Time
+	now      func() time.Time
+}
+
+func newKeyRegistry(a config.Auth) *keyRegistry {
+	r := &keyRegistry{
+		keys:   make(map[string]config.APIKey, len(a.APIKeys)),
+		hashes: make(map[string]string, len(a.APIKeys)),
+		keyUsage: &keyUsage{
+			used:     make(map[string]int64),
+			inFlight: make(map[string]int),
+			total:    make(map[string]int64),
+			rejected: make(map[string]int64),
+			last:     make(map[string]time.Time),
+			now:      time.Now,
+		},
+	}
+	for _, k := range a.APIKeys {
+		r.keys[k.Name] = k
+		if h := k.Hash(); h != "" {
+			r.hashes[h] = k.Name
+		}
+	}
+	return r
+}
+
+// AuthEnabled diz se os endpoints de tools exigem credencial.
+func (s *Service) AuthEnabled() bool {
+	return s.cfg.Auth.Enabled()
+}
+
+// Auth retorna a configuração de autenticação dos clientes.
+func (s *Service) Auth() config.Auth {
+	return s.cfg.Auth
+}
+
+// AuthenticateKey resolve o segredo apresentado pelo cliente para o Principal da chave.
+func (s *Service) AuthenticateKey(secret string) (*Principal, bool) {
+	if secret == "" {
+		return nil, false
+	}
+	sum := sha256.Sum256([]byte(secret))
+	got := hex.EncodeToString(sum[:])
+	// compara com todas (tempo constante por chave; o nº de chaves não é segredo)
+	var name string
+	for h, n := range s.keys.hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(got)) == 1 {
+			name = n
+		}
+	}
+	if name == "" {
+		return nil, false
+	}
+	k := s.keys.keys[name]
+	return &Principal{Kind: PrincipalAPIKey, Name: name, Tools: k.Tools, Tags: k.Tags, Roles: config.RolesEffective(k.Roles), Workspaces: k.Workspaces}, true
+}
+
+// AuthenticateToken valida um bearer token OIDC (auth.oidc) e resolve o Principal: sub
+// como nome, a claim de tools como escopo e a de papéis como Roles.
+func (s *Service) AuthenticateToken(ctx context.Context, token string) (*Principal, error) {
+	if s.oidc == nil {
+		return nil, fmt.Errorf("%w: oidc not configured", oidc.ErrInvalidToken)
+	}
+	claims, err := s.oidc.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	tools, _ := claims.Strings(s.cfg.Auth.OIDC.ToolsClaimEffective())
+	roles, _ := claims.Strings(s.cfg.Auth.OIDC.RolesClaimEffective())
+	workspaces, _ := claims.Strings(s.cfg.Auth.OIDC.WorkspacesClaimEffective())
+	return &Principal{Kind: PrincipalOIDC, Name: claims.Subject(), Tools: tools, Roles: config.RolesEffective(roles), Workspaces: workspaces}, nil
+}
+
+// authorize confere o escopo do principal da request e, para chaves, reserva uma chamada
+// da cota diária e um slot de max_concurrent. release devolve o slot.
+func (s *Service) authorize(ctx context.Context, toolName string, tool config.Tool) (release func(), err error) {
+	p := PrincipalFromContext(ctx)
+	if p == nil {
+		return func() {}, nil
+	}
+	if !p.Permits(toolName, tool.Tags) {
+		if p.Kind == PrincipalAPIKey {
+			s.keys.reject(p.Name, "forbidden")
+		}
+		return nil, fmt.Errorf("%w: %s may not call %s", ErrToolNotPermitted, p.Identity(), toolName)
+	}
+	if p.Kind != PrincipalAPIKey {
+		return func() {}, nil
+	}
+	return s.keys.admit(p.Name)
+}
+
+func (r *keyRegistry) admit(name string) (func(), error) {
+	k := r.keys[name]
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now().UTC()
+	r.rollLocked(now)
+
+	if k.MaxConcurrent > 0 && r.inFlight[name] >= k.MaxConcurrent {
+		r.rejectLocked(name, "busy")
+		return nil, fmt.Errorf("%w: key %s has %d calls running", ErrKeyBusy, name, k.MaxConcurrent)
+	}
+	if k.DailyQuota > 0 && r.used[name] >= int64(k.DailyQuota) {
+		r.rejectLocked(name, "quota")
+		return nil, &QuotaError{Key: name, Limit: k.DailyQuota, Used: r.used[name], ResetAt: nextUTCDay(now)}
+	}
+	r.used[name]++
+	r.total[name]++
+	r.inFlight[name]++
+	r.last[name] = now
+	keyCalls.With(name, "allowed").Inc()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.inFlight[name]--; r.inFlight[name] <= 0 {
+				delete(r.inFlight, name)
+			}
+		})
+	}, nil
+}
+
+// refundQuota devolve à cota da chave da request a chamada admitida pelo authorize, quando
+// o input é recusado antes de chegar à tool.
+func (s *Service) refundQuota(ctx context.Context) {
+	if p := PrincipalFromContext(ctx); p != nil && p.Kind == PrincipalAPIKey {
+		s.keys.refund(p.Name)
+	}
+}
+
+func (r *keyRegistry) refund(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rollLocked(r.now().UTC())
+	if r.used[name] > 0 { // 0: o dia virou depois do admit
+		r.used[name]--
+	}
+}
+
+func (r *keyRegistry) reject(name, result string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rejectLocked(name, result)
+}
+
+func (r *keyRegistry) rejectLocked(name, result string) {
+	r.rejected[name]++
+	keyCalls.With(name, result).Inc()
+}
+
+// rollLocked zera as cotas na virada do dia UTC.
+func (r *keyRegistry) rollLocked(now time.Time) {
+	if day := now.Format(time.DateOnly); day != r.day {
+		r.day = day
+		clear(r.used)
+	}
+}
+
+// KeyUsage lista o uso de cada chave configurada, por nome.
+func (s *Service) KeyUsage() []KeyUsage {
+	r := s.keys
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now().UTC()
+	r.rollLocked(now)
+
+	configured := make(map[string]bool, len(r.hashes))
+	for _, n := range r.hashes {
+		configured[n] = true
+	}
+	out := make([]KeyUsage, 0, len(r.keys))
+	for name, k := range r.keys {
+		u := KeyUsage{
+			Name: name, Tools: k.Tools, Tags: k.Tags,
+			DailyQuota: k.DailyQuota, UsedToday: r.used[name], ResetAt: nextUTCDay(now),
+			MaxConcurrent: k.MaxConcurrent, InFlight: r.inFlight[name],
+			Total: r.total[name], Rejected: r.rejected[name], Configured: configured[name],
+		}
+		if k.DailyQuota > 0 {
+			rem := max(int64(k.DailyQuota)-u.UsedToday, 0)
+			u.Remaining = &rem
+		}
+		if t, ok := r.last[name]; ok {
+			u.LastUsed = &t
+		}
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func nextUTCDay(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+type discardLines struct{}
+
+func (discardLines) WriteLine([]byte) error { return nil }
+
+func TestStreamTool_RejectedInputRefundsQuota(t *testing.T) {
+	t.Setenv("TEST_KEY_CI", "ci-secret")
+	svc := New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"strict": {Runtime: "native", Mode: "launcher", Cmd: "/bin/cat", TimeoutMS: 5000,
+				InputSchema: map[string]any{"type": "object", "required": []any{"path"}}},
+		},
+		Auth: config.Auth{APIKeys: []config.APIKey{{Name: "ci", KeyEnv: "TEST_KEY_CI", DailyQuota: 1}}},
+	})
+	defer svc.Close()
+
+	p, ok := svc.AuthenticateKey("ci-secret")
+	if !ok {
+		t.Fatal("key not authenticated")
+	}
+	ctx := WithPrincipal(context.Background(), p)
+
+	// input inválido não chega à tool: não gasta a cota
+	for _, in := range []string{`{`, `{}`} {
+		if err := svc.StreamTool(ctx, "strict", []byte(in), discardLines{}); err == nil || errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("input %s: expected an input error, got %v", in, err)
+		}
+	}
+	if err := svc.StreamTool(ctx, config.DiagEcho, []byte(`{}`), discardLines{}); err != nil {
+		t.Fatalf("quota must still have the call left: %v", err)
+	}
+	if err := svc.StreamTool(ctx, config.DiagEcho, []byte(`{}`), discardLines{}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected quota exceeded, got %v", err)
+	}
+}
+
+func TestWithStateFrom_KeepsKeyUsageAndReplays(t *testing.T) {
+	t.Setenv("TEST_KEY_CI", "ci-secret")
+	t.Setenv("TEST_HMAC_CI", "shared-secret")
+	cfg := &config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"cat": {Runtime: "native", Mode: "launcher", Cmd: "/bin/cat", TimeoutMS: 5000}},
+		Auth: config.Auth{
+			APIKeys: []config.APIKey{{Name: "ci", KeyEnv: "TEST_KEY_CI", DailyQuota: 1}},
+			HMAC:    config.HMAC{Clients: []config.HMACClient{{Name: "ci", SecretEnv: "TEST_HMAC_CI"}}},
+		},
+	}
+	old := New(cfg)
+	defer old.Close()
+
+	release, err := old.keys.admit("ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := Sign("ci", "shared-secret", http.MethodPost, "/mcp/cat", time.Now().Unix(), nil)
+	if _, err := old.AuthenticateSignature(sig, http.MethodPost, "/mcp/cat", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// reload: o serviço novo continua a contagem e o anti-replay do antigo
+	svc := New(cfg, WithStateFrom(old))
+	defer svc.Close()
+	if _, err := svc.keys.admit("ci"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("quota must survive the reload, got %v", err)
+	}
+	if _, err := svc.AuthenticateSignature(sig, http.MethodPost, "/mcp/cat", nil); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("signature replayed across the reload must be refused, got %v", err)
+	}
+	release() // a execução do serviço antigo devolve o slot no registro compartilhado
+	if u := svc.KeyUsage(); len(u) != 1 || u[0].InFlight != 0 || u[0].UsedToday != 1 {
+		t.Fatalf("usage after release: %+v", u)
+	}
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// binaryChunkSize é o tamanho máximo de cada frame de output: binary (antes do base64).
+const binaryChunkSize = 32 * 1024
+
+// ErrBinaryUnsupported é retornado quando o transport não sabe entregar frames binários.
+var ErrBinaryUnsupported = errors.New("transport does not support binary tool output")
+
+// EventWriter é implementado pelos transports que entregam eventos de output além das
+// linhas (event: message): data (output: binary), continuation e line_truncated
+// (linhas gigantes). data é o payload JSON do evento.
+type EventWriter interface {
+	WriteEvent(event string, data []byte) error
+}
+
+// BinaryFrame é um pedaço do stdout de uma tool binária. Data vai em base64 no JSON;
+// seq começa em 0 e permite ao cliente detectar frames perdidos (slow_client: drop).
+type BinaryFrame struct {
+	Seq  int64  `json:"seq"`
+	Data []byte `json:"data"`
+}
+
+// pumpBinary lê o stdout em pedaços (sem assumir texto) e entrega cada um como frame.
+// max_output_lines conta frames. firstOutput é chamado a cada pedaço (startup timeout).
+func pumpBinary(ctx context.Context, r io.Reader, out LineWriter, limit *outputLimiter, exec *execution, firstOutput func()) error {
+	ew, ok := out.(EventWriter)
+	if !ok {
+		return ErrBinaryUnsupported
+	}
+
+	buf := make([]byte, binaryChunkSize)
+	for seq := int64(0); ; {
+		n, err := r.Read(buf)
+		if n > 0 {
+			firstOutput()
+			// limite de saída: para de ler; o defer do runAttempt mata o processo
+			if lerr := limit.admit(n); lerr != nil {
+				return lerr
+			}
+			frame, merr := json.Marshal(BinaryFrame{Seq: seq, Data: buf[:n]})
+			if merr != nil {
+				return merr
+			}
+			if werr := ew.WriteEvent("data", frame); werr != nil {
+				return werr
+			}
+			exec.bytes.Add(int64(n))
+			seq++
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctxErr(ctx)
+			}
+			return fmt.Errorf("read stdout: %w", err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/runner"
+)
+
+// Cancelamento em duas fases (cancellation: cooperative): quando a execução é cancelada
+// (cliente saiu, DELETE, timeout, shutdown), a tool primeiro recebe cancel_message no
+// stdin (ex: $/cancelRequest de servidores MCP) e tem cancel_grace_ms para limpar e sair.
+// Só depois vem o kill de sempre (SIGTERM, shutdown_grace_ms, SIGKILL).
+
+// processContext é o ctx do processo da tool. Sem cancellation: cooperative, é o da
+// execução. Com ela, o processo só é cancelado por stop (fim da fase 1); prazo e valores
+// continuam os da execução.
+func processContext(tctx context.Context, tool config.Tool) (context.Context, context.CancelFunc) {
+	if !tool.Cooperative() {
+		return tctx, func() {}
+	}
+	return context.WithCancel(detachedContext{Context: context.WithoutCancel(tctx), parent: tctx})
+}
+
+// detachedContext não é cancelado com parent, mas mantém o Deadline dele (MCP_DEADLINE_UNIX_MS).
+type detachedContext struct {
+	context.Context
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) { return c.parent.Deadline() }
+
+// sendCancel é a fase 1: escreve a mensagem de cancelamento no stdin da tool.
+func sendCancel(p runner.Process, tool config.Tool, rid string, log *slog.Logger) {
+	if _, err := io.WriteString(p.Stdin(), tool.CancelMessageFor(rid)+"\n"); err != nil {
+		log.Debug("cancel message not delivered (stdin closed)", logging.Err(err))
+		return
+	}
+	log.Info("cancel message sent to tool", logging.Int64("cancel_grace_ms", tool.CancelGrace().Milliseconds()))
+}
+
+// drainUntilExit descarta o stdout da tool até ela sair (EOF) ou max passar.
+func drainUntilExit(p runner.Process, max time.Duration) {
+	exited := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, p.Stdout())
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(max):
+	}
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/observability/metrics"
+)
+
+// configDriftInterval é o intervalo da comparação do config em disco com o carregado.
+const configDriftInterval = 30 * time.Second
+
+var configDrift = metrics.Default.Gauge("mcp_gw_config_drift",
+	"1 when the config on disk differs from the loaded one (edit not applied), 0 otherwise.")
+
+// ConfigInfo identifica o config em execução (GET /admin/config).
+type ConfigInfo struct {
+	Source   string    `json:"source,omitempty"` // arquivo ou fonte remota
+	Profile  string    `json:"profile,omitempty"`
+	SHA256   string    `json:"sha256"` // config.Checksum do config efetivo
+	LoadedAt time.Time `json:"loaded_at"`
+
+	// Drift: resultado da última comparação com o arquivo em disco (nil = não verificado,
+	// ex: fonte remota, que tem refresh próprio).
+	Drift *ConfigDrift `json:"drift,omitempty"`
+}
+
+// ConfigDrift compara o arquivo em disco com o config carregado.
+type ConfigDrift struct {
+	Drifted    bool      `json:"drifted"`
+	DiskSHA256 string    `json:"disk_sha256,omitempty"`
+	Error      string    `json:"error,omitempty"` // config em disco ilegível/inválido
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// configState: metadados do config em execução + estado do drift.
+type configState struct {
+	mu    sync.Mutex
+	info  ConfigInfo
+	drift *ConfigDrift
+}
+
+// WithConfigSource registra de onde o config veio (arquivo ou URL sem credenciais).
+func WithConfigSource(source string) Option {
+	return func(s *Service) { s.cfgState.info.Source = source }
+}
+
+// ConfigInfo retorna a identificação do config em execução e o último drift verificado.
+func (s *Service) ConfigInfo() ConfigInfo {
+	s.cfgState.mu.Lock()
+	defer s.cfgState.mu.Unlock()
+	info := s.cfgState.info
+	if d := s.cfgState.drift; d != nil {
+		dc := *d
+		info.Drift = &dc
+	}
+	return info
+}
+
+// EffectiveConfig é o config em execução sem segredos (config.Redacted).
+func (s *Service) EffectiveConfig() *config.Config {
+	return s.cfg.Redacted()
+}
+
+// RunConfigDriftCheck relê path a cada configDriftInterval até ctx terminar e compara
+// com o config carregado: edições não aplicadas (ou um arquivo que deixou de ser válido)
+// viram mcp_gw_config_drift=1 e um warning a cada mudança de estado. O app chama em
+// goroutine, só para configs locais.
+func (s *Service) RunConfigDriftCheck(ctx context.Context, path string) {
+	log := logging.LoggerFromContext(ctx)
+	t := time.NewTicker(configDriftInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		d := s.checkConfigDrift(path)
+		s.cfgState.mu.Lock()
+		prev := s.cfgState.drift
+		s.cfgState.drift = &d
+		s.cfgState.mu.Unlock()
+
+		if d.Drifted {
+			configDrift.Set(1)
+		} else {
+			configDrift.Set(0)
+		}
+		switch {
+		case prev != nil && prev.Drifted == d.Drifted && prev.DiskSHA256 == d.DiskSHA256 && prev.Error == d.Error:
+			// sem mudança desde a última verificação
+		case d.Error != "":
+			log.Warn("config on disk is invalid; running config is unchanged", logging.String("path", path), logging.String("error", d.Error))
+		case d.Drifted:
+			log.Warn("config on disk differs from the loaded config (restart to apply)", logging.String("path", path),
+				logging.String("loaded_sha256", s.cfgState.info.SHA256), logging.String("disk_sha256", d.DiskSHA256))
+		case prev != nil && prev.Drifted:
+			log.Info("config on disk matches the loaded config again", logging.String("path", path))
+		}
+	}
+}
+
+func (s *Service) checkConfigDrift(path string) ConfigDrift {
+	d := ConfigDrift{CheckedAt: time.Now().UTC()}
+	disk, err := config.LoadFromFile(path)
+	if err != nil {
+		d.Drifted, d.Error = true, err.Error()
+		return d
+	}
+	d.DiskSHA256 = disk.WithDiagnosticTools().Checksum()
+	d.Drifted = d.DiskSHA256 != s.cfgState.info.SHA256
+	return d
+}
@@ -1,19 +1,28 @@
 package core
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/netip"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
 	"mcp-router/internal/config"
+	"mcp-router/internal/flags"
+	"mcp-router/internal/jsonschema"
+	"mcp-router/internal/observability/events"
 	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/oidc"
 	"mcp-router/internal/runner"
 	"mcp-router/internal/sandbox"
+	"mcp-router/internal/storage"
 )
 
 type LineWriter interface {
@@ -21,74 +30,282 @@ type LineWriter interface {
 }
 
 type Service struct {
-	cfg *config.Config
-	r   *runner.Runner
+	cfg   *config.Config
+	r     *runner.Runner
+	store storage.Store
+	execs *executionRegistry
+
+	// URLs assinadas de uso único (/admin/signed-urls)
+	signer *signer
+
+	// pré-pull de imagens das tools container (estado no /readyz)
+	pull imagePuller
 
 	// Limite de concorrência por tool (Prioridade 1.2)
 	semMu sync.Mutex
-	sem   map[string]chan struct{}
+	sem   map[string]*fairLimiter
+
+	// Limites do gateway inteiro (total e por identidade), antes do semáforo da tool
+	limits *clientLimiter
+
+	// Chaves de API (auth.api_keys): hashes, cotas diárias e chamadas em andamento
+	keys *keyRegistry
+
+	// Validação de bearer tokens OIDC (auth.oidc; nil = desligado)
+	oidc *oidc.Verifier
+
+	// Assinaturas já aceitas de auth.hmac (proteção contra replay)
+	replays *replayCache
+
+	// Contadores por tool de GET /mcp/tools/<name>/stats
+	stats *statsRegistry
+
+	// input_schema (validação antes do spawn) e output_schema compilados por tool
+	schemas    map[string]*jsonschema.Schema
+	outSchemas map[string]*jsonschema.Schema
+
+	// Redação de segredos na saída por tool (redaction global + tools[*].redaction)
+	redactors map[string]*redactor
+
+	// Sinks de eventos de execução por tool (tools[*].events + WithEventSink)
+	sinks map[string]events.Multi
+
+	// tools[*].aliases -> nome canônico
+	aliases map[string]string
+
+	// Identificação do config carregado + drift do arquivo em disco (/admin/config)
+	cfgState configState
 }
 
-func New(cfg *config.Config) *Service {
-	return &Service{
-		cfg: cfg,
-		r:   runner.New(cfg),
-		sem: make(map[string]chan struct{}),
+// Option customiza o Service na construção (dependências opcionais).
+type Option func(*Service)
+
+// WithStateFrom herda de prev (o serviço substituído num reload do config) o estado que
+// não pode recomeçar: o uso das chaves de API (cotas diárias, chamadas em andamento) e as
+// assinaturas HMAC já aceitas. Sem isso um reload zeraria as cotas e reabriria o replay.
+func WithStateFrom(prev *Service) Option {
+	return func(s *Service) {
+		if prev == nil {
+			return
+		}
+		s.keys.keyUsage = prev.keys.keyUsage
+		s.replays = prev.replays
+	}
+}
+
+// WithStore define o backend de storage (artefatos/transcripts/jobs).
+// Sem ele, o Service usa o disco local em config.DefaultStoragePath.
+func WithStore(st storage.Store) Option {
+	return func(s *Service) { s.store = st }
+}
+
+func New(cfg *config.Config, opts ...Option) *Service {
+	// _echo, _sleep, _env, _version: sempre presentes, qualquer que seja o config
+	cfg = cfg.WithDiagnosticTools()
+	s := &Service{
+		cfg:     cfg,
+		r:       runner.New(cfg),
+		sem:     make(map[string]*fairLimiter),
+		limits:  newClientLimiter(cfg.Limits),
+		keys:    newKeyRegistry(cfg.Auth),
+		replays: &replayCache{},
+		execs:   newExecutionRegistry(),
+		sinks:   make(map[string]events.Multi),
+		stats:   newStatsRegistry(),
+
+		schemas:    compileSchemas(cfg, func(t config.Tool) map[string]any { return t.InputSchema }),
+		redactors:  newRedactors(cfg),
+		outSchemas: compileSchemas(cfg, func(t config.Tool) map[string]any { return t.OutputSchema }),
+
+		aliases: newAliases(cfg),
+		signer:  newSigner(),
+		pull:    imagePuller{images: make(map[string]*ImageStatus)},
+
+		cfgState: configState{info: ConfigInfo{Profile: cfg.ActiveProfile, SHA256: cfg.Checksum(), LoadedAt: time.Now().UTC()}},
 	}
+	newEventSinks(s)
+	if cfg.Auth.OIDC.Enabled() {
+		s.oidc = oidc.New(cfg.Auth.OIDC)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.store == nil {
+		s.store = storage.NewLocal(cfg.Storage.PathEffective())
+	}
+	return s
+}
+
+// Close libera recursos mantidos entre requests (containers reutilizados).
+func (s *Service) Close() {
+	s.r.Close()
+	s.closeEventSinks()
+}
+
+// Store retorna o backend de storage do gateway.
+func (s *Service) Store() storage.Store {
+	return s.store
 }
 
 type ToolInfo struct {
-	Name    string `json:"name"`
-	Runtime string `json:"runtime"`
-	Mode    string `json:"mode"`
+	Name          string         `json:"name"`
+	Runtime       string         `json:"runtime"`
+	Mode          string         `json:"mode"`
+	Description   string         `json:"description,omitempty"`
+	Version       string         `json:"version,omitempty"`
+	Tags          []string       `json:"tags,omitempty"`
+	Aliases       []string       `json:"aliases,omitempty"`
+	Deprecated    bool           `json:"deprecated,omitempty"`
+	InputSchema   map[string]any `json:"input_schema,omitempty"`
+	TimeoutMS     int64          `json:"timeout_ms"`
+	MaxTimeoutMS  int64          `json:"max_timeout_ms"` // teto do X-MCP-Timeout
+	MaxConcurrent int            `json:"max_concurrent"`
+}
+
+// HasTag diz se a tool tem a tag (filtro ?filter=tag:<tag> do catálogo).
+func (t ToolInfo) HasTag(tag string) bool {
+	return slices.Contains(t.Tags, tag)
 }
 
-// GET /mcp/tools (e stdio "tools/list" no futuro)
+// GET /mcp/tools (e stdio "tools/list" no futuro), ordenado por nome.
 func (s *Service) ListTools(ctx context.Context) ([]ToolInfo, error) {
-	_ = ctx
+	// com credencial, só as tools do escopo dela
+	p := PrincipalFromContext(ctx)
 	out := make([]ToolInfo, 0, len(s.cfg.Tools))
 	for name, t := range s.cfg.Tools {
+		if p != nil && !p.Permits(name, t.Tags) {
+			continue
+		}
 		out = append(out, ToolInfo{
-			Name:    name,
-			Runtime: t.Runtime,
-			Mode:    t.Mode,
+			Name:          name,
+			Runtime:       t.Runtime,
+			Mode:          t.Mode,
+			Description:   t.Description,
+			Version:       t.Version,
+			Tags:          t.Tags,
+			Aliases:       t.Aliases,
+			Deprecated:    t.Deprecated,
+			InputSchema:   t.InputSchema,
+			TimeoutMS:     t.Timeout().Milliseconds(),
+			MaxTimeoutMS:  t.MaxTimeout().Milliseconds(),
+			MaxConcurrent: t.MaxConc(),
 		})
 	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
 	return out, nil
 }
 
+// ToolHealth retorna a saúde das sessões reutilizadas das tools com health configurado.
+func (s *Service) ToolHealth() []runner.PoolHealth {
+	return s.r.PoolHealth()
+}
+
+// ContainerRuntimes lista os runtimes OCI (container_runtime) exigidos pelas tools, sem repetição.
+func (s *Service) ContainerRuntimes() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range s.cfg.Tools {
+		if t.Runtime == "container" && t.ContainerRuntime != "" && !seen[t.ContainerRuntime] {
+			seen[t.ContainerRuntime] = true
+			out = append(out, t.ContainerRuntime)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// AdminToken retorna o token dos endpoints /admin/* ("" = admin desligado).
+func (s *Service) AdminToken() string {
+	return s.cfg.Admin.Token()
+}
+
+// TLS retorna a configuração de TLS do transport HTTP.
+func (s *Service) TLS() config.TLS {
+	return s.cfg.TLS
+}
+
+// AccessLog retorna a configuração do access log do transport HTTP.
+func (s *Service) AccessLog() config.AccessLog {
+	return s.cfg.AccessLog
+}
+
+// TrustedProxies retorna os proxies cujos headers de IP do cliente valem (trusted_proxies).
+func (s *Service) TrustedProxies() []netip.Prefix {
+	return s.cfg.TrustedProxyPrefixes()
+}
+
+// HostCheck retorna os Host/Origin aceitos pelo transport HTTP.
+func (s *Service) HostCheck() config.HostCheck {
+	return s.cfg.HostCheck
+}
+
+// Compression retorna a configuração de compressão das respostas HTTP.
+func (s *Service) Compression() config.Compression {
+	return s.cfg.Compression
+}
+
+// Files retorna a config dos endpoints de arquivos do workspace e a raiz do workspace
+// nomeado ("" = workspace_root; o nome já passou pela allowlist).
+func (s *Service) Files(workspace string) (config.Files, string) {
+	root, _ := s.cfg.WorkspaceFor(workspace)
+	return s.cfg.Files, root
+}
+
+// ResolveFlags combina as flags default do config com as pedidas pelo cliente
+// (header X-MCP-Flags), respeitando a allowlist. Retorna também as rejeitadas.
+func (s *Service) ResolveFlags(requested string) (flags.Set, []string) {
+	return flags.Resolve(requested, s.cfg.Flags.Allowed, s.cfg.Flags.Default)
+}
+
 // ErrToolBusy é retornado quando o limite de concorrência da tool foi atingido.
 var ErrToolBusy = fmt.Errorf("tool is busy")
 
-func (s *Service) toolSemaphore(toolName string, tool config.Tool) chan struct{} {
+// ErrToolStartupTimeout é a causa quando a tool não emite nenhuma linha dentro de
+// startup_timeout_ms (processo morto; timeout_ms continua limitando a execução inteira).
+var ErrToolStartupTimeout = errors.New("tool_startup_timeout")
+
+func (s *Service) toolSemaphore(toolName string, tool config.Tool) *fairLimiter {
 	s.semMu.Lock()
 	defer s.semMu.Unlock()
 
-	if ch, ok := s.sem[toolName]; ok {
-		return ch
+	if l, ok := s.sem[toolName]; ok {
+		return l
 	}
 
-	capacity := tool.MaxConc() // default conservador no config
-	ch := make(chan struct{}, capacity)
-	s.sem[toolName] = ch
-	return ch
+	l := newFairLimiter(toolName, tool.MaxConc()) // default conservador no config
+	s.sem[toolName] = l
+	return l
 }
 
-func acquireSemaphore(sem chan struct{}) error {
-	// Fail-fast (evita fila infinita e fork-bomb por paralelismo)
-	select {
-	case sem <- struct{}{}:
-		return nil
-	default:
-		return ErrToolBusy
+// Slots retorna o estado dos limites de concorrência das tools já chamadas desde o start.
+func (s *Service) Slots() []SlotInfo {
+	s.semMu.Lock()
+	out := make([]SlotInfo, 0, len(s.sem))
+	for _, l := range s.sem {
+		out = append(out, l.info())
 	}
+	s.semMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Tool < out[j].Tool })
+	return out
 }
 
-func releaseSemaphore(sem chan struct{}) {
-	select {
-	case <-sem:
-	default:
+// acquireSlot reserva a execução nos limites do gateway (limits, fila por priority) e
+// depois no slot da tool. Fail-fast por padrão (evita fila infinita e fork-bomb por
+// paralelismo); com queue_timeout_ms espera em fila justa. O release devolve os dois.
+func (s *Service) acquireSlot(ctx context.Context, toolName string, tool config.Tool) (func(), error) {
+	identity := Identity(ctx)
+	if err := s.limits.acquire(ctx, identity, tool.PriorityEffective(), tool.QueueTimeout()); err != nil {
+		return nil, err
 	}
+	sem := s.toolSemaphore(toolName, tool)
+	if err := sem.acquire(ctx, identity, tool.QueueTimeout()); err != nil {
+		s.limits.release(identity)
+		return nil, err
+	}
+	return func() {
+		sem.release()
+		s.limits.release(identity)
+	}, nil
 }
 
 // StreamTool executa a tool (launcher), manda 1 input (linha JSON) e streama stdout linha a linha.
@@ -97,8 +314,23 @@ func releaseSemaphore(sem chan struct{}) {
 // - toolName validado via sandbox
 // - toda execução tem timeout (Tool.Timeout())
 // - processo é finalizado em cancelamento (ctx.Done())
-func (s *Service) StreamTool(ctx context.Context, toolName string, inputJSON []byte, out LineWriter) (retErr error) {
+func (s *Service) StreamTool(ctx context.Context, toolName string, inputJSON []byte, out LineWriter) error {
+	return s.StreamToolInput(ctx, toolName, inputJSON, nil, out)
+}
+
+// StreamToolBody executa uma tool stream_body com o body inteiro em stream: os bytes de
+// body vão para o stdin à medida que chegam (sem validação nem retry) e o stdin fecha no
+// fim do body. Erro de leitura de body (ex: ErrBodyTooLarge) encerra a execução.
+func (s *Service) StreamToolBody(ctx context.Context, toolName string, body io.Reader, out LineWriter) error {
+	return s.StreamToolInput(ctx, toolName, nil, rawBody{body}, out)
+}
+
+// StreamToolInput é o StreamTool com input em stream (tools interactive): depois de
+// inputJSON, cada linha JSON lida de more vai para o stdin da tool, que só é fechado
+// no fim de more. more == nil: uma mensagem só (stdin fechado logo após o input).
+func (s *Service) StreamToolInput(ctx context.Context, toolName string, inputJSON []byte, more io.Reader, out LineWriter) (retErr error) {
 	start := time.Now()
+	ctx = runner.WithIdentity(ctx, Identity(ctx))
 
 	baseLog := logging.LoggerFromContext(ctx)
 	rid := logging.RequestIDFromContext(ctx)
@@ -109,8 +341,18 @@ func (s *Service) StreamTool(ctx context.Context, toolName string, inputJSON []b
 	)
 
 	var runtimeName string
+	var exec *execution
+	retries := 0
 
 	defer func() {
+		if retries > 0 {
+			log = log.With(logging.Int("retries", retries))
+		}
+		if exec != nil {
+			if n := exec.redactions.Load(); n > 0 {
+				log = log.With(logging.Int64("redactions", n))
+			}
+		}
 		if retErr != nil {
 			log.Error("tool execution failed",
 				logging.Runtime(runtimeName),
@@ -126,8 +368,9 @@ func (s *Service) StreamTool(ctx context.Context, toolName string, inputJSON []b
 	}()
 
 	if err := sandbox.ValidateToolName(toolName); err != nil {
-		return fmt.Errorf("invalid tool name: %w", err)
+		return fmt.Errorf("%w: %w", ErrInvalidToolName, err)
 	}
+	toolName = s.canonicalTool(toolName)
 
 	tool, err := s.r.MustGetTool(toolName)
 	if err != nil {
@@ -137,104 +380,457 @@ func (s *Service) StreamTool(ctx context.Context, toolName string, inputJSON []b
 	runtimeName = tool.Runtime
 	log = log.With(logging.Runtime(runtimeName))
 
+	wsName, wsRoot, err := s.requestWorkspace(ctx, tool)
+	if err != nil {
+		return err
+	}
+	if wsName != "" {
+		log = log.With(logging.String("workspace", wsName))
+	}
+
+	// auth.api_keys: escopo de tools, cota diária e max_concurrent da chave
+	releaseKey, err := s.authorize(ctx, toolName, tool)
+	if err != nil {
+		return err
+	}
+	defer releaseKey()
+	defer func() { s.stats.record(toolName, start, time.Since(start), retErr) }()
+
+	// input recusado não chega à tool: a chamada volta para a cota da chave
+	rejectInput := func(err error) error {
+		s.refundQuota(ctx)
+		return err
+	}
+
+	_, streamBody := more.(rawBody)
+	switch {
+	case streamBody && !tool.StreamBody:
+		return rejectInput(ErrStreamBodyNotAllowed)
+	case more != nil && !streamBody && !tool.Interactive:
+		return rejectInput(ErrInteractiveNotAllowed)
+	}
+
+	// body em stream não é validado: os bytes vão para o stdin como chegam
+	if !streamBody {
+		if len(inputJSON) == 0 {
+			inputJSON = []byte(`{}`)
+		}
+		if !json.Valid(inputJSON) {
+			return rejectInput(ErrInvalidInput)
+		}
+		// input_schema: recusa o input antes de ocupar slot e gastar um spawn
+		if err := s.validateInput(toolName, inputJSON); err != nil {
+			return rejectInput(err)
+		}
+	}
+
 	// Limite de concorrência por tool
-	sem := s.toolSemaphore(toolName, tool)
-	if err := acquireSemaphore(sem); err != nil {
+	release, err := s.acquireSlot(ctx, toolName, tool)
+	if err != nil {
 		log.Warn("tool concurrency limit reached",
 			logging.Err(err),
 			slog.Int("max_concurrent", tool.MaxConc()),
+			logging.Client(logging.ClientFromContext(ctx)),
 		)
+		if IsBusy(err) {
+			events.Default.Publish(events.Event{Type: events.TypeToolBusy, Tool: toolName, RequestID: rid,
+				Client: logging.ClientFromContext(ctx), Code: ErrorCode(err), Error: err.Error()})
+		}
 		return err
 	}
-	defer releaseSemaphore(sem)
+	defer release()
 
 	log.Info("tool execution started",
 		slog.String("mode", tool.Mode),
 		slog.Int("max_concurrent", tool.MaxConc()),
 	)
 
-	tctx, cancel := context.WithTimeout(ctx, tool.Timeout())
+	// Bus do gateway (GET /admin/events): início e fim de cada execução
+	busEvent := events.Event{Tool: toolName, RequestID: rid, Client: logging.ClientFromContext(ctx)}
+	started := busEvent
+	started.Type = events.TypeRequestStarted
+	events.Default.Publish(started)
+	defer func() {
+		e := busEvent
+		e.Type, e.DurationMS = events.TypeRequestFinished, time.Since(start).Milliseconds()
+		if retErr != nil {
+			e.Code, e.Error = ErrorCode(retErr), retErr.Error()
+		}
+		events.Default.Publish(e)
+	}()
+
+	// Registro de execuções em andamento (inspeção + kill forçado no shutdown)
+	exec = &execution{
+		requestID: rid,
+		tool:      toolName,
+		runtime:   runtimeName,
+		client:    logging.ClientFromContext(ctx),
+		owner:     Identity(ctx),
+	}
+	ctx, unregister := s.execs.register(ctx, exec)
+	defer unregister()
+
+	// record: guarda input + saída entregue (não grava input em stream nem replays)
+	if tool.Record && more == nil && ctx.Value(replayKey{}) == nil {
+		rec := &recorder{rec: Recording{Tool: toolName, Input: inputJSON, RequestID: rid, RecordedAt: time.Now().UTC()}}
+		out = teeOutput(out, rec.observe)
+		defer func() { s.saveRecording(ctx, rec, start, retErr) }()
+	}
+
+	// Eventos de execução (tools[*].events): start agora, uma por linha, end no retorno
+	if sink := s.sinks[toolName]; len(sink) > 0 {
+		base := events.Event{Tool: toolName, RequestID: rid, Client: exec.client}
+		e := base
+		e.Type, e.Time = events.TypeStart, time.Now()
+		sink.OnStart(e)
+		var seq int64
+		out = teeOutput(out, func(_ string, data []byte) {
+			seq++
+			e := base
+			e.Type, e.Time, e.Seq, e.Line = events.TypeLine, time.Now(), seq, string(data)
+			sink.OnLine(e)
+		})
+		defer func() {
+			e := base
+			e.Type, e.Time = events.TypeEnd, time.Now()
+			e.DurationMS, e.Lines = time.Since(start).Milliseconds(), seq
+			if retErr != nil {
+				e.Code, e.Error = ErrorCode(retErr), retErr.Error()
+			}
+			sink.OnEnd(e)
+		}()
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, toolTimeout(ctx, tool))
 	defer cancel()
 
-	p, err := s.r.Start(tctx, toolName, tool)
+	// workspace: ephemeral: diretório próprio da request (ver workspace.go)
+	if tool.Ephemeral() {
+		var cleanup func(error)
+		if tool, cleanup, err = s.ephemeralWorkspace(wsRoot, tool, rid, log); err != nil {
+			return err
+		}
+		defer func() { cleanup(retErr) }()
+	}
+
+	// deadline_field: o prazo também vai no input (além de MCP_DEADLINE_UNIX_MS no env)
+	if tool.DeadlineField != "" && !streamBody {
+		deadline, _ := tctx.Deadline()
+		inputJSON = injectDeadline(inputJSON, tool.DeadlineField, deadline)
+	}
+
+	// request_id_field: o request_id também vai no input (além de MCP_REQUEST_ID no env)
+	if tool.RequestIDField != "" && !streamBody && rid != "" {
+		inputJSON = injectField(inputJSON, tool.RequestIDField, rid)
+	}
+
+	// Retry transparente só para falhas antes da 1ª linha de saída (nada chegou ao cliente)
+	for attempt := 1; ; attempt++ {
+		kind, err := s.runAttempt(tctx, toolName, tool, inputJSON, more, out, exec, log)
+		// input em stream e sessões daemon não têm retry: o estado já consumido não volta
+		if err == nil || kind == "" || more != nil || tool.Mode == "daemon" || !tool.Retry.RetriesOn(kind) || attempt >= tool.Retry.AttemptsEffective() {
+			return err
+		}
+
+		delay := tool.Retry.Backoff() << (attempt - 1)
+		retries = attempt
+		log.Warn("retrying tool after transient failure",
+			logging.Int("attempt", attempt+1),
+			logging.String("reason", kind),
+			logging.Int64("backoff_ms", delay.Milliseconds()),
+			logging.Err(err),
+		)
+		select {
+		case <-time.After(delay):
+		case <-tctx.Done():
+			return ctxErr(tctx)
+		}
+	}
+}
+
+// runAttempt executa uma tentativa (spawn -> stdin -> stream stdout -> wait).
+// Retorna o tipo de falha transitória (config.RetryOn*) quando nada foi streamado, senão "".
+func (s *Service) runAttempt(tctx context.Context, toolName string, tool config.Tool, inputJSON []byte, more io.Reader, out LineWriter, exec *execution, log *slog.Logger) (kind string, err error) {
+	// startup_timeout_ms: cancela a tentativa (com causa própria) se a 1ª linha não chegar
+	tctx, cancelAttempt := context.WithCancelCause(tctx)
+	defer cancelAttempt(nil)
+	startup := tool.StartupTimeout()
+	var startupTimer *time.Timer
+	if startup > 0 {
+		startupTimer = time.AfterFunc(startup, func() {
+			cancelAttempt(fmt.Errorf("%w: no output within %s", ErrToolStartupTimeout, startup))
+		})
+		defer startupTimer.Stop()
+	}
+	defer func() {
+		if errors.Is(err, ErrToolStartupTimeout) {
+			log.Warn("tool startup timeout", logging.Int64("startup_timeout_ms", startup.Milliseconds()))
+			kind = config.RetryOnStartupTimeout
+		}
+	}()
+
+	// cancellation: cooperative: o processo sobrevive ao tctx até o fim da fase 1 (ver cancel.go)
+	pctx, stopProcess := processContext(tctx, tool)
+	defer stopProcess()
+
+	p, err := s.r.Start(pctx, toolName, tool)
 	if err != nil {
-		return err
+		if tctx.Err() != nil {
+			return "", ctxErr(tctx)
+		}
+		return config.RetryOnSpawnError, fmt.Errorf("%w: %w", ErrSpawnFailed, err)
 	}
 
 	log.Debug("process started")
+	s.execs.attach(exec, p)
 
 	// Garante kill no cancelamento + cleanup
 	done := make(chan struct{})
 	go func() {
 		select {
 		case <-tctx.Done():
+			if tool.Cooperative() {
+				sendCancel(p, tool, exec.requestID, log)
+				select {
+				case <-time.After(tool.CancelGrace()):
+					log.Warn("tool ignored cancel message; killing")
+				case <-done:
+					return
+				}
+			}
+			stopProcess()
 			_ = p.Close()
 		case <-done:
 		}
 	}()
-	defer close(done)
-	defer func() { _ = p.Close() }()
+	defer func() {
+		if tool.Cooperative() && tctx.Err() != nil {
+			// a leitura parou no cancelamento: a tool sai sozinha ou o watcher mata no fim da grace
+			drainUntilExit(p, tool.CancelGrace())
+		}
+		close(done)
+		stopProcess()
+		_ = p.Close()
+	}()
 
-	if len(inputJSON) == 0 {
-		inputJSON = []byte(`{}`)
-	}
-	if !json.Valid(inputJSON) {
-		return fmt.Errorf("invalid input json")
+	switch body, streamBody := more.(rawBody); {
+	case streamBody:
+		go forwardBody(p.Stdin(), body.Reader, cancelAttempt, log)
+	case more != nil:
+		if err := writeJSONLine(p.Stdin(), inputJSON); err != nil {
+			_ = p.Stdin().Close()
+			return "", fmt.Errorf("write stdin: %w", err)
+		}
+		go forwardInput(p.Stdin(), more, cancelAttempt, log)
+	case tool.Cooperative():
+		// stdin aberto: é por ele que chega a mensagem de cancelamento
+		if err := writeJSONLine(p.Stdin(), inputJSON); err != nil {
+			_ = p.Stdin().Close()
+			return "", fmt.Errorf("write stdin: %w", err)
+		}
+	default:
+		if err := writeJSONLineAndClose(p.Stdin(), inputJSON); err != nil {
+			return "", fmt.Errorf("write stdin: %w", err)
+		}
 	}
 
-	if err := writeJSONLineAndClose(p.Stdin(), inputJSON); err != nil {
-		return fmt.Errorf("write stdin: %w", err)
+	limit := &outputLimiter{maxBytes: tool.MaxOutputBytes, maxLines: tool.MaxOutputLines}
+
+	// output: binary: stdout em frames base64 (sem scanner de linhas)
+	if tool.Output == config.OutputBinary {
+		err := pumpBinary(tctx, p.Stdout(), out, limit, exec, func() {
+			if startupTimer != nil {
+				startupTimer.Stop()
+			}
+		})
+		if tctx.Err() != nil {
+			return "", ctxErr(tctx)
+		}
+		var trunc *TruncatedError
+		if errors.As(err, &trunc) {
+			log.Warn("tool output limit reached", logging.Err(err))
+		}
+		if err != nil {
+			return "", err
+		}
+		return waitAttempt(tctx, p, limit)
 	}
 
-	sc := bufio.NewScanner(p.Stdout())
-	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	// linhas acima de max_line_bytes saem em pedaços (event: continuation / line_truncated)
+	lr := newLineReader(p.Stdout(), tool.MaxLineBytesEffective())
+	var giant *giantLine
+
+	// tty em container: o pty do container ecoa a linha de input (o docker CLI repassa em raw)
+	skipEcho := tool.TTY && tool.Runtime == "container"
+	// output_schema: contrato de cada linha (warn anota, strict encerra o stream)
+	oc := s.newOutputCheck(toolName, tool)
+	for {
+		chunk, more, rerr := lr.Next()
+		if rerr != nil {
+			if rerr == io.EOF || tctx.Err() != nil {
+				break
+			}
+			return "", fmt.Errorf("read stdout: %w", rerr)
+		}
 
-	var lines int64
-	for sc.Scan() {
 		select {
 		case <-tctx.Done():
-			return tctx.Err()
+			return "", ctxErr(tctx)
 		default:
 		}
 
-		line := append([]byte(nil), sc.Bytes()...)
+		line := chunk
+		if tool.TTY && !more {
+			line = bytes.TrimRight(line, "\r") // ONLCR do terminal: "\r\n"
+		}
+		if startupTimer != nil && len(line) > 0 {
+			startupTimer.Stop() // a partir daqui só timeout_ms vale
+		}
+
+		// linha gigante: do 1º pedaço (more=true) até o more=false
+		if more || giant != nil {
+			if giant == nil {
+				giant = &giantLine{policy: tool.LongLinesEffective()}
+			}
+			if err := giant.feed(out, line, more, limit, exec); err != nil {
+				var trunc *TruncatedError
+				if errors.As(err, &trunc) {
+					log.Warn("tool output limit reached", logging.Err(err))
+				}
+				return "", err
+			}
+			if !more {
+				log.Warn("tool emitted oversized line",
+					slog.Int64("line_bytes", giant.bytes),
+					slog.String("long_lines", giant.policy),
+				)
+				giant = nil
+			}
+			continue
+		}
+
 		if len(line) == 0 {
 			continue
 		}
+		if skipEcho {
+			skipEcho = false
+			if bytes.Equal(line, bytes.TrimSpace(inputJSON)) {
+				continue
+			}
+		}
+
+		// segredos saem antes de qualquer consumidor da linha (cliente, schema, sinks)
+		line, n := s.redactors[toolName].redact(line)
+		if n > 0 {
+			exec.redactions.Add(int64(n))
+		}
+
+		violations, err := oc.check(line)
+		if err != nil {
+			log.Warn("tool output violates output_schema", logging.Err(err))
+			return "", err
+		}
+
+		// limite de saída: para de ler; o defer mata o processo
+		if err := limit.admit(len(line)); err != nil {
+			log.Warn("tool output limit reached", logging.Err(err))
+			return "", err
+		}
 
 		if err := out.WriteLine(line); err != nil {
-			return err
+			return "", err
 		}
+		if len(violations) > 0 {
+			if err := oc.warn(out, violations, log); err != nil {
+				return "", err
+			}
+		}
+		exec.bytes.Add(int64(len(line)))
 
-		lines++
-		if log.Enabled(tctx, slog.LevelDebug) && lines%200 == 0 {
-			log.Debug("streaming progress", slog.Int64("lines_out", lines))
+		if log.Enabled(tctx, slog.LevelDebug) && limit.lines%200 == 0 {
+			log.Debug("streaming progress", slog.Int64("lines_out", limit.lines))
 		}
 	}
 
-	if err := sc.Err(); err != nil {
-		return fmt.Errorf("read stdout: %w", err)
+	// Processo morto pelo cancelamento: reporta a causa (timeout, cancel, shutdown), não o sinal.
+	if tctx.Err() != nil {
+		return "", ctxErr(tctx)
 	}
 
+	return waitAttempt(tctx, p, limit)
+}
+
+// waitAttempt espera o processo depois do fim da saída e classifica a falha.
+func waitAttempt(tctx context.Context, p runner.Process, limit *outputLimiter) (string, error) {
 	if err := p.Wait(); err != nil {
-		return err
+		if tctx.Err() != nil {
+			return "", ctxErr(tctx)
+		}
+		if limit.lines == 0 {
+			return config.RetryOnNonzeroExit, err
+		}
+		return "", err
 	}
 
-	return nil
+	return "", nil
+}
+
+// ctxErr prefere a causa do cancelamento (ex: ErrForceKilled) ao erro genérico do ctx.
+func ctxErr(ctx context.Context) error {
+	if cause := context.Cause(ctx); cause != nil {
+		return cause
+	}
+	return ctx.Err()
 }
 
 func writeJSONLineAndClose(w io.WriteCloser, b []byte) error {
+	if err := writeJSONLine(w, b); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func writeJSONLine(w io.Writer, b []byte) error {
 	if len(b) == 0 {
 		b = []byte(`{}`)
 	}
 	if b[len(b)-1] != '\n' {
 		b = append(b, '\n')
 	}
-	if _, err := w.Write(b); err != nil {
-		_ = w.Close()
-		return err
+	_, err := w.Write(b)
+	return err
+}
+
+// ToolFlushPolicy retorna o tuning de streaming HTTP da tool (intervalo de flush e buffer de escrita).
+func (s *Service) ToolFlushPolicy(name string) (time.Duration, int) {
+	t, ok := s.cfg.Tools[name]
+	if !ok {
+		return 0, 0
 	}
-	return w.Close()
+	return t.FlushInterval(), t.WriteBufferBytes
+}
+
+// ToolBackpressure retorna o buffer de saída (linhas) e a política de cliente lento da tool.
+func (s *Service) ToolBackpressure(name string) (int, string) {
+	return s.cfg.Stream.BufferLinesEffective(), s.cfg.SlowClientPolicy(s.cfg.Tools[name])
+}
+
+// StreamResumeWindow é a janela de reconexão de streams SSE (0 = resume desligado).
+func (s *Service) StreamResumeWindow() time.Duration {
+	return s.cfg.Stream.ResumeWindow()
+}
+
+// ToolInteractive diz se a tool aceita input em stream (interactive: true).
+func (s *Service) ToolInteractive(name string) bool {
+	return s.cfg.Tools[name].Interactive
+}
+
+// ToolStreamBody diz se a tool recebe bodies grandes em stream (stream_body: true) e até
+// quantos bytes.
+func (s *Service) ToolStreamBody(name string) (bool, int64) {
+	t := s.cfg.Tools[name]
+	return t.StreamBody, t.MaxBodyBytesEffective()
 }
 
 func (s *Service) ToolTimeout(name string) (time.Duration, bool) {
@@ -244,3 +840,30 @@ func (s *Service) ToolTimeout(name string) (time.Duration, bool) {
 	}
 	return t.Timeout(), true
 }
+
+// requestTimeoutKey carrega o timeout pedido pelo cliente (header X-MCP-Timeout).
+type requestTimeoutKey struct{}
+
+// WithRequestTimeout faz a execução em ctx usar d no lugar do timeout_ms da tool, até o
+// teto max_timeout_ms (ver ClampToolTimeout).
+func WithRequestTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, d)
+}
+
+// ClampToolTimeout limita o timeout pedido pelo cliente ao max_timeout_ms da tool.
+// Retorna o timeout que vale de fato.
+func (s *Service) ClampToolTimeout(name string, d time.Duration) (time.Duration, bool) {
+	t, ok := s.cfg.Tools[name]
+	if !ok {
+		return 0, false
+	}
+	return min(d, t.MaxTimeout()), true
+}
+
+// toolTimeout é o timeout da execução: o pedido pelo cliente (limitado) ou o da tool.
+func toolTimeout(ctx context.Context, tool config.Tool) time.Duration {
+	if d, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok && d > 0 {
+		return min(d, tool.MaxTimeout())
+	}
+	return tool.Timeout()
+}
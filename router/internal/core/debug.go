@@ -0,0 +1,51 @@
+package core
+
+import (
+	"os"
+	"runtime"
+	"time"
+)
+
+// processStart marca o início do gateway (uptime no /admin/debug/runtime).
+var processStart = time.Now()
+
+// RuntimeInfo é o retrato do processo do gateway para diagnóstico de vazamentos
+// (goroutines de stream presas, descritores e processos de tool que não fecham).
+type RuntimeInfo struct {
+	Goroutines     int    `json:"goroutines"`
+	OpenFDs        int    `json:"open_fds"` // -1 quando /proc não está disponível
+	ToolProcesses  int    `json:"tool_processes"`
+	Executions     int    `json:"executions"`
+	Sessions       int    `json:"sessions"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	NumGC          uint32 `json:"num_gc"`
+	UptimeMS       int64  `json:"uptime_ms"`
+	GoVersion      string `json:"go_version"`
+}
+
+// RuntimeInfo coleta os contadores do processo. ReadMemStats pausa o mundo por um
+// instante: é endpoint de diagnóstico, não de scrape.
+func (s *Service) RuntimeInfo() RuntimeInfo {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	return RuntimeInfo{
+		Goroutines:     runtime.NumGoroutine(),
+		OpenFDs:        openFDs(),
+		ToolProcesses:  s.r.LiveProcesses(),
+		Executions:     len(s.execs.snapshot()),
+		Sessions:       len(s.r.Sessions()),
+		HeapAllocBytes: ms.HeapAlloc,
+		NumGC:          ms.NumGC,
+		UptimeMS:       time.Since(processStart).Milliseconds(),
+		GoVersion:      runtime.Version(),
+	}
+}
+
+func openFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(entries)
+}
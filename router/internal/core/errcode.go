@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"mcp-router/internal/jsonschema"
+	"mcp-router/internal/runner"
+)
+
+// Sentinelas das validações de StreamTool (classificadas em códigos por ErrorFor).
+var (
+	ErrInvalidToolName = errors.New("invalid tool name")
+	ErrInvalidInput    = errors.New("invalid input json")
+	ErrSpawnFailed     = errors.New("spawn failed")
+	ErrSchemaViolation = errors.New("input does not match the tool input_schema")
+	ErrOutputViolation = errors.New("tool output does not match the tool output_schema")
+)
+
+// SchemaError é o input recusado pelo input_schema da tool, com as violações
+// (entregues em details do erro).
+type SchemaError struct {
+	Violations []jsonschema.Violation
+}
+
+func (e *SchemaError) Error() string {
+	return ErrSchemaViolation.Error() + ": " + joinViolations(e.Violations)
+}
+
+func (e *SchemaError) Unwrap() error { return ErrSchemaViolation }
+
+// ErrorDetails são as violações (details do APIError).
+func (e *SchemaError) ErrorDetails() any { return e.Violations }
+
+// OutputViolationError é a linha de saída que quebrou o output_schema (output_validation: strict).
+type OutputViolationError struct {
+	Line       int64                  `json:"line"` // nº da linha de saída (1 = primeira)
+	Violations []jsonschema.Violation `json:"violations"`
+}
+
+func (e *OutputViolationError) Error() string {
+	return fmt.Sprintf("%s: line %d: %s", ErrOutputViolation, e.Line, joinViolations(e.Violations))
+}
+
+func (e *OutputViolationError) Unwrap() error { return ErrOutputViolation }
+
+// ErrorDetails é a própria linha + violações (details do APIError).
+func (e *OutputViolationError) ErrorDetails() any { return e }
+
+func joinViolations(vs []jsonschema.Violation) string {
+	parts := make([]string, len(vs))
+	for i, v := range vs {
+		parts[i] = v.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Códigos de erro estáveis, iguais em HTTP, SSE (event: error) e stdio: o cliente
+// decide pelo code; message é texto livre para humanos e pode mudar.
+const (
+	CodeToolBusy         = "tool_busy"
+	CodeClientBusy       = "client_busy"
+	CodeGatewayBusy      = "gateway_busy"
+	CodeTimeout          = "timeout"
+	CodeStartupTimeout   = "startup_timeout"
+	CodeSpawnFailed      = "spawn_failed"
+	CodeToolFailed       = "tool_failed"
+	CodeUnknownTool      = "unknown_tool"
+	CodeInvalidToolName  = "invalid_tool_name"
+	CodeInvalidInput     = "invalid_input"
+	CodeSchemaViolation  = "schema_violation"
+	CodeOutputViolation  = "output_violation"
+	CodeInvalidRequest   = "invalid_request"
+	CodeNotInteractive   = "not_interactive"
+	CodeBodyTooLarge     = "body_too_large"
+	CodeUnsupported      = "unsupported"
+	CodeUnknownWorkspace = "unknown_workspace"
+	CodeHostNotAllowed   = "host_not_allowed"
+	CodeForbidden        = "forbidden"
+	CodeQuotaExceeded    = "quota_exceeded"
+	CodeCancelled        = "cancelled"
+	CodeKilled           = "killed"
+	CodeShutdown         = "shutdown"
+	CodeSessionRequired  = "session_required"
+	CodeSessionNotFound  = "session_not_found"
+	CodeSessionBusy      = "session_busy"
+	CodeSessionLimit     = "session_limit"
+	CodeSessionMismatch  = "session_mismatch"
+	CodeNotDaemon        = "not_daemon"
+	CodeSignatureInvalid = "signature_invalid"
+	CodeSignatureExpired = "signature_expired"
+	CodeSignatureUsed    = "signature_used"
+	CodeUnauthorized     = "unauthorized"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeUnsupportedMedia = "unsupported_media_type"
+	CodeInternal         = "internal"
+)
+
+// retryableCodes são falhas transitórias: repetir a mesma chamada mais tarde pode dar certo.
+var retryableCodes = map[string]bool{
+	CodeToolBusy:       true,
+	CodeClientBusy:     true,
+	CodeGatewayBusy:    true,
+	CodeStartupTimeout: true,
+	CodeSpawnFailed:    true,
+	CodeShutdown:       true,
+	CodeSessionBusy:    true,
+	CodeSessionLimit:   true,
+}
+
+// errorCodes mapeia as sentinelas para os códigos (primeira que casar com errors.Is).
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrToolBusy, CodeToolBusy},
+	{ErrClientBusy, CodeClientBusy},
+	{ErrGatewayBusy, CodeGatewayBusy},
+	{ErrToolStartupTimeout, CodeStartupTimeout},
+	{ErrSpawnFailed, CodeSpawnFailed},
+	{ErrInvalidToolName, CodeInvalidToolName},
+	{ErrInvalidInput, CodeInvalidInput},
+	{ErrSchemaViolation, CodeSchemaViolation},
+	{ErrOutputViolation, CodeOutputViolation},
+	{ErrInvalidInputMessage, CodeInvalidInput},
+	{ErrInteractiveNotAllowed, CodeNotInteractive},
+	{ErrStreamBodyNotAllowed, CodeUnsupported},
+	{ErrBodyTooLarge, CodeBodyTooLarge},
+	{ErrBinaryUnsupported, CodeUnsupported},
+	{ErrUnknownWorkspace, CodeUnknownWorkspace},
+	{ErrToolNotPermitted, CodeForbidden},
+	{ErrQuotaExceeded, CodeQuotaExceeded},
+	{ErrKeyBusy, CodeClientBusy},
+	{ErrWorkspaceUnsupported, CodeInvalidRequest},
+	{ErrLongLineUnsupported, CodeUnsupported},
+	{ErrCancelled, CodeCancelled},
+	{ErrKilledByAdmin, CodeKilled},
+	{ErrForceKilled, CodeShutdown},
+	{ErrNotDaemon, CodeNotDaemon},
+	{ErrSignatureInvalid, CodeSignatureInvalid},
+	{ErrSignatureExpired, CodeSignatureExpired},
+	{ErrSignatureUsed, CodeSignatureUsed},
+	{ErrTooManyGrants, CodeGatewayBusy},
+	{ErrRecordingNotFound, CodeNotFound},
+	{runner.ErrUnknownTool, CodeUnknownTool},
+	{ErrUnknownFederatedTool, CodeUnknownTool},
+	{runner.ErrSessionRequired, CodeSessionRequired},
+	{runner.ErrSessionNotFound, CodeSessionNotFound},
+	{runner.ErrSessionBusy, CodeSessionBusy},
+	{runner.ErrSessionLimit, CodeSessionLimit},
+	{runner.ErrSessionMismatch, CodeSessionMismatch},
+	{context.DeadlineExceeded, CodeTimeout},
+	{context.Canceled, CodeCancelled},
+}
+
+// APIError é o modelo de erro entregue aos clientes (corpo JSON do HTTP, data do
+// event: error no SSE/NDJSON e no stdio).
+type APIError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+	RequestID string `json:"request_id,omitempty"`
+	Details   any    `json:"details,omitempty"` // ex: violações do input_schema
+}
+
+func (e *APIError) Error() string { return e.Code + ": " + e.Message }
+
+// NewAPIError monta um erro com code e message explícitos (validações do transport).
+func NewAPIError(code, message, requestID string) *APIError {
+	return &APIError{Code: code, Message: message, Retryable: retryableCodes[code], RequestID: requestID}
+}
+
+// ErrorFor classifica err (de StreamTool, sessões, URLs assinadas...) no modelo de erro.
+// Erros sem sentinela conhecida viram tool_failed (ex: exit code != 0, pipe quebrado).
+func ErrorFor(err error, requestID string) *APIError {
+	var ae *APIError
+	if errors.As(err, &ae) {
+		out := *ae
+		if out.RequestID == "" {
+			out.RequestID = requestID
+		}
+		return &out
+	}
+	out := NewAPIError(ErrorCode(err), err.Error(), requestID)
+	// erros com detalhes estruturados (violações de schema): message = sentinela + details
+	var d interface {
+		error
+		ErrorDetails() any
+	}
+	if errors.As(err, &d) {
+		out.Details = d.ErrorDetails()
+		if u := errors.Unwrap(d); u != nil {
+			out.Message = u.Error()
+		}
+	}
+	return out
+}
+
+// ErrorCode devolve só o código de err (ver ErrorFor).
+func ErrorCode(err error) string {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return CodeToolFailed
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"mcp-router/internal/runner"
+)
+
+func TestErrorFor_Codes(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		code      string
+		retryable bool
+	}{
+		{ErrToolBusy, CodeToolBusy, true},
+		{fmt.Errorf("%w: no output within 1s", ErrToolStartupTimeout), CodeStartupTimeout, true},
+		{fmt.Errorf("%w: exec: not found", ErrSpawnFailed), CodeSpawnFailed, true},
+		{context.DeadlineExceeded, CodeTimeout, false},
+		{fmt.Errorf("%w: nope", runner.ErrUnknownTool), CodeUnknownTool, false},
+		{runner.ErrSessionBusy, CodeSessionBusy, true},
+		{errors.New("exit status 1"), CodeToolFailed, false},
+	} {
+		got := ErrorFor(tc.err, "rid-1")
+		if got.Code != tc.code || got.Retryable != tc.retryable || got.Message != tc.err.Error() || got.RequestID != "rid-1" {
+			t.Errorf("ErrorFor(%v) = %+v, want code=%s retryable=%v", tc.err, got, tc.code, tc.retryable)
+		}
+	}
+
+	// APIError já classificado passa adiante (sem reclassificar como tool_failed)
+	wrapped := fmt.Errorf("wrap: %w", NewAPIError(CodeNotInteractive, "no", ""))
+	if got := ErrorFor(wrapped, "rid-2"); got.Code != CodeNotInteractive || got.RequestID != "rid-2" {
+		t.Errorf("ErrorFor(wrapped APIError) = %+v", got)
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"mcp-router/internal/observability/events"
+	"mcp-router/internal/observability/logging"
+)
+
+// eventsCloseTimeout é quanto o Close espera as filas dos sinks esvaziarem.
+const eventsCloseTimeout = 5 * time.Second
+
+// WithEventSink liga um sink próprio às execuções de uma tool, além dos de tools[*].events.
+func WithEventSink(tool string, sink events.Sink) Option {
+	return func(s *Service) { s.sinks[tool] = append(s.sinks[tool], sink) }
+}
+
+// newEventSinks cria os sinks configurados em tools[*].events.
+func newEventSinks(s *Service) {
+	for name, t := range s.cfg.Tools {
+		for _, ec := range t.Events {
+			sink, err := events.New(name, ec)
+			if err != nil {
+				slog.Default().Warn("event sink disabled", logging.Tool(name), slog.String("sink", ec.Type), logging.Err(err))
+				continue
+			}
+			s.sinks[name] = append(s.sinks[name], sink)
+		}
+	}
+}
+
+// closeEventSinks entrega o que ainda está nas filas dos sinks (até eventsCloseTimeout).
+func (s *Service) closeEventSinks() {
+	ctx, cancel := context.WithTimeout(context.Background(), eventsCloseTimeout)
+	defer cancel()
+	for _, list := range s.sinks {
+		for _, sink := range list {
+			if c, ok := sink.(interface{ Close(context.Context) }); ok {
+				c.Close(ctx)
+			}
+		}
+	}
+}
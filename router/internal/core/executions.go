@@ -0,0 +1,204 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mcp-router/internal/observability/events"
+	"mcp-router/internal/runner"
+)
+
+// ErrForceKilled é a causa de cancelamento de execuções mortas no shutdown.
+var ErrForceKilled = errors.New("execution force-killed on shutdown")
+
+// ErrCancelled é a causa quando o cliente cancela explicitamente a execução
+// (DELETE /mcp/requests/<id> ou {"cancel":"<id>"} no stdio).
+var ErrCancelled = errors.New("execution cancelled by client")
+
+// ErrKilledByAdmin é a causa quando um admin mata a execução (DELETE /admin/executions/<id>).
+var ErrKilledByAdmin = errors.New("execution killed by admin")
+
+// execution é uma execução de tool em andamento (registro interno).
+type execution struct {
+	requestID string
+	tool      string
+	runtime   string
+	client    string
+	owner     string // Identity de quem iniciou: só ele (ou um admin) cancela
+	startedAt time.Time
+
+	proc  runner.Info  // preenchido após o spawn (protegido pelo mu do registry)
+	bytes atomic.Int64 // bytes de stdout entregues ao cliente
+
+	redactions atomic.Int64 // segredos redigidos da saída (redaction)
+
+	cancel context.CancelCauseFunc
+}
+
+// ExecutionInfo é o snapshot público de uma execução em andamento.
+type ExecutionInfo struct {
+	ID            uint64    `json:"id"`
+	RequestID     string    `json:"request_id"`
+	Tool          string    `json:"tool"`
+	Runtime       string    `json:"runtime"`
+	Client        string    `json:"client,omitempty"`
+	PID           int       `json:"pid,omitempty"`
+	ContainerID   string    `json:"container_id,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	AgeMs         int64     `json:"age_ms"`
+	BytesStreamed int64     `json:"bytes_streamed"`
+	Redactions    int64     `json:"redactions,omitempty"`
+}
+
+// executionRegistry rastreia as execuções em andamento (para inspeção e shutdown).
+type executionRegistry struct {
+	mu     sync.Mutex
+	nextID uint64
+	items  map[uint64]*execution
+}
+
+func newExecutionRegistry() *executionRegistry {
+	return &executionRegistry{items: make(map[uint64]*execution)}
+}
+
+// register adiciona a execução e retorna um ctx cancelável por ela + função de remoção.
+func (r *executionRegistry) register(ctx context.Context, e *execution) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	e.cancel = cancel
+	if e.startedAt.IsZero() {
+		e.startedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	r.nextID++
+	id := r.nextID
+	r.items[id] = e
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.items, id)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// snapshot retorna as execuções em andamento, mais antigas primeiro.
+func (r *executionRegistry) snapshot() []ExecutionInfo {
+	now := time.Now()
+
+	r.mu.Lock()
+	out := make([]ExecutionInfo, 0, len(r.items))
+	for id, e := range r.items {
+		out = append(out, ExecutionInfo{
+			ID:            id,
+			RequestID:     e.requestID,
+			Tool:          e.tool,
+			Runtime:       e.runtime,
+			Client:        e.client,
+			PID:           e.proc.PID,
+			ContainerID:   e.proc.ContainerID,
+			StartedAt:     e.startedAt,
+			AgeMs:         now.Sub(e.startedAt).Milliseconds(),
+			BytesStreamed: e.bytes.Load(),
+			Redactions:    e.redactions.Load(),
+		})
+	}
+	r.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out
+}
+
+// attach associa o processo spawnado à execução (pid/container), quando disponível.
+func (r *executionRegistry) attach(e *execution, p runner.Process) {
+	i, ok := p.(runner.Inspector)
+	if !ok {
+		return
+	}
+	info := i.Info()
+	r.mu.Lock()
+	e.proc = info
+	r.mu.Unlock()
+}
+
+// cancelAll cancela todas as execuções com a causa informada e retorna o snapshot delas.
+func (r *executionRegistry) cancelAll(cause error) []ExecutionInfo {
+	snap := r.snapshot()
+
+	r.mu.Lock()
+	for _, e := range r.items {
+		e.cancel(cause)
+	}
+	r.mu.Unlock()
+
+	return snap
+}
+
+// cancelRequest cancela as execuções com o request_id informado. Com owner != "", só
+// cancela execuções iniciadas pela mesma identidade. Retorna quantas foram canceladas.
+func (r *executionRegistry) cancelRequest(requestID, owner string, cause error) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, e := range r.items {
+		if e.requestID == requestID && (owner == "" || e.owner == owner) {
+			e.cancel(cause)
+			n++
+		}
+	}
+	return n
+}
+
+// cancelID cancela uma execução pelo id do registry.
+func (r *executionRegistry) cancelID(id uint64, cause error) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.items[id]
+	if ok {
+		e.cancel(cause)
+	}
+	return ok
+}
+
+// KillExecution mata uma execução pelo id do registry (ver Executions). Retorna false se não existir.
+func (s *Service) KillExecution(id uint64) bool {
+	var info ExecutionInfo
+	for _, e := range s.execs.snapshot() {
+		if e.ID == id {
+			info = e
+		}
+	}
+	if !s.execs.cancelID(id, ErrKilledByAdmin) {
+		return false
+	}
+	publishKilled(info, ErrKilledByAdmin)
+	return true
+}
+
+// publishKilled avisa o bus do gateway que a execução foi morta (admin ou shutdown).
+func publishKilled(e ExecutionInfo, cause error) {
+	events.Default.Publish(events.Event{Type: events.TypeProcessKilled, Tool: e.Tool, RequestID: e.RequestID,
+		Client: e.Client, DurationMS: e.AgeMs, Code: ErrorCode(cause), Error: cause.Error()})
+}
+
+// CancelRequest cancela a execução em andamento do request_id (mata o processo via ctx).
+// owner restringe o cancelamento a execuções da mesma identidade ("" = qualquer, uso admin).
+// Retorna false se nenhuma execução correspondente estiver rodando.
+func (s *Service) CancelRequest(requestID, owner string) bool {
+	if requestID == "" {
+		return false
+	}
+	return s.execs.cancelRequest(requestID, owner, ErrCancelled) > 0
+}
+
+// Executions retorna um snapshot das execuções em andamento.
+func (s *Service) Executions() []ExecutionInfo {
+	return s.execs.snapshot()
+}
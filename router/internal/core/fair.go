@@ -0,0 +1,232 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mcp-router/internal/observability/metrics"
+)
+
+// maxToolQueue limita quantos chamadores podem esperar por slot numa mesma tool.
+const maxToolQueue = 256
+
+// Sem label de identidade: o valor vem do cliente (IP, key) e cada um viraria uma série
+// nova. Quem foi rejeitado fica identificado no log e no evento tool_busy.
+var (
+	toolQueueWaits = metrics.Default.CounterVec("mcp_gw_tool_queue_waits_total",
+		"Callers that waited in a tool queue for a concurrency slot.", "tool")
+	toolQueueWaitMs = metrics.Default.CounterVec("mcp_gw_tool_queue_wait_ms_total",
+		"Total milliseconds callers waited in a tool queue (divide by waits for the average).", "tool")
+	toolQueueRejected = metrics.Default.CounterVec("mcp_gw_tool_queue_rejected_total",
+		"Callers rejected as busy (no slot within queue_timeout_ms or queue full).", "tool")
+	toolSlotsInUse = metrics.Default.GaugeVec("mcp_gw_tool_slots_in_use",
+		"Concurrency slots of the tool currently held by executions.", "tool")
+	toolSlotsCapacity = metrics.Default.GaugeVec("mcp_gw_tool_slots_capacity",
+		"Concurrency slots of the tool (max_concurrent).", "tool")
+	toolQueueLength = metrics.Default.GaugeVec("mcp_gw_tool_queue_length",
+		"Callers currently waiting in the tool queue.", "tool")
+	toolQueueWaitSeconds = metrics.Default.HistogramVec("mcp_gw_tool_queue_wait_seconds",
+		"Time to get a concurrency slot (0 when one was free), rejections after waiting included.",
+		[]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}, "tool")
+)
+
+// SlotInfo é o estado do limite de concorrência de uma tool (GET /admin/executions).
+type SlotInfo struct {
+	Tool        string `json:"tool"`
+	Capacity    int    `json:"capacity"`
+	InUse       int    `json:"in_use"`
+	Queued      int    `json:"queued"`
+	Waits       int64  `json:"waits"` // chamadas que esperaram na fila (desde o start)
+	WaitMsTotal int64  `json:"wait_ms_total"`
+	WaitMsMax   int64  `json:"wait_ms_max"`
+}
+
+// fairLimiter é o limite de concorrência por tool com fila justa entre identidades.
+//
+// Sem queue_timeout_ms, é fail-fast (ErrToolBusy) como antes. Com fila, quando um slot
+// libera ele vai para a próxima identidade em round-robin (FIFO dentro da identidade),
+// então um cliente agressivo com muitas chamadas enfileiradas não atrasa os demais.
+type fairLimiter struct {
+	tool     string
+	capacity int
+
+	mu     sync.Mutex
+	inUse  int
+	queued int
+	queues map[string][]*slotWaiter // identidade -> FIFO
+	order  []string                 // identidades com espera (anel round-robin)
+	next   int
+
+	// contabilidade da espera (SlotInfo)
+	waits     int64
+	waitTotal time.Duration
+	waitMax   time.Duration
+
+	inUseGauge, queueGauge *metrics.Gauge
+	waitHist               *metrics.Histogram
+}
+
+type slotWaiter struct {
+	ready   chan struct{}
+	granted bool // protegido por fairLimiter.mu
+}
+
+func newFairLimiter(tool string, capacity int) *fairLimiter {
+	toolSlotsCapacity.With(tool).Set(int64(capacity))
+	return &fairLimiter{
+		tool:       tool,
+		capacity:   capacity,
+		queues:     make(map[string][]*slotWaiter),
+		inUseGauge: toolSlotsInUse.With(tool),
+		queueGauge: toolQueueLength.With(tool),
+		waitHist:   toolQueueWaitSeconds.With(tool),
+	}
+}
+
+// info é o snapshot do limite para o admin.
+func (l *fairLimiter) info() SlotInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return SlotInfo{
+		Tool:        l.tool,
+		Capacity:    l.capacity,
+		InUse:       l.inUse,
+		Queued:      l.queued,
+		Waits:       l.waits,
+		WaitMsTotal: l.waitTotal.Milliseconds(),
+		WaitMsMax:   l.waitMax.Milliseconds(),
+	}
+}
+
+// publishLocked atualiza os gauges de slots e fila.
+func (l *fairLimiter) publishLocked() {
+	l.inUseGauge.Set(int64(l.inUse))
+	l.queueGauge.Set(int64(l.queued))
+}
+
+// acquire obtém um slot para identity, esperando até maxWait (0 = fail-fast).
+func (l *fairLimiter) acquire(ctx context.Context, identity string, maxWait time.Duration) error {
+	if identity == "" {
+		identity = "local"
+	}
+
+	l.mu.Lock()
+	if l.inUse < l.capacity && l.queued == 0 {
+		l.inUse++
+		l.publishLocked()
+		l.mu.Unlock()
+		l.waitHist.Observe(0)
+		return nil
+	}
+	if maxWait <= 0 || l.queued >= maxToolQueue {
+		l.mu.Unlock()
+		toolQueueRejected.With(l.tool).Inc()
+		return ErrToolBusy
+	}
+
+	w := &slotWaiter{ready: make(chan struct{})}
+	if len(l.queues[identity]) == 0 {
+		l.order = append(l.order, identity)
+	}
+	l.queues[identity] = append(l.queues[identity], w)
+	l.queued++
+	l.publishLocked()
+	l.mu.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-w.ready:
+	case <-timer.C:
+		err = ErrToolBusy
+	case <-ctx.Done():
+		err = ctxErr(ctx)
+	}
+
+	waited := time.Since(start)
+	l.mu.Lock()
+	if err != nil {
+		if w.granted {
+			// slot chegou junto com o timeout: fica com ele
+			err = nil
+		} else {
+			l.removeLocked(identity, w)
+		}
+	}
+	l.waits++
+	l.waitTotal += waited
+	l.waitMax = max(l.waitMax, waited)
+	l.mu.Unlock()
+
+	l.waitHist.Observe(waited.Seconds())
+	toolQueueWaits.With(l.tool).Inc()
+	toolQueueWaitMs.With(l.tool).Add(waited.Milliseconds())
+	if err == ErrToolBusy {
+		toolQueueRejected.With(l.tool).Inc()
+	}
+	return err
+}
+
+// release devolve o slot; se houver espera, transfere direto para a próxima identidade.
+func (l *fairLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer l.publishLocked()
+
+	if l.queued == 0 {
+		if l.inUse > 0 {
+			l.inUse--
+		}
+		return
+	}
+
+	if l.next >= len(l.order) {
+		l.next = 0
+	}
+	identity := l.order[l.next]
+	q := l.queues[identity]
+	w := q[0]
+	l.queues[identity] = q[1:]
+	l.queued--
+
+	if len(l.queues[identity]) == 0 {
+		delete(l.queues, identity)
+		l.order = append(l.order[:l.next], l.order[l.next+1:]...)
+	} else {
+		l.next++
+	}
+
+	w.granted = true
+	close(w.ready)
+}
+
+// removeLocked tira um waiter que desistiu (timeout/cancelamento) da fila.
+func (l *fairLimiter) removeLocked(identity string, w *slotWaiter) {
+	q := l.queues[identity]
+	for i, x := range q {
+		if x != w {
+			continue
+		}
+		l.queues[identity] = append(q[:i], q[i+1:]...)
+		l.queued--
+		l.publishLocked()
+		break
+	}
+	if len(l.queues[identity]) > 0 {
+		return
+	}
+	delete(l.queues, identity)
+	for i, id := range l.order {
+		if id == identity {
+			l.order = append(l.order[:i], l.order[i+1:]...)
+			if l.next > i {
+				l.next--
+			}
+			break
+		}
+	}
+}
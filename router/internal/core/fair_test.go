@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func waitQueued(t *testing.T, l *fairLimiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		l.mu.Lock()
+		q := l.queued
+		l.mu.Unlock()
+		if q == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued, got %d", n, q)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFairLimiter_RoundRobinAcrossIdentities(t *testing.T) {
+	l := newFairLimiter("t", 1)
+	ctx := context.Background()
+
+	if err := l.acquire(ctx, "holder", 0); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	enqueue := func(identity, label string, queuedAfter int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.acquire(ctx, identity, 5*time.Second); err != nil {
+				t.Errorf("%s: %v", label, err)
+				return
+			}
+			mu.Lock()
+			order = append(order, label)
+			mu.Unlock()
+			l.release()
+		}()
+		waitQueued(t, l, queuedAfter)
+	}
+
+	// cliente agressivo enfileira 3 antes do outro cliente chegar
+	enqueue("aggressive", "a1", 1)
+	enqueue("aggressive", "a2", 2)
+	enqueue("aggressive", "a3", 3)
+	enqueue("polite", "b1", 4)
+
+	l.release()
+	wg.Wait()
+
+	want := []string{"a1", "b1", "a2", "a3"}
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("grant order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestFairLimiter_FailFastAndQueueTimeout(t *testing.T) {
+	l := newFairLimiter("t", 1)
+	ctx := context.Background()
+
+	_ = l.acquire(ctx, "a", 0)
+	if err := l.acquire(ctx, "b", 0); !errors.Is(err, ErrToolBusy) {
+		t.Fatalf("expected fail-fast ErrToolBusy, got %v", err)
+	}
+	if err := l.acquire(ctx, "b", 20*time.Millisecond); !errors.Is(err, ErrToolBusy) {
+		t.Fatalf("expected ErrToolBusy after queue timeout, got %v", err)
+	}
+
+	l.mu.Lock()
+	queued, order := l.queued, len(l.order)
+	l.mu.Unlock()
+	if queued != 0 || order != 0 {
+		t.Fatalf("timed-out waiter must leave the queue (queued=%d order=%d)", queued, order)
+	}
+
+	l.release()
+	if err := l.acquire(ctx, "b", 0); err != nil {
+		t.Fatalf("slot must be free after release: %v", err)
+	}
+}
+
+func TestFairLimiter_SlotAccounting(t *testing.T) {
+	l := newFairLimiter("acct", 1)
+	ctx := context.Background()
+
+	if err := l.acquire(ctx, "a", 0); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- l.acquire(ctx, "b", 5*time.Second) }()
+	waitQueued(t, l, 1)
+
+	if got := l.info(); got.InUse != 1 || got.Queued != 1 || got.Capacity != 1 || got.Waits != 0 {
+		t.Fatalf("while queued: %+v", got)
+	}
+	if v := toolSlotsInUse.With("acct").Value(); v != 1 {
+		t.Fatalf("in-use gauge = %d, want 1", v)
+	}
+	if v := toolQueueLength.With("acct").Value(); v != 1 {
+		t.Fatalf("queue gauge = %d, want 1", v)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	l.release()
+	if err := <-done; err != nil {
+		t.Fatalf("queued acquire: %v", err)
+	}
+	l.release()
+
+	got := l.info()
+	if got.InUse != 0 || got.Queued != 0 || got.Waits != 1 || got.WaitMsMax < 30 || got.WaitMsTotal != got.WaitMsMax {
+		t.Fatalf("after release: %+v", got)
+	}
+	if v := toolSlotsInUse.With("acct").Value(); v != 0 {
+		t.Fatalf("in-use gauge = %d, want 0", v)
+	}
+}
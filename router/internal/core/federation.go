@@ -0,0 +1,251 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"mcp-router/internal/observability/logging"
+)
+
+// Federação: tools marcadas com `federate: true` são servidores MCP completos (JSON-RPC).
+// O gateway agrega os tools/list de cada filho com prefixo de namespace
+// (ex: "git.status", "fs.read_file") e roteia tools/call para o filho correto.
+//
+// Cada chamada é uma sessão curta (launcher): initialize -> initialized -> request -> fecha.
+
+// FederationSeparator separa namespace e nome da tool do filho.
+const FederationSeparator = "."
+
+// MCPProtocolVersion é a versão do protocolo anunciada aos filhos no initialize.
+const MCPProtocolVersion = "2024-11-05"
+
+// federationExitGrace é quanto esperamos o filho sair sozinho após o EOF no stdin.
+const federationExitGrace = 500 * time.Millisecond
+
+// ErrUnknownFederatedTool é retornado quando o nome não pertence a nenhum namespace.
+var ErrUnknownFederatedTool = fmt.Errorf("unknown federated tool")
+
+// FederatedTool é uma entrada do tools/list agregado (formato MCP).
+type FederatedTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// RPCError é o erro JSON-RPC devolvido por um filho.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string { return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message) }
+
+// federatedMembers retorna namespace -> nome da tool no config (ordenado por namespace).
+func (s *Service) federatedMembers() map[string]string {
+	out := make(map[string]string)
+	for name, t := range s.cfg.Tools {
+		if t.Federate {
+			out[t.NamespaceEffective(name)] = name
+		}
+	}
+	return out
+}
+
+// FederatedListTools agrega tools/list de todos os filhos federados.
+// Filhos com falha são omitidos (logados) para não derrubar o catálogo inteiro.
+func (s *Service) FederatedListTools(ctx context.Context) ([]FederatedTool, error) {
+	log := logging.LoggerFromContext(ctx)
+
+	members := s.federatedMembers()
+	p := PrincipalFromContext(ctx)
+	namespaces := make([]string, 0, len(members))
+	for ns, toolName := range members {
+		// com credencial, só os filhos do escopo dela
+		if p != nil && !p.Permits(toolName, s.cfg.Tools[toolName].Tags) {
+			continue
+		}
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	var out []FederatedTool
+	for _, ns := range namespaces {
+		toolName := members[ns]
+		raw, err := s.mcpCall(ctx, toolName, "tools/list", map[string]any{})
+		if err != nil {
+			log.Warn("federated tools/list failed", logging.Tool(toolName), logging.Err(err))
+			continue
+		}
+
+		var res struct {
+			Tools []FederatedTool `json:"tools"`
+		}
+		if err := json.Unmarshal(raw, &res); err != nil {
+			log.Warn("federated tools/list invalid result", logging.Tool(toolName), logging.Err(err))
+			continue
+		}
+		for _, t := range res.Tools {
+			t.Name = ns + FederationSeparator + t.Name
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// FederatedCallTool roteia "ns.tool" para o filho do namespace e devolve o result do tools/call.
+func (s *Service) FederatedCallTool(ctx context.Context, name string, arguments json.RawMessage) (json.RawMessage, error) {
+	ns, child, ok := strings.Cut(name, FederationSeparator)
+	if !ok || child == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFederatedTool, name)
+	}
+	toolName, ok := s.federatedMembers()[ns]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFederatedTool, name)
+	}
+	releaseKey, err := s.authorize(ctx, toolName, s.cfg.Tools[toolName])
+	if err != nil {
+		return nil, err
+	}
+	defer releaseKey()
+
+	if len(arguments) == 0 {
+		arguments = json.RawMessage(`{}`)
+	}
+	return s.mcpCall(ctx, toolName, "tools/call", map[string]any{
+		"name":      child,
+		"arguments": arguments,
+	})
+}
+
+// mcpCall abre uma sessão MCP curta com o filho e executa um único método.
+// Respeita os mesmos invariantes do StreamTool: semáforo, timeout e kill no cancelamento.
+func (s *Service) mcpCall(ctx context.Context, toolName, method string, params any) (json.RawMessage, error) {
+	tool, err := s.r.MustGetTool(toolName)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquireSlot(ctx, toolName, tool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	exec := &execution{
+		requestID: logging.RequestIDFromContext(ctx),
+		tool:      toolName,
+		runtime:   tool.Runtime,
+		client:    logging.ClientFromContext(ctx),
+		owner:     Identity(ctx),
+	}
+	ctx, unregister := s.execs.register(ctx, exec)
+	defer unregister()
+
+	tctx, cancel := context.WithTimeout(ctx, toolTimeout(ctx, tool))
+	defer cancel()
+
+	p, err := s.r.Start(tctx, toolName, tool)
+	if err != nil {
+		return nil, err
+	}
+	s.execs.attach(exec, p)
+	go func() {
+		<-tctx.Done()
+		_ = p.Close()
+	}()
+	defer func() { _ = p.Close() }()
+
+	stdin := p.Stdin()
+	enc := json.NewEncoder(stdin)
+	msgs := []any{
+		map[string]any{
+			"jsonrpc": "2.0", "id": 1, "method": "initialize",
+			"params": map[string]any{
+				"protocolVersion": MCPProtocolVersion,
+				"capabilities":    map[string]any{},
+				"clientInfo":      map[string]any{"name": "mcp-gw", "version": "federation"},
+			},
+		},
+		map[string]any{"jsonrpc": "2.0", "method": "notifications/initialized"},
+		map[string]any{"jsonrpc": "2.0", "id": 2, "method": method, "params": params},
+	}
+	for _, m := range msgs {
+		if err := enc.Encode(m); err != nil {
+			return nil, fmt.Errorf("write stdin: %w", err)
+		}
+	}
+
+	// stdin fica aberto até a resposta chegar (servidores MCP costumam sair no EOF)
+	res, err := readRPCResult(p.Stdout(), 2)
+	_ = stdin.Close()
+	if err != nil {
+		if tctx.Err() != nil {
+			return nil, ctxErr(tctx)
+		}
+		return nil, err
+	}
+
+	// Dá ao filho uma janela curta para sair pelo EOF antes do kill (evita SIGTERM desnecessário).
+	exited := make(chan struct{})
+	go func() {
+		_ = p.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(federationExitGrace):
+	}
+
+	logging.LoggerFromContext(ctx).Debug("federated call completed",
+		logging.Tool(toolName),
+		slog.String("method", method),
+	)
+	return res, nil
+}
+
+// readRPCResult lê linhas JSON-RPC até encontrar a resposta com o id informado.
+// Notificações e respostas de outros ids são ignoradas.
+func readRPCResult(r io.Reader, id int) (json.RawMessage, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	for sc.Scan() {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *RPCError       `json:"error"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			continue // ruído no stdout
+		}
+		if string(msg.ID) != fmt.Sprint(id) {
+			continue
+		}
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+		return msg.Result, nil
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read stdout: %w", err)
+	}
+	return nil, fmt.Errorf("child exited without response to request %d", id)
+}
+
+// FederationEnabled indica se alguma tool participa da federação.
+func (s *Service) FederationEnabled() bool {
+	for _, t := range s.cfg.Tools {
+		if t.Federate {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+// Requests assinadas (auth.hmac): o cliente manda
+//
+//	X-MCP-Signature: client=<nome>,ts=<unix segundos>,sig=<hex>
+//
+// com sig = HMAC-SHA256(segredo, METHOD "\n" path[?query] "\n" ts "\n" hex(sha256(body))).
+// O timestamp precisa estar dentro de auth.hmac.window_ms do relógio do gateway e cada
+// assinatura vale uma vez dentro da janela.
+
+// ErrBadSignature é a request assinada recusada (formato, cliente, assinatura, janela ou
+// replay); a causa vai na mensagem, para o log.
+var ErrBadSignature = errors.New("invalid request signature")
+
+// PrincipalHMAC é o cliente autenticado por request assinada.
+const PrincipalHMAC = "hmac"
+
+// replayCache guarda as assinaturas já aceitas até saírem da janela.
+type replayCache struct {
+	mu     sync.Mutex
+	seen   map[string]time.Time // sig -> expira em
+	purged time.Time
+}
+
+// SignatureBase é a string assinada de uma request.
+func SignatureBase(method, path string, ts int64, body []byte) string {
+	sum := sha256.Sum256(body)
+	return method + "\n" + path + "\n" + strconv.FormatInt(ts, 10) + "\n" + hex.EncodeToString(sum[:])
+}
+
+// Sign calcula o valor de X-MCP-Signature (usado pelos clientes e pelos testes).
+func Sign(client, secret, method, path string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(SignatureBase(method, path, ts, body)))
+	return fmt.Sprintf("client=%s,ts=%d,sig=%s", client, ts, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// AuthenticateSignature confere o X-MCP-Signature de uma request (path com a query
+// crua) e resolve o Principal do cliente.
+func (s *Service) AuthenticateSignature(header, method, path string, body []byte) (*Principal, error) {
+	fields := make(map[string]string, 3)
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		fields[k] = v
+	}
+	c, ok := s.cfg.Auth.HMAC.Client(fields["client"])
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown client %q", ErrBadSignature, fields["client"])
+	}
+	secret := c.Secret()
+	if secret == "" {
+		return nil, fmt.Errorf("%w: client %s has no secret configured", ErrBadSignature, c.Name)
+	}
+	ts, err := strconv.ParseInt(fields["ts"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad timestamp", ErrBadSignature)
+	}
+	got, err := hex.DecodeString(fields["sig"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrBadSignature)
+	}
+
+	window := s.cfg.Auth.HMAC.Window()
+	now := time.Now()
+	if d := now.Sub(time.Unix(ts, 0)); d > window || d < -window {
+		return nil, fmt.Errorf("%w: timestamp outside the %s window", ErrBadSignature, window)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(SignatureBase(method, path, ts, body)))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return nil, fmt.Errorf("%w: signature mismatch for client %s", ErrBadSignature, c.Name)
+	}
+	// vence quando o timestamp sai da janela: depois disso a própria janela recusa. A chave
+	// é a forma canônica (hex minúsculo): o hex aceita maiúsculas, e trocar a caixa da
+	// assinatura não pode virar uma request nova
+	if !s.replays.add(hex.EncodeToString(got), time.Unix(ts, 0).Add(window), now) {
+		return nil, fmt.Errorf("%w: replayed signature from client %s", ErrBadSignature, c.Name)
+	}
+	return &Principal{Kind: PrincipalHMAC, Name: c.Name, Tools: c.Tools, Tags: c.Tags, Roles: config.RolesEffective(c.Roles), Workspaces: c.Workspaces}, nil
+}
+
+// add registra sig; false se ela já foi usada e ainda está na janela.
+func (rc *replayCache) add(sig string, expires, now time.Time) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.seen == nil {
+		rc.seen = make(map[string]time.Time)
+	}
+	if now.Sub(rc.purged) >= time.Second {
+		for k, exp := range rc.seen {
+			if now.After(exp) {
+				delete(rc.seen, k)
+			}
+		}
+		rc.purged = now
+	}
+	if _, dup := rc.seen[sig]; dup {
+		return false
+	}
+	rc.seen[sig] = expires
+	return true
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/runtime"
+)
+
+// Estados do pré-pull de uma imagem.
+const (
+	ImagePending = "pending"
+	ImagePulling = "pulling"
+	ImageReady   = "ready"
+	ImageFailed  = "failed"
+)
+
+// ImageStatus é o estado do pré-pull de uma imagem (exposto no /readyz).
+type ImageStatus struct {
+	Image  string `json:"image"`
+	Policy string `json:"pull_policy"`
+	State  string `json:"state"`
+	Error  string `json:"error,omitempty"`
+}
+
+// imagePuller guarda o progresso do pré-pull das imagens das tools container.
+type imagePuller struct {
+	mu     sync.Mutex
+	images map[string]*ImageStatus
+}
+
+// prepullTargets junta as imagens das tools container com a policy mais forte entre elas
+// (always > if-not-present). Tools com never ficam de fora.
+func prepullTargets(cfg *config.Config) map[string]string {
+	out := make(map[string]string)
+	for _, t := range cfg.Tools {
+		if t.Runtime != "container" || t.Image == "" {
+			continue
+		}
+		policy := t.PullPolicyEffective()
+		if policy == config.PullNever {
+			continue
+		}
+		if out[t.Image] != config.PullAlways {
+			out[t.Image] = policy
+		}
+	}
+	return out
+}
+
+// PrepullImages baixa as imagens das tools container (sequencialmente, para não
+// saturar o link) para a primeira request não esperar minutos por um pull frio.
+// Bloqueia até terminar; o app chama em goroutine no startup.
+func (s *Service) PrepullImages(ctx context.Context) {
+	log := logging.LoggerFromContext(ctx)
+
+	targets := prepullTargets(s.cfg)
+	images := make([]string, 0, len(targets))
+	s.pull.mu.Lock()
+	for img, policy := range targets {
+		images = append(images, img)
+		s.pull.images[img] = &ImageStatus{Image: img, Policy: policy, State: ImagePending}
+	}
+	s.pull.mu.Unlock()
+	sort.Strings(images)
+
+	for _, img := range images {
+		s.setImageState(img, ImagePulling, "")
+		if _, err := runtime.PullImage(ctx, img, targets[img], log); err != nil {
+			s.setImageState(img, ImageFailed, err.Error())
+			continue
+		}
+		s.setImageState(img, ImageReady, "")
+	}
+}
+
+func (s *Service) setImageState(image, state, errMsg string) {
+	s.pull.mu.Lock()
+	defer s.pull.mu.Unlock()
+	if st, ok := s.pull.images[image]; ok {
+		st.State, st.Error = state, errMsg
+	}
+}
+
+// Images retorna o estado do pré-pull (ordenado por imagem; vazio se não houve pré-pull).
+func (s *Service) Images() []ImageStatus {
+	s.pull.mu.Lock()
+	defer s.pull.mu.Unlock()
+
+	out := make([]ImageStatus, 0, len(s.pull.images))
+	for _, st := range s.pull.images {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Image < out[j].Image })
+	return out
+}
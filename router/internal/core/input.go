@@ -0,0 +1,161 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/jsonschema"
+)
+
+// maxInputMessageBytes limita cada mensagem do input em stream (o mesmo teto do body HTTP).
+const maxInputMessageBytes = 1 << 20
+
+var (
+	// ErrInteractiveNotAllowed: input em stream para uma tool sem interactive: true.
+	ErrInteractiveNotAllowed = errors.New("tool does not accept streamed input")
+	// ErrInvalidInputMessage: mensagem do input em stream que não é JSON (ou grande demais).
+	ErrInvalidInputMessage = errors.New("invalid input message")
+	// ErrStreamBodyNotAllowed: body em stream para uma tool sem stream_body: true.
+	ErrStreamBodyNotAllowed = errors.New("tool does not accept streamed body")
+	// ErrBodyTooLarge: body acima do limite (max_body_bytes no stream_body).
+	ErrBodyTooLarge = errors.New("request body too large")
+)
+
+// rawBody é o input de uma tool stream_body: os bytes do body, copiados como chegam.
+type rawBody struct{ io.Reader }
+
+// forwardInput copia as mensagens seguintes do cliente (uma linha JSON cada) para o stdin
+// da tool e fecha o stdin quando o cliente encerra o stream. Mensagem inválida cancela a
+// tentativa; stdin fechado pela tool só encerra o repasse (o fluxo principal reporta).
+func forwardInput(stdin io.WriteCloser, more io.Reader, cancel context.CancelCauseFunc, log *slog.Logger) {
+	defer func() { _ = stdin.Close() }()
+
+	sc := bufio.NewScanner(more)
+	sc.Buffer(make([]byte, 0, 64*1024), maxInputMessageBytes)
+	n := 0
+	for sc.Scan() {
+		msg := bytes.TrimSpace(sc.Bytes())
+		if len(msg) == 0 {
+			continue
+		}
+		if !json.Valid(msg) {
+			cancel(fmt.Errorf("%w: message %d is not valid JSON", ErrInvalidInputMessage, n+2))
+			return
+		}
+		if _, err := stdin.Write(append(append([]byte(nil), msg...), '\n')); err != nil {
+			return
+		}
+		n++
+	}
+	if errors.Is(sc.Err(), bufio.ErrTooLong) {
+		cancel(fmt.Errorf("%w: message %d exceeds %d bytes", ErrInvalidInputMessage, n+2, maxInputMessageBytes))
+		return
+	}
+	log.Debug("input stream closed", slog.Int("messages", n))
+}
+
+// forwardBody copia o body (stream_body) para o stdin da tool e fecha o stdin no fim.
+// Falha na leitura do body (limite, cliente abortou) cancela a tentativa; stdin fechado
+// pela tool só encerra a cópia.
+func forwardBody(stdin io.WriteCloser, body io.Reader, cancel context.CancelCauseFunc, log *slog.Logger) {
+	defer func() { _ = stdin.Close() }()
+
+	src := &readErrReader{r: body}
+	n, err := io.Copy(stdin, src)
+	if src.err != nil {
+		if !errors.Is(src.err, ErrBodyTooLarge) {
+			src.err = fmt.Errorf("%w: read body: %w", ErrInvalidInput, src.err)
+		}
+		cancel(src.err)
+		return
+	}
+	if err != nil {
+		log.Debug("tool closed stdin before end of body", slog.Int64("body_bytes", n))
+		return
+	}
+	log.Debug("body stream closed", slog.Int64("body_bytes", n))
+}
+
+// readErrReader guarda o erro de leitura (≠ EOF) para separá-lo do erro de escrita do io.Copy.
+type readErrReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// compileSchemas compila os schemas de tools[*] escolhidos por pick (já conferidos pelo
+// config.Validate).
+func compileSchemas(cfg *config.Config, pick func(config.Tool) map[string]any) map[string]*jsonschema.Schema {
+	out := make(map[string]*jsonschema.Schema)
+	for name, t := range cfg.Tools {
+		raw := pick(t)
+		if raw == nil {
+			continue
+		}
+		if sc, err := jsonschema.Compile(raw); err == nil {
+			out[name] = sc
+		}
+	}
+	return out
+}
+
+// validateInput confere o input contra o input_schema da tool (sem schema: aceita tudo).
+func (s *Service) validateInput(toolName string, inputJSON []byte) error {
+	sc := s.schemas[toolName]
+	if sc == nil {
+		return nil
+	}
+	violations, err := sc.Validate(inputJSON)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+	return nil
+}
+
+// injectDeadline põe o prazo da execução (epoch Unix em ms) no campo field do input
+// (deadline_field da tool). Input que não é objeto JSON segue inalterado.
+func injectDeadline(inputJSON []byte, field string, deadline time.Time) []byte {
+	return injectRaw(inputJSON, field, json.RawMessage(strconv.FormatInt(deadline.UnixMilli(), 10)))
+}
+
+// injectField põe a string v no campo field do input (request_id_field da tool).
+func injectField(inputJSON []byte, field, v string) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return inputJSON
+	}
+	return injectRaw(inputJSON, field, b)
+}
+
+// injectRaw grava v no campo field do input. Input que não é objeto JSON segue inalterado.
+func injectRaw(inputJSON []byte, field string, v json.RawMessage) []byte {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(inputJSON, &obj) != nil || obj == nil {
+		return inputJSON
+	}
+	obj[field] = v
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return inputJSON
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOutputTruncated indica que a saída da tool excedeu max_output_bytes/max_output_lines.
+var ErrOutputTruncated = errors.New("tool output truncated")
+
+// TruncatedError traz os totais entregues até o corte (para o event: truncated).
+type TruncatedError struct {
+	Limit string `json:"limit"` // max_output_bytes | max_output_lines
+	Max   int64  `json:"max"`
+	Bytes int64  `json:"bytes"`
+	Lines int64  `json:"lines"`
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("%s: %s=%d exceeded (streamed %d bytes, %d lines)", ErrOutputTruncated, e.Limit, e.Max, e.Bytes, e.Lines)
+}
+
+func (e *TruncatedError) Unwrap() error { return ErrOutputTruncated }
+
+// outputLimiter aplica os limites de saída por tool (0 = sem limite).
+type outputLimiter struct {
+	maxBytes int64
+	maxLines int64
+
+	bytes int64
+	lines int64
+}
+
+// admit contabiliza a linha ou devolve *TruncatedError se ela ultrapassar algum limite.
+// A linha que estoura o limite não é entregue.
+func (l *outputLimiter) admit(n int) error {
+	if l.maxLines > 0 && l.lines+1 > l.maxLines {
+		return &TruncatedError{Limit: "max_output_lines", Max: l.maxLines, Bytes: l.bytes, Lines: l.lines}
+	}
+	if l.maxBytes > 0 && l.bytes+int64(n) > l.maxBytes {
+		return &TruncatedError{Limit: "max_output_bytes", Max: l.maxBytes, Bytes: l.bytes, Lines: l.lines}
+	}
+	l.lines++
+	l.bytes += int64(n)
+	return nil
+}
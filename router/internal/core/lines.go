@@ -0,0 +1,137 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"mcp-router/internal/config"
+)
+
+// ErrLongLineUnsupported é retornado quando uma linha passa de max_line_bytes e o
+// transport não sabe entregar event: continuation / line_truncated.
+var ErrLongLineUnsupported = errors.New("line exceeds max_line_bytes and transport does not support continuation events")
+
+// lineReader lê o stdout linha a linha sem limite de tamanho de linha: linhas acima de max
+// saem em pedaços (more=true em todos menos o último), cortados em fronteira de runa UTF-8.
+// Substitui o bufio.Scanner, que abortava o stream com "token too long".
+type lineReader struct {
+	r    *bufio.Reader
+	max  int
+	pend []byte
+	err  error
+}
+
+func newLineReader(r io.Reader, max int) *lineReader {
+	return &lineReader{r: bufio.NewReaderSize(r, 64*1024), max: max}
+}
+
+// Next devolve a próxima linha (sem '\n') ou o próximo pedaço de uma linha gigante.
+// O chamador sabe que um pedaço com more=false encerra a linha gigante por ter visto
+// more=true antes. Depois do último dado, devolve o erro de leitura (io.EOF no fim).
+func (l *lineReader) Next() (chunk []byte, more bool, err error) {
+	for {
+		if i := bytes.IndexByte(l.pend, '\n'); i >= 0 && i <= l.max {
+			line := append([]byte(nil), l.pend[:i]...)
+			l.pend = l.pend[i+1:]
+			return line, false, nil
+		}
+		if len(l.pend) > l.max {
+			cut := utf8Cut(l.pend, l.max)
+			part := append([]byte(nil), l.pend[:cut]...)
+			l.pend = l.pend[cut:]
+			return part, true, nil
+		}
+		if l.err != nil {
+			if len(l.pend) > 0 {
+				line := l.pend
+				l.pend = nil
+				return line, false, nil
+			}
+			return nil, false, l.err
+		}
+
+		frag, err := l.r.ReadSlice('\n')
+		l.pend = append(l.pend, frag...)
+		if err != nil && err != bufio.ErrBufferFull {
+			l.err = err
+		}
+	}
+}
+
+// utf8Cut recua o corte em até 3 bytes para não partir uma runa ao meio.
+func utf8Cut(b []byte, max int) int {
+	for cut := max; cut > 0 && cut > max-utf8.UTFMax; cut-- {
+		if utf8.RuneStart(b[cut]) {
+			return cut
+		}
+	}
+	return max
+}
+
+// ContinuationPart é um pedaço de uma linha acima de max_line_bytes (long_lines: split).
+// O cliente concatena Data dos parts até o que vier com More=false.
+type ContinuationPart struct {
+	Part int64  `json:"part"`
+	More bool   `json:"more"`
+	Data string `json:"data"`
+}
+
+// LineTruncated é o marcador de uma linha gigante cortada (long_lines: truncate):
+// só o início (até max_line_bytes) é entregue.
+type LineTruncated struct {
+	LineBytes int64  `json:"line_bytes"`
+	Head      string `json:"head"`
+}
+
+// giantLine é o estado da linha gigante em entrega (entre o 1º pedaço e o more=false).
+type giantLine struct {
+	policy string
+	part   int64
+	bytes  int64
+	head   []byte
+}
+
+// feed entrega um pedaço da linha gigante conforme a política. Com split, cada pedaço
+// conta como uma linha em max_output_lines; com truncate, só o marcador conta.
+func (g *giantLine) feed(out LineWriter, chunk []byte, more bool, limit *outputLimiter, exec *execution) error {
+	ew, ok := out.(EventWriter)
+	if !ok {
+		return ErrLongLineUnsupported
+	}
+	g.bytes += int64(len(chunk))
+
+	var event string
+	var payload any
+	switch {
+	case g.policy == config.LongLinesTruncate:
+		if g.part == 0 {
+			g.head = chunk
+		}
+		g.part++
+		if more {
+			return nil // descarta o resto da linha
+		}
+		event, payload = "line_truncated", LineTruncated{LineBytes: g.bytes, Head: string(g.head)}
+		chunk = g.head
+	default:
+		event, payload = "continuation", ContinuationPart{Part: g.part, More: more, Data: string(chunk)}
+		g.part++
+	}
+
+	if err := limit.admit(len(chunk)); err != nil {
+		return err
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if err := ew.WriteEvent(event, b); err != nil {
+		return err
+	}
+	exec.bytes.Add(int64(len(chunk)))
+	return nil
+}
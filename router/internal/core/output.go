@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/jsonschema"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/observability/metrics"
+)
+
+var outputViolations = metrics.Default.CounterVec("mcp_gw_output_violations_total",
+	"Tool output lines that did not match the tool output_schema.", "tool", "mode")
+
+// OutputWarning é o payload do event: warning de uma linha fora do output_schema
+// (output_validation: warn). A linha já foi entregue; o evento vem logo depois dela.
+type OutputWarning struct {
+	Code       string                 `json:"code"` // output_violation
+	Line       int64                  `json:"line"`
+	Violations []jsonschema.Violation `json:"violations"`
+}
+
+// outputCheck valida as linhas de uma tentativa contra o output_schema.
+type outputCheck struct {
+	tool   string
+	schema *jsonschema.Schema
+	strict bool
+	line   int64
+	warned bool // só a 1ª violação da tentativa vai para o log em Warn
+}
+
+// newOutputCheck retorna nil quando a tool não tem output_schema (check/warn viram no-op).
+func (s *Service) newOutputCheck(toolName string, tool config.Tool) *outputCheck {
+	sc := s.outSchemas[toolName]
+	if sc == nil {
+		return nil
+	}
+	return &outputCheck{tool: toolName, schema: sc, strict: tool.OutputValidationEffective() == config.OutputValidationStrict}
+}
+
+// check confere uma linha de saída. Em strict, violação vira *OutputViolationError (o
+// stream termina antes de entregar a linha); em warn, as violações voltam para warn.
+func (c *outputCheck) check(line []byte) ([]jsonschema.Violation, error) {
+	if c == nil {
+		return nil, nil
+	}
+	c.line++
+	var vs []jsonschema.Violation
+	if !json.Valid(line) {
+		vs = []jsonschema.Violation{{Message: "line is not valid JSON"}}
+	} else if vs, _ = c.schema.Validate(line); len(vs) == 0 {
+		return nil, nil
+	}
+	mode := config.OutputValidationWarn
+	if c.strict {
+		mode = config.OutputValidationStrict
+	}
+	outputViolations.With(c.tool, mode).Inc()
+	if c.strict {
+		return nil, &OutputViolationError{Line: c.line, Violations: vs}
+	}
+	return vs, nil
+}
+
+// warn registra a violação e, se o transport entrega eventos de output, anota o stream
+// com event: warning.
+func (c *outputCheck) warn(out LineWriter, vs []jsonschema.Violation, log *slog.Logger) error {
+	lvl := slog.LevelDebug
+	if !c.warned {
+		lvl, c.warned = slog.LevelWarn, true
+	}
+	log.Log(context.Background(), lvl, "tool output violates output_schema",
+		logging.Int64("line", c.line),
+		slog.String("violation", vs[0].String()),
+	)
+	ew, ok := out.(EventWriter)
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(OutputWarning{Code: CodeOutputViolation, Line: c.line, Violations: vs})
+	if err != nil {
+		return err
+	}
+	return ew.WriteEvent("warning", b)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/runtime"
+	"mcp-router/internal/sandbox"
+)
+
+// ConfigPlan é o resultado de comparar o config em execução com um proposto:
+// o diff + as execuções em andamento de tools alteradas/removidas (afetadas por um reload).
+type ConfigPlan struct {
+	config.Diff
+	Affected []ExecutionInfo `json:"affected"`
+}
+
+// PlanConfig compara o config atual com next sem aplicar nada (dry-run).
+func (s *Service) PlanConfig(next *config.Config) ConfigPlan {
+	plan := ConfigPlan{
+		Diff:     config.Compare(s.cfg, next.WithDiagnosticTools()),
+		Affected: []ExecutionInfo{},
+	}
+
+	touched := make(map[string]bool)
+	for _, t := range plan.Tools {
+		if t.Kind != config.ChangeAdded {
+			touched[t.Name] = true
+		}
+	}
+	for _, e := range s.Executions() {
+		if touched[e.Tool] {
+			plan.Affected = append(plan.Affected, e)
+		}
+	}
+	return plan
+}
+
+// ToolPlan é o dry-run de uma chamada (POST /mcp/<tool>?dry_run=1): o processo que seria
+// criado e os limites aplicados a ele. Remote/builtin não criam processo: só o destino.
+type ToolPlan struct {
+	Tool             string             `json:"tool"`
+	Runtime          string             `json:"runtime"`
+	Mode             string             `json:"mode,omitempty"`
+	Spawn            *runtime.SpawnPlan `json:"spawn,omitempty"`
+	Endpoint         string             `json:"endpoint,omitempty"`
+	Builtin          string             `json:"builtin,omitempty"`
+	Reuse            bool               `json:"reuse,omitempty"`
+	TimeoutMS        int64              `json:"timeout_ms"`
+	StartupTimeoutMS int64              `json:"startup_timeout_ms,omitempty"`
+	ShutdownGraceMS  int64              `json:"shutdown_grace_ms"`
+	Shutdown         string             `json:"shutdown"`
+	Workspace        string             `json:"workspace,omitempty"` // ephemeral: o spawn recebe um diretório próprio
+	QueueTimeoutMS   int64              `json:"queue_timeout_ms,omitempty"`
+	MaxConcurrent    int                `json:"max_concurrent"`
+}
+
+// PlanTool resolve o ToolPlan de tool sem executar nada (também usado pelo CLI, sem Service).
+func PlanTool(ctx context.Context, cfg *config.Config, name string, tool config.Tool) (ToolPlan, error) {
+	plan := ToolPlan{
+		Tool:             name,
+		Runtime:          tool.Runtime,
+		Mode:             tool.Mode,
+		Reuse:            tool.Reuse != nil,
+		TimeoutMS:        tool.Timeout().Milliseconds(),
+		StartupTimeoutMS: tool.StartupTimeout().Milliseconds(),
+		ShutdownGraceMS:  tool.ShutdownGrace().Milliseconds(),
+		Shutdown:         tool.ShutdownEffective(),
+		Workspace:        tool.Workspace,
+		QueueTimeoutMS:   tool.QueueTimeout().Milliseconds(),
+		MaxConcurrent:    tool.MaxConc(),
+	}
+	switch tool.Runtime {
+	case "remote":
+		plan.Endpoint = tool.Endpoint
+	case "builtin":
+		plan.Builtin = tool.Builtin
+	default:
+		sp, err := runtime.Plan(ctx, cfg, tool)
+		if err != nil {
+			return ToolPlan{}, err
+		}
+		plan.Spawn = &sp
+	}
+	return plan, nil
+}
+
+// SpawnPlan retorna o dry-run da tool name no config em execução.
+func (s *Service) SpawnPlan(ctx context.Context, name string) (ToolPlan, error) {
+	if err := sandbox.ValidateToolName(name); err != nil {
+		return ToolPlan{}, fmt.Errorf("%w: %w", ErrInvalidToolName, err)
+	}
+	name = s.canonicalTool(name)
+	tool, err := s.r.MustGetTool(name)
+	if err != nil {
+		return ToolPlan{}, err
+	}
+	return PlanTool(ctx, s.cfg, name, tool)
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/metrics"
+)
+
+// ErrGatewayBusy é retornado quando limits.max_concurrent (todas as tools) foi atingido.
+var ErrGatewayBusy = errors.New("gateway is busy")
+
+// ErrClientBusy é retornado quando a identidade do cliente já ocupa
+// limits.max_concurrent_per_client execuções (somando todas as tools).
+var ErrClientBusy = errors.New("client concurrency limit reached")
+
+// maxAdmissionQueue limita quantos chamadores podem esperar pelo limite global.
+const maxAdmissionQueue = 1024
+
+// priorityClasses em ordem de preferência, com o peso de cada uma na admissão.
+var priorityClasses = []struct {
+	name   string
+	weight int
+}{
+	{config.PriorityHigh, 4},
+	{config.PriorityNormal, 2},
+	{config.PriorityLow, 1},
+}
+
+var (
+	limitRejected = metrics.Default.CounterVec("mcp_gw_limit_rejected_total",
+		"Calls rejected by the gateway-wide concurrency limits.", "scope") // identidade: no log e no evento tool_busy
+	admissionWaits = metrics.Default.CounterVec("mcp_gw_admission_waits_total",
+		"Calls that waited for a gateway-wide slot, by priority class.", "priority")
+	admissionWaitMs = metrics.Default.CounterVec("mcp_gw_admission_wait_ms_total",
+		"Total milliseconds calls waited for a gateway-wide slot (divide by waits for the average).", "priority")
+)
+
+// clientLimiter conta execuções em andamento no gateway inteiro e por identidade.
+// Fica antes do semáforo da tool. O limite por cliente é sempre fail-fast (a fila não
+// serve para um cliente que já ocupa sua cota); o global espera até maxWait numa fila
+// por classe de prioridade, e cada slot liberado vai para a classe escolhida por round
+// robin ponderado (smooth weighted round robin): high passa na frente sem matar low.
+type clientLimiter struct {
+	global    int // 0 = sem limite
+	perClient int // 0 = sem limite
+
+	mu       sync.Mutex
+	inUse    int
+	byClient map[string]int // em uso + esperando
+	queued   int
+	queues   map[string][]*slotWaiter // classe -> FIFO
+	credit   map[string]int           // estado do round robin ponderado
+}
+
+func newClientLimiter(l config.Limits) *clientLimiter {
+	return &clientLimiter{
+		global:    l.MaxConcurrent,
+		perClient: l.MaxConcurrentPerClient,
+		byClient:  make(map[string]int),
+		queues:    make(map[string][]*slotWaiter),
+		credit:    make(map[string]int),
+	}
+}
+
+// acquire reserva uma execução para identity ou falha com ErrClientBusy/ErrGatewayBusy.
+// Com o gateway cheio espera até maxWait (0 = fail-fast) na fila de priority.
+func (l *clientLimiter) acquire(ctx context.Context, identity, priority string, maxWait time.Duration) error {
+	if identity == "" {
+		identity = "local"
+	}
+	if priority == "" {
+		priority = config.PriorityNormal
+	}
+
+	l.mu.Lock()
+	if l.perClient > 0 && l.byClient[identity] >= l.perClient {
+		l.mu.Unlock()
+		limitRejected.With("client").Inc()
+		return ErrClientBusy
+	}
+	if l.global <= 0 || (l.inUse < l.global && l.queued == 0) {
+		l.inUse++
+		l.byClient[identity]++
+		l.mu.Unlock()
+		return nil
+	}
+	if maxWait <= 0 || l.queued >= maxAdmissionQueue {
+		l.mu.Unlock()
+		limitRejected.With("global").Inc()
+		return ErrGatewayBusy
+	}
+
+	// a espera conta na cota do cliente: não dá para furar o limite enfileirando
+	w := &slotWaiter{ready: make(chan struct{})}
+	l.queues[priority] = append(l.queues[priority], w)
+	l.queued++
+	l.byClient[identity]++
+	l.mu.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-w.ready:
+	case <-timer.C:
+		err = ErrGatewayBusy
+	case <-ctx.Done():
+		err = ctxErr(ctx)
+	}
+
+	if err != nil {
+		l.mu.Lock()
+		if w.granted {
+			// slot chegou junto com o timeout: fica com ele
+			err = nil
+		} else {
+			l.removeLocked(priority, w)
+			l.dropClientLocked(identity)
+		}
+		l.mu.Unlock()
+	}
+
+	admissionWaits.With(priority).Inc()
+	admissionWaitMs.With(priority).Add(time.Since(start).Milliseconds())
+	if err == ErrGatewayBusy {
+		limitRejected.With("global").Inc()
+	}
+	return err
+}
+
+// release devolve a execução; se houver espera, o slot passa direto para a próxima classe.
+func (l *clientLimiter) release(identity string) {
+	if identity == "" {
+		identity = "local"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dropClientLocked(identity)
+
+	class := l.nextClassLocked()
+	if class == "" {
+		if l.inUse > 0 {
+			l.inUse--
+		}
+		return
+	}
+
+	q := l.queues[class]
+	w := q[0]
+	l.queues[class] = q[1:]
+	l.queued--
+	if len(l.queues[class]) == 0 {
+		delete(l.queues, class)
+		delete(l.credit, class)
+	}
+	w.granted = true
+	close(w.ready)
+}
+
+// nextClassLocked escolhe a classe com espera pelo round robin ponderado ("" = ninguém).
+func (l *clientLimiter) nextClassLocked() string {
+	best, total := "", 0
+	for _, c := range priorityClasses {
+		if len(l.queues[c.name]) == 0 {
+			continue
+		}
+		l.credit[c.name] += c.weight
+		total += c.weight
+		if best == "" || l.credit[c.name] > l.credit[best] {
+			best = c.name
+		}
+	}
+	if best != "" {
+		l.credit[best] -= total
+	}
+	return best
+}
+
+func (l *clientLimiter) dropClientLocked(identity string) {
+	if n := l.byClient[identity]; n <= 1 {
+		delete(l.byClient, identity)
+	} else {
+		l.byClient[identity] = n - 1
+	}
+}
+
+// removeLocked tira um waiter que desistiu (timeout/cancelamento) da fila da classe.
+func (l *clientLimiter) removeLocked(class string, w *slotWaiter) {
+	q := l.queues[class]
+	for i, x := range q {
+		if x == w {
+			l.queues[class] = append(q[:i], q[i+1:]...)
+			l.queued--
+			break
+		}
+	}
+	if len(l.queues[class]) == 0 {
+		// classe sem espera recomeça do zero (crédito acumulado não vira rajada depois)
+		delete(l.queues, class)
+		delete(l.credit, class)
+	}
+}
+
+// IsBusy diz se err é uma rejeição por limite de concorrência (tool, cliente ou gateway).
+func IsBusy(err error) bool {
+	return errors.Is(err, ErrToolBusy) || errors.Is(err, ErrClientBusy) || errors.Is(err, ErrGatewayBusy)
+}
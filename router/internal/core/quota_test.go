@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+func TestClientLimiter_PerClientAndGlobal(t *testing.T) {
+	l := newClientLimiter(config.Limits{MaxConcurrent: 3, MaxConcurrentPerClient: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := l.acquire(ctx, "a", "", 0); err != nil {
+			t.Fatalf("acquire a #%d: %v", i, err)
+		}
+	}
+	if err := l.acquire(ctx, "a", "", 0); !errors.Is(err, ErrClientBusy) {
+		t.Fatalf("third acquire by a: expected ErrClientBusy, got %v", err)
+	}
+
+	// o limite por cliente não afeta outra identidade...
+	if err := l.acquire(ctx, "b", "", 0); err != nil {
+		t.Fatalf("acquire b: %v", err)
+	}
+	// ...mas o total do gateway sim
+	if err := l.acquire(ctx, "c", "", 0); !errors.Is(err, ErrGatewayBusy) {
+		t.Fatalf("acquire c: expected ErrGatewayBusy, got %v", err)
+	}
+
+	l.release("a")
+	if err := l.acquire(ctx, "c", "", 0); err != nil {
+		t.Fatalf("slot must be free after release: %v", err)
+	}
+	if !IsBusy(ErrClientBusy) || !IsBusy(ErrGatewayBusy) || !IsBusy(ErrToolBusy) {
+		t.Fatal("IsBusy must cover every concurrency rejection")
+	}
+}
+
+func TestClientLimiter_WeightedPriorityAdmission(t *testing.T) {
+	l := newClientLimiter(config.Limits{MaxConcurrent: 1})
+	ctx := context.Background()
+	if err := l.acquire(ctx, "holder", "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	granted := make(chan string)
+	queued := 0
+	enqueue := func(priority string, n int) {
+		for i := 0; i < n; i++ {
+			queued++
+			go func() {
+				if err := l.acquire(ctx, priority, priority, 5*time.Second); err != nil {
+					t.Errorf("acquire %s: %v", priority, err)
+					return
+				}
+				granted <- priority
+			}()
+			waitAdmissionQueued(t, l, queued)
+		}
+	}
+	// batch chega primeiro; interativas depois
+	enqueue(config.PriorityLow, 2)
+	enqueue(config.PriorityHigh, 4)
+
+	var order []string
+	holder := "holder"
+	for i := 0; i < 6; i++ {
+		l.release(holder)
+		holder = <-granted
+		order = append(order, holder)
+	}
+
+	want := []string{"high", "high", "low", "high", "high", "low"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("admission order = %v, want %v", order, want)
+		}
+	}
+}
+
+func waitAdmissionQueued(t *testing.T, l *clientLimiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		l.mu.Lock()
+		q := l.queued
+		l.mu.Unlock()
+		if q == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued, got %d", n, q)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
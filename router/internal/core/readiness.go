@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/runner"
+	"mcp-router/internal/runtime"
+)
+
+// Readiness por tool (/readyz): o que dá para checar sem executar a tool. Uma tool com
+// check em fail não derruba o gateway (as demais atendem), só marca degraded.
+
+// Resultado de um check.
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+const (
+	// imageCheckTimeout limita o `docker image inspect` de cada imagem.
+	imageCheckTimeout = 800 * time.Millisecond
+	// failureRateMinCalls é o mínimo de chamadas na janela para avaliar a taxa de erro.
+	failureRateMinCalls = 5
+	// failureRateWarn é a taxa de erro (na janela do stats) que vira warn.
+	failureRateWarn = 0.5
+)
+
+// Check é um check de readiness de uma tool.
+type Check struct {
+	Name       string `json:"name"` // binary, image, health, failure_rate
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// ToolReadiness é a readiness de uma tool: pronta se nenhum check falhou.
+type ToolReadiness struct {
+	Tool    string  `json:"tool"`
+	Runtime string  `json:"runtime"`
+	Ready   bool    `json:"ready"`
+	Checks  []Check `json:"checks"`
+}
+
+// ToolReadiness roda os checks das tools (em paralelo; ordenado por nome).
+func (s *Service) ToolReadiness(ctx context.Context) []ToolReadiness {
+	names := make([]string, 0, len(s.cfg.Tools))
+	for name := range s.cfg.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	health := make(map[string]runner.PoolHealth)
+	for _, ph := range s.ToolHealth() {
+		health[ph.Tool] = ph
+	}
+	images := make(map[string]ImageStatus)
+	for _, img := range s.Images() {
+		images[img.Image] = img
+	}
+
+	out := make([]ToolReadiness, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			t := s.cfg.Tools[name]
+			tr := ToolReadiness{Tool: name, Runtime: t.Runtime, Ready: true}
+			switch t.Runtime {
+			case "native":
+				tr.Checks = append(tr.Checks, timed("binary", func() (string, string) { return binaryCheck(t) }))
+			case "container":
+				tr.Checks = append(tr.Checks, timed("image", func() (string, string) { return imageCheck(ctx, t, images) }))
+			}
+			if ph, ok := health[name]; ok {
+				tr.Checks = append(tr.Checks, timed("health", func() (string, string) { return healthCheck(ph) }))
+			}
+			tr.Checks = append(tr.Checks, timed("failure_rate", func() (string, string) {
+				return failureRateCheck(s.stats.snapshot(name, time.Now()))
+			}))
+			for _, c := range tr.Checks {
+				if c.Status == CheckFail {
+					tr.Ready = false
+				}
+			}
+			out[i] = tr
+		}(i, name)
+	}
+	wg.Wait()
+	return out
+}
+
+func timed(name string, check func() (status, detail string)) Check {
+	start := time.Now()
+	status, detail := check()
+	return Check{Name: name, Status: status, Detail: detail, DurationMS: time.Since(start).Milliseconds()}
+}
+
+// binaryCheck confere se o cmd da tool native existe e é executável.
+func binaryCheck(t config.Tool) (string, string) {
+	path, err := exec.LookPath(t.Cmd)
+	if err != nil {
+		return CheckFail, err.Error()
+	}
+	return CheckPass, path
+}
+
+// imageCheck usa o estado do pré-pull quando há; senão pergunta ao docker.
+func imageCheck(ctx context.Context, t config.Tool, images map[string]ImageStatus) (string, string) {
+	if st, ok := images[t.Image]; ok {
+		switch st.State {
+		case ImageReady:
+			return CheckPass, t.Image
+		case ImageFailed:
+			return CheckFail, fmt.Sprintf("%s: pull failed: %s", t.Image, st.Error)
+		default:
+			return CheckFail, fmt.Sprintf("%s: %s", t.Image, st.State)
+		}
+	}
+	cctx, cancel := context.WithTimeout(ctx, imageCheckTimeout)
+	defer cancel()
+	if !runtime.ImagePresent(cctx, t.Image) {
+		if t.PullPolicyEffective() == config.PullNever {
+			return CheckFail, t.Image + ": not present (pull_policy never)"
+		}
+		// a primeira chamada baixa a imagem: funciona, mas devagar
+		return CheckWarn, t.Image + ": not present, will be pulled on first call"
+	}
+	return CheckPass, t.Image
+}
+
+// healthCheck traduz a saúde das sessões reutilizadas (tools com health).
+func healthCheck(ph runner.PoolHealth) (string, string) {
+	switch ph.State {
+	case runner.HealthUnhealthy:
+		return CheckFail, ph.LastError
+	case runner.HealthUnknown:
+		return CheckPass, "not checked yet"
+	}
+	return CheckPass, ph.State
+}
+
+// failureRateCheck avisa quando a maior parte das chamadas recentes falhou; não derruba a
+// tool (erros podem ser do input do cliente).
+func failureRateCheck(st ToolStats) (string, string) {
+	detail := fmt.Sprintf("%d/%d calls failed in the last %s", st.Errors, st.Invocations, time.Duration(st.WindowMS)*time.Millisecond)
+	if st.Invocations >= failureRateMinCalls && float64(st.Errors)/float64(st.Invocations) >= failureRateWarn {
+		return CheckWarn, detail
+	}
+	return CheckPass, detail
+}
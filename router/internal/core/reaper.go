@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/observability/metrics"
+	"mcp-router/internal/runtime"
+)
+
+var (
+	orphanContainersRemoved = metrics.Default.Counter("mcp_gw_orphan_containers_removed_total",
+		"Gateway-labelled containers removed because their owner (request or gateway process) was gone.")
+	orphanProcesses = metrics.Default.Gauge("mcp_gw_orphan_processes",
+		"Processes left behind by finished tools in the last process audit.")
+	orphanProcessesFound = metrics.Default.CounterVec("mcp_gw_orphan_processes_total",
+		"Leftover tool processes found by the process audit.", "reason")
+	orphanProcessesKilled = metrics.Default.Counter("mcp_gw_orphan_processes_killed_total",
+		"Leftover tool processes killed by the process audit (process_audit.kill).")
+)
+
+// RunContainerGC remove containers órfãos no startup e a cada orphan_gc_interval_ms até
+// ctx terminar. Sem tools container não faz nada. O app chama em goroutine.
+func (s *Service) RunContainerGC(ctx context.Context) {
+	needsDocker := false
+	for _, t := range s.cfg.Tools {
+		if t.Runtime == "container" {
+			needsDocker = true
+			break
+		}
+	}
+	if !needsDocker {
+		return
+	}
+
+	log := logging.LoggerFromContext(ctx)
+	t := time.NewTicker(s.cfg.OrphanGCInterval())
+	defer t.Stop()
+	for {
+		removed, err := runtime.ReapOrphanContainers(ctx)
+		if len(removed) > 0 {
+			orphanContainersRemoved.Add(int64(len(removed)))
+			log.Warn("removed orphaned containers", logging.Int("count", len(removed)), logging.String("containers", strings.Join(removed, ",")))
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Warn("orphan container gc failed", logging.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunProcessAudit procura, a cada process_audit.interval_ms, processos que sobreviveram
+// ao Close das tools (grupo que não morreu, netos que escaparam com setsid), loga e
+// opcionalmente mata. Bloqueia até ctx terminar; o app chama em goroutine.
+func (s *Service) RunProcessAudit(ctx context.Context) {
+	log := logging.LoggerFromContext(ctx)
+	if err := s.r.EnableSubreaper(); err != nil {
+		log.Warn("process audit: cannot become child subreaper (escaped grandchildren go to init)", logging.Err(err))
+	}
+
+	audit := s.cfg.ProcessAudit
+	t := time.NewTicker(audit.Interval())
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		leftovers, err := s.r.AuditProcesses(audit.Kill)
+		if err != nil {
+			log.Warn("process audit failed; disabling", logging.Err(err))
+			return
+		}
+		orphanProcesses.Set(int64(len(leftovers)))
+		for _, l := range leftovers {
+			orphanProcessesFound.With(l.Reason).Inc()
+			if l.Killed {
+				orphanProcessesKilled.Inc()
+			}
+			log.Warn("tool process left behind",
+				logging.Tool(l.Tool),
+				logging.Int("pid", l.PID),
+				logging.Int("pgid", l.PGID),
+				logging.String("reason", l.Reason),
+				logging.Bool("zombie", l.Zombie),
+				logging.Bool("killed", l.Killed),
+			)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"mcp-router/internal/observability/logging"
+)
+
+// Gravações (tools[*].record) ficam em recordings/<tool>/<id>.json. O id é o hash do
+// conteúdo (tool, input, saída, código do erro): a mesma chamada com o mesmo resultado
+// cai na mesma gravação.
+const (
+	recordingsPrefix = "recordings/"
+	// maxRecordingBytes limita a saída guardada por gravação (o resto é descartado).
+	maxRecordingBytes = 16 << 20
+)
+
+// ErrRecordingNotFound: id sem gravação no storage.
+var ErrRecordingNotFound = errors.New("recording not found")
+
+// RecordedEvent é uma linha (event "message") ou evento de output entregue ao cliente.
+type RecordedEvent struct {
+	Event string `json:"event"`
+	Data  string `json:"data"`
+}
+
+// Recording é uma chamada gravada: input + stream de saída completo.
+type Recording struct {
+	ID         string          `json:"id"`
+	Tool       string          `json:"tool"`
+	Input      json.RawMessage `json:"input"`
+	Output     []RecordedEvent `json:"output"`
+	Truncated  bool            `json:"truncated,omitempty"` // saída passou de maxRecordingBytes
+	Error      *APIError       `json:"error,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	DurationMS int64           `json:"duration_ms"`
+}
+
+// RecordingInfo é o item da listagem de gravações.
+type RecordingInfo struct {
+	ID   string `json:"id"`
+	Tool string `json:"tool"`
+}
+
+// recorder acumula a saída de uma execução gravada.
+type recorder struct {
+	rec   Recording
+	bytes int
+}
+
+func (r *recorder) observe(event string, data []byte) {
+	if r.rec.Truncated {
+		return
+	}
+	if r.bytes+len(data) > maxRecordingBytes {
+		r.rec.Truncated = true
+		return
+	}
+	r.bytes += len(data)
+	r.rec.Output = append(r.rec.Output, RecordedEvent{Event: event, Data: string(data)})
+}
+
+// recordingID é o hash do conteúdo determinístico da gravação.
+func recordingID(rec *Recording) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", rec.Tool, rec.Input)
+	for _, e := range rec.Output {
+		fmt.Fprintf(h, "%s\x00%d\x00%s\x00", e.Event, len(e.Data), e.Data)
+	}
+	if rec.Error != nil {
+		h.Write([]byte(rec.Error.Code))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// saveRecording persiste a gravação (best effort: falha só vira log).
+func (s *Service) saveRecording(ctx context.Context, r *recorder, start time.Time, err error) {
+	rec := &r.rec
+	rec.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		rec.Error = ErrorFor(err, "")
+	}
+	rec.ID = recordingID(rec)
+	b, merr := json.Marshal(rec)
+	if merr != nil {
+		return
+	}
+	pctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	log := logging.LoggerFromContext(ctx)
+	if perr := s.store.Put(pctx, recordingsPrefix+rec.Tool+"/"+rec.ID+".json", bytes.NewReader(b)); perr != nil {
+		log.Warn("failed to persist recording", logging.Tool(rec.Tool), logging.Err(perr))
+		return
+	}
+	log.Info("execution recorded", logging.Tool(rec.Tool), logging.String("recording_id", rec.ID))
+}
+
+// ListRecordings lista as gravações (de uma tool, se tool != "").
+func (s *Service) ListRecordings(ctx context.Context, tool string) ([]RecordingInfo, error) {
+	prefix := recordingsPrefix
+	if tool != "" {
+		prefix += tool + "/"
+	}
+	keys, err := s.store.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RecordingInfo, 0, len(keys))
+	for _, k := range keys {
+		rest := strings.TrimSuffix(strings.TrimPrefix(k, recordingsPrefix), ".json")
+		if t, id, ok := strings.Cut(rest, "/"); ok {
+			out = append(out, RecordingInfo{ID: id, Tool: t})
+		}
+	}
+	return out, nil
+}
+
+// GetRecording lê uma gravação pelo id.
+func (s *Service) GetRecording(ctx context.Context, id string) (*Recording, error) {
+	if !validRecordingID(id) {
+		return nil, fmt.Errorf("%w: %q", ErrRecordingNotFound, id)
+	}
+	keys, err := s.store.List(ctx, recordingsPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if !strings.HasSuffix(k, "/"+id+".json") {
+			continue
+		}
+		rc, err := s.store.Get(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		var rec Recording
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return nil, fmt.Errorf("decode recording: %w", err)
+		}
+		return &rec, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrRecordingNotFound, id)
+}
+
+func validRecordingID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// ReplayResult compara a saída de uma nova execução com a gravada.
+type ReplayResult struct {
+	ID             string      `json:"id"`
+	Tool           string      `json:"tool"`
+	Match          bool        `json:"match"`
+	RecordedEvents int         `json:"recorded_events"`
+	ReplayedEvents int         `json:"replayed_events"`
+	FirstDiff      *ReplayDiff `json:"first_diff,omitempty"`
+	RecordedError  string      `json:"recorded_error,omitempty"` // código do erro gravado
+	ReplayError    *APIError   `json:"replay_error,omitempty"`
+	DurationMS     int64       `json:"duration_ms"`
+}
+
+// ReplayDiff é o primeiro evento diferente (nil de um lado = stream mais curto).
+type ReplayDiff struct {
+	Index    int            `json:"index"`
+	Recorded *RecordedEvent `json:"recorded,omitempty"`
+	Replayed *RecordedEvent `json:"replayed,omitempty"`
+}
+
+// replayKey marca o ctx de um replay (a execução de replay não é gravada de novo).
+type replayKey struct{}
+
+// collectWriter guarda a saída do replay (linhas e eventos de output).
+type collectWriter struct{ rec recorder }
+
+func (c *collectWriter) WriteLine(line []byte) error {
+	c.rec.observe("message", line)
+	return nil
+}
+
+func (c *collectWriter) WriteEvent(event string, data []byte) error {
+	c.rec.observe(event, data)
+	return nil
+}
+
+// Replay executa a tool de novo com o input gravado e compara a saída.
+func (s *Service) Replay(ctx context.Context, id string) (*ReplayResult, error) {
+	rec, err := s.GetRecording(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	col := &collectWriter{}
+	runErr := s.StreamTool(context.WithValue(ctx, replayKey{}, true), rec.Tool, rec.Input, col)
+
+	res := &ReplayResult{
+		ID:             rec.ID,
+		Tool:           rec.Tool,
+		RecordedEvents: len(rec.Output),
+		ReplayedEvents: len(col.rec.rec.Output),
+		DurationMS:     time.Since(start).Milliseconds(),
+	}
+	if rec.Error != nil {
+		res.RecordedError = rec.Error.Code
+	}
+	replayCode := ""
+	if runErr != nil {
+		res.ReplayError = ErrorFor(runErr, logging.RequestIDFromContext(ctx))
+		replayCode = res.ReplayError.Code
+	}
+	got := col.rec.rec.Output
+	for i := 0; i < max(len(rec.Output), len(got)); i++ {
+		var a, b *RecordedEvent
+		if i < len(rec.Output) {
+			a = &rec.Output[i]
+		}
+		if i < len(got) {
+			b = &got[i]
+		}
+		if a == nil || b == nil || *a != *b {
+			res.FirstDiff = &ReplayDiff{Index: i, Recorded: a, Replayed: b}
+			break
+		}
+	}
+	res.Match = res.FirstDiff == nil && res.RecordedError == replayCode
+	return res, nil
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"regexp"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/metrics"
+)
+
+var redactions = metrics.Default.CounterVec("mcp_gw_redactions_total",
+	"Secrets redacted from tool output lines.", "tool", "detector")
+
+// redactionDetectors são as regex dos detectores embutidos (config.RedactionDetectors).
+// Conservadoras de propósito: falso positivo só troca texto, falso negativo vaza segredo.
+var redactionDetectors = map[string]*regexp.Regexp{
+	"aws_access_key": regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+	"aws_secret_key": regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?[=:"' ]+[A-Za-z0-9/+=]{40}\b`),
+	"bearer_token":   regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{8,}=*`),
+	"email":          regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`),
+	"private_key":    regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----.*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`),
+	"github_token":   regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	"jwt":            regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{5,}\.eyJ[A-Za-z0-9_\-]{5,}\.[A-Za-z0-9_\-]{10,}`),
+}
+
+type redactRule struct {
+	name string
+	re   *regexp.Regexp
+	repl []byte
+}
+
+// redactor aplica os detectores de uma tool a cada linha de saída.
+type redactor struct {
+	tool  string
+	rules []redactRule
+}
+
+// newRedactors monta o redactor de cada tool (bloco global + tools[*].redaction).
+func newRedactors(cfg *config.Config) map[string]*redactor {
+	out := make(map[string]*redactor)
+	for name, t := range cfg.Tools {
+		if t.Redaction.Disabled {
+			continue
+		}
+		r := &redactor{tool: name}
+		for _, rc := range []config.Redaction{cfg.Redaction, t.Redaction} {
+			for _, d := range rc.Detectors {
+				if re := redactionDetectors[d]; re != nil {
+					r.rules = append(r.rules, redactRule{name: d, re: re, repl: []byte(rc.ReplacementEffective(d))})
+				}
+			}
+			for _, p := range rc.Patterns {
+				if re, err := regexp.Compile(p.Regex); err == nil {
+					r.rules = append(r.rules, redactRule{name: p.Name, re: re, repl: []byte(rc.ReplacementEffective(p.Name))})
+				}
+			}
+		}
+		if len(r.rules) > 0 {
+			out[name] = r
+		}
+	}
+	return out
+}
+
+// redact devolve a linha sem os segredos e quantos foram trocados (nil-safe).
+func (r *redactor) redact(line []byte) ([]byte, int) {
+	if r == nil {
+		return line, 0
+	}
+	total := 0
+	for _, rule := range r.rules {
+		n := 0
+		line = rule.re.ReplaceAllFunc(line, func([]byte) []byte {
+			n++
+			return rule.repl
+		})
+		if n > 0 {
+			redactions.With(r.tool, rule.name).Add(int64(n))
+			total += n
+		}
+	}
+	return line, total
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/runner"
+)
+
+// Selftest (mcp-gw selftest, --selftest-on-start): exercita cada tool do config antes dos
+// clientes. Tools com selftest.input são chamadas com ele (modo probe); as demais launcher
+// são só iniciadas (modo spawn). Daemon, reuse, remote e builtin sem input ficam de fora:
+// não há como iniciá-las sem uma request de verdade.
+
+// Modos e resultados do selftest.
+const (
+	SelftestProbe = "probe"
+	SelftestSpawn = "spawn"
+
+	SelftestPass = "pass"
+	SelftestFail = "fail"
+	SelftestSkip = "skip"
+)
+
+// selftestParallel limita quantas tools o selftest exercita ao mesmo tempo.
+const selftestParallel = 4
+
+// selftestStderrBytes limita o stderr (o fim dele) anexado ao detalhe de uma falha no spawn.
+const selftestStderrBytes = 512
+
+// SelftestResult é o resultado do selftest de uma tool.
+type SelftestResult struct {
+	Tool       string `json:"tool"`
+	Mode       string `json:"mode,omitempty"`
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// Selftest exercita as tools names (vazio = todas do config, fora as de diagnóstico),
+// ordenado por nome.
+func (s *Service) Selftest(ctx context.Context, names []string) ([]SelftestResult, error) {
+	if len(names) == 0 {
+		for name := range s.cfg.Tools {
+			if !config.IsDiagnosticTool(name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := s.r.MustGetTool(name); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]SelftestResult, len(names))
+	sem := make(chan struct{}, selftestParallel)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer func() { <-sem; wg.Done() }()
+			out[i] = s.selftestTool(ctx, name, s.cfg.Tools[name])
+		}(i, name)
+	}
+	wg.Wait()
+	return out, nil
+}
+
+func (s *Service) selftestTool(ctx context.Context, name string, tool config.Tool) SelftestResult {
+	res := SelftestResult{Tool: name}
+	if tool.Selftest.Skip {
+		res.Status, res.Detail = SelftestSkip, "selftest.skip"
+		return res
+	}
+
+	ctx = logging.WithRequestID(ctx, "selftest-"+name)
+	start := time.Now()
+	timeout := tool.SelftestTimeout()
+	var err error
+	switch {
+	case tool.Selftest.Input != nil:
+		res.Mode = SelftestProbe
+		err = s.selftestProbe(ctx, name, tool.Selftest.Input, timeout)
+	case tool.Runtime == "remote" || tool.Runtime == "builtin" || tool.Mode == "daemon" || tool.Reuse != nil:
+		res.Status, res.Detail = SelftestSkip, "no selftest.input (only launcher tools can be spawned dry)"
+		return res
+	default:
+		res.Mode = SelftestSpawn
+		err = s.selftestSpawn(ctx, name, tool, timeout)
+	}
+	res.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Status, res.Detail = SelftestFail, err.Error()
+		return res
+	}
+	res.Status = SelftestPass
+	return res
+}
+
+// firstLine encerra o probe (cancel) assim que a primeira linha chega.
+type firstLine struct {
+	got    atomic.Bool
+	cancel context.CancelFunc
+}
+
+func (f *firstLine) WriteLine([]byte) error {
+	if !f.got.Swap(true) {
+		f.cancel()
+	}
+	return nil
+}
+
+// selftestProbe chama a tool com input e exige uma linha de saída dentro de timeout.
+func (s *Service) selftestProbe(ctx context.Context, name string, input map[string]any, timeout time.Duration) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	out := &firstLine{cancel: cancel}
+	err = s.StreamTool(ctx, name, body, out)
+	switch {
+	case out.got.Load():
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("no output within %s", timeout)
+	case err != nil:
+		return err
+	}
+	return errors.New("tool exited without output")
+}
+
+// selftestSpawn inicia o processo com o stdin fechado: passa se ele segue vivo até
+// timeout ou sai sem erro.
+func (s *Service) selftestSpawn(ctx context.Context, name string, tool config.Tool, timeout time.Duration) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p, err := s.r.Start(ctx, name, tool)
+	if err != nil {
+		return fmt.Errorf("spawn: %w", err)
+	}
+	defer p.Close()
+	_ = p.Stdin().Close()
+	go func() { _, _ = io.Copy(io.Discard, p.Stdout()) }()
+
+	// o stderr é lido só pelo pump do runner: a cauda vem dele, até o EOF, antes do Wait
+	// (que fecha os pipes)
+	type exit struct {
+		err    error
+		stderr string
+	}
+	done := make(chan exit, 1)
+	go func() {
+		stderr := ""
+		if t, ok := p.(runner.StderrTailer); ok {
+			stderr = strings.TrimSpace(t.StderrTail())
+			if len(stderr) > selftestStderrBytes {
+				stderr = stderr[len(stderr)-selftestStderrBytes:]
+			}
+		}
+		done <- exit{p.Wait(), stderr}
+	}()
+	select {
+	case e := <-done:
+		switch {
+		case e.err == nil:
+			return nil
+		case e.stderr != "":
+			return fmt.Errorf("exited: %v: %s", e.err, strings.ReplaceAll(e.stderr, "\n", "; "))
+		}
+		return fmt.Errorf("exited: %v", e.err)
+	case <-time.After(timeout):
+		return nil // segue vivo esperando input: subiu
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
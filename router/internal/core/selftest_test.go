@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+)
+
+func TestSelftest(t *testing.T) {
+	sh := func(script string) config.Tool {
+		return config.Tool{Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", Args: []string{"-c", script}, TimeoutMS: 5000}
+	}
+	probe := sh(`read l; echo "$l"`)
+	probe.Selftest.Input = map[string]any{"ping": true}
+	silent := sh(`sleep 5`)
+	silent.Selftest.Input = map[string]any{}
+	silent.StartupTimeoutMS = 200
+	waits := sh(`cat >/dev/null`)
+	waits.StartupTimeoutMS = 200
+	skipped := sh(`exit 1`)
+	skipped.Selftest.Skip = true
+
+	svc := New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"probe": probe, "silent": silent, "waits": waits, "skipped": skipped,
+			"crashes": sh(`echo bad config >&2; exit 2`),
+			"daemon":  {Runtime: "native", Mode: "daemon", Cmd: "/bin/cat", TimeoutMS: 5000},
+		},
+	})
+	defer svc.Close()
+
+	results, err := svc.Selftest(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]SelftestResult)
+	for _, r := range results {
+		got[r.Tool] = r
+	}
+	want := map[string][2]string{
+		"probe":   {SelftestProbe, SelftestPass},
+		"silent":  {SelftestProbe, SelftestFail},
+		"waits":   {SelftestSpawn, SelftestPass},
+		"crashes": {SelftestSpawn, SelftestFail},
+		"skipped": {"", SelftestSkip},
+		"daemon":  {"", SelftestSkip},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("results: %+v", results)
+	}
+	for name, w := range want {
+		if r := got[name]; r.Mode != w[0] || r.Status != w[1] {
+			t.Errorf("%s: mode=%q status=%q detail=%q, want %s/%s", name, r.Mode, r.Status, r.Detail, w[0], w[1])
+		}
+	}
+	if d := got["crashes"].Detail; !strings.Contains(d, "bad config") {
+		t.Errorf("crash detail should carry stderr: %q", d)
+	}
+
+	if _, err := svc.Selftest(context.Background(), []string{"nope"}); err == nil {
+		t.Fatal("unknown tool should fail")
+	}
+}
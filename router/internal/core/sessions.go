@@ -0,0 +1,50 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"mcp-router/internal/runner"
+	"mcp-router/internal/sandbox"
+)
+
+// ErrNotDaemon: sessão pedida para uma tool que não é mode: daemon.
+var ErrNotDaemon = errors.New("tool is not a daemon tool (mode: daemon)")
+
+// OpenSession cria uma sessão (processo exclusivo) de uma tool daemon.
+func (s *Service) OpenSession(toolName string) (runner.SessionInfo, error) {
+	if err := sandbox.ValidateToolName(toolName); err != nil {
+		return runner.SessionInfo{}, fmt.Errorf("%w: %w", ErrInvalidToolName, err)
+	}
+	toolName = s.canonicalTool(toolName)
+	tool, err := s.r.MustGetTool(toolName)
+	if err != nil {
+		return runner.SessionInfo{}, err
+	}
+	if tool.Mode != "daemon" {
+		return runner.SessionInfo{}, ErrNotDaemon
+	}
+	return s.r.OpenSession(toolName, tool)
+}
+
+// CloseSession encerra a sessão. false = não existe.
+func (s *Service) CloseSession(id string) bool {
+	return s.r.CloseSession(id)
+}
+
+// Sessions lista as sessões abertas.
+func (s *Service) Sessions() []runner.SessionInfo {
+	return s.r.Sessions()
+}
+
+// CheckSession valida a sessão de uma request antes do stream: tools daemon exigem uma
+// sessão viva da própria tool; nas demais o header é ignorado.
+func (s *Service) CheckSession(toolName, id string) error {
+	if s.cfg.Tools[toolName].Mode != "daemon" {
+		return nil
+	}
+	if id == "" {
+		return runner.ErrSessionRequired
+	}
+	return s.r.LookupSession(id, toolName)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/storage"
+)
+
+// shutdownReportPrefix é onde os relatórios de shutdown ficam no storage.
+// A chave usa timestamp ordenável para List devolver o mais recente por último.
+const shutdownReportPrefix = "reports/shutdown/"
+
+// ShutdownReport descreve as execuções que ainda estavam rodando após a janela de drain
+// e foram mortas à força. Serve para avisar usuários afetados e achar long-runners crônicos.
+type ShutdownReport struct {
+	At          time.Time       `json:"at"`
+	DrainMs     int64           `json:"drain_ms"`
+	ForceKilled []ExecutionInfo `json:"force_killed"`
+}
+
+// ForceKillAll cancela todas as execuções restantes (fim do drain), registra o relatório
+// em log e persiste no storage (best effort) para consulta via admin após o restart.
+func (s *Service) ForceKillAll(ctx context.Context, drain time.Duration) ShutdownReport {
+	log := logging.LoggerFromContext(ctx)
+
+	rep := ShutdownReport{
+		At:          time.Now().UTC(),
+		DrainMs:     drain.Milliseconds(),
+		ForceKilled: s.execs.cancelAll(ErrForceKilled),
+	}
+	if rep.ForceKilled == nil {
+		rep.ForceKilled = []ExecutionInfo{}
+	}
+
+	for _, e := range rep.ForceKilled {
+		log.Warn("execution force-killed on shutdown",
+			logging.RequestID(e.RequestID),
+			logging.Tool(e.Tool),
+			logging.Runtime(e.Runtime),
+			logging.Client(e.Client),
+			logging.Int64("age_ms", e.AgeMs),
+		)
+		publishKilled(e, ErrForceKilled)
+	}
+	log.Info("shutdown report",
+		logging.Int("force_killed", len(rep.ForceKilled)),
+		logging.Int64("drain_ms", rep.DrainMs),
+	)
+
+	if len(rep.ForceKilled) > 0 {
+		b, _ := json.Marshal(rep)
+		key := shutdownReportPrefix + rep.At.Format("20060102T150405.000000000Z") + ".json"
+		pctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.store.Put(pctx, key, bytes.NewReader(b)); err != nil {
+			log.Warn("failed to persist shutdown report", logging.Err(err))
+		}
+	}
+
+	return rep
+}
+
+// LastShutdownReport lê o relatório de shutdown mais recente do storage.
+// Retorna storage.ErrNotFound se nenhum shutdown matou execuções.
+func (s *Service) LastShutdownReport(ctx context.Context) (*ShutdownReport, error) {
+	keys, err := s.store.List(ctx, shutdownReportPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	rc, err := s.store.Get(ctx, keys[len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	var rep ShutdownReport
+	if err := json.Unmarshal(b, &rep); err != nil {
+		return nil, fmt.Errorf("decode shutdown report: %w", err)
+	}
+	return &rep, nil
+}
+
+// ShutdownDrain retorna a janela de drain configurada para o shutdown gracioso.
+func (s *Service) ShutdownDrain() time.Duration {
+	return s.cfg.ShutdownDrain()
+}
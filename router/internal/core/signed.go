@@ -0,0 +1,141 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/runner"
+)
+
+// maxSignedGrants limita quantas URLs assinadas podem estar pendentes ao mesmo tempo.
+const maxSignedGrants = 10000
+
+var (
+	ErrSignatureInvalid = errors.New("invalid signature")
+	ErrSignatureExpired = errors.New("signed url expired")
+	ErrSignatureUsed    = errors.New("signed url already used")
+	ErrTooManyGrants    = errors.New("too many pending signed urls")
+)
+
+// SignedURL é uma URL de uso único para uma tool com input fixo.
+type SignedURL struct {
+	URL       string    `json:"url"` // path + query (ex: /mcp/git?id=..&exp=..&sig=..)
+	Tool      string    `json:"tool"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type signedGrant struct {
+	tool  string
+	input []byte
+	exp   time.Time
+}
+
+// signer emite e resgata URLs assinadas (HMAC-SHA256 com chave efêmera do processo).
+//
+// A assinatura cobre tool, id, exp e o hash do input; o grant fica em memória até ser
+// usado ou expirar, o que garante uso único. Restart invalida todas as URLs pendentes.
+type signer struct {
+	key []byte
+
+	mu     sync.Mutex
+	grants map[string]signedGrant
+}
+
+func newSigner() *signer {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("signer: random key: %v", err))
+	}
+	return &signer{key: key, grants: make(map[string]signedGrant)}
+}
+
+func (s *signer) sign(tool, id string, exp int64, input []byte) string {
+	sum := sha256.Sum256(input)
+	m := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(m, "%s\n%s\n%d\n%x", tool, id, exp, sum)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func (s *signer) mint(tool string, input []byte, ttl time.Duration, now time.Time) (SignedURL, error) {
+	idb := make([]byte, 16)
+	if _, err := rand.Read(idb); err != nil {
+		return SignedURL{}, err
+	}
+	id := hex.EncodeToString(idb)
+	exp := now.Add(ttl).Truncate(time.Second)
+
+	s.mu.Lock()
+	for k, g := range s.grants {
+		if !now.Before(g.exp) {
+			delete(s.grants, k)
+		}
+	}
+	if len(s.grants) >= maxSignedGrants {
+		s.mu.Unlock()
+		return SignedURL{}, ErrTooManyGrants
+	}
+	s.grants[id] = signedGrant{tool: tool, input: input, exp: exp}
+	s.mu.Unlock()
+
+	q := url.Values{}
+	q.Set("id", id)
+	q.Set("exp", strconv.FormatInt(exp.Unix(), 10))
+	q.Set("sig", s.sign(tool, id, exp.Unix(), input))
+	return SignedURL{URL: "/mcp/" + tool + "?" + q.Encode(), Tool: tool, ExpiresAt: exp}, nil
+}
+
+// redeem valida a assinatura e consome o grant, retornando o input fixo.
+func (s *signer) redeem(tool string, q url.Values, now time.Time) ([]byte, error) {
+	id, sig := q.Get("id"), q.Get("sig")
+	exp, err := strconv.ParseInt(q.Get("exp"), 10, 64)
+	if err != nil || id == "" || sig == "" {
+		return nil, ErrSignatureInvalid
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.grants[id]
+	if !ok {
+		// id desconhecido: assinatura forjada ou grant já consumido/expirado
+		return nil, ErrSignatureUsed
+	}
+	if g.tool != tool || g.exp.Unix() != exp || !hmac.Equal([]byte(sig), []byte(s.sign(tool, id, exp, g.input))) {
+		return nil, ErrSignatureInvalid
+	}
+	delete(s.grants, id)
+	if !now.Before(g.exp) {
+		return nil, ErrSignatureExpired
+	}
+	return g.input, nil
+}
+
+// MintSignedURL cria uma URL de uso único para tool com input fixo, válida por ttl
+// (0 = config.DefaultSignedURLTTL; máximo config.MaxSignedURLTTL).
+func (s *Service) MintSignedURL(tool string, input []byte, ttl time.Duration) (SignedURL, error) {
+	if _, ok := s.cfg.Tools[tool]; !ok {
+		return SignedURL{}, fmt.Errorf("%w: %s", runner.ErrUnknownTool, tool)
+	}
+	if ttl <= 0 {
+		ttl = config.DefaultSignedURLTTL
+	}
+	if ttl > config.MaxSignedURLTTL {
+		return SignedURL{}, fmt.Errorf("ttl must be at most %s", config.MaxSignedURLTTL)
+	}
+	return s.signer.mint(tool, input, ttl, time.Now())
+}
+
+// RedeemSignedURL valida os parâmetros id/exp/sig de uma URL assinada e a consome.
+// Retorna o input fixo que deve ser usado na execução (o body da request é ignorado).
+func (s *Service) RedeemSignedURL(tool string, q url.Values) ([]byte, error) {
+	return s.signer.redeem(tool, q, time.Now())
+}
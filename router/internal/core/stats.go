@@ -0,0 +1,166 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"mcp-router/internal/sandbox"
+)
+
+// Janela dos contadores de GET /mcp/tools/<name>/stats: buckets de um minuto, e as
+// últimas statsSamples durações para os percentis.
+const (
+	statsBucket  = time.Minute
+	statsBuckets = 15
+	statsSamples = 1024
+)
+
+// ToolStats é o resumo de saúde de uma tool (sem Prometheus): contadores da janela
+// recente, totais desde o start e percentis das últimas execuções.
+type ToolStats struct {
+	Tool           string     `json:"tool"`
+	WindowMS       int64      `json:"window_ms"`
+	Invocations    int64      `json:"invocations"`
+	Errors         int64      `json:"errors"`
+	BusyRejections int64      `json:"busy_rejections"`
+	P50MS          int64      `json:"p50_ms"`
+	P95MS          int64      `json:"p95_ms"`
+	Total          StatsTotal `json:"total"`
+	InFlight       int        `json:"in_flight"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+	LastErrorCode  string     `json:"last_error_code,omitempty"`
+}
+
+// StatsTotal são os contadores desde o start do gateway.
+type StatsTotal struct {
+	Invocations    int64 `json:"invocations"`
+	Errors         int64 `json:"errors"`
+	BusyRejections int64 `json:"busy_rejections"`
+}
+
+type statsBucketCounts struct {
+	minute                    int64 // unix minute do bucket (reaproveitado em anel)
+	invocations, errors, busy int64
+}
+
+type toolStats struct {
+	buckets   [statsBuckets]statsBucketCounts
+	total     StatsTotal
+	durations [statsSamples]int64 // anel de durações (ms)
+	n         int                 // amostras no anel (até statsSamples)
+	next      int
+	lastRun   time.Time
+	lastErr   time.Time
+	lastCode  string
+}
+
+// statsRegistry guarda os contadores por tool em memória.
+type statsRegistry struct {
+	mu    sync.Mutex
+	tools map[string]*toolStats
+}
+
+func newStatsRegistry() *statsRegistry {
+	return &statsRegistry{tools: make(map[string]*toolStats)}
+}
+
+func (r *statsRegistry) bucket(ts *toolStats, now time.Time) *statsBucketCounts {
+	m := now.Unix() / int64(statsBucket/time.Second)
+	b := &ts.buckets[m%statsBuckets]
+	if b.minute != m {
+		*b = statsBucketCounts{minute: m}
+	}
+	return b
+}
+
+// record contabiliza uma chamada terminada. Rejeições por concorrência contam só como
+// busy (a tool não rodou); as demais entram em invocations e nos percentis.
+func (r *statsRegistry) record(tool string, started time.Time, dur time.Duration, err error) {
+	now := started.Add(dur)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ts := r.tools[tool]
+	if ts == nil {
+		ts = &toolStats{}
+		r.tools[tool] = ts
+	}
+	b := r.bucket(ts, now)
+	if IsBusy(err) {
+		b.busy++
+		ts.total.BusyRejections++
+		return
+	}
+	b.invocations++
+	ts.total.Invocations++
+	ts.lastRun = started
+	if err != nil {
+		b.errors++
+		ts.total.Errors++
+		ts.lastErr = now
+		ts.lastCode = ErrorCode(err)
+	}
+	ts.durations[ts.next] = dur.Milliseconds()
+	ts.next = (ts.next + 1) % statsSamples
+	ts.n = min(ts.n+1, statsSamples)
+}
+
+func (r *statsRegistry) snapshot(tool string, now time.Time) ToolStats {
+	out := ToolStats{Tool: tool, WindowMS: (statsBuckets * statsBucket).Milliseconds()}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ts := r.tools[tool]
+	if ts == nil {
+		return out
+	}
+	cur := now.Unix() / int64(statsBucket/time.Second)
+	for _, b := range ts.buckets {
+		if b.minute > cur-statsBuckets && b.minute <= cur {
+			out.Invocations += b.invocations
+			out.Errors += b.errors
+			out.BusyRejections += b.busy
+		}
+	}
+	out.Total = ts.total
+	if ts.n > 0 {
+		d := slices.Clone(ts.durations[:ts.n])
+		slices.Sort(d)
+		out.P50MS = percentile(d, 50)
+		out.P95MS = percentile(d, 95)
+	}
+	if !ts.lastRun.IsZero() {
+		t := ts.lastRun
+		out.LastRunAt = &t
+	}
+	if !ts.lastErr.IsZero() {
+		t := ts.lastErr
+		out.LastErrorAt, out.LastErrorCode = &t, ts.lastCode
+	}
+	return out
+}
+
+// percentile pelo método nearest-rank (sorted não vazio).
+func percentile(sorted []int64, p int) int64 {
+	i := (len(sorted)*p + 99) / 100
+	return sorted[max(i-1, 0)]
+}
+
+// ToolStats retorna o resumo de GET /mcp/tools/<name>/stats.
+func (s *Service) ToolStats(name string) (ToolStats, error) {
+	if err := sandbox.ValidateToolName(name); err != nil {
+		return ToolStats{}, fmt.Errorf("%w: %w", ErrInvalidToolName, err)
+	}
+	name = s.canonicalTool(name)
+	if _, err := s.r.MustGetTool(name); err != nil {
+		return ToolStats{}, err
+	}
+	out := s.stats.snapshot(name, time.Now())
+	for _, e := range s.execs.snapshot() {
+		if e.Tool == name {
+			out.InFlight++
+		}
+	}
+	return out, nil
+}
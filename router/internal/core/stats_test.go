@@ -0,0 +1,37 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatsRegistry_WindowAndPercentiles(t *testing.T) {
+	r := newStatsRegistry()
+	now := time.Now()
+	old := now.Add(-time.Hour)
+
+	r.record("t", old, 5*time.Millisecond, nil) // fora da janela, só no total
+	for i := 1; i <= 20; i++ {
+		r.record("t", now, time.Duration(i)*time.Millisecond, nil)
+	}
+	r.record("t", now, time.Millisecond, errors.New("exit status 1"))
+	r.record("t", now, 0, ErrToolBusy)
+
+	st := r.snapshot("t", now)
+	if st.Invocations != 21 || st.Errors != 1 || st.BusyRejections != 1 {
+		t.Fatalf("window counts: %+v", st)
+	}
+	if st.Total.Invocations != 22 || st.Total.Errors != 1 || st.Total.BusyRejections != 1 {
+		t.Fatalf("totals: %+v", st.Total)
+	}
+	if st.P50MS != 9 || st.P95MS != 19 { // 22 amostras: 1,1,2,3,4,5,5,6..20
+		t.Fatalf("percentiles: p50=%d p95=%d", st.P50MS, st.P95MS)
+	}
+	if st.LastErrorCode != CodeToolFailed || st.LastErrorAt == nil {
+		t.Fatalf("last error: %+v", st)
+	}
+	if empty := r.snapshot("other", now); empty.Total.Invocations != 0 || empty.LastRunAt != nil {
+		t.Fatalf("unknown tool must be empty: %+v", empty)
+	}
+}
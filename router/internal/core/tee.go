@@ -0,0 +1,40 @@
+package core
+
+// teeWriter repassa a saída ao transport e avisa observe de cada linha ou evento de
+// output entregue (linhas chegam como event "message"). Usado pelos sinks de eventos e
+// pela gravação (record).
+type teeWriter struct {
+	LineWriter
+	observe func(event string, data []byte)
+}
+
+// teeOutput embrulha out sem esconder o EventWriter do transport: quem não entrega
+// eventos de output continua recusando binary/continuation como antes.
+func teeOutput(out LineWriter, observe func(event string, data []byte)) LineWriter {
+	w := &teeWriter{LineWriter: out, observe: observe}
+	if ew, ok := out.(EventWriter); ok {
+		return &teeEventWriter{teeWriter: w, ew: ew}
+	}
+	return w
+}
+
+func (w *teeWriter) WriteLine(line []byte) error {
+	if err := w.LineWriter.WriteLine(line); err != nil {
+		return err
+	}
+	w.observe("message", line)
+	return nil
+}
+
+type teeEventWriter struct {
+	*teeWriter
+	ew EventWriter
+}
+
+func (w *teeEventWriter) WriteEvent(event string, data []byte) error {
+	if err := w.ew.WriteEvent(event, data); err != nil {
+		return err
+	}
+	w.observe(event, data)
+	return nil
+}
@@ -0,0 +1,31 @@
+package core
+
+import "testing"
+
+type plainWriter struct{}
+
+func (plainWriter) WriteLine([]byte) error { return nil }
+
+type richWriter struct{ plainWriter }
+
+func (richWriter) WriteEvent(string, []byte) error { return nil }
+
+func TestTeeOutput_KeepsTransportCapabilities(t *testing.T) {
+	var seen []string
+	observe := func(event string, data []byte) { seen = append(seen, event+" "+string(data)) }
+
+	if _, ok := teeOutput(plainWriter{}, observe).(EventWriter); ok {
+		t.Fatal("tee must not advertise output events the transport cannot deliver")
+	}
+
+	out := teeOutput(richWriter{}, observe)
+	w, ok := out.(EventWriter)
+	if !ok {
+		t.Fatal("tee must keep the transport EventWriter")
+	}
+	_ = out.WriteLine([]byte(`{"a":1}`))
+	_ = w.WriteEvent("data", []byte(`{"seq":0}`))
+	if len(seen) != 2 || seen[0] != `message {"a":1}` || seen[1] != `data {"seq":0}` {
+		t.Fatalf("observed: %q", seen)
+	}
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"slices"
+
+	"mcp-router/internal/flags"
+)
+
+// Recursos de protocolo anunciados em GET /version, para clientes e shims adaptarem o
+// comportamento à versão do gateway sem tentativa e erro. Só aparecem os ligados.
+const (
+	FeatureSSE            = "sse"             // resposta em text/event-stream
+	FeatureSSEResume      = "sse-resume"      // X-MCP-Resume + GET /mcp/streams/<token> (stream.resume_window_ms)
+	FeatureNDJSON         = "ndjson"          // saída application/x-ndjson (X-MCP-Flags: ndjson liberada no config)
+	FeatureNDJSONInput    = "ndjson-input"    // input em stream para tools interactive
+	FeatureCancellation   = "cancellation"    // DELETE /mcp/requests/<id>
+	FeatureSessions       = "sessions"        // Mcp-Session-Id (tools daemon)
+	FeatureDryRun         = "dry-run"         // POST /mcp/<tool>?dry_run=1
+	FeatureFederation     = "federation"      // endpoint JSON-RPC agregado em /mcp
+	FeatureWorkspaceFiles = "workspace-files" // /workspace/files/<path> (files.enabled)
+	FeatureWorkspaces     = "workspaces"      // X-MCP-Workspace (workspaces nomeados)
+)
+
+// Features lista os recursos de protocolo ligados neste gateway.
+func (s *Service) Features() []string {
+	out := []string{FeatureSSE, FeatureNDJSONInput, FeatureCancellation, FeatureSessions, FeatureDryRun}
+	if s.StreamResumeWindow() > 0 {
+		out = append(out, FeatureSSEResume)
+	}
+	if slices.Contains(s.cfg.Flags.Allowed, flags.NDJSON) || slices.Contains(s.cfg.Flags.Default, flags.NDJSON) {
+		out = append(out, FeatureNDJSON)
+	}
+	if s.FederationEnabled() {
+		out = append(out, FeatureFederation)
+	}
+	if s.cfg.Files.Enabled {
+		out = append(out, FeatureWorkspaceFiles)
+	}
+	if len(s.cfg.Workspaces) > 0 {
+		out = append(out, FeatureWorkspaces)
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+)
+
+// Workspace nomeado (header X-MCP-Workspace, config workspaces): a request roda com outra
+// raiz de workspace. Processos que sobrevivem à request (reuse, daemon) foram criados com a
+// raiz de outro tenant e recusam a seleção.
+var (
+	ErrUnknownWorkspace     = errors.New("unknown workspace")
+	ErrWorkspaceUnsupported = errors.New("workspace selection is not supported by this tool (reuse or daemon)")
+)
+
+// WorkspaceRoot resolve o workspace nomeado ("" = workspace_root); ok=false fora da allowlist.
+func (s *Service) WorkspaceRoot(name string) (string, bool) {
+	return s.cfg.WorkspaceFor(name)
+}
+
+// requestWorkspace é a raiz de workspace da request (X-MCP-Workspace no ctx) para tool.
+func (s *Service) requestWorkspace(ctx context.Context, tool config.Tool) (name, root string, err error) {
+	name = logging.WorkspaceFromContext(ctx)
+	root, ok := s.cfg.WorkspaceFor(name)
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q", ErrUnknownWorkspace, name)
+	}
+	if name != "" && (tool.Reuse != nil || tool.Mode == "daemon") {
+		return "", "", ErrWorkspaceUnsupported
+	}
+	return name, root, nil
+}
+
+// workspace: ephemeral — cada request ganha <workspace_root>/.ephemeral/<request-id>-XXXX e a
+// tool enxerga só ele (vira o workspace_subdir da execução). Tools nunca veem os arquivos
+// umas das outras; no fim o diretório é removido, salvo workspace_retain.
+
+// ephemeralWorkspace cria o diretório da request e devolve a tool apontando para ele. cleanup
+// recebe o erro da execução (workspace_retain: on_failure mantém o diretório quando != nil).
+func (s *Service) ephemeralWorkspace(root string, tool config.Tool, rid string, log *slog.Logger) (config.Tool, func(error), error) {
+	base := filepath.Join(root, config.EphemeralDir)
+	// 0711: atravessável (a tool chega no próprio diretório), mas não listável
+	if err := os.MkdirAll(base, 0o711); err != nil {
+		return tool, nil, fmt.Errorf("%w: ephemeral workspace: %w", ErrSpawnFailed, err)
+	}
+	dir, err := os.MkdirTemp(base, ephemeralPrefix(rid))
+	if err != nil {
+		return tool, nil, fmt.Errorf("%w: ephemeral workspace: %w", ErrSpawnFailed, err)
+	}
+	s.shareWithToolUser(dir, tool, log)
+
+	if tool.Workdir != "" {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(tool.Workdir)), 0o755); err != nil {
+			_ = os.RemoveAll(dir)
+			return tool, nil, fmt.Errorf("%w: ephemeral workspace: %w", ErrSpawnFailed, err)
+		}
+	}
+
+	tool.WorkspaceSubdir = config.EphemeralDir + "/" + filepath.Base(dir)
+	log.Debug("ephemeral workspace created", logging.String("workspace", tool.WorkspaceSubdir))
+
+	cleanup := func(runErr error) {
+		retain := tool.WorkspaceRetainEffective()
+		if retain == config.WorkspaceRetainAlways || (retain == config.WorkspaceRetainOnFailure && runErr != nil) {
+			log.Info("ephemeral workspace retained", logging.String("workspace", dir))
+			return
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warn("failed to remove ephemeral workspace", logging.String("workspace", dir), logging.Err(err))
+		}
+	}
+	return tool, cleanup, nil
+}
+
+// shareWithToolUser passa o diretório para o user da tool (user/container): com o gateway
+// root, chown; sem permissão para isso, abre o modo (o nome aleatório continua isolando).
+func (s *Service) shareWithToolUser(dir string, tool config.Tool, log *slog.Logger) {
+	u := s.cfg.ToolUser(tool)
+	if u == "" {
+		return
+	}
+	uid, gid, err := config.ParseUser(u)
+	if err != nil || uid == os.Geteuid() {
+		return
+	}
+	if err := os.Chown(dir, uid, gid); err == nil {
+		return
+	}
+	if err := os.Chmod(dir, 0o777); err != nil {
+		log.Warn("failed to open ephemeral workspace to tool user", logging.String("workspace", dir), logging.Err(err))
+	}
+}
+
+// ephemeralPrefix é o request id reduzido a um nome de arquivo seguro (prefixo do MkdirTemp).
+func ephemeralPrefix(rid string) string {
+	var b strings.Builder
+	for _, r := range rid {
+		if b.Len() >= 64 {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "req-"
+	}
+	return b.String() + "-"
+}
@@ -0,0 +1,395 @@
+// Package egress é o forward proxy embutido que dá às tools native o controle de saída
+// que os containers têm (docker_network): a tool recebe HTTP_PROXY/HTTPS_PROXY apontando
+// para o gateway, que só deixa passar os domínios de tools[*].egress_allow e registra todo
+// destino pedido. Nomes que resolvem para loopback, link-local ou rede privada são
+// recusados (salvo egress_proxy.allow_internal): a allowlist não abre o próprio gateway.
+//
+// É enforcement por convenção, como workspace_access ro em native: um processo que ignora
+// as variáveis de proxy sai direto. Para isolamento de rede de verdade, use sandbox: bwrap
+// ou runtime container.
+package egress
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/observability/metrics"
+)
+
+// Decisões do proxy (label decision de mcp_gw_egress_requests_total e campo dos logs).
+const (
+	DecisionAllowed = "allowed"
+	DecisionDenied  = "denied"
+)
+
+const (
+	// dialTimeout limita a conexão com o destino.
+	dialTimeout = 10 * time.Second
+	// readHeaderTimeout protege o listener de clientes que não mandam a request.
+	readHeaderTimeout = 10 * time.Second
+)
+
+var egressRequests = metrics.Default.CounterVec("mcp_gw_egress_requests_total",
+	"Outbound requests seen by the egress proxy, by tool and decision.", "tool", "decision")
+
+// hopHeaders não atravessam o proxy (RFC 9110 §7.6.1).
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Proxy é o forward proxy das tools. Cada tool se identifica pelo Proxy-Authorization
+// (embutido na URL do HTTP_PROXY): usuário = nome da tool, senha = HMAC(chave aleatória do
+// processo, tool). A senha de uma tool não vale para outro nome, então uma tool não usa a
+// allowlist de outra.
+type Proxy struct {
+	listen   string
+	allow    map[string][]string // tool -> padrões de egress_allow
+	key      []byte
+	internal []netip.Prefix // allow_internal
+
+	dialer    *net.Dialer
+	transport *http.Transport
+
+	mu   sync.Mutex
+	srv  *http.Server
+	addr string // vazio até o primeiro URL
+	err  error
+}
+
+// New cria o proxy das tools com egress_allow em cfg (o listener só sobe no primeiro URL).
+func New(cfg *config.Config) *Proxy {
+	listen := cfg.EgressProxy.Listen
+	if listen == "" {
+		listen = config.DefaultEgressProxyListen
+	}
+	allow := make(map[string][]string)
+	for name, t := range cfg.Tools {
+		if t.EgressAllow != nil {
+			allow[name] = t.EgressAllow
+		}
+	}
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	p := &Proxy{
+		listen:   listen,
+		allow:    allow,
+		key:      key,
+		internal: cfg.EgressProxy.AllowInternalPrefixes(),
+	}
+	// o Control vê o IP já resolvido de cada tentativa: um nome que muda de IP entre a
+	// checagem e o dial (DNS rebinding) também cai aqui
+	p.dialer = &net.Dialer{Timeout: dialTimeout, Control: func(_, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		ip, err := netip.ParseAddr(host)
+		if err != nil {
+			return err
+		}
+		return p.checkAddr(ip)
+	}}
+	p.transport = &http.Transport{
+		Proxy:               nil, // o proxy não encadeia no proxy do próprio gateway
+		DialContext:         p.dialer.DialContext,
+		TLSHandshakeTimeout: dialTimeout,
+		MaxIdleConns:        64,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return p
+}
+
+// toolToken é a senha da tool no Proxy-Authorization.
+func (p *Proxy) toolToken(tool string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(tool))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// errInternalAddr: o destino resolve para um endereço interno fora de allow_internal.
+var errInternalAddr = errors.New("destination resolves to an internal address")
+
+// checkAddr recusa loopback, link-local, redes privadas e endereços não especificados,
+// exceto os cobertos por allow_internal.
+func (p *Proxy) checkAddr(ip netip.Addr) error {
+	ip = ip.Unmap()
+	if !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsUnspecified() {
+		return nil
+	}
+	for _, pfx := range p.internal {
+		if pfx.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w (%s)", errInternalAddr, ip)
+}
+
+// checkHost resolve host e confere todos os endereços com checkAddr.
+func (p *Proxy) checkHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(ip)
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil // o dial devolve o erro de resolução
+	}
+	for _, ip := range ips {
+		if err := p.checkAddr(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// URL retorna o HTTP_PROXY da tool (com as credenciais dela), subindo o listener na
+// primeira chamada.
+func (p *Proxy) URL(tool string) (string, error) {
+	if _, ok := p.allow[tool]; !ok {
+		return "", fmt.Errorf("egress: tool %q has no egress_allow", tool)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.addr == "" && p.err == nil {
+		p.err = p.startLocked()
+	}
+	if p.err != nil {
+		return "", p.err
+	}
+	u := url.URL{Scheme: "http", User: url.UserPassword(tool, p.toolToken(tool)), Host: p.addr}
+	return u.String(), nil
+}
+
+func (p *Proxy) startLocked() error {
+	ln, err := net.Listen("tcp", p.listen)
+	if err != nil {
+		return fmt.Errorf("egress proxy: listen %s: %w", p.listen, err)
+	}
+	p.addr = ln.Addr().String()
+	p.srv = &http.Server{Handler: p, ReadHeaderTimeout: readHeaderTimeout}
+	go func() {
+		if err := p.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("egress proxy stopped", logging.Err(err))
+		}
+	}()
+	slog.Info("egress proxy listening", logging.String("addr", p.addr))
+	return nil
+}
+
+// Close derruba o listener e os túneis abertos.
+func (p *Proxy) Close() error {
+	p.mu.Lock()
+	srv := p.srv
+	p.mu.Unlock()
+	p.transport.CloseIdleConnections()
+	if srv == nil {
+		return nil
+	}
+	return srv.Close()
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tool, ok := p.authenticate(r)
+	if !ok {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="mcp-gw egress"`)
+		http.Error(w, "proxy authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+
+	host, port := r.URL.Hostname(), r.URL.Port()
+	if r.Method == http.MethodConnect {
+		host, port, _ = net.SplitHostPort(r.Host)
+	} else if !r.URL.IsAbs() {
+		http.Error(w, "absolute URL required", http.StatusBadRequest)
+		return
+	}
+	if port == "" {
+		port = "80"
+		if r.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	log := slog.Default().With(logging.Tool(tool), logging.String("host", host),
+		logging.String("port", port), logging.String("method", r.Method))
+	if !Allowed(p.allow[tool], host) {
+		egressRequests.With(tool, DecisionDenied).Inc()
+		log.Warn("egress denied", logging.String("decision", DecisionDenied))
+		http.Error(w, "destination not in egress_allow", http.StatusForbidden)
+		return
+	}
+	if err := p.checkHost(r.Context(), host); err != nil {
+		egressRequests.With(tool, DecisionDenied).Inc()
+		log.Warn("egress denied", logging.String("decision", DecisionDenied), logging.Err(err))
+		http.Error(w, "destination resolves to an internal address", http.StatusForbidden)
+		return
+	}
+	egressRequests.With(tool, DecisionAllowed).Inc()
+	log.Info("egress", logging.String("decision", DecisionAllowed))
+
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r, net.JoinHostPort(host, port), log)
+		return
+	}
+	p.forward(w, r, log)
+}
+
+// authenticate identifica a tool pelo Proxy-Authorization (Basic tool:toolToken(tool)).
+func (p *Proxy) authenticate(r *http.Request) (string, bool) {
+	scheme, cred, ok := strings.Cut(r.Header.Get("Proxy-Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(cred))
+	if err != nil {
+		return "", false
+	}
+	tool, token, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return "", false
+	}
+	if _, known := p.allow[tool]; !known {
+		return "", false
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(p.toolToken(tool))) != 1 {
+		return "", false
+	}
+	return tool, true
+}
+
+// tunnel atende CONNECT (HTTPS): bytes opacos entre a tool e o destino.
+func (p *Proxy) tunnel(w http.ResponseWriter, r *http.Request, target string, log *slog.Logger) {
+	dst, err := p.dialer.DialContext(r.Context(), "tcp", target)
+	if err != nil {
+		log.Warn("egress dial failed", logging.Err(err))
+		dialError(w, err)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		_ = dst.Close()
+		http.Error(w, "tunnel unsupported", http.StatusInternalServerError)
+		return
+	}
+	src, buf, err := hj.Hijack()
+	if err != nil {
+		_ = dst.Close()
+		return
+	}
+	if _, err := src.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		_ = src.Close()
+		_ = dst.Close()
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		// bytes que o cliente já mandou junto com o CONNECT estão no buffer do hijack
+		_, _ = io.Copy(dst, io.MultiReader(buf.Reader, src))
+		closeWrite(dst)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(src, dst)
+		closeWrite(src)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+	_ = src.Close()
+	_ = dst.Close()
+}
+
+// forward atende HTTP em claro (request com URL absoluta).
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		log.Warn("egress request failed", logging.Err(err))
+		dialError(w, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// dialError responde à falha de conexão: 403 se o destino virou endereço interno depois da
+// checagem, 502 no resto.
+func dialError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errInternalAddr) {
+		http.Error(w, "destination resolves to an internal address", http.StatusForbidden)
+		return
+	}
+	http.Error(w, "bad gateway", http.StatusBadGateway)
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, f := range strings.Split(h.Get("Connection"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			h.Del(f)
+		}
+	}
+	for _, k := range hopHeaders {
+		h.Del(k)
+	}
+}
+
+func closeWrite(c net.Conn) {
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+	}
+}
+
+// Allowed diz se host casa com algum padrão de egress_allow: nome exato, "*.dominio"
+// (só subdomínios) ou "*" (qualquer destino, só auditoria). Sem diferenciar maiúsculas.
+func Allowed(patterns []string, host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return false
+	}
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		switch {
+		case p == "*":
+			return true
+		case strings.HasPrefix(p, "*."):
+			if strings.HasSuffix(host, p[1:]) {
+				return true
+			}
+		case host == p:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package egress
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+)
+
+func TestAllowed(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		host     string
+		want     bool
+	}{
+		{[]string{"api.example.com"}, "api.example.com", true},
+		{[]string{"api.example.com"}, "API.Example.com.", true},
+		{[]string{"api.example.com"}, "example.com", false},
+		{[]string{"*.example.com"}, "a.b.example.com", true},
+		{[]string{"*.example.com"}, "example.com", false},
+		{[]string{"*.example.com"}, "evilexample.com", false},
+		{[]string{"*"}, "anything.test", true},
+		{nil, "api.example.com", false},
+		{[]string{"*"}, "", false},
+	}
+	for _, c := range cases {
+		if got := Allowed(c.patterns, c.host); got != c.want {
+			t.Errorf("Allowed(%v, %q) = %v, want %v", c.patterns, c.host, got, c.want)
+		}
+	}
+}
+
+func TestProxy_EnforcesPerToolAllowlist(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			t.Errorf("proxy credentials leaked upstream")
+		}
+		_, _ = io.WriteString(w, "plain:"+r.URL.Path)
+	}))
+	defer upstream.Close()
+	tlsUpstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "tls:"+r.URL.Path)
+	}))
+	defer tlsUpstream.Close()
+
+	p := New(&config.Config{
+		Tools: map[string]config.Tool{
+			"web":    {Runtime: "native", EgressAllow: []string{"127.0.0.1"}},
+			"sealed": {Runtime: "native", EgressAllow: []string{"api.example.com"}},
+			"plain":  {Runtime: "native"},
+		},
+		// os upstreams de teste escutam em loopback
+		EgressProxy: config.EgressProxy{AllowInternal: []string{"127.0.0.1"}},
+	})
+	defer p.Close()
+
+	if _, err := p.URL("plain"); err == nil {
+		t.Fatal("tool without egress_allow should not get a proxy URL")
+	}
+
+	get := func(proxyURL, target string) (int, string) {
+		t.Helper()
+		return proxyGet(t, proxyURL, target)
+	}
+
+	web, err := p.URL("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, body := get(web, upstream.URL+"/a"); st != http.StatusOK || body != "plain:/a" {
+		t.Fatalf("allowed http: status %d, %q", st, body)
+	}
+	if st, body := get(web, tlsUpstream.URL+"/b"); st != http.StatusOK || body != "tls:/b" {
+		t.Fatalf("allowed CONNECT: status %d, %q", st, body)
+	}
+
+	sealed, err := p.URL("sealed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := get(sealed, upstream.URL+"/a"); st != http.StatusForbidden {
+		t.Fatalf("denied http: status %d, want 403", st)
+	}
+	if st, body := get(sealed, tlsUpstream.URL+"/b"); st == http.StatusOK || !strings.Contains(body, "Forbidden") {
+		t.Fatalf("denied CONNECT: status %d, %q", st, body)
+	}
+
+	// token de outro processo (ou sem credenciais): 407
+	forged := strings.Replace(web, p.toolToken("web"), "forged", 1)
+	if st, _ := get(forged, upstream.URL+"/a"); st != http.StatusProxyAuthRequired {
+		t.Fatalf("forged credentials: status %d, want 407", st)
+	}
+
+	// a senha de uma tool não vale com o nome de outra: sealed não usa a allowlist de web
+	borrowed := strings.Replace(sealed, "//sealed:", "//web:", 1)
+	if st, _ := get(borrowed, upstream.URL+"/a"); st != http.StatusProxyAuthRequired {
+		t.Fatalf("credentials of another tool: status %d, want 407", st)
+	}
+}
+
+func TestProxy_RefusesInternalDestinations(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("internal destination reached: %s", r.URL)
+	}))
+	defer upstream.Close()
+	tlsUpstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("internal destination reached over CONNECT: %s", r.URL)
+	}))
+	defer tlsUpstream.Close()
+
+	// o nome está na allowlist, mas resolve para loopback (ex: a porta admin do gateway)
+	p := New(&config.Config{Tools: map[string]config.Tool{
+		"web": {Runtime: "native", EgressAllow: []string{"localhost", "127.0.0.1"}},
+	}})
+	defer p.Close()
+	web, err := p.URL("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := strings.Replace(upstream.URL, "127.0.0.1", "localhost", 1)
+	for _, target := range []string{upstream.URL + "/admin", byName + "/admin", tlsUpstream.URL + "/admin"} {
+		if st, body := proxyGet(t, web, target); st == http.StatusOK || (st != http.StatusForbidden && !strings.Contains(body, "Forbidden")) {
+			t.Fatalf("%s: status %d, %q; want 403", target, st, body)
+		}
+	}
+
+	for ip, want := range map[string]bool{
+		"10.1.2.3": false, "192.168.0.1": false, "169.254.169.254": false, "::1": false, "0.0.0.0": false,
+		"fd00::1": false, "93.184.216.34": true, "2606:4700::1111": true,
+	} {
+		if got := p.checkAddr(netip.MustParseAddr(ip)) == nil; got != want {
+			t.Errorf("checkAddr(%s) allowed=%v, want %v", ip, got, want)
+		}
+	}
+}
+
+func proxyGet(t *testing.T, proxyURL, target string) (int, string) {
+	t.Helper()
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(u),
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	resp, err := client.Get(target)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
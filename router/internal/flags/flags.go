@@ -0,0 +1,91 @@
+package flags
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Flags experimentais conhecidas pelo gateway.
+// Outras flags podem ser liberadas no config para experimentos futuros;
+// o gateway só as propaga (context/logs) sem mudar comportamento.
+const (
+	// NDJSON troca SSE por application/x-ndjson (uma linha JSON por evento).
+	NDJSON = "ndjson"
+	// CoalesceFlush agrupa flushes em janelas curtas em vez de 1 flush por linha.
+	CoalesceFlush = "coalesce_flush"
+)
+
+// Header HTTP usado pelo cliente para pedir flags (lista separada por vírgula).
+const Header = "X-MCP-Flags"
+
+// Set é o conjunto de flags ativas numa request.
+type Set map[string]bool
+
+func (s Set) Enabled(name string) bool { return s[name] }
+
+// List retorna as flags ativas em ordem (estável para logs/headers).
+func (s Set) List() []string {
+	out := make([]string, 0, len(s))
+	for k, v := range s {
+		if v {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s Set) String() string { return strings.Join(s.List(), ",") }
+
+// Resolve combina as flags default com as pedidas pelo cliente.
+// Flags pedidas fora da allowlist são ignoradas silenciosamente (e retornadas em rejected).
+// Um pedido "-nome" desliga uma flag default.
+func Resolve(requested string, allowed, defaults []string) (set Set, rejected []string) {
+	allow := make(map[string]bool, len(allowed))
+	for _, a := range allowed {
+		allow[a] = true
+	}
+
+	set = Set{}
+	for _, d := range defaults {
+		set[d] = true
+	}
+
+	for _, raw := range strings.Split(requested, ",") {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		off := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		if !allow[name] {
+			rejected = append(rejected, name)
+			continue
+		}
+		if off {
+			delete(set, name)
+		} else {
+			set[name] = true
+		}
+	}
+	return set, rejected
+}
+
+type ctxKey struct{}
+
+func WithContext(ctx context.Context, s Set) context.Context {
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+func FromContext(ctx context.Context) Set {
+	if s, ok := ctx.Value(ctxKey{}).(Set); ok {
+		return s
+	}
+	return Set{}
+}
+
+// Enabled é um atalho para FromContext(ctx).Enabled(name).
+func Enabled(ctx context.Context, name string) bool {
+	return FromContext(ctx).Enabled(name)
+}
@@ -0,0 +1,484 @@
+// Package jsonschema valida documentos JSON contra o subconjunto de JSON Schema usado
+// em tools[*].input_schema: type, enum, const, properties, required,
+// additionalProperties, items, min/maxItems, minimum/maximum (e exclusive*),
+// min/maxLength, pattern, allOf/anyOf/oneOf/not.
+//
+// Palavras de anotação (title, description, default, examples, format...) são ignoradas;
+// $ref não é suportado e é recusado na compilação (schema maior = arquivo já expandido).
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxViolations limita quantas violações uma validação reporta.
+const MaxViolations = 20
+
+// Violation é uma regra do schema que o documento não cumpre.
+type Violation struct {
+	Path    string `json:"path"` // JSON Pointer do valor ("" = raiz)
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	p := v.Path
+	if p == "" {
+		p = "/"
+	}
+	return p + ": " + v.Message
+}
+
+// Schema é um schema compilado (regex prontas, tipos conferidos).
+type Schema struct {
+	types    []string
+	enum     []any
+	constVal any
+	hasConst bool
+
+	properties map[string]*Schema
+	required   []string
+	addlBool   *bool
+	addl       *Schema
+
+	items              *Schema
+	minItems, maxItems *int
+
+	minimum, maximum, exclMin, exclMax *float64
+
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+
+	allOf, anyOf, oneOf []*Schema
+	not                 *Schema
+}
+
+var knownTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// Compile valida e prepara um schema decodificado (de YAML ou JSON).
+func Compile(raw map[string]any) (*Schema, error) {
+	return compile(raw, "")
+}
+
+func compile(raw map[string]any, at string) (*Schema, error) {
+	s := &Schema{}
+	fail := func(kw, msg string) error {
+		return fmt.Errorf("schema %s/%s: %s", at, kw, msg)
+	}
+	for kw, v := range raw {
+		var err error
+		switch kw {
+		case "$ref", "$dynamicRef":
+			return nil, fail(kw, "references are not supported (inline the referenced schema)")
+		case "type":
+			switch t := v.(type) {
+			case string:
+				s.types = []string{t}
+			case []any:
+				for _, x := range t {
+					str, ok := x.(string)
+					if !ok {
+						return nil, fail(kw, "must be a string or an array of strings")
+					}
+					s.types = append(s.types, str)
+				}
+			default:
+				return nil, fail(kw, "must be a string or an array of strings")
+			}
+			for _, t := range s.types {
+				if !knownTypes[t] {
+					return nil, fail(kw, fmt.Sprintf("unknown type %q", t))
+				}
+			}
+		case "enum":
+			arr, ok := v.([]any)
+			if !ok {
+				return nil, fail(kw, "must be an array")
+			}
+			s.enum = normalizeAll(arr)
+		case "const":
+			s.constVal, s.hasConst = normalize(v), true
+		case "properties":
+			m, ok := asMap(v)
+			if !ok {
+				return nil, fail(kw, "must be an object")
+			}
+			s.properties = make(map[string]*Schema, len(m))
+			for name, sub := range m {
+				sm, ok := asMap(sub)
+				if !ok {
+					return nil, fail(kw+"/"+name, "must be an object")
+				}
+				if s.properties[name], err = compile(sm, at+"/properties/"+name); err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			arr, ok := v.([]any)
+			if !ok {
+				return nil, fail(kw, "must be an array of strings")
+			}
+			for _, x := range arr {
+				str, ok := x.(string)
+				if !ok {
+					return nil, fail(kw, "must be an array of strings")
+				}
+				s.required = append(s.required, str)
+			}
+		case "additionalProperties":
+			if b, ok := v.(bool); ok {
+				s.addlBool = &b
+			} else if s.addl, err = subSchema(v, at+"/"+kw); err != nil {
+				return nil, err
+			}
+		case "items":
+			if s.items, err = subSchema(v, at+"/"+kw); err != nil {
+				return nil, err
+			}
+		case "allOf", "anyOf", "oneOf":
+			arr, ok := v.([]any)
+			if !ok || len(arr) == 0 {
+				return nil, fail(kw, "must be a non-empty array of schemas")
+			}
+			list := make([]*Schema, 0, len(arr))
+			for i, x := range arr {
+				sub, err := subSchema(x, at+"/"+kw+"/"+strconv.Itoa(i))
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, sub)
+			}
+			switch kw {
+			case "allOf":
+				s.allOf = list
+			case "anyOf":
+				s.anyOf = list
+			default:
+				s.oneOf = list
+			}
+		case "not":
+			if s.not, err = subSchema(v, at+"/"+kw); err != nil {
+				return nil, err
+			}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			f, ok := toFloat(v)
+			if !ok {
+				return nil, fail(kw, "must be a number")
+			}
+			switch kw {
+			case "minimum":
+				s.minimum = &f
+			case "maximum":
+				s.maximum = &f
+			case "exclusiveMinimum":
+				s.exclMin = &f
+			default:
+				s.exclMax = &f
+			}
+		case "minItems", "maxItems", "minLength", "maxLength":
+			f, ok := toFloat(v)
+			if !ok || f < 0 || f != math.Trunc(f) {
+				return nil, fail(kw, "must be a non-negative integer")
+			}
+			n := int(f)
+			switch kw {
+			case "minItems":
+				s.minItems = &n
+			case "maxItems":
+				s.maxItems = &n
+			case "minLength":
+				s.minLength = &n
+			default:
+				s.maxLength = &n
+			}
+		case "pattern":
+			str, ok := v.(string)
+			if !ok {
+				return nil, fail(kw, "must be a string")
+			}
+			if s.pattern, err = regexp.Compile(str); err != nil {
+				return nil, fail(kw, err.Error())
+			}
+		}
+	}
+	return s, nil
+}
+
+func subSchema(v any, at string) (*Schema, error) {
+	m, ok := asMap(v)
+	if !ok {
+		if b, isBool := v.(bool); isBool {
+			// true = aceita tudo; false = nada (equivale a not: {})
+			if b {
+				return &Schema{}, nil
+			}
+			return &Schema{not: &Schema{}}, nil
+		}
+		return nil, fmt.Errorf("schema %s: must be an object", at)
+	}
+	return compile(m, at)
+}
+
+// Validate confere o documento (bytes JSON) contra o schema.
+func (s *Schema) Validate(doc []byte) ([]Violation, error) {
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+	var out []Violation
+	s.validate(v, "", &out)
+	return out, nil
+}
+
+func (s *Schema) validate(v any, path string, out *[]Violation) {
+	if len(*out) >= MaxViolations {
+		return
+	}
+	add := func(format string, args ...any) {
+		if len(*out) < MaxViolations {
+			*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	if len(s.types) > 0 && !typeMatches(s.types, v) {
+		add("expected %s, got %s", strings.Join(s.types, " or "), typeOf(v))
+		return
+	}
+	if s.hasConst && !equal(v, s.constVal) {
+		add("must be %s", compact(s.constVal))
+	}
+	if s.enum != nil {
+		ok := false
+		for _, e := range s.enum {
+			if equal(v, e) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			add("must be one of %s", compact(s.enum))
+		}
+	}
+
+	switch x := v.(type) {
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := x[name]; !ok {
+				add("missing required property %q", name)
+			}
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + escapePointer(k)
+			if ps, ok := s.properties[k]; ok {
+				ps.validate(x[k], child, out)
+				continue
+			}
+			if s.addlBool != nil && !*s.addlBool {
+				*out = appendCapped(*out, Violation{Path: child, Message: "additional property not allowed"})
+			} else if s.addl != nil {
+				s.addl.validate(x[k], child, out)
+			}
+		}
+	case []any:
+		if s.minItems != nil && len(x) < *s.minItems {
+			add("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(x) > *s.maxItems {
+			add("must have at most %d items", *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range x {
+				s.items.validate(item, path+"/"+strconv.Itoa(i), out)
+			}
+		}
+	case string:
+		n := len([]rune(x))
+		if s.minLength != nil && n < *s.minLength {
+			add("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			add("must be at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(x) {
+			add("must match pattern %q", s.pattern.String())
+		}
+	case float64:
+		if s.minimum != nil && x < *s.minimum {
+			add("must be >= %v", *s.minimum)
+		}
+		if s.maximum != nil && x > *s.maximum {
+			add("must be <= %v", *s.maximum)
+		}
+		if s.exclMin != nil && x <= *s.exclMin {
+			add("must be > %v", *s.exclMin)
+		}
+		if s.exclMax != nil && x >= *s.exclMax {
+			add("must be < %v", *s.exclMax)
+		}
+	}
+
+	for _, sub := range s.allOf {
+		sub.validate(v, path, out)
+	}
+	if len(s.anyOf) > 0 && countMatches(s.anyOf, v) == 0 {
+		add("must match at least one schema in anyOf")
+	}
+	if len(s.oneOf) > 0 {
+		if n := countMatches(s.oneOf, v); n != 1 {
+			add("must match exactly one schema in oneOf (matched %d)", n)
+		}
+	}
+	if s.not != nil && countMatches([]*Schema{s.not}, v) == 1 {
+		add("must not match the schema in not")
+	}
+}
+
+func appendCapped(out []Violation, v Violation) []Violation {
+	if len(out) >= MaxViolations {
+		return out
+	}
+	return append(out, v)
+}
+
+func countMatches(list []*Schema, v any) int {
+	n := 0
+	for _, sub := range list {
+		var vs []Violation
+		sub.validate(v, "", &vs)
+		if len(vs) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func typeMatches(types []string, v any) bool {
+	for _, t := range types {
+		switch t {
+		case "object":
+			if _, ok := v.(map[string]any); ok {
+				return true
+			}
+		case "array":
+			if _, ok := v.([]any); ok {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := v.(float64); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := v.(float64); ok && f == math.Trunc(f) {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "null":
+			if v == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// normalize converte valores vindos do YAML (int, map[string]any aninhado) para a
+// forma do encoding/json, para comparar enum/const com o documento validado.
+func normalize(v any) any {
+	if f, ok := toFloat(v); ok {
+		return f
+	}
+	switch x := v.(type) {
+	case []any:
+		return normalizeAll(x)
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, e := range x {
+			m[k] = normalize(e)
+		}
+		return m
+	}
+	return v
+}
+
+func normalizeAll(arr []any) []any {
+	out := make([]any, len(arr))
+	for i, e := range arr {
+		out[i] = normalize(e)
+	}
+	return out
+}
+
+func equal(a, b any) bool {
+	ja, err1 := json.Marshal(a)
+	jb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ja) == string(jb)
+}
+
+func compact(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func asMap(v any) (map[string]any, bool) {
+	m, ok := v.(map[string]any)
+	return m, ok
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,93 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testSchema = `
+type: object
+required: [path, mode]
+additionalProperties: false
+properties:
+  path: {type: string, minLength: 1, pattern: "^[a-z/]+$"}
+  mode: {enum: [read, write]}
+  depth: {type: integer, minimum: 0, maximum: 5}
+  tags: {type: array, maxItems: 2, items: {type: string}}
+  target:
+    oneOf:
+      - {type: string}
+      - {type: object, required: [id]}
+`
+
+func compileYAML(t *testing.T, src string) *Schema {
+	t.Helper()
+	var raw map[string]any
+	if err := yaml.Unmarshal([]byte(src), &raw); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Compile(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestValidate(t *testing.T) {
+	s := compileYAML(t, testSchema)
+
+	cases := []struct {
+		doc  string
+		want []string // trechos esperados nas violações (vazio = válido)
+	}{
+		{`{"path":"a/b","mode":"read","depth":2,"tags":["x"],"target":"t"}`, nil},
+		{`{"path":"a","mode":"read","target":{"id":1}}`, nil},
+		{`{"mode":"exec"}`, []string{`/: missing required property "path"`, `/mode: must be one of ["read","write"]`}},
+		{`{"path":"A!","mode":"read","depth":1.5}`, []string{"/path: must match pattern", "/depth: expected integer, got number"}},
+		{`{"path":"a","mode":"read","depth":9,"tags":["a","b","c"],"x":1}`, []string{"/depth: must be <= 5", "/tags: must have at most 2 items", "/x: additional property not allowed"}},
+		{`{"path":"a","mode":"read","tags":[1]}`, []string{"/tags/0: expected string"}},
+		{`{"path":"a","mode":"read","target":{}}`, []string{"/target: must match exactly one schema in oneOf (matched 0)"}},
+		{`[]`, []string{"/: expected object, got array"}},
+	}
+	for _, c := range cases {
+		vs, err := s.Validate([]byte(c.doc))
+		if err != nil {
+			t.Fatalf("%s: %v", c.doc, err)
+		}
+		var got []string
+		for _, v := range vs {
+			got = append(got, v.String())
+		}
+		joined := strings.Join(got, "\n")
+		if len(c.want) == 0 && len(got) > 0 {
+			t.Fatalf("%s: unexpected violations:\n%s", c.doc, joined)
+		}
+		for _, w := range c.want {
+			if !strings.Contains(joined, w) {
+				t.Fatalf("%s: missing %q in:\n%s", c.doc, w, joined)
+			}
+		}
+		if len(got) != len(c.want) {
+			t.Fatalf("%s: expected %d violations, got:\n%s", c.doc, len(c.want), joined)
+		}
+	}
+}
+
+func TestCompile_RejectsInvalidSchemas(t *testing.T) {
+	for _, src := range []string{
+		`{$ref: "#/defs/x"}`,
+		`{type: thing}`,
+		`{properties: {a: {pattern: "("}}}`,
+		`{minLength: -1}`,
+	} {
+		var raw map[string]any
+		if err := yaml.Unmarshal([]byte(src), &raw); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Compile(raw); err == nil {
+			t.Fatalf("%s: expected compile error", src)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"mcp-router/internal/observability/metrics"
+)
+
+var busDropped = metrics.Default.Counter("mcp_gw_bus_events_dropped_total",
+	"Gateway events dropped because a subscriber (GET /admin/events) was too slow.")
+
+// Tipos dos eventos do gateway (Bus): ciclo de vida das requests e do processo.
+const (
+	TypeRequestStarted  = "request_started"
+	TypeRequestFinished = "request_finished"
+	TypeToolBusy        = "tool_busy"
+	TypeProcessKilled   = "process_killed"
+	TypeConfigReloaded  = "config_reloaded"
+)
+
+// Bus distribui os eventos do gateway para quem assina (GET /admin/events). Diferente
+// dos sinks por tool, não entrega garantida: assinante lento perde eventos.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[chan Event]struct{}
+}
+
+// Default é o bus do processo: sobrevive ao reload do config (o core é trocado).
+var Default = NewBus()
+
+// NewBus cria um bus sem assinantes.
+func NewBus() *Bus {
+	return &Bus{subs: map[chan Event]struct{}{}}
+}
+
+// Publish entrega e a cada assinante sem bloquear (fila cheia: descarta). Time vazio
+// vira agora.
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.subs) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			busDropped.Inc()
+		}
+	}
+}
+
+// Subscribe assina o bus com uma fila de buffer eventos. cancel encerra a assinatura
+// (e fecha o canal).
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Subscribers retorna quantos assinantes o bus tem.
+func (b *Bus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
@@ -0,0 +1,203 @@
+// Package events espelha execuções de tools (início, linhas de saída, fim) em sistemas
+// externos: webhook, Kafka (REST Proxy) e NATS, configurados por tool em tools[*].events.
+//
+// O core só conhece a interface Sink; qualquer destino novo implementa os três hooks
+// e entra via core.WithEventSink, sem mexer no caminho de execução.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/observability/metrics"
+)
+
+var (
+	eventsPublished = metrics.Default.CounterVec("mcp_gw_events_published_total",
+		"Execution events delivered to external sinks.", "tool", "sink")
+	eventsDropped = metrics.Default.CounterVec("mcp_gw_events_dropped_total",
+		"Execution events dropped because the sink queue was full.", "tool", "sink")
+	eventsFailed = metrics.Default.CounterVec("mcp_gw_events_failed_total",
+		"Execution events the sink failed to deliver.", "tool", "sink")
+)
+
+// Tipos de evento.
+const (
+	TypeStart = "start"
+	TypeLine  = "line"
+	TypeEnd   = "end"
+)
+
+// Event é a mensagem publicada nos sinks (o mesmo JSON em todos os destinos) e no Bus.
+type Event struct {
+	Type      string    `json:"type"` // start | line | end (sinks); Type* do Bus
+	Tool      string    `json:"tool"`
+	RequestID string    `json:"request_id,omitempty"`
+	Client    string    `json:"client,omitempty"`
+	Time      time.Time `json:"time"`
+
+	// line
+	Seq  int64  `json:"seq,omitempty"`
+	Line string `json:"line,omitempty"`
+
+	// end
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Lines      int64  `json:"lines,omitempty"`
+	Code       string `json:"code,omitempty"` // código do erro (core.ErrorCode); vazio = sucesso
+	Error      string `json:"error,omitempty"`
+
+	// config_reloaded (Bus)
+	Tools int `json:"tools,omitempty"`
+}
+
+// Sink recebe os eventos de uma execução. As chamadas vêm do caminho quente do stream:
+// implementações não podem bloquear (enfileirar e publicar em background).
+type Sink interface {
+	OnStart(Event)
+	OnLine(Event)
+	OnEnd(Event)
+}
+
+// Multi repassa os eventos para vários sinks.
+type Multi []Sink
+
+func (m Multi) OnStart(e Event) {
+	for _, s := range m {
+		s.OnStart(e)
+	}
+}
+
+func (m Multi) OnLine(e Event) {
+	for _, s := range m {
+		s.OnLine(e)
+	}
+}
+
+func (m Multi) OnEnd(e Event) {
+	for _, s := range m {
+		s.OnEnd(e)
+	}
+}
+
+// publisher entrega um evento serializado a um destino (síncrono, chamado pelo worker).
+type publisher interface {
+	publish(ctx context.Context, body []byte) error
+	close()
+}
+
+// publishTimeout limita cada entrega: destino travado não acumula workers.
+const publishTimeout = 10 * time.Second
+
+// asyncSink é o Sink dos destinos embutidos: fila limitada + um worker publicando em ordem.
+type asyncSink struct {
+	tool  string
+	kind  string
+	lines bool
+	pub   publisher
+	q     chan Event
+	done  chan struct{}
+
+	mu     sync.RWMutex // closed x enqueue (enviar em canal fechado é panic)
+	closed bool
+
+	failing bool // só o worker lê/escreve: loga só a transição ok -> falha
+}
+
+// New cria o sink de um item de tools[tool].events.
+func New(tool string, cfg config.EventSink) (Sink, error) {
+	var pub publisher
+	switch cfg.Type {
+	case config.EventSinkWebhook:
+		pub = newWebhook(cfg.URL, cfg.Headers)
+	case config.EventSinkKafka:
+		pub = newKafka(cfg.URL, cfg.TopicEffective(tool), tool, cfg.Headers)
+	case config.EventSinkNATS:
+		p, err := newNATS(cfg.URL, cfg.TopicEffective(tool))
+		if err != nil {
+			return nil, err
+		}
+		pub = p
+	default:
+		return nil, fmt.Errorf("events: unknown sink type %q", cfg.Type)
+	}
+	s := &asyncSink{
+		tool:  tool,
+		kind:  cfg.Type,
+		lines: cfg.LinesEnabled(),
+		pub:   pub,
+		q:     make(chan Event, cfg.BufferEffective()),
+		done:  make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *asyncSink) OnStart(e Event) { s.enqueue(e) }
+func (s *asyncSink) OnEnd(e Event)   { s.enqueue(e) }
+
+func (s *asyncSink) OnLine(e Event) {
+	if s.lines {
+		s.enqueue(e)
+	}
+}
+
+func (s *asyncSink) enqueue(e Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.q <- e:
+	default:
+		eventsDropped.With(s.tool, s.kind).Inc()
+	}
+}
+
+func (s *asyncSink) run() {
+	defer close(s.done)
+	for e := range s.q {
+		body, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err = s.pub.publish(ctx, body)
+		cancel()
+		if err != nil {
+			eventsFailed.With(s.tool, s.kind).Inc()
+			if !s.failing {
+				slog.Default().Warn("event sink delivery failed",
+					logging.Tool(s.tool), slog.String("sink", s.kind), logging.Err(err))
+			}
+			s.failing = true
+			continue
+		}
+		if s.failing {
+			slog.Default().Info("event sink recovered", logging.Tool(s.tool), slog.String("sink", s.kind))
+		}
+		s.failing = false
+		eventsPublished.With(s.tool, s.kind).Inc()
+	}
+}
+
+// Close para de aceitar eventos e espera a fila esvaziar (até ctx).
+func (s *asyncSink) Close(ctx context.Context) {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.q)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+	s.pub.close()
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+func emitAll(t *testing.T, s Sink) {
+	t.Helper()
+	s.OnStart(Event{Type: TypeStart, Tool: "echo", RequestID: "r1"})
+	s.OnLine(Event{Type: TypeLine, Tool: "echo", RequestID: "r1", Seq: 1, Line: `{"x":1}`})
+	s.OnEnd(Event{Type: TypeEnd, Tool: "echo", RequestID: "r1", Lines: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.(*asyncSink).Close(ctx)
+}
+
+func TestWebhookAndKafkaSinks(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = append(bodies[r.URL.Path], r.Header.Get("Content-Type")+" "+string(b))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	off := false
+	wh, err := New("echo", config.EventSink{Type: config.EventSinkWebhook, URL: srv.URL + "/hook", Lines: &off})
+	if err != nil {
+		t.Fatal(err)
+	}
+	emitAll(t, wh)
+	kf, err := New("echo", config.EventSink{Type: config.EventSinkKafka, URL: srv.URL + "/kafka/", Topic: "exec"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	emitAll(t, kf)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := bodies["/hook"]; len(got) != 2 || !strings.Contains(got[0], `"type":"start"`) || !strings.Contains(got[1], `"type":"end"`) {
+		t.Fatalf("webhook with lines: false must get only start/end: %q", got)
+	}
+	got := bodies["/kafka/topics/exec"]
+	if len(got) != 3 {
+		t.Fatalf("kafka events: %q", got)
+	}
+	ct, body, _ := strings.Cut(got[1], " ")
+	var rec struct {
+		Records []struct {
+			Key   string `json:"key"`
+			Value Event  `json:"value"`
+		} `json:"records"`
+	}
+	if ct != "application/vnd.kafka.json.v2+json" || json.Unmarshal([]byte(body), &rec) != nil ||
+		len(rec.Records) != 1 || rec.Records[0].Key != "echo" || rec.Records[0].Value.Line != `{"x":1}` {
+		t.Fatalf("kafka record: %s %s", ct, body)
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	msgs := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "PING"):
+				_, _ = conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := r.ReadString('\n')
+				msgs <- strings.Fields(line)[1] + " " + strings.TrimSpace(payload)
+			}
+		}
+	}()
+
+	s, err := New("echo", config.EventSink{Type: config.EventSinkNATS, URL: "nats://" + ln.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	emitAll(t, s)
+
+	for _, want := range []string{`"type":"start"`, `"type":"line"`, `"type":"end"`} {
+		select {
+		case m := <-msgs:
+			if !strings.HasPrefix(m, "mcp.events.echo ") || !strings.Contains(m, want) {
+				t.Fatalf("nats message %q, want %s on mcp.events.echo", m, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for %s", want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// httpPublisher faz um POST por evento (webhook e Kafka REST Proxy).
+type httpPublisher struct {
+	url         string
+	contentType string
+	headers     map[string]string
+	client      *http.Client
+	wrap        func([]byte) []byte // corpo final a partir do JSON do evento
+}
+
+func newWebhook(endpoint string, headers map[string]string) *httpPublisher {
+	return &httpPublisher{
+		url:         endpoint,
+		contentType: "application/json",
+		headers:     headers,
+		client:      &http.Client{},
+		wrap:        func(b []byte) []byte { return b },
+	}
+}
+
+// newKafka publica via Kafka REST Proxy v2 (POST /topics/<topic>), chaveado pela tool
+// para manter a ordem das execuções de uma tool na mesma partição.
+func newKafka(base, topic, tool string, headers map[string]string) *httpPublisher {
+	key, _ := json.Marshal(tool)
+	return &httpPublisher{
+		url:         strings.TrimRight(base, "/") + "/topics/" + url.PathEscape(topic),
+		contentType: "application/vnd.kafka.json.v2+json",
+		headers:     headers,
+		client:      &http.Client{},
+		wrap: func(b []byte) []byte {
+			out := make([]byte, 0, len(b)+len(key)+32)
+			out = append(out, `{"records":[{"key":`...)
+			out = append(out, key...)
+			out = append(out, `,"value":`...)
+			out = append(out, b...)
+			return append(out, "}]}"...)
+		},
+	}
+}
+
+func (p *httpPublisher) publish(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(p.wrap(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", p.contentType)
+	for k, v := range p.headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("POST %s: %s", p.url, resp.Status)
+	}
+	return nil
+}
+
+func (p *httpPublisher) close() {
+	p.client.CloseIdleConnections()
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsPublisher fala o protocolo texto do NATS (CONNECT/PUB/PING/PONG) direto no TCP:
+// só publica, então não precisa do client oficial. Reconecta na próxima publicação
+// quando a conexão cai.
+type natsPublisher struct {
+	addr    string
+	subject string
+	user    *url.Userinfo
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newNATS(raw, subject string) (*natsPublisher, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("events: invalid nats url %q", raw)
+	}
+	if strings.ContainsAny(subject, " \t\r\n") || subject == "" {
+		return nil, fmt.Errorf("events: invalid nats subject %q", subject)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &natsPublisher{addr: addr, subject: subject, user: u.User}, nil
+}
+
+func (p *natsPublisher) publish(ctx context.Context, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// uma tentativa extra com conexão nova: a anterior pode ter caído desde o último evento
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if p.conn == nil {
+			if err = p.connect(ctx); err != nil {
+				return err
+			}
+		}
+		if dl, ok := ctx.Deadline(); ok {
+			_ = p.conn.SetWriteDeadline(dl)
+		}
+		msg := make([]byte, 0, len(p.subject)+len(body)+32)
+		msg = fmt.Appendf(msg, "PUB %s %d\r\n", p.subject, len(body))
+		msg = append(msg, body...)
+		msg = append(msg, "\r\n"...)
+		if _, err = p.conn.Write(msg); err == nil {
+			return nil
+		}
+		p.drop()
+	}
+	return err
+}
+
+// connect abre a conexão (INFO -> CONNECT -> PING/PONG) e sobe o leitor que responde
+// aos PINGs do servidor (sem PONG o servidor derruba clientes ociosos).
+func (p *natsPublisher) connect(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("nats %s: unexpected greeting %q: %v", p.addr, strings.TrimSpace(line), err)
+	}
+	connect := `{"verbose":false,"pedantic":false,"name":"mcp-gw"`
+	if p.user != nil {
+		pass, _ := p.user.Password()
+		connect += fmt.Sprintf(`,"user":%q,"pass":%q`, p.user.Username(), pass)
+	}
+	connect += "}"
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		conn.Close()
+		return err
+	}
+	for {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		switch {
+		case strings.HasPrefix(line, "PONG"):
+			_ = conn.SetDeadline(time.Time{})
+			p.conn = conn
+			go p.readLoop(conn, r)
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			conn.Close()
+			return errors.New("nats: " + strings.TrimSpace(line))
+		}
+	}
+}
+
+func (p *natsPublisher) readLoop(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			break
+		}
+		if strings.HasPrefix(line, "PING") {
+			p.mu.Lock()
+			_, err = conn.Write([]byte("PONG\r\n"))
+			p.mu.Unlock()
+			if err != nil {
+				break
+			}
+		}
+	}
+	p.mu.Lock()
+	if p.conn == conn {
+		p.drop()
+	}
+	p.mu.Unlock()
+}
+
+// drop fecha a conexão atual (chamado com mu).
+func (p *natsPublisher) drop() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+		p.conn = nil
+	}
+}
+
+func (p *natsPublisher) close() {
+	p.mu.Lock()
+	p.drop()
+	p.mu.Unlock()
+}
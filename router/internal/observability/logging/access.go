@@ -0,0 +1,177 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Formatos do access log (uma linha por request, emitida quando a request termina).
+const (
+	AccessLogSlog     = "slog"     // default: linha Info "http access" no logger do gateway
+	AccessLogCombined = "combined" // texto estilo Apache combined (+ campos do gateway)
+	AccessLogOff      = "off"
+)
+
+// AccessLogOptions configura o access log do Middleware (e de quem ele aceita o IP do cliente).
+type AccessLogOptions struct {
+	Format string    // "" = AccessLogSlog
+	Out    io.Writer // destino do combined (nil = stderr)
+	// TrustedProxies: peers cujos CF-Connecting-IP/X-Forwarded-For valem (vazio = nenhum)
+	TrustedProxies []netip.Prefix
+}
+
+// accessInfo são os campos que só o handler conhece (tool, linhas streamadas,
+// identidade autenticada); o Middleware guarda um por request no ctx.
+type accessInfo struct {
+	mu        sync.Mutex
+	tool      string
+	identity  string
+	workspace string
+	lines     int64
+}
+
+// SetAccessTool registra a tool da request no access log.
+func SetAccessTool(ctx context.Context, tool string) {
+	if a, ok := ctx.Value(accessKey).(*accessInfo); ok {
+		a.mu.Lock()
+		a.tool = tool
+		a.mu.Unlock()
+	}
+}
+
+// SetAccessIdentity registra a identidade do cliente (default: o mesmo que client).
+func SetAccessIdentity(ctx context.Context, identity string) {
+	if a, ok := ctx.Value(accessKey).(*accessInfo); ok {
+		a.mu.Lock()
+		a.identity = identity
+		a.mu.Unlock()
+	}
+}
+
+// SetAccessWorkspace registra o workspace nomeado da request no access log.
+func SetAccessWorkspace(ctx context.Context, workspace string) {
+	if a, ok := ctx.Value(accessKey).(*accessInfo); ok {
+		a.mu.Lock()
+		a.workspace = workspace
+		a.mu.Unlock()
+	}
+}
+
+// AddAccessLines soma linhas de output entregues ao cliente (lines_out).
+func AddAccessLines(ctx context.Context, n int64) {
+	if a, ok := ctx.Value(accessKey).(*accessInfo); ok {
+		a.mu.Lock()
+		a.lines += n
+		a.mu.Unlock()
+	}
+}
+
+// accessRecorder conta status e bytes escritos sem esconder Flush/Unwrap (streams SSE).
+type accessRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (a *accessRecorder) WriteHeader(code int) {
+	if a.status == 0 {
+		a.status = code
+	}
+	a.ResponseWriter.WriteHeader(code)
+}
+
+func (a *accessRecorder) Write(b []byte) (int, error) {
+	if a.status == 0 {
+		a.status = http.StatusOK
+	}
+	n, err := a.ResponseWriter.Write(b)
+	a.bytes += int64(n)
+	return n, err
+}
+
+func (a *accessRecorder) Flush() {
+	if f, ok := a.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap expõe o ResponseWriter original (http.ResponseController: deadlines, full duplex).
+func (a *accessRecorder) Unwrap() http.ResponseWriter {
+	return a.ResponseWriter
+}
+
+// writeAccessLog emite a linha de resumo da request no formato pedido.
+func writeAccessLog(opts AccessLogOptions, r *http.Request, rec *accessRecorder, info *accessInfo, rid, client string, start time.Time) {
+	info.mu.Lock()
+	tool, identity, workspace, lines := info.tool, info.identity, info.workspace, info.lines
+	info.mu.Unlock()
+	if identity == "" {
+		identity = client
+	}
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK // handler não escreveu nada
+	}
+	dur := time.Since(start).Milliseconds()
+
+	if opts.Format != AccessLogCombined {
+		slog.Default().Info("http access",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			Tool(tool),
+			slog.Int("status", status),
+			slog.Int64("bytes_out", rec.bytes),
+			slog.Int64("lines_out", lines),
+			DurationMs(dur),
+			RequestID(rid),
+			slog.String("client_ip", client),
+			slog.String("identity", identity),
+			slog.String("workspace", workspace),
+		)
+		return
+	}
+
+	out := opts.Out
+	if out == nil {
+		out = os.Stderr
+	}
+	// host ident user [time] "request" status bytes "referer" "user-agent" + campos do gateway
+	_, _ = fmt.Fprintf(out, "%s - %s [%s] %s %d %d %s %s tool=%s lines_out=%d duration_ms=%d request_id=%s%s\n",
+		client,
+		dashIfEmpty(identity),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		strconv.Quote(r.Method+" "+r.URL.RequestURI()+" "+r.Proto),
+		status,
+		rec.bytes,
+		strconv.Quote(dashIfEmpty(r.Referer())),
+		strconv.Quote(dashIfEmpty(r.UserAgent())),
+		dashIfEmpty(tool),
+		lines,
+		dur,
+		rid,
+		workspaceField(workspace),
+	)
+}
+
+// workspaceField só aparece no combined quando a request escolheu um workspace nomeado.
+func workspaceField(ws string) string {
+	if ws == "" {
+		return ""
+	}
+	return " workspace=" + ws
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,214 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Captura por request (logging.capture no config): os registros com request_id ficam
+// num buffer limitado em memória, para /admin/requests/<id>/logs devolver a trilha
+// completa de uma execução sem grep na saída do servidor. Só entram registros que
+// passaram pelo nível (global ou da tool).
+
+// CapturedRecord é um registro de log capturado.
+type CapturedRecord struct {
+	Time  time.Time      `json:"time"`
+	Level string         `json:"level"`
+	Msg   string         `json:"msg"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// RequestLogs é a trilha capturada de uma request. Dropped conta os registros mais
+// antigos descartados pelo limite por request.
+type RequestLogs struct {
+	RequestID string           `json:"request_id"`
+	Records   []CapturedRecord `json:"records"`
+	Dropped   int              `json:"dropped,omitempty"`
+}
+
+var capture = &captureStore{byID: map[string]*RequestLogs{}}
+
+type captureStore struct {
+	mu       sync.Mutex
+	requests int // requests mantidas (0 = captura desligada)
+	records  int // registros por request
+	byID     map[string]*RequestLogs
+	order    []string // ids por ordem de chegada (a mais antiga sai primeiro)
+}
+
+// SetCapture liga a captura com até requests requests de records registros cada
+// (requests <= 0 desliga e descarta o que havia).
+func SetCapture(requests, records int) {
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	if requests <= 0 || records <= 0 {
+		requests, records = 0, 0
+	}
+	capture.requests, capture.records = requests, records
+	capture.byID = map[string]*RequestLogs{}
+	capture.order = nil
+}
+
+// CaptureEnabled diz se a captura por request está ligada.
+func CaptureEnabled() bool {
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	return capture.requests > 0
+}
+
+// CapturedLogs retorna uma cópia da trilha de rid (false: request desconhecida ou já
+// descartada).
+func CapturedLogs(rid string) (RequestLogs, bool) {
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	rl, ok := capture.byID[rid]
+	if !ok {
+		return RequestLogs{}, false
+	}
+	out := *rl
+	out.Records = append([]CapturedRecord(nil), rl.Records...)
+	return out, true
+}
+
+func (c *captureStore) add(rid string, rec CapturedRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.requests == 0 {
+		return
+	}
+	rl, ok := c.byID[rid]
+	if !ok {
+		for len(c.order) >= c.requests {
+			delete(c.byID, c.order[0])
+			c.order = c.order[1:]
+		}
+		rl = &RequestLogs{RequestID: rid}
+		c.byID[rid] = rl
+		c.order = append(c.order, rid)
+	}
+	if len(rl.Records) >= c.records {
+		rl.Records = append(rl.Records[:0], rl.Records[1:]...)
+		rl.Dropped++
+	}
+	rl.Records = append(rl.Records, rec)
+}
+
+// captureHandler copia para o captureStore os registros com request_id (do logger, do
+// próprio registro ou do ctx) e repassa tudo ao handler real.
+type captureHandler struct {
+	inner  slog.Handler
+	rid    string
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h *captureHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.inner.Enabled(ctx, l)
+}
+
+func (h *captureHandler) Handle(ctx context.Context, r slog.Record) error {
+	if CaptureEnabled() {
+		h.capture(ctx, r)
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *captureHandler) capture(ctx context.Context, r slog.Record) {
+	rid := h.rid
+	attrs := make(map[string]any, len(h.attrs)+r.NumAttrs())
+	for _, a := range h.attrs {
+		putAttr(attrs, a)
+	}
+	own := attrs
+	for _, g := range h.groups {
+		m, ok := own[g].(map[string]any)
+		if !ok {
+			m = map[string]any{}
+			own[g] = m
+		}
+		own = m
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "request_id" && len(h.groups) == 0 {
+			rid = a.Value.String()
+		}
+		putAttr(own, a)
+		return true
+	})
+	if rid == "" {
+		rid = RequestIDFromContext(ctx)
+	}
+	if rid == "" {
+		return
+	}
+	delete(attrs, "request_id")
+	capture.add(rid, CapturedRecord{Time: r.Time, Level: r.Level.String(), Msg: r.Message, Attrs: attrs})
+}
+
+func (h *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	nh.inner = h.inner.WithAttrs(attrs)
+	if len(h.groups) > 0 {
+		// atributos dentro de grupo: aninhados sob o caminho de grupos
+		g := slog.Group(h.groups[len(h.groups)-1], attrsToAny(attrs)...)
+		for i := len(h.groups) - 2; i >= 0; i-- {
+			g = slog.Group(h.groups[i], g)
+		}
+		nh.attrs = append(append([]slog.Attr(nil), h.attrs...), g)
+		return &nh
+	}
+	nh.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	for _, a := range attrs {
+		if a.Key == "request_id" {
+			nh.rid = a.Value.String()
+		}
+	}
+	return &nh
+}
+
+func (h *captureHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	nh := *h
+	nh.inner = h.inner.WithGroup(name)
+	nh.groups = append(append([]string(nil), h.groups...), name)
+	return &nh
+}
+
+// putAttr grava a em m (grupos viram mapas; erros, a mensagem).
+func putAttr(m map[string]any, a slog.Attr) {
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		g := map[string]any{}
+		for _, ga := range v.Group() {
+			putAttr(g, ga)
+		}
+		if a.Key == "" {
+			for k, gv := range g {
+				m[k] = gv
+			}
+			return
+		}
+		m[a.Key] = g
+		return
+	}
+	val := v.Any()
+	if err, ok := val.(error); ok {
+		val = err.Error()
+	}
+	m[a.Key] = val
+}
+
+func attrsToAny(attrs []slog.Attr) []any {
+	out := make([]any, len(attrs))
+	for i, a := range attrs {
+		out[i] = a
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestCaptureHandler_BoundedPerRequest(t *testing.T) {
+	SetCapture(2, 3)
+	t.Cleanup(func() { SetCapture(0, 0) })
+	log := slog.New(&levelHandler{inner: &captureHandler{inner: slog.NewTextHandler(io.Discard, nil)}})
+	SetLevel(slog.LevelInfo)
+
+	req := log.With(RequestID("r1"), Tool("echo"))
+	req.Info("started", slog.Int("pid", 42))
+	req.Debug("below level")
+	req.WithGroup("exit").Warn("tool failed", Err(errors.New("boom")))
+	log.InfoContext(WithRequestID(context.Background(), "r1"), "from ctx")
+	log.Info("no request")
+
+	got, ok := CapturedLogs("r1")
+	if !ok || len(got.Records) != 3 || got.Dropped != 0 {
+		t.Fatalf("r1: %+v", got)
+	}
+	if got.Records[0].Attrs["tool"] != "echo" || got.Records[0].Attrs["pid"] != int64(42) {
+		t.Fatalf("attrs: %v", got.Records[0].Attrs)
+	}
+	if _, ok := got.Records[0].Attrs["request_id"]; ok {
+		t.Fatalf("request_id must not repeat in attrs: %v", got.Records[0].Attrs)
+	}
+	if g, _ := got.Records[1].Attrs["exit"].(map[string]any); g["error"] != "boom" {
+		t.Fatalf("grouped error: %v", got.Records[1].Attrs)
+	}
+
+	// 4º registro descarta o mais antigo
+	req.Info("last")
+	got, _ = CapturedLogs("r1")
+	if len(got.Records) != 3 || got.Dropped != 1 || got.Records[2].Msg != "last" || got.Records[0].Msg != "tool failed" {
+		t.Fatalf("after overflow: %+v", got)
+	}
+
+	// 3ª request descarta a mais antiga
+	log.With(RequestID("r2")).Info("x")
+	log.With(RequestID("r3")).Info("y")
+	if _, ok := CapturedLogs("r1"); ok {
+		t.Fatal("oldest request must be evicted")
+	}
+	if _, ok := CapturedLogs("r3"); !ok {
+		t.Fatal("newest request must be kept")
+	}
+}
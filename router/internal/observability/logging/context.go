@@ -13,6 +13,9 @@ type ctxKey int
 const (
 	requestIDKey ctxKey = iota
 	loggerKey
+	clientKey
+	accessKey
+	workspaceKey
 )
 
 func WithRequestID(ctx context.Context, id string) context.Context {
@@ -58,3 +61,28 @@ func LoggerFromContext(ctx context.Context) *slog.Logger {
 	}
 	return slog.Default()
 }
+
+// WithClient grava a identidade do cliente (IP ou identidade autenticada) no ctx.
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey, client)
+}
+
+func ClientFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(clientKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// WithWorkspace grava o workspace nomeado da request (header X-MCP-Workspace) no ctx.
+func WithWorkspace(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, workspaceKey, name)
+}
+
+// WorkspaceFromContext retorna o workspace nomeado da request ("" = workspace_root).
+func WorkspaceFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(workspaceKey).(string); ok {
+		return v
+	}
+	return ""
+}
@@ -21,6 +21,11 @@ func RequestID(id string) slog.Attr {
 	return slog.String("request_id", id)
 }
 
+// Client identifica o cliente (IP ou identidade autenticada).
+func Client(id string) slog.Attr {
+	return slog.String("client", id)
+}
+
 // DurationMs representa duração em milissegundos.
 // Use sempre duration_ms (não misturar com duration_ns/s).
 func DurationMs(ms int64) slog.Attr {
@@ -0,0 +1,213 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// level é o nível global do logger do gateway (alterável em runtime: /admin/loglevel).
+var level = new(slog.LevelVar)
+
+// toolLevels sobrepõe o nível global para os registros de uma tool (log_level no config).
+var (
+	toolMu     sync.RWMutex
+	toolLevels = map[string]slog.Level{}
+)
+
+// componentLevels sobrepõe o nível global para os registros emitidos por um pacote do
+// gateway (logging.components no config: transport, core, runner, runtime). O componente
+// sai do pacote de quem chamou o logger (r.PC), então os call sites não mudam.
+var (
+	compMu          sync.RWMutex
+	componentLevels = map[string]slog.Level{}
+	componentMin    *slog.Level // menor nível entre os componentes (nil = nenhum)
+	componentByPC   sync.Map    // uintptr -> string
+)
+
+// ParseLevel aceita debug, info, warn e error (sem diferenciar maiúsculas).
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		l = slog.LevelDebug
+	case "info":
+		l = slog.LevelInfo
+	case "warn", "warning":
+		l = slog.LevelWarn
+	case "error":
+		l = slog.LevelError
+	default:
+		return 0, fmt.Errorf("invalid log level %q (want debug, info, warn or error)", s)
+	}
+	return l, nil
+}
+
+// SetLevel troca o nível global.
+func SetLevel(l slog.Level) { level.Set(l) }
+
+// Level retorna o nível global.
+func Level() slog.Level { return level.Level() }
+
+// SetToolLevel define o nível dos registros com tool=name.
+func SetToolLevel(name string, l slog.Level) {
+	toolMu.Lock()
+	toolLevels[name] = l
+	toolMu.Unlock()
+}
+
+// ClearToolLevel volta a tool para o nível global.
+func ClearToolLevel(name string) {
+	toolMu.Lock()
+	delete(toolLevels, name)
+	toolMu.Unlock()
+}
+
+// ToolLevels retorna um snapshot dos níveis por tool.
+func ToolLevels() map[string]slog.Level {
+	toolMu.RLock()
+	defer toolMu.RUnlock()
+	out := make(map[string]slog.Level, len(toolLevels))
+	for k, v := range toolLevels {
+		out[k] = v
+	}
+	return out
+}
+
+// SetComponentLevels troca os níveis por componente (nil ou vazio = só o global).
+func SetComponentLevels(levels map[string]slog.Level) {
+	compMu.Lock()
+	defer compMu.Unlock()
+	componentLevels = make(map[string]slog.Level, len(levels))
+	componentMin = nil
+	for k, v := range levels {
+		componentLevels[k] = v
+		if componentMin == nil || v < *componentMin {
+			l := v
+			componentMin = &l
+		}
+	}
+}
+
+// ComponentLevels retorna um snapshot dos níveis por componente.
+func ComponentLevels() map[string]slog.Level {
+	compMu.RLock()
+	defer compMu.RUnlock()
+	out := make(map[string]slog.Level, len(componentLevels))
+	for k, v := range componentLevels {
+		out[k] = v
+	}
+	return out
+}
+
+// componentLevel retorna o nível do componente que emitiu o registro (pc), se houver.
+func componentLevel(pc uintptr) (slog.Level, bool) {
+	compMu.RLock()
+	defer compMu.RUnlock()
+	if len(componentLevels) == 0 || pc == 0 {
+		return 0, false
+	}
+	l, ok := componentLevels[componentOf(pc)]
+	return l, ok
+}
+
+// minComponentLevel é o menor nível configurado entre os componentes.
+func minComponentLevel() (slog.Level, bool) {
+	compMu.RLock()
+	defer compMu.RUnlock()
+	if componentMin == nil {
+		return 0, false
+	}
+	return *componentMin, true
+}
+
+func componentOf(pc uintptr) string {
+	if c, ok := componentByPC.Load(pc); ok {
+		return c.(string)
+	}
+	f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	c := componentFromFunc(f.Function)
+	componentByPC.Store(pc, c)
+	return c
+}
+
+// componentFromFunc extrai o componente do nome qualificado da função: o primeiro
+// diretório depois de internal/ ("mcp-router/internal/transport.(*HTTP).Run" -> transport).
+func componentFromFunc(fn string) string {
+	_, rest, ok := strings.Cut(fn, "/internal/")
+	if !ok {
+		return ""
+	}
+	if i := strings.IndexAny(rest, "/."); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest
+}
+
+func toolLevel(name string) (slog.Level, bool) {
+	toolMu.RLock()
+	defer toolMu.RUnlock()
+	l, ok := toolLevels[name]
+	return l, ok
+}
+
+// levelHandler decide o nível (tool, senão componente, senão global) antes do handler
+// real: loggers derivados com .With(Tool(name)) lembram a tool e usam o nível dela, se
+// houver. O componente só é conhecido no Handle (r.PC): Enabled deixa passar até o menor
+// nível dos componentes e o Handle descarta o que não vale para o componente do registro.
+type levelHandler struct {
+	inner slog.Handler
+	tool  string
+}
+
+func (h *levelHandler) Enabled(_ context.Context, l slog.Level) bool {
+	if h.tool != "" {
+		if tl, ok := toolLevel(h.tool); ok {
+			return l >= tl
+		}
+	}
+	floor := level.Level()
+	if cl, ok := minComponentLevel(); ok && cl < floor {
+		floor = cl
+	}
+	return l >= floor
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.toolOverride() {
+		floor, ok := componentLevel(r.PC)
+		if !ok {
+			floor = level.Level()
+		}
+		if r.Level < floor {
+			return nil
+		}
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+// toolOverride diz se o nível da tool do logger decide (Enabled já filtrou).
+func (h *levelHandler) toolOverride() bool {
+	if h.tool == "" {
+		return false
+	}
+	_, ok := toolLevel(h.tool)
+	return ok
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	tool := h.tool
+	for _, a := range attrs {
+		if a.Key == "tool" {
+			tool = a.Value.String()
+		}
+	}
+	return &levelHandler{inner: h.inner.WithAttrs(attrs), tool: tool}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{inner: h.inner.WithGroup(name), tool: h.tool}
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler_ToolOverridesGlobal(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(&levelHandler{inner: slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})})
+	SetLevel(slog.LevelInfo)
+	SetToolLevel("noisy", slog.LevelDebug)
+	t.Cleanup(func() { ClearToolLevel("noisy") })
+
+	log.Debug("global debug")
+	log.With(Tool("quiet")).Debug("quiet debug")
+	log.With(Tool("noisy")).Debug("noisy debug")
+	log.With(Tool("noisy")).WithGroup("g").Debug("noisy grouped debug")
+
+	out := buf.String()
+	if strings.Contains(out, "global debug") || strings.Contains(out, "quiet debug") {
+		t.Fatalf("debug must stay off outside the overridden tool: %q", out)
+	}
+	if !strings.Contains(out, "noisy debug") || !strings.Contains(out, "noisy grouped debug") {
+		t.Fatalf("tool override must enable debug for that tool: %q", out)
+	}
+
+	// nível global alterado em runtime vale para loggers já criados
+	quiet := log.With(Tool("quiet"))
+	SetLevel(slog.LevelDebug)
+	t.Cleanup(func() { SetLevel(slog.LevelInfo) })
+	quiet.Debug("quiet after change")
+	if !strings.Contains(buf.String(), "quiet after change") {
+		t.Fatalf("runtime level change must apply to existing loggers: %q", buf.String())
+	}
+}
+
+func TestComponentFromFunc(t *testing.T) {
+	cases := map[string]string{
+		"mcp-router/internal/transport.(*HTTP).handleMCP":      "transport",
+		"mcp-router/internal/core.(*Service).run.func1":        "core",
+		"mcp-router/internal/observability/logging.Middleware": "observability",
+		"log.Printf": "",
+		"github.com/spf13/cobra.(*Command).ExecuteC": "",
+	}
+	for fn, want := range cases {
+		if got := componentFromFunc(fn); got != want {
+			t.Errorf("componentFromFunc(%q) = %q, want %q", fn, got, want)
+		}
+	}
+}
+
+func TestLevelHandler_ComponentLevels(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(&levelHandler{inner: slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})})
+	SetLevel(slog.LevelWarn)
+	t.Cleanup(func() { SetLevel(slog.LevelInfo); SetComponentLevels(nil) })
+
+	// os registros deste teste vêm de internal/observability
+	SetComponentLevels(map[string]slog.Level{"observability": slog.LevelDebug, "core": slog.LevelError})
+	log.Debug("component debug")
+	SetComponentLevels(map[string]slog.Level{"core": slog.LevelDebug})
+	log.Info("other component info")
+	log.Warn("other component warn")
+
+	// tool vence o componente
+	SetComponentLevels(map[string]slog.Level{"observability": slog.LevelDebug})
+	SetToolLevel("strict", slog.LevelError)
+	t.Cleanup(func() { ClearToolLevel("strict") })
+	log.With(Tool("strict")).Warn("tool warn")
+
+	out := buf.String()
+	if !strings.Contains(out, "component debug") {
+		t.Fatalf("component level must enable debug for its records: %q", out)
+	}
+	if strings.Contains(out, "other component info") || !strings.Contains(out, "other component warn") {
+		t.Fatalf("records outside the configured components must use the global level: %q", out)
+	}
+	if strings.Contains(out, "tool warn") {
+		t.Fatalf("tool level must override the component level: %q", out)
+	}
+	if got := ComponentLevels(); len(got) != 1 || got["observability"] != slog.LevelDebug {
+		t.Fatalf("ComponentLevels() = %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
@@ -15,23 +16,31 @@ const (
 type Config struct {
 	Mode  Mode
 	Level slog.Level
+	Out   io.Writer // nil = stderr (ex: *RotatingFile para logging.output: file)
 }
 
 func New(cfg Config) *slog.Logger {
 	var handler slog.Handler
 
+	// o nível é decidido pelo levelHandler (global + por tool, alteráveis em runtime)
+	level.Set(cfg.Level)
 	opts := &slog.HandlerOptions{
-		Level: cfg.Level,
+		Level: slog.LevelDebug,
+	}
+
+	out := cfg.Out
+	if out == nil {
+		out = os.Stderr
 	}
 
 	switch cfg.Mode {
 	case ModeText:
-		handler = slog.NewTextHandler(os.Stderr, opts)
+		handler = slog.NewTextHandler(out, opts)
 	default:
-		handler = slog.NewJSONHandler(os.Stderr, opts)
+		handler = slog.NewJSONHandler(out, opts)
 	}
 
-	logger := slog.New(handler)
+	logger := slog.New(&levelHandler{inner: &captureHandler{inner: handler}})
 	slog.SetDefault(logger)
 
 	return logger
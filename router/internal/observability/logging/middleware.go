@@ -2,14 +2,27 @@ package logging
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"net/netip"
+	"strings"
+	"time"
 )
 
 // Middleware injeta request_id e logger no context da request.
 // - request_id vem do header X-Request-Id (se existir) ou é gerado.
 // - logger é slog.Default() (ou o que você setou com logging.New()) com request_id.
+// - ao fim da request emite o access log (formato slog; ver MiddlewareWithAccessLog).
 func Middleware(next http.Handler) http.Handler {
+	return MiddlewareWithAccessLog(next, AccessLogOptions{})
+}
+
+// MiddlewareWithAccessLog é o Middleware com o formato do access log escolhido
+// (AccessLogSlog, AccessLogCombined ou AccessLogOff).
+func MiddlewareWithAccessLog(next http.Handler, opts AccessLogOptions) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
 		// Se o cliente mandou um request id, aproveita; senão gera.
 		// (Header canonical: X-Request-Id / X-Request-ID. Vamos aceitar ambas.)
 		hid := r.Header.Get("X-Request-Id")
@@ -31,6 +44,9 @@ func Middleware(next http.Handler) http.Handler {
 			ctx = newCtx
 		}
 
+		client := clientIP(r, opts.TrustedProxies)
+		ctx = WithClient(ctx, client)
+
 		// Injeta logger request-scoped (sem tool/runtime aqui; esses entram nos handlers)
 		log := LoggerFromContext(ctx).With(RequestID(rid))
 		ctx = WithLogger(ctx, log)
@@ -38,6 +54,76 @@ func Middleware(next http.Handler) http.Handler {
 		// (Opcional) ecoar request id de volta ajuda debug com proxies/tunnel
 		w.Header().Set("X-Request-Id", rid)
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+		if opts.Format == AccessLogOff {
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		info := &accessInfo{}
+		ctx = context.WithValue(ctx, accessKey, info)
+		rec := &accessRecorder{ResponseWriter: w}
+		defer func() {
+			writeAccessLog(opts, r, rec, info, rid, client, start)
+		}()
+		next.ServeHTTP(rec, r.WithContext(ctx))
 	})
 }
+
+// ClientIP é o IP do cliente resolvido pelo Middleware; fora dele, o peer da conexão.
+func ClientIP(r *http.Request) string {
+	if c := ClientFromContext(r.Context()); c != "" {
+		return c
+	}
+	return peerIP(r)
+}
+
+// clientIP resolve o IP do cliente: o peer da conexão (RemoteAddr). Só quando o peer é um
+// proxy confiável valem CF-Connecting-IP e X-Forwarded-For (headers do cliente: qualquer
+// um forja). No X-Forwarded-For vale o último salto que não é proxy confiável.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	peer := peerIP(r)
+	if !trustedProxy(peer, trusted) {
+		return peer
+	}
+	if v := strings.TrimSpace(r.Header.Get("CF-Connecting-IP")); v != "" {
+		if ip, err := netip.ParseAddr(v); err == nil {
+			return ip.Unmap().String()
+		}
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		if !trustedProxy(ip.String(), trusted) {
+			return ip.Unmap().String()
+		}
+	}
+	return peer
+}
+
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func trustedProxy(ip string, trusted []netip.Prefix) bool {
+	if len(trusted) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestMiddleware_ClientIsPeerAddress(t *testing.T) {
+	var got string
+	h := MiddlewareWithAccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = ClientFromContext(r.Context())
+	}), AccessLogOptions{Format: AccessLogOff})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.10:5555"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("CF-Connecting-IP", "203.0.113.8")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "192.0.2.10" {
+		t.Fatalf("client = %q, want the peer address (forwarded headers are client-controlled)", got)
+	}
+}
+
+func TestMiddleware_TrustedProxyHeaders(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	cases := []struct {
+		name, peer, cf, xff, want string
+	}{
+		{"untrusted peer ignores headers", "192.0.2.10:1", "203.0.113.8", "203.0.113.7", "192.0.2.10"},
+		{"trusted peer, cf header", "10.1.2.3:1", "203.0.113.8", "203.0.113.7", "203.0.113.8"},
+		{"trusted peer, xff", "10.1.2.3:1", "", "203.0.113.7", "203.0.113.7"},
+		{"xff: last untrusted hop wins", "10.1.2.3:1", "", "198.51.100.1, 203.0.113.7, 10.9.9.9", "203.0.113.7"},
+		{"xff: garbage falls back to peer", "10.1.2.3:1", "", "not-an-ip", "10.1.2.3"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			h := MiddlewareWithAccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			}), AccessLogOptions{Format: AccessLogOff, TrustedProxies: trusted})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.peer
+			if tc.cf != "" {
+				req.Header.Set("CF-Connecting-IP", tc.cf)
+			}
+			req.Header.Set("X-Forwarded-For", tc.xff)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tc.want {
+				t.Fatalf("client = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
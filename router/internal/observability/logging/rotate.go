@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RotatingFile é um io.Writer em arquivo com rotação por tamanho: quando a próxima
+// escrita passaria de maxBytes, path vira path.1 (path.1 vira path.2, ...) e um path
+// novo é aberto. Só maxBackups arquivos antigos são mantidos.
+type RotatingFile struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenRotatingFile abre (ou cria, em append) o arquivo de log em path.
+func OpenRotatingFile(path string, maxBytes int64, maxBackups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("log dir: %w", err)
+	}
+	r := &RotatingFile{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	r.f, r.size = f, st.Size()
+	return nil
+}
+
+// Write grava p inteiro no arquivo atual (um registro nunca é partido entre arquivos).
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate desloca os backups (o mais antigo cai fora) e reabre path vazio.
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	r.f = nil
+
+	_ = os.Remove(backupName(r.path, r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(backupName(r.path, i), backupName(r.path, i+1))
+	}
+	if err := os.Rename(r.path, backupName(r.path, 1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	return r.open()
+}
+
+// Close fecha o arquivo; escritas seguintes falham com os.ErrClosed.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
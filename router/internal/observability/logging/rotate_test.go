@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile_RotatesBySizeAndKeepsMaxBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "gw.log")
+	rf, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	// cada registro tem 6 bytes: cabe um por arquivo (o segundo passaria de 10)
+	for _, rec := range []string{"aaaaa\n", "bbbbb\n", "ccccc\n", "ddddd\n"} {
+		if _, err := rf.Write([]byte(rec)); err != nil {
+			t.Fatalf("write %q: %v", rec, err)
+		}
+	}
+
+	for name, want := range map[string]string{
+		path:        "ddddd\n",
+		path + ".1": "ccccc\n",
+		path + ".2": "bbbbb\n",
+	} {
+		got, err := os.ReadFile(name)
+		if err != nil || string(got) != want {
+			t.Fatalf("%s = %q (%v), want %q", filepath.Base(name), got, err, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("only max_backups files must be kept, found %s.3", filepath.Base(path))
+	}
+
+	// reabrir continua no mesmo arquivo (append), sem rotacionar antes da hora
+	_ = rf.Close()
+	rf2, err := OpenRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf2.Close()
+	_, _ = rf2.Write([]byte("eeeee\n"))
+	if got, _ := os.ReadFile(path); !strings.HasPrefix(string(got), "ddddd\n") {
+		t.Fatalf("reopen must append, got %q", got)
+	}
+}
@@ -0,0 +1,315 @@
+// Package metrics implementa contadores/gauges/histogramas mínimos no formato texto do Prometheus.
+//
+// Sem dependências externas: o gateway só precisa expor alguns números operacionais
+// (pressão de buffer, filas, execuções) em /metrics.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter é um contador monotônico.
+type Counter struct{ v atomic.Int64 }
+
+func (c *Counter) Inc()         { c.v.Add(1) }
+func (c *Counter) Add(n int64)  { c.v.Add(n) }
+func (c *Counter) Value() int64 { return c.v.Load() }
+
+// Gauge é um valor que sobe e desce.
+type Gauge struct{ v atomic.Int64 }
+
+func (g *Gauge) Set(n int64)  { g.v.Store(n) }
+func (g *Gauge) Add(n int64)  { g.v.Add(n) }
+func (g *Gauge) Inc()         { g.v.Add(1) }
+func (g *Gauge) Dec()         { g.v.Add(-1) }
+func (g *Gauge) Value() int64 { return g.v.Load() }
+
+// CounterVec é um contador com labels (uma série por combinação de valores).
+type CounterVec struct {
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*Counter
+}
+
+// With retorna o contador da combinação de labels (na ordem declarada).
+func (v *CounterVec) With(values ...string) *Counter {
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.series[key]
+	if !ok {
+		c = &Counter{}
+		v.series[key] = c
+	}
+	return c
+}
+
+// GaugeVec é um gauge com labels (uma série por combinação de valores).
+type GaugeVec struct {
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*Gauge
+}
+
+// With retorna o gauge da combinação de labels (na ordem declarada).
+func (v *GaugeVec) With(values ...string) *Gauge {
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	g, ok := v.series[key]
+	if !ok {
+		g = &Gauge{}
+		v.series[key] = g
+	}
+	return g
+}
+
+// Histogram conta observações em buckets cumulativos (le), mais soma e total.
+type Histogram struct {
+	bounds []float64
+
+	mu     sync.Mutex
+	counts []int64 // por bucket (não cumulativo); o último é o +Inf
+	sum    float64
+	count  int64
+}
+
+// Observe registra uma observação.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v) // 1º bucket com bound >= v
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.sum += v
+	h.count++
+}
+
+// HistogramVec é um histograma com labels (todas as séries com os mesmos buckets).
+type HistogramVec struct {
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*Histogram
+}
+
+// With retorna o histograma da combinação de labels (na ordem declarada).
+func (v *HistogramVec) With(values ...string) *Histogram {
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	h, ok := v.series[key]
+	if !ok {
+		h = &Histogram{bounds: v.buckets, counts: make([]int64, len(v.buckets)+1)}
+		v.series[key] = h
+	}
+	return h
+}
+
+type metric struct {
+	name string
+	help string
+	kind string // counter | gauge | histogram
+	val  func() int64
+	vec  seriesWriter // métricas com labels
+}
+
+// seriesWriter escreve as séries de uma métrica com labels.
+type seriesWriter interface {
+	writeText(w io.Writer, name string) error
+}
+
+// Registry agrupa as métricas expostas em /metrics.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// Default é o registry do processo (usado pelo endpoint /metrics).
+var Default = NewRegistry()
+
+func (r *Registry) add(m *metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.metrics[m.name]; dup {
+		panic("metrics: duplicate metric " + m.name)
+	}
+	r.metrics[m.name] = m
+}
+
+func (r *Registry) Counter(name, help string) *Counter {
+	c := &Counter{}
+	r.add(&metric{name: name, help: help, kind: "counter", val: c.Value})
+	return c
+}
+
+func (r *Registry) Gauge(name, help string) *Gauge {
+	g := &Gauge{}
+	r.add(&metric{name: name, help: help, kind: "gauge", val: g.Value})
+	return g
+}
+
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{labels: labels, series: make(map[string]*Counter)}
+	r.add(&metric{name: name, help: help, kind: "counter", vec: v})
+	return v
+}
+
+func (r *Registry) GaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{labels: labels, series: make(map[string]*Gauge)}
+	r.add(&metric{name: name, help: help, kind: "gauge", vec: v})
+	return v
+}
+
+// HistogramVec registra um histograma com os buckets (limites superiores, crescentes).
+func (r *Registry) HistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	v := &HistogramVec{labels: labels, buckets: buckets, series: make(map[string]*Histogram)}
+	r.add(&metric{name: name, help: help, kind: "histogram", vec: v})
+	return v
+}
+
+// WriteText escreve todas as métricas no formato de exposição texto (ordenadas por nome).
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for n := range r.metrics {
+		names = append(names, n)
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	for _, n := range names {
+		r.mu.Lock()
+		m := r.metrics[n]
+		r.mu.Unlock()
+
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind); err != nil {
+			return err
+		}
+		if m.vec == nil {
+			if _, err := fmt.Fprintf(w, "%s %d\n", m.name, m.val()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := m.vec.writeText(w, m.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *CounterVec) writeText(w io.Writer, name string) error {
+	return writeSeries(w, name, v.labels, &v.mu, v.series, (*Counter).Value)
+}
+
+func (v *GaugeVec) writeText(w io.Writer, name string) error {
+	return writeSeries(w, name, v.labels, &v.mu, v.series, (*Gauge).Value)
+}
+
+func (v *HistogramVec) writeText(w io.Writer, name string) error {
+	v.mu.Lock()
+	keys := sortedKeys(v.series)
+	hs := make([]*Histogram, len(keys))
+	for i, k := range keys {
+		hs[i] = v.series[k]
+	}
+	v.mu.Unlock()
+
+	for i, k := range keys {
+		h := hs[i]
+		h.mu.Lock()
+		counts := append([]int64(nil), h.counts...)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+
+		pairs := labelPairs(v.labels, k)
+		var cum int64
+		for j, c := range counts {
+			cum += c
+			le := "+Inf"
+			if j < len(h.bounds) {
+				le = strconv.FormatFloat(h.bounds[j], 'g', -1, 64)
+			}
+			bucket := append(pairs[:len(pairs):len(pairs)], fmt.Sprintf("le=%q", le))
+			if _, err := fmt.Fprintf(w, "%s_bucket{%s} %d\n", name, strings.Join(bucket, ","), cum); err != nil {
+				return err
+			}
+		}
+		lbl := ""
+		if len(pairs) > 0 {
+			lbl = "{" + strings.Join(pairs, ",") + "}"
+		}
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", name, lbl, strconv.FormatFloat(sum, 'g', -1, 64), name, lbl, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSeries escreve uma linha por série (ordenadas pela combinação de labels). Os
+// valores são lidos sob mu e escritos depois, sem segurar o lock durante o I/O.
+func writeSeries[T any](w io.Writer, name string, labels []string, mu *sync.Mutex, series map[string]T, val func(T) int64) error {
+	mu.Lock()
+	keys := sortedKeys(series)
+	items := make([]T, len(keys))
+	for i, k := range keys {
+		items[i] = series[k]
+	}
+	mu.Unlock()
+
+	for i, k := range keys {
+		if _, err := fmt.Fprintf(w, "%s{%s} %d\n", name, strings.Join(labelPairs(labels, k), ","), val(items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelPairs monta os pares name="value" da chave de uma série.
+func labelPairs(labels []string, key string) []string {
+	values := strings.Split(key, "\xff")
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		val := ""
+		if i < len(values) {
+			val = values[i]
+		}
+		pairs[i] = fmt.Sprintf("%s=%q", l, val)
+	}
+	return pairs
+}
+
+// Handler expõe o registry em formato texto do Prometheus.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteText(w)
+	})
+}
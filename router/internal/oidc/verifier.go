@@ -0,0 +1,303 @@
+// Package oidc valida bearer tokens (JWT RS256) de um provedor de identidade OIDC: a
+// assinatura pelas chaves públicas do JWKS e as claims iss, aud, exp, nbf e iat. Só o
+// necessário para o gateway dispensar um proxy de autenticação na frente; não há fluxo
+// de login nem discovery.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+// ErrInvalidToken é qualquer token recusado (formato, assinatura, emissor, audiência ou
+// validade); a causa vai na mensagem, para o log.
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+const (
+	// fetchTimeout limita o download do JWKS.
+	fetchTimeout = 10 * time.Second
+	// maxJWKSBytes limita o corpo do JWKS.
+	maxJWKSBytes = 1 << 20
+	// minRefetch espaça as tentativas de download depois da primeira (kid desconhecido,
+	// cache vencido), com sucesso ou não: tokens com kid inventado não viram um download
+	// por request.
+	minRefetch = 30 * time.Second
+)
+
+// Claims são as claims do payload do token.
+type Claims map[string]any
+
+// Subject é a claim sub.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Strings lê uma claim de lista: array de strings ou string separada por espaço (como
+// scope). ok=false quando a claim não existe.
+func (c Claims) Strings(name string) (out []string, ok bool) {
+	v, ok := c[name]
+	if !ok {
+		return nil, false
+	}
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v), true
+	case []any:
+		for _, item := range v {
+			if s, isStr := item.(string); isStr {
+				out = append(out, s)
+			}
+		}
+		return out, true
+	}
+	return nil, true
+}
+
+// Verifier valida tokens de um emissor. As chaves do JWKS ficam em cache por
+// jwks_refresh_ms e são baixadas de novo antes disso quando chega um kid desconhecido.
+// O download roda fora do lock e um por vez: as validações com chave em cache não
+// esperam por ele.
+type Verifier struct {
+	cfg    config.OIDC
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetched   time.Time  // último download com sucesso
+	attempted time.Time  // última tentativa (minRefetch)
+	inflight  *jwksFetch // download em andamento (nil = nenhum)
+}
+
+// jwksFetch é um download do JWKS; quem precisa do resultado espera done.
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// New cria o verificador (o JWKS é baixado na primeira validação).
+func New(cfg config.OIDC) *Verifier {
+	return &Verifier{cfg: cfg, client: &http.Client{Timeout: fetchTimeout}, now: time.Now}
+}
+
+// LooksLikeJWT diz se o bearer tem o formato de um JWT (três partes base64url), para o
+// transport separar tokens OIDC de chaves de API.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2 && !strings.ContainsAny(token, " \t")
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify confere assinatura e claims e retorna as claims do token.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	if h.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, h.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	key, err := v.key(ctx, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig); err != nil {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrInvalidToken, err)
+	}
+	if err := v.checkClaims(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (v *Verifier) checkClaims(c Claims) error {
+	if iss, _ := c["iss"].(string); iss != v.cfg.Issuer {
+		return fmt.Errorf("%w: issuer %q", ErrInvalidToken, iss)
+	}
+	if aud, _ := c.Strings("aud"); !slices.Contains(aud, v.cfg.Audience) {
+		return fmt.Errorf("%w: audience %v", ErrInvalidToken, aud)
+	}
+	now, skew := v.now(), v.cfg.ClockSkew()
+	exp, ok := numericDate(c["exp"])
+	if !ok {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(exp.Add(skew)) {
+		return fmt.Errorf("%w: expired at %s", ErrInvalidToken, exp.UTC().Format(time.RFC3339))
+	}
+	if nbf, ok := numericDate(c["nbf"]); ok && now.Add(skew).Before(nbf) {
+		return fmt.Errorf("%w: not valid before %s", ErrInvalidToken, nbf.UTC().Format(time.RFC3339))
+	}
+	if iat, ok := numericDate(c["iat"]); ok && now.Add(skew).Before(iat) {
+		return fmt.Errorf("%w: issued in the future", ErrInvalidToken)
+	}
+	return nil
+}
+
+// key retorna a chave do kid, baixando o JWKS quando o cache venceu ou não tem o kid.
+// Chave em cache vencido continua valendo enquanto o download novo corre (e se ele
+// falhar: provedor fora do ar); kid desconhecido espera o download, se houver um.
+func (v *Verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	now := v.now()
+	k, known := v.lookupLocked(kid)
+	if known && now.Sub(v.fetched) < v.cfg.JWKSRefresh() {
+		v.mu.Unlock()
+		return k, nil
+	}
+	f := v.inflight
+	if f == nil && (v.attempted.IsZero() || now.Sub(v.attempted) >= minRefetch) {
+		f = &jwksFetch{done: make(chan struct{})}
+		v.inflight, v.attempted = f, now
+		go v.refresh(f, now)
+	}
+	v.mu.Unlock()
+
+	if known {
+		return k, nil
+	}
+	if f == nil {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidToken, kid)
+	}
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: jwks: %v", ErrInvalidToken, ctx.Err())
+	}
+
+	v.mu.Lock()
+	k, known = v.lookupLocked(kid)
+	v.mu.Unlock()
+	switch {
+	case known:
+		return k, nil
+	case f.err != nil:
+		return nil, fmt.Errorf("%w: jwks: %v", ErrInvalidToken, f.err)
+	}
+	return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidToken, kid)
+}
+
+// refresh baixa o JWKS (sem o ctx de quem disparou: o resultado serve a todos) e troca
+// as chaves em cache quando dá certo.
+func (v *Verifier) refresh(f *jwksFetch, started time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	keys, err := v.fetch(ctx)
+
+	v.mu.Lock()
+	if err == nil {
+		v.keys, v.fetched = keys, started
+	}
+	f.err = err
+	v.inflight = nil
+	v.mu.Unlock()
+	close(f.done)
+}
+
+// lookupLocked acha a chave do kid; sem kid no token, vale a única chave do JWKS.
+func (v *Verifier) lookupLocked(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			return k, true
+		}
+	}
+	k, ok := v.keys[kid]
+	return k, ok
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (v *Verifier) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+		n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+		e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+		if err1 != nil || err2 != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RS256 keys")
+	}
+	return keys, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func numericDate(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
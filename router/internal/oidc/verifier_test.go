@@ -0,0 +1,206 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	input := enc(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + enc(claims)
+	sum := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func jwks(keys map[string]*rsa.PrivateKey) []byte {
+	var set []map[string]string
+	for kid, k := range keys {
+		set = append(set, map[string]string{
+			"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		})
+	}
+	b, _ := json.Marshal(map[string]any{"keys": set})
+	return b
+}
+
+func TestVerifier(t *testing.T) {
+	k1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	k2, _ := rsa.GenerateKey(rand.Reader, 2048)
+	published := map[string]*rsa.PrivateKey{"k1": k1}
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write(jwks(published))
+	}))
+	defer srv.Close()
+
+	v := New(config.OIDC{Issuer: "https://idp.example", Audience: "mcp-gw", JWKSURL: srv.URL})
+	now := time.Unix(1_800_000_000, 0)
+	v.now = func() time.Time { return now }
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss": "https://idp.example", "aud": []string{"other", "mcp-gw"}, "sub": "alice",
+			"exp": now.Add(time.Hour).Unix(), "iat": now.Unix(), "mcp.tools": "fs_* git",
+		}
+	}
+	ctx := context.Background()
+
+	c, err := v.Verify(ctx, sign(t, k1, "k1", valid()))
+	if err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+	tools, ok := c.Strings("mcp.tools")
+	if c.Subject() != "alice" || !ok || len(tools) != 2 || tools[0] != "fs_*" {
+		t.Fatalf("claims: sub=%q tools=%v", c.Subject(), tools)
+	}
+
+	bad := map[string]func(map[string]any){
+		"issuer":   func(c map[string]any) { c["iss"] = "https://evil.example" },
+		"audience": func(c map[string]any) { c["aud"] = "other" },
+		"expired":  func(c map[string]any) { c["exp"] = now.Add(-2 * time.Minute).Unix() },
+		"no exp":   func(c map[string]any) { delete(c, "exp") },
+		"nbf":      func(c map[string]any) { c["nbf"] = now.Add(5 * time.Minute).Unix() },
+	}
+	for name, mutate := range bad {
+		claims := valid()
+		mutate(claims)
+		if _, err := v.Verify(ctx, sign(t, k1, "k1", claims)); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", name, err)
+		}
+	}
+
+	// dentro da tolerância de relógio
+	claims := valid()
+	claims["exp"] = now.Add(-30 * time.Second).Unix()
+	if _, err := v.Verify(ctx, sign(t, k1, "k1", claims)); err != nil {
+		t.Fatalf("within clock skew: %v", err)
+	}
+
+	// assinado por outra chave com o kid publicado
+	if _, err := v.Verify(ctx, sign(t, k2, "k1", valid())); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("forged signature accepted: %v", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("jwks fetched %d times, want 1 (cached)", n)
+	}
+
+	// rotação: kid novo força um download (espaçado por minRefetch)
+	published["k2"] = k2
+	if _, err := v.Verify(ctx, sign(t, k2, "k2", valid())); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refetch should wait minRefetch: %v", err)
+	}
+	now = now.Add(minRefetch)
+	if _, err := v.Verify(ctx, sign(t, k2, "k2", valid())); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+}
+
+func TestVerifier_SlowJWKSDoesNotBlock(t *testing.T) {
+	k1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	set := jwks(map[string]*rsa.PrivateKey{"k1": k1})
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release // provedor lento a partir do segundo download
+		}
+		_, _ = w.Write(set)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	v := New(config.OIDC{Issuer: "https://idp.example", Audience: "mcp-gw", JWKSURL: srv.URL})
+	var now atomic.Int64
+	now.Store(1_800_000_000)
+	v.now = func() time.Time { return time.Unix(now.Load(), 0) }
+	claims := map[string]any{"iss": "https://idp.example", "aud": "mcp-gw", "sub": "alice", "exp": now.Load() + 3600}
+
+	ctx := context.Background()
+	if _, err := v.Verify(ctx, sign(t, k1, "k1", claims)); err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+
+	// kids desconhecidos disparam um único download, que trava no provedor
+	now.Add(int64(minRefetch / time.Second))
+	errs := make(chan error, 5)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, err := v.Verify(ctx, sign(t, k1, "unknown", claims))
+			errs <- err
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for fetches.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// enquanto isso, a chave em cache continua validando sem esperar
+	start := time.Now()
+	if _, err := v.Verify(ctx, sign(t, k1, "k1", claims)); err != nil {
+		t.Fatalf("cached key during refetch: %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("cached key waited %s for the jwks download", d)
+	}
+
+	// quem desiste (ctx) não fica preso no download
+	cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := v.Verify(cctx, sign(t, k1, "other", claims)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("cancelled wait: err = %v", err)
+	}
+
+	release <- struct{}{}
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("unknown kid: err = %v", err)
+		}
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("jwks fetched %d times, want 2 (one refetch shared by all unknown kids)", n)
+	}
+
+	// novo kid desconhecido logo depois: sem download até passar minRefetch
+	if _, err := v.Verify(ctx, sign(t, k1, "again", claims)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("unknown kid: err = %v", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("refetch within minRefetch: %d fetches", n)
+	}
+}
+
+func TestVerifier_RejectsOtherAlgorithms(t *testing.T) {
+	v := New(config.OIDC{Issuer: "i", Audience: "a", JWKSURL: "http://127.0.0.1:1"})
+	enc := base64.RawURLEncoding.EncodeToString
+	for _, alg := range []string{"none", "HS256"} {
+		token := enc([]byte(`{"alg":"`+alg+`"}`)) + "." + enc([]byte(`{"iss":"i","aud":"a"}`)) + "."
+		if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("alg %s: err = %v", alg, err)
+		}
+	}
+}
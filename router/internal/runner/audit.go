@@ -0,0 +1,151 @@
+package runner
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Motivos de um processo deixado para trás (Leftover.Reason).
+const (
+	LeftoverInGroup = "left_in_group" // membro do process group da tool vivo após o Close
+	LeftoverEscaped = "escaped_group" // neto que saiu do grupo (setsid) e foi herdado pelo gateway
+)
+
+// auditGrace é quanto esperamos após o Close antes de cobrar o grupo (SIGKILL ainda em voo).
+const auditGrace = 2 * time.Second
+
+// closedGroupTTL é por quanto tempo lembramos o grupo de uma tool encerrada.
+const closedGroupTTL = 10 * time.Minute
+
+// Leftover é um processo que sobreviveu à tool que o criou.
+type Leftover struct {
+	PID    int    `json:"pid"`
+	PGID   int    `json:"pgid"`
+	Tool   string `json:"tool,omitempty"`
+	Reason string `json:"reason"`
+	Zombie bool   `json:"zombie,omitempty"` // já morto, só faltava o wait (reaped pela auditoria)
+	Killed bool   `json:"killed,omitempty"`
+}
+
+// procEntry é um processo do host (lido de /proc).
+type procEntry struct {
+	PID, PPID, PGID int
+	Zombie          bool
+}
+
+// procAudit rastreia os process groups das tools (Setpgid: pgid == pid do processo raiz).
+type procAudit struct {
+	mu       sync.Mutex
+	live     map[int]string // pgid -> tool (entre o spawn e o Close)
+	closed   map[int]closedGroup
+	suspects map[int]bool // candidatos a escaped da varredura anterior
+}
+
+type closedGroup struct {
+	tool string
+	at   time.Time
+}
+
+func newProcAudit() *procAudit {
+	return &procAudit{live: make(map[int]string), closed: make(map[int]closedGroup), suspects: make(map[int]bool)}
+}
+
+func (a *procAudit) track(pgid int, tool string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.live[pgid] = tool
+	delete(a.closed, pgid)
+}
+
+// release marca o grupo como encerrado; o que sobrar nele após auditGrace é leftover.
+func (a *procAudit) release(pgid int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	tool, ok := a.live[pgid]
+	if !ok {
+		return
+	}
+	delete(a.live, pgid)
+	a.closed[pgid] = closedGroup{tool: tool, at: time.Now()}
+}
+
+// scan classifica os processos. Candidatos a escaped só contam na segunda varredura
+// seguida: um spawn entre o Start e o track não pode virar falso positivo.
+func (a *procAudit) scan(procs []procEntry, self, selfPgid int, now time.Time) []Leftover {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for pgid, g := range a.closed {
+		if now.Sub(g.at) > closedGroupTTL {
+			delete(a.closed, pgid)
+		}
+	}
+
+	var out []Leftover
+	suspects := make(map[int]bool)
+	for _, p := range procs {
+		if p.PID == self {
+			continue
+		}
+		if g, ok := a.closed[p.PGID]; ok {
+			if now.Sub(g.at) >= auditGrace {
+				out = append(out, Leftover{PID: p.PID, PGID: p.PGID, Tool: g.tool, Reason: LeftoverInGroup, Zombie: p.Zombie})
+			}
+			continue
+		}
+		// filhos diretos fora do nosso grupo e fora dos grupos das tools: herdados (subreaper)
+		if p.PPID != self || p.PGID == selfPgid {
+			continue
+		}
+		if _, ok := a.live[p.PGID]; ok {
+			continue
+		}
+		if a.suspects[p.PID] {
+			out = append(out, Leftover{PID: p.PID, PGID: p.PGID, Reason: LeftoverEscaped, Zombie: p.Zombie})
+		}
+		suspects[p.PID] = true
+	}
+	a.suspects = suspects
+
+	sort.Slice(out, func(i, j int) bool { return out[i].PID < out[j].PID })
+	return out
+}
+
+// AuditProcesses procura processos que sobraram de tools encerradas. Zumbis filhos do
+// gateway são sempre reaped; os vivos só recebem SIGKILL com kill=true.
+func (r *Runner) AuditProcesses(kill bool) ([]Leftover, error) {
+	procs, err := listProcs()
+	if err != nil {
+		return nil, err
+	}
+	self := os.Getpid()
+	selfPgid := processGroup(self)
+
+	out := r.audit.scan(procs, self, selfPgid, time.Now())
+	for i := range out {
+		l := &out[i]
+		if l.Zombie {
+			reapZombie(l.PID)
+			continue
+		}
+		if kill && killLeftover(l.PID) == nil {
+			l.Killed = true
+		}
+	}
+	return out, nil
+}
+
+// EnableSubreaper faz os netos órfãos das tools serem herdados pelo gateway (e não pelo
+// init), para a auditoria enxergá-los e recolher os zumbis. Só Linux; no-op nos demais.
+func (r *Runner) EnableSubreaper() error {
+	return setChildSubreaper()
+}
+
+// liveGroups conta os process groups de tools ainda não encerrados (spawn sem Close).
+func (a *procAudit) liveGroups() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.live)
+}
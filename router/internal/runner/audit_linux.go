@@ -0,0 +1,75 @@
+//go:build linux
+
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+const prSetChildSubreaper = 36 // PR_SET_CHILD_SUBREAPER
+
+func setChildSubreaper() error {
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+		return fmt.Errorf("prctl(PR_SET_CHILD_SUBREAPER): %w", errno)
+	}
+	return nil
+}
+
+// listProcs lê pid/ppid/pgrp/estado de /proc/<pid>/stat.
+func listProcs() ([]procEntry, error) {
+	dirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var out []procEntry
+	for _, d := range dirs {
+		pid, err := strconv.Atoi(d.Name())
+		if err != nil {
+			continue
+		}
+		b, err := os.ReadFile("/proc/" + d.Name() + "/stat")
+		if err != nil {
+			continue // saiu durante a varredura
+		}
+		if p, ok := parseProcStat(pid, b); ok {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// parseProcStat interpreta "pid (comm) state ppid pgrp ..."; comm pode ter espaços e
+// parênteses, então os campos começam após o último ')'.
+func parseProcStat(pid int, b []byte) (procEntry, bool) {
+	i := bytes.LastIndexByte(b, ')')
+	if i < 0 {
+		return procEntry{}, false
+	}
+	f := bytes.Fields(b[i+1:])
+	if len(f) < 3 {
+		return procEntry{}, false
+	}
+	ppid, err1 := strconv.Atoi(string(f[1]))
+	pgid, err2 := strconv.Atoi(string(f[2]))
+	if err1 != nil || err2 != nil {
+		return procEntry{}, false
+	}
+	return procEntry{PID: pid, PPID: ppid, PGID: pgid, Zombie: string(f[0]) == "Z"}, true
+}
+
+func reapZombie(pid int) {
+	var ws syscall.WaitStatus
+	_, _ = syscall.Wait4(pid, &ws, syscall.WNOHANG, nil)
+}
+
+// processGroup é o pgid de pid (0 se não der para ler).
+func processGroup(pid int) int {
+	pgid, _ := syscall.Getpgid(pid)
+	return pgid
+}
+
+func killLeftover(pid int) error { return syscall.Kill(pid, syscall.SIGKILL) }
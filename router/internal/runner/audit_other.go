@@ -0,0 +1,17 @@
+//go:build !linux
+
+package runner
+
+import "errors"
+
+var errAuditUnsupported = errors.New("process audit is only supported on linux")
+
+func setChildSubreaper() error { return nil }
+
+func listProcs() ([]procEntry, error) { return nil, errAuditUnsupported }
+
+func reapZombie(pid int) { _ = pid }
+
+func processGroup(pid int) int { _ = pid; return 0 }
+
+func killLeftover(pid int) error { _ = pid; return errAuditUnsupported }
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProcAudit_FindsLeftoversInGroupAndEscapedChildren(t *testing.T) {
+	const self, selfPgid = 100, 100
+	a := newProcAudit()
+	a.track(200, "git") // encerrada (abaixo)
+	a.track(300, "npm") // em andamento
+	a.release(200)
+
+	procs := []procEntry{
+		{PID: self, PPID: 1, PGID: selfPgid},
+		{PID: 150, PPID: self, PGID: selfPgid}, // docker ps/pull do próprio gateway
+		{PID: 201, PPID: 1, PGID: 200},         // filho da tool encerrada que ignorou SIGTERM
+		{PID: 200, PPID: self, PGID: 200, Zombie: true},
+		{PID: 301, PPID: 300, PGID: 300},  // tool em andamento
+		{PID: 400, PPID: self, PGID: 400}, // neto que fez setsid e foi herdado
+	}
+
+	// dentro do auditGrace nada do grupo fechado conta; o escaped ainda é só suspeito
+	if got := a.scan(procs, self, selfPgid, time.Now()); len(got) != 0 {
+		t.Fatalf("expected no leftovers on the first pass, got %+v", got)
+	}
+
+	got := a.scan(procs, self, selfPgid, time.Now().Add(auditGrace))
+	want := []Leftover{
+		{PID: 200, PGID: 200, Tool: "git", Reason: LeftoverInGroup, Zombie: true},
+		{PID: 201, PGID: 200, Tool: "git", Reason: LeftoverInGroup},
+		{PID: 400, PGID: 400, Reason: LeftoverEscaped},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("leftovers = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("leftover[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
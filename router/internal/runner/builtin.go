@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"mcp-router/internal/builtin"
+	"mcp-router/internal/config"
+)
+
+// builtinHandler executa a tool dentro do gateway: input JSON -> resultado JSON (uma linha).
+// emit entrega linhas intermediárias (ex: _echo com repeat); resultado nil = sem linha final.
+type builtinHandler func(ctx context.Context, input []byte, emit func(any) error) (any, error)
+
+// builtinProcess implementa Process para tools builtin (mesmo contrato do launcher):
+// - Stdin acumula o input; ao fechar, executa o handler
+// - Stdout entrega o resultado (e cada emit) como uma linha JSON
+// - Close cancela (handlers são rápidos; só garante que Wait não trave)
+type builtinProcess struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	handle builtinHandler
+
+	in     bytes.Buffer
+	inOnce sync.Once
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	done    chan struct{}
+	waitErr error
+}
+
+func startBuiltin(ctx context.Context, handle builtinHandler) *builtinProcess {
+	bctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	return &builtinProcess{
+		ctx:    bctx,
+		cancel: cancel,
+		handle: handle,
+		pr:     pr,
+		pw:     pw,
+		done:   make(chan struct{}),
+	}
+}
+
+func (p *builtinProcess) Stdin() io.WriteCloser { return builtinStdin{p} }
+func (p *builtinProcess) Stdout() io.ReadCloser { return p.pr }
+func (p *builtinProcess) Stderr() io.ReadCloser { return http.NoBody }
+
+func (p *builtinProcess) Wait() error {
+	<-p.done
+	return p.waitErr
+}
+
+func (p *builtinProcess) Close() error {
+	p.cancel()
+	p.inOnce.Do(func() {
+		_ = p.pw.CloseWithError(context.Canceled)
+		close(p.done)
+	})
+	// o handler pode estar bloqueado escrevendo no pipe sem leitor
+	_ = p.pr.CloseWithError(context.Canceled)
+	<-p.done
+	return nil
+}
+
+type builtinStdin struct{ p *builtinProcess }
+
+func (s builtinStdin) Write(b []byte) (int, error) { return s.p.in.Write(b) }
+
+func (s builtinStdin) Close() error {
+	s.p.inOnce.Do(func() { go s.p.run() })
+	return nil
+}
+
+func (p *builtinProcess) run() {
+	defer close(p.done)
+
+	res, err := p.handle(p.ctx, bytes.TrimSpace(p.in.Bytes()), p.emit)
+	if err == nil && res != nil {
+		err = p.emit(res)
+	}
+	p.waitErr = err
+	_ = p.pw.CloseWithError(err)
+}
+
+// emit escreve v como uma linha JSON no stdout do processo.
+func (p *builtinProcess) emit(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = p.pw.Write(append(b, '\n'))
+	return err
+}
+
+type identityKey struct{}
+
+// WithIdentity grava quem responde pela request (core.Identity): o kv separa o estado por
+// ela.
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext retorna a identidade gravada por WithIdentity ("" = local/stdio).
+func IdentityFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(identityKey{}).(string)
+	return id
+}
+
+// builtinFor resolve o handler da tool builtin. O estado (ex: kv) é por tool e vive no Runner.
+func (r *Runner) builtinFor(toolName string, tool config.Tool) (builtinHandler, error) {
+	switch tool.Builtin {
+	case "kv":
+		r.mu.Lock()
+		kv, ok := r.kvs[toolName]
+		if !ok {
+			kv = builtin.NewKV(tool.KV)
+			r.kvs[toolName] = kv
+		}
+		r.mu.Unlock()
+
+		return func(ctx context.Context, input []byte, _ func(any) error) (any, error) {
+			// namespace por identidade (principal ou IP do peer) + sessão informada no input
+			return kv.Handle(IdentityFromContext(ctx), input)
+		}, nil
+	case "echo":
+		return func(ctx context.Context, input []byte, emit func(any) error) (any, error) {
+			return nil, builtin.Echo(ctx, input, emit)
+		}, nil
+	case "sleep":
+		return func(ctx context.Context, input []byte, _ func(any) error) (any, error) {
+			return builtin.Sleep(ctx, input)
+		}, nil
+	case "env":
+		return func(ctx context.Context, _ []byte, _ func(any) error) (any, error) {
+			return builtin.Env(ctx, toolName, SessionIDFromContext(ctx)), nil
+		}, nil
+	case "version":
+		return func(context.Context, []byte, func(any) error) (any, error) {
+			return builtin.Version(), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown builtin tool: %q", tool.Builtin)
+	}
+}
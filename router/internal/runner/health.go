@@ -0,0 +1,245 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+)
+
+// Estados de saúde de uma tool com health check.
+const (
+	HealthUnknown   = "unknown" // nenhuma sessão checada ainda
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// maxHealthResponse limita quanto da resposta do ping guardamos para comparar com expect.
+const maxHealthResponse = 64 * 1024
+
+// PoolHealth é o estado de saúde das sessões reutilizadas de uma tool (/readyz, /admin/tools).
+type PoolHealth struct {
+	Tool                string    `json:"tool"`
+	State               string    `json:"state"`
+	Idle                int       `json:"idle"`
+	Restarts            int       `json:"restarts"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastCheck           time.Time `json:"last_check,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	Restarting          bool      `json:"restarting,omitempty"`
+}
+
+// PoolHealth lista a saúde das tools com health configurado (ordenado por nome).
+// Tools cujo pool ainda não subiu (nenhuma request) aparecem como unknown.
+func (r *Runner) PoolHealth() []PoolHealth {
+	var out []PoolHealth
+	for name, t := range r.cfg.Tools {
+		if t.Health == nil {
+			continue
+		}
+		r.mu.Lock()
+		p := r.pools[name]
+		r.mu.Unlock()
+		if p == nil {
+			out = append(out, PoolHealth{Tool: name, State: HealthUnknown})
+			continue
+		}
+		out = append(out, p.healthSnapshot())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tool < out[j].Tool })
+	return out
+}
+
+func (p *containerPool) healthSnapshot() PoolHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := p.hstate
+	h.Tool = p.toolName
+	h.Idle = len(p.idle)
+	if h.State == "" {
+		h.State = HealthUnknown
+	}
+	return h
+}
+
+// healthLoop checa as sessões ociosas a cada health.interval_ms até o pool fechar.
+func (p *containerPool) healthLoop() {
+	t := time.NewTicker(p.health.Interval())
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			p.checkIdle()
+		}
+	}
+}
+
+// checkIdle tira as sessões ociosas do pool, checa cada uma e devolve as saudáveis.
+// As que falham são derrubadas e uma substituta sobe em background (com backoff).
+func (p *containerPool) checkIdle() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	for _, s := range idle {
+		s.timer.Stop()
+	}
+	p.mu.Unlock()
+
+	if len(idle) == 0 {
+		return
+	}
+
+	var failed error
+	for _, s := range idle {
+		if err := p.check(s); err != nil {
+			s.log.Warn("container session failed health check",
+				logging.String("container", s.info.ContainerID), logging.Err(err))
+			s.kill("unhealthy")
+			failed = err
+			continue
+		}
+		p.reidle(s)
+	}
+
+	p.mu.Lock()
+	p.hstate.LastCheck = time.Now()
+	if failed == nil {
+		p.hstate.State = HealthHealthy
+		p.hstate.ConsecutiveFailures = 0
+		p.hstate.LastError = ""
+		p.mu.Unlock()
+		return
+	}
+	p.markFailedLocked(failed)
+	restart := !p.hstate.Restarting && !p.closed
+	p.hstate.Restarting = p.hstate.Restarting || restart
+	p.mu.Unlock()
+
+	if restart {
+		go p.restart()
+	}
+}
+
+func (p *containerPool) markFailedLocked(err error) {
+	p.hstate.State = HealthUnhealthy
+	p.hstate.ConsecutiveFailures++
+	p.hstate.LastError = err.Error()
+}
+
+// restart sobe uma sessão substituta, tentando de novo com backoff exponencial
+// (intervalo, 2x, 4x... até MaxHealthRestartDelay) enquanto a nova sessão não passar no check.
+func (p *containerPool) restart() {
+	log := slog.Default().With(logging.Tool(p.toolName), logging.Runtime(p.tool.Runtime))
+	delay := p.health.Interval()
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-p.stop:
+				p.endRestart()
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+			if delay > config.MaxHealthRestartDelay {
+				delay = config.MaxHealthRestartDelay
+			}
+		}
+
+		s, err := p.spawn(log)
+		if err == nil {
+			if err = p.check(s); err != nil {
+				s.kill("unhealthy")
+			}
+		}
+
+		p.mu.Lock()
+		p.hstate.LastCheck = time.Now()
+		if err != nil {
+			p.markFailedLocked(err)
+			p.mu.Unlock()
+			log.Warn("container session restart failed", logging.Int("attempt", attempt+1), logging.Err(err))
+			continue
+		}
+		p.hstate.State = HealthHealthy
+		p.hstate.ConsecutiveFailures = 0
+		p.hstate.LastError = ""
+		p.hstate.Restarts++
+		p.hstate.Restarting = false
+		p.mu.Unlock()
+
+		log.Info("container session restarted", logging.String("container", s.info.ContainerID), logging.Int("attempt", attempt+1))
+		p.reidle(s)
+		return
+	}
+}
+
+func (p *containerPool) endRestart() {
+	p.mu.Lock()
+	p.hstate.Restarting = false
+	p.mu.Unlock()
+}
+
+// reidle devolve ao pool uma sessão que não atendeu request (health check/restart):
+// não conta em max_requests, mas respeita max_age e o shutdown.
+func (p *containerPool) reidle(s *session) {
+	p.mu.Lock()
+	if p.closed || time.Since(s.born) >= p.reuse.MaxAge() {
+		reason := "max_age"
+		if p.closed {
+			reason = "shutdown"
+		}
+		p.mu.Unlock()
+		s.kill(reason)
+		return
+	}
+	s.timer = time.AfterFunc(p.reuse.MaxIdle(), func() { p.expire(s) })
+	p.idle = append(p.idle, s)
+	p.mu.Unlock()
+}
+
+// check verifica a liveness da sessão e, com health.ping, a resposta dentro do timeout.
+func (p *containerPool) check(s *session) error {
+	if !s.alive() {
+		return errors.New("process exited")
+	}
+	if p.health.Ping == "" {
+		return nil
+	}
+
+	var resp bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- s.exchange([]byte(p.health.Ping), func(line []byte) error {
+			if resp.Len() < maxHealthResponse {
+				resp.Write(line)
+			}
+			return nil
+		})
+	}()
+
+	timer := time.NewTimer(p.health.Timeout())
+	defer timer.Stop()
+	var err error
+	select {
+	case err = <-done:
+	case <-timer.C:
+		s.kill("health_timeout") // fecha o stdout: exchange retorna
+		<-done
+		return fmt.Errorf("health ping timed out after %s", p.health.Timeout())
+	}
+	if err != nil {
+		return fmt.Errorf("health ping: %w", err)
+	}
+	if p.health.Expect != "" && !bytes.Contains(resp.Bytes(), []byte(p.health.Expect)) {
+		return fmt.Errorf("health ping response does not contain %q", p.health.Expect)
+	}
+	return nil
+}
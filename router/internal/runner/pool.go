@@ -0,0 +1,389 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/runtime"
+)
+
+// Reuso de containers (tool.reuse): cada sessão é um `docker run -i` que fica vivo entre
+// requests. Uma request escreve uma linha de input e recebe as linhas de saída até a linha
+// com "done": true; a sessão então volta para o pool. Sessões com resposta incompleta
+// (cancelamento, timeout, limite de saída) são descartadas: não há como ressincronizar.
+
+// sessionExitGrace é quanto esperamos o container sair pelo EOF antes do kill.
+const sessionExitGrace = 500 * time.Millisecond
+
+// containerPool guarda as sessões ociosas de uma tool. O número de sessões é limitado
+// pelo max_concurrent da tool (o semáforo do core vem antes do Start).
+type containerPool struct {
+	cfg      *config.Config
+	toolName string
+	tool     config.Tool
+	reuse    config.Reuse
+	health   *config.Health // nil = sem health check
+	audit    *procAudit
+	proxy    string // HTTP_PROXY das sessões (egress_allow; "" = sem proxy)
+
+	mu     sync.Mutex
+	idle   []*session
+	closed bool
+	hstate PoolHealth    // protegido por mu
+	stop   chan struct{} // fecha no close (encerra healthLoop/restart)
+}
+
+// session é um processo vivo com stdin/stdout anexados (container do reuse ou sessão daemon).
+type session struct {
+	pool *containerPool
+	cmd  *exec.Cmd
+	info Info
+
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	closers []io.Closer // pipes de leitura (fechados após a saída; não usamos cmd.Wait)
+
+	// exited fecha quando o processo termina (liveness sem bloquear leituras do stdout)
+	exited chan struct{}
+	state  *os.ProcessState
+
+	born     time.Time
+	requests int
+	timer    *time.Timer // idle timer (protegido por pool.mu)
+
+	killOnce sync.Once
+	log      *slog.Logger
+}
+
+func (r *Runner) poolFor(toolName string, tool config.Tool) *containerPool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.pools[toolName]; ok {
+		return p
+	}
+	p := &containerPool{cfg: r.cfg, toolName: toolName, tool: tool, reuse: *tool.Reuse, health: tool.Health, audit: r.audit, stop: make(chan struct{})}
+	r.pools[toolName] = p
+	if p.health != nil {
+		go p.healthLoop()
+	}
+	return p
+}
+
+// get devolve uma sessão ociosa válida ou sobe uma nova.
+func (p *containerPool) get(log *slog.Logger) (*session, error) {
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		s := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		s.timer.Stop()
+		if time.Since(s.born) < p.reuse.MaxAge() {
+			p.mu.Unlock()
+			log.Debug("reusing container session", logging.String("container", s.info.ContainerID), logging.Int("requests", s.requests))
+			return s, nil
+		}
+		go s.kill("max_age")
+	}
+	p.mu.Unlock()
+
+	return p.spawn(log)
+}
+
+func (p *containerPool) spawn(log *slog.Logger) (*session, error) {
+	rt, err := runtime.FromTool(p.tool)
+	if err != nil {
+		return nil, err
+	}
+
+	// ctx de fundo: a sessão sobrevive à request que a criou
+	cmd, stdin, stdout, stderr, err := rt.Spawn(runtime.WithProxy(context.Background(), p.proxy), p.cfg, p.tool)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &session{
+		pool:    p,
+		cmd:     cmd,
+		info:    Info{PID: cmd.Process.Pid, ContainerID: runtime.ContainerName(cmd)},
+		stdin:   stdin,
+		stdout:  bufio.NewReaderSize(stdout, 64*1024),
+		closers: []io.Closer{stdout, stderr},
+		exited:  make(chan struct{}),
+		born:    time.Now(),
+		log:     slog.Default().With(logging.Tool(p.toolName), logging.Runtime(p.tool.Runtime)),
+	}
+	p.audit.track(s.info.PID, p.toolName)
+	go func() {
+		// Process.Wait (e não cmd.Wait): reaping sem fechar o stdout que ainda pode ter dados
+		s.state, _ = cmd.Process.Wait()
+		close(s.exited)
+	}()
+	go func() {
+		// stderr da sessão vai para o log do gateway (não há request dona dele)
+		sc := bufio.NewScanner(stderr)
+		sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for sc.Scan() {
+			s.log.Debug("tool stderr", slog.String("stderr", sc.Text()), slog.String("container", s.info.ContainerID))
+		}
+	}()
+
+	log.Info("tool session started", logging.Int("pid", s.info.PID), logging.String("container", s.info.ContainerID))
+	return s, nil
+}
+
+// put devolve a sessão ao pool (ou a recicla por max_requests/max_age).
+func (p *containerPool) put(s *session) {
+	s.requests++
+
+	reason := ""
+	switch {
+	case p.reuse.MaxRequests > 0 && s.requests >= p.reuse.MaxRequests:
+		reason = "max_requests"
+	case time.Since(s.born) >= p.reuse.MaxAge():
+		reason = "max_age"
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		reason = "shutdown"
+	}
+	if reason != "" {
+		p.mu.Unlock()
+		go s.kill(reason) // fora do caminho da request
+		return
+	}
+	s.timer = time.AfterFunc(p.reuse.MaxIdle(), func() { p.expire(s) })
+	p.idle = append(p.idle, s)
+	p.mu.Unlock()
+}
+
+// expire derruba a sessão que ficou ociosa por max_idle (se ainda estiver no pool).
+func (p *containerPool) expire(s *session) {
+	p.mu.Lock()
+	found := false
+	for i, x := range p.idle {
+		if x == s {
+			p.idle = append(p.idle[:i], p.idle[i+1:]...)
+			found = true
+			break
+		}
+	}
+	p.mu.Unlock()
+	if found {
+		s.kill("max_idle")
+	}
+}
+
+// close derruba as sessões ociosas; as em uso morrem ao voltar (put).
+func (p *containerPool) close() {
+	p.mu.Lock()
+	if !p.closed {
+		close(p.stop)
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, s := range idle {
+		s.timer.Stop()
+		s.kill("shutdown")
+	}
+}
+
+func (s *session) kill(reason string) {
+	s.killOnce.Do(func() {
+		s.log.Info("tool session stopped",
+			logging.String("container", s.info.ContainerID),
+			logging.String("reason", reason),
+			logging.Int("requests", s.requests),
+		)
+		tool := s.pool.tool
+		switch tool.ShutdownEffective() {
+		case config.ShutdownEOF:
+			_ = s.stdin.Close()
+			select {
+			case <-s.exited:
+			case <-time.After(tool.ShutdownGrace()):
+				runtime.ForceKill(s.cmd)
+				<-s.exited
+			}
+		case config.ShutdownSIGTERM:
+			runtime.KillProcessGrace(s.cmd, tool.ShutdownGrace())
+			<-s.exited
+			_ = s.stdin.Close()
+		default:
+			// EOF primeiro: tools que saem sozinhas encerram o container limpo (sem SIGTERM)
+			_ = s.stdin.Close()
+			select {
+			case <-s.exited:
+			case <-time.After(sessionExitGrace):
+				runtime.KillProcessGrace(s.cmd, tool.ShutdownGrace())
+				<-s.exited
+			}
+		}
+		for _, c := range s.closers {
+			_ = c.Close()
+		}
+		runtime.ReleaseContainer(s.info.ContainerID)
+		s.pool.audit.release(s.info.PID)
+	})
+}
+
+// pooledProcess adapta uma sessão à interface Process de uma única request.
+type pooledProcess struct {
+	s *session
+
+	in  bytes.Buffer
+	pr  *io.PipeReader
+	pw  *io.PipeWriter
+	err error // resultado da resposta (válido após done)
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+	clean     bool // resposta completa ("done": true); protegido por done
+
+	// release devolve a sessão a quem a emprestou (pool do reuse ou sessão daemon)
+	release func(s *session, clean bool)
+}
+
+func (r *Runner) startPooled(toolName string, tool config.Tool, log *slog.Logger) (Process, error) {
+	s, err := r.poolFor(toolName, tool).get(log)
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	release := func(s *session, clean bool) {
+		if clean {
+			s.pool.put(s)
+		}
+	}
+	return &pooledProcess{s: s, pr: pr, pw: pw, done: make(chan struct{}), release: release}, nil
+}
+
+func (p *pooledProcess) Info() Info            { return p.s.info }
+func (p *pooledProcess) Stdin() io.WriteCloser { return pooledStdin{p} }
+func (p *pooledProcess) Stdout() io.ReadCloser { return p.pr }
+func (p *pooledProcess) Stderr() io.ReadCloser { return io.NopCloser(bytes.NewReader(nil)) }
+
+// Wait espera a resposta terminar (linha "done" ou fim da sessão).
+func (p *pooledProcess) Wait() error {
+	p.startOnce.Do(p.start) // stdin nunca fechado: dispara com o que houver
+	<-p.done
+	return p.err
+}
+
+// Close devolve a sessão se a resposta terminou limpa; senão mata o processo.
+func (p *pooledProcess) Close() error {
+	p.closeOnce.Do(func() {
+		started := true
+		p.startOnce.Do(func() { started = false; close(p.done); p.clean = true })
+
+		// desbloqueia o pump se ninguém mais lê (limite de saída, erro no cliente)
+		_ = p.pr.Close()
+		select {
+		case <-p.done:
+		default:
+			// resposta em andamento (cancelamento/timeout): a sessão não é mais reutilizável
+			p.s.kill("aborted")
+			<-p.done
+		}
+
+		clean := !started || p.clean
+		if !clean {
+			p.s.kill("broken")
+		}
+		p.release(p.s, clean)
+	})
+	return nil
+}
+
+type pooledStdin struct{ p *pooledProcess }
+
+func (w pooledStdin) Write(b []byte) (int, error) { return w.p.in.Write(b) }
+
+// Close envia o input (uma linha) para a sessão e começa a bombear a resposta.
+func (w pooledStdin) Close() error {
+	w.p.startOnce.Do(w.p.start)
+	return nil
+}
+
+func (p *pooledProcess) start() {
+	go func() {
+		defer close(p.done)
+		p.err = p.pump()
+		p.pw.CloseWithError(p.err)
+	}()
+}
+
+// pump escreve o input e copia linhas até a linha com "done": true.
+func (p *pooledProcess) pump() error {
+	err := p.s.exchange(p.in.Bytes(), func(line []byte) error {
+		_, err := p.pw.Write(line)
+		return err
+	})
+	p.clean = err == nil
+	return err
+}
+
+// exchange envia uma linha de input e entrega cada linha de saída a emit até a linha
+// com "done": true (nil). Uso exclusivo: quem segura a sessão (request ou health check).
+func (s *session) exchange(input []byte, emit func([]byte) error) error {
+	line := bytes.TrimRight(input, "\n")
+	if _, err := s.stdin.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("tool session stdin: %w", err)
+	}
+
+	for {
+		out, err := s.stdout.ReadBytes('\n')
+		if len(out) > 0 {
+			if werr := emit(out); werr != nil {
+				return werr
+			}
+			if isDoneLine(out) {
+				return nil
+			}
+		}
+		if err != nil {
+			// container saiu no meio da resposta
+			s.kill("exited")
+			if st := s.state; st != nil && !st.Success() {
+				return fmt.Errorf("tool session exited: %s", st)
+			}
+			return fmt.Errorf("tool session ended before done: %w", err)
+		}
+	}
+}
+
+// alive indica se o processo da sessão ainda está rodando.
+func (s *session) alive() bool {
+	select {
+	case <-s.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// isDoneLine reconhece a linha que encerra uma resposta ({"done": true, ...}).
+func isDoneLine(line []byte) bool {
+	if !bytes.Contains(line, []byte(`"done"`)) {
+		return false
+	}
+	var v struct {
+		Done bool `json:"done"`
+	}
+	return json.Unmarshal(line, &v) == nil && v.Done
+}
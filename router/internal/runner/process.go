@@ -19,6 +19,29 @@ type Process interface {
 	Close() error
 }
 
+// StderrTailer é implementado por processos cujo stderr é lido pelo pump do runner: quem
+// quer o conteúdo (ex: detalhe de falha do selftest) pede a cauda, em vez de ler o pipe
+// em concorrência com o pump.
+type StderrTailer interface {
+	// StderrTail espera o pump terminar (EOF do stderr ou ctx cancelado) e retorna os
+	// últimos stderrTailBytes lidos.
+	StderrTail() string
+}
+
+// stderrTailBytes é quanto do fim do stderr o pump guarda para StderrTail.
+const stderrTailBytes = 4 << 10
+
+// Info identifica o processo de uma execução (inspeção via admin).
+type Info struct {
+	PID         int
+	ContainerID string // nome do container (runtime container)
+}
+
+// Inspector é implementado por processos que expõem Info (builtin/remote não têm processo).
+type Inspector interface {
+	Info() Info
+}
+
 type execProcess struct {
 	toolName string
 	runtime  string
@@ -29,18 +52,46 @@ type execProcess struct {
 
 	log *slog.Logger
 
+	info Info
+
 	startedAt time.Time
 
 	closeOnce sync.Once
 	wg        sync.WaitGroup
 	closeFn   func()
 	waitFn    func() error
+
+	// cauda do stderr (pump); stderrDone fecha quando o pump termina
+	tailMu     sync.Mutex
+	tail       []byte
+	stderrDone chan struct{}
 }
 
+func (p *execProcess) Info() Info            { return p.info }
 func (p *execProcess) Stdin() io.WriteCloser { return p.stdin }
 func (p *execProcess) Stdout() io.ReadCloser { return p.stdout }
 func (p *execProcess) Stderr() io.ReadCloser { return p.stderr }
 
+func (p *execProcess) StderrTail() string {
+	if p.stderrDone != nil {
+		<-p.stderrDone
+	}
+	p.tailMu.Lock()
+	defer p.tailMu.Unlock()
+	return string(p.tail)
+}
+
+// appendTail guarda a linha na cauda do stderr, descartando o início além de stderrTailBytes.
+func (p *execProcess) appendTail(line []byte) {
+	p.tailMu.Lock()
+	defer p.tailMu.Unlock()
+	p.tail = append(p.tail, line...)
+	p.tail = append(p.tail, '\n')
+	if n := len(p.tail) - stderrTailBytes; n > 0 {
+		p.tail = append(p.tail[:0], p.tail[n:]...)
+	}
+}
+
 // Wait espera o processo terminar e registra sucesso/erro + duração.
 // Não loga stdout/payload.
 func (p *execProcess) Wait() error {
@@ -96,8 +147,11 @@ func (p *execProcess) Close() error {
 // - respeita ctx.Done()
 // - logs em nível Debug (stderr pode ser barulhento)
 // - proteção simples contra spam: trunca após N linhas
+// - guarda a cauda para StderrTail (único leitor do pipe)
 func (p *execProcess) startStderrPump(ctx context.Context) {
+	p.stderrDone = make(chan struct{})
 	if p.stderr == nil {
+		close(p.stderrDone)
 		return
 	}
 
@@ -106,6 +160,7 @@ func (p *execProcess) startStderrPump(ctx context.Context) {
 	p.wg.Add(1)
 	go func() {
 		defer p.wg.Done()
+		defer close(p.stderrDone)
 
 		pumpStart := time.Now()
 
@@ -129,6 +184,7 @@ func (p *execProcess) startStderrPump(ctx context.Context) {
 			default:
 			}
 
+			p.appendTail(sc.Bytes())
 			lines++
 			if lines <= maxLines {
 				log.Debug("tool stderr",
@@ -0,0 +1,226 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+)
+
+// remoteRetryBaseDelay é o backoff inicial entre tentativas (dobra a cada retry).
+const remoteRetryBaseDelay = 200 * time.Millisecond
+
+// remoteProcess implementa Process sobre um endpoint MCP HTTP/SSE remoto.
+//
+// Modelo (mesmo contrato do launcher local):
+// - Stdin acumula o input; ao fechar, dispara o POST para o endpoint
+// - Stdout entrega uma linha por evento (SSE data) ou por linha (JSONL)
+// - Close cancela a request (equivalente ao kill)
+//
+// Retries só acontecem antes de qualquer byte de resposta (erro de conexão, 429 ou 5xx),
+// então nunca duplicam output para o cliente.
+type remoteProcess struct {
+	toolName string
+	tool     config.Tool
+	client   *http.Client
+	log      *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	in     bytes.Buffer
+	inOnce sync.Once
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	done    chan struct{}
+	waitErr error
+}
+
+func startRemote(ctx context.Context, toolName string, tool config.Tool, log *slog.Logger) *remoteProcess {
+	rctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	return &remoteProcess{
+		toolName: toolName,
+		tool:     tool,
+		client:   &http.Client{},
+		log:      log,
+		ctx:      rctx,
+		cancel:   cancel,
+		pr:       pr,
+		pw:       pw,
+		done:     make(chan struct{}),
+	}
+}
+
+func (p *remoteProcess) Stdin() io.WriteCloser { return remoteStdin{p} }
+func (p *remoteProcess) Stdout() io.ReadCloser { return p.pr }
+func (p *remoteProcess) Stderr() io.ReadCloser { return http.NoBody }
+
+func (p *remoteProcess) Wait() error {
+	<-p.done
+	return p.waitErr
+}
+
+func (p *remoteProcess) Close() error {
+	p.cancel()
+	// Se stdin nunca foi fechado, não há goroutine rodando.
+	p.inOnce.Do(func() {
+		_ = p.pw.CloseWithError(context.Canceled)
+		close(p.done)
+	})
+	<-p.done
+	return nil
+}
+
+type remoteStdin struct{ p *remoteProcess }
+
+func (s remoteStdin) Write(b []byte) (int, error) { return s.p.in.Write(b) }
+
+func (s remoteStdin) Close() error {
+	s.p.inOnce.Do(func() { go s.p.run() })
+	return nil
+}
+
+func (p *remoteProcess) run() {
+	defer close(p.done)
+	err := p.exchange()
+	p.waitErr = err
+	if err != nil {
+		_ = p.pw.CloseWithError(err)
+		return
+	}
+	_ = p.pw.Close()
+}
+
+func (p *remoteProcess) exchange() error {
+	body := p.in.Bytes()
+	attempts := p.tool.Retries + 1
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			delay := remoteRetryBaseDelay << (attempt - 2)
+			p.log.Warn("retrying remote tool request",
+				logging.Int("attempt", attempt),
+				logging.Int64("backoff_ms", delay.Milliseconds()),
+				logging.Err(lastErr),
+			)
+			select {
+			case <-time.After(delay):
+			case <-p.ctx.Done():
+				return p.ctx.Err()
+			}
+		}
+
+		resp, err := p.post(body)
+		if err != nil {
+			if p.ctx.Err() != nil {
+				return p.ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("remote endpoint returned %s", resp.Status)
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			resp.Body.Close()
+			return fmt.Errorf("remote endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+		}
+
+		defer resp.Body.Close()
+		p.log.Debug("remote tool connected",
+			logging.Int("attempt", attempt),
+			logging.String("content_type", resp.Header.Get("Content-Type")),
+		)
+		if strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream") {
+			return pumpSSE(resp.Body, p.pw)
+		}
+		return pumpLines(resp.Body, p.pw)
+	}
+
+	return fmt.Errorf("remote tool failed after %d attempt(s): %w", attempts, lastErr)
+}
+
+func (p *remoteProcess) post(body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(p.ctx, http.MethodPost, p.tool.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream, application/x-ndjson, application/json")
+	if rid := logging.RequestIDFromContext(p.ctx); rid != "" {
+		req.Header.Set("X-Request-Id", rid)
+	}
+	// Injeção de auth/headers do config (valores aceitam ${ENV})
+	for k, v := range p.tool.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	return p.client.Do(req)
+}
+
+// pumpSSE converte eventos SSE em linhas: cada bloco "data:" vira uma linha.
+// event: error do upstream vira erro do processo.
+func pumpSSE(r io.Reader, w io.Writer) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	event := ""
+	var data []string
+	flush := func() error {
+		defer func() { event, data = "", nil }()
+		if len(data) == 0 {
+			return nil
+		}
+		payload := strings.Join(data, "\n")
+		if event == "error" {
+			return fmt.Errorf("remote tool error: %s", payload)
+		}
+		if payload == "[DONE]" {
+			return nil
+		}
+		_, err := io.WriteString(w, payload+"\n")
+		return err
+	}
+
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if err := flush(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// comentário/keepalive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func pumpLines(r io.Reader, w io.Writer) error {
+	_, err := io.Copy(w, r)
+	return err
+}
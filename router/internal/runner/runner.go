@@ -2,20 +2,57 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"mcp-router/internal/builtin"
 	"mcp-router/internal/config"
+	"mcp-router/internal/egress"
 	"mcp-router/internal/observability/logging"
 	"mcp-router/internal/runtime"
 )
 
 type Runner struct {
 	cfg *config.Config
+
+	// estado das tools builtin (por nome da tool; vive enquanto o gateway estiver de pé)
+	mu  sync.Mutex
+	kvs map[string]*builtin.KV
+
+	// sessões de containers reutilizados (tool.reuse), por nome da tool
+	pools map[string]*containerPool
+
+	// sessões de tools daemon, por Mcp-Session-Id (protegido por mu)
+	sessions map[string]*boundSession
+
+	// process groups das tools (auditoria de processos deixados para trás)
+	audit *procAudit
+
+	// proxy de saída das tools native com egress_allow (listener sobe no primeiro uso)
+	egress *egress.Proxy
 }
 
 func New(cfg *config.Config) *Runner {
-	return &Runner{cfg: cfg}
+	return &Runner{cfg: cfg, kvs: make(map[string]*builtin.KV), pools: make(map[string]*containerPool), sessions: make(map[string]*boundSession), audit: newProcAudit(), egress: egress.New(cfg)}
+}
+
+// Close derruba os containers ociosos mantidos por reuse e as sessões daemon (shutdown do gateway).
+func (r *Runner) Close() {
+	r.closeSessions()
+
+	r.mu.Lock()
+	pools := make([]*containerPool, 0, len(r.pools))
+	for _, p := range r.pools {
+		pools = append(pools, p)
+	}
+	r.mu.Unlock()
+
+	for _, p := range pools {
+		p.close()
+	}
+	_ = r.egress.Close()
 }
 
 func (r *Runner) Start(ctx context.Context, toolName string, tool config.Tool) (Process, error) {
@@ -28,6 +65,43 @@ func (r *Runner) Start(ctx context.Context, toolName string, tool config.Tool) (
 		logging.RequestID(logging.RequestIDFromContext(ctx)),
 	)
 
+	// Remote: não há processo local; a request HTTP faz o papel do processo.
+	if tool.Runtime == "remote" {
+		log.Info("connecting remote tool", logging.String("endpoint", tool.Endpoint))
+		return startRemote(ctx, toolName, tool, log), nil
+	}
+
+	// Builtin: roda dentro do gateway, sem processo.
+	if tool.Runtime == "builtin" {
+		handle, err := r.builtinFor(toolName, tool)
+		if err != nil {
+			log.Error("failed to resolve builtin", logging.Err(err))
+			return nil, err
+		}
+		log.Debug("starting builtin tool", logging.String("builtin", tool.Builtin))
+		return startBuiltin(ctx, handle), nil
+	}
+
+	// Container reutilizado: pega (ou sobe) uma sessão do pool da tool.
+	if tool.Reuse != nil && tool.Runtime == "container" {
+		p, err := r.startPooled(toolName, tool, log)
+		if err != nil {
+			log.Error("failed to start container session", logging.Err(err))
+			return nil, err
+		}
+		return p, nil
+	}
+
+	// Daemon: o processo da sessão (Mcp-Session-Id) atende a request.
+	if tool.Mode == "daemon" {
+		p, err := r.startSession(ctx, toolName)
+		if err != nil {
+			log.Warn("failed to attach tool session", logging.Err(err))
+			return nil, err
+		}
+		return p, nil
+	}
+
 	// Resolve runtime backend a partir do tool (native/container)
 	rt, err := runtime.FromTool(tool)
 	if err != nil {
@@ -43,7 +117,24 @@ func (r *Runner) Start(ctx context.Context, toolName string, tool config.Tool) (
 		logging.String("mode", tool.Mode),
 	)
 
-	cmd, stdin, stdout, stderr, err := rt.Spawn(ctx, r.cfg, tool)
+	// X-MCP-Workspace: o processo vê a raiz do workspace nomeado (validado pelo core)
+	cfg := r.cfg
+	if ws := logging.WorkspaceFromContext(ctx); ws != "" {
+		root, ok := r.cfg.WorkspaceFor(ws)
+		if !ok {
+			return nil, fmt.Errorf("unknown workspace %q", ws)
+		}
+		cfg = r.cfg.WithWorkspaceRoot(root)
+	}
+
+	// egress_allow: a tool sai pelo proxy do gateway (HTTP_PROXY/HTTPS_PROXY)
+	proxyURL, err := r.egressURL(toolName, tool)
+	if err != nil {
+		log.Error("failed to start egress proxy", logging.Err(err))
+		return nil, err
+	}
+
+	cmd, stdin, stdout, stderr, err := rt.Spawn(runtime.WithProxy(ctx, proxyURL), cfg, tool)
 	if err != nil {
 		log.Error("failed to spawn tool process",
 			logging.Err(err),
@@ -59,13 +150,24 @@ func (r *Runner) Start(ctx context.Context, toolName string, tool config.Tool) (
 		log.Debug("process started")
 	}
 
+	info := Info{ContainerID: runtime.ContainerName(cmd)}
+	if cmd != nil && cmd.Process != nil {
+		info.PID = cmd.Process.Pid
+		r.audit.track(info.PID, toolName) // Setpgid: pgid == pid
+	}
+
 	p := &execProcess{
+		info:     info,
 		toolName: toolName,
 		stdin:    stdin,
 		stdout:   stdout,
 		stderr:   stderr,
-		closeFn:  func() { runtime.KillProcess(cmd) },
-		waitFn:   func() error { return cmd.Wait() },
+		closeFn: func() {
+			runtime.StopProcess(cmd, stdin, tool)
+			runtime.ReleaseContainer(info.ContainerID)
+			r.audit.release(info.PID)
+		},
+		waitFn: func() error { return cmd.Wait() },
 	}
 
 	// stderr pump é “owned” pelo process; termina com ctx/process
@@ -77,10 +179,26 @@ func (r *Runner) Start(ctx context.Context, toolName string, tool config.Tool) (
 	return p, nil
 }
 
+// LiveProcesses conta os processos de tool vivos (spawnados e ainda não encerrados).
+func (r *Runner) LiveProcesses() int {
+	return r.audit.liveGroups()
+}
+
+// egressURL é o HTTP_PROXY da tool ("" sem egress_allow).
+func (r *Runner) egressURL(toolName string, tool config.Tool) (string, error) {
+	if tool.EgressAllow == nil {
+		return "", nil
+	}
+	return r.egress.URL(toolName)
+}
+
+// ErrUnknownTool é retornado quando a tool não existe no config.
+var ErrUnknownTool = errors.New("unknown tool")
+
 func (r *Runner) MustGetTool(name string) (config.Tool, error) {
 	tool, ok := r.cfg.Tools[name]
 	if !ok {
-		return config.Tool{}, fmt.Errorf("unknown tool: %s", name)
+		return config.Tool{}, fmt.Errorf("%w: %s", ErrUnknownTool, name)
 	}
 	return tool, nil
 }
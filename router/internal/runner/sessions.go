@@ -0,0 +1,241 @@
+package runner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+)
+
+// Sessões de tools daemon: cada sessão é um processo exclusivo (mesmo protocolo do reuse:
+// uma linha de input, resposta até a linha "done": true). Requests com o mesmo
+// Mcp-Session-Id caem sempre no mesmo processo, preservando o estado dele em memória.
+
+var (
+	ErrSessionRequired = errors.New("daemon tool requires a session (Mcp-Session-Id)")
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionBusy     = errors.New("session is busy with another request")
+	ErrSessionLimit    = errors.New("too many open sessions for tool")
+	ErrSessionMismatch = errors.New("session belongs to another tool")
+)
+
+// SessionInfo descreve uma sessão aberta (POST /mcp/sessions, /admin/sessions).
+type SessionInfo struct {
+	ID          string    `json:"session_id"`
+	Tool        string    `json:"tool"`
+	PID         int       `json:"pid,omitempty"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Created     time.Time `json:"created"`
+	LastUsed    time.Time `json:"last_used"`
+	Requests    int       `json:"requests"`
+	Busy        bool      `json:"busy"`
+}
+
+// boundSession é uma sessão de processo amarrada a um id (protegida por Runner.mu).
+type boundSession struct {
+	id       string
+	toolName string
+	s        *session
+	created  time.Time
+	lastUsed time.Time
+	busy     bool
+	timer    *time.Timer // idle_timeout_ms
+}
+
+type sessionIDKey struct{}
+
+// WithSessionID associa a request à sessão (header Mcp-Session-Id).
+func WithSessionID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionIDKey{}, id)
+}
+
+// SessionIDFromContext retorna o id da sessão da request ("" = sem sessão).
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey{}).(string)
+	return id
+}
+
+// OpenSession sobe o processo de uma tool daemon e devolve a sessão criada.
+func (r *Runner) OpenSession(toolName string, tool config.Tool) (SessionInfo, error) {
+	r.mu.Lock()
+	open := 0
+	for _, b := range r.sessions {
+		if b.toolName == toolName {
+			open++
+		}
+	}
+	r.mu.Unlock()
+	if open >= tool.Session.MaxSessionsEffective() {
+		return SessionInfo{}, ErrSessionLimit
+	}
+
+	// spawner sem pool de ociosas: só fornece cfg/tool/audit para o spawn e o kill
+	proxyURL, err := r.egressURL(toolName, tool)
+	if err != nil {
+		return SessionInfo{}, err
+	}
+	sp := &containerPool{cfg: r.cfg, toolName: toolName, tool: tool, audit: r.audit, proxy: proxyURL, stop: make(chan struct{})}
+	s, err := sp.spawn(slog.Default().With(logging.Tool(toolName), logging.Runtime(tool.Runtime)))
+	if err != nil {
+		return SessionInfo{}, err
+	}
+
+	var raw [16]byte
+	_, _ = rand.Read(raw[:])
+	now := time.Now()
+	b := &boundSession{id: hex.EncodeToString(raw[:]), toolName: toolName, s: s, created: now, lastUsed: now}
+
+	r.mu.Lock()
+	r.sessions[b.id] = b
+	b.timer = time.AfterFunc(tool.Session.IdleTimeout(), func() { r.expireSession(b.id) })
+	info := b.info()
+	r.mu.Unlock()
+
+	s.log.Info("tool session opened", logging.String("session_id", b.id))
+	return info, nil
+}
+
+// CloseSession encerra a sessão (DELETE /mcp/sessions/<id>). false = não existe.
+func (r *Runner) CloseSession(id string) bool {
+	r.mu.Lock()
+	b, ok := r.sessions[id]
+	if ok {
+		delete(r.sessions, id)
+		b.timer.Stop()
+	}
+	r.mu.Unlock()
+	if ok {
+		go b.s.kill("session_closed")
+	}
+	return ok
+}
+
+// LookupSession valida que a sessão existe, está viva e pertence à tool.
+func (r *Runner) LookupSession(id, toolName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.sessions[id]
+	switch {
+	case !ok || !b.s.alive():
+		return ErrSessionNotFound
+	case b.toolName != toolName:
+		return ErrSessionMismatch
+	}
+	return nil
+}
+
+// Sessions lista as sessões abertas (ordem de criação).
+func (r *Runner) Sessions() []SessionInfo {
+	r.mu.Lock()
+	out := make([]SessionInfo, 0, len(r.sessions))
+	for _, b := range r.sessions {
+		out = append(out, b.info())
+	}
+	r.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out
+}
+
+func (b *boundSession) info() SessionInfo {
+	return SessionInfo{
+		ID:          b.id,
+		Tool:        b.toolName,
+		PID:         b.s.info.PID,
+		ContainerID: b.s.info.ContainerID,
+		Created:     b.created,
+		LastUsed:    b.lastUsed,
+		Requests:    b.s.requests,
+		Busy:        b.busy,
+	}
+}
+
+// startSession reserva a sessão da request (uma request por vez) e a adapta a Process.
+func (r *Runner) startSession(ctx context.Context, toolName string) (Process, error) {
+	id := SessionIDFromContext(ctx)
+	if id == "" {
+		return nil, ErrSessionRequired
+	}
+
+	r.mu.Lock()
+	b, ok := r.sessions[id]
+	switch {
+	case !ok:
+		r.mu.Unlock()
+		return nil, ErrSessionNotFound
+	case b.toolName != toolName:
+		r.mu.Unlock()
+		return nil, ErrSessionMismatch
+	case b.busy:
+		r.mu.Unlock()
+		return nil, ErrSessionBusy
+	case !b.s.alive():
+		delete(r.sessions, id)
+		b.timer.Stop()
+		r.mu.Unlock()
+		go b.s.kill("exited")
+		return nil, ErrSessionNotFound
+	}
+	b.busy = true
+	b.timer.Stop()
+	r.mu.Unlock()
+
+	pr, pw := io.Pipe()
+	p := &pooledProcess{s: b.s, pr: pr, pw: pw, done: make(chan struct{})}
+	p.release = func(s *session, clean bool) { r.releaseSession(b, clean) }
+	return p, nil
+}
+
+// releaseSession devolve a sessão ao fim da request. Resposta incompleta (cancelamento,
+// timeout) deixa o processo dessincronizado: a sessão é encerrada.
+func (r *Runner) releaseSession(b *boundSession, clean bool) {
+	r.mu.Lock()
+	b.busy = false
+	b.lastUsed = time.Now()
+	if _, open := r.sessions[b.id]; open && clean {
+		b.s.requests++
+		b.timer = time.AfterFunc(b.s.pool.tool.Session.IdleTimeout(), func() { r.expireSession(b.id) })
+		r.mu.Unlock()
+		return
+	}
+	delete(r.sessions, b.id)
+	r.mu.Unlock()
+	b.s.kill("broken")
+}
+
+// expireSession encerra a sessão ociosa por idle_timeout_ms (se não estiver em uso).
+func (r *Runner) expireSession(id string) {
+	r.mu.Lock()
+	b, ok := r.sessions[id]
+	if !ok || b.busy {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.sessions, id)
+	r.mu.Unlock()
+	b.s.kill("idle_timeout")
+}
+
+// closeSessions encerra todas as sessões (shutdown do gateway).
+func (r *Runner) closeSessions() {
+	r.mu.Lock()
+	all := make([]*boundSession, 0, len(r.sessions))
+	for id, b := range r.sessions {
+		b.timer.Stop()
+		all = append(all, b)
+		delete(r.sessions, id)
+	}
+	r.mu.Unlock()
+	for _, b := range all {
+		b.s.kill("shutdown")
+	}
+}
@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"mcp-router/internal/config"
+)
+
+// bwrapSystemDirs são montados read-only para o binário da tool e suas libs funcionarem.
+var bwrapSystemDirs = []string{"/usr", "/bin", "/sbin", "/lib", "/lib64", "/etc"}
+
+// bwrapCommand embrulha a tool native com bubblewrap (sandbox: bwrap): só o workspace
+// (conforme workspace_access) e o tools_root ficam visíveis, sem rede por default.
+func bwrapCommand(cfg *config.Config, tool config.Tool, ws, workdir string) (*exec.Cmd, error) {
+	bin, err := exec.LookPath("bwrap")
+	if err != nil {
+		return nil, fmt.Errorf("sandbox bwrap: %w", err)
+	}
+	cmdPath, err := exec.LookPath(tool.Cmd)
+	if err != nil {
+		return nil, err
+	}
+	if cmdPath, err = filepath.Abs(cmdPath); err != nil {
+		return nil, err
+	}
+	return exec.Command(bin, bwrapArgs(cfg, tool, ws, workdir, cmdPath)...), nil
+}
+
+func bwrapArgs(cfg *config.Config, tool config.Tool, ws, workdir, cmdPath string) []string {
+	args := []string{
+		"--die-with-parent",
+		"--unshare-all",
+		"--proc", "/proc",
+		"--dev", "/dev",
+		"--tmpfs", "/tmp",
+	}
+	// rede: mesma semântica do container (none por default; bridge = rede do host)
+	if tool.DockerNetworkEffective() == "bridge" {
+		args = append(args, "--share-net")
+	}
+
+	for _, d := range bwrapSystemDirs {
+		args = append(args, "--ro-bind-try", d, d)
+	}
+	if cfg.ToolsRoot != "" {
+		args = append(args, "--ro-bind-try", cfg.ToolsRoot, cfg.ToolsRoot)
+	}
+	// o próprio executável pode estar fora dos diretórios acima
+	args = append(args, "--ro-bind", cmdPath, cmdPath)
+
+	chdir := "/"
+	switch tool.WorkspaceAccessEffective() {
+	case config.WorkspaceAccessRW:
+		args = append(args, "--bind", ws, ws)
+		chdir = ws
+	case config.WorkspaceAccessRO:
+		args = append(args, "--ro-bind", ws, ws)
+		chdir = ws
+	}
+	if workdir != "" {
+		chdir = workdir
+	}
+	args = append(args, "--chdir", chdir)
+
+	args = append(args, "--", cmdPath)
+	return append(args, tool.Args...)
+}
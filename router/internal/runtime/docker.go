@@ -2,16 +2,23 @@ package runtime
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
 )
 
 type DockerRuntime struct{}
 
+// containerNamePrefix identifica containers do gateway (`docker ps --filter name=mcp-gw-`).
+const containerNamePrefix = "mcp-gw-"
+
 // Spawn executa a tool em container via `docker run -i`.
 //
 // Hardening mínimo (Prioridade 1.1), configurável por tool:
@@ -19,21 +26,82 @@ type DockerRuntime struct{}
 // - read_only: true|false (default: true)
 // - no-new-privileges (sempre)
 // - cap-drop=ALL (sempre)
+// - seccomp_profile / apparmor_profile (opcionais)
 //
 // Observação (Lab):
 // - ainda usamos docker.sock (alto privilégio). Cloudflare Access continua obrigatório.
 func (DockerRuntime) Spawn(ctx context.Context, cfg *config.Config, tool config.Tool) (*exec.Cmd, io.WriteCloser, io.ReadCloser, io.ReadCloser, error) {
-	env := append(os.Environ(),
-		"WORKSPACE_ROOT="+cfg.WorkspaceRoot,
-		"TOOLS_ROOT="+cfg.ToolsRoot,
+	cmd, name, err := dockerCommand(ctx, cfg, tool)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	cmd.Env = append(toolEnviron(cfg), cmd.Env...)
+	// grupo próprio: KillProcess sinaliza o grupo do docker CLI, nunca o do gateway
+	cmd.SysProcAttr = processGroupAttr()
+
+	// tty: o docker CLI exige stdin terminal com -i -t, então stdin e stdout vão para o pty
+	var (
+		stdin  io.WriteCloser
+		stdout io.ReadCloser
+		tts    *os.File
 	)
+	if tool.TTY {
+		if stdin, stdout, tts, err = attachTTY(cmd, true); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	} else {
+		if stdin, err = cmd.StdinPipe(); err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if stdout, err = cmd.StdoutPipe(); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// cancelamento do ctx: tool.shutdown (EOF/SIGTERM) no lugar do SIGKILL do CommandContext
+	cmd.Cancel = func() error {
+		StopProcess(cmd, stdin, tool)
+		return nil
+	}
+
+	trackContainer(name)
+	err = cmd.Start()
+	if tts != nil {
+		_ = tts.Close()
+	}
+	if err != nil {
+		ReleaseContainer(name)
+		if tts != nil {
+			_ = stdout.Close()
+		}
+		return nil, nil, nil, nil, err
+	}
+
+	return cmd, stdin, stdout, stderr, nil
+}
+
+// dockerCommand monta o `docker run` da tool sem iniciar nada (usado pelo Spawn e pelo
+// dry-run). cmd.Env tem só as variáveis que o gateway acrescenta ao ambiente herdado.
+func dockerCommand(ctx context.Context, cfg *config.Config, tool config.Tool) (*exec.Cmd, string, error) {
+	env := []string{
+		"WORKSPACE_ROOT=" + cfg.WorkspaceRoot,
+		"TOOLS_ROOT=" + cfg.ToolsRoot,
+	}
 
 	// Defaults conservadores (somente para container)
 	netMode := tool.DockerNetworkEffective() // "none" | "bridge"
 	readOnly := tool.ReadOnlyEffective()     // true/false
 
+	name := newContainerName()
 	args := []string{
 		"run", "-i", "--rm",
+		// nome conhecido de antemão: permite inspeção/kill pelo admin (o id só sai após o start)
+		"--name", name,
 
 		// Hardening base
 		"--security-opt=no-new-privileges",
@@ -41,6 +109,46 @@ func (DockerRuntime) Spawn(ctx context.Context, cfg *config.Config, tool config.
 		"--network", netMode,
 	}
 
+	// tty: pty alocado também dentro do container
+	if tool.TTY {
+		args = append(args, "-t")
+	}
+
+	// labels do gateway: o reaper acha containers que sobreviveram ao dono
+	args = append(args, dockerLabels(logging.RequestIDFromContext(ctx))...)
+
+	// prazo e request_id da execução dentro do container (ver DeadlineEnv e RequestIDEnv)
+	for _, e := range append(deadlineEnv(ctx), requestIDEnv(ctx)...) {
+		args = append(args, "-e", e)
+	}
+
+	// pull_policy -> --pull (o pré-pull do startup normalmente já deixou a imagem local)
+	args = append(args, "--pull", dockerPullFlag(tool.PullPolicyEffective()))
+
+	// Runtime OCI alternativo (isolamento mais forte para tools não confiáveis)
+	if tool.ContainerRuntime != "" {
+		args = append(args, "--runtime="+tool.ContainerRuntime)
+	}
+
+	// Perfis de segurança do kernel (vazio = defaults do docker)
+	if tool.SeccompProfile != "" {
+		p, err := seccompProfilePath(tool.SeccompProfile)
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, "--security-opt", "seccomp="+p)
+	}
+	if tool.AppArmorProfile != "" {
+		args = append(args, "--security-opt", "apparmor="+tool.AppArmorProfile)
+	}
+
+	// Nunca root por default (DefaultContainerUser); o workspace precisa ser legível por esse usuário
+	uid, gid, _, err := toolCredential(cfg, tool)
+	if err != nil {
+		return nil, "", err
+	}
+	args = append(args, "--user", fmt.Sprintf("%d:%d", uid, gid))
+
 	if readOnly {
 		args = append(args, "--read-only")
 		// tmpfs para permitir escrita temporária sem quebrar read-only (muitas imagens precisam)
@@ -48,10 +156,45 @@ func (DockerRuntime) Spawn(ctx context.Context, cfg *config.Config, tool config.
 		args = append(args, "--tmpfs", "/var/tmp:rw,noexec,nosuid,size=64m")
 	}
 
-	// Workspace mount (sandbox)
-	args = append(args,
-		"-v", fmt.Sprintf("%s:/workspaces", cfg.WorkspaceRoot),
-	)
+	// Workspace mount (sandbox): só o workspace_subdir quando configurado; ro/none via workspace_access
+	switch access := tool.WorkspaceAccessEffective(); access {
+	case config.WorkspaceAccessNone:
+		// sem mount: a tool não enxerga o workspace
+	default:
+		ws, _, err := toolWorkspace(cfg, tool)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := checkWorkspaceReadable(ws, uid, gid); err != nil {
+			return nil, "", err
+		}
+		mount := fmt.Sprintf("%s:%s", ws, containerWorkspace)
+		if access == config.WorkspaceAccessRO {
+			mount += ":ro"
+		}
+		args = append(args, "-v", mount)
+		if tool.Workdir != "" {
+			args = append(args, "-w", containerWorkdir(tool))
+		} else if tool.WorkspaceSubdir != "" {
+			args = append(args, "-w", containerWorkspace)
+		}
+	}
+
+	// Volumes extras: revalida com symlinks resolvidos (o config só vê o caminho declarado)
+	for _, m := range tool.Mounts {
+		host, err := filepath.EvalSymlinks(m.Host)
+		if err != nil {
+			return nil, "", fmt.Errorf("mount %s: %w", m.Host, err)
+		}
+		if !cfg.MountAllowed(host) {
+			return nil, "", fmt.Errorf("mount %s resolves outside mount_roots: %s", m.Host, host)
+		}
+		spec := host + ":" + m.Container
+		if m.ModeEffective() == config.WorkspaceAccessRO {
+			spec += ":ro"
+		}
+		args = append(args, "-v", spec)
+	}
 
 	// Imagem + args da tool
 	args = append(args, tool.Image)
@@ -59,25 +202,36 @@ func (DockerRuntime) Spawn(ctx context.Context, cfg *config.Config, tool config.
 
 	cmd := exec.CommandContext(ctx, "docker", args...)
 	cmd.Env = env
+	return cmd, name, nil
+}
 
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return nil, nil, nil, nil, err
+// dockerPullFlag traduz pull_policy para os valores do `docker run --pull`.
+func dockerPullFlag(policy string) string {
+	switch policy {
+	case config.PullAlways:
+		return "always"
+	case config.PullNever:
+		return "never"
+	default:
+		return "missing"
 	}
+}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, nil, nil, nil, err
-	}
+func newContainerName() string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	return containerNamePrefix + hex.EncodeToString(b[:])
+}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, nil, nil, nil, err
+// ContainerName retorna o nome do container de um cmd criado pelo DockerRuntime ("" se não for container).
+func ContainerName(cmd *exec.Cmd) string {
+	if cmd == nil {
+		return ""
 	}
-
-	if err := cmd.Start(); err != nil {
-		return nil, nil, nil, nil, err
+	for i := 0; i+1 < len(cmd.Args); i++ {
+		if cmd.Args[i] == "--name" {
+			return cmd.Args[i+1]
+		}
 	}
-
-	return cmd, stdin, stdout, stderr, nil
+	return ""
 }
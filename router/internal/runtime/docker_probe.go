@@ -2,7 +2,11 @@ package runtime
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os/exec"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -13,3 +17,35 @@ func DockerReady(ctx context.Context) error {
 	cmd := exec.CommandContext(cctx, "docker", "version", "--format", "{{.Server.Version}}")
 	return cmd.Run()
 }
+
+// DockerRuntimesReady confirma que os runtimes OCI (container_runtime) estão registrados
+// no daemon. Retorna os ausentes junto com o erro.
+func DockerRuntimesReady(ctx context.Context, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, 800*time.Millisecond)
+	defer cancel()
+
+	out, err := exec.CommandContext(cctx, "docker", "info", "--format", "{{json .Runtimes}}").Output()
+	if err != nil {
+		return nil, err
+	}
+	var installed map[string]json.RawMessage
+	if err := json.Unmarshal(out, &installed); err != nil {
+		return nil, fmt.Errorf("docker info runtimes: %w", err)
+	}
+
+	var missing []string
+	for _, n := range names {
+		if _, ok := installed[n]; !ok {
+			missing = append(missing, n)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return missing, fmt.Errorf("container runtime not installed: %s", strings.Join(missing, ", "))
+	}
+	return nil, nil
+}
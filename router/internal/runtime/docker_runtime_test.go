@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -96,6 +97,10 @@ exit 0
 		{"--tmpfs", "/tmp:rw,noexec,nosuid,size=64m"},
 		{"--tmpfs", "/var/tmp:rw,noexec,nosuid,size=64m"},
 		{"-v", fmt.Sprintf("%s:/workspaces", cfg.WorkspaceRoot)},
+		{"--user", config.DefaultContainerUser},
+		{"--pull", "missing"},
+		{"--label", LabelManaged + "=1"},
+		{"--label", LabelInstance + "=" + instanceID},
 	}
 	for _, seq := range mustContain {
 		if !containsSubsequence(lines, seq) {
@@ -122,6 +127,216 @@ exit 0
 	}
 }
 
+func TestDockerRuntime_Spawn_MountsOnlyWorkspaceSubdir(t *testing.T) {
+	tmp := t.TempDir()
+	fakeScript := `#!/bin/sh
+for a in "$@"; do
+  echo "$a"
+done
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(tmp, "docker"), []byte(fakeScript), 0o755); err != nil {
+		t.Fatalf("write fake docker: %v", err)
+	}
+	t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "proj", "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{WorkspaceRoot: root, ToolsRoot: "/tools"}
+	tool := config.Tool{
+		Runtime:         "container",
+		Image:           "alpine:latest",
+		WorkspaceSubdir: "proj",
+		Workdir:         "src",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	cmd, _, stdout, _, err := DockerRuntime{}.Spawn(ctx, cfg, tool)
+	if err != nil {
+		t.Fatalf("Spawn error: %v", err)
+	}
+	defer cmd.Wait()
+
+	outBytes, _ := io.ReadAll(stdout)
+	lines := strings.Split(strings.TrimSpace(string(outBytes)), "\n")
+	for _, seq := range [][]string{
+		{"-v", filepath.Join(root, "proj") + ":/workspaces"},
+		{"-w", "/workspaces/src"},
+	} {
+		if !containsSubsequence(lines, seq) {
+			t.Fatalf("missing subsequence %v. full=%q", seq, string(outBytes))
+		}
+	}
+}
+
+func TestDockerRuntime_Spawn_WorkspaceAccess(t *testing.T) {
+	tmp := t.TempDir()
+	fakeScript := `#!/bin/sh
+for a in "$@"; do
+  echo "$a"
+done
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(tmp, "docker"), []byte(fakeScript), 0o755); err != nil {
+		t.Fatalf("write fake docker: %v", err)
+	}
+	t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &config.Config{WorkspaceRoot: "/tmp/workspaces", ToolsRoot: "/tools"}
+
+	for access, wantMount := range map[string]string{
+		"":     "/tmp/workspaces:/workspaces",
+		"rw":   "/tmp/workspaces:/workspaces",
+		"ro":   "/tmp/workspaces:/workspaces:ro",
+		"none": "",
+	} {
+		tool := config.Tool{Runtime: "container", Image: "alpine:latest", WorkspaceAccess: access}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		cmd, _, stdout, _, err := DockerRuntime{}.Spawn(ctx, cfg, tool)
+		if err != nil {
+			cancel()
+			t.Fatalf("access=%q: Spawn error: %v", access, err)
+		}
+		outBytes, _ := io.ReadAll(stdout)
+		_ = cmd.Wait()
+		cancel()
+
+		lines := strings.Split(strings.TrimSpace(string(outBytes)), "\n")
+		got := ""
+		if i := indexOf(lines, "-v"); i >= 0 && i+1 < len(lines) {
+			got = lines[i+1]
+		}
+		if got != wantMount {
+			t.Fatalf("access=%q: mount = %q, want %q", access, got, wantMount)
+		}
+	}
+}
+
+func TestDockerRuntime_Spawn_ExtraMountsWithinRoots(t *testing.T) {
+	tmp := t.TempDir()
+	fakeScript := `#!/bin/sh
+for a in "$@"; do
+  echo "$a"
+done
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(tmp, "docker"), []byte(fakeScript), 0o755); err != nil {
+		t.Fatalf("write fake docker: %v", err)
+	}
+	t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	models := filepath.Join(root, "models")
+	if err := os.Mkdir(models, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// symlink dentro da raiz apontando para fora: barrado no spawn
+	if err := os.Symlink("/etc", filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{WorkspaceRoot: "/tmp/workspaces", ToolsRoot: "/tools", MountRoots: []string{root}}
+	tool := config.Tool{
+		Runtime: "container",
+		Image:   "alpine:latest",
+		Mounts: []config.Mount{
+			{Host: models, Container: "/models"},
+			{Host: root, Container: "/data", Mode: "rw"},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	cmd, _, stdout, _, err := DockerRuntime{}.Spawn(ctx, cfg, tool)
+	if err != nil {
+		t.Fatalf("Spawn error: %v", err)
+	}
+	outBytes, _ := io.ReadAll(stdout)
+	_ = cmd.Wait()
+
+	lines := strings.Split(strings.TrimSpace(string(outBytes)), "\n")
+	for _, seq := range [][]string{
+		{"-v", models + ":/models:ro"},
+		{"-v", root + ":/data"},
+	} {
+		if !containsSubsequence(lines, seq) {
+			t.Fatalf("missing subsequence %v. full=%q", seq, string(outBytes))
+		}
+	}
+
+	tool.Mounts = []config.Mount{{Host: filepath.Join(root, "escape"), Container: "/etc-copy"}}
+	if _, _, _, _, err := (DockerRuntime{}).Spawn(ctx, cfg, tool); err == nil {
+		t.Fatalf("expected mount resolving outside mount_roots to be rejected")
+	}
+}
+
+func TestDockerRuntime_Spawn_SecurityProfiles(t *testing.T) {
+	tmp := t.TempDir()
+	fakeScript := `#!/bin/sh
+for a in "$@"; do
+  echo "$a"
+done
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(tmp, "docker"), []byte(fakeScript), 0o755); err != nil {
+		t.Fatalf("write fake docker: %v", err)
+	}
+	t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &config.Config{WorkspaceRoot: "/tmp/workspaces", ToolsRoot: "/tools"}
+	tool := config.Tool{
+		Runtime:          "container",
+		Image:            "alpine:latest",
+		SeccompProfile:   config.SeccompProfileStrict,
+		AppArmorProfile:  "mcp-gw-strict",
+		ContainerRuntime: "runsc",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	cmd, _, stdout, _, err := DockerRuntime{}.Spawn(ctx, cfg, tool)
+	if err != nil {
+		t.Fatalf("Spawn error: %v", err)
+	}
+	outBytes, _ := io.ReadAll(stdout)
+	_ = cmd.Wait()
+
+	lines := strings.Split(strings.TrimSpace(string(outBytes)), "\n")
+	if !containsSubsequence(lines, []string{"--security-opt", "apparmor=mcp-gw-strict"}) {
+		t.Fatalf("missing apparmor security-opt. full=%q", string(outBytes))
+	}
+	if indexOf(lines, "--runtime=runsc") == -1 {
+		t.Fatalf("missing --runtime=runsc. full=%q", string(outBytes))
+	}
+	var profile string
+	for i, a := range lines {
+		if i > 0 && lines[i-1] == "--security-opt" && strings.HasPrefix(a, "seccomp=") {
+			profile = strings.TrimPrefix(a, "seccomp=")
+		}
+	}
+	if b, err := os.ReadFile(profile); err != nil || !strings.Contains(string(b), `"ptrace"`) {
+		t.Fatalf("expected materialized strict profile at %q (err=%v)", profile, err)
+	}
+
+	tool.SeccompProfile = filepath.Join(tmp, "missing.json")
+	if _, _, _, _, err := (DockerRuntime{}).Spawn(ctx, cfg, tool); err == nil {
+		t.Fatalf("expected error for missing seccomp profile file")
+	}
+}
+
 func TestDockerRuntime_Spawn_SetsWorkspaceAndToolsEnv(t *testing.T) {
 	tmp := t.TempDir()
 	fakeDockerPath := filepath.Join(tmp, "docker")
@@ -265,3 +480,107 @@ func containsSubsequence(xs []string, seq []string) bool {
 	}
 	return false
 }
+
+func TestDockerRuntimesReady_ReportsMissingRuntimes(t *testing.T) {
+	tmp := t.TempDir()
+	fakeScript := `#!/bin/sh
+echo '{"io.containerd.runc.v2":{"path":"runc"},"runc":{"path":"runc"},"runsc":{"path":"/usr/bin/runsc"}}'
+`
+	if err := os.WriteFile(filepath.Join(tmp, "docker"), []byte(fakeScript), 0o755); err != nil {
+		t.Fatalf("write fake docker: %v", err)
+	}
+	t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if _, err := DockerRuntimesReady(context.Background(), []string{"runc", "runsc"}); err != nil {
+		t.Fatalf("expected installed runtimes to be ready: %v", err)
+	}
+	missing, err := DockerRuntimesReady(context.Background(), []string{"kata", "runsc"})
+	if err == nil || len(missing) != 1 || missing[0] != "kata" {
+		t.Fatalf("expected kata missing, got missing=%v err=%v", missing, err)
+	}
+}
+
+func TestPullImage_RespectsPolicy(t *testing.T) {
+	tmp := t.TempDir()
+	calls := filepath.Join(tmp, "calls")
+	// fake docker: registra a chamada; "image inspect" falha só para imagens "missing*"
+	fakeScript := `#!/bin/sh
+echo "$1 $2" >> ` + calls + `
+if [ "$1" = image ]; then
+  case "$5" in missing*) exit 1 ;; esac
+  exit 0
+fi
+echo "latest: Pulling from library/alpine"
+echo "Status: Downloaded newer image"
+`
+	if err := os.WriteFile(filepath.Join(tmp, "docker"), []byte(fakeScript), 0o755); err != nil {
+		t.Fatalf("write fake docker: %v", err)
+	}
+	t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	cases := []struct {
+		image, policy string
+		pulled        bool
+	}{
+		{"present:1", config.PullIfNotPresent, false},
+		{"missing:1", config.PullIfNotPresent, true},
+		{"present:1", config.PullAlways, true},
+		{"missing:1", config.PullNever, false},
+	}
+	for _, c := range cases {
+		pulled, err := PullImage(ctx, c.image, c.policy, log)
+		if err != nil || pulled != c.pulled {
+			t.Fatalf("%s/%s: pulled=%v err=%v, want pulled=%v", c.image, c.policy, pulled, err, c.pulled)
+		}
+	}
+
+	b, _ := os.ReadFile(calls)
+	if strings.Count(string(b), "pull ") != 2 {
+		t.Fatalf("expected exactly 2 docker pull calls, got:\n%s", b)
+	}
+}
+
+func TestReapOrphanContainers_RemovesOnlyContainersWithoutOwner(t *testing.T) {
+	tmp := t.TempDir()
+	removed := filepath.Join(tmp, "removed")
+
+	// processo "vivo" de outro gateway neste host: o próprio teste
+	live := os.Getpid()
+	trackContainer("mcp-gw-mine-live")
+	defer ReleaseContainer("mcp-gw-mine-live")
+
+	ps := strings.Join([]string{
+		"mcp-gw-mine-live\t" + instanceID + "\t" + hostname + "\t1",
+		"mcp-gw-mine-leaked\t" + instanceID + "\t" + hostname + "\t1",
+		fmt.Sprintf("mcp-gw-other-live\tother\t%s\t%d", hostname, live),
+		"mcp-gw-other-dead\tother\t" + hostname + "\t999999999",
+		"mcp-gw-remote\tother\tanother-host\t999999999",
+	}, "\n")
+	if err := os.WriteFile(filepath.Join(tmp, "ps"), []byte(ps+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\ncase \"$1\" in\n" +
+		"  ps) cat " + filepath.Join(tmp, "ps") + " ;;\n" +
+		"  rm) shift; shift; echo \"$@\" > " + removed + " ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(filepath.Join(tmp, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	got, err := ReapOrphanContainers(context.Background())
+	if err != nil {
+		t.Fatalf("reap: %v", err)
+	}
+	want := []string{"mcp-gw-mine-leaked", "mcp-gw-other-dead"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("orphans = %v, want %v", got, want)
+	}
+	b, _ := os.ReadFile(removed)
+	if strings.TrimSpace(string(b)) != strings.Join(want, " ") {
+		t.Fatalf("docker rm -f got %q", b)
+	}
+}
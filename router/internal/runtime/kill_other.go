@@ -0,0 +1,103 @@
+//go:build !windows
+
+package runtime
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// KillProcessGrace tenta encerrar o processo de forma graciosa e, se necessário, força a morte:
+//  1. SIGTERM no grupo (process tree inteira)
+//  2. espera até grace o processo morrer
+//  3. SIGKILL no grupo como fallback
+func KillProcessGrace(cmd *exec.Cmd, grace time.Duration) {
+	if grace <= 0 {
+		grace = DefaultKillGrace
+	}
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+
+	pid := cmd.Process.Pid
+
+	// Descobre o PGID real; não assuma que PGID == PID.
+	pgid, err := syscall.Getpgid(pid)
+	if err != nil {
+		// Fallback: tenta no processo direto.
+		_ = cmd.Process.Signal(syscall.SIGTERM)
+		waitForExit(cmd.Process, grace)
+		_ = cmd.Process.Kill()
+		return
+	}
+
+	// 1) SIGTERM no grupo inteiro (process tree).
+	_ = syscall.Kill(-pgid, syscall.SIGTERM)
+
+	// 2) Espera graciosa: dá tempo pro helper escrever marker e sair.
+	if waitForExit(cmd.Process, grace) {
+		return
+	}
+
+	// 3) Força: SIGKILL no grupo.
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+
+	// Espera um pouco pra evitar deixar processo em estado esquisito (best effort).
+	_ = waitForExit(cmd.Process, 500*time.Millisecond)
+}
+
+// ForceKill manda SIGKILL no grupo do processo, sem espera.
+func ForceKill(cmd *exec.Cmd) {
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+	if pgid, err := syscall.Getpgid(cmd.Process.Pid); err == nil {
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+		return
+	}
+	_ = cmd.Process.Kill()
+}
+
+// KillOSProcess mantém compatibilidade com usos antigos.
+// Tenta SIGTERM + SIGKILL no PID (não no grupo).
+func KillOSProcess(p *os.Process) error {
+	if p == nil {
+		return nil
+	}
+
+	// Best-effort gracioso
+	_ = p.Signal(syscall.SIGTERM)
+	if waitForExit(p, 500*time.Millisecond) {
+		return nil
+	}
+	return p.Kill()
+}
+
+// waitForExit retorna true se o processo já tiver saído dentro do timeout.
+// Implementação por polling usando Signal(0).
+func waitForExit(p *os.Process, timeout time.Duration) bool {
+	if p == nil {
+		return true
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		// Signal(0) não envia sinal, só testa existência/permissão.
+		err := p.Signal(syscall.Signal(0))
+		if err != nil {
+			// Se não existe mais, ótimo.
+			// Nota: alguns erros podem ser permissão; aqui, para processo filho nosso,
+			// o mais comum é "os: process already finished".
+			if errors.Is(err, os.ErrProcessDone) {
+				return true
+			}
+			// Em geral, erro aqui indica que o processo não está mais rodando.
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+//go:build windows
+
+package runtime
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// KillProcessGrace no Windows: não há SIGTERM nem PGID como em Unix, então o processo
+// é morto direto (Process.Kill). grace fica só pela assinatura comum.
+func KillProcessGrace(cmd *exec.Cmd, grace time.Duration) {
+	_ = grace
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+	_ = cmd.Process.Kill()
+	_ = waitForExit(cmd.Process, 500*time.Millisecond)
+}
+
+// ForceKill mata o processo (Process.Kill), sem espera.
+func ForceKill(cmd *exec.Cmd) {
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+	_ = cmd.Process.Kill()
+}
+
+// KillOSProcess mantém compatibilidade com usos antigos (Process.Kill).
+func KillOSProcess(p *os.Process) error {
+	if p == nil {
+		return nil
+	}
+	return p.Kill()
+}
+
+// waitForExit retorna true se o processo já tiver saído dentro do timeout. O Signal(0) do
+// Unix não existe aqui: espera no handle do processo (WaitForSingleObject).
+func waitForExit(p *os.Process, timeout time.Duration) bool {
+	if p == nil {
+		return true
+	}
+	h, err := windows.OpenProcess(windows.SYNCHRONIZE, false, uint32(p.Pid))
+	if err != nil {
+		// sem handle: o processo já não existe
+		return true
+	}
+	defer windows.CloseHandle(h)
+	ev, err := windows.WaitForSingleObject(h, uint32(timeout/time.Millisecond))
+	return err != nil || ev == windows.WAIT_OBJECT_0
+}
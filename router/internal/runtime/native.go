@@ -6,7 +6,6 @@ import (
 	"log"
 	"os"
 	"os/exec"
-	"syscall"
 
 	"mcp-router/internal/config"
 )
@@ -19,30 +18,25 @@ func (NativeRuntime) Spawn(
 	tool config.Tool,
 ) (*exec.Cmd, io.WriteCloser, io.ReadCloser, io.ReadCloser, error) {
 
-	env := append(os.Environ(),
-		"WORKSPACE_ROOT="+cfg.WorkspaceRoot,
-		"TOOLS_ROOT="+cfg.ToolsRoot,
-	)
-
-	// IMPORTANTE:
-	// NÃO usar exec.CommandContext aqui.
-	// O cancel do ctx deve ser tratado explicitamente com KillProcess,
-	// para garantir SIGTERM antes de SIGKILL.
-	cmd := exec.Command(tool.Cmd, tool.Args...)
-	cmd.Env = env
-
-	// Cria um novo process group (necessário para matar a árvore inteira).
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
+	cmd, err := nativeCommand(cfg, tool)
+	if err != nil {
+		return nil, nil, nil, nil, err
 	}
+	cmd.Env = append(toolEnviron(cfg), append(append(append(cmd.Env, deadlineEnv(ctx)...), requestIDEnv(ctx)...), proxyEnv(ctx)...)...)
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
+	// tty: stdout num pty (CLIs que mudam de comportamento/bufferizam sem terminal)
+	var stdout io.ReadCloser
+	var tts *os.File
+	if tool.TTY {
+		if _, stdout, tts, err = attachTTY(cmd, false); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	} else if stdout, err = cmd.StdoutPipe(); err != nil {
 		return nil, nil, nil, nil, err
 	}
 
@@ -57,7 +51,14 @@ func (NativeRuntime) Spawn(
 		tool.Args,
 	)
 
-	if err := cmd.Start(); err != nil {
+	err = cmd.Start()
+	if tts != nil {
+		_ = tts.Close() // o filho tem a própria cópia
+	}
+	if err != nil {
+		if tts != nil {
+			_ = stdout.Close()
+		}
 		return nil, nil, nil, nil, err
 	}
 
@@ -73,20 +74,74 @@ func (NativeRuntime) Spawn(
 		<-ctx.Done()
 
 		log.Printf(
-			"[native] ctx canceled for pid=%d, invoking KillProcess",
-			cmd.Process.Pid,
+			"[native] ctx canceled for pid=%d, stopping (shutdown=%s)",
+			cmd.Process.Pid, tool.ShutdownEffective(),
 		)
 
-		// Fecha stdin para ferramentas que saem por EOF
-		_ = stdin.Close()
-
-		KillProcess(cmd)
+		// EOF no stdin e/ou SIGTERM conforme tool.shutdown
+		StopProcess(cmd, stdin, tool)
 
 		log.Printf(
-			"[native] KillProcess finished for pid=%d",
+			"[native] stop finished for pid=%d",
 			cmd.Process.Pid,
 		)
 	}()
 
 	return cmd, stdin, stdout, stderr, nil
 }
+
+// nativeCommand monta o processo da tool sem iniciá-lo (usado pelo Spawn e pelo dry-run).
+// cmd.Env tem só as variáveis que o gateway acrescenta ao ambiente herdado (toolEnviron).
+func nativeCommand(cfg *config.Config, tool config.Tool) (*exec.Cmd, error) {
+	ws, workdir, err := toolWorkspace(cfg, tool)
+	if err != nil {
+		return nil, err
+	}
+
+	// workspace_access=none: a tool não recebe o caminho do workspace
+	access := tool.WorkspaceAccessEffective()
+	env := []string{"TOOLS_ROOT=" + cfg.ToolsRoot, "WORKSPACE_ACCESS=" + access}
+	if access != config.WorkspaceAccessNone {
+		env = append(env, "WORKSPACE_ROOT="+ws)
+	}
+
+	// IMPORTANTE:
+	// NÃO usar exec.CommandContext aqui.
+	// O cancel do ctx deve ser tratado explicitamente com KillProcess,
+	// para garantir SIGTERM antes de SIGKILL.
+	var cmd *exec.Cmd
+	if tool.Sandbox == config.SandboxBwrap {
+		// o cwd é definido dentro do sandbox (--chdir)
+		if cmd, err = bwrapCommand(cfg, tool, ws, workdir); err != nil {
+			return nil, err
+		}
+	} else {
+		cmd = exec.Command(tool.Cmd, tool.Args...)
+		switch {
+		case workdir != "":
+			cmd.Dir = workdir
+		case tool.WorkspaceSubdir != "":
+			cmd.Dir = ws
+		}
+	}
+	cmd.Env = env
+
+	// Cria um novo process group (necessário para matar a árvore inteira).
+	cmd.SysProcAttr = processGroupAttr()
+
+	// user: troca uid/gid (exige gateway root, exceto para o próprio usuário).
+	// Como root, zera os grupos suplementares herdados.
+	if uid, gid, ok, err := toolCredential(cfg, tool); err != nil {
+		return nil, err
+	} else if ok {
+		if access != config.WorkspaceAccessNone {
+			if err := checkWorkspaceReadable(ws, uid, gid); err != nil {
+				return nil, err
+			}
+		}
+		if err := setCredential(cmd, uid, gid); err != nil {
+			return nil, err
+		}
+	}
+	return cmd, nil
+}
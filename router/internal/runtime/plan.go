@@ -0,0 +1,96 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"mcp-router/internal/config"
+)
+
+// SpawnPlan é o processo que o Spawn criaria para a tool, resolvido sem executar nada
+// (POST /mcp/<tool>?dry_run=1, mcp-gw tools plan). Env lista só o que o gateway acrescenta
+// ao ambiente herdado. Container: o nome em --name é gerado de novo a cada spawn.
+type SpawnPlan struct {
+	Runtime   string    `json:"runtime"`
+	Path      string    `json:"path"`
+	Args      []string  `json:"args"` // argv completo (args[0] incluso)
+	Dir       string    `json:"dir,omitempty"`
+	Env       []string  `json:"env"`
+	User      string    `json:"user,omitempty"`
+	Mounts    []string  `json:"mounts,omitempty"`
+	Hardening Hardening `json:"hardening"`
+	Warnings  []string  `json:"warnings,omitempty"`
+}
+
+// Hardening resume o isolamento aplicado ao processo.
+type Hardening struct {
+	Network          string   `json:"network,omitempty"`
+	ReadOnly         bool     `json:"read_only"`
+	NoNewPrivileges  bool     `json:"no_new_privileges"`
+	CapDrop          string   `json:"cap_drop,omitempty"`
+	SeccompProfile   string   `json:"seccomp_profile,omitempty"`
+	AppArmorProfile  string   `json:"apparmor_profile,omitempty"`
+	ContainerRuntime string   `json:"container_runtime,omitempty"`
+	Sandbox          string   `json:"sandbox,omitempty"`
+	EgressAllow      []string `json:"egress_allow,omitempty"` // native: destinos liberados no proxy de saída
+}
+
+// Plan monta o comando da tool exatamente como o Spawn (mesmos builders), mas não o inicia.
+// Erros de montagem (mount fora de mount_roots, user inválido, bwrap ausente) são os
+// mesmos que o spawn real devolveria.
+func Plan(ctx context.Context, cfg *config.Config, tool config.Tool) (SpawnPlan, error) {
+	var (
+		cmd *exec.Cmd
+		err error
+	)
+	plan := SpawnPlan{Runtime: tool.Runtime}
+	switch tool.Runtime {
+	case "native":
+		if cmd, err = nativeCommand(cfg, tool); err != nil {
+			return SpawnPlan{}, err
+		}
+		if uid, gid, ok, _ := toolCredential(cfg, tool); ok {
+			plan.User = fmt.Sprintf("%d:%d", uid, gid)
+		}
+		if tool.Sandbox == config.SandboxBwrap {
+			plan.Hardening = Hardening{Network: tool.DockerNetworkEffective(), Sandbox: tool.Sandbox}
+		}
+		if tool.EgressAllow != nil {
+			// HTTP_PROXY/HTTPS_PROXY entram no spawn (a URL tem credenciais por processo)
+			plan.Hardening = Hardening{Network: "egress_proxy", EgressAllow: tool.EgressAllow}
+		}
+	case "container":
+		if cmd, _, err = dockerCommand(ctx, cfg, tool); err != nil {
+			return SpawnPlan{}, err
+		}
+		plan.Hardening = Hardening{
+			Network:          tool.DockerNetworkEffective(),
+			ReadOnly:         tool.ReadOnlyEffective(),
+			NoNewPrivileges:  true,
+			CapDrop:          "ALL",
+			AppArmorProfile:  tool.AppArmorProfile,
+			ContainerRuntime: tool.ContainerRuntime,
+		}
+		for i := 0; i+1 < len(cmd.Args); i++ {
+			switch a, v := cmd.Args[i], cmd.Args[i+1]; {
+			case a == "--user":
+				plan.User = v
+			case a == "-v":
+				plan.Mounts = append(plan.Mounts, v)
+			case a == "--security-opt" && strings.HasPrefix(v, "seccomp="):
+				plan.Hardening.SeccompProfile = strings.TrimPrefix(v, "seccomp=")
+			}
+		}
+	default:
+		return SpawnPlan{}, fmt.Errorf("invalid runtime: %s", tool.Runtime)
+	}
+
+	plan.Path, plan.Args, plan.Dir, plan.Env = cmd.Path, cmd.Args, cmd.Dir, cmd.Env
+	// executável fora do PATH: o spawn falharia, mas o resto do plano ainda ajuda a depurar
+	if cmd.Err != nil {
+		plan.Warnings = append(plan.Warnings, cmd.Err.Error())
+	}
+	return plan, nil
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package runtime
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// processGroupAttr põe o processo num grupo próprio: KillProcess sinaliza o grupo inteiro
+// (a árvore da tool), nunca o do gateway.
+func processGroupAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// setCredential troca o uid/gid do processo. Como root, zera os grupos suplementares herdados.
+func setCredential(cmd *exec.Cmd, uid, gid int) error {
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:         uint32(uid),
+		Gid:         uint32(gid),
+		NoSetGroups: os.Geteuid() != 0,
+	}
+	return nil
+}
+
+// fileOwner devolve o dono (uid/gid) de fi; ok=false se o sistema não informa.
+func fileOwner(fi fs.FileInfo) (uid, gid int, ok bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(st.Uid), int(st.Gid), true
+}
+
+func pidAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,47 @@
+//go:build windows
+
+package runtime
+
+import (
+	"errors"
+	"io/fs"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// processGroupAttr: grupo de console próprio (CREATE_NEW_PROCESS_GROUP), o equivalente
+// mais próximo do Setpgid — um Ctrl+C no console do gateway não chega à tool.
+func processGroupAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// setCredential: tools[].user depende de uid/gid, que não existem no Windows.
+func setCredential(cmd *exec.Cmd, uid, gid int) error {
+	_ = cmd
+	return errors.New("tool user is not supported on windows")
+}
+
+// fileOwner: o Windows não tem dono uid/gid (ACLs); ok=false pula a checagem.
+func fileOwner(fi fs.FileInfo) (uid, gid int, ok bool) {
+	_ = fi
+	return 0, 0, false
+}
+
+func pidAlive(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// existe, mas é de outro usuário
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
+
+// stillActive é o exit code de um processo ainda rodando (STILL_ACTIVE).
+const stillActive = 259
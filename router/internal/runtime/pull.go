@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
+)
+
+// ImagePresent indica se a imagem já existe no daemon local.
+func ImagePresent(ctx context.Context, image string) bool {
+	return exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{.Id}}", image).Run() == nil
+}
+
+// PullImage baixa a imagem conforme a policy (never: não faz nada; if-not-present: só se
+// faltar), logando o progresso do `docker pull` linha a linha em Debug.
+// Retorna pulled=true se houve download.
+func PullImage(ctx context.Context, image, policy string, log *slog.Logger) (pulled bool, err error) {
+	switch policy {
+	case config.PullNever:
+		return false, nil
+	case config.PullIfNotPresent, "":
+		if ImagePresent(ctx, image) {
+			return false, nil
+		}
+	}
+
+	start := time.Now()
+	log = log.With(logging.String("image", image))
+	log.Info("pulling image", logging.String("pull_policy", policy))
+
+	cmd := exec.CommandContext(ctx, "docker", "pull", image)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
+
+	lines := 0
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		lines++
+		log.Debug("image pull progress", slog.String("progress", sc.Text()))
+	}
+	if err := cmd.Wait(); err != nil {
+		log.Error("image pull failed", logging.Err(err), logging.DurationMs(time.Since(start).Milliseconds()))
+		return false, fmt.Errorf("docker pull %s: %w", image, err)
+	}
+
+	log.Info("image pulled",
+		logging.Int("progress_lines", lines),
+		logging.DurationMs(time.Since(start).Milliseconds()),
+	)
+	return true, nil
+}
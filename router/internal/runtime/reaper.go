@@ -0,0 +1,133 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Labels de todo `docker run` do gateway. O reaper usa instance/host/pid para decidir se
+// o dono do container ainda existe; request é metadado para inspeção (`docker ps`).
+const (
+	LabelManaged  = "mcp-gw.managed"
+	LabelInstance = "mcp-gw.instance"
+	LabelHost     = "mcp-gw.host"
+	LabelPID      = "mcp-gw.pid"
+	LabelRequest  = "mcp-gw.request"
+)
+
+// instanceID identifica este processo do gateway (muda a cada start).
+var instanceID = func() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}()
+
+var hostname, _ = os.Hostname()
+
+// liveContainers são os containers deste processo cujo docker CLI ainda é nosso
+// (do Spawn até o kill/saída). Fora daqui, um container da própria instância é órfão.
+var liveContainers = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+func trackContainer(name string) {
+	liveContainers.Lock()
+	liveContainers.names[name] = true
+	liveContainers.Unlock()
+}
+
+// ReleaseContainer marca o container como encerrado pelo gateway (após o kill/saída do
+// docker CLI). Se ele ainda existir no daemon, o próximo ciclo do reaper o remove.
+func ReleaseContainer(name string) {
+	if name == "" {
+		return
+	}
+	liveContainers.Lock()
+	delete(liveContainers.names, name)
+	liveContainers.Unlock()
+}
+
+func containerLive(name string) bool {
+	liveContainers.Lock()
+	defer liveContainers.Unlock()
+	return liveContainers.names[name]
+}
+
+// dockerLabels monta os --label do container (request vazio = sessão de reuse).
+func dockerLabels(requestID string) []string {
+	labels := []string{
+		"--label", LabelManaged + "=1",
+		"--label", LabelInstance + "=" + instanceID,
+		"--label", LabelHost + "=" + hostname,
+		"--label", LabelPID + "=" + strconv.Itoa(os.Getpid()),
+	}
+	if requestID != "" {
+		labels = append(labels, "--label", LabelRequest+"="+requestID)
+	}
+	return labels
+}
+
+// managedContainer é uma linha do `docker ps` filtrado pelo label do gateway.
+type managedContainer struct {
+	Name, Instance, Host string
+	PID                  int
+}
+
+// orphaned decide se o dono do container sumiu:
+//   - desta instância: o docker CLI não é mais nosso (request terminou, mas o container ficou)
+//   - de outra instância neste host: o processo do gateway dono não existe mais
+//   - de outro host: nunca (não há como saber se o dono está vivo)
+func orphaned(c managedContainer, pidAlive func(int) bool) bool {
+	switch {
+	case c.Instance == instanceID:
+		return !containerLive(c.Name)
+	case c.Host != hostname:
+		return false
+	case c.PID <= 0:
+		return true
+	default:
+		return !pidAlive(c.PID)
+	}
+}
+
+// ReapOrphanContainers lista os containers com o label do gateway e remove (docker rm -f)
+// os órfãos — sobras de um gateway morto sem cleanup (kill -9, OOM, crash). Retorna os removidos.
+func ReapOrphanContainers(ctx context.Context) ([]string, error) {
+	format := fmt.Sprintf(`{{.Names}}\t{{.Label %q}}\t{{.Label %q}}\t{{.Label %q}}`, LabelInstance, LabelHost, LabelPID)
+	out, err := exec.CommandContext(ctx, "docker", "ps", "-a", "--filter", "label="+LabelManaged+"=1", "--format", format).Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker ps: %w", err)
+	}
+
+	var orphans []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		f := strings.Split(line, "\t")
+		if len(f) != 4 || f[0] == "" {
+			continue
+		}
+		pid, _ := strconv.Atoi(f[3])
+		if orphaned(managedContainer{Name: f[0], Instance: f[1], Host: f[2], PID: pid}, pidAlive) {
+			orphans = append(orphans, f[0])
+		}
+	}
+	if len(orphans) == 0 {
+		return nil, nil
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", append([]string{"rm", "-f"}, orphans...)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return orphans, fmt.Errorf("docker rm: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return orphans, nil
+}
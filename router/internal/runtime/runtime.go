@@ -4,11 +4,24 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
 
 	"mcp-router/internal/config"
+	"mcp-router/internal/observability/logging"
 )
 
+// DeadlineEnv traz o prazo da execução (epoch Unix em ms) para a tool: tools bem
+// comportadas limitam o trabalho sozinhas em vez de levar SIGKILL no meio de uma escrita.
+const DeadlineEnv = "MCP_DEADLINE_UNIX_MS"
+
+// RequestIDEnv traz o request_id da execução para a tool, que pode repeti-lo nos próprios
+// logs e fechar a correlação com os logs do gateway.
+const RequestIDEnv = "MCP_REQUEST_ID"
+
 type Runtime interface {
 	Spawn(ctx context.Context, cfg *config.Config, tool config.Tool) (*exec.Cmd, io.WriteCloser, io.ReadCloser, io.ReadCloser, error)
 	//                                             cmd      stdin          stdout         stderr
@@ -25,3 +38,64 @@ func FromTool(tool config.Tool) (Runtime, error) {
 	}
 
 }
+
+type proxyKey struct{}
+
+// WithProxy grava no ctx a URL do proxy de saída (egress_allow) que o Spawn injeta nas
+// variáveis de proxy da tool. "" não altera ctx.
+func WithProxy(ctx context.Context, proxyURL string) context.Context {
+	if proxyURL == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, proxyKey{}, proxyURL)
+}
+
+// proxyEnv são as variáveis de proxy (maiúsculas e minúsculas: cada cliente HTTP lê
+// um jeito) quando ctx tem proxy; NO_PROXY vazio para nenhum destino escapar dele.
+func proxyEnv(ctx context.Context) []string {
+	u, _ := ctx.Value(proxyKey{}).(string)
+	if u == "" {
+		return nil
+	}
+	return []string{
+		"HTTP_PROXY=" + u, "HTTPS_PROXY=" + u, "ALL_PROXY=" + u,
+		"http_proxy=" + u, "https_proxy=" + u, "all_proxy=" + u,
+		"NO_PROXY=", "no_proxy=",
+	}
+}
+
+// toolEnviron é o ambiente do gateway herdado pelas tools, sem os segredos do gateway
+// (cfg.SecretEnvNames): uma tool, mesmo em sandbox, não lê o token admin nem as chaves
+// dos clientes.
+func toolEnviron(cfg *config.Config) []string {
+	secrets := cfg.SecretEnvNames()
+	env := os.Environ()
+	out := env[:0:0]
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if !slices.Contains(secrets, name) {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// deadlineEnv é DeadlineEnv=<ms> quando ctx tem prazo. Processos de pool e de sessão
+// sobrevivem à request e não recebem prazo.
+func deadlineEnv(ctx context.Context) []string {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	return []string{DeadlineEnv + "=" + strconv.FormatInt(d.UnixMilli(), 10)}
+}
+
+// requestIDEnv é RequestIDEnv=<id> quando ctx tem request_id. Processos de pool e de
+// sessão nascem fora de uma request e não recebem.
+func requestIDEnv(ctx context.Context) []string {
+	rid := logging.RequestIDFromContext(ctx)
+	if rid == "" {
+		return nil
+	}
+	return []string{RequestIDEnv + "=" + rid}
+}
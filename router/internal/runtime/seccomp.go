@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"mcp-router/internal/config"
+)
+
+// strictSeccompProfile é o perfil "strict" embutido: por cima do default do docker, nega
+// syscalls de administração do kernel, namespaces, montagem, módulos, ptrace e keyring.
+//
+//go:embed profiles/seccomp-strict.json
+var strictSeccompProfile []byte
+
+var (
+	strictSeccompOnce sync.Once
+	strictSeccompPath string
+	strictSeccompErr  error
+)
+
+// seccompProfilePath resolve o arquivo passado ao docker ("strict" é materializado uma vez
+// em TMPDIR, com hash no nome; o docker CLI lê o arquivo no lado do cliente).
+func seccompProfilePath(profile string) (string, error) {
+	if profile != config.SeccompProfileStrict {
+		if _, err := os.Stat(profile); err != nil {
+			return "", fmt.Errorf("seccomp_profile: %w", err)
+		}
+		return profile, nil
+	}
+
+	strictSeccompOnce.Do(func() {
+		sum := sha256.Sum256(strictSeccompProfile)
+		p := filepath.Join(os.TempDir(), "mcp-gw-seccomp-strict-"+hex.EncodeToString(sum[:6])+".json")
+		if b, err := os.ReadFile(p); err == nil && string(b) == string(strictSeccompProfile) {
+			strictSeccompPath = p
+			return
+		}
+		tmp, err := os.CreateTemp(filepath.Dir(p), ".mcp-gw-seccomp-*")
+		if err == nil {
+			_, err = tmp.Write(strictSeccompProfile)
+			if cerr := tmp.Close(); err == nil {
+				err = cerr
+			}
+			if err == nil {
+				err = os.Rename(tmp.Name(), p)
+			}
+			if err != nil {
+				_ = os.Remove(tmp.Name())
+			}
+		}
+		strictSeccompPath, strictSeccompErr = p, err
+	})
+	if strictSeccompErr != nil {
+		return "", fmt.Errorf("seccomp_profile strict: %w", strictSeccompErr)
+	}
+	return strictSeccompPath, nil
+}
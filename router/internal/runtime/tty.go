@@ -0,0 +1,103 @@
+//go:build !windows
+
+package runtime
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// ttySize é o tamanho reportado às tools com tty (algumas CLIs formatam pela largura).
+var ttySize = pty.Winsize{Rows: 24, Cols: 120}
+
+// attachTTY liga o cmd a um pty (tty: true). Native: só o stdout vai para o pty e o stdin
+// continua pipe (sem eco). Container (withStdin): o docker CLI exige stdin TTY com -i -t,
+// então stdin e stdout vão para o pty, com eco desligado do nosso lado.
+//
+// O cmd vira líder de sessão com o pty como terminal de controle (Setsid: o pgid continua
+// igual ao pid, então o KillProcess segue matando a árvore). Retorna o stdin (nil sem
+// withStdin), o stdout e o lado tty, que o chamador fecha após o Start.
+func attachTTY(cmd *exec.Cmd, withStdin bool) (io.WriteCloser, io.ReadCloser, *os.File, error) {
+	ptmx, tts, err := pty.Open()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// o pty.Open deixa o ptmx bloqueante (Fd()); uma cópia não bloqueante vai para o poller
+	// do runtime, assim o Close desbloqueia leituras pendentes
+	master, err := pollableFile(ptmx)
+	if err != nil {
+		_ = tts.Close()
+		return nil, nil, nil, err
+	}
+	fail := func(err error) (io.WriteCloser, io.ReadCloser, *os.File, error) {
+		_ = master.Close()
+		_ = tts.Close()
+		return nil, nil, nil, err
+	}
+	if err := pty.Setsize(tts, &ttySize); err != nil {
+		return fail(err)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = false // incompatível com Setsid (que já cria o grupo)
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.Stdout = tts
+	cmd.SysProcAttr.Ctty = 1
+
+	var stdin io.WriteCloser
+	if withStdin {
+		if err := disableEcho(tts); err != nil {
+			return fail(err)
+		}
+		cmd.Stdin = tts
+		cmd.SysProcAttr.Ctty = 0
+		stdin = ptyStdin{master}
+	}
+	return stdin, ptyReader{master}, tts, nil
+}
+
+func pollableFile(f *os.File) (*os.File, error) {
+	defer f.Close()
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		_ = syscall.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), f.Name()), nil
+}
+
+// ptyReader é o stdout de uma tool com tty. No Linux, ler o master depois que o último
+// processo fechou o tty dá EIO: para o scanner isso é só o fim da saída.
+type ptyReader struct{ f *os.File }
+
+func (r ptyReader) Read(b []byte) (int, error) {
+	n, err := r.f.Read(b)
+	if err != nil && errors.Is(err, syscall.EIO) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r ptyReader) Close() error { return r.f.Close() }
+
+// ptyStdin escreve no master; Close manda VEOF (^D) em vez de fechar, pois o mesmo master
+// carrega o stdout. Em modo canônico, ^D no início da linha é EOF para quem lê o tty.
+type ptyStdin struct{ f *os.File }
+
+func (w ptyStdin) Write(b []byte) (int, error) { return w.f.Write(b) }
+
+func (w ptyStdin) Close() error {
+	_, err := w.f.Write([]byte{0x04})
+	return err
+}
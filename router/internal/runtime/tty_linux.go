@@ -0,0 +1,22 @@
+//go:build linux
+
+package runtime
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// disableEcho tira o ECHO do tty (o input enviado pelo gateway não volta no stdout).
+func disableEcho(tts *os.File) error {
+	var t syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, tts.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return errno
+	}
+	t.Lflag &^= syscall.ECHO
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, tts.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !windows
+
+package runtime
+
+import "os"
+
+// disableEcho: fora do Linux o eco fica como está (o docker CLI põe o tty em raw de qualquer forma).
+func disableEcho(tts *os.File) error {
+	_ = tts
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package runtime
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// attachTTY: sem pty no Windows (ConPTY não é suportado), tty: true falha no spawn.
+func attachTTY(cmd *exec.Cmd, withStdin bool) (io.WriteCloser, io.ReadCloser, *os.File, error) {
+	_, _ = cmd, withStdin
+	return nil, nil, nil, errors.New("tty is not supported on windows")
+}
@@ -0,0 +1,58 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"mcp-router/internal/config"
+)
+
+// toolCredential resolve o uid/gid efetivo da tool (ok=false: herda o usuário do gateway).
+func toolCredential(cfg *config.Config, tool config.Tool) (uid, gid int, ok bool, err error) {
+	u := cfg.ToolUser(tool)
+	if u == "" {
+		return 0, 0, false, nil
+	}
+	uid, gid, err = config.ParseUser(u)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return uid, gid, true, nil
+}
+
+// checkWorkspaceReadable falha cedo (erro claro no spawn, não um EACCES dentro da tool)
+// quando o diretório não é legível+atravessável por uid/gid. Diretório inexistente passa:
+// não há nada para ler ainda. Grupos suplementares não são considerados.
+func checkWorkspaceReadable(dir string, uid, gid int) error {
+	if uid == 0 {
+		return nil
+	}
+	fi, err := os.Stat(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	owner, group, ok := fileOwner(fi)
+	if !ok {
+		return nil
+	}
+
+	perm := fi.Mode().Perm()
+	var need fs.FileMode
+	switch {
+	case owner == uid:
+		need = 0o500
+	case group == gid:
+		need = 0o050
+	default:
+		need = 0o005
+	}
+	if perm&need != need {
+		return fmt.Errorf("workspace %s (mode %s, owner %d:%d) is not readable by user %d:%d", dir, perm, owner, group, uid, gid)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package runtime
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/sandbox"
+)
+
+// containerWorkspace é onde o workspace da tool aparece dentro do container.
+const containerWorkspace = "/workspaces"
+
+// toolWorkspace resolve o workspace visível para a tool (workspace_root ou
+// <workspace_root>/<workspace_subdir>) e o workdir dentro dele ("" = não definido).
+// Ambos passam pelo sandbox.ValidatePath (traversal, encoding, symlinks que escapam).
+func toolWorkspace(cfg *config.Config, tool config.Tool) (ws, workdir string, err error) {
+	ws = cfg.WorkspaceRoot
+	if tool.WorkspaceSubdir != "" {
+		if ws, err = sandbox.ValidatePath(cfg.WorkspaceRoot, tool.WorkspaceSubdir); err != nil {
+			return "", "", fmt.Errorf("workspace_subdir: %w", err)
+		}
+	}
+	if tool.Workdir != "" {
+		if workdir, err = sandbox.ValidatePath(ws, tool.Workdir); err != nil {
+			return "", "", fmt.Errorf("workdir: %w", err)
+		}
+	}
+	return ws, workdir, nil
+}
+
+// containerWorkdir traduz o workdir (relativo ao workspace da tool) para o caminho no container.
+func containerWorkdir(tool config.Tool) string {
+	return path.Join(containerWorkspace, filepath.ToSlash(filepath.Clean(tool.Workdir)))
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package sandbox
+
+// platformCheckPath: sem regras extras fora do Windows.
+func platformCheckPath(p string) error {
+	_ = p
+	return nil
+}
+
+func platformNormalize(p string) string {
+	return p
+}
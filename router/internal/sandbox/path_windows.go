@@ -0,0 +1,16 @@
+//go:build windows
+
+package sandbox
+
+import "strings"
+
+// platformCheckPath aplica as regras de caminho do Windows (ver checkWindowsPath).
+func platformCheckPath(p string) error {
+	return checkWindowsPath(p)
+}
+
+// platformNormalize converte "/" para "\" para que a validação componente a componente
+// enxergue os mesmos segmentos que o filesystem.
+func platformNormalize(p string) string {
+	return strings.ReplaceAll(p, "/", `\`)
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package sandbox
+
+import "testing"
+
+func TestValidatePath_WindowsSemantics(t *testing.T) {
+	ws := t.TempDir()
+
+	for _, p := range []string{`C:\Windows\win.ini`, `\\server\share`, "NUL", "a.txt:ads", "dir."} {
+		if _, err := ValidatePath(ws, p); err == nil {
+			t.Errorf("ValidatePath(%q) = nil, want error", p)
+		}
+	}
+
+	if _, err := ValidatePath(ws, `sub\file.txt`); err != nil {
+		t.Errorf("ValidatePath(sub\\file.txt) = %v, want nil", err)
+	}
+}
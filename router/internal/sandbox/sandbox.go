@@ -93,6 +93,14 @@ func ValidatePath(workspaceRoot, requestedPath string) (string, error) {
 		}
 	}
 
+	// Regras específicas da plataforma (Windows: drive letters, UNC, CON/NUL, ADS)
+	for _, p := range []string{requestedPath, decoded} {
+		if err := platformCheckPath(p); err != nil {
+			return "", fmt.Errorf("invalid path for platform: %w", err)
+		}
+	}
+	decoded = platformNormalize(decoded)
+
 	// Validar componente por componente: cada symlink deve estar dentro do workspace
 	wsRoot = filepath.Clean(wsRoot)
 	pathParts := strings.Split(decoded, string(filepath.Separator))
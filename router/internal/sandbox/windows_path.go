@@ -0,0 +1,69 @@
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Nomes de dispositivo reservados no Windows: abrem o device em qualquer diretório,
+// inclusive com extensão (ex: "nul.txt", "COM1.log").
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true, "CONIN$": true, "CONOUT$": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// checkWindowsPath aplica a semântica de caminhos do Windows a um caminho relativo ao workspace.
+// É pura (sem syscalls) para ser testável em qualquer plataforma; só é ligada em ValidatePath
+// em builds windows (ver path_windows.go).
+//
+// Rejeita:
+// - drive letters (C:\x, C:x — relativo ao cwd do drive)
+// - UNC e device paths (\\server\share, \\?\C:\, \\.\pipe\x, //server)
+// - nomes de dispositivo reservados (CON, NUL, COM1, "nul.txt", "con  ")
+// - alternate data streams (file.txt:stream, file.txt::$DATA)
+// - componentes terminados em ponto/espaço (Windows os remove: "a." == "a")
+func checkWindowsPath(p string) error {
+	if p == "" {
+		return nil
+	}
+
+	norm := strings.ReplaceAll(p, "/", `\`)
+
+	if strings.HasPrefix(norm, `\\`) {
+		return fmt.Errorf("UNC/device path not allowed")
+	}
+	if strings.HasPrefix(norm, `\`) {
+		return fmt.Errorf("path cannot be absolute")
+	}
+	if len(norm) >= 2 && norm[1] == ':' && isASCIILetter(norm[0]) {
+		return fmt.Errorf("drive letter not allowed")
+	}
+
+	for _, comp := range strings.Split(norm, `\`) {
+		if comp == "" || comp == "." {
+			continue
+		}
+		if strings.ContainsRune(comp, ':') {
+			return fmt.Errorf("alternate data stream not allowed: %q", comp)
+		}
+		if strings.HasSuffix(comp, ".") || strings.HasSuffix(comp, " ") {
+			return fmt.Errorf("component with trailing dot/space not allowed: %q", comp)
+		}
+		base := comp
+		if i := strings.IndexByte(base, '.'); i >= 0 {
+			base = base[:i]
+		}
+		base = strings.ToUpper(strings.TrimRight(base, " "))
+		if windowsReservedNames[base] {
+			return fmt.Errorf("reserved device name not allowed: %q", comp)
+		}
+	}
+	return nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
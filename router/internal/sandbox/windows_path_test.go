@@ -0,0 +1,50 @@
+package sandbox
+
+import "testing"
+
+// Matriz cross-platform: checkWindowsPath é pura e roda em qualquer GOOS.
+// Em builds windows ela também é exercitada via ValidatePath (path_windows_test.go).
+func TestCheckWindowsPath_Matrix(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+		why     string
+	}{
+		{"file.txt", false, "plain file"},
+		{`dir\file.txt`, false, "backslash subdir"},
+		{"dir/file.txt", false, "slash subdir"},
+		{"console.log", false, "prefix of reserved name is fine"},
+		{"nullable", false, "prefix of reserved name is fine"},
+		{"COM10", false, "only COM1-9 are reserved"},
+
+		{`C:\Windows\win.ini`, true, "drive absolute"},
+		{"c:win.ini", true, "drive relative"},
+		{`\\server\share\x`, true, "UNC"},
+		{`\\?\C:\x`, true, "extended-length device path"},
+		{`\\.\pipe\evil`, true, "device namespace"},
+		{"//server/share", true, "UNC with forward slashes"},
+		{`\Windows`, true, "rooted path"},
+
+		{"CON", true, "reserved"},
+		{"nul", true, "reserved lower-case"},
+		{"nul.txt", true, "reserved with extension"},
+		{`dir\COM1.log`, true, "reserved in subdir"},
+		{"LPT9", true, "reserved LPT"},
+		{"con  ", true, "reserved with trailing spaces"},
+
+		{"file.txt:secret", true, "ADS"},
+		{"file.txt::$DATA", true, "ADS default stream"},
+
+		{"dir.", true, "trailing dot"},
+		{`dir \file`, true, "trailing space component"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.why+"/"+tt.path, func(t *testing.T) {
+			err := checkWindowsPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkWindowsPath(%q) err=%v, wantErr=%v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
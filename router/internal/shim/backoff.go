@@ -0,0 +1,42 @@
+package shim
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff é o backoff exponencial com jitter dos shims (reconexão, restart).
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration // teto da espera
+}
+
+// Delay é a espera antes da tentativa attempt (1, 2, ...): Initial*2^(attempt-1) limitado
+// a Max, com jitter de até 50% para baixo (clientes não voltam todos ao mesmo tempo).
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Initial
+	for i := 1; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// Sleep espera d ou até ctx ser cancelado (retorna ctx.Err()).
+func Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package shim
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DataFrame é um frame de output binário do gateway (event: data; tools com output: binary).
+type DataFrame struct {
+	Seq  int64  `json:"seq"`
+	Data []byte `json:"data"` // base64 no JSON
+}
+
+// DecodeFrame decodifica o payload de um event: data.
+func DecodeFrame(payload []byte) (DataFrame, error) {
+	var f DataFrame
+	if err := json.Unmarshal(payload, &f); err != nil {
+		return DataFrame{}, fmt.Errorf("invalid data frame: %w", err)
+	}
+	return f, nil
+}
+
+// FrameSequence acompanha o seq dos frames e informa quantos se perderam (slow_client: drop).
+type FrameSequence struct{ next int64 }
+
+// Observe registra o frame e devolve quantos frames faltaram antes dele.
+func (s *FrameSequence) Observe(f DataFrame) int64 {
+	lost := f.Seq - s.next
+	s.next = f.Seq + 1
+	if lost < 0 {
+		return 0
+	}
+	return lost
+}
+
+// LinePart é um pedaço de uma linha gigante do gateway (event: continuation; long_lines: split).
+type LinePart struct {
+	Part int64  `json:"part"`
+	More bool   `json:"more"`
+	Data string `json:"data"`
+}
+
+// DecodeLinePart decodifica o payload de um event: continuation.
+func DecodeLinePart(payload []byte) (LinePart, error) {
+	var p LinePart
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return LinePart{}, fmt.Errorf("invalid continuation: %w", err)
+	}
+	return p, nil
+}
+
+// LineTruncated é o marcador de uma linha gigante cortada (event: line_truncated).
+type LineTruncated struct {
+	LineBytes int64  `json:"line_bytes"`
+	Head      string `json:"head"`
+}
@@ -0,0 +1,199 @@
+package shim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sidecar de status dos shims (--status-addr): um listener HTTP mínimo com GET /healthz
+// (pid, uptime, bytes, restarts, último erro) para ferramentas de desktop detectarem um
+// shim morto ou travado. Um *Status nil é válido e não faz nada (sidecar desligado).
+
+// statusShutdownTimeout limita o shutdown do listener de status.
+const statusShutdownTimeout = 2 * time.Second
+
+// Status acumula o estado exposto no /healthz.
+type Status struct {
+	component string
+	started   time.Time
+	child     bool // o shim supervisiona um processo: sem filho vivo, /healthz responde 503
+
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+
+	mu        sync.Mutex
+	childPID  int
+	childAt   time.Time
+	restarts  int
+	lastErr   string
+	lastErrAt time.Time
+}
+
+// StatusReport é o corpo do GET /healthz.
+type StatusReport struct {
+	Status        string     `json:"status"` // ok | down
+	Component     string     `json:"component"`
+	PID           int        `json:"pid"`
+	UptimeMS      int64      `json:"uptime_ms"`
+	ChildPID      int        `json:"child_pid,omitempty"`
+	ChildUptimeMS int64      `json:"child_uptime_ms,omitempty"`
+	BytesIn       int64      `json:"bytes_in"`
+	BytesOut      int64      `json:"bytes_out"`
+	Restarts      int        `json:"restarts"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+}
+
+// NewStatus cria o estado do sidecar; supervisesChild: o shim roda um processo filho.
+func NewStatus(component string, supervisesChild bool) *Status {
+	return &Status{component: component, started: time.Now(), child: supervisesChild}
+}
+
+// ChildStarted registra o processo filho em execução.
+func (s *Status) ChildStarted(pid int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.childPID, s.childAt = pid, time.Now()
+}
+
+// ChildExited registra o fim do filho (err != nil vira o último erro).
+func (s *Status) ChildExited(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.childPID, s.childAt = 0, time.Time{}
+	s.mu.Unlock()
+	s.SetError(err)
+}
+
+// Restarted conta um restart do filho.
+func (s *Status) Restarted() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restarts++
+}
+
+// SetError registra o último erro (nil não faz nada).
+func (s *Status) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr, s.lastErrAt = err.Error(), time.Now()
+}
+
+// In conta os bytes lidos de r (stdin -> destino).
+func (s *Status) In(r io.Reader) io.Reader {
+	if s == nil {
+		return r
+	}
+	return &countingReader{r: r, n: &s.bytesIn}
+}
+
+// Out conta os bytes escritos em w (destino -> stdout).
+func (s *Status) Out(w io.Writer) io.Writer {
+	if s == nil {
+		return w
+	}
+	return &countingWriter{w: w, n: &s.bytesOut}
+}
+
+// Report é um snapshot do estado.
+func (s *Status) Report() StatusReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	r := StatusReport{
+		Status:    "ok",
+		Component: s.component,
+		PID:       os.Getpid(),
+		UptimeMS:  now.Sub(s.started).Milliseconds(),
+		ChildPID:  s.childPID,
+		BytesIn:   s.bytesIn.Load(),
+		BytesOut:  s.bytesOut.Load(),
+		Restarts:  s.restarts,
+		LastError: s.lastErr,
+	}
+	if s.childPID != 0 {
+		r.ChildUptimeMS = now.Sub(s.childAt).Milliseconds()
+	} else if s.child {
+		r.Status = "down"
+	}
+	if !s.lastErrAt.IsZero() {
+		at := s.lastErrAt.UTC()
+		r.LastErrorAt = &at
+	}
+	return r
+}
+
+// Serve sobe o listener de status em addr (ex: 127.0.0.1:0) até ctx ser cancelado. O
+// endereço efetivo (porta escolhida) sai no log "status listening".
+func (s *Status) Serve(ctx context.Context, addr string, log *slog.Logger) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		rep := s.Report()
+		w.Header().Set("Content-Type", "application/json")
+		if rep.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(rep)
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn("status listener stopped", Err(err))
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), statusShutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+	log.Info("status listening", slog.String("addr", ln.Addr().String()))
+	return nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
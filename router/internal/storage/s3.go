@@ -0,0 +1,300 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"mcp-router/internal/config"
+)
+
+// S3 implementa Store sobre a API S3 (AWS ou MinIO) com assinatura SigV4.
+// Implementação mínima só com stdlib: PUT/GET/DELETE object e ListObjectsV2.
+type S3 struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	prefix    string
+	pathStyle bool
+
+	accessKey string
+	secretKey string
+
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3(cfg config.S3Storage) (*S3, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint %q", cfg.Endpoint)
+	}
+
+	akEnv := cfg.AccessKeyEnv
+	if akEnv == "" {
+		akEnv = "AWS_ACCESS_KEY_ID"
+	}
+	skEnv := cfg.SecretKeyEnv
+	if skEnv == "" {
+		skEnv = "AWS_SECRET_ACCESS_KEY"
+	}
+	ak, sk := os.Getenv(akEnv), os.Getenv(skEnv)
+	if ak == "" || sk == "" {
+		return nil, fmt.Errorf("storage: s3 credentials missing (set %s and %s)", akEnv, skEnv)
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = config.DefaultS3Region
+	}
+
+	return &S3{
+		endpoint:  u,
+		bucket:    cfg.Bucket,
+		region:    region,
+		prefix:    strings.Trim(cfg.Prefix, "/"),
+		pathStyle: cfg.PathStyle,
+		accessKey: ak,
+		secretKey: sk,
+		client:    &http.Client{Timeout: 60 * time.Second},
+		now:       time.Now,
+	}, nil
+}
+
+func (s *S3) objectKey(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	// SigV4 precisa do hash do payload; objetos do gateway são pequenos o bastante para bufferizar.
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPut, s.objectKey(key), nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s.check(resp, key)
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.objectKey(key), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(resp, key); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, s.objectKey(key), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s.check(resp, key)
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]string, error) {
+	full := s.objectKey(prefix)
+	if prefix == "" && s.prefix != "" {
+		full = s.prefix + "/"
+	}
+
+	var keys []string
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {full}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.check(resp, prefix); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		var res listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("storage: decode s3 list: %w", err)
+		}
+		for _, c := range res.Contents {
+			k := c.Key
+			if s.prefix != "" {
+				k = strings.TrimPrefix(k, s.prefix+"/")
+			}
+			keys = append(keys, k)
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			break
+		}
+		token = res.NextContinuationToken
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *S3) check(resp *http.Response, key string) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	default:
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("storage: s3 %s %q: %s: %s", resp.Request.Method, key, resp.Status, strings.TrimSpace(string(b)))
+	}
+}
+
+// do monta e assina (SigV4) uma request para o bucket.
+func (s *S3) do(ctx context.Context, method, objectKey string, query url.Values, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if s.pathStyle {
+		path += "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	path += "/" + objectKey
+	u.Path = path
+	u.RawPath = encodePath(path)
+	u.RawQuery = encodeQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s.sign(req, body)
+	return s.client.Do(req)
+}
+
+func (s *S3) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	k := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	k = hmacSHA256(k, s.region)
+	k = hmacSHA256(k, "s3")
+	k = hmacSHA256(k, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(k, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, sig,
+	))
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+// uriEncode segue as regras do SigV4 (só unreserved ficam literais).
+func uriEncode(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
+
+func encodePath(p string) string {
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		segs[i] = uriEncode(seg)
+	}
+	return strings.Join(segs, "/")
+}
+
+func encodeQuery(q url.Values) string {
+	if len(q) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"mcp-router/internal/config"
+)
+
+// Store é o backend de persistência para saídas do gateway
+// (artefatos, transcripts, resultados de jobs, exports de uso).
+//
+// Chaves são caminhos relativos separados por "/" (ex: "transcripts/2024/abc.jsonl").
+// Implementações devem ser seguras para uso concorrente.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// List retorna as chaves com o prefixo informado (ordem lexicográfica).
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// ErrNotFound é retornado por Get/Delete quando a chave não existe.
+var ErrNotFound = errors.New("storage: key not found")
+
+// New cria o Store configurado (default: disco local).
+func New(cfg config.Storage) (Store, error) {
+	switch cfg.BackendEffective() {
+	case "local":
+		return NewLocal(cfg.PathEffective()), nil
+	case "s3":
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q", cfg.Backend)
+	}
+}
+
+// ValidateKey rejeita chaves que poderiam escapar do root/prefixo do backend.
+func ValidateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("storage: empty key")
+	}
+	if strings.HasPrefix(key, "/") || strings.ContainsRune(key, '\\') {
+		return fmt.Errorf("storage: key must be relative: %q", key)
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return fmt.Errorf("storage: invalid key segment in %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"mcp-router/internal/config"
+)
+
+func roundTrip(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := st.Put(ctx, "transcripts/a.jsonl", strings.NewReader("hello\n")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := st.Put(ctx, "jobs/b.json", strings.NewReader("{}")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	rc, err := st.Get(ctx, "transcripts/a.jsonl")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	b, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(b) != "hello\n" {
+		t.Fatalf("unexpected content %q", b)
+	}
+
+	keys, err := st.List(ctx, "transcripts/")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "transcripts/a.jsonl" {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	if err := st.Delete(ctx, "transcripts/a.jsonl"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := st.Get(ctx, "transcripts/a.jsonl"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestLocal_RoundTrip(t *testing.T) {
+	roundTrip(t, NewLocal(t.TempDir()))
+}
+
+func TestValidateKey_RejectsEscapes(t *testing.T) {
+	for _, k := range []string{"", "/etc/passwd", "../x", "a/../b", "a//b", `a\b`, "a/./b"} {
+		if err := ValidateKey(k); err == nil {
+			t.Errorf("ValidateKey(%q) = nil, want error", k)
+		}
+	}
+}
+
+// fakeS3 é um bucket em memória path-style que exige assinatura SigV4.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AK/") {
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		w.Write([]byte("<ListBucketResult>"))
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				w.Write([]byte("<Contents><Key>" + k + "</Key></Contents>"))
+			}
+		}
+		w.Write([]byte("</ListBucketResult>"))
+	case r.Method == http.MethodPut:
+		b, _ := io.ReadAll(r.Body)
+		f.objects[key] = string(b)
+	case r.Method == http.MethodGet:
+		v, ok := f.objects[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(v))
+	case r.Method == http.MethodDelete:
+		if _, ok := f.objects[key]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3_RoundTripPathStyle(t *testing.T) {
+	srv := httptest.NewServer(&fakeS3{objects: map[string]string{}})
+	defer srv.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AK")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SK")
+
+	st, err := New(config.Storage{
+		Backend: "s3",
+		S3:      config.S3Storage{Endpoint: srv.URL, Bucket: "bucket", PathStyle: true, Prefix: "gw"},
+	})
+	if err != nil {
+		t.Fatalf("new s3: %v", err)
+	}
+	roundTrip(t, st)
+}
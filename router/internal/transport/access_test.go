@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/observability/logging"
+)
+
+func TestAccessLog_CombinedSummaryLine(t *testing.T) {
+	svc := newTestCore(t)
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+
+	var logOut syncBuffer
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.MiddlewareWithAccessLog(mux,
+		logging.AccessLogOptions{Format: logging.AccessLogCombined, Out: &logOut})))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/echo", strings.NewReader(`{"x":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "access-test")
+	req.Header.Set("X-Request-Id", "rid-access")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	line := logOut.String()
+	for _, want := range []string{
+		`"POST /mcp/echo HTTP/1.1" 200 ` + strconv.Itoa(len(body)) + ` "-" "access-test"`,
+		"tool=echo lines_out=1 ",
+		"request_id=rid-access\n",
+	} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("access log %q missing %q", line, want)
+		}
+	}
+	if strings.Count(line, "\n") != 1 {
+		t.Fatalf("expected exactly one access line, got %q", line)
+	}
+}
@@ -0,0 +1,353 @@
+package transport
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/runner"
+	"mcp-router/internal/storage"
+)
+
+// registerAdmin registra os endpoints administrativos (/admin/*).
+// Todos passam por requireAdmin.
+func (h *HTTP) registerAdmin(mux *http.ServeMux) {
+	mux.Handle("/admin/shutdown-report", h.requireAdmin(http.HandlerFunc(h.handleShutdownReport)))
+	mux.Handle("/admin/executions", h.requireAdmin(http.HandlerFunc(h.handleExecutions)))
+	mux.Handle("/admin/executions/", h.requireAdmin(http.HandlerFunc(h.handleExecutionKill)))
+	mux.Handle("/admin/config", h.requireAdmin(http.HandlerFunc(h.handleConfig)))
+	mux.Handle("/admin/config/plan", h.requireAdmin(http.HandlerFunc(h.handleConfigPlan)))
+	mux.Handle("/admin/tools", h.requireAdmin(http.HandlerFunc(h.handleAdminTools)))
+	mux.Handle("/admin/signed-urls", h.requireAdmin(http.HandlerFunc(h.handleSignedURL)))
+	mux.Handle("/admin/sessions", h.requireAdmin(http.HandlerFunc(h.handleAdminSessions)))
+	mux.Handle("/admin/loglevel", h.requireAdmin(http.HandlerFunc(h.handleLogLevel)))
+	mux.Handle("/admin/recordings", h.requireAdmin(http.HandlerFunc(h.handleRecordings)))
+	mux.Handle("/admin/recordings/", h.requireAdmin(http.HandlerFunc(h.handleRecordings)))
+	mux.Handle("/admin/keys", h.requireAdmin(http.HandlerFunc(h.handleKeys)))
+	mux.Handle("/admin/requests/", h.requireAdmin(http.HandlerFunc(h.handleRequestLogs)))
+	mux.Handle("/admin/events", h.requireAdmin(http.HandlerFunc(h.handleAdminEvents)))
+}
+
+// requireAdmin exige "Authorization: Bearer <token>" com o token admin do ambiente ou uma
+// credencial de auth com o papel admin. Sem token nem credencial admin configurados, os
+// endpoints admin ficam desligados (404), nunca abertos.
+func (h *HTTP) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := h.core.AdminToken()
+		roles := h.core.AuthEnabled() && h.core.Auth().HasAdminCredential()
+		if token == "" && !roles {
+			http.NotFound(w, r)
+			return
+		}
+		if h.isAdmin(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !roles || !hasCredentials(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-gw-admin"`)
+			writeError(w, r, http.StatusUnauthorized, core.CodeUnauthorized, "unauthorized")
+			return
+		}
+		r, ok := h.authenticated(w, r)
+		if !ok {
+			return
+		}
+		if !core.PrincipalFromContext(r.Context()).HasRole(config.RoleAdmin) {
+			writeError(w, r, http.StatusForbidden, core.CodeForbidden, "credential lacks the admin role")
+			return
+		}
+		// admin enxerga o gateway inteiro, não o escopo de tools da credencial
+		next.ServeHTTP(w, r.WithContext(core.WithPrincipal(r.Context(), nil)))
+	})
+}
+
+// isAdmin indica se a request traz o bearer token admin válido.
+func (h *HTTP) isAdmin(r *http.Request) bool {
+	token := h.core.AdminToken()
+	if token == "" {
+		return false
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// GET /admin/shutdown-report
+// Último relatório de shutdown persistido + execuções que seriam mortas agora.
+func (h *HTTP) handleShutdownReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	resp := map[string]any{
+		"drain_ms": h.core.ShutdownDrain().Milliseconds(),
+		"inflight": h.core.Executions(),
+	}
+
+	last, err := h.core.LastShutdownReport(r.Context())
+	switch {
+	case err == nil:
+		resp["last"] = last
+	case errors.Is(err, storage.ErrNotFound):
+		resp["last"] = nil
+	default:
+		writeErrorFor(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GET /admin/executions
+// Execuções em andamento (mais antigas primeiro) + slots de concorrência por tool (uso,
+// fila e espera acumulada), para dimensionar max_concurrent.
+func (h *HTTP) handleExecutions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"executions": h.core.Executions(), "slots": h.core.Slots()})
+}
+
+// GET /admin/tools
+// Tools configuradas + saúde das sessões reutilizadas (tools com health).
+func (h *HTTP) handleAdminTools(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	tools, err := h.core.ListTools(r.Context())
+	if err != nil {
+		writeErrorFor(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	health := make(map[string]runner.PoolHealth)
+	for _, ph := range h.core.ToolHealth() {
+		health[ph.Tool] = ph
+	}
+
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	out := make([]map[string]any, 0, len(tools))
+	for _, t := range tools {
+		item := map[string]any{"name": t.Name, "runtime": t.Runtime, "mode": t.Mode}
+		if ph, ok := health[t.Name]; ok {
+			item["health"] = ph
+		}
+		out = append(out, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tools": out})
+}
+
+// DELETE /admin/executions/<id>
+// Mata a execução (o cliente recebe event: error com "execution killed by admin").
+func (h *HTTP) handleExecutionKill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/admin/executions/"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid execution id")
+		return
+	}
+	if !h.core.KillExecution(id) {
+		writeError(w, r, http.StatusNotFound, core.CodeNotFound, "execution not found")
+		return
+	}
+
+	logging.LoggerFromContext(r.Context()).Warn("execution killed by admin", logging.Int64("execution_id", int64(id)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /admin/requests/<request_id>/logs
+// Registros de log do gateway capturados para a request (logging.capture no config).
+func (h *HTTP) handleRequestLogs(w http.ResponseWriter, r *http.Request) {
+	rid, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/admin/requests/"), "/")
+	if rid == "" || action != "logs" {
+		writeError(w, r, http.StatusNotFound, core.CodeNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !logging.CaptureEnabled() {
+		writeError(w, r, http.StatusNotFound, core.CodeNotFound, "log capture disabled (logging.capture.requests)")
+		return
+	}
+	logs, ok := logging.CapturedLogs(rid)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, core.CodeNotFound, "no captured logs for request")
+		return
+	}
+	writeJSON(w, http.StatusOK, logs)
+}
+
+// GET /admin/config[?format=yaml]
+// Config efetivo em execução (includes e profile aplicados, segredos redigidos) + sha256,
+// horário do load e o último resultado da comparação com o arquivo em disco (drift).
+func (h *HTTP) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	info := h.core.ConfigInfo()
+	data, err := yaml.Marshal(h.core.EffectiveConfig())
+	if err != nil {
+		writeErrorFor(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("X-Config-SHA256", info.SHA256)
+	if r.URL.Query().Get("format") == "yaml" {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(data)
+		return
+	}
+
+	// JSON com as mesmas chaves do YAML (os structs do config só têm tags yaml)
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		writeErrorFor(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		core.ConfigInfo
+		Config map[string]any `json:"config"`
+	}{info, doc})
+}
+
+// POST /admin/config/plan
+// Body: config proposto (YAML ou JSON). Dry-run: compara com o config em execução e lista
+// tools adicionadas/alteradas/removidas + execuções em andamento afetadas. Nada é aplicado.
+func (h *HTTP) handleConfigPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid body")
+		return
+	}
+
+	next, err := config.Parse(body)
+	if err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, core.CodeInvalidRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, h.core.PlanConfig(next))
+}
+
+// POST /admin/signed-urls
+// Body: {"tool":"git","input":{...},"ttl_ms":60000}. Emite uma URL de uso único
+// (/mcp/<tool>?id=..&exp=..&sig=..) que executa a tool uma vez com o input fixo, sem token.
+func (h *HTTP) handleSignedURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		Tool  string          `json:"tool"`
+		Input json.RawMessage `json:"input"`
+		TTLMS int64           `json:"ttl_ms"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid body")
+		return
+	}
+	if len(req.Input) == 0 || string(req.Input) == "null" {
+		req.Input = json.RawMessage(`{}`)
+	}
+
+	su, err := h.core.MintSignedURL(req.Tool, req.Input, time.Duration(req.TTLMS)*time.Millisecond)
+	if err != nil {
+		writeErrorFor(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	logging.LoggerFromContext(r.Context()).Info("signed url issued",
+		logging.Tool(su.Tool),
+		logging.String("expires_at", su.ExpiresAt.Format(time.RFC3339)),
+	)
+	writeJSON(w, http.StatusCreated, su)
+}
+
+// LogLevelState é a resposta de /admin/loglevel: nível global e os sobrepostos por tool.
+type LogLevelState struct {
+	Level string            `json:"level"`
+	Tools map[string]string `json:"tools"`
+}
+
+// GET|PUT /admin/loglevel
+// PUT body: {"level":"debug"} troca o nível global; {"tool":"git","level":"debug"} só o
+// da tool; {"tool":"git","level":""} volta a tool para o global. Vale até o restart.
+func (h *HTTP) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req struct {
+			Tool  string `json:"tool"`
+			Level string `json:"level"`
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid body")
+			return
+		}
+		if req.Tool != "" && req.Level == "" {
+			logging.ClearToolLevel(req.Tool)
+		} else {
+			l, err := logging.ParseLevel(req.Level)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, err.Error())
+				return
+			}
+			if req.Tool != "" {
+				if _, ok := h.core.ToolTimeout(req.Tool); !ok {
+					writeError(w, r, http.StatusNotFound, core.CodeUnknownTool, "unknown tool: "+req.Tool)
+					return
+				}
+				logging.SetToolLevel(req.Tool, l)
+			} else {
+				logging.SetLevel(l)
+			}
+		}
+		logging.LoggerFromContext(r.Context()).Warn("log level changed by admin",
+			logging.Tool(req.Tool),
+			logging.String("level", req.Level),
+		)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	st := LogLevelState{Level: strings.ToLower(logging.Level().String()), Tools: map[string]string{}}
+	for name, l := range logging.ToolLevels() {
+		st.Tools[name] = strings.ToLower(l.String())
+	}
+	writeJSON(w, http.StatusOK, st)
+}
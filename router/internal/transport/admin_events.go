@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/events"
+)
+
+const (
+	// adminEventsBuffer é a fila de cada assinante do /admin/events (cheia: eventos descartados).
+	adminEventsBuffer = 256
+	// adminEventsKeepAlive é o intervalo do comentário SSE que mantém proxies e curl conectados.
+	adminEventsKeepAlive = 15 * time.Second
+)
+
+// GET /admin/events[?type=a,b][&tool=<tool>]
+// Stream SSE dos eventos do gateway (request_started, request_finished, tool_busy,
+// process_killed, config_reloaded): event: <type>, data: o JSON do evento. Sem replay,
+// só o que acontece depois de conectar.
+func (h *HTTP) handleAdminEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, core.CodeInternal, "streaming unsupported")
+		return
+	}
+
+	var types map[string]bool
+	if v := r.URL.Query().Get("type"); v != "" {
+		types = map[string]bool{}
+		for _, t := range strings.Split(v, ",") {
+			types[strings.TrimSpace(t)] = true
+		}
+	}
+	tool := r.URL.Query().Get("tool")
+
+	ch, cancel := events.Default.Subscribe(adminEventsBuffer)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	tick := time.NewTicker(adminEventsKeepAlive)
+	defer tick.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-tick.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e := <-ch:
+			if (types != nil && !types[e.Type]) || (tool != "" && e.Tool != tool) {
+				continue
+			}
+			if err := sendSSE(w, e.Type, e); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
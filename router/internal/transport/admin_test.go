@@ -0,0 +1,484 @@
+package transport_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/transport"
+)
+
+func newAdminTestServer(t *testing.T) (*core.Service, *httptest.Server) {
+	t.Helper()
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+	t.Setenv("MCP_TOOL_EXIT_MARKER", "")
+	t.Setenv("MCP_GW_ADMIN_TOKEN", "admintok")
+
+	cfg := &config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"slow": {
+				Runtime:   "native",
+				Mode:      "launcher",
+				Cmd:       os.Args[0],
+				Args:      []string{"__mcp_tool_disconnect_helper__"},
+				TimeoutMS: 5000,
+			},
+		},
+		Storage: config.Storage{Path: t.TempDir()},
+	}
+
+	svc := core.New(cfg)
+	mux := http.NewServeMux()
+	transport.NewHTTP(svc).Register(mux)
+	// mesmo stack do HTTP.Run (request_id + client no ctx)
+	srv := httptest.NewServer(transport.WrapHardening(logging.Middleware(mux)))
+	t.Cleanup(srv.Close)
+	return svc, srv
+}
+
+func adminGet(t *testing.T, url, token string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get %s: %v", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	_, srv := newAdminTestServer(t)
+
+	if resp := adminGet(t, srv.URL+"/admin/shutdown-report", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", resp.StatusCode)
+	}
+	if resp := adminGet(t, srv.URL+"/admin/shutdown-report", "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with wrong token, got %d", resp.StatusCode)
+	}
+
+	t.Setenv("MCP_GW_ADMIN_TOKEN", "")
+	if resp := adminGet(t, srv.URL+"/admin/shutdown-report", "admintok"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 when admin is disabled, got %d", resp.StatusCode)
+	}
+}
+
+func TestShutdownReport_ListsForceKilledExecutions(t *testing.T) {
+	svc, srv := newAdminTestServer(t)
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/slow", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// espera a tool iniciar (primeira linha SSE)
+	br := bufio.NewReader(resp.Body)
+	if _, err := br.ReadString('\n'); err != nil {
+		t.Fatalf("read first event: %v", err)
+	}
+
+	rep := svc.ForceKillAll(context.Background(), time.Second)
+	if len(rep.ForceKilled) != 1 || rep.ForceKilled[0].Tool != "slow" || rep.ForceKilled[0].Client == "" {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+
+	got := adminGet(t, srv.URL+"/admin/shutdown-report", "admintok")
+	if got.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", got.StatusCode)
+	}
+	var body struct {
+		Last *core.ShutdownReport `json:"last"`
+	}
+	if err := json.NewDecoder(got.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Last == nil || len(body.Last.ForceKilled) != 1 || body.Last.ForceKilled[0].RequestID != rep.ForceKilled[0].RequestID {
+		t.Fatalf("persisted report mismatch: %+v", body.Last)
+	}
+}
+
+func TestAdmin_ExecutionsListAndKill(t *testing.T) {
+	_, srv := newAdminTestServer(t)
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/slow", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	br := bufio.NewReader(resp.Body)
+	if _, err := br.ReadString('\n'); err != nil {
+		t.Fatalf("read first event: %v", err)
+	}
+
+	got := adminGet(t, srv.URL+"/admin/executions", "admintok")
+	var body struct {
+		Executions []core.ExecutionInfo `json:"executions"`
+		Slots      []core.SlotInfo      `json:"slots"`
+	}
+	if err := json.NewDecoder(got.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Executions) != 1 {
+		t.Fatalf("expected 1 execution, got %+v", body.Executions)
+	}
+	if len(body.Slots) != 1 || body.Slots[0].Tool != "slow" || body.Slots[0].InUse != 1 || body.Slots[0].Capacity < 1 {
+		t.Fatalf("unexpected slots: %+v", body.Slots)
+	}
+	e := body.Executions[0]
+	if e.Tool != "slow" || e.PID == 0 || e.RequestID == "" || e.BytesStreamed == 0 {
+		t.Fatalf("unexpected execution info: %+v", e)
+	}
+
+	kill := func(id string) int {
+		req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/admin/executions/"+id, nil)
+		req.Header.Set("Authorization", "Bearer admintok")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := kill("999"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown execution, got %d", code)
+	}
+	if code := kill(strconv.FormatUint(e.ID, 10)); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+
+	rest, _ := io.ReadAll(br)
+	if !strings.Contains(string(rest), "killed by admin") {
+		t.Fatalf("expected kill reason in stream, got %q", rest)
+	}
+}
+
+func TestAdmin_ConfigPlanListsAffectedExecutions(t *testing.T) {
+	_, srv := newAdminTestServer(t)
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/slow", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil {
+		t.Fatalf("read first event: %v", err)
+	}
+
+	proposed := `
+workspace_root: /tmp/workspaces
+tools_root: /tmp/tools
+tools:
+  slow:
+    runtime: native
+    cmd: /bin/cat
+  kv:
+    runtime: builtin
+    builtin: kv
+`
+	preq, _ := http.NewRequest(http.MethodPost, srv.URL+"/admin/config/plan", strings.NewReader(proposed))
+	preq.Header.Set("Authorization", "Bearer admintok")
+	presp, err := http.DefaultClient.Do(preq)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	defer presp.Body.Close()
+	if presp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", presp.StatusCode)
+	}
+
+	var plan core.ConfigPlan
+	if err := json.NewDecoder(presp.Body).Decode(&plan); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(plan.Tools) != 2 || plan.Tools[0].Name != "kv" || plan.Tools[0].Kind != config.ChangeAdded ||
+		plan.Tools[1].Name != "slow" || plan.Tools[1].Kind != config.ChangeChanged {
+		t.Fatalf("unexpected tools diff: %+v", plan.Tools)
+	}
+	if len(plan.Affected) != 1 || plan.Affected[0].Tool != "slow" {
+		t.Fatalf("expected the in-flight slow execution as affected, got %+v", plan.Affected)
+	}
+
+	bad, _ := http.NewRequest(http.MethodPost, srv.URL+"/admin/config/plan", strings.NewReader("tools: {}"))
+	bad.Header.Set("Authorization", "Bearer admintok")
+	bresp, err := http.DefaultClient.Do(bad)
+	if err != nil {
+		t.Fatalf("plan invalid: %v", err)
+	}
+	bresp.Body.Close()
+	if bresp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for invalid config, got %d", bresp.StatusCode)
+	}
+}
+
+func TestAdmin_SignedURLIsSingleUseWithFixedInput(t *testing.T) {
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+	t.Setenv("MCP_GW_ADMIN_TOKEN", "admintok")
+
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"echo": {Runtime: "native", Mode: "launcher", Cmd: os.Args[0], Args: []string{"__mcp_tool_echo_helper__"}, TimeoutMS: 3000},
+		},
+		Storage: config.Storage{Path: t.TempDir()},
+	})
+	mux := http.NewServeMux()
+	transport.NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(transport.WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	mreq, _ := http.NewRequest(http.MethodPost, srv.URL+"/admin/signed-urls",
+		strings.NewReader(`{"tool":"echo","input":{"action":"fixed"},"ttl_ms":60000}`))
+	mreq.Header.Set("Authorization", "Bearer admintok")
+	mresp, err := http.DefaultClient.Do(mreq)
+	if err != nil {
+		t.Fatalf("mint: %v", err)
+	}
+	defer mresp.Body.Close()
+	if mresp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", mresp.StatusCode)
+	}
+	var su core.SignedURL
+	if err := json.NewDecoder(mresp.Body).Decode(&su); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	// sem token e sem Content-Type; o body enviado é ignorado em favor do input fixo
+	post := func(url string) (int, string) {
+		resp, err := http.Post(url, "", strings.NewReader(`{"action":"other"}`))
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	if code, _ := post(srv.URL + strings.Replace(su.URL, "sig=", "sig=00", 1)); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for tampered signature, got %d", code)
+	}
+	code, body := post(srv.URL + su.URL)
+	if code != http.StatusOK || !strings.Contains(body, `"action":"fixed"`) {
+		t.Fatalf("expected fixed input to run, got %d: %s", code, body)
+	}
+	if code, _ := post(srv.URL + su.URL); code != http.StatusForbidden {
+		t.Fatalf("expected 403 on reuse, got %d", code)
+	}
+}
+
+func TestAdmin_DebugEndpointsBehindToken(t *testing.T) {
+	_, srv := newAdminTestServer(t)
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/admin/debug/runtime", "/admin/debug/goroutines"} {
+		if resp := adminGet(t, srv.URL+path, ""); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s: expected 401 without token, got %d", path, resp.StatusCode)
+		}
+		if resp := adminGet(t, srv.URL+path, "admintok"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected 200 with token, got %d", path, resp.StatusCode)
+		}
+	}
+
+	// uma tool rodando aparece como processo vivo e execução em andamento
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/slow", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil {
+		t.Fatalf("read first event: %v", err)
+	}
+
+	var info core.RuntimeInfo
+	if err := json.NewDecoder(adminGet(t, srv.URL+"/admin/debug/runtime", "admintok").Body).Decode(&info); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if info.Goroutines == 0 || info.ToolProcesses != 1 || info.Executions != 1 {
+		t.Fatalf("unexpected runtime info: %+v", info)
+	}
+
+	dump, _ := io.ReadAll(adminGet(t, srv.URL+"/admin/debug/goroutines", "admintok").Body)
+	if !strings.Contains(string(dump), "goroutine ") {
+		t.Fatalf("expected a goroutine dump, got %q", dump[:min(len(dump), 200)])
+	}
+}
+
+func TestAdmin_LogLevelPerTool(t *testing.T) {
+	_, srv := newAdminTestServer(t)
+	t.Cleanup(func() { logging.ClearToolLevel("slow") })
+
+	put := func(body string) (*http.Response, transport.LogLevelState) {
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+"/admin/loglevel", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admintok")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var st transport.LogLevelState
+		_ = json.NewDecoder(resp.Body).Decode(&st)
+		return resp, st
+	}
+
+	if resp, st := put(`{"tool":"slow","level":"debug"}`); resp.StatusCode != http.StatusOK || st.Tools["slow"] != "debug" || st.Level != "info" {
+		t.Fatalf("set tool level: %d %+v", resp.StatusCode, st)
+	}
+	if resp, _ := put(`{"tool":"nope","level":"debug"}`); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown tool: expected 404, got %d", resp.StatusCode)
+	}
+	if resp, _ := put(`{"level":"loud"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid level: expected 400, got %d", resp.StatusCode)
+	}
+	if resp, st := put(`{"tool":"slow","level":""}`); resp.StatusCode != http.StatusOK || len(st.Tools) != 0 {
+		t.Fatalf("clear tool level: %d %+v", resp.StatusCode, st)
+	}
+}
+
+func TestAdmin_EffectiveConfig(t *testing.T) {
+	svc, srv := newAdminTestServer(t)
+
+	resp := adminGet(t, srv.URL+"/admin/config", "admintok")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var got struct {
+		SHA256   string    `json:"sha256"`
+		LoadedAt time.Time `json:"loaded_at"`
+		Config   struct {
+			Tools map[string]map[string]any `json:"tools"`
+		} `json:"config"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.SHA256 == "" || got.SHA256 != svc.ConfigInfo().SHA256 || resp.Header.Get("X-Config-SHA256") != got.SHA256 {
+		t.Fatalf("unexpected sha256 %q (header %q)", got.SHA256, resp.Header.Get("X-Config-SHA256"))
+	}
+	if got.LoadedAt.IsZero() || got.Config.Tools["slow"]["runtime"] != "native" {
+		t.Fatalf("unexpected effective config: %+v", got)
+	}
+
+	yresp := adminGet(t, srv.URL+"/admin/config?format=yaml", "admintok")
+	defer yresp.Body.Close()
+	body, _ := io.ReadAll(yresp.Body)
+	if !strings.Contains(string(body), "slow:") || yresp.Header.Get("Content-Type") != "application/yaml" {
+		t.Fatalf("unexpected yaml response: %s", body)
+	}
+}
+
+func TestAdmin_RequestLogs(t *testing.T) {
+	_, srv := newAdminTestServer(t)
+
+	if resp := adminGet(t, srv.URL+"/admin/requests/rid-logs/logs", "admintok"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("capture off: expected 404, got %d", resp.StatusCode)
+	}
+
+	prev := slog.Default()
+	logging.New(logging.Config{Mode: logging.ModeJSON, Level: slog.LevelInfo, Out: io.Discard})
+	logging.SetCapture(10, 50)
+	t.Cleanup(func() {
+		logging.SetCapture(0, 0)
+		slog.SetDefault(prev)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/_echo", strings.NewReader(`{"x":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "rid-logs")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	resp = adminGet(t, srv.URL+"/admin/requests/rid-logs/logs", "admintok")
+	var logs logging.RequestLogs
+	if err := json.NewDecoder(resp.Body).Decode(&logs); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || logs.RequestID != "rid-logs" || len(logs.Records) == 0 {
+		t.Fatalf("request logs: %d %+v", resp.StatusCode, logs)
+	}
+	for _, rec := range logs.Records {
+		if rec.Attrs["tool"] == "_echo" {
+			return
+		}
+	}
+	t.Fatalf("no record for the tool: %+v", logs.Records)
+}
+
+func TestAdmin_EventsStream(t *testing.T) {
+	_, srv := newAdminTestServer(t)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/admin/events?type=request_started,request_finished&tool=_echo", nil)
+	req.Header.Set("Authorization", "Bearer admintok")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("events: %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	call, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/_echo", strings.NewReader(`{}`))
+	call.Header.Set("Content-Type", "application/json")
+	call.Header.Set("X-Request-Id", "rid-events")
+	cresp, err := http.DefaultClient.Do(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, cresp.Body)
+	cresp.Body.Close()
+
+	var got []string
+	sc := bufio.NewScanner(resp.Body)
+	for len(got) < 2 && sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e struct {
+			Type      string `json:"type"`
+			Tool      string `json:"tool"`
+			RequestID string `json:"request_id"`
+		}
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			t.Fatalf("event %q: %v", data, err)
+		}
+		if e.Tool != "_echo" || e.RequestID != "rid-events" {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+		got = append(got, e.Type)
+	}
+	if strings.Join(got, ",") != "request_started,request_finished" {
+		t.Fatalf("events: %v", got)
+	}
+}
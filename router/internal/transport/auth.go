@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/oidc"
+)
+
+// signatureHeader traz a assinatura HMAC da request (auth.hmac; formato em core.Sign).
+const signatureHeader = "X-MCP-Signature"
+
+// maxSignedBodyBytes limita o body de uma request assinada: ele é lido inteiro para
+// conferir o hash antes do handler.
+const maxSignedBodyBytes = 8 << 20
+
+// apiKeyHeader é a alternativa ao "Authorization: Bearer <chave>" (auth.api_keys).
+const apiKeyHeader = "X-API-Key"
+
+// requireRole exige o papel role (config.RoleInvoke, RoleRead) quando auth está
+// configurado: requests sem credencial só passam se o papel está em auth.public_roles
+// (401 caso contrário), credencial recusada dá 401 e credencial sem o papel, 403. O token
+// admin passa direto. O escopo e as cotas da credencial são aplicados no core
+// (PrincipalFromContext).
+func (h *HTTP) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.core.AuthEnabled() || h.isAdmin(r) {
+			next(w, r)
+			return
+		}
+		if !hasCredentials(r) {
+			if slices.Contains(h.core.Auth().PublicRoles, role) {
+				next(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-gw"`)
+			writeError(w, r, http.StatusUnauthorized, core.CodeUnauthorized, "missing credentials")
+			return
+		}
+		r, ok := h.authenticated(w, r)
+		if !ok {
+			return
+		}
+		if p := core.PrincipalFromContext(r.Context()); !p.HasRole(role) {
+			writeError(w, r, http.StatusForbidden, core.CodeForbidden, "credential lacks the "+role+" role")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireInvokeOrSignedURL é o requireRole(invoke) de POST /mcp/<tool>, a única rota em
+// que uma URL assinada (?sig=) substitui a credencial: o handleMCP resgata a URL antes de
+// qualquer outra coisa. Nas demais rotas ?sig= não muda nada.
+func (h *HTTP) requireInvokeOrSignedURL(next http.HandlerFunc) http.HandlerFunc {
+	auth := h.requireRole(config.RoleInvoke, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if signedURLCall(r) {
+			next(w, r)
+			return
+		}
+		auth(w, r)
+	}
+}
+
+// signedURLCall diz se a request é a execução de uma URL assinada (POST com ?sig=, sem
+// dry_run: o plano de spawn exige credencial).
+func signedURLCall(r *http.Request) bool {
+	q := r.URL.Query()
+	return r.Method == http.MethodPost && q.Has("sig") && !q.Has("dry_run")
+}
+
+// hasRole diz se a request (já passada pelo requireRole) pode exercer role; usado onde o
+// papel depende do método, como nos arquivos do workspace.
+func (h *HTTP) hasRole(r *http.Request, role string) bool {
+	if !h.core.AuthEnabled() || h.isAdmin(r) {
+		return true
+	}
+	if p := core.PrincipalFromContext(r.Context()); p != nil {
+		return p.HasRole(role)
+	}
+	return slices.Contains(h.core.Auth().PublicRoles, role)
+}
+
+// authenticated valida a credencial da request e devolve a request com o Principal no
+// ctx; com ok=false a resposta de erro já foi escrita.
+func (h *HTTP) authenticated(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	p, err := h.authenticate(w, r)
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		writeError(w, r, http.StatusRequestEntityTooLarge, core.CodeBodyTooLarge, fmt.Sprintf("signed body exceeds %d bytes", mbe.Limit))
+		return r, false
+	}
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-gw"`)
+		writeError(w, r, http.StatusUnauthorized, core.CodeUnauthorized, "missing or invalid credentials")
+		logging.LoggerFromContext(r.Context()).Warn("authentication failed", logging.Err(err))
+		return r, false
+	}
+	logging.SetAccessIdentity(r.Context(), p.Identity())
+	ctx := core.WithPrincipal(r.Context(), p)
+	ctx = logging.WithLogger(ctx, logging.LoggerFromContext(ctx).With(logging.String("principal", p.Identity())))
+	return r.WithContext(ctx), true
+}
+
+// hasCredentials diz se a request trouxe alguma credencial de cliente.
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get(signatureHeader) != "" || apiKey(r) != ""
+}
+
+// authenticate resolve a credencial da request: X-MCP-Signature vai para o HMAC
+// (auth.hmac), bearer com formato de JWT para o OIDC (auth.oidc), o resto é chave de API.
+func (h *HTTP) authenticate(w http.ResponseWriter, r *http.Request) (*core.Principal, error) {
+	if sig := r.Header.Get(signatureHeader); sig != "" && h.core.Auth().HMAC.Enabled() {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodyBytes))
+		if err != nil {
+			return nil, err
+		}
+		// o handler lê o body de novo
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return h.core.AuthenticateSignature(sig, r.Method, r.URL.RequestURI(), body)
+	}
+	secret := apiKey(r)
+	if secret == "" {
+		return nil, errors.New("no credentials")
+	}
+	if h.core.Auth().OIDC.Enabled() && r.Header.Get(apiKeyHeader) == "" && oidc.LooksLikeJWT(secret) {
+		return h.core.AuthenticateToken(r.Context(), secret)
+	}
+	p, ok := h.core.AuthenticateKey(secret)
+	if !ok {
+		return nil, errors.New("unknown api key")
+	}
+	return p, nil
+}
+
+// apiKey é a chave apresentada pelo cliente (X-API-Key ou bearer).
+func apiKey(r *http.Request) string {
+	if k := r.Header.Get(apiKeyHeader); k != "" {
+		return k
+	}
+	k, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return k
+}
+
+// GET /admin/keys
+// Uso por chave de API: chamadas no dia (UTC) contra daily_quota, em andamento contra
+// max_concurrent, total e recusas desde o start.
+func (h *HTTP) handleKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"keys": h.core.KeyUsage()})
+}
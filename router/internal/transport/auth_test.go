@@ -0,0 +1,489 @@
+package transport
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func TestHTTP_APIKeyScopesAndQuota(t *testing.T) {
+	t.Setenv("MCP_GW_ADMIN_TOKEN", "admintok")
+	t.Setenv("TEST_KEY_CI", "ci-secret")
+	t.Setenv("TEST_KEY_ALL", "all-secret")
+
+	tool := config.Tool{Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", Args: []string{"-c", `cat >/dev/null; echo '{"ok":true}'`},
+		TimeoutMS: 10000}
+	tagged := tool
+	tagged.Tags = []string{"read"}
+
+	svc := core.New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"fs_read": tool, "lint": tagged, "deploy": tool},
+		Auth: config.Auth{APIKeys: []config.APIKey{
+			{Name: "ci", KeyEnv: "TEST_KEY_CI", Tools: []string{"fs_*"}, Tags: []string{"read"}, DailyQuota: 2},
+			{Name: "all", KeyEnv: "TEST_KEY_ALL"},
+		}},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	do := func(method, path, key string) (int, map[string]any) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		var out map[string]any
+		_ = json.Unmarshal(raw, &out)
+		return resp.StatusCode, out
+	}
+	errCode := func(body map[string]any) string {
+		c, _ := body["code"].(string)
+		return c
+	}
+
+	if code, _ := do(http.MethodPost, "/mcp/fs_read", ""); code != http.StatusUnauthorized {
+		t.Fatalf("no key: status %d, want 401", code)
+	}
+	// probes do orquestrador ficam fora da auth, mesmo sem public_roles
+	for _, probe := range []string{"/healthz", "/readyz", "/livez"} {
+		if code, _ := do(http.MethodGet, probe, ""); code != http.StatusOK {
+			t.Fatalf("%s without credentials: status %d, want 200", probe, code)
+		}
+	}
+	if code, _ := do(http.MethodPost, "/mcp/fs_read", "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("bad key: status %d, want 401", code)
+	}
+
+	// tools/list mostra só o escopo da chave
+	_, list := do(http.MethodGet, "/mcp/tools", "ci-secret")
+	raw, _ := json.Marshal(list)
+	if !strings.Contains(string(raw), "fs_read") || !strings.Contains(string(raw), "lint") || strings.Contains(string(raw), "deploy") {
+		t.Fatalf("tool list not scoped: %s", raw)
+	}
+
+	code, body := do(http.MethodPost, "/mcp/deploy", "ci-secret")
+	if code != http.StatusForbidden || errCode(body) != core.CodeForbidden {
+		t.Fatalf("out of scope: status %d body %v", code, body)
+	}
+
+	for _, name := range []string{"fs_read", "lint"} {
+		if code, body := do(http.MethodPost, "/mcp/"+name, "ci-secret"); code != http.StatusOK {
+			t.Fatalf("%s: status %d body %v", name, code, body)
+		}
+	}
+	code, body = do(http.MethodPost, "/mcp/fs_read", "ci-secret")
+	if code != http.StatusTooManyRequests || errCode(body) != core.CodeQuotaExceeded {
+		t.Fatalf("quota: status %d body %v", code, body)
+	}
+	details, _ := body["details"].(map[string]any)
+	if details["key"] != "ci" || details["limit"] != float64(2) || details["reset_at"] == nil {
+		t.Fatalf("quota details: %v", details)
+	}
+
+	// a outra chave não tem escopo nem cota
+	if code, body := do(http.MethodPost, "/mcp/deploy", "all-secret"); code != http.StatusOK {
+		t.Fatalf("unscoped key: status %d body %v", code, body)
+	}
+
+	code, usage := do(http.MethodGet, "/admin/keys", "admintok")
+	if code != http.StatusOK {
+		t.Fatalf("/admin/keys: status %d", code)
+	}
+	keys, _ := usage["keys"].([]any)
+	if len(keys) != 2 {
+		t.Fatalf("/admin/keys: %v", usage)
+	}
+	ci := keys[1].(map[string]any)
+	if ci["name"] != "ci" || ci["used_today"] != float64(2) || ci["remaining"] != float64(0) || ci["rejected"] != float64(2) {
+		t.Fatalf("ci usage: %v", ci)
+	}
+}
+
+func TestHTTP_OIDCBearer(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer idp.Close()
+
+	token := func(tools any) string {
+		claims := map[string]any{"iss": "https://idp.example", "aud": "mcp-gw", "sub": "alice",
+			"exp": time.Now().Add(time.Hour).Unix()}
+		if tools != nil {
+			claims["mcp.tools"] = tools
+		}
+		h, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+		c, _ := json.Marshal(claims)
+		input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+		sum := sha256.Sum256([]byte(input))
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+		return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+
+	tool := config.Tool{Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", Args: []string{"-c", `cat >/dev/null; echo '{"ok":true}'`},
+		TimeoutMS: 10000}
+	svc := core.New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"fs_read": tool, "deploy": tool},
+		Auth:          config.Auth{OIDC: config.OIDC{Issuer: "https://idp.example", Audience: "mcp-gw", JWKSURL: idp.URL}},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	post := func(name, bearer string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/"+name, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	scoped := token([]string{"fs_*"})
+	if code := post("fs_read", scoped); code != http.StatusOK {
+		t.Fatalf("token in scope: status %d", code)
+	}
+	if code := post("deploy", scoped); code != http.StatusForbidden {
+		t.Fatalf("token out of scope: status %d, want 403", code)
+	}
+	if code := post("fs_read", token(nil)); code != http.StatusForbidden {
+		t.Fatalf("token without tools claim: status %d, want 403", code)
+	}
+	if code := post("fs_read", scoped[:len(scoped)-4]+"AAAA"); code != http.StatusUnauthorized {
+		t.Fatalf("tampered token: status %d, want 401", code)
+	}
+}
+
+func TestHTTP_HMACSignedRequests(t *testing.T) {
+	t.Setenv("TEST_HMAC_CI", "shared-secret")
+
+	tool := config.Tool{Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", Args: []string{"-c", `cat`},
+		TimeoutMS: 10000}
+	svc := core.New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"echo": tool, "deploy": tool},
+		Auth: config.Auth{HMAC: config.HMAC{Clients: []config.HMACClient{
+			{Name: "ci", SecretEnv: "TEST_HMAC_CI", Tools: []string{"echo"}},
+		}}},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	post := func(path, body, sig string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(signatureHeader, sig)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(out)
+	}
+	now := time.Now().Unix()
+	body := `{"n":1}`
+
+	sig := core.Sign("ci", "shared-secret", http.MethodPost, "/mcp/echo", now, []byte(body))
+	if code, out := post("/mcp/echo", body, sig); code != http.StatusOK || !strings.Contains(out, `"n":1`) {
+		t.Fatalf("signed request: status %d body %s", code, out)
+	}
+	if code, _ := post("/mcp/echo", body, sig); code != http.StatusUnauthorized {
+		t.Fatalf("replayed signature: status %d, want 401", code)
+	}
+	prefix, hexSig, _ := strings.Cut(sig, "sig=")
+	if code, _ := post("/mcp/echo", body, prefix+"sig="+strings.ToUpper(hexSig)); code != http.StatusUnauthorized {
+		t.Fatalf("replayed signature in upper case: status %d, want 401", code)
+	}
+
+	cases := map[string]struct{ path, body, sig string }{
+		"tampered body": {"/mcp/echo", `{"n":2}`, core.Sign("ci", "shared-secret", http.MethodPost, "/mcp/echo", now+1, []byte(body))},
+		"other path":    {"/mcp/echo?x=1", body, core.Sign("ci", "shared-secret", http.MethodPost, "/mcp/echo", now+2, []byte(body))},
+		"wrong secret":  {"/mcp/echo", body, core.Sign("ci", "guess", http.MethodPost, "/mcp/echo", now+3, []byte(body))},
+		"unknown":       {"/mcp/echo", body, core.Sign("bob", "shared-secret", http.MethodPost, "/mcp/echo", now+4, []byte(body))},
+		"stale":         {"/mcp/echo", body, core.Sign("ci", "shared-secret", http.MethodPost, "/mcp/echo", now-600, []byte(body))},
+	}
+	for name, c := range cases {
+		if code, _ := post(c.path, c.body, c.sig); code != http.StatusUnauthorized {
+			t.Errorf("%s: status %d, want 401", name, code)
+		}
+	}
+
+	sig = core.Sign("ci", "shared-secret", http.MethodPost, "/mcp/deploy", now, []byte(body))
+	if code, _ := post("/mcp/deploy", body, sig); code != http.StatusForbidden {
+		t.Fatalf("out of scope: status %d, want 403", code)
+	}
+}
+
+func TestHTTP_Roles(t *testing.T) {
+	t.Setenv("MCP_GW_ADMIN_TOKEN", "")
+	t.Setenv("TEST_KEY_RUN", "run-secret")
+	t.Setenv("TEST_KEY_VIEW", "view-secret")
+	t.Setenv("TEST_KEY_OPS", "ops-secret")
+
+	tool := config.Tool{Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", Args: []string{"-c", `cat >/dev/null; echo '{}'`},
+		TimeoutMS: 10000}
+	svc := core.New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"echo": tool},
+		Auth: config.Auth{
+			PublicRoles: []string{config.RoleRead},
+			APIKeys: []config.APIKey{
+				{Name: "run", KeyEnv: "TEST_KEY_RUN"},
+				{Name: "view", KeyEnv: "TEST_KEY_VIEW", Roles: []string{config.RoleRead}},
+				{Name: "ops", KeyEnv: "TEST_KEY_OPS", Roles: []string{config.RoleAdmin}},
+			},
+		},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	do := func(method, path, key string) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(apiKeyHeader, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	cases := []struct {
+		method, path, key string
+		want              int
+	}{
+		{http.MethodGet, "/healthz", "", http.StatusOK},
+		{http.MethodGet, "/mcp/tools", "", http.StatusOK},
+		{http.MethodPost, "/mcp/echo", "", http.StatusUnauthorized},
+		{http.MethodGet, "/admin/keys", "", http.StatusUnauthorized},
+
+		{http.MethodPost, "/mcp/echo", "run-secret", http.StatusOK},
+		{http.MethodGet, "/mcp/tools", "run-secret", http.StatusOK},
+		{http.MethodGet, "/admin/keys", "run-secret", http.StatusForbidden},
+
+		{http.MethodGet, "/mcp/tools", "view-secret", http.StatusOK},
+		{http.MethodPost, "/mcp/echo", "view-secret", http.StatusForbidden},
+
+		{http.MethodGet, "/admin/keys", "ops-secret", http.StatusOK},
+		{http.MethodPost, "/mcp/echo", "ops-secret", http.StatusForbidden},
+		{http.MethodGet, "/version", "bad-secret", http.StatusUnauthorized},
+		{http.MethodGet, "/healthz", "bad-secret", http.StatusOK}, // probes não olham credencial
+	}
+	for _, c := range cases {
+		if got := do(c.method, c.path, c.key); got != c.want {
+			t.Errorf("%s %s key=%q: status %d, want %d", c.method, c.path, c.key, got, c.want)
+		}
+	}
+}
+
+func TestHTTP_SignedURLBypassOnlyForToolCalls(t *testing.T) {
+	t.Setenv("MCP_GW_ADMIN_TOKEN", "")
+	t.Setenv("TEST_KEY_RUN", "run-secret")
+
+	tool := config.Tool{Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", Args: []string{"-c", `cat >/dev/null; echo '{}'`},
+		TimeoutMS: 10000}
+	svc := core.New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"echo": tool},
+		Files:         config.Files{Enabled: true},
+		Auth:          config.Auth{APIKeys: []config.APIKey{{Name: "run", KeyEnv: "TEST_KEY_RUN"}}},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	do := func(method, path string) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// ?sig= forjado não é credencial fora da execução de uma tool
+	for _, c := range []struct{ method, path string }{
+		{http.MethodGet, "/mcp/tools?sig=x"},
+		{http.MethodGet, "/mcp/tools/echo?sig=x"},
+		{http.MethodPost, "/mcp?sig=x"},
+		{http.MethodGet, "/mcp/sessions?sig=x"},
+		{http.MethodDelete, "/mcp/requests/abc?sig=x"},
+		{http.MethodGet, "/mcp/streams/abc?sig=x"},
+		{http.MethodGet, filesPrefix + "a.txt?sig=x"},
+		{http.MethodGet, "/mcp/echo?sig=x"},
+		{http.MethodPost, "/mcp/echo?sig=x&dry_run=1"},
+	} {
+		if got := do(c.method, c.path); got != http.StatusUnauthorized {
+			t.Errorf("%s %s: status %d, want 401", c.method, c.path, got)
+		}
+	}
+
+	// na execução de uma tool a URL é resgatada (e recusada) antes de qualquer outra coisa
+	if got := do(http.MethodPost, "/mcp/echo?id=abc&exp=1&sig=x"); got != http.StatusForbidden {
+		t.Errorf("bogus signed url: status %d, want 403", got)
+	}
+}
+
+func TestHTTP_KVNamespacedByPrincipal(t *testing.T) {
+	t.Setenv("TEST_KEY_A", "a-secret")
+	t.Setenv("TEST_KEY_B", "b-secret")
+	svc := core.New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"kv": {Runtime: "builtin", Mode: "launcher", Builtin: "kv"}},
+		Auth: config.Auth{APIKeys: []config.APIKey{
+			{Name: "a", KeyEnv: "TEST_KEY_A"},
+			{Name: "b", KeyEnv: "TEST_KEY_B"},
+		}},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	call := func(key, body string) string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/kv", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("kv %s: %d %s", body, resp.StatusCode, raw)
+		}
+		return string(raw)
+	}
+
+	// mesmo IP (loopback), principals diferentes: estados separados
+	call("a-secret", `{"op":"set","key":"plan","value":"from-a"}`)
+	if out := call("b-secret", `{"op":"get","key":"plan"}`); strings.Contains(out, "from-a") {
+		t.Fatalf("principal b read principal a's key: %s", out)
+	}
+	call("b-secret", `{"op":"set","key":"plan","value":"from-b"}`)
+	if out := call("a-secret", `{"op":"get","key":"plan"}`); !strings.Contains(out, "from-a") || strings.Contains(out, "from-b") {
+		t.Fatalf("principal a must keep its own value: %s", out)
+	}
+}
+
+func TestHTTP_ClientLimitKeyedByPrincipal(t *testing.T) {
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+	t.Setenv("TEST_KEY_CI", "ci-secret")
+	svc := core.New(&config.Config{
+		WorkspaceRoot:  "/tmp/workspaces",
+		ToolsRoot:      "/tmp/tools",
+		TrustedProxies: []string{"127.0.0.1"},
+		Limits:         config.Limits{MaxConcurrentPerClient: 1},
+		Tools: map[string]config.Tool{
+			"hang": {Runtime: "native", Mode: "launcher", Cmd: os.Args[0],
+				Args: []string{"__mcp_tool_disconnect_helper__"}, TimeoutMS: 5000, MaxConcurrent: 4},
+		},
+		Auth: config.Auth{APIKeys: []config.APIKey{{Name: "ci", KeyEnv: "TEST_KEY_CI"}}},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.MiddlewareWithAccessLog(mux,
+		logging.AccessLogOptions{Format: logging.AccessLogOff, TrustedProxies: svc.TrustedProxies()})))
+	defer srv.Close()
+
+	call := func(forwardedFor string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/hang", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer ci-secret")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	first := call("203.0.113.1")
+	defer first.Body.Close()
+	if line, _ := bufio.NewReader(first.Body).ReadString('\n'); !strings.HasPrefix(line, "event: message") {
+		t.Fatalf("expected first message event, got %q", line)
+	}
+
+	// mesma key, outro IP encaminhado: o limite é do principal, não do IP
+	second := call("203.0.113.2")
+	second.Body.Close()
+	if second.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("same key from another forwarded IP: expected 429, got %d", second.StatusCode)
+	}
+}
@@ -0,0 +1,168 @@
+package transport
+
+import (
+	"errors"
+	"sync"
+
+	"mcp-router/internal/observability/metrics"
+)
+
+// errSlowClient é devolvido ao core quando a política disconnect derruba um cliente lento.
+var errSlowClient = errors.New("slow client: outbound buffer full")
+
+var (
+	streamBufferedLines = metrics.Default.Gauge("mcp_gw_stream_buffered_lines",
+		"Lines waiting in outbound stream buffers (all streams).")
+	streamBufferFull = metrics.Default.CounterVec("mcp_gw_stream_buffer_full_total",
+		"Times a stream buffer was full when the tool produced a line.", "policy")
+	streamDroppedLines = metrics.Default.Counter("mcp_gw_stream_dropped_lines_total",
+		"Lines dropped by the drop slow-client policy.")
+	streamSlowDisconnects = metrics.Default.Counter("mcp_gw_stream_slow_client_disconnects_total",
+		"Clients disconnected by the disconnect slow-client policy.")
+)
+
+// streamSink é o destino do boundedWriter (sseWriter em produção).
+type streamSink interface {
+	WriteLine([]byte) error
+	WriteEvent(event string, data []byte) error
+	writeEvent(event string, payload any) error
+}
+
+type bufferedLine struct {
+	line    []byte
+	event   string // vazio = message; senão evento de output (data, continuation, ...)
+	dropped int64  // linhas descartadas antes desta (política drop)
+}
+
+// boundedWriter desacopla a leitura do stdout da tool da escrita no cliente com um
+// buffer limitado. Quando o buffer enche, aplica a política de cliente lento:
+// - block: WriteLine espera (a pressão chega ao stdout da tool)
+// - drop: descarta a linha; o cliente recebe event: dropped antes da próxima
+// - disconnect: aborta a conexão e devolve errSlowClient (o core mata a tool)
+type boundedWriter struct {
+	dst    streamSink
+	policy string
+	abort  func() // disconnect: desbloqueia escritas pendentes no cliente
+
+	ch     chan bufferedLine
+	failed chan struct{} // fechado no primeiro erro de escrita
+	done   chan struct{} // fechado quando o drain termina
+
+	errOnce sync.Once
+	err     error
+
+	dropped   int64 // só acessado pelo produtor
+	closeOnce sync.Once
+}
+
+func newBoundedWriter(dst streamSink, size int, policy string, abort func()) *boundedWriter {
+	w := &boundedWriter{
+		dst:    dst,
+		policy: policy,
+		abort:  abort,
+		ch:     make(chan bufferedLine, size),
+		failed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.drain()
+	return w
+}
+
+func (w *boundedWriter) fail(err error) {
+	w.errOnce.Do(func() {
+		w.err = err
+		close(w.failed)
+	})
+}
+
+func (w *boundedWriter) drain() {
+	defer close(w.done)
+	for it := range w.ch {
+		streamBufferedLines.Dec()
+		select {
+		case <-w.failed:
+			continue // só esvazia
+		default:
+		}
+		if it.dropped > 0 {
+			if err := w.dst.writeEvent("dropped", map[string]int64{"dropped": it.dropped}); err != nil {
+				w.fail(err)
+				continue
+			}
+		}
+		var err error
+		if it.event != "" {
+			err = w.dst.WriteEvent(it.event, it.line)
+		} else {
+			err = w.dst.WriteLine(it.line)
+		}
+		if err != nil {
+			w.fail(err)
+		}
+	}
+}
+
+func (w *boundedWriter) WriteLine(line []byte) error {
+	return w.enqueue(bufferedLine{line: line})
+}
+
+// WriteEvent enfileira um evento de output; a política de cliente lento vale igual
+// (com drop, o cliente percebe o buraco pelo seq/part).
+func (w *boundedWriter) WriteEvent(event string, data []byte) error {
+	return w.enqueue(bufferedLine{line: data, event: event})
+}
+
+func (w *boundedWriter) enqueue(it bufferedLine) error {
+	select {
+	case <-w.failed:
+		return w.err
+	default:
+	}
+
+	it.dropped = w.dropped
+
+	select {
+	case w.ch <- it:
+		streamBufferedLines.Inc()
+		w.dropped = 0
+		return nil
+	default:
+	}
+
+	// buffer cheio: cliente lento
+	streamBufferFull.With(w.policy).Inc()
+	switch w.policy {
+	case "drop":
+		w.dropped++
+		streamDroppedLines.Inc()
+		return nil
+	case "disconnect":
+		streamSlowDisconnects.Inc()
+		w.fail(errSlowClient)
+		if w.abort != nil {
+			w.abort()
+		}
+		return errSlowClient
+	default: // block
+		select {
+		case w.ch <- it:
+			streamBufferedLines.Inc()
+			w.dropped = 0
+			return nil
+		case <-w.failed:
+			return w.err
+		}
+	}
+}
+
+// Close espera o buffer esvaziar (ou a escrita falhar) e avisa drops pendentes no fim.
+// Deve ser chamado antes de usar o destino diretamente (eventos finais).
+func (w *boundedWriter) Close() {
+	w.closeOnce.Do(func() {
+		close(w.ch)
+		<-w.done
+		if w.dropped > 0 && w.err == nil {
+			_ = w.dst.writeEvent("dropped", map[string]int64{"dropped": w.dropped})
+		}
+	})
+}
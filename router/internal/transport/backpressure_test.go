@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// gatedSink bloqueia cada escrita até o teste liberar (simula cliente lento).
+type gatedSink struct {
+	gate chan struct{}
+
+	mu     sync.Mutex
+	lines  []string
+	events []string
+}
+
+func (s *gatedSink) WriteLine(b []byte) error {
+	<-s.gate
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, string(b))
+	return nil
+}
+
+func (s *gatedSink) WriteEvent(_ string, b []byte) error { return s.WriteLine(b) }
+
+func (s *gatedSink) writeEvent(event string, _ any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestBoundedWriter_DropPolicy(t *testing.T) {
+	sink := &gatedSink{gate: make(chan struct{})}
+	w := newBoundedWriter(sink, 1, "drop", nil)
+
+	// buffer de 1 linha com o cliente parado: no máximo 2 linhas passam (buffer + drain), o resto cai
+	for i := 0; i < 5; i++ {
+		if err := w.WriteLine([]byte("x")); err != nil {
+			t.Fatalf("drop policy must not fail: %v", err)
+		}
+	}
+	close(sink.gate)
+	w.Close()
+
+	if len(sink.lines) < 1 || len(sink.lines) > 2 {
+		t.Fatalf("expected 1-2 delivered lines, got %d", len(sink.lines))
+	}
+	if len(sink.events) != 1 || sink.events[0] != "dropped" {
+		t.Fatalf("expected a single dropped marker, got %v", sink.events)
+	}
+}
+
+func TestBoundedWriter_DisconnectPolicy(t *testing.T) {
+	sink := &gatedSink{gate: make(chan struct{})}
+	aborted := false
+	w := newBoundedWriter(sink, 1, "disconnect", func() {
+		aborted = true
+		close(sink.gate) // a conexão abortada desbloqueia a escrita pendente
+	})
+
+	var err error
+	for i := 0; i < 5 && err == nil; i++ {
+		err = w.WriteLine([]byte("x"))
+	}
+	if !errors.Is(err, errSlowClient) || !aborted {
+		t.Fatalf("expected errSlowClient + abort, got err=%v aborted=%v", err, aborted)
+	}
+	w.Close()
+}
+
+func TestBoundedWriter_BlockPolicyDeliversEverything(t *testing.T) {
+	sink := &gatedSink{gate: make(chan struct{})}
+	w := newBoundedWriter(sink, 1, "block", nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			_ = w.WriteLine([]byte("x"))
+		}
+	}()
+	close(sink.gate)
+	<-done
+	w.Close()
+
+	if len(sink.lines) != 10 || len(sink.events) != 0 {
+		t.Fatalf("block policy must deliver all lines, got lines=%d events=%v", len(sink.lines), sink.events)
+	}
+}
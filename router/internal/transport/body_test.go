@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func TestHTTP_StreamBodyToStdin(t *testing.T) {
+	// conta os bytes do stdin: só responde no EOF (fim do body)
+	count := config.Tool{Runtime: "native", Mode: "launcher", Cmd: "/bin/sh",
+		Args: []string{"-c", `printf '{"bytes":%d}\n' "$(wc -c)"`}, TimeoutMS: 10000}
+	small := count
+	count.StreamBody = true
+	limited := count
+	limited.MaxBodyBytes = 1000
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"count": count, "limited": limited, "small": small},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	post := func(tool, ct string, body io.Reader) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/"+tool, body)
+		req.Header.Set("Content-Type", ct)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post %s: %v", tool, err)
+		}
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(out)
+	}
+	// chunked: o io.Pipe não tem tamanho conhecido
+	chunked := func(data []byte) io.Reader {
+		pr, pw := io.Pipe()
+		go func() {
+			for len(data) > 0 {
+				n := min(len(data), 64<<10)
+				if _, err := pw.Write(data[:n]); err != nil {
+					return
+				}
+				data = data[n:]
+			}
+			_ = pw.Close()
+		}()
+		return pr
+	}
+	bytesOut := func(out string) int {
+		t.Helper()
+		for _, line := range strings.Split(out, "\n") {
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				var got struct{ Bytes int }
+				if err := json.Unmarshal([]byte(data), &got); err != nil {
+					t.Fatalf("tool output %q: %v", data, err)
+				}
+				return got.Bytes
+			}
+		}
+		t.Fatalf("no tool output in %q", out)
+		return 0
+	}
+
+	big := bytes.Repeat([]byte("0123456789abcdef"), 3<<16) // 3MiB, acima do limite de 1MiB
+	if st, out := post("count", "application/octet-stream", chunked(big)); st != http.StatusOK || bytesOut(out) != len(big) {
+		t.Fatalf("octet-stream chunked: status %d, out %q", st, out)
+	}
+	if st, out := post("count", "application/json", bytes.NewReader(big)); st != http.StatusOK || bytesOut(out) != len(big) {
+		t.Fatalf("json with Content-Length over 1MiB: status %d, out %q", st, out)
+	}
+
+	// body pequeno com Content-Length: caminho bufferizado (input JSON + newline)
+	if st, out := post("count", "application/json", strings.NewReader(`{"a":1}`)); st != http.StatusOK || bytesOut(out) != len(`{"a":1}`+"\n") {
+		t.Fatalf("small json: status %d, out %q", st, out)
+	}
+
+	// acima de max_body_bytes: 413 antes (Content-Length) ou durante o stream (chunked)
+	if st, out := post("limited", "application/octet-stream", bytes.NewReader(big[:5000])); st != http.StatusRequestEntityTooLarge || !strings.Contains(out, core.CodeBodyTooLarge) {
+		t.Fatalf("Content-Length over max_body_bytes: status %d, out %q", st, out)
+	}
+	if st, out := post("limited", "application/octet-stream", chunked(big[:5000])); st != http.StatusRequestEntityTooLarge || !strings.Contains(out, core.CodeBodyTooLarge) {
+		t.Fatalf("chunked over max_body_bytes: status %d, out %q", st, out)
+	}
+
+	// sem stream_body: octet-stream recusado, json grande esbarra no limite de 1MiB
+	if st, _ := post("small", "application/octet-stream", strings.NewReader("x")); st != http.StatusUnsupportedMediaType {
+		t.Fatalf("octet-stream without stream_body: status %d", st)
+	}
+	if st, _ := post("small", "application/json", bytes.NewReader(big)); st != http.StatusBadRequest {
+		t.Fatalf("big json without stream_body: status %d", st)
+	}
+}
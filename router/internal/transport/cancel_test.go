@@ -0,0 +1,281 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func newHangingCore(t *testing.T) *core.Service {
+	t.Helper()
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+
+	return core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"hang": {
+				Runtime:   "native",
+				Mode:      "launcher",
+				Cmd:       os.Args[0],
+				Args:      []string{"__mcp_tool_disconnect_helper__"},
+				TimeoutMS: 5000,
+			},
+		},
+	})
+}
+
+func TestHTTP_CancelRequest(t *testing.T) {
+	svc := newHangingCore(t)
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/hang", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "req-cancel-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	br := bufio.NewReader(resp.Body)
+	if line, _ := br.ReadString('\n'); !strings.HasPrefix(line, "event: message") {
+		t.Fatalf("expected first message event, got %q", line)
+	}
+
+	del := func(id string) int {
+		req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/mcp/requests/"+id, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := del("nope"); code != http.StatusNotFound {
+		t.Fatalf("unknown id: expected 404, got %d", code)
+	}
+	if code := del("req-cancel-1"); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+
+	rest, _ := io.ReadAll(br)
+	if !strings.Contains(string(rest), "event: cancelled") {
+		t.Fatalf("expected event: cancelled, got %q", rest)
+	}
+	if strings.Contains(string(rest), "event: error") {
+		t.Fatalf("cancel must not emit event: error, got %q", rest)
+	}
+}
+
+func TestHTTP_CancelOnlyByOwner(t *testing.T) {
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+	t.Setenv("TEST_KEY_A", "a-secret")
+	t.Setenv("TEST_KEY_B", "b-secret")
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"hang": {Runtime: "native", Mode: "launcher", Cmd: os.Args[0],
+				Args: []string{"__mcp_tool_disconnect_helper__"}, TimeoutMS: 5000},
+		},
+		Auth: config.Auth{APIKeys: []config.APIKey{
+			{Name: "a", KeyEnv: "TEST_KEY_A"},
+			{Name: "b", KeyEnv: "TEST_KEY_B"},
+		}},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/hang", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer a-secret")
+	req.Header.Set("X-Request-Id", "req-owned-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	br := bufio.NewReader(resp.Body)
+	if line, _ := br.ReadString('\n'); !strings.HasPrefix(line, "event: message") {
+		t.Fatalf("expected first message event, got %q", line)
+	}
+
+	del := func(key string) int {
+		req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/mcp/requests/req-owned-1", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// mesmo IP (loopback), outro principal: não enxerga a execução
+	if code := del("b-secret"); code != http.StatusNotFound {
+		t.Fatalf("other principal: expected 404, got %d", code)
+	}
+	if code := del("a-secret"); code != http.StatusNoContent {
+		t.Fatalf("owner: expected 204, got %d", code)
+	}
+	if rest, _ := io.ReadAll(br); !strings.Contains(string(rest), "event: cancelled") {
+		t.Fatalf("expected event: cancelled, got %q", rest)
+	}
+}
+
+// syncBuffer é um bytes.Buffer seguro para leitura enquanto o transport escreve.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func TestStdio_CancelInFlight(t *testing.T) {
+	svc := newHangingCore(t)
+
+	pr, pw := io.Pipe()
+	out := &syncBuffer{}
+	tr := NewStdio(svc)
+	tr.in = pr
+	tr.out = out
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- tr.Run(ctx) }()
+
+	_, _ = io.WriteString(pw, `{"id":"a","tool":"hang","input":{}}`+"\n")
+
+	// espera a tool começar (linha {"ready":true}) antes de cancelar
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(out.String(), `"ready"`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("tool did not start, out=%q", out.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, _ = io.WriteString(pw, `{"cancel":"a"}`+"\n")
+	_, _ = io.WriteString(pw, `{"cancel":"zzz"}`+"\n")
+	_ = pw.Close()
+
+	if err := <-errCh; err != nil {
+		t.Fatalf("stdio.Run error: %v", err)
+	}
+
+	var events []stdioResp
+	for _, l := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r stdioResp
+		if err := json.Unmarshal([]byte(l), &r); err != nil {
+			t.Fatalf("invalid output line %q: %v", l, err)
+		}
+		events = append(events, r)
+	}
+
+	got := map[string]string{}
+	for _, e := range events {
+		if e.Event != "message" {
+			got[e.ID] = e.Event
+		}
+	}
+	if got["a"] != "cancelled" {
+		t.Fatalf("expected a=cancelled, got events %+v", events)
+	}
+	if got["zzz"] != "error" {
+		t.Fatalf("expected zzz=error (unknown_request), got events %+v", events)
+	}
+}
+
+func TestHTTP_CooperativeCancel(t *testing.T) {
+	marker := t.TempDir() + "/cancel"
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			// responde ao input e fica esperando a mensagem de cancelamento no stdin
+			"coop": {Runtime: "native", Mode: "launcher", Cmd: "/bin/sh",
+				Args:         []string{"-c", `read -r in; echo '{"ok":true}'; read -r msg; printf '%s' "$msg" > "$1"`, "sh", marker},
+				TimeoutMS:    5000,
+				Cancellation: config.CancellationCooperative, CancelGraceMS: 2000},
+		},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/coop", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "req-coop-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	br := bufio.NewReader(resp.Body)
+	if line, _ := br.ReadString('\n'); !strings.HasPrefix(line, "event: message") {
+		t.Fatalf("expected first message event, got %q", line)
+	}
+
+	del, _ := http.NewRequest(http.MethodDelete, srv.URL+"/mcp/requests/req-coop-1", nil)
+	dresp, err := http.DefaultClient.Do(del)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	dresp.Body.Close()
+	_, _ = io.ReadAll(br)
+
+	// a tool sai sozinha dentro da grace; o marker é gravado antes do fim da execução
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		got, err := os.ReadFile(marker)
+		if err == nil {
+			want := `{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":"req-coop-1"}}`
+			if string(got) != want {
+				t.Fatalf("cancel message %q, want %q", got, want)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("tool never received the cancel message")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
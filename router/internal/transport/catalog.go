@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mcp-router/internal/core"
+)
+
+// filterTools aplica os filtros "<campo>:<valor>" do catálogo (tag, runtime, mode).
+func filterTools(tools []core.ToolInfo, filters []string) ([]core.ToolInfo, error) {
+	for _, f := range filters {
+		key, val, ok := strings.Cut(f, ":")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("invalid filter %q (want tag:<tag>, runtime:<runtime> or mode:<mode>)", f)
+		}
+		var match func(core.ToolInfo) bool
+		switch key {
+		case "tag":
+			match = func(t core.ToolInfo) bool { return t.HasTag(val) }
+		case "runtime":
+			match = func(t core.ToolInfo) bool { return t.Runtime == val }
+		case "mode":
+			match = func(t core.ToolInfo) bool { return t.Mode == val }
+		default:
+			return nil, fmt.Errorf("unknown filter field %q", key)
+		}
+		kept := tools[:0:0]
+		for _, t := range tools {
+			if match(t) {
+				kept = append(kept, t)
+			}
+		}
+		tools = kept
+	}
+	return tools, nil
+}
+
+// selectToolFields reduz cada tool aos campos JSON pedidos em ?fields=.
+func selectToolFields(tools []core.ToolInfo, fields []string) ([]map[string]any, error) {
+	for i, f := range fields {
+		fields[i] = strings.TrimSpace(f)
+		if !toolFields[fields[i]] {
+			return nil, fmt.Errorf("unknown field %q", fields[i])
+		}
+	}
+	out := make([]map[string]any, 0, len(tools))
+	for _, t := range tools {
+		b, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]any
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+		m := make(map[string]any, len(fields))
+		for _, f := range fields {
+			if v, ok := all[f]; ok {
+				m[f] = v
+			}
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// toolFields são os campos selecionáveis (tags JSON de core.ToolInfo).
+var toolFields = map[string]bool{
+	"name": true, "runtime": true, "mode": true, "description": true, "version": true,
+	"tags": true, "input_schema": true, "timeout_ms": true, "max_timeout_ms": true, "max_concurrent": true,
+}
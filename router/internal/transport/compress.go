@@ -0,0 +1,165 @@
+package transport
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mcp-router/internal/config"
+)
+
+// encoder é a interface comum de gzip.Writer e flate.Writer.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	"gzip": {New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}},
+	"deflate": {New: func() any {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	}},
+}
+
+// WrapCompression comprime as respostas (gzip ou deflate, conforme Accept-Encoding) quando
+// compression.enabled. Os streams das tools (/mcp/<tool>) só com compression.streams: cada
+// flush do sseWriter vira um flush do compressor, então a entrega por mensagem continua.
+func WrapCompression(next http.Handler, c config.Compression) http.Handler {
+	if !c.Enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isToolStreamPath(r.URL.Path) && !c.Streams {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		enc := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if enc == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: enc}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// isToolStreamPath diz se o path é o streaming de uma tool (POST /mcp/<tool>).
+func isToolStreamPath(p string) bool {
+	return strings.HasPrefix(p, "/mcp/") && p != "/mcp/tools" && !strings.HasPrefix(p, "/mcp/tools/") && !strings.HasPrefix(p, "/mcp/requests/") && !strings.HasPrefix(p, "/mcp/sessions")
+}
+
+// negotiateEncoding escolhe gzip ou deflate pelo maior q do Accept-Encoding (empate: gzip).
+// "" = sem compressão (header ausente, identity ou q=0).
+func negotiateEncoding(accept string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if name == "*" {
+			name = "gzip"
+		}
+		if _, ok := encoderPools[name]; !ok || q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "gzip") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressWriter comprime o body da resposta. O Content-Encoding só é definido no
+// WriteHeader, para respostas com body e que o handler não tenha codificado.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	enc         encoder
+	wroteHeader bool
+	active      bool
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	if c.wroteHeader {
+		c.ResponseWriter.WriteHeader(code)
+		return
+	}
+	c.wroteHeader = true
+	h := c.Header()
+	if h.Get("Content-Encoding") == "" && code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified {
+		c.active = true
+		h.Set("Content-Encoding", c.encoding)
+		h.Del("Content-Length")
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *compressWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		if c.Header().Get("Content-Type") == "" {
+			c.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		c.WriteHeader(http.StatusOK)
+	}
+	if !c.active {
+		return c.ResponseWriter.Write(b)
+	}
+	if c.enc == nil {
+		c.enc = encoderPools[c.encoding].Get().(encoder)
+		c.enc.Reset(c.ResponseWriter)
+	}
+	return c.enc.Write(b)
+}
+
+// Flush esvazia o compressor (sync flush) antes do flush da conexão: o cliente consegue
+// descomprimir tudo o que foi escrito até aqui.
+func (c *compressWriter) Flush() {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.enc != nil {
+		_ = c.enc.Flush()
+	}
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap expõe o ResponseWriter original (http.ResponseController: deadlines).
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// close fecha o stream comprimido (body vazio também precisa do trailer do gzip).
+func (c *compressWriter) close() {
+	if !c.active {
+		return
+	}
+	if c.enc == nil {
+		c.enc = encoderPools[c.encoding].Get().(encoder)
+		c.enc.Reset(c.ResponseWriter)
+	}
+	_ = c.enc.Close()
+	c.enc.Reset(io.Discard)
+	encoderPools[c.encoding].Put(c.enc)
+	c.enc = nil
+}
@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mcp-router/internal/config"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for accept, want := range map[string]string{
+		"":                          "",
+		"identity":                  "",
+		"gzip":                      "gzip",
+		"deflate":                   "deflate",
+		"deflate, gzip":             "gzip",
+		"gzip;q=0.5, deflate":       "deflate",
+		"gzip;q=0, deflate;q=0":     "",
+		"br, *":                     "gzip",
+		"GZIP;q=0.8, deflate;q=0.1": "gzip",
+	} {
+		if got := negotiateEncoding(accept); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestWrapCompression_StreamFlushIsDecodablePerMessage(t *testing.T) {
+	for name, c := range map[string]config.Compression{
+		"streams":    {Enabled: true, Streams: true},
+		"no-streams": {Enabled: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			release := make(chan struct{})
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_ = sendRawSSE(w, "message", []byte(`{"n":1}`))
+				w.(http.Flusher).Flush()
+				<-release // a tool ainda não terminou
+				_ = sendRawSSE(w, "message", []byte(`{"n":2}`))
+			})
+			srv := httptest.NewServer(WrapCompression(handler, c))
+			defer srv.Close()
+			defer close(release)
+
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/echo", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			resp, err := (&http.Client{Transport: &http.Transport{DisableCompression: true}}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if got := resp.Header.Get("Content-Encoding"); (got == "gzip") != c.Streams {
+				t.Fatalf("Content-Encoding = %q with streams=%v", got, c.Streams)
+			}
+			if !c.Streams {
+				return
+			}
+
+			zr, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			br := bufio.NewReader(zr)
+			for _, want := range []string{"event: message\n", "data: {\"n\":1}\n"} {
+				line, err := br.ReadString('\n')
+				if err != nil || line != want {
+					t.Fatalf("before the stream ends: got %q (%v), want %q", line, err, want)
+				}
+			}
+		})
+	}
+}
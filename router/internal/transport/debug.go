@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/pprof"
+	rpprof "runtime/pprof"
+
+	"mcp-router/internal/core"
+)
+
+// registerDebug registra pprof (/debug/pprof/*) e os endpoints de diagnóstico do
+// runtime (/admin/debug/*). Tudo atrás do requireAdmin: sem token admin, 404.
+func (h *HTTP) registerDebug(mux *http.ServeMux) {
+	mux.Handle("/debug/pprof/", h.requireAdmin(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", h.requireAdmin(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", h.requireAdmin(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", h.requireAdmin(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", h.requireAdmin(http.HandlerFunc(pprof.Trace)))
+
+	mux.Handle("/admin/debug/runtime", h.requireAdmin(http.HandlerFunc(h.handleDebugRuntime)))
+	mux.Handle("/admin/debug/goroutines", h.requireAdmin(http.HandlerFunc(h.handleDebugGoroutines)))
+}
+
+// GET /admin/debug/runtime
+// Contadores para achar vazamentos: goroutines, fds abertos, processos de tool vivos,
+// execuções e sessões. Goroutines crescendo com executions em 0 = stream preso.
+func (h *HTTP) handleDebugRuntime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.core.RuntimeInfo())
+}
+
+// GET /admin/debug/goroutines
+// Dump de todas as goroutines com stacks completos (texto, como um SIGQUIT sem matar).
+func (h *HTTP) handleDebugGoroutines(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_ = rpprof.Lookup("goroutine").WriteTo(w, 2)
+}
@@ -0,0 +1,130 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mcp-router/internal/flags"
+	"mcp-router/internal/observability/logging"
+)
+
+// Modo dev (mcp-gw http --dev): CORS para origens locais, plano do dry-run nos headers de cada
+// chamada e listener só em loopback. Nada disso vale fora do --dev.
+
+// Headers do plano de spawn (--dev), os mesmos dados de POST /mcp/<tool>?dry_run=1.
+const (
+	devPlanCommandHeader  = "X-MCP-Plan-Command"
+	devPlanDirHeader      = "X-MCP-Plan-Dir"
+	devPlanEnvHeader      = "X-MCP-Plan-Env" // só os nomes: valores podem ter segredos
+	devPlanMountsHeader   = "X-MCP-Plan-Mounts"
+	devPlanLimitsHeader   = "X-MCP-Plan-Limits"
+	devPlanWarningsHeader = "X-MCP-Plan-Warnings"
+)
+
+// EnableDev liga o modo dev. Chamado antes do Run; vale também para os configs recarregados.
+func (h *HTTP) EnableDev() { h.dev = true }
+
+// checkDevListener recusa o --dev fora de loopback: CORS e plano nos headers não
+// podem ficar expostos na rede.
+func (h *HTTP) checkDevListener(addr string) error {
+	if h.dev && !h.loopback {
+		return fmt.Errorf("--dev only listens on loopback addresses (got %s; use e.g. 127.0.0.1:8080)", addr)
+	}
+	return nil
+}
+
+// devCORSHeaders são os headers de request que o gateway lê; o preflight libera só estes
+// (não ecoa o Access-Control-Request-Headers).
+var devCORSHeaders = strings.Join([]string{
+	"Authorization", "Content-Type", apiKeyHeader, signatureHeader, timeoutHeader, resumeHeader,
+	workspaceHeader, flags.Header, sessionHeader, "X-Request-ID", "Last-Event-ID",
+}, ", ")
+
+// WrapDevCORS responde aos preflights (--dev) e libera para o JavaScript da página só as
+// origens de loopback e as listadas em host_check.allowed_origins ("*" não conta): uma
+// página qualquer aberta no browser não pode chamar tools nem ler o workspace do gateway
+// local. Sem credenciais de browser (cookies): a auth do gateway vai em headers.
+func WrapDevCORS(next http.Handler, allowed []string) http.Handler {
+	var explicit []string
+	for _, o := range allowed {
+		if o != "*" {
+			explicit = append(explicit, o)
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !devOriginAllowed(origin, explicit) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		hdr := w.Header()
+		hdr.Set("Access-Control-Allow-Origin", origin)
+		hdr.Add("Vary", "Origin")
+		hdr.Set("Access-Control-Expose-Headers", "*")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			hdr.Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, DELETE, OPTIONS")
+			hdr.Set("Access-Control-Allow-Headers", devCORSHeaders)
+			hdr.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// devOriginAllowed: origem de loopback (qualquer porta) ou uma das explícitas.
+func devOriginAllowed(origin string, explicit []string) bool {
+	return originAllowed(origin, nil, true) || (len(explicit) > 0 && originAllowed(origin, explicit, false))
+}
+
+// setDevPlanHeaders põe o plano de spawn da tool nos headers da resposta (best effort:
+// falha no plano só vira log de debug).
+func (h *HTTP) setDevPlanHeaders(ctx context.Context, w http.ResponseWriter, toolName string) {
+	plan, err := h.core.SpawnPlan(ctx, toolName)
+	if err != nil {
+		logging.LoggerFromContext(ctx).Debug("dev: spawn plan unavailable", logging.Err(err))
+		return
+	}
+	hdr := w.Header()
+	limits := []string{
+		"timeout_ms=" + strconv.FormatInt(plan.TimeoutMS, 10),
+		"max_concurrent=" + strconv.Itoa(plan.MaxConcurrent),
+		"shutdown=" + plan.Shutdown,
+	}
+	if plan.StartupTimeoutMS > 0 {
+		limits = append(limits, "startup_timeout_ms="+strconv.FormatInt(plan.StartupTimeoutMS, 10))
+	}
+	if plan.QueueTimeoutMS > 0 {
+		limits = append(limits, "queue_timeout_ms="+strconv.FormatInt(plan.QueueTimeoutMS, 10))
+	}
+	hdr.Set(devPlanLimitsHeader, strings.Join(limits, "; "))
+
+	switch {
+	case plan.Endpoint != "":
+		hdr.Set(devPlanCommandHeader, "remote "+plan.Endpoint)
+	case plan.Builtin != "":
+		hdr.Set(devPlanCommandHeader, "builtin "+plan.Builtin)
+	case plan.Spawn != nil:
+		sp := plan.Spawn
+		hdr.Set(devPlanCommandHeader, strings.Join(sp.Args, " "))
+		if sp.Dir != "" {
+			hdr.Set(devPlanDirHeader, sp.Dir)
+		}
+		if len(sp.Env) > 0 {
+			names := make([]string, len(sp.Env))
+			for i, kv := range sp.Env {
+				names[i], _, _ = strings.Cut(kv, "=")
+			}
+			hdr.Set(devPlanEnvHeader, strings.Join(names, ","))
+		}
+		if len(sp.Mounts) > 0 {
+			hdr.Set(devPlanMountsHeader, strings.Join(sp.Mounts, "; "))
+		}
+		if len(sp.Warnings) > 0 {
+			hdr.Set(devPlanWarningsHeader, strings.Join(sp.Warnings, "; "))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func TestHTTP_DevMode(t *testing.T) {
+	svc := core.New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"hello": {Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", Args: []string{"-c", `read -r in; echo "$in"`},
+				TimeoutMS: 4000},
+		},
+	})
+	defer svc.Close()
+
+	h := NewHTTP(svc)
+	h.EnableDev()
+	mux := http.NewServeMux()
+	h.Register(mux)
+	srv := httptest.NewServer(WrapDevCORS(WrapHardening(logging.Middleware(mux)), []string{"*", "https://app.example"}))
+	defer srv.Close()
+
+	// preflight de outra origem
+	req, _ := http.NewRequest(http.MethodOptions, srv.URL+"/mcp/hello", nil)
+	req.Header.Set("Origin", "http://localhost:5173")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type,authorization")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "http://localhost:5173" ||
+		!strings.Contains(resp.Header.Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Fatalf("preflight: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// só loopback e as origens explícitas; "*" em allowed_origins não abre o CORS do --dev
+	for origin, want := range map[string]bool{
+		"http://127.0.0.1:3000":    true,
+		"https://app.example":      true,
+		"https://evil.example":     false,
+		"http://localhost.evil.io": false,
+	} {
+		req, _ := http.NewRequest(http.MethodOptions, srv.URL+"/mcp/hello", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "x-evil")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Access-Control-Allow-Origin") == origin; got != want {
+			t.Fatalf("preflight from %s: allowed=%v, want %v", origin, got, want)
+		}
+		if strings.Contains(strings.ToLower(resp.Header.Get("Access-Control-Allow-Headers")), "x-evil") {
+			t.Fatalf("preflight from %s echoed the requested headers", origin)
+		}
+	}
+
+	// chamada: plano de spawn nos headers, sem valores do env
+	req, _ = http.NewRequest(http.MethodPost, srv.URL+"/mcp/hello", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "http://localhost:5173")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "http://localhost:5173" {
+		t.Fatalf("call: %d %v", resp.StatusCode, resp.Header)
+	}
+	if cmd := resp.Header.Get(devPlanCommandHeader); !strings.Contains(cmd, "/bin/sh -c read -r in") {
+		t.Fatalf("%s: %q", devPlanCommandHeader, cmd)
+	}
+	if !strings.Contains(resp.Header.Get(devPlanLimitsHeader), "timeout_ms=4000") {
+		t.Fatalf("%s: %q", devPlanLimitsHeader, resp.Header.Get(devPlanLimitsHeader))
+	}
+	if env := resp.Header.Get(devPlanEnvHeader); strings.Contains(env, "=") {
+		t.Fatalf("%s: %q", devPlanEnvHeader, env)
+	}
+
+	// o handler completo: host_check continua recusando origens de fora no --dev
+	h.loopback = true // o Run marca pelo listener
+	hs := httptest.NewServer(h.handler())
+	defer hs.Close()
+	req, _ = http.NewRequest(http.MethodPost, hs.URL+"/mcp/hello", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://evil.example")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("foreign origin in dev: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// fora de loopback o --dev recusa o listener
+	h.loopback = false
+	if err := h.checkDevListener("0.0.0.0:8080"); err == nil {
+		t.Fatal("dev mode must refuse a non-loopback listener")
+	}
+	h.loopback = true
+	if err := h.checkDevListener("127.0.0.1:8080"); err != nil {
+		t.Fatal(err)
+	}
+}
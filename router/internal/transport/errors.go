@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"net/http"
+
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+// writeError responde com o modelo de erro do gateway (core.APIError) em JSON, no lugar
+// do texto livre do http.Error: o cliente decide pelo code, não pela mensagem.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, msg string) {
+	writeAPIError(w, status, core.NewAPIError(code, msg, logging.RequestIDFromContext(r.Context())))
+}
+
+// writeErrorFor classifica err (core.ErrorFor) e responde com status.
+func writeErrorFor(w http.ResponseWriter, r *http.Request, status int, err error) {
+	writeAPIError(w, status, core.ErrorFor(err, logging.RequestIDFromContext(r.Context())))
+}
+
+func writeAPIError(w http.ResponseWriter, status int, e *core.APIError) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if e.Retryable && (status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable) {
+		w.Header().Set("Retry-After", "1")
+	}
+	writeJSON(w, status, e)
+}
+
+// codeStatus mapeia os códigos de erro para o status HTTP das respostas antes do 1º evento.
+var codeStatus = map[string]int{
+	core.CodeToolBusy:         http.StatusTooManyRequests,
+	core.CodeClientBusy:       http.StatusTooManyRequests,
+	core.CodeGatewayBusy:      http.StatusTooManyRequests,
+	core.CodeSessionLimit:     http.StatusTooManyRequests,
+	core.CodeTimeout:          http.StatusGatewayTimeout,
+	core.CodeStartupTimeout:   http.StatusGatewayTimeout,
+	core.CodeSpawnFailed:      http.StatusBadGateway,
+	core.CodeToolFailed:       http.StatusBadGateway,
+	core.CodeOutputViolation:  http.StatusBadGateway,
+	core.CodeUnknownTool:      http.StatusNotFound,
+	core.CodeSessionNotFound:  http.StatusNotFound,
+	core.CodeNotFound:         http.StatusNotFound,
+	core.CodeInvalidToolName:  http.StatusBadRequest,
+	core.CodeInvalidInput:     http.StatusBadRequest,
+	core.CodeSchemaViolation:  http.StatusUnprocessableEntity,
+	core.CodeInvalidRequest:   http.StatusBadRequest,
+	core.CodeNotInteractive:   http.StatusBadRequest,
+	core.CodeBodyTooLarge:     http.StatusRequestEntityTooLarge,
+	core.CodeSessionRequired:  http.StatusBadRequest,
+	core.CodeSessionMismatch:  http.StatusBadRequest,
+	core.CodeNotDaemon:        http.StatusBadRequest,
+	core.CodeSessionBusy:      http.StatusConflict,
+	core.CodeSignatureInvalid: http.StatusForbidden,
+	core.CodeSignatureExpired: http.StatusForbidden,
+	core.CodeSignatureUsed:    http.StatusForbidden,
+	core.CodeUnknownWorkspace: http.StatusForbidden,
+	core.CodeForbidden:        http.StatusForbidden,
+	core.CodeQuotaExceeded:    http.StatusTooManyRequests,
+	core.CodeUnauthorized:     http.StatusUnauthorized,
+	core.CodeShutdown:         http.StatusServiceUnavailable,
+}
+
+// errorStatus é o status HTTP de err (500 para o que não tem mapeamento).
+func errorStatus(err error) int {
+	if st, ok := codeStatus[core.ErrorFor(err, "").Code]; ok {
+		return st
+	}
+	return http.StatusInternalServerError
+}
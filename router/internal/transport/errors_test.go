@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/runner"
+)
+
+func TestErrorStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{core.ErrToolBusy, http.StatusTooManyRequests},
+		{core.ErrGatewayBusy, http.StatusTooManyRequests},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{fmt.Errorf("%w: no output within 1s", core.ErrToolStartupTimeout), http.StatusGatewayTimeout},
+		{fmt.Errorf("%w: nope", runner.ErrUnknownTool), http.StatusNotFound},
+		{core.ErrInvalidInput, http.StatusBadRequest},
+		{fmt.Errorf("%w: exec: no such file", core.ErrSpawnFailed), http.StatusBadGateway},
+		{errors.New("exit status 3"), http.StatusBadGateway},
+		{runner.ErrSessionBusy, http.StatusConflict},
+		{core.ErrForceKilled, http.StatusServiceUnavailable},
+		{core.NewAPIError(core.CodeInternal, "boom", ""), http.StatusInternalServerError},
+	} {
+		if got := errorStatus(tc.err); got != tc.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestHTTP_ErrorsBeforeFirstEventUseMappedStatus(t *testing.T) {
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			// dorme antes da 1ª linha: estoura timeout_ms sem ter streamado nada
+			"slow": {Runtime: "native", Mode: "launcher", Cmd: os.Args[0],
+				Args: []string{"__mcp_tool_slowstart_helper__", "2s", "0s"}, TimeoutMS: 200},
+			"missing": {Runtime: "native", Mode: "launcher", Cmd: "/nonexistent/mcp-gw-tool", TimeoutMS: 1000},
+		},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	for tool, want := range map[string]struct {
+		status int
+		code   string
+	}{
+		"nope":    {http.StatusNotFound, core.CodeUnknownTool},
+		"slow":    {http.StatusGatewayTimeout, core.CodeTimeout},
+		"missing": {http.StatusBadGateway, core.CodeSpawnFailed},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/"+tool, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tool, err)
+		}
+		var body core.APIError
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+
+		if resp.StatusCode != want.status || body.Code != want.code {
+			t.Errorf("%s: got %d %+v, want %d code=%s", tool, resp.StatusCode, body, want.status, want.code)
+		}
+		if body.RequestID == "" || body.RequestID != resp.Header.Get("X-Request-Id") {
+			t.Errorf("%s: request_id %q must match X-Request-Id %q", tool, body.RequestID, resp.Header.Get("X-Request-Id"))
+		}
+	}
+}
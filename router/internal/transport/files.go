@@ -0,0 +1,252 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/sandbox"
+)
+
+// Arquivos do workspace por HTTP (files.enabled): clientes colocam inputs para tools de
+// filesystem e buscam as saídas sem SSH no host.
+//
+//	GET    /workspace/files/<path>  arquivo (Range/If-Modified-Since) ou listagem do diretório
+//	PUT    /workspace/files/<path>  grava o body (diretórios intermediários criados)
+//	DELETE /workspace/files/<path>  remove arquivo ou diretório vazio
+//
+// Todo caminho passa pelo sandbox.ValidatePath (traversal, encoding, symlinks que escapam).
+// <workspace>/.ephemeral (workspaces por request, inclusive os retidos) não é exposto: some
+// da listagem e responde 404.
+
+const filesPrefix = "/workspace/files/"
+
+// FileInfo é uma entrada da listagem de diretório e a resposta do PUT.
+type FileInfo struct {
+	Path    string    `json:"path"`
+	Dir     bool      `json:"dir,omitempty"`
+	Bytes   int64     `json:"bytes"`
+	ModTime time.Time `json:"mod_time"`
+	SHA256  string    `json:"sha256,omitempty"` // só no PUT
+}
+
+func (h *HTTP) handleFiles(w http.ResponseWriter, r *http.Request) {
+	cfg, root := h.core.Files(logging.WorkspaceFromContext(r.Context()))
+	if !cfg.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut, http.MethodDelete:
+		if cfg.ReadOnly {
+			writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "workspace files are read-only")
+			return
+		}
+		if !h.hasRole(r, config.RoleInvoke) {
+			writeError(w, r, http.StatusForbidden, core.CodeForbidden, "credential lacks the invoke role")
+			return
+		}
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// ValidatePath decodifica o caminho (QueryUnescape): o path vai escapado, com "+" literal
+	rel := strings.TrimPrefix(r.URL.EscapedPath(), filesPrefix)
+	rel = strings.ReplaceAll(rel, "+", "%2B")
+	full, err := sandbox.ValidatePath(root, rel)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid path")
+		logging.LoggerFromContext(r.Context()).Warn("workspace file path rejected", logging.Err(err))
+		return
+	}
+	name := workspaceRel(root, full)
+	if ephemeralPath(name) {
+		writeFileError(w, r, fs.ErrNotExist)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		h.putFile(w, r, full, name, cfg.MaxUpload())
+	case http.MethodDelete:
+		h.deleteFile(w, r, full, name)
+	default:
+		h.getFile(w, r, full, name)
+	}
+}
+
+func (h *HTTP) getFile(w http.ResponseWriter, r *http.Request, full, name string) {
+	f, err := os.Open(full)
+	if err != nil {
+		writeFileError(w, r, err)
+		return
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		writeFileError(w, r, err)
+		return
+	}
+
+	if !st.IsDir() {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, st.Name(), st.ModTime(), f)
+		return
+	}
+
+	entries, err := f.ReadDir(-1)
+	if err != nil {
+		writeFileError(w, r, err)
+		return
+	}
+	out := make([]FileInfo, 0, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue // removido durante a listagem
+		}
+		fi := FileInfo{Path: pathJoin(name, e.Name()), Dir: e.IsDir(), ModTime: info.ModTime().UTC()}
+		if ephemeralPath(fi.Path) {
+			continue
+		}
+		if !e.IsDir() {
+			fi.Bytes = info.Size()
+		}
+		out = append(out, fi)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	writeJSON(w, http.StatusOK, map[string]any{"path": name, "entries": out})
+}
+
+// putFile grava o body num temporário ao lado do destino e renomeia no fim: leitores
+// nunca veem um arquivo pela metade e um upload abortado não estraga o anterior.
+func (h *HTTP) putFile(w http.ResponseWriter, r *http.Request, full, name string, max int64) {
+	if name == "" {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "path must name a file")
+		return
+	}
+	if r.ContentLength > max {
+		writeError(w, r, http.StatusRequestEntityTooLarge, core.CodeBodyTooLarge, fmt.Sprintf("upload exceeds %d bytes", max))
+		return
+	}
+	st, statErr := os.Stat(full)
+	if statErr == nil && st.IsDir() {
+		writeError(w, r, http.StatusConflict, core.CodeInvalidRequest, "path is a directory")
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		writeFileError(w, r, err)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		writeFileError(w, r, err)
+		return
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // no-op depois do rename
+
+	sum := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, sum), http.MaxBytesReader(w, r.Body, max))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, core.CodeBodyTooLarge, fmt.Sprintf("upload exceeds %d bytes", max))
+			return
+		}
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "upload failed")
+		logging.LoggerFromContext(r.Context()).Warn("workspace upload failed", logging.String("path", name), logging.Err(err))
+		return
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		writeFileError(w, r, err)
+		return
+	}
+	if err := os.Rename(tmp.Name(), full); err != nil {
+		writeFileError(w, r, err)
+		return
+	}
+
+	logging.LoggerFromContext(r.Context()).Info("workspace file uploaded", logging.String("path", name), logging.Int64("bytes", n))
+	status := http.StatusOK
+	if statErr != nil {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, FileInfo{Path: name, Bytes: n, ModTime: time.Now().UTC(), SHA256: hex.EncodeToString(sum.Sum(nil))})
+}
+
+func (h *HTTP) deleteFile(w http.ResponseWriter, r *http.Request, full, name string) {
+	if name == "" {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "cannot delete the workspace root")
+		return
+	}
+	if err := os.Remove(full); err != nil {
+		if st, serr := os.Stat(full); serr == nil && st.IsDir() {
+			writeError(w, r, http.StatusConflict, core.CodeInvalidRequest, "directory is not empty")
+			return
+		}
+		writeFileError(w, r, err)
+		return
+	}
+	logging.LoggerFromContext(r.Context()).Info("workspace file deleted", logging.String("path", name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeFileError responde erros do filesystem (404 para inexistente, 403 sem permissão).
+func writeFileError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		writeError(w, r, http.StatusNotFound, core.CodeNotFound, "file not found")
+	case errors.Is(err, fs.ErrPermission):
+		writeError(w, r, http.StatusForbidden, core.CodeInvalidRequest, "permission denied")
+	default:
+		writeError(w, r, http.StatusInternalServerError, core.CodeInternal, "file operation failed")
+		logging.LoggerFromContext(r.Context()).Error("workspace file operation failed", logging.Err(err))
+	}
+}
+
+// workspaceRel é o caminho de full relativo ao workspace, com "/" ("" = a raiz).
+func workspaceRel(root, full string) string {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(abs, full)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ephemeralPath diz se o caminho relativo cai em .ephemeral (workspaces de outras requests).
+// EqualFold: em filesystem sem distinção de caixa .EPHEMERAL é o mesmo diretório.
+func ephemeralPath(rel string) bool {
+	first, _, _ := strings.Cut(rel, "/")
+	return strings.EqualFold(first, config.EphemeralDir)
+}
+
+func pathJoin(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func newFilesServer(t *testing.T, files config.Files) (*httptest.Server, string) {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := core.New(&config.Config{WorkspaceRoot: root, ToolsRoot: "/tmp/tools", Files: files})
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	t.Cleanup(srv.Close)
+	return srv, root
+}
+
+func doFile(t *testing.T, method, url string, body io.Reader) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, body)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(out)
+}
+
+func TestFiles_UploadDownloadDelete(t *testing.T) {
+	srv, root := newFilesServer(t, config.Files{Enabled: true, MaxUploadBytes: 1024})
+	base := srv.URL + "/workspace/files/"
+
+	st, out := doFile(t, http.MethodPut, base+"in/data+1.txt", strings.NewReader("hello"))
+	if st != http.StatusCreated {
+		t.Fatalf("put: status %d, %s", st, out)
+	}
+	var put FileInfo
+	_ = json.Unmarshal([]byte(out), &put)
+	if put.Path != "in/data+1.txt" || put.Bytes != 5 || put.SHA256 == "" {
+		t.Fatalf("put response: %+v", put)
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "in", "data+1.txt")); string(got) != "hello" {
+		t.Fatalf("file on disk: %q", got)
+	}
+	if st, _ := doFile(t, http.MethodPut, base+"in/data+1.txt", strings.NewReader("hello again")); st != http.StatusOK {
+		t.Fatalf("overwrite: status %d", st)
+	}
+
+	if st, out := doFile(t, http.MethodGet, base+"in/data+1.txt", nil); st != http.StatusOK || out != "hello again" {
+		t.Fatalf("get: status %d, %q", st, out)
+	}
+	st, out = doFile(t, http.MethodGet, base+"in", nil)
+	if st != http.StatusOK || !strings.Contains(out, `"path":"in/data+1.txt"`) {
+		t.Fatalf("list: status %d, %s", st, out)
+	}
+
+	if st, _ := doFile(t, http.MethodPut, base+"big", strings.NewReader(strings.Repeat("x", 2048))); st != http.StatusRequestEntityTooLarge {
+		t.Fatalf("upload over max_upload_bytes: status %d", st)
+	}
+	if _, err := os.Stat(filepath.Join(root, "big")); !os.IsNotExist(err) {
+		t.Fatalf("oversized upload left a file: %v", err)
+	}
+
+	if st, _ := doFile(t, http.MethodDelete, base+"in", nil); st != http.StatusConflict {
+		t.Fatalf("delete non-empty dir: status %d", st)
+	}
+	if st, _ := doFile(t, http.MethodDelete, base+"in/data+1.txt", nil); st != http.StatusNoContent {
+		t.Fatalf("delete: status %d", st)
+	}
+	if st, _ := doFile(t, http.MethodGet, base+"in/data+1.txt", nil); st != http.StatusNotFound {
+		t.Fatalf("get after delete: status %d", st)
+	}
+}
+
+func TestFiles_StaysInsideWorkspace(t *testing.T) {
+	srv, root := newFilesServer(t, config.Files{Enabled: true})
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("s"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"../etc/passwd", "%2e%2e/etc/passwd", "%252e%252e%252fetc", "escape/secret"} {
+		if st, _ := doFile(t, http.MethodGet, srv.URL+"/workspace/files/"+p, nil); st != http.StatusBadRequest {
+			t.Errorf("GET %q: status %d, want 400", p, st)
+		}
+		if st, _ := doFile(t, http.MethodPut, srv.URL+"/workspace/files/"+p, strings.NewReader("x")); st != http.StatusBadRequest {
+			t.Errorf("PUT %q: status %d, want 400", p, st)
+		}
+	}
+	if st, _ := doFile(t, http.MethodDelete, srv.URL+"/workspace/files/", nil); st != http.StatusBadRequest {
+		t.Fatalf("delete root: status %d", st)
+	}
+}
+
+func TestFiles_DisabledAndReadOnly(t *testing.T) {
+	srv, _ := newFilesServer(t, config.Files{})
+	if st, _ := doFile(t, http.MethodGet, srv.URL+"/workspace/files/", nil); st != http.StatusNotFound {
+		t.Fatalf("disabled: status %d, want 404", st)
+	}
+
+	srv, root := newFilesServer(t, config.Files{Enabled: true, ReadOnly: true})
+	if err := os.WriteFile(filepath.Join(root, "out.txt"), []byte("result"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if st, out := doFile(t, http.MethodGet, srv.URL+"/workspace/files/out.txt", nil); st != http.StatusOK || out != "result" {
+		t.Fatalf("read-only get: status %d, %q", st, out)
+	}
+	if st, _ := doFile(t, http.MethodPut, srv.URL+"/workspace/files/new.txt", strings.NewReader("x")); st != http.StatusMethodNotAllowed {
+		t.Fatalf("read-only put: status %d, want 405", st)
+	}
+	if st, _ := doFile(t, http.MethodDelete, srv.URL+"/workspace/files/out.txt", nil); st != http.StatusMethodNotAllowed {
+		t.Fatalf("read-only delete: status %d, want 405", st)
+	}
+}
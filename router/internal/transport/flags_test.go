@@ -0,0 +1,92 @@
+package transport_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/transport"
+)
+
+func newFlagsTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+
+	cfg := &config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"echo": {
+				Runtime:   "native",
+				Mode:      "launcher",
+				Cmd:       os.Args[0],
+				Args:      []string{"__mcp_tool_echo_helper__"},
+				TimeoutMS: 3000,
+			},
+		},
+		Flags: config.Flags{Allowed: []string{"ndjson", "coalesce_flush"}},
+	}
+
+	mux := http.NewServeMux()
+	transport.NewHTTP(core.New(cfg)).Register(mux)
+	srv := httptest.NewServer(transport.WrapHardening(mux))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postEcho(t *testing.T, srv *httptest.Server, flagsHeader string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/echo", strings.NewReader(`{"hello":"world"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if flagsHeader != "" {
+		req.Header.Set("X-MCP-Flags", flagsHeader)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestFlags_NDJSONMode(t *testing.T) {
+	srv := newFlagsTestServer(t)
+
+	resp := postEcho(t, srv, "ndjson, coalesce_flush")
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("expected ndjson content-type, got %q", ct)
+	}
+	if got := resp.Header.Get("X-MCP-Flags"); got != "coalesce_flush,ndjson" {
+		t.Fatalf("expected applied flags header, got %q", got)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	if !sc.Scan() {
+		t.Fatalf("expected one ndjson line")
+	}
+	var line map[string]any
+	if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+		t.Fatalf("line is not raw json: %q (%v)", sc.Text(), err)
+	}
+	if line["tool"] != "echo" {
+		t.Fatalf("unexpected line %v", line)
+	}
+}
+
+func TestFlags_NotAllowlistedAreIgnored(t *testing.T) {
+	srv := newFlagsTestServer(t)
+
+	resp := postEcho(t, srv, "stream_v2")
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected default SSE, got %q", ct)
+	}
+	if got := resp.Header.Get("X-MCP-Flags"); got != "" {
+		t.Fatalf("expected no flags applied, got %q", got)
+	}
+}
@@ -0,0 +1,56 @@
+package transport
+
+import (
+	"bufio"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEWriter_BufferedFlushWindow(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse := &sseWriter{w: rec, f: rec, state: &streamState{}, flushEvery: time.Hour}
+	sse.bw = bufio.NewWriterSize(rec, 1024)
+
+	if err := sse.WriteLine([]byte(`{"n":1}`)); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	if rec.Body.Len() != 0 || rec.Flushed {
+		t.Fatalf("expected line held in write buffer, got body=%q flushed=%v", rec.Body.String(), rec.Flushed)
+	}
+
+	sse.Close()
+	if !strings.Contains(rec.Body.String(), `data: {"n":1}`) || !rec.Flushed {
+		t.Fatalf("expected final flush on Close, got body=%q flushed=%v", rec.Body.String(), rec.Flushed)
+	}
+}
+
+func TestSSEWriter_BufferOverflowWritesThrough(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse := &sseWriter{w: rec, f: rec, state: &streamState{}, flushEvery: time.Hour}
+	sse.bw = bufio.NewWriterSize(rec, 64)
+
+	for i := 0; i < 10; i++ {
+		_ = sse.WriteLine([]byte(`{"payload":"0123456789"}`))
+	}
+	if rec.Body.Len() == 0 {
+		t.Fatalf("expected full buffer to write through before the flush window")
+	}
+	sse.Close()
+}
+
+func TestSSEWriter_ControlEventsFlushImmediately(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse := &sseWriter{w: rec, f: rec, state: &streamState{}, flushEvery: time.Hour}
+	sse.bw = bufio.NewWriterSize(rec, 1024)
+
+	_ = sse.WriteLine([]byte(`{"n":1}`))
+	_ = sse.writeEvent("error", map[string]string{"error": "boom"})
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `data: {"n":1}`) || !strings.Contains(body, "event: error") || !rec.Flushed {
+		t.Fatalf("expected buffered line + error event flushed, got %q", body)
+	}
+	sse.Close()
+}
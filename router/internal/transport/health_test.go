@@ -0,0 +1,128 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func TestHTTP_ReadyzToolChecks(t *testing.T) {
+	ok := config.Tool{Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", TimeoutMS: 1000}
+	missing := ok
+	missing.Cmd = "/nonexistent/tool-binary"
+
+	svc := core.New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"good": ok, "broken": missing},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	get := func(path string) (int, map[string]any) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		return resp.StatusCode, out
+	}
+
+	code, body := get("/readyz")
+	if code != http.StatusOK || body["ready"] != true || body["degraded"] != true {
+		t.Fatalf("readyz: status %d body %v", code, body)
+	}
+	ready := body["tools_ready"].(map[string]any)
+	if ready["good"] != true || ready["broken"] != false {
+		t.Fatalf("tools_ready: %v", ready)
+	}
+	problems := body["tool_problems"].([]any)
+	if len(problems) != 1 {
+		t.Fatalf("tool_problems: %v", problems)
+	}
+	p := problems[0].(map[string]any)
+	check := p["checks"].([]any)[0].(map[string]any)
+	if p["tool"] != "broken" || check["name"] != "binary" || check["status"] != core.CheckFail {
+		t.Fatalf("problem: %v", p)
+	}
+	if _, ok := body["tool_checks"]; ok {
+		t.Fatal("tool_checks should only appear with ?verbose=1")
+	}
+
+	_, body = get("/readyz?verbose=1")
+	checks := body["tool_checks"].([]any)
+	if len(checks) != len(ready) {
+		t.Fatalf("tool_checks: %v", checks)
+	}
+	for _, tc := range checks {
+		for _, c := range tc.(map[string]any)["checks"].([]any) {
+			if _, ok := c.(map[string]any)["duration_ms"]; !ok {
+				t.Fatalf("verbose check without duration: %v", c)
+			}
+		}
+	}
+
+	code, body = get("/livez")
+	if code != http.StatusOK || body["live"] != true {
+		t.Fatalf("livez: status %d body %v", code, body)
+	}
+}
+
+func TestHTTP_Version(t *testing.T) {
+	svc := core.New(&config.Config{
+		WorkspaceRoot: t.TempDir(),
+		ToolsRoot:     "/tmp/tools",
+		Stream:        config.Stream{ResumeWindowMS: 30000},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Version  string   `json:"version"`
+		Commit   string   `json:"commit"`
+		Features []string `json:"features"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Version == "" || body.Commit == "" {
+		t.Fatalf("version: status %d body %+v", resp.StatusCode, body)
+	}
+	want := map[string]bool{core.FeatureSSEResume: false, core.FeatureCancellation: false}
+	for _, f := range body.Features {
+		if _, ok := want[f]; ok {
+			want[f] = true
+		}
+		if f == core.FeatureFederation {
+			t.Fatalf("federation advertised while disabled: %v", body.Features)
+		}
+	}
+	for f, seen := range want {
+		if !seen {
+			t.Fatalf("feature %s missing: %v", f, body.Features)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/egress"
+	"mcp-router/internal/observability/logging"
+)
+
+// loopbackHosts são os Host/Origin aceitos por default quando o gateway escuta em loopback.
+var loopbackHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// WrapHostCheck recusa (403) requests com Host ou Origin fora de host_check: uma página
+// que resolve o próprio domínio para 127.0.0.1 (DNS rebinding) chega com o Host dela.
+// loopback: o listener é local (127.0.0.0/8, ::1, named pipe); sem allowed_hosts/
+// allowed_origins, só nomes de loopback passam. /healthz, /readyz e /livez ficam de fora (probes
+// usam o IP do pod/host).
+func WrapHostCheck(next http.Handler, hc config.HostCheck, loopback bool) http.Handler {
+	if hc.Disabled {
+		return next
+	}
+	hosts, origins := hc.AllowedHosts, hc.AllowedOrigins
+	if len(hosts) == 0 && loopback {
+		hosts = loopbackHosts
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" || r.URL.Path == "/livez" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(hosts) > 0 && !egress.Allowed(hosts, hostOnly(r.Host)) {
+			rejectHost(w, r, "host not allowed", logging.String("host", r.Host))
+			return
+		}
+		if o := r.Header.Get("Origin"); o != "" && !originAllowed(o, origins, loopback) {
+			rejectHost(w, r, "origin not allowed", logging.String("origin", o))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func rejectHost(w http.ResponseWriter, r *http.Request, msg string, attr slog.Attr) {
+	logging.LoggerFromContext(r.Context()).Warn("request rejected by host_check", attr, logging.String("client_ip", logging.ClientIP(r)))
+	writeError(w, r, http.StatusForbidden, core.CodeHostNotAllowed, msg)
+}
+
+// originAllowed: com allowed_origins, o Origin precisa bater (scheme://host[:port]); sem
+// ele, em loopback só origens de loopback passam e em outro endereço não há checagem.
+func originAllowed(origin string, allowed []string, loopback bool) bool {
+	if len(allowed) == 0 {
+		if !loopback {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && u.Host != "" && egress.Allowed(loopbackHosts, u.Hostname())
+	}
+	origin = strings.TrimSuffix(origin, "/")
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostOnly tira a porta (e os colchetes de IPv6) do Host.
+func hostOnly(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.Trim(host, "[]")
+}
+
+// isLoopbackListener diz se o listener só aceita conexões locais (TCP em loopback ou
+// named pipe/unix socket).
+func isLoopbackListener(ln net.Listener) bool {
+	addr, ok := ln.Addr().(*net.TCPAddr)
+	if !ok {
+		return true
+	}
+	return addr.IP.IsLoopback()
+}
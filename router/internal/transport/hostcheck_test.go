@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mcp-router/internal/config"
+)
+
+func TestWrapHostCheck(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	cases := []struct {
+		name     string
+		hc       config.HostCheck
+		loopback bool
+		path     string
+		host     string
+		origin   string
+		want     int
+	}{
+		{"loopback default localhost", config.HostCheck{}, true, "/mcp/tools", "localhost:8080", "", http.StatusNoContent},
+		{"loopback default ipv6", config.HostCheck{}, true, "/mcp/tools", "[::1]:8080", "", http.StatusNoContent},
+		{"loopback rebinding host", config.HostCheck{}, true, "/mcp/tools", "attacker.example:8080", "", http.StatusForbidden},
+		{"loopback foreign origin", config.HostCheck{}, true, "/mcp/echo", "127.0.0.1:8080", "http://attacker.example", http.StatusForbidden},
+		{"loopback local origin", config.HostCheck{}, true, "/mcp/echo", "127.0.0.1:8080", "http://localhost:3000", http.StatusNoContent},
+		{"probes skip the check", config.HostCheck{}, true, "/healthz", "10.0.0.7:8080", "", http.StatusNoContent},
+		{"public bind without list", config.HostCheck{}, false, "/mcp/tools", "gw.example.com", "https://app.example.com", http.StatusNoContent},
+		{"allowed_hosts wildcard", config.HostCheck{AllowedHosts: []string{"*.example.com"}}, false, "/mcp/tools", "gw.example.com:443", "", http.StatusNoContent},
+		{"allowed_hosts miss", config.HostCheck{AllowedHosts: []string{"*.example.com"}}, false, "/mcp/tools", "gw.example.org", "", http.StatusForbidden},
+		{"allowed_origins hit", config.HostCheck{AllowedOrigins: []string{"https://app.example.com"}}, false, "/mcp/tools", "gw", "https://app.example.com", http.StatusNoContent},
+		{"allowed_origins miss", config.HostCheck{AllowedOrigins: []string{"https://app.example.com"}}, true, "/mcp/tools", "localhost", "http://localhost:3000", http.StatusForbidden},
+		{"disabled", config.HostCheck{Disabled: true}, true, "/mcp/tools", "attacker.example", "http://attacker.example", http.StatusNoContent},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, c.path, nil)
+			req.Host = c.host
+			if c.origin != "" {
+				req.Header.Set("Origin", c.origin)
+			}
+			rec := httptest.NewRecorder()
+			WrapHostCheck(ok, c.hc, c.loopback).ServeHTTP(rec, req)
+			if rec.Code != c.want {
+				t.Fatalf("status %d, want %d (%s)", rec.Code, c.want, rec.Body.String())
+			}
+		})
+	}
+}
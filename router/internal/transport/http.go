@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -9,70 +10,218 @@ import (
 	"io"
 	"mime"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"mcp-router/internal/builtin"
+	"mcp-router/internal/config"
 	"mcp-router/internal/core"
+	"mcp-router/internal/flags"
 	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/observability/metrics"
+	"mcp-router/internal/runner"
 	"mcp-router/internal/runtime"
 	"mcp-router/internal/sandbox"
 )
 
 const maxRequestBodyBytes = 1 << 20 // 1MB
 
+// ndjsonMediaType é o Content-Type do input em stream (tools interactive).
+const ndjsonMediaType = "application/x-ndjson"
+
+// octetMediaType é o Content-Type de body binário (só tools stream_body).
+const octetMediaType = "application/octet-stream"
+
+// coalesceFlushInterval é a janela de flush quando a flag coalesce_flush está ativa.
+const coalesceFlushInterval = 50 * time.Millisecond
+
+// timeoutHeader: na request, o timeout pedido pelo cliente (duração Go, ex: 120s),
+// limitado ao max_timeout_ms da tool; na resposta, o timeout que vale de fato.
+const timeoutHeader = "X-MCP-Timeout"
+
+// deprecationHeader traz o aviso de chamadas por alias ou a tools deprecated (junto com
+// "Deprecation: true").
+const deprecationHeader = "X-MCP-Deprecation"
+
 type HTTP struct {
 	core *core.Service
+
+	// live: serviço + handler atendidos pelo servidor de Run. Reload troca os dois sem
+	// derrubar o listener; requests em andamento terminam no serviço antigo.
+	live atomic.Pointer[httpLive]
+
+	// resumes: streams SSE retomáveis (stream.resume_window_ms); sobrevivem ao Reload
+	resumes *resumeRegistry
+
+	// loopback: o listener de Run é local (default do host_check: só localhost)
+	loopback bool
+
+	// dev: mcp-gw http --dev (ver dev.go; mantido no Reload)
+	dev bool
+
+	// started: início do processo (uptime do /livez; mantido no Reload)
+	started time.Time
+}
+
+type httpLive struct {
+	core    *core.Service
+	handler http.Handler
 }
 
 func NewHTTP(c *core.Service) *HTTP {
-	return &HTTP{core: c}
+	return &HTTP{core: c, resumes: newResumeRegistry(), started: time.Now()}
 }
 
 // Register registra as rotas HTTP do gateway.
 func (h *HTTP) Register(mux *http.ServeMux) {
+	// probes do orquestrador (k8s, systemd, load balancer): sem auth, como no host_check
 	mux.HandleFunc("/healthz", h.handleHealthz)
 	mux.HandleFunc("/readyz", h.handleReadyz)
+	mux.HandleFunc("/livez", h.handleLivez)
+
+	// auth: requireRole autentica o cliente e confere o papel da rota (read ou invoke)
+	mux.HandleFunc("/version", h.requireRole(config.RoleRead, h.handleVersion))
+	mux.Handle("/metrics", h.requireRole(config.RoleRead, metrics.Default.Handler().ServeHTTP))
+
+	mux.HandleFunc("/mcp/tools", h.requireRole(config.RoleRead, h.handleTools))
+	mux.HandleFunc("/mcp/tools/", h.requireRole(config.RoleRead, h.handleToolStats))
+	mux.HandleFunc("/mcp/requests/", h.requireRole(config.RoleInvoke, h.handleCancel))
+	mux.HandleFunc("/mcp/sessions", h.requireRole(config.RoleInvoke, h.handleSessions))
+	mux.HandleFunc("/mcp/sessions/", h.requireRole(config.RoleInvoke, h.handleSessions))
+	mux.HandleFunc("/mcp/streams/", h.requireRole(config.RoleInvoke, h.handleResume))
+	mux.HandleFunc("/mcp/", h.requireInvokeOrSignedURL(h.withWorkspace(h.handleMCP)))
 
-	mux.HandleFunc("/mcp/tools", h.handleTools)
-	mux.HandleFunc("/mcp/", h.handleMCP)
+	// Endpoint MCP agregado (JSON-RPC) das tools federadas
+	mux.HandleFunc("/mcp", h.requireRole(config.RoleInvoke, h.withWorkspace(h.handleRPC)))
+
+	// Arquivos do workspace (files.enabled; X-MCP-Workspace escolhe a raiz). Download é
+	// read; upload e remoção exigem invoke (handleFiles)
+	mux.HandleFunc(filesPrefix, h.requireRole(config.RoleRead, h.withWorkspace(h.handleFiles)))
+
+	// UI de administração (estática; os dados vêm das rotas acima, com a auth delas)
+	ui := uiHandler()
+	mux.Handle("/ui", ui)
+	mux.Handle("/ui/", ui)
+
+	h.registerAdmin(mux)
+	h.registerDebug(mux)
 }
 
-// Run sobe o servidor HTTP e faz shutdown gracioso quando ctx for cancelado.
+// Run sobe o servidor HTTP (HTTPS com tls configurado) e faz shutdown gracioso quando
+// ctx for cancelado.
 //
 // Importante: o handler do server é embrulhado com hardening (bloqueia dot-segments antes do ServeMux).
 func (h *HTTP) Run(ctx context.Context, addr string) error {
-	mux := http.NewServeMux()
-	h.Register(mux)
-
 	srv := &http.Server{
-		Addr:              addr,
-		Handler:           WrapHardening(logging.Middleware(mux)),
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.live.Load().handler.ServeHTTP(w, r)
+		}),
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       15 * time.Second,
 		WriteTimeout:      0,                // SSE
 		IdleTimeout:       60 * time.Second, // keep-alive
 	}
 
+	tlsOn := false
+	if tc := h.core.TLS(); tc.Enabled() {
+		cfg, err := serverTLSConfig(tc)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = cfg
+		tlsOn = true
+	}
+
+	// socket do systemd (socket activation) ou addr (TCP ou npipe://, ver npipe.go)
+	ln, err := activationListener(ctx)
+	if err != nil {
+		return err
+	}
+	if ln != nil {
+		logging.LoggerFromContext(ctx).Info("http: serving on systemd-activated socket", logging.String("addr", ln.Addr().String()))
+	} else {
+		if addr == "" {
+			addr = ":http"
+		}
+		if ln, err = listen(addr); err != nil {
+			return err
+		}
+	}
+	// o handler depende do endereço efetivo (host_check em loopback)
+	h.loopback = isLoopbackListener(ln)
+	if err := h.checkDevListener(ln.Addr().String()); err != nil {
+		_ = ln.Close()
+		return err
+	}
+	h.live.CompareAndSwap(nil, &httpLive{core: h.core, handler: h.handler()})
+
 	errCh := make(chan error, 1)
-	go func() { errCh <- srv.ListenAndServe() }()
+	go func() {
+		if tlsOn {
+			errCh <- srv.ServeTLS(ln, "", "")
+		} else {
+			errCh <- srv.Serve(ln)
+		}
+	}()
+	sdNotify(ctx, "READY=1")
 
 	select {
 	case <-ctx.Done():
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		// 1) drain: para de aceitar conexões e espera requests em andamento
+		sdNotify(ctx, "STOPPING=1")
+		svc := h.live.Load().core
+		drain := svc.ShutdownDrain()
+		drainCtx, cancel := context.WithTimeout(context.Background(), drain)
 		defer cancel()
-		_ = srv.Shutdown(shutdownCtx)
+		if err := srv.Shutdown(drainCtx); err == nil {
+			svc.ForceKillAll(ctx, drain) // relatório vazio (nada a matar)
+			return nil
+		}
+
+		// 2) fim do drain: mata o que sobrou e emite o relatório
+		svc.ForceKillAll(ctx, drain)
+
+		// 3) janela curta para os handlers emitirem event:error e retornarem
+		finalCtx, cancelFinal := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancelFinal()
+		_ = srv.Shutdown(finalCtx)
 		return nil
 	case err := <-errCh:
 		return err
 	}
 }
 
+// handler monta o mux com os middlewares (hardening, access log, compressão) de h.core.
+func (h *HTTP) handler() http.Handler {
+	mux := http.NewServeMux()
+	h.Register(mux)
+	hc := h.core.HostCheck()
+	handler := WrapHostCheck(WrapHardening(logging.MiddlewareWithAccessLog(WrapCompression(mux, h.core.Compression()), logging.AccessLogOptions{Format: h.core.AccessLog().Format, TrustedProxies: h.core.TrustedProxies()})),
+		hc, h.loopback)
+	if h.dev {
+		handler = WrapDevCORS(handler, hc.AllowedOrigins)
+	}
+	return handler
+}
+
+// Reload passa a atender as próximas requests com svc (config novo). Listener e TLS
+// continuam os do start.
+func (h *HTTP) Reload(svc *core.Service) {
+	next := &HTTP{core: svc, resumes: h.resumes, loopback: h.loopback, dev: h.dev, started: h.started}
+	h.live.Store(&httpLive{core: svc, handler: next.handler()})
+}
+
 // WrapHardening bloqueia paths com dot-segments antes do ServeMux tentar limpar e redirecionar.
 // Isso garante 400 (e não 301) para tentativas como /mcp/../evil.
 func WrapHardening(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Só aplica no namespace /mcp
-		if !strings.HasPrefix(r.URL.Path, "/mcp") {
+		// Só aplica no namespace /mcp (e nos arquivos do workspace)
+		if !strings.HasPrefix(r.URL.Path, "/mcp") && !strings.HasPrefix(r.URL.Path, "/workspace") {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -81,7 +230,7 @@ func WrapHardening(next http.Handler) http.Handler {
 		p := r.URL.Path
 		if strings.Contains(p, "/../") || strings.HasSuffix(p, "/..") ||
 			strings.Contains(p, "/./") || strings.HasSuffix(p, "/.") {
-			http.Error(w, "invalid path", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid path")
 			return
 		}
 
@@ -89,7 +238,7 @@ func WrapHardening(next http.Handler) http.Handler {
 		ep := strings.ToLower(r.URL.EscapedPath())
 		// cobre %2e%2e%2f, %2e%2e/, %2e/ etc
 		if strings.Contains(ep, "%2e%2e") || strings.Contains(ep, "%2e/") || strings.Contains(ep, "/%2e") {
-			http.Error(w, "invalid path", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid path")
 			return
 		}
 
@@ -102,6 +251,36 @@ func (h *HTTP) handleHealthz(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("ok\n"))
 }
 
+// GET /livez: o processo está vivo e atendendo. Nunca consulta docker nem tools (para o
+// orquestrador não reiniciar o gateway por causa de uma dependência fora do ar; isso é
+// o /readyz).
+func (h *HTTP) handleLivez(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"live":      true,
+		"pid":       os.Getpid(),
+		"uptime_ms": time.Since(h.started).Milliseconds(),
+	})
+}
+
+// GET /version: build do gateway (o mesmo do `mcp-gw version`) e os recursos de
+// protocolo ligados, para clientes e shims se adaptarem à versão do gateway.
+func (h *HTTP) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		builtin.VersionReport
+		Features []string `json:"features"`
+	}{builtin.Version(), h.core.Features()})
+}
+
+// verbose diz se a request pediu a forma detalhada (?verbose=1).
+func verbose(r *http.Request) bool {
+	v, _ := strconv.ParseBool(r.URL.Query().Get("verbose"))
+	return v
+}
+
 func (h *HTTP) handleReadyz(w http.ResponseWriter, r *http.Request) {
 	tools, err := h.core.ListTools(r.Context())
 	if err != nil {
@@ -143,92 +322,370 @@ func (h *HTTP) handleReadyz(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		runtimes["container"] = true
+
+		// pré-pull: não pronto enquanto alguma imagem estiver baixando ou tiver falhado
+		for _, img := range h.core.Images() {
+			if img.State == core.ImageReady {
+				continue
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ready":    false,
+				"reason":   "images_not_ready",
+				"images":   h.core.Images(),
+				"runtimes": runtimes,
+			})
+			return
+		}
+
+		if required := h.core.ContainerRuntimes(); len(required) > 0 {
+			missing, err := runtime.DockerRuntimesReady(r.Context(), required)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"ready":    false,
+					"reason":   "container_runtime_unavailable",
+					"error":    err.Error(),
+					"missing":  missing,
+					"runtimes": runtimes,
+				})
+				return
+			}
+			runtimes["container_runtimes"] = required
+		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(map[string]any{
+	resp := map[string]any{
 		"ready":         true,
 		"config_loaded": true,
 		"tools":         len(tools),
 		"runtimes":      runtimes,
-	})
+	}
+	// sessões com health check falhando: o gateway segue pronto (as demais tools atendem),
+	// mas sinaliza degraded
+	if th := h.core.ToolHealth(); len(th) > 0 {
+		resp["tools_health"] = th
+		for _, ph := range th {
+			if ph.State == runner.HealthUnhealthy {
+				resp["degraded"] = true
+			}
+		}
+	}
+
+	// readiness por tool (binário, imagem, health, taxa de erro); ?verbose=1 traz todos os
+	// checks com a duração de cada um, senão só os que não passaram
+	start := time.Now()
+	toolChecks := h.core.ToolReadiness(r.Context())
+	ready := make(map[string]bool, len(toolChecks))
+	var problems []core.ToolReadiness
+	for _, tr := range toolChecks {
+		ready[tr.Tool] = tr.Ready
+		if !tr.Ready {
+			resp["degraded"] = true
+		}
+		var bad []core.Check
+		for _, c := range tr.Checks {
+			if c.Status != core.CheckPass {
+				c.DurationMS = 0
+				bad = append(bad, c)
+			}
+		}
+		if len(bad) > 0 {
+			tr.Checks = bad
+			problems = append(problems, tr)
+		}
+	}
+	resp["tools_ready"] = ready
+	if verbose(r) {
+		resp["tool_checks"] = toolChecks
+		resp["duration_ms"] = time.Since(start).Milliseconds()
+	} else if len(problems) > 0 {
+		resp["tool_problems"] = problems
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
 }
 
 func (h *HTTP) handleTools(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	tools, err := h.core.ListTools(r.Context())
 	if err != nil {
-		http.Error(w, "failed to list tools", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, core.CodeInternal, "failed to list tools")
 		return
 	}
 
+	// ?filter=tag:foo (repetível; todos precisam casar) e ?fields=name,description
+	q := r.URL.Query()
+	tools, err = filterTools(tools, q["filter"])
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, err.Error())
+		return
+	}
+	var out any = tools
+	if f := q.Get("fields"); f != "" {
+		if out, err = selectToolFields(tools, strings.Split(f, ",")); err != nil {
+			writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{"tools": tools})
+	_ = json.NewEncoder(w).Encode(map[string]any{"tools": out})
+}
+
+// GET /mcp/tools/<name>/stats: contadores recentes da tool (ver core.ToolStats).
+func (h *HTTP) handleToolStats(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/mcp/tools/"), "/stats")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		writeError(w, r, http.StatusNotFound, core.CodeNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	st, err := h.core.ToolStats(name)
+	if err != nil {
+		writeErrorFor(w, r, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, st)
+}
+
+func (h *HTTP) handleDryRun(w http.ResponseWriter, r *http.Request) {
+	toolName := strings.Trim(strings.TrimPrefix(r.URL.Path, "/mcp/"), "/")
+	logging.SetAccessTool(r.Context(), toolName)
+	plan, err := h.core.SpawnPlan(r.Context(), toolName)
+	if err != nil {
+		writeErrorFor(w, r, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+func (h *HTTP) handleRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeError(w, r, http.StatusUnsupportedMediaType, core.CodeUnsupportedMedia, "unsupported media type")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid body")
+		return
+	}
+
+	var resp *rpcResponse
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		resp = rpcErr(nil, rpcParseError, "parse error")
+	} else {
+		resp = handleRPC(r.Context(), h.core, &req)
+	}
+
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// DELETE /mcp/requests/<request_id>
+// Cancela a execução em andamento (mata o processo); o stream dela termina com event: cancelled.
+// Só quem iniciou pode cancelar (o mesmo principal; sem auth, o mesmo IP), ou um admin
+// com o bearer token.
+func (h *HTTP) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	rid := strings.Trim(strings.TrimPrefix(r.URL.Path, "/mcp/requests/"), "/")
+	if rid == "" || strings.Contains(rid, "/") {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid request id")
+		return
+	}
+
+	owner := core.Identity(r.Context())
+	if owner == "" {
+		owner = logging.ClientIP(r)
+	}
+	if h.isAdmin(r) {
+		owner = ""
+	}
+
+	if !h.core.CancelRequest(rid, owner) {
+		writeError(w, r, http.StatusNotFound, core.CodeNotFound, "request not found")
+		return
+	}
+	logging.LoggerFromContext(r.Context()).Info("request cancelled by client",
+		logging.String("cancelled_request_id", rid),
+	)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *HTTP) handleMCP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	// Content-Type precisa ser application/json
+	// ?dry_run=1: devolve o plano de spawn (comando, env, mounts, limites) sem executar
+	if dry, _ := strconv.ParseBool(r.URL.Query().Get("dry_run")); dry {
+		h.handleDryRun(w, r)
+		return
+	}
+
+	// URL assinada (?id=&exp=&sig=): o input é fixo, então body e Content-Type são ignorados
+	signed := signedURLCall(r)
+
+	// Content-Type precisa ser application/json, ou application/x-ndjson para input em
+	// stream (tools interactive: uma mensagem por linha até o cliente encerrar o body), ou
+	// application/octet-stream (tools stream_body: o body vai cru para o stdin)
 	ct := r.Header.Get("Content-Type")
-	if ct == "" {
-		http.Error(w, "unsupported media type", http.StatusUnsupportedMediaType)
+	if ct == "" && !signed {
+		writeError(w, r, http.StatusUnsupportedMediaType, core.CodeUnsupportedMedia, "unsupported media type")
 		return
 	}
 	mediaType, _, err := mime.ParseMediaType(ct)
-	if err != nil || mediaType != "application/json" {
-		http.Error(w, "unsupported media type", http.StatusUnsupportedMediaType)
+	if !signed && (err != nil || (mediaType != "application/json" && mediaType != ndjsonMediaType && mediaType != octetMediaType)) {
+		writeError(w, r, http.StatusUnsupportedMediaType, core.CodeUnsupportedMedia, "unsupported media type")
 		return
 	}
+	streamInput := !signed && mediaType == ndjsonMediaType
 
 	toolName := strings.TrimPrefix(r.URL.Path, "/mcp/")
 	toolName = strings.Trim(toolName, "/")
 
 	if err := sandbox.ValidateToolName(toolName); err != nil {
-		http.Error(w, "invalid tool name", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidToolName, "invalid tool name")
 		return
 	}
+	// alias -> tool canônica; alias ou tool deprecated: Deprecation + X-MCP-Deprecation com o aviso
+	toolName, dep := h.core.ResolveTool(r.Context(), toolName)
+	if dep != nil {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set(deprecationHeader, dep.Message)
+	}
+	logging.SetAccessTool(r.Context(), toolName)
 
-	// body bounded
-	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "invalid body", http.StatusBadRequest)
+	var body []byte
+	var more io.Reader
+	if signed {
+		// sem credencial (requireInvokeOrSignedURL): nada além daqui sem uma URL válida
+		body, err = h.core.RedeemSignedURL(toolName, r.URL.Query())
+		if err != nil {
+			writeErrorFor(w, r, http.StatusForbidden, err)
+			return
+		}
+	}
+
+	// tools daemon: a request vai para o processo da sessão (Mcp-Session-Id)
+	sid := r.Header.Get(sessionHeader)
+	if err := h.core.CheckSession(toolName, sid); err != nil {
+		writeErrorFor(w, r, errorStatus(err), err)
 		return
 	}
-	body = bytes.TrimSpace(body)
-	if len(body) == 0 {
-		body = []byte(`{}`)
+
+	// stream_body: body chunked, acima do limite do buffer ou binário vai em stream
+	canStream, maxBody := h.core.ToolStreamBody(toolName)
+	streamBody := !signed && !streamInput && canStream &&
+		(mediaType == octetMediaType || r.ContentLength < 0 || r.ContentLength > maxRequestBodyBytes)
+	if !signed && mediaType == octetMediaType && !canStream {
+		writeError(w, r, http.StatusUnsupportedMediaType, core.CodeUnsupportedMedia, "tool does not accept application/octet-stream bodies")
+		return
 	}
-	if !json.Valid(body) {
-		http.Error(w, "body must be valid JSON", http.StatusBadRequest)
+	if streamBody && r.ContentLength > maxBody {
+		writeErrorFor(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("%w: limit is %d bytes", core.ErrBodyTooLarge, maxBody))
 		return
 	}
 
+	if streamBody {
+		more = openBodyStream(w, r, maxBody)
+	} else if streamInput {
+		if !h.core.ToolInteractive(toolName) {
+			writeError(w, r, http.StatusBadRequest, core.CodeNotInteractive, "tool does not accept streamed input")
+			return
+		}
+		body, more, err = openInputStream(w, r)
+		if err != nil {
+			writeErrorFor(w, r, http.StatusBadRequest, err)
+			return
+		}
+	} else if !signed {
+		// body bounded
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid body")
+			return
+		}
+		body = bytes.TrimSpace(body)
+		if len(body) == 0 {
+			body = []byte(`{}`)
+		}
+		if !json.Valid(body) {
+			writeError(w, r, http.StatusBadRequest, core.CodeInvalidInput, "body must be valid JSON")
+			return
+		}
+	}
+
 	// runtime (best effort via ListTools) - usado só para header/log
 	rt := h.lookupRuntime(r.Context(), toolName)
 
+	// feature flags (config default + X-MCP-Flags filtrado pela allowlist)
+	fs, rejected := h.core.ResolveFlags(r.Header.Get(flags.Header))
+	ctx := flags.WithContext(r.Context(), fs)
+
 	// request-scoped logger (from middleware) + fixed fields
-	rid := logging.RequestIDFromContext(r.Context())
-	logger := logging.LoggerFromContext(r.Context()).With(
+	rid := logging.RequestIDFromContext(ctx)
+	logger := logging.LoggerFromContext(ctx).With(
 		logging.Tool(toolName),
 		logging.Runtime(rt),
 		logging.RequestID(rid),
+		logging.String("flags", fs.String()),
 	)
+	if len(rejected) > 0 {
+		logger.Debug("ignored flags not in allowlist", logging.String("rejected", strings.Join(rejected, ",")))
+	}
+	ctx = logging.WithLogger(ctx, logger)
+	ctx = runner.WithSessionID(ctx, sid)
+
+	// X-MCP-Timeout: timeout por request, até o teto max_timeout_ms da tool
+	timeout, _ := h.core.ToolTimeout(toolName)
+	if v := r.Header.Get(timeoutHeader); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid "+timeoutHeader+" (want a positive duration, e.g. 120s)")
+			return
+		}
+		timeout, _ = h.core.ClampToolTimeout(toolName, d)
+		if timeout < d {
+			logger.Debug("requested timeout capped", logging.String("requested", d.String()), logging.String("timeout", timeout.String()))
+		}
+		ctx = core.WithRequestTimeout(ctx, timeout)
+	}
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, core.CodeInternal, "streaming unsupported")
 		logger.Error("streaming unsupported",
 			logging.Err(fmt.Errorf("http.Flusher not supported")),
 			logging.DurationMs(time.Since(start).Milliseconds()),
@@ -237,32 +694,126 @@ func (h *HTTP) handleMCP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// SSE headers (somente depois de validar tudo)
-	w.Header().Set("Content-Type", "text/event-stream")
+	ndjson := fs.Enabled(flags.NDJSON)
+	if ndjson {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/event-stream")
+	}
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no")
 	w.Header().Set("X-MCP-Tool", toolName)
+	if sid != "" {
+		w.Header().Set(sessionHeader, sid)
+	}
 
-	// timeout (best effort via core helper)
-	if d, ok := h.core.ToolTimeout(toolName); ok {
-		w.Header().Set("X-MCP-Timeout", d.String())
+	if timeout > 0 {
+		w.Header().Set(timeoutHeader, timeout.String())
 	}
 	if rt != "" {
 		w.Header().Set("X-MCP-Runtime", rt)
 	}
+	if len(fs) > 0 {
+		w.Header().Set(flags.Header, fs.String())
+	}
+	// --dev: o plano do dry-run nos headers, sem precisar de ?dry_run=1
+	if h.dev {
+		h.setDevPlanHeaders(ctx, w, toolName)
+	}
 
 	state := &streamState{}
-	sse := &sseWriter{w: w, f: flusher, state: state}
+	sse := &sseWriter{w: w, f: flusher, state: state, ndjson: ndjson}
+
+	// tuning por tool (config); a flag coalesce_flush só liga coalescência em tools com flush por linha
+	flushEvery, bufSize := h.core.ToolFlushPolicy(toolName)
+	if flushEvery == 0 && fs.Enabled(flags.CoalesceFlush) {
+		flushEvery = coalesceFlushInterval
+	}
+	sse.flushEvery = flushEvery
+	if bufSize > 0 {
+		sse.bw = bufio.NewWriterSize(w, bufSize)
+	}
 
-	// r.Context() é cancelado quando o cliente desconecta.
-	err = h.core.StreamTool(r.Context(), toolName, body, sse)
+	// buffer limitado entre a tool e o cliente + política de cliente lento
+	bufLines, policy := h.core.ToolBackpressure(toolName)
+
+	if window := h.core.StreamResumeWindow(); window > 0 && !ndjson && !streamInput && !streamBody {
+		// resume ligado: a execução sobrevive à queda do cliente (ver resume.go)
+		err = h.streamResumable(ctx, w, r, toolName, body, sse, window)
+	} else {
+		out := newBoundedWriter(sse, bufLines, policy, func() {
+			// derruba escritas bloqueadas no cliente lento (disconnect)
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Now())
+		})
+
+		// r.Context() é cancelado quando o cliente desconecta.
+		if streamBody {
+			err = h.core.StreamToolBody(ctx, toolName, more, out)
+		} else {
+			err = h.core.StreamToolInput(ctx, toolName, body, more, out)
+		}
+		out.Close()
+	}
+	sse.Close()
+	sse.mu.Lock()
+	logging.AddAccessLines(ctx, sse.lines)
+	sse.mu.Unlock()
+	if errors.Is(err, errSlowClient) {
+		// conexão já abortada: nada mais a escrever
+		logger.Warn("slow client disconnected (outbound buffer full)",
+			logging.Int("buffer_lines", bufLines),
+			logging.DurationMs(time.Since(start).Milliseconds()),
+		)
+		return
+	}
+	finishStream(ctx, w, r, sse, err, start)
+}
+
+// finishStream fecha o stream conforme o resultado da execução: evento terminal
+// (cancelled, truncated, error) ou, se nada foi enviado ainda, erro HTTP.
+func finishStream(ctx context.Context, w http.ResponseWriter, r *http.Request, sse *sseWriter, err error, start time.Time) {
+	logger := logging.LoggerFromContext(ctx)
+	rid := logging.RequestIDFromContext(ctx)
+	flusher, state := sse.f, sse.state
+
+	if errors.Is(err, errClientGone) {
+		// resume: a execução segue sem cliente até a reconexão (ou o fim da janela)
+		logger.Info("client disconnected; stream kept for resume",
+			logging.DurationMs(time.Since(start).Milliseconds()),
+		)
+		return
+	}
+	if errors.Is(err, core.ErrCancelled) {
+		// cancelamento explícito (DELETE /mcp/requests/<id>) não é falha: encerra com event: cancelled
+		_ = sse.writeEvent("cancelled", map[string]string{"request_id": rid})
+		flusher.Flush()
+		logger.Info("tool stream cancelled",
+			logging.DurationMs(time.Since(start).Milliseconds()),
+		)
+		return
+	}
+	var trunc *core.TruncatedError
+	if errors.As(err, &trunc) {
+		// limite de saída atingido: encerra com event: truncated (totais entregues)
+		_ = sse.writeEvent("truncated", trunc)
+		flusher.Flush()
+		logger.Warn("tool stream truncated",
+			logging.String("limit", trunc.Limit),
+			logging.Int64("bytes", trunc.Bytes),
+			logging.Int64("lines", trunc.Lines),
+			logging.DurationMs(time.Since(start).Milliseconds()),
+		)
+		return
+	}
 	if err != nil {
 		// regra: erro antes do primeiro evento -> HTTP error
 		if state.canHTTPError() {
-			// mapeia concorrência para 429 (fail-fast)
-			if errors.Is(err, core.ErrToolBusy) {
-				w.Header().Set("Retry-After", "1")
-				http.Error(w, "tool busy", http.StatusTooManyRequests)
+			w.Header().Del(resumeHeader) // nada a retomar
+			// status pelo código do erro: 429 busy, 504 timeout, 404 tool desconhecida,
+			// 400 input inválido, 502 spawn/falha da tool (ver codeStatus)
+			if core.IsBusy(err) {
+				writeErrorFor(w, r, errorStatus(err), err)
 				logger.Warn("tool busy (concurrency limit)",
 					logging.Err(err),
 					logging.DurationMs(time.Since(start).Milliseconds()),
@@ -270,7 +821,7 @@ func (h *HTTP) handleMCP(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeErrorFor(w, r, errorStatus(err), err)
 			logger.Error("tool stream failed before first event",
 				logging.Err(err),
 				logging.DurationMs(time.Since(start).Milliseconds()),
@@ -286,12 +837,7 @@ func (h *HTTP) handleMCP(w http.ResponseWriter, r *http.Request) {
 
 		// Evita múltiplos erros em SSE
 		state.trySendStreamError(func() error {
-			// Para busy pós-início (raro), também vira error event.
-			msg := err.Error()
-			if errors.Is(err, core.ErrToolBusy) {
-				msg = "tool busy"
-			}
-			return sendSSE(w, "error", map[string]string{"error": msg})
+			return sse.writeEvent("error", core.ErrorFor(err, rid))
 		})
 		flusher.Flush()
 		return
@@ -338,29 +884,176 @@ func (s *streamState) trySendStreamError(send func() error) {
 }
 
 // sseWriter implementa core.LineWriter.
+//
+// Formatos:
+// - SSE (default): event: message / data: <linha>
+// - NDJSON (flag ndjson): <linha>\n
+//
+// Flush (por tool via flush_interval_ms, ou pela flag coalesce_flush):
+// - flushEvery == 0: flush por linha (default; menor latência)
+// - flushEvery > 0: agrupa linhas e faz flush no fim da janela (maior throughput)
+//
+// bw (write_buffer_bytes) é um buffer de escrita próprio na frente do ResponseWriter;
+// quando cheio, escreve para a conexão mesmo antes da janela de flush.
 type sseWriter struct {
 	w     http.ResponseWriter
 	f     http.Flusher
+	bw    *bufio.Writer
 	state *streamState
+
+	ndjson     bool
+	flushEvery time.Duration
+
+	mu    sync.Mutex
+	timer *time.Timer
+	lines int64 // linhas entregues (access log); protegido por mu
+}
+
+// out retorna o destino das escritas (buffer próprio, se configurado).
+func (s *sseWriter) out() io.Writer {
+	if s.bw != nil {
+		return s.bw
+	}
+	return s.w
 }
 
 func (s *sseWriter) WriteLine(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if !s.state.started {
 		s.state.markStarted()
 	}
-	if err := sendRawSSE(s.w, "message", line); err != nil {
+
+	var err error
+	if s.ndjson {
+		_, err = s.out().Write(append(bytes.TrimSpace(line), '\n'))
+	} else {
+		err = sendRawSSE(s.out(), "message", line)
+	}
+	if err != nil {
 		return err
 	}
-	s.f.Flush()
+	s.lines++
+
+	s.scheduleFlush()
+	return nil
+}
+
+// WriteEvent escreve um evento de output (data, continuation, line_truncated) com a
+// mesma política de flush das linhas.
+func (s *sseWriter) WriteEvent(event string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.state.started {
+		s.state.markStarted()
+	}
+
+	var err error
+	if s.ndjson {
+		b, _ := json.Marshal(map[string]any{"event": event, "data": json.RawMessage(data)})
+		_, err = s.out().Write(append(b, '\n'))
+	} else {
+		err = sendRawSSE(s.out(), event, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.scheduleFlush()
+	return nil
+}
+
+// writeEventID escreve um evento SSE com id (streams com resume); event vazio = message.
+func (s *sseWriter) writeEventID(id, event string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.state.started {
+		s.state.markStarted()
+	}
+
+	if _, err := fmt.Fprintf(s.out(), "id: %s\n", id); err != nil {
+		return err
+	}
+	if event == "" {
+		event = "message"
+		s.lines++
+	}
+	if err := sendRawSSE(s.out(), event, data); err != nil {
+		return err
+	}
+
+	s.scheduleFlush()
 	return nil
 }
 
-func sendSSE(w http.ResponseWriter, event string, payload any) error {
+// writeEvent escreve um evento de controle (ex: error) no formato da resposta.
+// Eventos de controle sempre saem imediatamente (ignoram a janela de flush).
+func (s *sseWriter) writeEvent(event string, payload any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	if s.ndjson {
+		b, _ := json.Marshal(map[string]any{"event": event, "data": payload})
+		_, err = s.out().Write(append(b, '\n'))
+	} else {
+		err = sendSSE(s.out(), event, payload)
+	}
+	s.flushLocked()
+	return err
+}
+
+// flushLocked esvazia o buffer próprio e faz flush da resposta. Chamar com s.mu travado.
+func (s *sseWriter) flushLocked() {
+	if s.bw != nil {
+		_ = s.bw.Flush()
+	}
+	s.f.Flush()
+}
+
+// scheduleFlush aplica a política de flush. Chamar com s.mu travado.
+func (s *sseWriter) scheduleFlush() {
+	if s.flushEvery <= 0 {
+		s.flushLocked()
+		return
+	}
+	if s.timer != nil {
+		return
+	}
+	s.timer = time.AfterFunc(s.flushEvery, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.timer == nil {
+			return
+		}
+		s.timer = nil
+		s.flushLocked()
+	})
+}
+
+// Close cancela flush pendente e faz o flush final (idempotente).
+// Deve ser chamado antes do handler retornar (ResponseWriter não pode ser usado depois).
+func (s *sseWriter) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.state.started {
+		s.flushLocked()
+	}
+}
+
+func sendSSE(w io.Writer, event string, payload any) error {
 	data, _ := json.Marshal(payload)
 	return sendRawSSE(w, event, data)
 }
 
-func sendRawSSE(w http.ResponseWriter, event string, data []byte) error {
+func sendRawSSE(w io.Writer, event string, data []byte) error {
 	if _, err := fmt.Fprintf(w, "event: %s\n", event); err != nil {
 		return err
 	}
@@ -369,3 +1062,61 @@ func sendRawSSE(w http.ResponseWriter, event string, data []byte) error {
 	}
 	return nil
 }
+
+// openBodyStream prepara o body de uma tool stream_body: leitura concorrente com a
+// resposta (full duplex, sem read deadline) e limitada a max bytes (ErrBodyTooLarge).
+func openBodyStream(w http.ResponseWriter, r *http.Request, max int64) io.Reader {
+	rc := http.NewResponseController(w)
+	_ = rc.EnableFullDuplex() // HTTP/2 já é full duplex (ErrNotSupported)
+	_ = rc.SetReadDeadline(time.Time{})
+	return bodyLimitReader{r: http.MaxBytesReader(w, r.Body, max)}
+}
+
+// bodyLimitReader traduz o estouro do MaxBytesReader em core.ErrBodyTooLarge.
+type bodyLimitReader struct{ r io.Reader }
+
+func (b bodyLimitReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		err = fmt.Errorf("%w: limit is %d bytes", core.ErrBodyTooLarge, mbe.Limit)
+	}
+	return n, err
+}
+
+// openInputStream prepara o body NDJSON de uma tool interactive: lê a 1ª mensagem (o
+// input) e devolve o resto do body para o core repassar ao stdin. A conexão vira full
+// duplex (HTTP/1.1 lê o body enquanto responde) e o ReadTimeout do servidor deixa de
+// valer para esta request: a sessão dura até o timeout da tool.
+func openInputStream(w http.ResponseWriter, r *http.Request) ([]byte, io.Reader, error) {
+	rc := http.NewResponseController(w)
+	_ = rc.EnableFullDuplex() // HTTP/2 já é full duplex (ErrNotSupported)
+	_ = rc.SetReadDeadline(time.Time{})
+
+	br := bufio.NewReaderSize(r.Body, 64*1024)
+	var first []byte
+	for {
+		line, err := br.ReadSlice('\n')
+		first = append(first, line...)
+		if len(first) > maxRequestBodyBytes {
+			return nil, nil, fmt.Errorf("first message exceeds %d bytes", maxRequestBodyBytes)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return nil, nil, fmt.Errorf("invalid body")
+		}
+		first = bytes.TrimSpace(first)
+		if len(first) > 0 || err == io.EOF {
+			break
+		}
+	}
+	if len(first) == 0 {
+		first = []byte(`{}`)
+	}
+	if !json.Valid(first) {
+		return nil, nil, fmt.Errorf("first message must be valid JSON")
+	}
+	return first, br, nil
+}
@@ -1,8 +1,10 @@
 package transport_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -125,3 +127,240 @@ func TestInvalidToolName_Hardening(t *testing.T) {
 		t.Fatalf("expected 400 for invalid tool name, got %d", w.Code)
 	}
 }
+
+func TestToolStats(t *testing.T) {
+	h := newTestHandler(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp/echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/mcp/tools/echo/stats", nil))
+	var st core.ToolStats
+	if rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &st) != nil {
+		t.Fatalf("stats: %d %s", rr.Code, rr.Body.String())
+	}
+	if st.Tool != "echo" || st.Invocations != 1 || st.Total.Invocations != 1 || st.LastRunAt == nil {
+		t.Fatalf("stats after one call: %+v", st)
+	}
+
+	for path, want := range map[string]int{
+		"/mcp/tools/nope/stats": http.StatusNotFound,
+		"/mcp/tools/echo/other": http.StatusNotFound,
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, rr.Code)
+		}
+	}
+}
+
+func TestToolsCatalog_FilterAndFields(t *testing.T) {
+	cfg := &config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"echo": {Runtime: "native", Mode: "launcher", Cmd: "true", Description: "Echoes input", Version: "1.2.0",
+				Tags: []string{"demo", "text"}, InputSchema: map[string]any{"type": "object"}, TimeoutMS: 5000},
+			"kv": {Runtime: "builtin", Mode: "launcher", Builtin: "kv", Tags: []string{"state"}},
+		},
+	}
+	mux := http.NewServeMux()
+	transport.NewHTTP(core.New(cfg)).Register(mux)
+
+	get := func(url string) (int, []map[string]any) {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		var body struct {
+			Tools []map[string]any `json:"tools"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &body)
+		return rr.Code, body.Tools
+	}
+
+	// as 4 tools de diagnóstico (_echo, _env, _sleep, _version) vêm antes na ordem por nome
+	code, tools := get("/mcp/tools")
+	if code != http.StatusOK || len(tools) != 6 || tools[0]["name"] != "_echo" || tools[4]["name"] != "echo" ||
+		tools[4]["description"] != "Echoes input" || tools[4]["timeout_ms"] != float64(5000) || tools[4]["input_schema"] == nil {
+		t.Fatalf("catalog: %d %v", code, tools)
+	}
+
+	code, tools = get("/mcp/tools?filter=tag:demo&fields=name,version")
+	if code != http.StatusOK || len(tools) != 1 || len(tools[0]) != 2 || tools[0]["version"] != "1.2.0" {
+		t.Fatalf("filtered catalog: %d %v", code, tools)
+	}
+	if code, tools = get("/mcp/tools?filter=tag:demo&filter=runtime:builtin"); code != http.StatusOK || len(tools) != 0 {
+		t.Fatalf("filters must be ANDed: %d %v", code, tools)
+	}
+
+	for _, bad := range []string{"/mcp/tools?filter=color:red", "/mcp/tools?filter=tag", "/mcp/tools?fields=secret"} {
+		if code, _ := get(bad); code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", bad, code)
+		}
+	}
+}
+
+func TestToolAliases_RouteWithDeprecation(t *testing.T) {
+	cfg := &config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"greet":  {Runtime: "builtin", Mode: "launcher", Builtin: "echo", Aliases: []string{"hello"}},
+			"legacy": {Runtime: "builtin", Mode: "launcher", Builtin: "echo", Deprecated: true},
+		},
+	}
+	mux := http.NewServeMux()
+	transport.NewHTTP(core.New(cfg)).Register(mux)
+
+	call := func(tool string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp/"+tool, strings.NewReader(`{"msg":"hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := call("hello")
+	if rr.Code != http.StatusOK || rr.Header().Get("X-MCP-Tool") != "greet" || !strings.Contains(rr.Body.String(), `"msg":"hi"`) {
+		t.Fatalf("alias must route to the canonical tool: %d %v %s", rr.Code, rr.Header(), rr.Body.String())
+	}
+	if rr.Header().Get("Deprecation") != "true" || !strings.Contains(rr.Header().Get("X-MCP-Deprecation"), `use "greet"`) {
+		t.Fatalf("alias call must carry the deprecation warning: %v", rr.Header())
+	}
+	if rr = call("greet"); rr.Code != http.StatusOK || rr.Header().Get("Deprecation") != "" {
+		t.Fatalf("canonical name must not warn: %d %v", rr.Code, rr.Header())
+	}
+	if rr = call("legacy"); rr.Code != http.StatusOK || rr.Header().Get("Deprecation") != "true" {
+		t.Fatalf("deprecated tool must warn: %d %v", rr.Code, rr.Header())
+	}
+
+	clash := &config.Config{WorkspaceRoot: "/tmp", ToolsRoot: "/tmp", Tools: map[string]config.Tool{
+		"kv":    {Runtime: "builtin", Builtin: "kv"},
+		"store": {Runtime: "builtin", Builtin: "kv", Aliases: []string{"kv"}},
+	}}
+	if err := clash.Validate(); err == nil || !strings.Contains(err.Error(), "already a tool name") {
+		t.Fatalf("alias clashing with a tool name must be rejected, got %v", err)
+	}
+}
+
+func TestInputSchema_RejectsBeforeSpawn(t *testing.T) {
+	cfg := &config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			// cmd inexistente: se o input passar do schema, o spawn falha (502), não 422
+			"strict": {Runtime: "native", Mode: "launcher", Cmd: "/nonexistent/tool", InputSchema: map[string]any{
+				"type": "object", "required": []any{"query"},
+				"properties": map[string]any{"query": map[string]any{"type": "string"}},
+			}},
+		},
+	}
+	mux := http.NewServeMux()
+	transport.NewHTTP(core.New(cfg)).Register(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp/strict", strings.NewReader(`{"query":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	var body struct {
+		Code    string `json:"code"`
+		Details []struct {
+			Path    string `json:"path"`
+			Message string `json:"message"`
+		} `json:"details"`
+	}
+	if rr.Code != http.StatusUnprocessableEntity || json.Unmarshal(rr.Body.Bytes(), &body) != nil {
+		t.Fatalf("expected 422, got %d %s", rr.Code, rr.Body.String())
+	}
+	if body.Code != core.CodeSchemaViolation || len(body.Details) != 1 || body.Details[0].Path != "/query" {
+		t.Fatalf("violation details: %s", rr.Body.String())
+	}
+}
+
+func TestDryRun_SpawnPlan(t *testing.T) {
+	data := t.TempDir()
+	data, _ = filepath.EvalSymlinks(data)
+	cfg := &config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		MountRoots:    []string{data},
+		Tools: map[string]config.Tool{
+			"grep": {Runtime: "container", Mode: "launcher", Image: "alpine:3", Args: []string{"grep", "-r"},
+				WorkspaceAccess: config.WorkspaceAccessNone, SeccompProfile: config.SeccompProfileStrict,
+				Mounts: []config.Mount{{Host: data, Container: "/data"}}, TimeoutMS: 5000},
+			"kv": {Runtime: "builtin", Mode: "launcher", Builtin: "kv"},
+		},
+	}
+	svc := core.New(cfg)
+	mux := http.NewServeMux()
+	transport.NewHTTP(svc).Register(mux)
+
+	plan := func(tool string) (int, core.ToolPlan) {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/mcp/"+tool+"?dry_run=1", nil))
+		var p core.ToolPlan
+		_ = json.Unmarshal(rr.Body.Bytes(), &p)
+		return rr.Code, p
+	}
+
+	code, p := plan("grep")
+	if code != http.StatusOK || p.Spawn == nil || p.TimeoutMS != 5000 {
+		t.Fatalf("dry run: %d %+v", code, p)
+	}
+	sp := p.Spawn
+	args := strings.Join(sp.Args, " ")
+	if sp.Args[0] != "docker" || !strings.Contains(args, "--cap-drop=ALL") || !strings.HasSuffix(args, "alpine:3 grep -r") {
+		t.Fatalf("docker args: %s", args)
+	}
+	if sp.User != config.DefaultContainerUser || len(sp.Mounts) != 1 || sp.Mounts[0] != data+":/data:ro" ||
+		sp.Hardening.Network != "none" || !sp.Hardening.ReadOnly || sp.Hardening.SeccompProfile == "" {
+		t.Fatalf("plan details: %+v", sp)
+	}
+	for _, e := range sp.Env {
+		if strings.HasPrefix(e, "PATH=") {
+			t.Fatalf("env must list only gateway additions: %v", sp.Env)
+		}
+	}
+
+	if code, p := plan("kv"); code != http.StatusOK || p.Spawn != nil || p.Builtin != "kv" {
+		t.Fatalf("builtin dry run: %d %+v", code, p)
+	}
+	if code, _ := plan("nope"); code != http.StatusNotFound {
+		t.Fatalf("unknown tool: expected 404, got %d", code)
+	}
+	if st, _ := svc.ToolStats("grep"); st.Total.Invocations != 0 {
+		t.Fatalf("dry run must not execute: %+v", st)
+	}
+}
+
+func TestDiagnosticTools(t *testing.T) {
+	h := newTestHandler(t) // config só com "echo": as tools _* vêm do gateway
+
+	call := func(tool, body string) (int, string) {
+		req := httptest.NewRequest(http.MethodPost, "/mcp/"+tool, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code, rr.Body.String()
+	}
+
+	code, body := call("_echo", `{"msg":"hi","repeat":3,"interval_ms":5}`)
+	if code != http.StatusOK || strings.Count(body, "event: message\ndata: {\"msg\":\"hi\",\"repeat\":3,\"interval_ms\":5}\n") != 3 {
+		t.Fatalf("_echo: %d %q", code, body)
+	}
+	if code, body = call("_sleep", `{"ms":1}`); code != http.StatusOK || !strings.Contains(body, `"slept_ms":1`) {
+		t.Fatalf("_sleep: %d %q", code, body)
+	}
+	if code, body = call("_env", `{}`); code != http.StatusOK || !strings.Contains(body, `"tool":"_env"`) || !strings.Contains(body, `"deadline_ms":`) {
+		t.Fatalf("_env: %d %q", code, body)
+	}
+	if code, body = call("_version", `{}`); code != http.StatusOK || !strings.Contains(body, `"go_version":"go`) {
+		t.Fatalf("_version: %d %q", code, body)
+	}
+	if code, body = call("_echo", `{"repeat":-1}`); code != http.StatusBadGateway || !strings.Contains(body, "repeat must be") {
+		t.Fatalf("_echo with invalid repeat must fail: %d %q", code, body)
+	}
+}
@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func TestHTTP_InteractiveToolReceivesStreamedInput(t *testing.T) {
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+	chat := config.Tool{Runtime: "native", Mode: "launcher", Cmd: os.Args[0], Args: []string{"__mcp_tool_chat_helper__"}, TimeoutMS: 5000}
+	oneShot := chat
+	chat.Interactive = true
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"chat": chat, "oneshot": oneShot},
+	})
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	post := func(tool string, body io.Reader) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/"+tool, body)
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		return resp
+	}
+
+	if resp := post("oneshot", strings.NewReader("{}\n")); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("non-interactive tool: expected 400, got %d", resp.StatusCode)
+	}
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() { _, _ = io.WriteString(pw, `{"n":1}`+"\n") }()
+	resp := post("chat", pr)
+	defer resp.Body.Close()
+	br := bufio.NewReader(resp.Body)
+
+	// cada resposta chega antes da próxima mensagem ser enviada (stdin segue aberto)
+	expect := func(want string) {
+		t.Helper()
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				t.Fatalf("waiting for %s: %v", want, err)
+			}
+			if strings.HasPrefix(line, "data: ") {
+				if got := strings.TrimSpace(strings.TrimPrefix(line, "data: ")); got != want {
+					t.Fatalf("got %s, want %s", got, want)
+				}
+				return
+			}
+		}
+	}
+	expect(`{"echo":{"n":1}}`)
+	_, _ = io.WriteString(pw, `{"n":2}`+"\n")
+	expect(`{"echo":{"n":2}}`)
+
+	// fim do body = EOF no stdin
+	_ = pw.Close()
+	expect(`{"done":true}`)
+	rest, err := io.ReadAll(br)
+	if err != nil || strings.Contains(string(rest), "event: error") {
+		t.Fatalf("expected clean end of stream after stdin EOF, got %q (%v)", rest, err)
+	}
+}
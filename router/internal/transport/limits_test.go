@@ -0,0 +1,80 @@
+package transport
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+)
+
+func newFloodCore(t *testing.T, tool config.Tool) *core.Service {
+	t.Helper()
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+
+	tool.Runtime = "native"
+	tool.Mode = "launcher"
+	tool.Cmd = os.Args[0]
+	tool.Args = []string{"__mcp_tool_flood_helper__"}
+	tool.TimeoutMS = 5000
+
+	return core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"flood": tool},
+	})
+}
+
+func TestStdio_MaxOutputLinesTruncates(t *testing.T) {
+	svc := newFloodCore(t, config.Tool{MaxOutputLines: 3})
+
+	resps := runStdio(t, `{"id":"1","tool":"flood"}`+"\n", svc)
+	if len(resps) != 4 {
+		t.Fatalf("expected 3 messages + truncated, got %d: %+v", len(resps), resps)
+	}
+	last := resps[3]
+	if last.Event != "truncated" {
+		t.Fatalf("expected last event=truncated, got %q", last.Event)
+	}
+
+	var tr core.TruncatedError
+	if err := json.Unmarshal(last.Data, &tr); err != nil {
+		t.Fatalf("decode truncated: %v", err)
+	}
+	if tr.Limit != "max_output_lines" || tr.Lines != 3 || tr.Bytes == 0 {
+		t.Fatalf("unexpected totals: %+v", tr)
+	}
+}
+
+func TestHTTP_MaxOutputBytesTruncates(t *testing.T) {
+	svc := newFloodCore(t, config.Tool{MaxOutputBytes: 100})
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(mux))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/flood", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if got := strings.Count(string(body), "event: message"); got != 3 {
+		t.Fatalf("expected 3 messages under 100 bytes, got %d: %q", got, body)
+	}
+	if !strings.Contains(string(body), "event: truncated") || !strings.Contains(string(body), `"limit":"max_output_bytes"`) {
+		t.Fatalf("expected truncated event, got %q", body)
+	}
+	if strings.Contains(string(body), "event: error") {
+		t.Fatalf("truncation must not be reported as error: %q", body)
+	}
+}
@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Named pipe do Windows: --addr npipe://./pipe/<nome> serve o HTTP em \\.\pipe\<nome> em
+// vez de TCP. Clientes Windows locais (mcp-gw-shim-xport --endpoint npipe://...) falam
+// com o gateway sem porta aberta, firewall ou as esquisitices de rede do WSL. O pipe só
+// aceita o usuário que subiu o gateway (e SYSTEM; como serviço, os usuários interativos).
+
+// npipeScheme é o prefixo de --addr para named pipe.
+const npipeScheme = "npipe://"
+
+// errNpipeUnsupported: named pipes só existem no Windows.
+var errNpipeUnsupported = errors.New("npipe listener is only supported on Windows")
+
+// listen abre o listener de addr: named pipe (npipe://) ou TCP.
+func listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, npipeScheme) {
+		return net.Listen("tcp", addr)
+	}
+	path, err := pipePath(addr)
+	if err != nil {
+		return nil, err
+	}
+	return listenPipe(path)
+}
+
+// pipePath converte npipe://./pipe/<nome> em \\.\pipe\<nome>. O gateway só escuta em
+// pipe local (host ".").
+func pipePath(addr string) (string, error) {
+	rest := strings.TrimPrefix(addr, npipeScheme)
+	host, name, ok := strings.Cut(rest, "/pipe/")
+	if !ok || host != "." || name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid npipe address %q (expected npipe://./pipe/<name>)", addr)
+	}
+	return `\\.\pipe\` + name, nil
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package transport
+
+import "net"
+
+func listenPipe(path string) (net.Listener, error) {
+	_ = path
+	return nil, errNpipeUnsupported
+}
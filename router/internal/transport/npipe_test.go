@@ -0,0 +1,20 @@
+package transport
+
+import "testing"
+
+func TestNpipe_PipePath(t *testing.T) {
+	got, err := pipePath("npipe://./pipe/mcp-gw")
+	if err != nil || got != `\\.\pipe\mcp-gw` {
+		t.Fatalf("pipePath = %q, %v", got, err)
+	}
+	for _, bad := range []string{
+		"npipe://./pipe/",
+		"npipe://server/pipe/mcp-gw", // só pipe local
+		"npipe://./pipe/a/b",
+		"npipe://./mcp-gw",
+	} {
+		if _, err := pipePath(bad); err == nil {
+			t.Errorf("pipePath(%q): expected error", bad)
+		}
+	}
+}
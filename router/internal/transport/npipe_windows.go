@@ -0,0 +1,34 @@
+//go:build windows
+
+package transport
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+)
+
+// listenPipe cria o named pipe com DACL restrita ao usuário atual e ao SYSTEM. Rodando
+// como serviço (LocalSystem), libera também os usuários interativos, senão ninguém da
+// sessão de desktop conseguiria conectar.
+func listenPipe(path string) (net.Listener, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, fmt.Errorf("npipe: current user: %w", err)
+	}
+	sddl := fmt.Sprintf("D:P(A;;GA;;;%s)(A;;GA;;;SY)", user.User.Sid.String())
+	if user.User.Sid.IsWellKnown(windows.WinLocalSystemSid) {
+		sddl += "(A;;GRGW;;;IU)"
+	}
+	ln, err := winio.ListenPipe(path, &winio.PipeConfig{
+		SecurityDescriptor: sddl,
+		InputBufferSize:    64 << 10,
+		OutputBufferSize:   64 << 10,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("npipe: listen %s: %w", path, err)
+	}
+	return ln, nil
+}
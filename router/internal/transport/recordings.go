@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+
+	"mcp-router/internal/core"
+)
+
+// GET  /admin/recordings[?tool=<tool>]     lista as gravações
+// GET  /admin/recordings/<id>              gravação completa (input + saída)
+// POST /admin/recordings/<id>/replay       executa de novo e compara com a gravação
+func (h *HTTP) handleRecordings(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/recordings"), "/")
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+			return
+		}
+		recs, err := h.core.ListRecordings(r.Context(), r.URL.Query().Get("tool"))
+		if err != nil {
+			writeErrorFor(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"recordings": recs})
+		return
+	}
+
+	id, action, _ := strings.Cut(rest, "/")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		rec, err := h.core.GetRecording(r.Context(), id)
+		if err != nil {
+			writeErrorFor(w, r, errorStatus(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, rec)
+	case action == "replay" && r.Method == http.MethodPost:
+		res, err := h.core.Replay(r.Context(), id)
+		if err != nil {
+			writeErrorFor(w, r, errorStatus(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	case action == "" || action == "replay":
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+	default:
+		writeError(w, r, http.StatusNotFound, core.CodeNotFound, "not found")
+	}
+}
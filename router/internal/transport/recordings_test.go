@@ -0,0 +1,95 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/transport"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+	t.Setenv("MCP_GW_ADMIN_TOKEN", "admintok")
+	store := t.TempDir()
+	cfg := &config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"echo": {Runtime: "native", Mode: "launcher", Cmd: os.Args[0], Args: []string{"__mcp_tool_echo_helper__"}, TimeoutMS: 3000, Record: true},
+		},
+		Storage: config.Storage{Path: store},
+	}
+	mux := http.NewServeMux()
+	transport.NewHTTP(core.New(cfg)).Register(mux)
+	srv := httptest.NewServer(transport.WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	call := func(method, path, body string) (int, []byte) {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer admintok")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, b
+	}
+
+	if code, body := call(http.MethodPost, "/mcp/echo", `{"q":"hi"}`); code != http.StatusOK {
+		t.Fatalf("tool call: %d %s", code, body)
+	}
+
+	code, body := call(http.MethodGet, "/admin/recordings?tool=echo", "")
+	var list struct {
+		Recordings []core.RecordingInfo `json:"recordings"`
+	}
+	if code != http.StatusOK || json.Unmarshal(body, &list) != nil || len(list.Recordings) != 1 {
+		t.Fatalf("list: %d %s", code, body)
+	}
+	id := list.Recordings[0].ID
+
+	code, body = call(http.MethodGet, "/admin/recordings/"+id, "")
+	var rec core.Recording
+	if code != http.StatusOK || json.Unmarshal(body, &rec) != nil || string(rec.Input) != `{"q":"hi"}` ||
+		len(rec.Output) != 1 || !strings.Contains(rec.Output[0].Data, `"q":"hi"`) {
+		t.Fatalf("recording: %d %s", code, body)
+	}
+
+	code, body = call(http.MethodPost, "/admin/recordings/"+id+"/replay", "")
+	var res core.ReplayResult
+	if code != http.StatusOK || json.Unmarshal(body, &res) != nil || !res.Match || res.ReplayedEvents != 1 {
+		t.Fatalf("replay: %d %s", code, body)
+	}
+
+	// gravação adulterada: o replay aponta o primeiro evento diferente
+	rec.Output[0].Data = `{"tool":"echo","result":"old"}`
+	b, _ := json.Marshal(rec)
+	fake := strings.Repeat("ab", 16)
+	if err := os.WriteFile(filepath.Join(store, "recordings", "echo", fake+".json"), b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	code, body = call(http.MethodPost, "/admin/recordings/"+fake+"/replay", "")
+	res = core.ReplayResult{}
+	if code != http.StatusOK || json.Unmarshal(body, &res) != nil || res.Match || res.FirstDiff == nil || res.FirstDiff.Index != 0 {
+		t.Fatalf("mismatch replay: %d %s", code, body)
+	}
+
+	// o replay não gera gravações novas
+	if _, body = call(http.MethodGet, "/admin/recordings", ""); strings.Count(string(body), `"id"`) != 2 {
+		t.Fatalf("replays must not be recorded: %s", body)
+	}
+	if code, _ = call(http.MethodGet, "/admin/recordings/"+strings.Repeat("0", 32), ""); code != http.StatusNotFound {
+		t.Fatalf("unknown recording: expected 404, got %d", code)
+	}
+}
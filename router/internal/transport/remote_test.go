@@ -0,0 +1,74 @@
+package transport_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/transport"
+)
+
+func TestRemoteRuntime_ProxiesUpstreamSSEWithRetryAndAuth(t *testing.T) {
+	t.Setenv("UPSTREAM_TOKEN", "s3cr3t")
+
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "warming up", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: message\ndata: {\"n\":1}\n\n")
+		_, _ = io.WriteString(w, "event: message\ndata: "+strings.TrimSpace(string(body))+"\n\n")
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"up": {
+				Runtime:  "remote",
+				Endpoint: upstream.URL + "/mcp/echo",
+				Headers:  map[string]string{"Authorization": "Bearer ${UPSTREAM_TOKEN}"},
+				Retries:  2,
+			},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	transport.NewHTTP(core.New(cfg)).Register(mux)
+	srv := httptest.NewServer(transport.WrapHardening(mux))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/mcp/up", "application/json", strings.NewReader(`{"hello":"remote"}`))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, out)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 1 retry (2 calls), got %d", got)
+	}
+	want := "event: message\ndata: {\"n\":1}\n\nevent: message\ndata: {\"hello\":\"remote\"}\n\n"
+	if string(out) != want {
+		t.Fatalf("unexpected stream:\n%s", out)
+	}
+}
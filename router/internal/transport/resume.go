@@ -0,0 +1,309 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+	"mcp-router/internal/observability/metrics"
+)
+
+// Resume de streams SSE (stream.resume_window_ms > 0): a execução roda desacoplada da
+// request e cada evento sai com "id: <token>:<seq>" e fica num buffer de replay. Se a
+// conexão cair, o cliente volta com GET /mcp/streams/<token> + Last-Event-ID e recebe o
+// que perdeu, seguido do resto ao vivo. Sem nenhum cliente lendo por mais que a janela,
+// a execução é cancelada (como numa desconexão sem resume); terminada, ela fica
+// disponível pela mesma janela para quem caiu perto do fim.
+//
+// Só streams SSE com input fixo são retomáveis: NDJSON não tem ids e input em stream
+// (application/x-ndjson) morre com o body da request. O buffer substitui a política de
+// cliente lento: quem fica mais de resumeBufferEvents para trás recebe event: dropped.
+
+// resumeHeader traz o token do stream na resposta (só com resume ligado).
+const resumeHeader = "X-MCP-Resume"
+
+// resumeBufferEvents é quantos eventos cada stream guarda para replay.
+const resumeBufferEvents = 4096
+
+var (
+	errClientGone    = errors.New("client disconnected")
+	errResumeExpired = errors.New("no client reconnected within stream.resume_window_ms")
+)
+
+var streamResumes = metrics.Default.Counter("mcp_gw_stream_resumes_total",
+	"SSE streams resumed by a reconnecting client (Last-Event-ID)")
+
+type resumeRegistry struct {
+	mu      sync.Mutex
+	streams map[string]*resumeStream
+}
+
+func newResumeRegistry() *resumeRegistry {
+	return &resumeRegistry{streams: make(map[string]*resumeStream)}
+}
+
+// start registra um stream novo; cancel encerra a execução quando a janela expira.
+func (g *resumeRegistry) start(rid, tool, client string, window time.Duration, cancel context.CancelCauseFunc) *resumeStream {
+	st := &resumeStream{
+		token:  uuid.NewString(),
+		rid:    rid,
+		tool:   tool,
+		client: client,
+		window: window,
+		cancel: cancel,
+		next:   1,
+		wake:   make(chan struct{}),
+	}
+	st.forget = func() {
+		g.mu.Lock()
+		delete(g.streams, st.token)
+		g.mu.Unlock()
+	}
+	g.mu.Lock()
+	g.streams[st.token] = st
+	g.mu.Unlock()
+	return st
+}
+
+func (g *resumeRegistry) get(token string) *resumeStream {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.streams[token]
+}
+
+// resumeStream é o buffer de replay de uma execução. Implementa core.LineWriter e
+// core.EventWriter: a tool escreve aqui sem nunca bloquear no cliente.
+type resumeStream struct {
+	token  string
+	rid    string // request id original (eventos cancelled/error)
+	tool   string
+	client string // IP de quem abriu: só ele (ou admin) retoma
+	window time.Duration
+	cancel context.CancelCauseFunc
+	forget func()
+
+	mu      sync.Mutex
+	events  []resumeEvent // últimos resumeBufferEvents, seq contínuos
+	next    int64         // seq do próximo evento (começa em 1)
+	wake    chan struct{} // fechado (e trocado) a cada evento e no fim
+	done    bool
+	err     error // resultado da execução (com done)
+	readers int
+	timer   *time.Timer // janela sem leitores (ou de retenção depois do fim)
+}
+
+type resumeEvent struct {
+	seq   int64
+	event string // vazio = message
+	data  []byte
+}
+
+func (s *resumeStream) WriteLine(line []byte) error {
+	s.append("", line)
+	return nil
+}
+
+func (s *resumeStream) WriteEvent(event string, data []byte) error {
+	s.append(event, data)
+	return nil
+}
+
+func (s *resumeStream) append(event string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, resumeEvent{seq: s.next, event: event, data: bytes.Clone(data)})
+	s.next++
+	if len(s.events) > resumeBufferEvents {
+		s.events = s.events[len(s.events)-resumeBufferEvents:]
+	}
+	s.notifyLocked()
+}
+
+func (s *resumeStream) notifyLocked() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// finish registra o fim da execução; o stream fica disponível por mais uma janela.
+func (s *resumeStream) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done, s.err = true, err
+	s.notifyLocked()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(s.window, s.forget)
+}
+
+func (s *resumeStream) attach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readers++
+	if !s.done && s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+func (s *resumeStream) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readers--
+	if s.readers > 0 || s.done {
+		return
+	}
+	s.timer = time.AfterFunc(s.window, func() {
+		s.mu.Lock()
+		expired := s.readers == 0 && !s.done
+		s.mu.Unlock()
+		if expired {
+			s.cancel(errResumeExpired)
+		}
+	})
+}
+
+func (s *resumeStream) eventID(seq int64) string {
+	return s.token + ":" + strconv.FormatInt(seq, 10)
+}
+
+// follow envia ao cliente os eventos depois de after e segue ao vivo até o fim da
+// execução (retorna o erro dela) ou até o cliente cair (errClientGone).
+func (s *resumeStream) follow(ctx context.Context, sse *sseWriter, after int64) error {
+	s.attach()
+	defer s.detach()
+
+	for {
+		s.mu.Lock()
+		var lost int64
+		var pending []resumeEvent
+		if len(s.events) > 0 {
+			if first := s.events[0].seq; after+1 < first {
+				lost, after = first-after-1, first-1
+			}
+			if i := after + 1 - s.events[0].seq; i < int64(len(s.events)) {
+				pending = s.events[i:]
+			}
+		}
+		done, err, wake := s.done, s.err, s.wake
+		s.mu.Unlock()
+
+		if lost > 0 {
+			streamDroppedLines.Add(lost)
+			if sse.writeEvent("dropped", map[string]int64{"dropped": lost}) != nil {
+				return errClientGone
+			}
+		}
+		// eventos já gravados não mudam: dá para escrever fora do lock
+		for _, ev := range pending {
+			if sse.writeEventID(s.eventID(ev.seq), ev.event, ev.data) != nil {
+				return errClientGone
+			}
+			after = ev.seq
+		}
+		if done {
+			return err
+		}
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return errClientGone
+		}
+	}
+}
+
+// parseLastEventID extrai o seq de Last-Event-ID ("<token>:<seq>" ou só "<seq>");
+// vazio = desde o início do buffer.
+func parseLastEventID(v, token string) (int64, bool) {
+	if v == "" {
+		return 0, true
+	}
+	if t, seq, ok := strings.Cut(v, ":"); ok {
+		if t != token {
+			return 0, false
+		}
+		v = seq
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	return n, err == nil && n >= 0
+}
+
+// streamResumable executa a tool desacoplada da request (resume ligado) e segue o
+// stream até o fim ou até o cliente cair.
+func (h *HTTP) streamResumable(ctx context.Context, w http.ResponseWriter, r *http.Request, toolName string, body []byte, sse *sseWriter, window time.Duration) error {
+	execCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	st := h.resumes.start(logging.RequestIDFromContext(ctx), toolName, logging.ClientIP(r), window, cancel)
+	w.Header().Set(resumeHeader, st.token)
+
+	go func() {
+		err := h.core.StreamToolInput(execCtx, toolName, body, nil, st)
+		cancel(nil)
+		st.finish(err)
+	}()
+	return st.follow(r.Context(), sse, 0)
+}
+
+// handleResume atende GET /mcp/streams/<token>: replay a partir de Last-Event-ID e o
+// resto do stream ao vivo. Só o cliente que abriu o stream (ou admin) pode retomá-lo.
+func (h *HTTP) handleResume(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	token := strings.Trim(strings.TrimPrefix(r.URL.Path, "/mcp/streams/"), "/")
+	st := h.resumes.get(token)
+	if st == nil || (st.client != logging.ClientIP(r) && !h.isAdmin(r)) {
+		writeError(w, r, http.StatusNotFound, core.CodeNotFound, "stream not found")
+		return
+	}
+	after, ok := parseLastEventID(r.Header.Get("Last-Event-ID"), token)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid Last-Event-ID")
+		return
+	}
+	logging.SetAccessTool(r.Context(), st.tool)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, core.CodeInternal, "streaming unsupported")
+		return
+	}
+
+	logger := logging.LoggerFromContext(r.Context()).With(
+		logging.Tool(st.tool),
+		logging.String("stream", token),
+		logging.String("stream_request_id", st.rid),
+		logging.Int64("last_event_id", after),
+	)
+	ctx := logging.WithLogger(logging.WithRequestID(r.Context(), st.rid), logger)
+	logger.Info("stream resumed")
+	streamResumes.Inc()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("X-MCP-Tool", st.tool)
+	w.Header().Set(resumeHeader, token)
+
+	sse := &sseWriter{w: w, f: flusher, state: &streamState{}}
+	err := st.follow(ctx, sse, after)
+	sse.Close()
+	sse.mu.Lock()
+	logging.AddAccessLines(ctx, sse.lines)
+	sse.mu.Unlock()
+	finishStream(ctx, w, r, sse, err, start)
+}
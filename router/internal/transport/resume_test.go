@@ -0,0 +1,92 @@
+package transport_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/transport"
+)
+
+func TestStreamResume_LastEventID(t *testing.T) {
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Stream:        config.Stream{ResumeWindowMS: 5000},
+		Tools: map[string]config.Tool{
+			"slow": {
+				Runtime:   "native",
+				Mode:      "launcher",
+				Cmd:       "/bin/sh",
+				Args:      []string{"-c", `echo '{"n":1}'; sleep 1; echo '{"n":2}'; echo '{"n":3}'`},
+				TimeoutMS: 5000,
+			},
+		},
+	})
+	mux := http.NewServeMux()
+	transport.NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// 1ª conexão: lê o primeiro evento e cai
+	ctx, drop := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/mcp/slow", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	token := resp.Header.Get("X-MCP-Resume")
+	if token == "" {
+		t.Fatal("expected X-MCP-Resume header")
+	}
+	var lastID string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if id, ok := strings.CutPrefix(sc.Text(), "id: "); ok {
+			lastID = id
+			break
+		}
+	}
+	if lastID != token+":1" {
+		t.Fatalf("first event id = %q, want %q", lastID, token+":1")
+	}
+	drop()
+	_ = resp.Body.Close()
+
+	// reconexão: só o que faltou, até o fim
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/mcp/streams/"+token, nil)
+	req.Header.Set("Last-Event-ID", lastID)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("resume status = %d body=%s", resp.StatusCode, body)
+	}
+	got := string(body)
+	if strings.Contains(got, `"n":1`) || !strings.Contains(got, `"n":2`) || !strings.Contains(got, `"n":3`) {
+		t.Fatalf("unexpected replay:\n%s", got)
+	}
+	if !strings.Contains(got, "id: "+token+":3") {
+		t.Fatalf("expected event ids in replay:\n%s", got)
+	}
+
+	// token desconhecido
+	resp, err = http.Get(srv.URL + "/mcp/streams/nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown stream status = %d, want 404", resp.StatusCode)
+	}
+}
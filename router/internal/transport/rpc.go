@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+// Endpoint MCP agregado (JSON-RPC 2.0), servido em POST /mcp e em linhas JSON-RPC no stdio.
+//
+// - initialize / ping respondidos localmente
+// - tools/list agrega os filhos federados ("<namespace>.<tool>")
+// - tools/call roteia para o filho do namespace
+
+// Códigos JSON-RPC padrão.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *core.RPCError  `json:"error,omitempty"`
+}
+
+// isNotification: requests sem id não têm resposta.
+func (r *rpcRequest) isNotification() bool { return len(r.ID) == 0 }
+
+func rpcErr(id json.RawMessage, code int, msg string) *rpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: &core.RPCError{Code: code, Message: msg}}
+}
+
+// rpcErrFor é o rpcErr com o modelo de erro do gateway (core.APIError) em error.data.
+func rpcErrFor(ctx context.Context, id json.RawMessage, code int, err error) *rpcResponse {
+	resp := rpcErr(id, code, err.Error())
+	resp.Error.Data, _ = json.Marshal(core.ErrorFor(err, logging.RequestIDFromContext(ctx)))
+	return resp
+}
+
+// handleRPC processa uma request JSON-RPC. Retorna nil para notificações.
+func handleRPC(ctx context.Context, svc *core.Service, req *rpcRequest) *rpcResponse {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcErr(req.ID, rpcInvalidRequest, "invalid request")
+	}
+
+	var (
+		result any
+		err    error
+	)
+
+	switch req.Method {
+	case "initialize":
+		result = map[string]any{
+			"protocolVersion": core.MCPProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "mcp-gw"},
+		}
+	case "ping":
+		result = map[string]any{}
+	case "tools/list":
+		var tools []core.FederatedTool
+		tools, err = svc.FederatedListTools(ctx)
+		if tools == nil {
+			tools = []core.FederatedTool{}
+		}
+		result = map[string]any{"tools": tools}
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if uerr := json.Unmarshal(req.Params, &p); uerr != nil || p.Name == "" {
+			return rpcErr(req.ID, rpcInvalidParams, "tools/call requires params.name")
+		}
+		var raw json.RawMessage
+		raw, err = svc.FederatedCallTool(ctx, p.Name, p.Arguments)
+		result = raw
+	default:
+		if req.isNotification() {
+			return nil // notifications/* desconhecidas são ignoradas
+		}
+		return rpcErr(req.ID, rpcMethodNotFound, "method not found: "+req.Method)
+	}
+
+	if req.isNotification() {
+		return nil
+	}
+
+	if err != nil {
+		logging.LoggerFromContext(ctx).Warn("rpc method failed",
+			logging.String("method", req.Method),
+			logging.Err(err),
+		)
+		var rerr *core.RPCError
+		switch {
+		case errors.As(err, &rerr):
+			return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Error: rerr}
+		case errors.Is(err, core.ErrUnknownFederatedTool):
+			return rpcErrFor(ctx, req.ID, rpcInvalidParams, err)
+		default:
+			return rpcErrFor(ctx, req.ID, rpcInternalError, err)
+		}
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+)
+
+func newFederationCore(t *testing.T) *core.Service {
+	t.Helper()
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+
+	cfg := &config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"git-server": {
+				Runtime:   "native",
+				Cmd:       os.Args[0],
+				Args:      []string{"__mcp_tool_mcpserver_helper__"},
+				TimeoutMS: 3000,
+				Federate:  true,
+				Namespace: "git",
+			},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	return core.New(cfg)
+}
+
+func postRPC(t *testing.T, h http.Handler, body string) (int, rpcResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var resp rpcResponse
+	if w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid rpc response %q: %v", w.Body.String(), err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestFederation_ListAndCallOverHTTP(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHTTP(newFederationCore(t)).Register(mux)
+	h := WrapHardening(mux)
+
+	code, resp := postRPC(t, h, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	if code != http.StatusOK || resp.Error != nil {
+		t.Fatalf("tools/list failed: code=%d err=%v", code, resp.Error)
+	}
+	b, _ := json.Marshal(resp.Result)
+	if !strings.Contains(string(b), `"name":"git.status"`) {
+		t.Fatalf("expected prefixed tool git.status, got %s", b)
+	}
+
+	_, resp = postRPC(t, h, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"git.status","arguments":{}}}`)
+	b, _ = json.Marshal(resp.Result)
+	if resp.Error != nil || !strings.Contains(string(b), "called status") {
+		t.Fatalf("tools/call not routed to child: result=%s err=%v", b, resp.Error)
+	}
+
+	_, resp = postRPC(t, h, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"nope.status"}}`)
+	if resp.Error == nil || resp.Error.Code != rpcInvalidParams {
+		t.Fatalf("expected invalid params for unknown namespace, got %+v", resp.Error)
+	}
+
+	code, _ = postRPC(t, h, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202 for notification, got %d", code)
+	}
+}
+
+func TestFederation_StdioJSONRPCLines(t *testing.T) {
+	svc := newFederationCore(t)
+
+	resps := runStdioRaw(t, `{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"git.status"}}`+"\n", svc)
+	if len(resps) != 1 {
+		t.Fatalf("expected 1 json-rpc response, got %d: %v", len(resps), resps)
+	}
+	if !strings.Contains(resps[0], `"id":"a"`) || !strings.Contains(resps[0], "called status") {
+		t.Fatalf("unexpected response %s", resps[0])
+	}
+}
@@ -0,0 +1,68 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+// sessionHeader endereça a sessão de uma tool daemon (mesmo processo entre requests).
+const sessionHeader = "Mcp-Session-Id"
+
+// POST /mcp/sessions {"tool":"repl"} -> 201 {"session_id":...} + header Mcp-Session-Id
+// DELETE /mcp/sessions/<id> (ou DELETE /mcp/sessions com o header) -> 204
+func (h *HTTP) handleSessions(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/mcp/sessions"), "/")
+
+	switch r.Method {
+	case http.MethodPost:
+		if id != "" {
+			writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.openSession(w, r)
+	case http.MethodDelete:
+		if id == "" {
+			id = r.Header.Get(sessionHeader)
+		}
+		if id == "" || strings.Contains(id, "/") || !h.core.CloseSession(id) {
+			writeError(w, r, http.StatusNotFound, core.CodeSessionNotFound, "session not found")
+			return
+		}
+		logging.LoggerFromContext(r.Context()).Info("tool session closed by client", logging.String("session_id", id))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *HTTP) openSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Tool string `json:"tool"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Tool == "" {
+		writeError(w, r, http.StatusBadRequest, core.CodeInvalidRequest, "invalid body")
+		return
+	}
+
+	info, err := h.core.OpenSession(req.Tool)
+	if err != nil {
+		writeErrorFor(w, r, errorStatus(err), err)
+		return
+	}
+	w.Header().Set(sessionHeader, info.ID)
+	writeJSON(w, http.StatusCreated, info)
+}
+
+// GET /admin/sessions
+func (h *HTTP) handleAdminSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sessions": h.core.Sessions()})
+}
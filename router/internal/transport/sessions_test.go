@@ -0,0 +1,97 @@
+package transport
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func TestHTTP_DaemonSessionsKeepProcessState(t *testing.T) {
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"counter": {Runtime: "native", Mode: "daemon", Cmd: os.Args[0], Args: []string{"__mcp_tool_counter_helper__"}, TimeoutMS: 3000},
+		},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	open := func() string {
+		resp, err := http.Post(srv.URL+"/mcp/sessions", "application/json", strings.NewReader(`{"tool":"counter"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated || resp.Header.Get(sessionHeader) == "" {
+			t.Fatalf("open session: status %d, header %q", resp.StatusCode, resp.Header.Get(sessionHeader))
+		}
+		return resp.Header.Get(sessionHeader)
+	}
+	call := func(sid string) (int, string) {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/counter", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		if sid != "" {
+			req.Header.Set(sessionHeader, sid)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+	count := func(sid string) (n, pid int) {
+		t.Helper()
+		code, body := call(sid)
+		for _, line := range strings.Split(body, "\n") {
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				var v struct{ Count, PID int }
+				if err := json.Unmarshal([]byte(data), &v); err == nil && v.Count > 0 {
+					return v.Count, v.PID
+				}
+			}
+		}
+		t.Fatalf("no count in response (status %d): %q", code, body)
+		return 0, 0
+	}
+
+	if code, _ := call(""); code != http.StatusBadRequest {
+		t.Fatalf("daemon tool without session: expected 400, got %d", code)
+	}
+
+	a, b := open(), open()
+	n1, pidA := count(a)
+	n2, pidA2 := count(a)
+	nb, pidB := count(b)
+	if n1 != 1 || n2 != 2 || pidA != pidA2 {
+		t.Fatalf("session a: counts %d,%d pids %d,%d; want 1,2 on the same process", n1, n2, pidA, pidA2)
+	}
+	if nb != 1 || pidB == pidA {
+		t.Fatalf("session b: count %d pid %d; want its own process starting at 1", nb, pidB)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/mcp/sessions/"+a, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete session: %v %v", resp, err)
+	}
+	resp.Body.Close()
+	if code, _ := call(a); code != http.StatusNotFound {
+		t.Fatalf("closed session: expected 404, got %d", code)
+	}
+}
@@ -4,79 +4,140 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"mcp-router/internal/core"
+	"mcp-router/internal/flags"
 )
 
 // Protocolo de entrada (1 JSON por linha):
 // {"id":"1","tool":"echo","input":{"hello":"world"}}
+// {"id":"2","tool":"echo","input":{},"flags":"coalesce_flush"}   (flags opcionais, como X-MCP-Flags)
+// {"cancel":"1"}                                                  (cancela o request "1", em andamento ou na fila)
 //
 // Saídas (JSON lines):
 // {"id":"1","event":"message","data":<linha json do stdout da tool>}
 // {"id":"1","event":"done","data":{"ok":true}}
-// {"id":"1","event":"error","data":{"error":"...", "detail":"..."}}
+// {"id":"1","event":"error","data":{"code":"tool_busy","message":"...","retryable":true,"request_id":"1"}}
+// {"id":"1","event":"cancelled","data":{"ok":false}}
+// {"id":"1","event":"truncated","data":{"limit":"max_output_lines","max":100,"bytes":...,"lines":100}}
+// {"id":"1","event":"continuation","data":{"part":0,"more":true,"data":"<pedaço de linha gigante>"}}
+// {"id":"1","event":"line_truncated","data":{"line_bytes":...,"head":"<início da linha>"}}
+//
+// As requests rodam em ordem (uma por vez); a leitura do stdin continua em paralelo
+// para que {"cancel":...} chegue enquanto uma tool está rodando.
+//
+// Linhas JSON-RPC 2.0 ({"jsonrpc":"2.0",...}) são tratadas como MCP agregado
+// (tools federadas) e respondidas em JSON-RPC puro (ver rpc.go).
+
+// stdioQueueSize limita quantas requests podem ficar enfileiradas atrás da que está rodando.
+const stdioQueueSize = 256
 
 type Stdio struct {
-	core *core.Service
+	core atomic.Pointer[core.Service] // Reload troca para as próximas mensagens
 	in   io.Reader
 	out  io.Writer
 	mu   sync.Mutex
+
+	pendMu  sync.Mutex
+	pending map[string]*stdioPending
+}
+
+// stdioPending é uma request enfileirada/em andamento, cancelável por id.
+type stdioPending struct {
+	cancel context.CancelCauseFunc
 }
 
 type StdioRequest struct {
-	ID    string          `json:"id,omitempty"`
-	Tool  string          `json:"tool"`
-	Input json.RawMessage `json:"input,omitempty"`
+	ID     string          `json:"id,omitempty"`
+	Tool   string          `json:"tool"`
+	Input  json.RawMessage `json:"input,omitempty"`
+	Flags  string          `json:"flags,omitempty"`
+	Cancel string          `json:"cancel,omitempty"`
 }
 
 func NewStdio(svc *core.Service) *Stdio {
-	return &Stdio{
-		core: svc,
-		in:   os.Stdin,
-		out:  os.Stdout,
+	t := &Stdio{
+		in:      os.Stdin,
+		out:     os.Stdout,
+		pending: make(map[string]*stdioPending),
 	}
+	t.core.Store(svc)
+	return t
+}
+
+// Reload passa a atender as próximas mensagens com svc (config novo); requests em
+// andamento terminam no serviço antigo.
+func (t *Stdio) Reload(svc *core.Service) {
+	t.core.Store(svc)
 }
 
 func (t *Stdio) Run(ctx context.Context) error {
 	sc := bufio.NewScanner(t.in)
 	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
 
+	jobs := make(chan func(), stdioQueueSize)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for job := range jobs {
+			job()
+		}
+	}()
+	// EOF no stdin: termina o que já foi enfileirado antes de sair
+	defer wg.Wait()
+	defer close(jobs)
+
 	for sc.Scan() {
 		line := bytesTrimSpace(sc.Bytes())
 		if len(line) == 0 {
 			continue
 		}
 
+		var probe struct {
+			JSONRPC string `json:"jsonrpc"`
+		}
+		if json.Unmarshal(line, &probe) == nil && probe.JSONRPC != "" {
+			line = append([]byte(nil), line...)
+			jobs <- func() { t.serveRPC(ctx, line) }
+			continue
+		}
+
+		// erros de validação também passam pela fila para manter a ordem das respostas
 		var req StdioRequest
 		if err := json.Unmarshal(line, &req); err != nil {
-			_ = t.emit(req.ID, "error", map[string]any{
-				"error":  "invalid_json",
-				"detail": err.Error(),
-			})
+			jobs <- func() {
+				_ = t.emit(req.ID, "error", core.NewAPIError(core.CodeInvalidRequest, "invalid json: "+err.Error(), req.ID))
+			}
+			continue
+		}
+		if req.Cancel != "" {
+			t.cancel(req.Cancel)
 			continue
 		}
 		if req.Tool == "" {
-			_ = t.emit(req.ID, "error", map[string]any{"error": "missing_tool"})
+			jobs <- func() {
+				_ = t.emit(req.ID, "error", core.NewAPIError(core.CodeInvalidRequest, "missing tool", req.ID))
+			}
 			continue
 		}
 		if len(req.Input) == 0 {
 			req.Input = json.RawMessage(`{}`)
 		}
 
-		w := &stdioWriter{id: req.ID, emitRaw: t.emitRaw}
-
-		if err := t.core.StreamTool(ctx, req.Tool, req.Input, w); err != nil {
-			_ = t.emit(req.ID, "error", map[string]any{
-				"error":  "tool_failed",
-				"detail": err.Error(),
-			})
-			continue
+		jctx, cancel := context.WithCancelCause(ctx)
+		pend := t.track(req.ID, cancel)
+		jobs <- func() {
+			defer cancel(nil)
+			defer t.untrack(req.ID, pend)
+			t.runTool(jctx, req)
 		}
-		_ = t.emit(req.ID, "done", map[string]any{"ok": true})
 	}
 
 	if err := sc.Err(); err != nil {
@@ -85,6 +146,91 @@ func (t *Stdio) Run(ctx context.Context) error {
 	return nil
 }
 
+func (t *Stdio) runTool(ctx context.Context, req StdioRequest) {
+	// cancelado enquanto esperava na fila
+	if errors.Is(context.Cause(ctx), core.ErrCancelled) {
+		_ = t.emit(req.ID, "cancelled", map[string]any{"ok": false})
+		return
+	}
+
+	w := &stdioWriter{id: req.ID, emitRaw: t.emitRaw}
+
+	// alias ou tool deprecated: event deprecation antes da saída
+	tool, dep := t.core.Load().ResolveTool(ctx, req.Tool)
+	if dep != nil {
+		_ = t.emit(req.ID, "deprecation", dep)
+	}
+	req.Tool = tool
+
+	fs, _ := t.core.Load().ResolveFlags(req.Flags)
+	rctx := flags.WithContext(ctx, fs)
+
+	err := t.core.Load().StreamTool(rctx, req.Tool, req.Input, w)
+	var trunc *core.TruncatedError
+	switch {
+	case errors.Is(err, core.ErrCancelled):
+		_ = t.emit(req.ID, "cancelled", map[string]any{"ok": false})
+	case errors.As(err, &trunc):
+		_ = t.emit(req.ID, "truncated", trunc)
+	case err != nil:
+		_ = t.emit(req.ID, "error", core.ErrorFor(err, req.ID))
+	default:
+		_ = t.emit(req.ID, "done", map[string]any{"ok": true})
+	}
+}
+
+// track registra a request como cancelável (requests sem id não podem ser canceladas).
+func (t *Stdio) track(id string, cancel context.CancelCauseFunc) *stdioPending {
+	p := &stdioPending{cancel: cancel}
+	if id == "" {
+		return p
+	}
+	t.pendMu.Lock()
+	t.pending[id] = p
+	t.pendMu.Unlock()
+	return p
+}
+
+func (t *Stdio) untrack(id string, p *stdioPending) {
+	t.pendMu.Lock()
+	// ids repetidos: só remove se ainda for a mesma entrada
+	if t.pending[id] == p {
+		delete(t.pending, id)
+	}
+	t.pendMu.Unlock()
+}
+
+// cancel cancela a request pelo id; o evento "cancelled" é emitido pelo próprio job.
+func (t *Stdio) cancel(id string) {
+	t.pendMu.Lock()
+	p, ok := t.pending[id]
+	t.pendMu.Unlock()
+
+	if !ok {
+		_ = t.emit(id, "error", core.NewAPIError(core.CodeNotFound, "unknown request", id))
+		return
+	}
+	p.cancel(core.ErrCancelled)
+}
+
+func (t *Stdio) serveRPC(ctx context.Context, line []byte) {
+	var req rpcRequest
+	resp := rpcErr(nil, rpcParseError, "parse error")
+	if err := json.Unmarshal(line, &req); err == nil {
+		resp = handleRPC(ctx, t.core.Load(), &req)
+	}
+	if resp == nil {
+		return
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = t.out.Write(append(b, '\n'))
+}
+
 // stdioWriter implementa core.LineWriter: cada linha de stdout vira um evento "message".
 type stdioWriter struct {
 	id      string
@@ -96,6 +242,11 @@ func (w *stdioWriter) WriteLine(line []byte) error {
 	return w.emitRaw(w.id, "message", json.RawMessage(append([]byte(nil), line...)))
 }
 
+// WriteEvent entrega um evento de output (data, continuation, line_truncated).
+func (w *stdioWriter) WriteEvent(event string, data []byte) error {
+	return w.emitRaw(w.id, event, json.RawMessage(append([]byte(nil), data...)))
+}
+
 func (t *Stdio) emit(id, event string, payload any) error {
 	b, _ := json.Marshal(payload)
 	return t.emitRaw(id, event, json.RawMessage(b))
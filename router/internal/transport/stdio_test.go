@@ -8,12 +8,14 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"testing"
 	"time"
 
 	"mcp-router/internal/config"
 	"mcp-router/internal/core"
+	"mcp-router/internal/runner"
 )
 
 // ----------------------------------------------------------------------
@@ -60,6 +62,96 @@ func toolHelperMain() {
 		fmt.Println(string(out))
 		os.Exit(0)
 
+	case "__mcp_tool_mcpserver_helper__":
+		// Servidor MCP mínimo (JSON-RPC por linha) para testes de federação.
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			var req struct {
+				ID     json.RawMessage `json:"id"`
+				Method string          `json:"method"`
+				Params struct {
+					Name string `json:"name"`
+				} `json:"params"`
+			}
+			if json.Unmarshal(sc.Bytes(), &req) != nil || len(req.ID) == 0 {
+				continue
+			}
+			var result any
+			switch req.Method {
+			case "initialize":
+				result = map[string]any{"protocolVersion": "2024-11-05", "capabilities": map[string]any{}}
+			case "tools/list":
+				result = map[string]any{"tools": []map[string]any{{"name": "status", "description": "repo status"}}}
+			case "tools/call":
+				result = map[string]any{"content": []map[string]any{{"type": "text", "text": "called " + req.Params.Name}}}
+			}
+			out, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+			fmt.Println(string(out))
+		}
+		os.Exit(0)
+
+	case "__mcp_tool_flood_helper__":
+		// Escreve linhas sem parar até ser morto (testes de limite de saída).
+		for i := 0; ; i++ {
+			fmt.Printf("{\"n\":%d,\"pad\":\"0123456789\"}\n", i)
+		}
+
+	case "__mcp_tool_flaky_helper__":
+		// Falha (exit 1, sem saída) na primeira execução; depois responde normalmente.
+		marker := os.Getenv("MCP_TOOL_FLAKY_MARKER")
+		if _, err := os.Stat(marker); err != nil {
+			_ = os.WriteFile(marker, []byte("failed once"), 0644)
+			os.Exit(1)
+		}
+		fmt.Println(`{"ok":true}`)
+		os.Exit(0)
+
+	case "__mcp_tool_slowstart_helper__":
+		// Espera antes da 1ª linha e depois dela (args: ms antes, ms depois).
+		before, _ := time.ParseDuration(os.Args[2])
+		after, _ := time.ParseDuration(os.Args[3])
+		time.Sleep(before)
+		fmt.Println(`{"started":true}`)
+		time.Sleep(after)
+		fmt.Println(`{"done":true}`)
+		os.Exit(0)
+
+	case "__mcp_tool_binary_helper__":
+		// Emite bytes que quebrariam o scanner de linhas (NUL, \r, newline solto, UTF-8 inválido).
+		payload := append([]byte{0x00, 0xff, 0xfe, '\n', '\r', 0x80}, bytes.Repeat([]byte{0x01, '\n'}, 40*1024)...)
+		_, _ = os.Stdout.Write(payload)
+		os.Exit(0)
+
+	case "__mcp_tool_counter_helper__":
+		// Tool daemon: estado em memória entre mensagens (uma resposta "done" por linha).
+		sc := bufio.NewScanner(os.Stdin)
+		for n := 1; sc.Scan(); n++ {
+			fmt.Printf("{\"count\":%d,\"pid\":%d,\"done\":true}\n", n, os.Getpid())
+		}
+		os.Exit(0)
+
+	case "__mcp_tool_chat_helper__":
+		// Tool interactive: responde cada mensagem do stdin até o EOF.
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			fmt.Printf("{\"echo\":%s}\n", sc.Bytes())
+		}
+		fmt.Println(`{"done":true}`)
+		os.Exit(0)
+
+	case "__mcp_tool_giantline_helper__":
+		// Uma linha bem maior que max_line_bytes (com runas multibyte) entre duas normais.
+		fmt.Println(`{"n":1}`)
+		fmt.Println(`{"blob":"` + strings.Repeat("aé", 3000) + `"}`)
+		fmt.Println(`{"n":2}`)
+		os.Exit(0)
+
+	case "__mcp_tool_tty_helper__":
+		// Responde se o stdout é um terminal (tty: true aloca um pty).
+		fi, _ := os.Stdout.Stat()
+		fmt.Printf("{\"tty\":%v}\n", fi.Mode()&os.ModeCharDevice != 0)
+		os.Exit(0)
+
 	case "__mcp_tool_disconnect_helper__":
 		marker := os.Getenv("MCP_TOOL_EXIT_MARKER")
 
@@ -105,6 +197,31 @@ func newTestCore(t *testing.T) *core.Service {
 	return core.New(cfg)
 }
 
+// runStdioRaw roda o transport e devolve as linhas cruas de saída.
+func runStdioRaw(t *testing.T, input string, svc *core.Service) []string {
+	t.Helper()
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+
+	out := &bytes.Buffer{}
+	tr := NewStdio(svc)
+	tr.in = bytes.NewBufferString(input)
+	tr.out = out
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := tr.Run(ctx); err != nil {
+		t.Fatalf("stdio.Run error: %v", err)
+	}
+
+	var lines []string
+	for _, l := range bytes.Split(out.Bytes(), []byte("\n")) {
+		if len(bytes.TrimSpace(l)) > 0 {
+			lines = append(lines, string(l))
+		}
+	}
+	return lines
+}
+
 type stdioResp struct {
 	ID    string          `json:"id,omitempty"`
 	Event string          `json:"event"`
@@ -169,8 +286,8 @@ func TestStdio_InvalidJSON(t *testing.T) {
 
 	var payload map[string]any
 	_ = json.Unmarshal(resps[0].Data, &payload)
-	if payload["error"] != "invalid_json" {
-		t.Fatalf("expected error=invalid_json, got %#v", payload["error"])
+	if payload["code"] != "invalid_request" {
+		t.Fatalf("expected code=invalid_request, got %#v", payload["code"])
 	}
 }
 
@@ -191,8 +308,8 @@ func TestStdio_MissingTool(t *testing.T) {
 
 	var payload map[string]any
 	_ = json.Unmarshal(resps[0].Data, &payload)
-	if payload["error"] != "missing_tool" {
-		t.Fatalf("expected error=missing_tool, got %#v", payload["error"])
+	if payload["code"] != "invalid_request" {
+		t.Fatalf("expected code=invalid_request, got %#v", payload["code"])
 	}
 }
 
@@ -213,8 +330,8 @@ func TestStdio_UnknownTool(t *testing.T) {
 
 	var payload map[string]any
 	_ = json.Unmarshal(resps[0].Data, &payload)
-	if payload["error"] != "tool_failed" {
-		t.Fatalf("expected error=tool_failed, got %#v", payload["error"])
+	if payload["code"] != "unknown_tool" {
+		t.Fatalf("expected code=unknown_tool, got %#v", payload["code"])
 	}
 }
 
@@ -259,3 +376,380 @@ func TestStdio_HappyPath_MessageThenDone(t *testing.T) {
 		t.Fatalf("expected last event=done, got %q", last.Event)
 	}
 }
+
+func TestStdio_BuiltinKV_PersistsBetweenCalls(t *testing.T) {
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"kv": {Runtime: "builtin", Builtin: "kv"},
+		},
+	})
+
+	resps := runStdio(t,
+		`{"id":"1","tool":"kv","input":{"op":"set","key":"plan","value":{"step":3}}}`+"\n"+
+			`{"id":"2","tool":"kv","input":{"op":"get","key":"plan"}}`+"\n", svc)
+
+	var got map[string]any
+	for _, r := range resps {
+		if r.ID == "2" && r.Event == "message" {
+			_ = json.Unmarshal(r.Data, &got)
+		}
+	}
+	if got["found"] != true {
+		t.Fatalf("expected kv value from previous call, got %+v", resps)
+	}
+	if v, _ := got["value"].(map[string]any); v["step"] != float64(3) {
+		t.Fatalf("unexpected value: %v", got["value"])
+	}
+}
+
+func TestStdio_RetryTransientFailureBeforeOutput(t *testing.T) {
+	flaky := config.Tool{
+		Runtime:   "native",
+		Mode:      "launcher",
+		Cmd:       os.Args[0],
+		Args:      []string{"__mcp_tool_flaky_helper__"},
+		TimeoutMS: 3000,
+	}
+	retrying := flaky
+	retrying.Retry = config.Retry{Attempts: 2, BackoffMS: 1, On: []string{config.RetryOnNonzeroExit}}
+
+	for name, tc := range map[string]struct {
+		tool config.Tool
+		want string
+	}{
+		"without retry": {flaky, "error"},
+		"with retry":    {retrying, "done"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("MCP_TOOL_FLAKY_MARKER", t.TempDir()+"/marker")
+			svc := core.New(&config.Config{
+				WorkspaceRoot: "/tmp/workspaces",
+				ToolsRoot:     "/tmp/tools",
+				Tools:         map[string]config.Tool{"flaky": tc.tool},
+			})
+
+			resps := runStdio(t, `{"id":"1","tool":"flaky","input":{}}`+"\n", svc)
+			if len(resps) == 0 || resps[len(resps)-1].Event != tc.want {
+				t.Fatalf("expected final event %q, got %+v", tc.want, resps)
+			}
+		})
+	}
+}
+
+func TestStdio_StartupTimeoutOnlyBoundsFirstLine(t *testing.T) {
+	tool := func(before, after string) config.Tool {
+		return config.Tool{
+			Runtime:          "native",
+			Mode:             "launcher",
+			Cmd:              os.Args[0],
+			Args:             []string{"__mcp_tool_slowstart_helper__", before, after},
+			TimeoutMS:        3000,
+			StartupTimeoutMS: 150,
+		}
+	}
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"hung": tool("2s", "0s"),
+			"long": tool("0s", "400ms"), // execução passa do startup_timeout, mas já respondeu
+		},
+	})
+
+	resps := runStdio(t, `{"id":"1","tool":"hung","input":{}}`+"\n"+`{"id":"2","tool":"long","input":{}}`+"\n", svc)
+	final := map[string]stdioResp{}
+	for _, r := range resps {
+		if r.Event == "done" || r.Event == "error" {
+			final[r.ID] = r
+		}
+	}
+	if r := final["1"]; r.Event != "error" || !strings.Contains(string(r.Data), "tool_startup_timeout") {
+		t.Fatalf("expected tool_startup_timeout for the hung spawn, got %+v", r)
+	}
+	if r := final["2"]; r.Event != "done" {
+		t.Fatalf("startup_timeout_ms must not bound the full run, got %+v", r)
+	}
+}
+
+func TestStdio_BinaryOutputIsFramedAsBase64(t *testing.T) {
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"blob": {Runtime: "native", Mode: "launcher", Cmd: os.Args[0], Args: []string{"__mcp_tool_binary_helper__"}, TimeoutMS: 3000, Output: config.OutputBinary},
+		},
+	})
+
+	resps := runStdio(t, `{"id":"1","tool":"blob","input":{}}`+"\n", svc)
+	var got []byte
+	var seq int64
+	for _, r := range resps {
+		switch r.Event {
+		case "message":
+			t.Fatalf("binary tools must not emit message events, got %s", r.Data)
+		case "data":
+			var f core.BinaryFrame
+			if err := json.Unmarshal(r.Data, &f); err != nil {
+				t.Fatalf("invalid frame %s: %v", r.Data, err)
+			}
+			if f.Seq != seq {
+				t.Fatalf("frame seq = %d, want %d", f.Seq, seq)
+			}
+			seq++
+			got = append(got, f.Data...)
+		}
+	}
+	want := append([]byte{0x00, 0xff, 0xfe, '\n', '\r', 0x80}, bytes.Repeat([]byte{0x01, '\n'}, 40*1024)...)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("decoded %d bytes, want %d (identical)", len(got), len(want))
+	}
+	if seq < 2 || resps[len(resps)-1].Event != "done" {
+		t.Fatalf("expected several frames then done, got %d frames, last %+v", seq, resps[len(resps)-1])
+	}
+}
+
+func TestStdio_GiantLinesAreSplitOrTruncated(t *testing.T) {
+	giant := `{"blob":"` + strings.Repeat("aé", 3000) + `"}`
+	tool := config.Tool{Runtime: "native", Mode: "launcher", Cmd: os.Args[0], Args: []string{"__mcp_tool_giantline_helper__"}, TimeoutMS: 3000, MaxLineBytes: 1024}
+
+	for _, policy := range []string{config.LongLinesSplit, config.LongLinesTruncate} {
+		t.Run(policy, func(t *testing.T) {
+			tool.LongLines = policy
+			svc := core.New(&config.Config{
+				WorkspaceRoot: "/tmp/workspaces",
+				ToolsRoot:     "/tmp/tools",
+				Tools:         map[string]config.Tool{"big": tool},
+			})
+
+			resps := runStdio(t, `{"id":"1","tool":"big","input":{}}`+"\n", svc)
+			var events []string
+			var joined strings.Builder
+			var parts int64
+			for _, r := range resps {
+				events = append(events, r.Event)
+				switch r.Event {
+				case "continuation":
+					var p core.ContinuationPart
+					if err := json.Unmarshal(r.Data, &p); err != nil {
+						t.Fatalf("invalid continuation %s: %v", r.Data, err)
+					}
+					if p.Part != parts || len(p.Data) > 1024 {
+						t.Fatalf("part %d (%d bytes), want part %d <= 1024 bytes", p.Part, len(p.Data), parts)
+					}
+					parts++
+					joined.WriteString(p.Data)
+				case "line_truncated":
+					var lt core.LineTruncated
+					if err := json.Unmarshal(r.Data, &lt); err != nil {
+						t.Fatalf("invalid line_truncated %s: %v", r.Data, err)
+					}
+					if lt.LineBytes != int64(len(giant)) || !strings.HasPrefix(giant, lt.Head) || len(lt.Head) < 1020 {
+						t.Fatalf("line_truncated = %d bytes, head %d bytes; want %d and a 1KiB prefix", lt.LineBytes, len(lt.Head), len(giant))
+					}
+				}
+			}
+
+			last := resps[len(resps)-1]
+			if last.Event != "done" || resps[0].Event != "message" || resps[len(resps)-2].Event != "message" {
+				t.Fatalf("giant line must not abort the stream, events = %v", events)
+			}
+			if policy == config.LongLinesSplit && joined.String() != giant {
+				t.Fatalf("joined %d bytes in %d parts, want the original %d-byte line", joined.Len(), parts, len(giant))
+			}
+		})
+	}
+}
+
+func TestStdio_TTYToolsSeeATerminal(t *testing.T) {
+	// docker fake: ecoa o input como o pty do container faria e diz se recebeu um terminal
+	bin := t.TempDir()
+	script := "#!/bin/sh\nread -r line\necho \"$line\"\n" +
+		"if [ -t 0 ] && [ -t 1 ]; then echo '{\"tty\":true}'; else echo '{\"tty\":false}'; fi\n"
+	if err := os.WriteFile(bin+"/docker", []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	native := config.Tool{Runtime: "native", Mode: "launcher", Cmd: os.Args[0], Args: []string{"__mcp_tool_tty_helper__"}, TimeoutMS: 3000}
+	for name, tool := range map[string]config.Tool{
+		"native":    native,
+		"container": {Runtime: "container", Image: "alpine:latest", TimeoutMS: 3000},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tool.TTY = true
+			svc := core.New(&config.Config{
+				WorkspaceRoot: "/tmp/workspaces",
+				ToolsRoot:     "/tmp/tools",
+				Tools:         map[string]config.Tool{"cli": tool},
+			})
+
+			resps := runStdio(t, `{"id":"1","tool":"cli","input":{"q":1}}`+"\n", svc)
+			var msgs []string
+			for _, r := range resps {
+				if r.Event == "message" {
+					msgs = append(msgs, string(r.Data))
+				}
+			}
+			if len(msgs) != 1 || msgs[0] != `{"tty":true}` {
+				t.Fatalf("expected a single {\"tty\":true} line without \\r or echo, got %q (all: %+v)", msgs, resps)
+			}
+		})
+	}
+}
+
+func TestStdio_ContainerReuseKeepsSessionAcrossRequests(t *testing.T) {
+	// docker fake: um "container" que responde uma linha com done:true por input
+	bin := t.TempDir()
+	script := "#!/bin/sh\nwhile read -r line; do echo \"{\\\"pid\\\":$$,\\\"done\\\":true}\"; done\n"
+	if err := os.WriteFile(bin+"/docker", []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	pids := func(reuse config.Reuse) []float64 {
+		svc := core.New(&config.Config{
+			WorkspaceRoot: "/tmp/workspaces",
+			ToolsRoot:     "/tmp/tools",
+			Tools: map[string]config.Tool{
+				"box": {Runtime: "container", Image: "alpine:latest", TimeoutMS: 3000, Reuse: &reuse},
+			},
+		})
+		defer svc.Close()
+
+		resps := runStdio(t,
+			`{"id":"1","tool":"box","input":{}}`+"\n"+`{"id":"2","tool":"box","input":{}}`+"\n", svc)
+		var out []float64
+		for _, r := range resps {
+			if r.Event == "message" {
+				var m map[string]any
+				_ = json.Unmarshal(r.Data, &m)
+				out = append(out, m["pid"].(float64))
+			}
+		}
+		if len(out) != 2 {
+			t.Fatalf("expected 2 messages, got %+v", resps)
+		}
+		return out
+	}
+
+	if got := pids(config.Reuse{}); got[0] != got[1] {
+		t.Fatalf("expected the same container session for both requests, got pids %v", got)
+	}
+	if got := pids(config.Reuse{MaxRequests: 1}); got[0] == got[1] {
+		t.Fatalf("max_requests=1 must recycle the session, got pids %v", got)
+	}
+}
+
+func TestStdio_ContainerHealthCheckRestartsFailedSession(t *testing.T) {
+	// docker fake: responde pong ao ping; com o arquivo "sick" presente, sai sem responder
+	bin := t.TempDir()
+	sick := bin + "/sick"
+	script := "#!/bin/sh\nwhile read -r line; do\n" +
+		"  [ -e " + sick + " ] && exit 1\n" +
+		"  case \"$line\" in *ping*) echo '{\"pong\":true,\"done\":true}' ;; *) echo '{\"done\":true}' ;; esac\n" +
+		"done\n"
+	if err := os.WriteFile(bin+"/docker", []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"box": {
+				Runtime: "container", Image: "alpine:latest", TimeoutMS: 3000,
+				Reuse:  &config.Reuse{},
+				Health: &config.Health{IntervalMS: 100, TimeoutMS: 1000, Ping: `{"ping":true}`, Expect: "pong"},
+			},
+		},
+	})
+	defer svc.Close()
+
+	if h := svc.ToolHealth(); len(h) != 1 || h[0].State != runner.HealthUnknown {
+		t.Fatalf("expected unknown health before the first session, got %+v", h)
+	}
+	runStdio(t, `{"id":"1","tool":"box","input":{}}`+"\n", svc)
+
+	waitHealth := func(desc string, ok func(runner.PoolHealth) bool) runner.PoolHealth {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			h := svc.ToolHealth()[0]
+			if ok(h) {
+				return h
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s, last health %+v", desc, h)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitHealth("healthy", func(h runner.PoolHealth) bool { return h.State == runner.HealthHealthy && h.Idle == 1 })
+
+	if err := os.WriteFile(sick, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h := waitHealth("unhealthy", func(h runner.PoolHealth) bool { return h.State == runner.HealthUnhealthy })
+	if h.LastError == "" {
+		t.Fatalf("unhealthy state must carry the last error, got %+v", h)
+	}
+	// restart com backoff: as tentativas falham enquanto o arquivo existir
+	waitHealth("failed restart attempts", func(h runner.PoolHealth) bool { return h.ConsecutiveFailures >= 2 && h.Restarting })
+
+	_ = os.Remove(sick)
+	h = waitHealth("restart", func(h runner.PoolHealth) bool { return h.State == runner.HealthHealthy && h.Restarts == 1 })
+	if h.ConsecutiveFailures != 0 || h.Idle != 1 {
+		t.Fatalf("expected a fresh healthy session after restart, got %+v", h)
+	}
+}
+
+func TestStdio_OutputSchemaWarnAndStrict(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"result"},
+		"properties": map[string]any{
+			"result": map[string]any{"type": "object", "properties": map[string]any{"n": map[string]any{"type": "integer"}}},
+		},
+	}
+	tool := config.Tool{Runtime: "native", Mode: "launcher", Cmd: os.Args[0], Args: []string{"__mcp_tool_echo_helper__"}, TimeoutMS: 3000, OutputSchema: schema}
+	strict := tool
+	strict.OutputValidation = config.OutputValidationStrict
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools:         map[string]config.Tool{"warn": tool, "strict": strict},
+	})
+
+	resps := runStdio(t, `{"id":"1","tool":"warn","input":{"n":1}}`+"\n"+`{"id":"2","tool":"warn","input":{"n":"x"}}`+"\n", svc)
+	events := map[string][]string{}
+	var warning core.OutputWarning
+	for _, r := range resps {
+		events[r.ID] = append(events[r.ID], r.Event)
+		if r.Event == "warning" {
+			_ = json.Unmarshal(r.Data, &warning)
+		}
+	}
+	if got := strings.Join(events["1"], ","); got != "message,done" {
+		t.Fatalf("valid output: %s", got)
+	}
+	if got := strings.Join(events["2"], ","); got != "message,warning,done" {
+		t.Fatalf("warn mode must deliver the line and annotate it: %s", got)
+	}
+	if warning.Code != core.CodeOutputViolation || warning.Line != 1 || len(warning.Violations) != 1 || warning.Violations[0].Path != "/result/n" {
+		t.Fatalf("warning payload: %+v", warning)
+	}
+
+	resps = runStdio(t, `{"id":"3","tool":"strict","input":{"n":"x"}}`+"\n", svc)
+	if len(resps) != 1 || resps[0].Event != "error" {
+		t.Fatalf("strict mode must fail before delivering the line: %+v", resps)
+	}
+	var payload map[string]any
+	_ = json.Unmarshal(resps[0].Data, &payload)
+	if payload["code"] != core.CodeOutputViolation || payload["details"] == nil {
+		t.Fatalf("strict error payload: %s", resps[0].Data)
+	}
+}
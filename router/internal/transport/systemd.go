@@ -0,0 +1,80 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"mcp-router/internal/observability/logging"
+)
+
+// Integração com o systemd:
+//   - socket activation: com LISTEN_PID/LISTEN_FDS (unit .socket), o HTTP serve no socket
+//     recebido (fd 3) em vez de abrir --addr; o systemd segura as conexões durante restarts
+//   - sd_notify: com NOTIFY_SOCKET (Type=notify), avisa READY=1 quando o listener está
+//     pronto e STOPPING=1 no início do drain
+
+// listenFDsStart é o primeiro fd passado pelo systemd (SD_LISTEN_FDS_START).
+const listenFDsStart = 3
+
+// SocketActivated diz se o processo recebeu sockets do systemd (dispensa --addr).
+func SocketActivated() bool {
+	n, err := listenFDs()
+	return err == nil && n > 0
+}
+
+func listenFDs() (int, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return 0, nil // sockets de outro processo (ou nenhum)
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("systemd: invalid LISTEN_FDS %q", os.Getenv("LISTEN_FDS"))
+	}
+	return n, nil
+}
+
+// activationListener retorna o socket do systemd (nil = sem socket activation). Só o
+// primeiro socket é usado. As variáveis LISTEN_* são removidas para não chegarem às tools.
+func activationListener(ctx context.Context) (net.Listener, error) {
+	n, err := listenFDs()
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	for _, k := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(k)
+	}
+	if n > 1 {
+		logging.LoggerFromContext(ctx).Warn("systemd passed more than one socket; using the first", logging.Int("sockets", n))
+	}
+
+	f := os.NewFile(uintptr(listenFDsStart), "systemd-socket")
+	defer f.Close() // FileListener duplica o fd
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("systemd: socket activation: %w", err)
+	}
+	return ln, nil
+}
+
+// sdNotify envia state ("READY=1", "STOPPING=1") ao systemd; sem NOTIFY_SOCKET não faz nada.
+func sdNotify(ctx context.Context, state string) {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return
+	}
+	if strings.HasPrefix(addr, "@") {
+		addr = "\x00" + addr[1:] // namespace abstrato
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		logging.LoggerFromContext(ctx).Warn("systemd notify failed", logging.String("state", state), logging.Err(err))
+		return
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte(state))
+}
@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestSystemd_ListenFDsAndNotify(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	if SocketActivated() {
+		t.Fatal("sockets passed to another pid must be ignored")
+	}
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	if !SocketActivated() {
+		t.Fatal("expected socket activation for this pid")
+	}
+	t.Setenv("LISTEN_FDS", "x")
+	if _, err := listenFDs(); err == nil {
+		t.Fatal("invalid LISTEN_FDS must be an error")
+	}
+
+	sock := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", sock)
+
+	sdNotify(context.Background(), "READY=1")
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != "READY=1" {
+		t.Fatalf("notify: %q %v", buf[:n], err)
+	}
+}
@@ -0,0 +1,165 @@
+package transport
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func TestHTTP_RequestTimeoutHeader(t *testing.T) {
+	t.Setenv("MCP_GW_TEST_TOOL", "1")
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			// 1ª linha só depois de 500ms: estoura o timeout_ms, cabe no max_timeout_ms
+			"slow": {Runtime: "native", Mode: "launcher", Cmd: os.Args[0],
+				Args: []string{"__mcp_tool_slowstart_helper__", "500ms", "0s"}, TimeoutMS: 200, MaxTimeoutMS: 2000},
+		},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		header, effective string
+		status            int
+	}{
+		{"", "200ms", http.StatusGatewayTimeout},
+		{"1s", "1s", http.StatusOK},
+		{"1h", "2s", http.StatusOK}, // limitado ao max_timeout_ms
+		{"100ms", "100ms", http.StatusGatewayTimeout},
+		{"abc", "", http.StatusBadRequest},
+		{"-5s", "", http.StatusBadRequest},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/slow", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.header != "" {
+			req.Header.Set(timeoutHeader, tc.header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.header, err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s=%q: status %d, want %d", timeoutHeader, tc.header, resp.StatusCode, tc.status)
+		}
+		if tc.effective != "" && resp.Header.Get(timeoutHeader) != tc.effective {
+			t.Errorf("%s=%q: response header %q, want %q", timeoutHeader, tc.header, resp.Header.Get(timeoutHeader), tc.effective)
+		}
+	}
+}
+
+func TestHTTP_DeadlinePropagation(t *testing.T) {
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"deadline": {Runtime: "native", Mode: "launcher", Cmd: "/bin/sh",
+				Args:      []string{"-c", `read -r in; printf '{"env":%s,"input":%s}\n' "$MCP_DEADLINE_UNIX_MS" "$in"`},
+				TimeoutMS: 5000, MaxTimeoutMS: 60000, DeadlineField: "_deadline"},
+		},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/deadline", strings.NewReader(`{"q":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(timeoutHeader, "30s")
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var got struct {
+		Env   int64 `json:"env"`
+		Input struct {
+			Q        int   `json:"q"`
+			Deadline int64 `json:"_deadline"`
+		} `json:"input"`
+	}
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			if err := json.Unmarshal([]byte(data), &got); err != nil {
+				t.Fatalf("tool output %q: %v", data, err)
+			}
+			break
+		}
+	}
+
+	// prazo do X-MCP-Timeout (30s), igual no env e no input
+	want := start.Add(30 * time.Second).UnixMilli()
+	if got.Env < want-2000 || got.Env > want+2000 || got.Input.Deadline != got.Env || got.Input.Q != 1 {
+		t.Fatalf("deadline env=%d input=%+v, want ~%d in both", got.Env, got.Input, want)
+	}
+}
+
+func TestHTTP_RequestIDPropagation(t *testing.T) {
+	svc := core.New(&config.Config{
+		WorkspaceRoot: "/tmp/workspaces",
+		ToolsRoot:     "/tmp/tools",
+		Tools: map[string]config.Tool{
+			"rid": {Runtime: "native", Mode: "launcher", Cmd: "/bin/sh",
+				Args:      []string{"-c", `read -r in; printf '{"env":"%s","input":%s}\n' "$MCP_REQUEST_ID" "$in"`},
+				TimeoutMS: 5000, RequestIDField: "_request_id"},
+		},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/rid", strings.NewReader(`{"q":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "rid-propagation")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var got struct {
+		Env   string `json:"env"`
+		Input struct {
+			Q         int    `json:"q"`
+			RequestID string `json:"_request_id"`
+		} `json:"input"`
+	}
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			if err := json.Unmarshal([]byte(data), &got); err != nil {
+				t.Fatalf("tool output %q: %v", data, err)
+			}
+			break
+		}
+	}
+	if got.Env != "rid-propagation" || got.Input.RequestID != "rid-propagation" || got.Input.Q != 1 {
+		t.Fatalf("request_id env=%q input=%+v, want rid-propagation in both", got.Env, got.Input)
+	}
+}
@@ -0,0 +1,43 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"mcp-router/internal/config"
+)
+
+// serverTLSConfig monta o tls.Config do transport HTTP. Sem client_ca_file não há
+// verificação de cliente; com ele, certificados apresentados são verificados e
+// require_client_cert recusa o handshake de quem não apresentar um (mTLS).
+func serverTLSConfig(c config.TLS) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: load cert/key: %w", err)
+	}
+
+	tc := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if c.ClientCAFile == "" {
+		return tc, nil
+	}
+
+	pem, err := os.ReadFile(c.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: read client_ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("tls: client_ca_file %q has no PEM certificates", c.ClientCAFile)
+	}
+	tc.ClientCAs = pool
+	tc.ClientAuth = tls.VerifyClientCertIfGiven
+	if c.RequireClientCert {
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tc, nil
+}
@@ -0,0 +1,35 @@
+package transport
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"mcp-router/internal/core"
+)
+
+// uiFiles é a UI de administração (/ui): HTML, JS e CSS estáticos embutidos no binário.
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// uiHandler serve /ui/*. Os arquivos não têm dados: tools, execuções e invocação vêm da
+// própria API, com o token informado na página (as rotas mantêm a auth delas).
+func uiHandler() http.Handler {
+	sub, _ := fs.Sub(uiFiles, "ui")
+	files := http.StripPrefix("/ui/", http.FileServer(http.FS(sub)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, r, http.StatusMethodNotAllowed, core.CodeMethodNotAllowed, "method not allowed")
+			return
+		}
+		if r.URL.Path == "/ui" {
+			http.Redirect(w, r, "/ui/", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,210 @@
+"use strict";
+
+// UI mínima do gateway: só usa a API HTTP pública (/mcp/*, /version, /admin/*) com o
+// token informado no topo (guardado em sessionStorage, nunca enviado a outro lugar).
+
+const $ = (id) => document.getElementById(id);
+let current = null;
+let abort = null;
+let runningID = "";
+
+function headers(extra) {
+  const h = Object.assign({}, extra);
+  const tok = sessionStorage.getItem("mcp-gw-token");
+  if (tok) h["Authorization"] = "Bearer " + tok;
+  return h;
+}
+
+async function getJSON(path) {
+  const resp = await fetch(path, { headers: headers() });
+  const body = await resp.json().catch(() => ({}));
+  if (!resp.ok) {
+    const err = new Error(body.message || resp.status + " " + resp.statusText);
+    err.status = resp.status;
+    throw err;
+  }
+  return body;
+}
+
+function show(el, v) {
+  el.textContent = typeof v === "string" ? v : JSON.stringify(v, null, 2);
+}
+
+async function loadVersion() {
+  try {
+    const v = await getJSON("/version");
+    $("version").textContent = v.version + " (" + v.commit + ") · " + (v.features || []).join(", ");
+  } catch (e) {
+    $("version").textContent = "";
+  }
+}
+
+async function loadTools() {
+  const list = $("tools");
+  list.textContent = "";
+  try {
+    const { tools } = await getJSON("/mcp/tools");
+    for (const t of tools) {
+      const li = document.createElement("li");
+      li.textContent = t.name;
+      const sub = document.createElement("small");
+      sub.textContent = [t.runtime, t.mode].concat(t.tags || []).join(" · ");
+      li.appendChild(sub);
+      li.addEventListener("click", () => selectTool(t, li));
+      if (current && current.name === t.name) li.classList.add("active");
+      list.appendChild(li);
+    }
+  } catch (e) {
+    const li = document.createElement("li");
+    li.className = "err";
+    li.textContent = "tools: " + e.message;
+    list.appendChild(li);
+  }
+}
+
+async function selectTool(t, li) {
+  current = t;
+  for (const el of $("tools").children) el.classList.remove("active");
+  li.classList.add("active");
+  $("tool-name").textContent = t.name;
+  $("tool-detail").hidden = false;
+  show($("tool-config"), t);
+  show($("output"), "");
+  await loadStats();
+}
+
+async function loadStats() {
+  if (!current) return;
+  try {
+    show($("tool-stats"), await getJSON("/mcp/tools/" + encodeURIComponent(current.name) + "/stats"));
+  } catch (e) {
+    show($("tool-stats"), "stats: " + e.message);
+  }
+}
+
+// invoke faz o POST da tool e mostra o SSE conforme chega (fetch + stream: EventSource
+// não envia Authorization nem body).
+async function invoke(ev) {
+  ev.preventDefault();
+  if (!current) return;
+  const out = $("output");
+  out.textContent = "";
+  const input = $("input").value.trim() || "{}";
+  try {
+    JSON.parse(input);
+  } catch (e) {
+    $("status").textContent = "invalid JSON: " + e.message;
+    return;
+  }
+
+  const extra = { "Content-Type": "application/json", "Accept": "text/event-stream" };
+  if ($("timeout").value.trim()) extra["X-MCP-Timeout"] = $("timeout").value.trim();
+  abort = new AbortController();
+  $("run").disabled = true;
+  $("cancel").disabled = false;
+  $("status").textContent = "running…";
+  const started = performance.now();
+
+  try {
+    const resp = await fetch("/mcp/" + encodeURIComponent(current.name), {
+      method: "POST", headers: headers(extra), body: input, signal: abort.signal,
+    });
+    runningID = resp.headers.get("X-Request-Id") || "";
+    if (!resp.ok || !resp.body) {
+      const body = await resp.json().catch(() => ({}));
+      append(out, "error", JSON.stringify(body), true);
+      $("status").textContent = resp.status + " " + (body.code || "");
+      return;
+    }
+    const reader = resp.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buf = "";
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) break;
+      buf += value;
+      let i;
+      while ((i = buf.indexOf("\n\n")) >= 0) {
+        renderEvent(out, buf.slice(0, i));
+        buf = buf.slice(i + 2);
+      }
+    }
+    $("status").textContent = "done in " + Math.round(performance.now() - started) + " ms · " + runningID;
+  } catch (e) {
+    $("status").textContent = e.name === "AbortError" ? "cancelled" : e.message;
+  } finally {
+    abort = null;
+    runningID = "";
+    $("run").disabled = false;
+    $("cancel").disabled = true;
+    loadStats();
+  }
+}
+
+function renderEvent(out, chunk) {
+  let event = "message";
+  const data = [];
+  for (const line of chunk.split("\n")) {
+    if (line.startsWith("event: ")) event = line.slice(7);
+    else if (line.startsWith("data: ")) data.push(line.slice(6));
+  }
+  if (data.length) append(out, event, data.join("\n"), event === "error");
+}
+
+function append(out, event, data, isErr) {
+  const line = document.createElement("div");
+  if (event !== "message") {
+    const tag = document.createElement("span");
+    tag.className = isErr ? "err" : "ev";
+    tag.textContent = event + ": ";
+    line.appendChild(tag);
+  }
+  line.appendChild(document.createTextNode(data));
+  out.appendChild(line);
+}
+
+async function cancel() {
+  if (runningID) {
+    await fetch("/mcp/requests/" + encodeURIComponent(runningID), { method: "DELETE", headers: headers() }).catch(() => {});
+  }
+  if (abort) abort.abort();
+}
+
+async function loadExecutions() {
+  const body = $("executions");
+  try {
+    const { executions } = await getJSON("/admin/executions");
+    $("exec-note").textContent = executions.length ? "" : "no executions running";
+    body.textContent = "";
+    for (const e of executions) {
+      const tr = document.createElement("tr");
+      for (const v of [e.id, e.tool, e.request_id, e.client || "", e.age_ms + " ms"]) {
+        const td = document.createElement("td");
+        td.textContent = v;
+        tr.appendChild(td);
+      }
+      body.appendChild(tr);
+    }
+  } catch (e) {
+    body.textContent = "";
+    $("exec-note").textContent = "needs the admin token (" + e.message + ")";
+  }
+}
+
+$("auth").addEventListener("submit", (ev) => {
+  ev.preventDefault();
+  const tok = $("token").value.trim();
+  if (tok) sessionStorage.setItem("mcp-gw-token", tok);
+  else sessionStorage.removeItem("mcp-gw-token");
+  $("token").value = "";
+  loadVersion();
+  loadTools();
+  loadExecutions();
+});
+$("refresh").addEventListener("click", loadTools);
+$("invoke").addEventListener("submit", invoke);
+$("cancel").addEventListener("click", cancel);
+
+loadVersion();
+loadTools();
+loadExecutions();
+setInterval(loadExecutions, 2000);
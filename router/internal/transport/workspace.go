@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"net/http"
+
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+// workspaceHeader escolhe um dos workspaces nomeados do config (workspaces:); sem ele a
+// request usa workspace_root. Nomes fora da allowlist, ou fora da lista da credencial,
+// são recusados antes do handler.
+const workspaceHeader = "X-MCP-Workspace"
+
+// withWorkspace valida o X-MCP-Workspace e grava o workspace no ctx (tools, arquivos) e no
+// access log.
+func (h *HTTP) withWorkspace(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(workspaceHeader)
+		if name == "" {
+			next(w, r)
+			return
+		}
+		if !h.workspacePermitted(r, name) {
+			writeError(w, r, http.StatusForbidden, core.CodeForbidden, "workspace not permitted for this credential")
+			logging.LoggerFromContext(r.Context()).Warn("workspace not permitted", logging.String("workspace", name))
+			return
+		}
+		if _, ok := h.core.WorkspaceRoot(name); !ok {
+			writeError(w, r, http.StatusForbidden, core.CodeUnknownWorkspace, "unknown workspace")
+			logging.LoggerFromContext(r.Context()).Warn("workspace rejected", logging.String("workspace", name))
+			return
+		}
+		logging.SetAccessWorkspace(r.Context(), name)
+		ctx := logging.WithWorkspace(r.Context(), name)
+		ctx = logging.WithLogger(ctx, logging.LoggerFromContext(ctx).With(logging.String("workspace", name)))
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// workspacePermitted diz se a request pode escolher o workspace nomeado. Com auth, só a
+// credencial com o nome na lista (Principal.Workspaces); acesso público e URL assinada
+// ficam no workspace_root. Sem auth e com o token admin, qualquer um da allowlist.
+func (h *HTTP) workspacePermitted(r *http.Request, name string) bool {
+	if !h.core.AuthEnabled() || h.isAdmin(r) {
+		return true
+	}
+	p := core.PrincipalFromContext(r.Context())
+	return p != nil && p.PermitsWorkspace(name)
+}
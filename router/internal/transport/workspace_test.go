@@ -0,0 +1,234 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-router/internal/config"
+	"mcp-router/internal/core"
+	"mcp-router/internal/observability/logging"
+)
+
+func TestHTTP_EphemeralWorkspace(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "shared.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// grava um arquivo no workspace e lista o que enxerga; "fail" sai com erro
+	script := `cat >/dev/null; touch "$WORKSPACE_ROOT/own.txt"; printf '{"ws":"%s","ls":"%s"}\n' "$WORKSPACE_ROOT" "$(ls "$WORKSPACE_ROOT" | tr '\n' ' ')"; [ "$1" != fail ]`
+	tool := config.Tool{Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", Args: []string{"-c", script, "sh"},
+		TimeoutMS: 10000, Workspace: config.WorkspaceEphemeral}
+	retained := tool
+	retained.Args = []string{"-c", script, "sh", "fail"}
+	retained.WorkspaceRetain = config.WorkspaceRetainOnFailure
+
+	svc := core.New(&config.Config{
+		WorkspaceRoot: root,
+		ToolsRoot:     "/tmp/tools",
+		Files:         config.Files{Enabled: true},
+		Tools:         map[string]config.Tool{"eph": tool, "kept": retained},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	call := func(name, rid string) string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/"+name, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-Id", rid)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post %s: %v", name, err)
+		}
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		return string(out)
+	}
+
+	first, second := call("eph", "ws-a"), call("eph", "ws-b")
+	for _, out := range []string{first, second} {
+		if !strings.Contains(out, `"ls":"own.txt "`) {
+			t.Fatalf("tool should see only its own files: %s", out)
+		}
+		if !strings.Contains(out, root+"/"+config.EphemeralDir+"/") {
+			t.Fatalf("workspace not under %s: %s", config.EphemeralDir, out)
+		}
+	}
+	if !strings.Contains(first, "/ws-a-") || !strings.Contains(second, "/ws-b-") {
+		t.Fatalf("workspace should be named after the request id:\n%s\n%s", first, second)
+	}
+
+	left, _ := os.ReadDir(filepath.Join(root, config.EphemeralDir))
+	if len(left) != 0 {
+		t.Fatalf("ephemeral workspaces not removed: %v", left)
+	}
+
+	call("kept", "ws-fail")
+	left, _ = os.ReadDir(filepath.Join(root, config.EphemeralDir))
+	if len(left) != 1 || !strings.HasPrefix(left[0].Name(), "ws-fail-") {
+		t.Fatalf("workspace_retain on_failure should keep the failed workspace: %v", left)
+	}
+	if _, err := os.Stat(filepath.Join(root, config.EphemeralDir, left[0].Name(), "own.txt")); err != nil {
+		t.Fatalf("retained workspace lost its files: %v", err)
+	}
+
+	// o workspace retido é de outra request: fora da listagem e do download
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/workspace/files/" + path)
+		if err != nil {
+			t.Fatalf("get %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(out)
+	}
+	if st, out := get(""); st != http.StatusOK || !strings.Contains(out, "shared.txt") || strings.Contains(out, config.EphemeralDir) {
+		t.Fatalf("listing should hide %s: status %d, %s", config.EphemeralDir, st, out)
+	}
+	for _, path := range []string{config.EphemeralDir, config.EphemeralDir + "/" + left[0].Name() + "/own.txt"} {
+		if st, _ := get(path); st != http.StatusNotFound {
+			t.Fatalf("get %s: expected 404, got %d", path, st)
+		}
+	}
+}
+
+func TestHTTP_WorkspaceHeader(t *testing.T) {
+	def, acme := t.TempDir(), t.TempDir()
+	svc := core.New(&config.Config{
+		WorkspaceRoot: def,
+		ToolsRoot:     "/tmp/tools",
+		Workspaces:    map[string]string{"acme": acme},
+		Files:         config.Files{Enabled: true},
+		Tools: map[string]config.Tool{
+			"ws": {Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", TimeoutMS: 10000,
+				Args: []string{"-c", `cat >/dev/null; printf '{"ws":"%s"}\n' "$WORKSPACE_ROOT"`}},
+		},
+	})
+	defer svc.Close()
+
+	var logOut bytes.Buffer
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.MiddlewareWithAccessLog(mux,
+		logging.AccessLogOptions{Format: logging.AccessLogCombined, Out: &logOut})))
+	defer srv.Close()
+
+	do := func(method, path, ws, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ws != "" {
+			req.Header.Set(workspaceHeader, ws)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(out)
+	}
+
+	if st, out := do(http.MethodPost, "/mcp/ws", "", `{}`); st != http.StatusOK || !strings.Contains(out, `"ws":"`+def+`"`) {
+		t.Fatalf("default workspace: status %d, %s", st, out)
+	}
+	if st, out := do(http.MethodPost, "/mcp/ws", "acme", `{}`); st != http.StatusOK || !strings.Contains(out, `"ws":"`+acme+`"`) {
+		t.Fatalf("acme workspace: status %d, %s", st, out)
+	}
+	if !strings.Contains(logOut.String(), "tool=ws lines_out=1 ") || !strings.Contains(logOut.String(), " workspace=acme\n") {
+		t.Fatalf("access log should record the workspace: %q", logOut.String())
+	}
+
+	st, out := do(http.MethodPost, "/mcp/ws", "other", `{}`)
+	if st != http.StatusForbidden || !strings.Contains(out, core.CodeUnknownWorkspace) {
+		t.Fatalf("workspace outside the allowlist: status %d, %s", st, out)
+	}
+
+	// arquivos seguem o mesmo header
+	if st, _ := do(http.MethodPut, "/workspace/files/in.txt", "acme", "tenant"); st != http.StatusCreated {
+		t.Fatalf("put in acme: status %d", st)
+	}
+	if got, _ := os.ReadFile(filepath.Join(acme, "in.txt")); string(got) != "tenant" {
+		t.Fatalf("file not written to the acme workspace: %q", got)
+	}
+	if st, _ := do(http.MethodGet, "/workspace/files/in.txt", "", ""); st != http.StatusNotFound {
+		t.Fatalf("default workspace should not see acme files: status %d", st)
+	}
+}
+
+func TestHTTP_WorkspacePerCredential(t *testing.T) {
+	t.Setenv("TEST_KEY_ACME", "acme-secret")
+	t.Setenv("TEST_KEY_PLAIN", "plain-secret")
+	def, acme, beta := t.TempDir(), t.TempDir(), t.TempDir()
+	svc := core.New(&config.Config{
+		WorkspaceRoot: def,
+		ToolsRoot:     "/tmp/tools",
+		Workspaces:    map[string]string{"acme": acme, "beta": beta},
+		Tools: map[string]config.Tool{
+			"ws": {Runtime: "native", Mode: "launcher", Cmd: "/bin/sh", TimeoutMS: 10000,
+				Args: []string{"-c", `cat >/dev/null; printf '{"ws":"%s"}\n' "$WORKSPACE_ROOT"`}},
+		},
+		Auth: config.Auth{APIKeys: []config.APIKey{
+			{Name: "acme", KeyEnv: "TEST_KEY_ACME", Workspaces: []string{"acme"}},
+			{Name: "plain", KeyEnv: "TEST_KEY_PLAIN"},
+		}},
+	})
+	defer svc.Close()
+
+	mux := http.NewServeMux()
+	NewHTTP(svc).Register(mux)
+	srv := httptest.NewServer(WrapHardening(logging.Middleware(mux)))
+	defer srv.Close()
+
+	call := func(key, ws string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp/ws", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		if ws != "" {
+			req.Header.Set(workspaceHeader, ws)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(out)
+	}
+
+	cases := []struct {
+		name, key, ws string
+		status        int
+		want          string
+	}{
+		{"own workspace", "acme-secret", "acme", http.StatusOK, `"ws":"` + acme + `"`},
+		{"default workspace", "acme-secret", "", http.StatusOK, `"ws":"` + def + `"`},
+		{"other tenant's workspace", "acme-secret", "beta", http.StatusForbidden, core.CodeForbidden},
+		{"key without workspaces", "plain-secret", "acme", http.StatusForbidden, core.CodeForbidden},
+		{"key without workspaces, default", "plain-secret", "", http.StatusOK, `"ws":"` + def + `"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			st, out := call(tc.key, tc.ws)
+			if st != tc.status || !strings.Contains(out, tc.want) {
+				t.Fatalf("status %d, %s; want %d with %q", st, out, tc.status, tc.want)
+			}
+		})
+	}
+}
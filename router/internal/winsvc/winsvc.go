@@ -0,0 +1,19 @@
+// Package winsvc integra o gateway ao Service Control Manager do Windows
+// (mcp-gw service install/uninstall/run). Em outros sistemas as operações retornam
+// ErrUnsupported: no Linux o gerenciador é o systemd (unit com `mcp-gw http`, com socket
+// activation e Type=notify).
+package winsvc
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultName é o nome do serviço registrado por padrão.
+const DefaultName = "mcp-gw"
+
+// ErrUnsupported: serviços do Windows só existem no Windows.
+var ErrUnsupported = errors.New("windows services are only supported on Windows (on Linux, run `mcp-gw http` from a systemd unit)")
+
+// RunFunc é o gateway rodando até ctx terminar (stop/shutdown do SCM).
+type RunFunc func(ctx context.Context) error
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package winsvc
+
+// Install registra o serviço name executando exe com args.
+func Install(name, exe string, args []string) error { return ErrUnsupported }
+
+// Uninstall para (se estiver rodando) e remove o serviço name.
+func Uninstall(name string) error { return ErrUnsupported }
+
+// Run atende o SCM como o serviço name, rodando run até o stop.
+func Run(name string, run RunFunc) error { return ErrUnsupported }
@@ -0,0 +1,129 @@
+//go:build windows
+
+package winsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// stopTimeout limita a espera pelo serviço parar no Uninstall.
+const stopTimeout = 30 * time.Second
+
+// Install registra o serviço name (início automático) executando exe com args.
+func Install(name, exe string, args []string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %q already exists", name)
+	}
+	s, err := m.CreateService(name, exe, mgr.Config{
+		DisplayName: "MCP gateway (" + name + ")",
+		Description: "mcp-gw: routes MCP tool calls over HTTP",
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("create service %q: %w", name, err)
+	}
+	defer s.Close()
+
+	// falha do processo: reinicia após 5s (as duas primeiras vezes), reset em 1 dia
+	_ = s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+		{Type: mgr.NoAction},
+	}, uint32((24 * time.Hour).Seconds()))
+	return nil
+}
+
+// Uninstall para (se estiver rodando) e remove o serviço name.
+func Uninstall(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %q: %w", name, err)
+	}
+	defer s.Close()
+
+	if st, err := s.Control(svc.Stop); err == nil {
+		deadline := time.Now().Add(stopTimeout)
+		for st.State != svc.Stopped && time.Now().Before(deadline) {
+			time.Sleep(300 * time.Millisecond)
+			if st, err = s.Query(); err != nil {
+				break
+			}
+		}
+	} else if !errors.Is(err, windows.ERROR_SERVICE_NOT_ACTIVE) {
+		return fmt.Errorf("stop service %q: %w", name, err)
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("delete service %q: %w", name, err)
+	}
+	return nil
+}
+
+// Run atende o SCM como o serviço name: run roda até o stop/shutdown, que cancela o ctx
+// (o drain do gateway acontece antes de reportar Stopped).
+func Run(name string, run RunFunc) error {
+	ok, err := svc.IsWindowsService()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("service run must be started by the Windows service manager (use mcp-gw http to run interactively)")
+	}
+	return svc.Run(name, &handler{run: run})
+}
+
+type handler struct {
+	run RunFunc
+}
+
+func (h *handler) Execute(_ []string, reqs <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- h.run(ctx) }()
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case err := <-done:
+			// o gateway parou sozinho (ex: porta ocupada): SCM aplica as recovery actions
+			if err != nil {
+				return true, 1
+			}
+			return false, 0
+		case r := <-reqs:
+			switch r.Cmd {
+			case svc.Interrogate:
+				status <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				if err := <-done; err != nil {
+					return true, 1
+				}
+				return false, 0
+			}
+		}
+	}
+}